@@ -0,0 +1,3880 @@
+// GENERATED BY THE COMMAND ABOVE; DO NOT EDIT
+// This file was generated by geeks-accelerator/swag at
+// 2026-10-17 01:30:23.675638228 +0000 UTC m=+109.289313990
+
+package docs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/alecthomas/template"
+	"github.com/geeks-accelerator/swag"
+)
+
+var doc = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{.Description}}",
+        "title": "{{.Title}}",
+        "contact": {},
+        "license": {
+            "name": "Apache 2.0",
+            "url": "http://www.apache.org/licenses/LICENSE-2.0.html"
+        },
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/account_roles": {
+            "get": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Find returns the sets of permissions assigned to the roles of the account in place of their defaults.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "account_role"
+                ],
+                "summary": "List the permissions assigned to roles",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/account_role.AccountRoleResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Delete removes the set of permissions assigned to a role for the account so it's granted its defaults again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "account_role"
+                ],
+                "summary": "Restore the default permissions of a role",
+                "parameters": [
+                    {
+                        "description": "Delete",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/account_role.AccountRoleDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Set assigns the set of permissions of a role for the account in place of its defaults.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "account_role"
+                ],
+                "summary": "Assign the permissions of a role",
+                "parameters": [
+                    {
+                        "description": "Set fields",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/account_role.AccountRoleSetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/accounts": {
+            "patch": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Update updates the specified account in the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "account"
+                ],
+                "summary": "Update account by ID",
+                "parameters": [
+                    {
+                        "description": "Update fields",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/account.AccountUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/accounts/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Read returns the specified account from the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "account"
+                ],
+                "summary": "Get account by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Account ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Included Archived, example: false",
+                        "name": "include-archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/account.AccountResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createassets": {
+            "get": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Find returns the existing created assets in the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "List created assets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter string, example: asset_name = 'Kwa Jeff Limited'",
+                        "name": "where",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order columns separated by comma, example: created_at desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit, example: 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset, example: 20",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Included Archived, example: false",
+                        "name": "include-archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/createasset.CreatedAssetResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Create inserts a new draft created asset into the system, the asset is created on the network once minted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "Create new created asset.",
+                "parameters": [
+                    {
+                        "description": "Created Asset details",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/createasset.CreatedAssetCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/createasset.CreatedAssetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Update updates the specified created asset in the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "Update created asset by ID",
+                "parameters": [
+                    {
+                        "description": "Update fields",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/createasset.CreatedAssetUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createassets/archive": {
+            "patch": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Archive soft-deletes the specified created asset from the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "Archive created asset by ID",
+                "parameters": [
+                    {
+                        "description": "Update fields",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/createasset.CreatedAssetArchiveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createassets/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Read returns the specified created asset from the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "Get created asset by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Created Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Included Archived, example: false",
+                        "name": "include-archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/createasset.CreatedAssetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createassets/{id}/holders": {
+            "get": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Holders returns the accounts holding the specified created asset as last synced from the indexer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "List holders by created asset ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Created Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter string, example: frozen = true",
+                        "name": "where",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order columns separated by comma, example: amount desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit, example: 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset, example: 20",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/createasset.CreatedAssetHolderResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createassets/{id}/issue": {
+            "post": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Issue prepares an atomic group of the payment from an investor to the creator and the transfer of the\nunits to the investor. Members Exitor can't sign for are left as drafts to be signed offline, the group\nis submitted once all the members are signed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "Issue created asset units for a payment by created asset ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Created Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Issue details",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/createasset.CreatedAssetIssueRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/createasset.CreatedAssetTxnResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createassets/{id}/metadata": {
+            "get": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Metadata fetches the ARC-3 metadata hosted at the asset URL and verifies its SHA-256 hash still\nmatches the metadata hash bound on-chain when the asset was created.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "Verify the metadata of a created asset by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Created Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/createasset.CreatedAssetMetadataVerification"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createassets/{id}/mint": {
+            "post": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Mint signs and submits the asset create transaction for the specified created asset. When the creator\naccount can't be signed for, a 409 is returned and the transaction must be signed offline.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "Mint created asset by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Created Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/createasset.CreatedAssetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createassets/{id}/signed-txn": {
+            "post": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "SignedTxn submits the asset create transaction for the specified created asset that was signed offline.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "Submit the signed create transaction by created asset ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Created Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Signed transaction",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/createasset.CreatedAssetSignedTxnRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/createasset.CreatedAssetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createassets/{id}/txns": {
+            "get": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Txns returns the transactions submitted for the specified created asset.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "List transactions by created asset ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Created Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/createasset.CreatedAssetTxnResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createassets/{id}/txns/{txn_id}/signed-txn": {
+            "post": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "TxnSignedTxn submits the created asset transaction that was signed offline. The member of a group is\nheld until all the members are signed and then the group is submitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "Submit the signed transaction by created asset transaction ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Created Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created Asset Transaction ID",
+                        "name": "txn_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Signed transaction",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/createasset.CreatedAssetTxnSignedRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/createasset.CreatedAssetTxnResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createassets/{id}/txns/{txn_id}/unsigned-txn": {
+            "post": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "TxnUnsignedTxn returns the draft created asset transaction to be signed offline by its sender.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "Get the unsigned transaction by created asset transaction ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Created Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created Asset Transaction ID",
+                        "name": "txn_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/createasset.UnsignedTxn"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/createassets/{id}/unsigned-txn": {
+            "post": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "UnsignedTxn issues the asset create transaction for the specified created asset to be signed offline.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "createasset"
+                ],
+                "summary": "Get the unsigned create transaction by created asset ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Created Asset ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/createasset.UnsignedTxn"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/invites": {
+            "post": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Send emails an invite to join the account to each of the email addresses. When not\nincluded, the user and account default to the ones of the current session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invite"
+                ],
+                "summary": "Invite users to an account.",
+                "parameters": [
+                    {
+                        "description": "Invite details",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/invite.SendUserInvitesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/invites/accept": {
+            "post": {
+                "description": "Accept completes the invite for the invited user and activates their access to the account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invite"
+                ],
+                "summary": "Accept an invite.",
+                "parameters": [
+                    {
+                        "description": "Invite user details",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/invite.AcceptInviteUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_account.UserAccountResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/token": {
+            "post": {
+                "description": "Token generates an oauth2 accessToken using Basic Auth with a user's email and password. With the\nclient_credentials grant type the accessToken is generated for the ID and secret of an api client instead.\nUsers are also issued a refreshToken that can be exchanged once with the refresh_token grant type for a new\naccessToken and refreshToken. Users that enabled two-factor authentication must include the\ntotp_code generated by their authenticator app or one of their recovery codes. Failed sign ins are\nthrottled for each email and IP address, a 429 response includes the Retry-After header.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Token handles a request to authenticate a user or an api client.",
+                "parameters": [
+                    {
+                        "enum": [
+                            "password",
+                            "client_credentials",
+                            "refresh_token"
+                        ],
+                        "type": "string",
+                        "description": "Grant Type",
+                        "name": "grant_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "username",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password",
+                        "name": "password",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client Secret",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Refresh Token",
+                        "name": "refresh_token",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Account ID",
+                        "name": "account_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Two-factor authentication code or recovery code",
+                        "name": "totp_code",
+                        "in": "formData"
+                    },
+                    {
+                        "enum": [
+                            "user",
+                            "admin",
+                            "drafter",
+                            "approver",
+                            "compliance",
+                            "auditor"
+                        ],
+                        "type": "string",
+                        "description": "Scope",
+                        "name": "scope",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_auth.Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/signup": {
+            "post": {
+                "description": "Signup creates a new account and user in the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "signup"
+                ],
+                "summary": "Signup handles new account creation.",
+                "parameters": [
+                    {
+                        "description": "Signup details",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/signup.SignupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/signup.SignupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_accounts": {
+            "get": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Find returns the existing user accounts in the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_account"
+                ],
+                "summary": "List user accounts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter string, example: account_id = 'c4653bf9-5978-48b7-89c5-95704aebb7e2'",
+                        "name": "where",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order columns separated by comma, example: created_at desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit, example: 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset, example: 20",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Included Archived, example: false",
+                        "name": "include-archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/user_account.UserAccountResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Create inserts a new user account into the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_account"
+                ],
+                "summary": "Create new user account.",
+                "parameters": [
+                    {
+                        "description": "User Account details",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/user_account.UserAccountCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/user_account.UserAccountResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Delete removes the specified user account from the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_account"
+                ],
+                "summary": "Delete user account by user ID and account ID",
+                "parameters": [
+                    {
+                        "description": "Delete",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/user_account.UserAccountDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Update updates the specified user account in the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_account"
+                ],
+                "summary": "Update user account by user ID and account ID",
+                "parameters": [
+                    {
+                        "description": "Update fields",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/user_account.UserAccountUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_accounts/archive": {
+            "patch": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Archive soft-deletes the specified user account from the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_account"
+                ],
+                "summary": "Archive user account by user ID and account ID",
+                "parameters": [
+                    {
+                        "description": "Update fields",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/user_account.UserAccountArchiveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_accounts/{user_id}/{account_id}": {
+            "get": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Read returns the specified user account from the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_account"
+                ],
+                "summary": "Get user account by user ID and account ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Account ID",
+                        "name": "account_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Included Archived, example: false",
+                        "name": "include-archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_account.UserAccountResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Find returns the existing users in the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "List users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter string, example: name = 'Company Name' and email = 'gabi.may@geeksinthewoods.com'",
+                        "name": "where",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order columns separated by comma, example: created_at desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit, example: 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset, example: 20",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Included Archived, example: false",
+                        "name": "include-archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/user.UserResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Create inserts a new user into the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Create new user.",
+                "parameters": [
+                    {
+                        "description": "User details",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/user.UserCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/user.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Update updates the specified user in the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update user by ID",
+                "parameters": [
+                    {
+                        "description": "Update fields",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/user.UserUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/archive": {
+            "patch": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Archive soft-deletes the specified user from the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Archive user by ID",
+                "parameters": [
+                    {
+                        "description": "Update fields",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/user.UserArchiveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/password": {
+            "patch": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "UpdatePassword updates the password for a specified user in the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update user password by ID",
+                "parameters": [
+                    {
+                        "description": "Update fields",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/user.UserUpdatePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/switch-account/{account_id}": {
+            "patch": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "SwitchAccount updates the auth claims to a new account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Switch account.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Account ID",
+                        "name": "account_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user_auth.Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Read returns the specified user from the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Included Archived, example: false",
+                        "name": "include-archived",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "OAuth2Password": []
+                    }
+                ],
+                "description": "Delete removes the specified user from the system.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Delete user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/weberror.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "account.AccountResponse": {
+            "type": "object",
+            "properties": {
+                "address1": {
+                    "type": "string",
+                    "example": "221 Tatitlek Ave"
+                },
+                "address2": {
+                    "type": "string",
+                    "example": "Box #1832"
+                },
+                "archived_at": {
+                    "description": "ArchivedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "billing_user_id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                },
+                "city": {
+                    "type": "string",
+                    "example": "Valdez"
+                },
+                "country": {
+                    "type": "string",
+                    "example": "USA"
+                },
+                "created_at": {
+                    "description": "CreatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Company Name"
+                },
+                "region": {
+                    "type": "string",
+                    "example": "AK"
+                },
+                "signup_user_id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                },
+                "status": {
+                    "description": "Status is enum with values [active, pending, disabled].",
+                    "type": "object",
+                    "$ref": "#/definitions/web.EnumResponse"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/Anchorage"
+                },
+                "updated_at": {
+                    "description": "UpdatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "zipcode": {
+                    "type": "string",
+                    "example": "99686"
+                }
+            }
+        },
+        "account.AccountUpdateRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "address1": {
+                    "type": "string",
+                    "example": "221 Tatitlek Ave"
+                },
+                "address2": {
+                    "type": "string",
+                    "example": "Box #1832"
+                },
+                "billing_user_id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                },
+                "city": {
+                    "type": "string",
+                    "example": "Valdez"
+                },
+                "country": {
+                    "type": "string",
+                    "example": "USA"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Company Name"
+                },
+                "region": {
+                    "type": "string",
+                    "example": "AK"
+                },
+                "signup_user_id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "pending",
+                        "disabled"
+                    ],
+                    "example": "disabled"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/Anchorage"
+                },
+                "zipcode": {
+                    "type": "string",
+                    "example": "99686"
+                }
+            }
+        },
+        "account_role.AccountRoleDeleteRequest": {
+            "type": "object",
+            "required": [
+                "account_id",
+                "role"
+            ],
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "drafter",
+                        "approver",
+                        "compliance",
+                        "auditor"
+                    ],
+                    "example": "auditor"
+                }
+            }
+        },
+        "account_role.AccountRoleResponse": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "created_at": {
+                    "description": "CreatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "asset:read",
+                        "user:read"
+                    ]
+                },
+                "role": {
+                    "type": "string",
+                    "example": "auditor"
+                },
+                "updated_at": {
+                    "description": "UpdatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                }
+            }
+        },
+        "account_role.AccountRoleSetRequest": {
+            "type": "object",
+            "required": [
+                "account_id",
+                "permissions",
+                "role"
+            ],
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "asset:read",
+                        "user:read"
+                    ]
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "drafter",
+                        "approver",
+                        "compliance",
+                        "auditor"
+                    ],
+                    "example": "auditor"
+                }
+            }
+        },
+        "auth.ClaimPreferences": {
+            "type": "object",
+            "properties": {
+                "pref_date_format": {
+                    "type": "string"
+                },
+                "pref_datetime_format": {
+                    "type": "string"
+                },
+                "pref_time_format": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "tz": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.Claims": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "mfa": {
+                    "type": "boolean"
+                },
+                "prefs": {
+                    "type": "object",
+                    "$ref": "#/definitions/auth.ClaimPreferences"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "root_account_id": {
+                    "type": "string"
+                },
+                "root_user_id": {
+                    "type": "string"
+                },
+                "sid": {
+                    "type": "string"
+                }
+            }
+        },
+        "createasset.CreatedAssetArchiveRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "985f1746-1d9f-459f-a2d9-fc53ece5ae86"
+                }
+            }
+        },
+        "createasset.CreatedAssetCreateRequest": {
+            "type": "object",
+            "required": [
+                "account_id",
+                "asset_name",
+                "creator_address",
+                "network",
+                "total"
+            ],
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "asset_name": {
+                    "type": "string",
+                    "example": "Kwa Jeff Limited"
+                },
+                "clawback_address": {
+                    "type": "string"
+                },
+                "creator_address": {
+                    "type": "string",
+                    "example": "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+                },
+                "decimals": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "default_frozen": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Ordinary shares of Kwa Jeff Limited."
+                },
+                "freeze_address": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string",
+                    "example": "https://exitor.io/images/kjl.png"
+                },
+                "legal_documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/createasset.CreatedAssetDocument"
+                    }
+                },
+                "manager_address": {
+                    "type": "string"
+                },
+                "metadata_hash": {
+                    "type": "string",
+                    "example": "hex encoded SHA-256 hash"
+                },
+                "network": {
+                    "type": "string",
+                    "example": "testnet"
+                },
+                "reserve_address": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "disabled"
+                    ],
+                    "example": "active"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1000000
+                },
+                "unit_name": {
+                    "type": "string",
+                    "example": "KJL"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://exitor.io"
+                }
+            }
+        },
+        "createasset.CreatedAssetDocument": {
+            "type": "object",
+            "required": [
+                "name",
+                "url"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Prospectus"
+                },
+                "sha256": {
+                    "type": "string",
+                    "example": "hex encoded SHA-256 hash of the document"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://exitor.io/docs/kjl-prospectus.pdf"
+                }
+            }
+        },
+        "createasset.CreatedAssetHolderResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+                },
+                "amount": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "created_asset_id": {
+                    "type": "string",
+                    "example": "985f1746-1d9f-459f-a2d9-fc53ece5ae86"
+                },
+                "created_at": {
+                    "description": "CreatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "frozen": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "id": {
+                    "type": "string",
+                    "example": "4c2b9d0e-5c55-4e0a-a1c4-3f5d6e7a8b9c"
+                },
+                "opted_in_round": {
+                    "type": "integer",
+                    "example": 8291045
+                },
+                "round": {
+                    "type": "integer",
+                    "example": 8291201
+                },
+                "updated_at": {
+                    "description": "UpdatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                }
+            }
+        },
+        "createasset.CreatedAssetIssueRequest": {
+            "type": "object",
+            "required": [
+                "address",
+                "amount",
+                "id",
+                "payment_amount",
+                "user_id"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+                },
+                "amount": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "id": {
+                    "type": "string",
+                    "example": "985f1746-1d9f-459f-a2d9-fc53ece5ae86"
+                },
+                "payment_amount": {
+                    "type": "integer",
+                    "example": 2500000
+                },
+                "payment_asset_id": {
+                    "type": "integer",
+                    "example": 31566704
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                }
+            }
+        },
+        "createasset.CreatedAssetMetadataVerification": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "hex encoded SHA-256 hash of the hosted document"
+                },
+                "metadata_hash": {
+                    "type": "string",
+                    "example": "hex encoded metadata hash of the asset"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://exitor.io/m/13169404"
+                },
+                "verified": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "createasset.CreatedAssetResponse": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "archived_at": {
+                    "description": "ArchivedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "asset_index": {
+                    "type": "integer",
+                    "example": 13169404
+                },
+                "asset_name": {
+                    "type": "string",
+                    "example": "Kwa Jeff Limited"
+                },
+                "clawback_address": {
+                    "type": "string"
+                },
+                "confirmed_round": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "description": "CreatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "creator_address": {
+                    "type": "string",
+                    "example": "EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"
+                },
+                "decimals": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "default_frozen": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Ordinary shares of Kwa Jeff Limited."
+                },
+                "destroyed_at": {
+                    "description": "DestroyedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "freeze_address": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "985f1746-1d9f-459f-a2d9-fc53ece5ae86"
+                },
+                "image_url": {
+                    "type": "string",
+                    "example": "https://exitor.io/images/kjl.png"
+                },
+                "last_valid_round": {
+                    "type": "integer"
+                },
+                "legal_documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/createasset.CreatedAssetDocument"
+                    }
+                },
+                "manager_address": {
+                    "type": "string"
+                },
+                "metadata_hash": {
+                    "type": "string",
+                    "example": "hex encoded hash"
+                },
+                "metadata_url": {
+                    "type": "string",
+                    "example": "https://exitor.io/m/13169404"
+                },
+                "mint_error": {
+                    "type": "string"
+                },
+                "mint_status": {
+                    "description": "MintStatus is enum with values [draft, submitted, confirmed, failed].",
+                    "type": "object",
+                    "$ref": "#/definitions/web.EnumResponse"
+                },
+                "network": {
+                    "type": "string",
+                    "example": "testnet"
+                },
+                "reserve_address": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is enum with values [active, disabled].",
+                    "type": "object",
+                    "$ref": "#/definitions/web.EnumResponse"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1000000
+                },
+                "tx_id": {
+                    "type": "string"
+                },
+                "unit_name": {
+                    "type": "string",
+                    "example": "KJL"
+                },
+                "updated_at": {
+                    "description": "UpdatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://exitor.io"
+                }
+            }
+        },
+        "createasset.CreatedAssetSignedTxnRequest": {
+            "type": "object",
+            "required": [
+                "id",
+                "signed_txn"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "985f1746-1d9f-459f-a2d9-fc53ece5ae86"
+                },
+                "signed_txn": {
+                    "type": "string",
+                    "example": "base64 encoded signed transaction"
+                }
+            }
+        },
+        "createasset.CreatedAssetTxnResponse": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "amount": {
+                    "type": "integer"
+                },
+                "clawback_address": {
+                    "type": "string"
+                },
+                "confirmed_round": {
+                    "type": "integer"
+                },
+                "created_asset_id": {
+                    "type": "string",
+                    "example": "985f1746-1d9f-459f-a2d9-fc53ece5ae86"
+                },
+                "created_at": {
+                    "description": "CreatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "freeze_address": {
+                    "type": "string"
+                },
+                "frozen": {
+                    "type": "boolean"
+                },
+                "group_id": {
+                    "type": "string"
+                },
+                "group_index": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "7b2a6a3e-3a4f-4f5e-9a35-5b3c9f5a2d11"
+                },
+                "last_valid_round": {
+                    "type": "integer"
+                },
+                "manager_address": {
+                    "type": "string"
+                },
+                "multisig": {
+                    "type": "boolean"
+                },
+                "payment_asset": {
+                    "type": "string",
+                    "example": "ALGO"
+                },
+                "receiver_address": {
+                    "type": "string"
+                },
+                "reserve_address": {
+                    "type": "string"
+                },
+                "sender_address": {
+                    "type": "string"
+                },
+                "signatures": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "signed": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "Status is enum with values [draft, submitted, confirmed, failed].",
+                    "type": "object",
+                    "$ref": "#/definitions/web.EnumResponse"
+                },
+                "target_address": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "tx_id": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Type is enum with values [config, freeze, clawback, destroy, opt_in, transfer, payment].",
+                    "type": "object",
+                    "$ref": "#/definitions/web.EnumResponse"
+                },
+                "updated_at": {
+                    "description": "UpdatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                }
+            }
+        },
+        "createasset.CreatedAssetTxnSignedRequest": {
+            "type": "object",
+            "required": [
+                "created_asset_id",
+                "id",
+                "signed_txn"
+            ],
+            "properties": {
+                "created_asset_id": {
+                    "type": "string",
+                    "example": "985f1746-1d9f-459f-a2d9-fc53ece5ae86"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "7b2a6a3e-3a4f-4f5e-9a35-5b3c9f5a2d11"
+                },
+                "signed_txn": {
+                    "type": "string",
+                    "example": "base64 encoded signed transaction"
+                }
+            }
+        },
+        "createasset.CreatedAssetUpdateRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "clawback_address": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Ordinary shares of Kwa Jeff Limited."
+                },
+                "freeze_address": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "985f1746-1d9f-459f-a2d9-fc53ece5ae86"
+                },
+                "image_url": {
+                    "type": "string",
+                    "example": "https://exitor.io/images/kjl.png"
+                },
+                "legal_documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/createasset.CreatedAssetDocument"
+                    }
+                },
+                "manager_address": {
+                    "type": "string"
+                },
+                "reserve_address": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "disabled"
+                    ],
+                    "example": "disabled"
+                }
+            }
+        },
+        "createasset.UnsignedTxn": {
+            "type": "object",
+            "properties": {
+                "created_asset_id": {
+                    "type": "string"
+                },
+                "last_valid_round": {
+                    "type": "integer"
+                },
+                "multisig": {
+                    "description": "Multisig is true when the sender is a multisig and Txn is the msgpack encoded signed\ntransaction with the signatures of the members collected so far.",
+                    "type": "boolean"
+                },
+                "tx_id": {
+                    "type": "string"
+                },
+                "txn": {
+                    "description": "Txn is the msgpack encoded transaction.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "invite.AcceptInviteUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "invite_hash",
+                "last_name",
+                "password",
+                "password_confirm"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "gabi@geeksinthewoods.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "Gabi"
+                },
+                "invite_hash": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "May"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "SecretString"
+                },
+                "password_confirm": {
+                    "type": "string",
+                    "example": "SecretString"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/Anchorage"
+                }
+            }
+        },
+        "invite.SendUserInvitesRequest": {
+            "type": "object",
+            "required": [
+                "account_id",
+                "emails",
+                "roles",
+                "user_id"
+            ],
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "emails": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "roles": {
+                    "type": "string"
+                },
+                "ttl": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                }
+            }
+        },
+        "signup.SignupAccount": {
+            "type": "object",
+            "required": [
+                "address1",
+                "city",
+                "country",
+                "name",
+                "region",
+                "zipcode"
+            ],
+            "properties": {
+                "address1": {
+                    "type": "string",
+                    "example": "221 Tatitlek Ave"
+                },
+                "address2": {
+                    "type": "string",
+                    "example": "Box #1832"
+                },
+                "city": {
+                    "type": "string",
+                    "example": "Valdez"
+                },
+                "country": {
+                    "type": "string",
+                    "example": "USA"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Company {RANDOM_UUID}"
+                },
+                "region": {
+                    "type": "string",
+                    "example": "AK"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/Anchorage"
+                },
+                "zipcode": {
+                    "type": "string",
+                    "example": "99686"
+                }
+            }
+        },
+        "signup.SignupRequest": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "description": "Account details.",
+                    "type": "object",
+                    "$ref": "#/definitions/signup.SignupAccount"
+                },
+                "user": {
+                    "description": "User details.",
+                    "type": "object",
+                    "$ref": "#/definitions/signup.SignupUser"
+                }
+            }
+        },
+        "signup.SignupResponse": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "object",
+                    "$ref": "#/definitions/account.AccountResponse"
+                },
+                "user": {
+                    "type": "object",
+                    "$ref": "#/definitions/user.UserResponse"
+                }
+            }
+        },
+        "signup.SignupUser": {
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "password",
+                "password_confirm"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "{RANDOM_EMAIL}"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "Celestino"
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "Muriuki"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "SecretString"
+                },
+                "password_confirm": {
+                    "type": "string",
+                    "example": "SecretString"
+                }
+            }
+        },
+        "user.UserArchiveRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "force": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                }
+            }
+        },
+        "user.UserCreateRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "password",
+                "password_confirm"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "gabi@geeksinthewoods.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "Gabi"
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "May"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "SecretString"
+                },
+                "password_confirm": {
+                    "type": "string",
+                    "example": "SecretString"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/Anchorage"
+                }
+            }
+        },
+        "user.UserResponse": {
+            "type": "object",
+            "properties": {
+                "archived_at": {
+                    "description": "ArchivedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "created_at": {
+                    "description": "CreatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "gabi@geeksinthewoods.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "Gabi"
+                },
+                "gravatar": {
+                    "type": "object",
+                    "$ref": "#/definitions/web.GravatarResponse"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "May"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Gabi"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/Anchorage"
+                },
+                "updated_at": {
+                    "description": "UpdatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                }
+            }
+        },
+        "user.UserUpdatePasswordRequest": {
+            "type": "object",
+            "required": [
+                "id",
+                "password",
+                "password_confirm"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "NeverTellSecret"
+                },
+                "password_confirm": {
+                    "type": "string",
+                    "example": "NeverTellSecret"
+                }
+            }
+        },
+        "user.UserUpdateRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "gabi.may@geeksinthewoods.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "Gabi May Not"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "Gabi May Not"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/Anchorage"
+                }
+            }
+        },
+        "user_account.UserAccountArchiveRequest": {
+            "type": "object",
+            "required": [
+                "account_id",
+                "user_id"
+            ],
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                }
+            }
+        },
+        "user_account.UserAccountCreateRequest": {
+            "type": "object",
+            "required": [
+                "account_id",
+                "roles",
+                "user_id"
+            ],
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "admin",
+                            "user",
+                            "drafter",
+                            "approver",
+                            "compliance",
+                            "auditor"
+                        ]
+                    },
+                    "example": [
+                        "admin"
+                    ]
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "invited",
+                        "disabled"
+                    ],
+                    "example": "active"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                }
+            }
+        },
+        "user_account.UserAccountDeleteRequest": {
+            "type": "object",
+            "required": [
+                "account_id",
+                "user_id"
+            ],
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                }
+            }
+        },
+        "user_account.UserAccountResponse": {
+            "type": "object",
+            "required": [
+                "roles"
+            ],
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "archived_at": {
+                    "description": "ArchivedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "created_at": {
+                    "description": "CreatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "permissions": {
+                    "description": "Permissions granted by the roles.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "asset:read"
+                    ]
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "admin",
+                            "user",
+                            "drafter",
+                            "approver",
+                            "compliance",
+                            "auditor"
+                        ]
+                    },
+                    "example": [
+                        "admin"
+                    ]
+                },
+                "status": {
+                    "description": "Status is enum with values [active, invited, disabled].",
+                    "type": "object",
+                    "$ref": "#/definitions/web.EnumResponse"
+                },
+                "updated_at": {
+                    "description": "UpdatedAt contains multiple format options for display.",
+                    "type": "object",
+                    "$ref": "#/definitions/web.TimeResponse"
+                },
+                "user_id": {
+                    "description": "ID         string            ` + "`" + `json:\"id\" example:\"d69bdef7-173f-4d29-b52c-3edc60baf6a2\"` + "`" + `",
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                }
+            }
+        },
+        "user_account.UserAccountUpdateRequest": {
+            "type": "object",
+            "required": [
+                "account_id",
+                "user_id"
+            ],
+            "properties": {
+                "account_id": {
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "admin",
+                            "user",
+                            "drafter",
+                            "approver",
+                            "compliance",
+                            "auditor"
+                        ]
+                    },
+                    "example": [
+                        "user"
+                    ]
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "invited",
+                        "disabled"
+                    ],
+                    "example": "disabled"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                }
+            }
+        },
+        "user_auth.Token": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "description": "AccessToken is the token that authorizes and authenticates\nthe requests.",
+                    "type": "string"
+                },
+                "account_id": {
+                    "description": "AccountID is the ID of the account for the user authenticated.",
+                    "type": "string",
+                    "example": "c4653bf9-5978-48b7-89c5-95704aebb7e2"
+                },
+                "expiry": {
+                    "description": "Expiry is the optional expiration time of the access token.\n\nIf zero, TokenSource implementations will reuse the same\ntoken forever and RefreshToken or equivalent\nmechanisms for that TokenSource will not be used.",
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "description": "RefreshToken is used to obtain a new access token when it expires. It is only\nincluded when a session was started and changes each time it's used.",
+                    "type": "string"
+                },
+                "token_type": {
+                    "description": "TokenType is the type of token.\nThe Type method returns either this or \"Bearer\", the default.",
+                    "type": "string"
+                },
+                "ttl": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "UserId is the ID of the user authenticated.",
+                    "type": "string",
+                    "example": "d69bdef7-173f-4d29-b52c-3edc60baf6a2"
+                }
+            }
+        },
+        "web.EnumMultiResponse": {
+            "type": "object",
+            "properties": {
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/web.EnumOption"
+                    }
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "active_etc"
+                    ]
+                }
+            }
+        },
+        "web.EnumOption": {
+            "type": "object",
+            "properties": {
+                "selected": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Active Etc"
+                },
+                "value": {
+                    "type": "string",
+                    "example": "active_etc"
+                }
+            }
+        },
+        "web.EnumResponse": {
+            "type": "object",
+            "properties": {
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/web.EnumOption"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Active Etc"
+                },
+                "value": {
+                    "type": "string",
+                    "example": "active_etc"
+                }
+            }
+        },
+        "web.GravatarResponse": {
+            "type": "object",
+            "properties": {
+                "medium": {
+                    "type": "string",
+                    "example": "https://www.gravatar.com/avatar/xy7.jpg?s=80"
+                },
+                "small": {
+                    "type": "string",
+                    "example": "https://www.gravatar.com/avatar/xy7.jpg?s=30"
+                }
+            }
+        },
+        "web.TimeResponse": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2019-06-25"
+                },
+                "kitchen": {
+                    "type": "string",
+                    "example": "3:00AM"
+                },
+                "local": {
+                    "type": "string",
+                    "example": "Tue Jun 25 3:00AM"
+                },
+                "local_date": {
+                    "type": "string",
+                    "example": "Tue Jun 25"
+                },
+                "local_time": {
+                    "type": "string",
+                    "example": "3:00AM"
+                },
+                "now_rel_time": {
+                    "type": "string",
+                    "example": "15 hours from now"
+                },
+                "now_time": {
+                    "type": "string",
+                    "example": "5 hours ago"
+                },
+                "rfc1123": {
+                    "type": "string",
+                    "example": "Tue, 25 Jun 2019 03:00:53 AKDT"
+                },
+                "time": {
+                    "type": "string",
+                    "example": "03:00:53"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/Anchorage"
+                },
+                "value": {
+                    "type": "string",
+                    "example": "2019-06-25T03:00:53.284-08:00"
+                },
+                "value_utc": {
+                    "type": "string",
+                    "example": "2019-06-25T11:00:53.284Z"
+                }
+            }
+        },
+        "weberror.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/weberror.FieldError"
+                    }
+                },
+                "stack_trace": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "weberror.FieldError": {
+            "type": "object",
+            "properties": {
+                "display": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "tag": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "object"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "OAuth2Password": {
+            "type": "oauth2",
+            "flow": "password",
+            "tokenUrl": "/v1/oauth/token",
+            "scopes": {
+                "admin": " Grants administrative privileges with role of admin.",
+                "user": " Grants basic privileges with role of user."
+            }
+        }
+    }
+}`
+
+type swaggerInfo struct {
+	Version     string
+	Host        string
+	BasePath    string
+	Schemes     []string
+	Title       string
+	Description string
+}
+
+// SwaggerInfo holds exported Swagger Info so clients can modify it
+var SwaggerInfo = swaggerInfo{
+	Version:     "1.0",
+	Host:        "",
+	BasePath:    "/v1",
+	Schemes:     []string{},
+	Title:       "Exitor API",
+	Description: "This is the API for managing Exitor accounts, users and the Algorand Standard Assets created by them.",
+}
+
+type s struct{}
+
+func (s *s) ReadDoc() string {
+	sInfo := SwaggerInfo
+	sInfo.Description = strings.Replace(sInfo.Description, "\n", "\\n", -1)
+
+	t, err := template.New("swagger_info").Funcs(template.FuncMap{
+		"marshal": func(v interface{}) string {
+			a, _ := json.Marshal(v)
+			return string(a)
+		},
+	}).Parse(doc)
+	if err != nil {
+		return doc
+	}
+
+	var tpl bytes.Buffer
+	if err := t.Execute(&tpl, sInfo); err != nil {
+		return doc
+	}
+
+	return tpl.String()
+}
+
+func init() {
+	swag.Register(swag.Name, &s{})
+}
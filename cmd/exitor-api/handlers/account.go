@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"exitor-dapp/internal/account"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web"
+	"exitor-dapp/internal/platform/web/webcontext"
+	"exitor-dapp/internal/platform/web/weberror"
+
+	"github.com/pkg/errors"
+)
+
+// Account represents the Account API method handler set.
+type Account struct {
+	Repository *account.Repository
+
+	// ADD OTHER STATE LIKE THE LOGGER AND CONFIG HERE.
+}
+
+// Read godoc
+// @Summary Get account by ID
+// @Description Read returns the specified account from the system.
+// @Tags account
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "Account ID"
+// @Param include-archived query boolean false "Included Archived, example: false"
+// @Success 200 {object} account.AccountResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 404 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /accounts/{id} [get]
+func (h *Account) Read(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Handle include-archived query value if set.
+	var includeArchived bool
+	if v := r.URL.Query().Get("include-archived"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			err = errors.WithMessagef(err, "unable to parse %s as boolean for include-archived param", v)
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		includeArchived = b
+	}
+
+	res, err := h.Repository.Read(ctx, claims, account.AccountReadRequest{
+		ID:              params["id"],
+		IncludeArchived: includeArchived,
+	})
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case account.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
+
+// Update godoc
+// @Summary Update account by ID
+// @Description Update updates the specified account in the system.
+// @Tags account
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param data body account.AccountUpdateRequest true "Update fields"
+// @Success 204
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /accounts [patch]
+func (h *Account) Update(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req account.AccountUpdateRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+
+	err = h.Repository.Update(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case account.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "Id: %s Account: %+v", req.ID, &req)
+		}
+	}
+
+	return web.RespondJson(ctx, w, nil, http.StatusNoContent)
+}
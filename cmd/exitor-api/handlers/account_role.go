@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"exitor-dapp/internal/account"
+	"exitor-dapp/internal/account/account_role"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web"
+	"exitor-dapp/internal/platform/web/webcontext"
+	"exitor-dapp/internal/platform/web/weberror"
+
+	"github.com/pkg/errors"
+)
+
+// AccountRole represents the AccountRole API method handler set.
+type AccountRole struct {
+	Repository *account_role.Repository
+
+	// ADD OTHER STATE LIKE THE LOGGER AND CONFIG HERE.
+}
+
+// Find godoc
+// @Summary List the permissions assigned to roles
+// @Description Find returns the sets of permissions assigned to the roles of the account in place of their defaults.
+// @Tags account_role
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Success 200 {array} account_role.AccountRoleResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /account_roles [get]
+func (h *AccountRole) Find(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.Repository.FindByAccountID(ctx, claims, account_role.AccountRoleFindByAccountIDRequest{
+		AccountID: claims.Audience,
+	})
+	if err != nil {
+		if verr, ok := weberror.NewValidationError(ctx, err); ok {
+			return web.RespondJsonError(ctx, w, verr)
+		}
+
+		return errors.Wrapf(err, "AccountID: %s", claims.Audience)
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
+
+// Set godoc
+// @Summary Assign the permissions of a role
+// @Description Set assigns the set of permissions of a role for the account in place of its defaults.
+// @Tags account_role
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param data body account_role.AccountRoleSetRequest true "Set fields"
+// @Success 204
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /account_roles [patch]
+func (h *AccountRole) Set(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req account_role.AccountRoleSetRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+
+	err = h.Repository.Set(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case account.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "AccountID: %s Role: %s", req.AccountID, req.Role)
+		}
+	}
+
+	return web.RespondJson(ctx, w, nil, http.StatusNoContent)
+}
+
+// Delete godoc
+// @Summary Restore the default permissions of a role
+// @Description Delete removes the set of permissions assigned to a role for the account so it's granted its defaults again.
+// @Tags account_role
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param data body account_role.AccountRoleDeleteRequest true "Delete"
+// @Success 204
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /account_roles [delete]
+func (h *AccountRole) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req account_role.AccountRoleDeleteRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+
+	err = h.Repository.Delete(ctx, claims, req)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case account.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "AccountID: %s Role: %s", req.AccountID, req.Role)
+		}
+	}
+
+	return web.RespondJson(ctx, w, nil, http.StatusNoContent)
+}
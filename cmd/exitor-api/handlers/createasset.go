@@ -0,0 +1,801 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/createasset"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web"
+	"exitor-dapp/internal/platform/web/webcontext"
+	"exitor-dapp/internal/platform/web/weberror"
+
+	"github.com/pkg/errors"
+)
+
+// Createasset represents the Createasset API method handler set.
+type Createasset struct {
+	Repository *createasset.Repository
+
+	// ADD OTHER STATE LIKE THE LOGGER AND CONFIG HERE.
+}
+
+// Find godoc
+// @Summary List created assets
+// @Description Find returns the existing created assets in the system.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param where				query string 	false	"Filter string, example: asset_name = 'Kwa Jeff Limited'"
+// @Param order				query string   	false 	"Order columns separated by comma, example: created_at desc"
+// @Param limit				query integer  	false 	"Limit, example: 10"
+// @Param offset			query integer  	false 	"Offset, example: 20"
+// @Param include-archived query boolean 	false 	"Included Archived, example: false"
+// @Success 200 {array} createasset.CreatedAssetResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets [get]
+func (h *Createasset) Find(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req createasset.CreatedAssetFindRequest
+
+	// Handle where query value if set.
+	if v := r.URL.Query().Get("where"); v != "" {
+		where, args, err := web.ExtractWhereArgs(v)
+		if err != nil {
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		req.Where = where
+		req.Args = args
+	}
+
+	// Handle order query value if set.
+	if v := r.URL.Query().Get("order"); v != "" {
+		for _, o := range strings.Split(v, ",") {
+			o = strings.TrimSpace(o)
+			if o != "" {
+				req.Order = append(req.Order, o)
+			}
+		}
+	}
+
+	// Handle limit query value if set.
+	if v := r.URL.Query().Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			err = errors.WithMessagef(err, "unable to parse %s as int for limit param", v)
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		ul := uint(l)
+		req.Limit = &ul
+	}
+
+	// Handle offset query value if set.
+	if v := r.URL.Query().Get("offset"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			err = errors.WithMessagef(err, "unable to parse %s as int for offset param", v)
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		ul := uint(l)
+		req.Offset = &ul
+	}
+
+	// Handle include-archived query value if set.
+	if v := r.URL.Query().Get("include-archived"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			err = errors.WithMessagef(err, "unable to parse %s as boolean for include-archived param", v)
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		req.IncludeArchived = b
+	}
+
+	res, err := h.Repository.Find(ctx, claims, req)
+	if err != nil {
+		return err
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
+
+// Read godoc
+// @Summary Get created asset by ID
+// @Description Read returns the specified created asset from the system.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "Created Asset ID"
+// @Param include-archived query boolean false "Included Archived, example: false"
+// @Success 200 {object} createasset.CreatedAssetResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 404 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets/{id} [get]
+func (h *Createasset) Read(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Handle include-archived query value if set.
+	var includeArchived bool
+	if v := r.URL.Query().Get("include-archived"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			err = errors.WithMessagef(err, "unable to parse %s as boolean for include-archived param", v)
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		includeArchived = b
+	}
+
+	res, err := h.Repository.Read(ctx, claims, createasset.CreatedAssetReadRequest{
+		ID:              params["id"],
+		IncludeArchived: includeArchived,
+	})
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
+
+// Create godoc
+// @Summary Create new created asset.
+// @Description Create inserts a new draft created asset into the system, the asset is created on the network once minted.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param data body createasset.CreatedAssetCreateRequest true "Created Asset details"
+// @Success 201 {object} createasset.CreatedAssetResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets [post]
+func (h *Createasset) Create(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req createasset.CreatedAssetCreateRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+
+	res, err := h.Repository.Create(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "Created Asset: %+v", &req)
+		}
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusCreated)
+}
+
+// Update godoc
+// @Summary Update created asset by ID
+// @Description Update updates the specified created asset in the system.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param data body createasset.CreatedAssetUpdateRequest true "Update fields"
+// @Success 204
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets [patch]
+func (h *Createasset) Update(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req createasset.CreatedAssetUpdateRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+
+	err = h.Repository.Update(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		case createasset.ErrInvalidMintStatus:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s Update: %+v", req.ID, &req)
+		}
+	}
+
+	return web.RespondJson(ctx, w, nil, http.StatusNoContent)
+}
+
+// Archive godoc
+// @Summary Archive created asset by ID
+// @Description Archive soft-deletes the specified created asset from the system.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param data body createasset.CreatedAssetArchiveRequest true "Update fields"
+// @Success 204
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets/archive [patch]
+func (h *Createasset) Archive(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req createasset.CreatedAssetArchiveRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+
+	err = h.Repository.Archive(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", req.ID)
+		}
+	}
+
+	return web.RespondJson(ctx, w, nil, http.StatusNoContent)
+}
+
+// Mint godoc
+// @Summary Mint created asset by ID
+// @Description Mint signs and submits the asset create transaction for the specified created asset. When the creator
+// @Description account can't be signed for, a 409 is returned and the transaction must be signed offline.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "Created Asset ID"
+// @Success 200 {object} createasset.CreatedAssetResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 409 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets/{id}/mint [post]
+func (h *Createasset) Mint(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.Repository.Mint(ctx, claims, createasset.CreatedAssetMintRequest{ID: params["id"]}, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		case createasset.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		case createasset.ErrInvalidMintStatus:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		case algosdk.ErrExternalSigningRequired, algosdk.ErrSignerAccountNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusConflict))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
+
+// UnsignedTxn godoc
+// @Summary Get the unsigned create transaction by created asset ID
+// @Description UnsignedTxn issues the asset create transaction for the specified created asset to be signed offline.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "Created Asset ID"
+// @Success 200 {object} createasset.UnsignedTxn
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets/{id}/unsigned-txn [post]
+func (h *Createasset) UnsignedTxn(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.Repository.UnsignedCreateTxn(ctx, claims, createasset.CreatedAssetMintRequest{ID: params["id"]}, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		case createasset.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		case createasset.ErrInvalidMintStatus:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, res, http.StatusOK)
+}
+
+// SignedTxn godoc
+// @Summary Submit the signed create transaction by created asset ID
+// @Description SignedTxn submits the asset create transaction for the specified created asset that was signed offline.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "Created Asset ID"
+// @Param data body createasset.CreatedAssetSignedTxnRequest true "Signed transaction"
+// @Success 200 {object} createasset.CreatedAssetResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets/{id}/signed-txn [post]
+func (h *Createasset) SignedTxn(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req createasset.CreatedAssetSignedTxnRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+	req.ID = params["id"]
+
+	res, err := h.Repository.SubmitSignedTxn(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		case createasset.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		case createasset.ErrInvalidMintStatus, createasset.ErrSignedTxnMismatch, createasset.ErrSignedTxnInvalidSignature:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
+
+// Holders godoc
+// @Summary List holders by created asset ID
+// @Description Holders returns the accounts holding the specified created asset as last synced from the indexer.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "Created Asset ID"
+// @Param where				query string 	false	"Filter string, example: frozen = true"
+// @Param order				query string   	false 	"Order columns separated by comma, example: amount desc"
+// @Param limit				query integer  	false 	"Limit, example: 10"
+// @Param offset			query integer  	false 	"Offset, example: 20"
+// @Success 200 {array} createasset.CreatedAssetHolderResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets/{id}/holders [get]
+func (h *Createasset) Holders(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := createasset.CreatedAssetHolderFindRequest{
+		CreatedAssetID: params["id"],
+	}
+
+	// Handle where query value if set.
+	if v := r.URL.Query().Get("where"); v != "" {
+		where, args, err := web.ExtractWhereArgs(v)
+		if err != nil {
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		req.Where = where
+		req.Args = args
+	}
+
+	// Handle order query value if set.
+	if v := r.URL.Query().Get("order"); v != "" {
+		for _, o := range strings.Split(v, ",") {
+			o = strings.TrimSpace(o)
+			if o != "" {
+				req.Order = append(req.Order, o)
+			}
+		}
+	}
+
+	// Handle limit query value if set.
+	if v := r.URL.Query().Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			err = errors.WithMessagef(err, "unable to parse %s as int for limit param", v)
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		ul := uint(l)
+		req.Limit = &ul
+	}
+
+	// Handle offset query value if set.
+	if v := r.URL.Query().Get("offset"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			err = errors.WithMessagef(err, "unable to parse %s as int for offset param", v)
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		ul := uint(l)
+		req.Offset = &ul
+	}
+
+	res, err := h.Repository.FindHolders(ctx, claims, req)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		case createasset.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
+
+// Txns godoc
+// @Summary List transactions by created asset ID
+// @Description Txns returns the transactions submitted for the specified created asset.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "Created Asset ID"
+// @Success 200 {array} createasset.CreatedAssetTxnResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets/{id}/txns [get]
+func (h *Createasset) Txns(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.Repository.FindTxns(ctx, claims, params["id"])
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		case createasset.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
+
+// Metadata godoc
+// @Summary Verify the metadata of a created asset by ID
+// @Description Metadata fetches the ARC-3 metadata hosted at the asset URL and verifies its SHA-256 hash still
+// @Description matches the metadata hash bound on-chain when the asset was created.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "Created Asset ID"
+// @Success 200 {object} createasset.CreatedAssetMetadataVerification
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 404 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets/{id}/metadata [get]
+func (h *Createasset) Metadata(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.Repository.VerifyMetadata(ctx, claims, params["id"])
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		case createasset.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		case createasset.ErrMetadataNotPublished, createasset.ErrAssetNotMinted:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, res, http.StatusOK)
+}
+
+// Issue godoc
+// @Summary Issue created asset units for a payment by created asset ID
+// @Description Issue prepares an atomic group of the payment from an investor to the creator and the transfer of the
+// @Description units to the investor. Members Exitor can't sign for are left as drafts to be signed offline, the group
+// @Description is submitted once all the members are signed.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "Created Asset ID"
+// @Param data body createasset.CreatedAssetIssueRequest true "Issue details"
+// @Success 200 {array} createasset.CreatedAssetTxnResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 404 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets/{id}/issue [post]
+func (h *Createasset) Issue(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req createasset.CreatedAssetIssueRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+	req.ID = params["id"]
+
+	res, err := h.Repository.Issue(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		case createasset.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		case createasset.ErrAssetNotMinted, createasset.ErrAssetDestroyed, createasset.ErrNotOptedIn,
+			createasset.ErrNotWhitelisted:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
+
+// TxnUnsignedTxn godoc
+// @Summary Get the unsigned transaction by created asset transaction ID
+// @Description TxnUnsignedTxn returns the draft created asset transaction to be signed offline by its sender.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "Created Asset ID"
+// @Param txn_id path string true "Created Asset Transaction ID"
+// @Success 200 {object} createasset.UnsignedTxn
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 404 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets/{id}/txns/{txn_id}/unsigned-txn [post]
+func (h *Createasset) TxnUnsignedTxn(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.Repository.UnsignedAssetTxn(ctx, claims, createasset.CreatedAssetTxnReadRequest{
+		CreatedAssetID: params["id"],
+		ID:             params["txn_id"],
+	})
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		case createasset.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		case createasset.ErrInvalidTxnStatus:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["txn_id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, res, http.StatusOK)
+}
+
+// TxnSignedTxn godoc
+// @Summary Submit the signed transaction by created asset transaction ID
+// @Description TxnSignedTxn submits the created asset transaction that was signed offline. The member of a group is
+// @Description held until all the members are signed and then the group is submitted.
+// @Tags createasset
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "Created Asset ID"
+// @Param txn_id path string true "Created Asset Transaction ID"
+// @Param data body createasset.CreatedAssetTxnSignedRequest true "Signed transaction"
+// @Success 200 {object} createasset.CreatedAssetTxnResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 404 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /createassets/{id}/txns/{txn_id}/signed-txn [post]
+func (h *Createasset) TxnSignedTxn(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req createasset.CreatedAssetTxnSignedRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+	req.CreatedAssetID = params["id"]
+	req.ID = params["txn_id"]
+
+	res, err := h.Repository.SubmitSignedAssetTxn(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case createasset.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		case createasset.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		case createasset.ErrInvalidTxnStatus, createasset.ErrSignedTxnMismatch, createasset.ErrSignedTxnInvalidSignature:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["txn_id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
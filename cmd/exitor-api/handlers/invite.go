@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"exitor-dapp/internal/account"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web"
+	"exitor-dapp/internal/platform/web/webcontext"
+	"exitor-dapp/internal/platform/web/weberror"
+	"exitor-dapp/internal/user_account/invite"
+
+	"github.com/pkg/errors"
+)
+
+// Invite represents the Invite API method handler set.
+type Invite struct {
+	Repository *invite.Repository
+
+	// ADD OTHER STATE LIKE THE LOGGER AND CONFIG HERE.
+}
+
+// Send godoc
+// @Summary Invite users to an account.
+// @Description Send emails an invite to join the account to each of the email addresses. When not
+// @Description included, the user and account default to the ones of the current session.
+// @Tags invite
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param data body invite.SendUserInvitesRequest true "Invite details"
+// @Success 204
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /invites [post]
+func (h *Invite) Send(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req invite.SendUserInvitesRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+
+	if req.UserID == "" {
+		req.UserID = claims.Subject
+	}
+	if req.AccountID == "" {
+		req.AccountID = claims.Audience
+	}
+
+	// The invite hashes are only delivered to the invited users by email.
+	_, err = h.Repository.SendUserInvites(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case account.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "AccountID: %s", req.AccountID)
+		}
+	}
+
+	return web.RespondJson(ctx, w, nil, http.StatusNoContent)
+}
+
+// Accept godoc
+// @Summary Accept an invite.
+// @Description Accept completes the invite for the invited user and activates their access to the account.
+// @Tags invite
+// @Accept  json
+// @Produce  json
+// @Param data body invite.AcceptInviteUserRequest true "Invite user details"
+// @Success 200 {object} user_account.UserAccountResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 409 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /invites/accept [post]
+func (h *Invite) Accept(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req invite.AcceptInviteUserRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+
+	res, err := h.Repository.AcceptInviteUser(ctx, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case invite.ErrInviteExpired, invite.ErrNoPendingInvite:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		case invite.ErrUserAccountActive:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusConflict))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			// The request contains the password so it's not included in the error.
+			return errors.Wrap(err, "accept invite")
+		}
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"exitor-dapp/internal/account"
+	"exitor-dapp/internal/account/account_role"
+	"exitor-dapp/internal/createasset"
+	"exitor-dapp/internal/login_throttle"
+	"exitor-dapp/internal/mid"
+	saasSwagger "exitor-dapp/internal/mid/saas-swagger"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/ratelimit"
+	"exitor-dapp/internal/platform/web"
+	"exitor-dapp/internal/platform/web/webcontext"
+	"exitor-dapp/internal/signup"
+	"exitor-dapp/internal/user"
+	"exitor-dapp/internal/user_account"
+	"exitor-dapp/internal/user_account/invite"
+	"exitor-dapp/internal/user_auth"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/go-redis/redis"
+)
+
+type AppContext struct {
+	Log               *log.Logger
+	Env               webcontext.Env
+	MasterDB          *sqlx.DB
+	Redis             *redis.Client
+	UserRepo          *user.Repository
+	UserAccountRepo   *user_account.Repository
+	AccountRepo       *account.Repository
+	AccountRoleRepo   *account_role.Repository
+	AuthRepo          *user_auth.Repository
+	SignupRepo        *signup.Repository
+	InviteRepo        *invite.Repository
+	CreateassetRepo   *createasset.Repository
+	LoginThrottleRepo *login_throttle.Repository
+	Authenticator     *auth.Authenticator
+	PreAppMiddleware  []web.Middleware
+	PostAppMiddleware []web.Middleware
+}
+
+// API returns a handler for a set of routes.
+func API(shutdown chan os.Signal, appCtx *AppContext) http.Handler {
+
+	// Include the pre middlewares first.
+	middlewares := appCtx.PreAppMiddleware
+
+	// Define app middlewares applied to all requests.
+	middlewares = append(middlewares,
+		mid.Trace(),
+		mid.Logger(appCtx.Log),
+		mid.Errors(appCtx.Log, nil),
+		mid.Metrics(),
+		mid.Panics())
+
+	// Append any global middlewares that should be included after the app middlewares.
+	if len(appCtx.PostAppMiddleware) > 0 {
+		middlewares = append(middlewares, appCtx.PostAppMiddleware...)
+	}
+
+	// Construct the web.App which holds all routes as well as common Middleware.
+	app := web.NewApp(shutdown, appCtx.Log, appCtx.Env, middlewares...)
+
+	// Limit the requests a client can make to endpoints that are targeted by credential stuffing.
+	rateLimitMid := mid.RateLimit(mid.RateLimitConfig{
+		Limiter: ratelimit.New(appCtx.Redis, "rate_limit:api", 60, time.Minute*15),
+	})
+
+	// Register health check endpoint. This route is not authenticated.
+	check := Check{
+		MasterDB: appCtx.MasterDB,
+		Redis:    appCtx.Redis,
+	}
+	app.Handle("GET", "/v1/health", check.Health)
+	app.Handle("GET", "/ping", check.Ping)
+
+	// Register the public keys used to verify auth tokens. This route is not authenticated.
+	jwks := Jwks{
+		Authenticator: appCtx.Authenticator,
+	}
+	app.Handle("GET", "/.well-known/jwks.json", jwks.Keys)
+
+	// Register account endpoints.
+	a := Account{
+		Repository: appCtx.AccountRepo,
+	}
+	app.Handle("GET", "/v1/accounts/:id", a.Read, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("PATCH", "/v1/accounts", a.Update, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionAccountManage))
+
+	// Register account role endpoints, only admins can assign the permissions of roles.
+	ar := AccountRole{
+		Repository: appCtx.AccountRoleRepo,
+	}
+	app.Handle("GET", "/v1/account_roles", ar.Find, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionAccountRead))
+	app.Handle("PATCH", "/v1/account_roles", ar.Set, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
+	app.Handle("DELETE", "/v1/account_roles", ar.Delete, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
+
+	// Register user endpoints.
+	u := User{
+		UserRepo:          appCtx.UserRepo,
+		AuthRepo:          appCtx.AuthRepo,
+		LoginThrottleRepo: appCtx.LoginThrottleRepo,
+	}
+	app.Handle("GET", "/v1/users", u.Find, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("POST", "/v1/users", u.Create, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("GET", "/v1/users/:id", u.Read, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("PATCH", "/v1/users", u.Update, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("PATCH", "/v1/users/password", u.UpdatePassword, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("PATCH", "/v1/users/archive", u.Archive, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("DELETE", "/v1/users/:id", u.Delete, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("PATCH", "/v1/users/switch-account/:account_id", u.SwitchAccount, mid.AuthenticateHeader(appCtx.Authenticator))
+
+	// This route is not authenticated
+	app.Handle("POST", "/v1/oauth/token", u.Token, rateLimitMid)
+
+	// Register user account endpoints.
+	ua := UserAccount{
+		Repository: appCtx.UserAccountRepo,
+	}
+	app.Handle("GET", "/v1/user_accounts", ua.Find, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("POST", "/v1/user_accounts", ua.Create, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("GET", "/v1/user_accounts/:user_id/:account_id", ua.Read, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("PATCH", "/v1/user_accounts", ua.Update, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("PATCH", "/v1/user_accounts/archive", ua.Archive, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("DELETE", "/v1/user_accounts", ua.Delete, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+
+	// Register invite endpoints.
+	inv := Invite{
+		Repository: appCtx.InviteRepo,
+	}
+	app.Handle("POST", "/v1/invites", inv.Send, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+
+	// This route is not authenticated
+	app.Handle("POST", "/v1/invites/accept", inv.Accept)
+
+	// Register signup endpoints.
+	s := Signup{
+		Repository: appCtx.SignupRepo,
+	}
+
+	// This route is not authenticated
+	app.Handle("POST", "/v1/signup", s.Signup, rateLimitMid)
+
+	// Register created asset endpoints.
+	ca := Createasset{
+		Repository: appCtx.CreateassetRepo,
+	}
+	app.Handle("GET", "/v1/createassets", ca.Find, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("POST", "/v1/createassets", ca.Create, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetDraft))
+	app.Handle("GET", "/v1/createassets/:id", ca.Read, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("PATCH", "/v1/createassets", ca.Update, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetDraft))
+	app.Handle("PATCH", "/v1/createassets/archive", ca.Archive, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetManage))
+	app.Handle("POST", "/v1/createassets/:id/mint", ca.Mint, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetMint))
+	app.Handle("POST", "/v1/createassets/:id/unsigned-txn", ca.UnsignedTxn, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetMint))
+	app.Handle("POST", "/v1/createassets/:id/signed-txn", ca.SignedTxn, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetMint))
+	app.Handle("GET", "/v1/createassets/:id/holders", ca.Holders, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("GET", "/v1/createassets/:id/txns", ca.Txns, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("GET", "/v1/createassets/:id/metadata", ca.Metadata, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("POST", "/v1/createassets/:id/issue", ca.Issue, mid.AuthenticateHeader(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetTransfer))
+	app.Handle("POST", "/v1/createassets/:id/txns/:txn_id/unsigned-txn", ca.TxnUnsignedTxn, mid.AuthenticateHeader(appCtx.Authenticator))
+	app.Handle("POST", "/v1/createassets/:id/txns/:txn_id/signed-txn", ca.TxnSignedTxn, mid.AuthenticateHeader(appCtx.Authenticator))
+
+	// Register swagger documentation.
+	// TODO: Add authentication. Current authenticator requires an Authorization header
+	// 		 which breaks the browser experience.
+	app.Handle("GET", "/docs/", saasSwagger.WrapHandler)
+	app.Handle("GET", "/docs/*", saasSwagger.WrapHandler)
+
+	return app
+}
@@ -0,0 +1,705 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"exitor-dapp/internal/login_throttle"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web"
+	"exitor-dapp/internal/platform/web/webcontext"
+	"exitor-dapp/internal/platform/web/weberror"
+	"exitor-dapp/internal/user"
+	"exitor-dapp/internal/user_auth"
+
+	"github.com/gorilla/schema"
+	"github.com/pkg/errors"
+)
+
+// sessionTtl defines the auth token expiration. Sessions started with the password grant can be
+// refreshed for the same duration with access tokens that expire after user_auth.AccessTokenTTL.
+var sessionTtl = time.Hour * 24
+
+// User represents the User API method handler set.
+type User struct {
+	UserRepo          *user.Repository
+	AuthRepo          *user_auth.Repository
+	LoginThrottleRepo *login_throttle.Repository
+
+	// ADD OTHER STATE LIKE THE LOGGER AND CONFIG HERE.
+}
+
+// Find godoc
+// @Summary List users
+// @Description Find returns the existing users in the system.
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param where				query string 	false	"Filter string, example: name = 'Company Name' and email = 'gabi.may@geeksinthewoods.com'"
+// @Param order				query string   	false 	"Order columns separated by comma, example: created_at desc"
+// @Param limit				query integer  	false 	"Limit, example: 10"
+// @Param offset			query integer  	false 	"Offset, example: 20"
+// @Param include-archived query boolean 	false 	"Included Archived, example: false"
+// @Success 200 {array} user.UserResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /users [get]
+func (h *User) Find(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req user.UserFindRequest
+
+	// Handle where query value if set.
+	if v := r.URL.Query().Get("where"); v != "" {
+		where, args, err := web.ExtractWhereArgs(v)
+		if err != nil {
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		req.Where = where
+		req.Args = args
+	}
+
+	// Handle order query value if set.
+	if v := r.URL.Query().Get("order"); v != "" {
+		for _, o := range strings.Split(v, ",") {
+			o = strings.TrimSpace(o)
+			if o != "" {
+				req.Order = append(req.Order, o)
+			}
+		}
+	}
+
+	// Handle limit query value if set.
+	if v := r.URL.Query().Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			err = errors.WithMessagef(err, "unable to parse %s as int for limit param", v)
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		ul := uint(l)
+		req.Limit = &ul
+	}
+
+	// Handle offset query value if set.
+	if v := r.URL.Query().Get("offset"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			err = errors.WithMessagef(err, "unable to parse %s as int for offset param", v)
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		ul := uint(l)
+		req.Offset = &ul
+	}
+
+	// Handle include-archived query value if set.
+	if v := r.URL.Query().Get("include-archived"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			err = errors.WithMessagef(err, "unable to parse %s as boolean for include-archived param", v)
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		req.IncludeArchived = b
+	}
+
+	res, err := h.UserRepo.Find(ctx, claims, req)
+	if err != nil {
+		return err
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
+
+// Read godoc
+// @Summary Get user by ID
+// @Description Read returns the specified user from the system.
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "User ID"
+// @Param include-archived query boolean false "Included Archived, example: false"
+// @Success 200 {object} user.UserResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 404 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /users/{id} [get]
+func (h *User) Read(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Handle include-archived query value if set.
+	var includeArchived bool
+	if v := r.URL.Query().Get("include-archived"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			err = errors.WithMessagef(err, "unable to parse %s as boolean for include-archived param", v)
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+		}
+		includeArchived = b
+	}
+
+	res, err := h.UserRepo.Read(ctx, claims, user.UserReadRequest{
+		ID:              params["id"],
+		IncludeArchived: includeArchived,
+	})
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case user.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusOK)
+}
+
+// Create godoc
+// @Summary Create new user.
+// @Description Create inserts a new user into the system.
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param data body user.UserCreateRequest true "User details"
+// @Success 201 {object} user.UserResponse
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /users [post]
+func (h *User) Create(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req user.UserCreateRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+
+	res, err := h.UserRepo.Create(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case user.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "User: %s", req.Email)
+		}
+	}
+
+	return web.RespondJson(ctx, w, res.Response(ctx), http.StatusCreated)
+}
+
+// Update godoc
+// @Summary Update user by ID
+// @Description Update updates the specified user in the system.
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param data body user.UserUpdateRequest true "Update fields"
+// @Success 204
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /users [patch]
+func (h *User) Update(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req user.UserUpdateRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+
+	err = h.UserRepo.Update(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case user.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", req.ID)
+		}
+	}
+
+	return web.RespondJson(ctx, w, nil, http.StatusNoContent)
+}
+
+// UpdatePassword godoc
+// @Summary Update user password by ID
+// @Description UpdatePassword updates the password for a specified user in the system.
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param data body user.UserUpdatePasswordRequest true "Update fields"
+// @Success 204
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /users/password [patch]
+func (h *User) UpdatePassword(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req user.UserUpdatePasswordRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+
+	err = h.UserRepo.UpdatePassword(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case user.ErrNotFound:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusNotFound))
+		case user.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", req.ID)
+		}
+	}
+
+	return web.RespondJson(ctx, w, nil, http.StatusNoContent)
+}
+
+// Archive godoc
+// @Summary Archive user by ID
+// @Description Archive soft-deletes the specified user from the system.
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param data body user.UserArchiveRequest true "Update fields"
+// @Success 204
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /users/archive [patch]
+func (h *User) Archive(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req user.UserArchiveRequest
+	if err := web.Decode(ctx, r, &req); err != nil {
+		if _, ok := errors.Cause(err).(*weberror.Error); !ok {
+			err = weberror.NewError(ctx, err, http.StatusBadRequest)
+		}
+		return web.RespondJsonError(ctx, w, err)
+	}
+
+	err = h.UserRepo.Archive(ctx, claims, req, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case user.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", req.ID)
+		}
+	}
+
+	return web.RespondJson(ctx, w, nil, http.StatusNoContent)
+}
+
+// Delete godoc
+// @Summary Delete user by ID
+// @Description Delete removes the specified user from the system.
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 403 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /users/{id} [delete]
+func (h *User) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = h.UserRepo.Delete(ctx, claims, user.UserDeleteRequest{ID: params["id"]})
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case user.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "ID: %s", params["id"])
+		}
+	}
+
+	return web.RespondJson(ctx, w, nil, http.StatusNoContent)
+}
+
+// SwitchAccount godoc
+// @Summary Switch account.
+// @Description SwitchAccount updates the auth claims to a new account.
+// @Tags user
+// @Accept  json
+// @Produce  json
+// @Security OAuth2Password
+// @Param account_id path string true "Account ID"
+// @Success 200 {object} user_auth.Token
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 401 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /users/switch-account/{account_id} [patch]
+func (h *User) SwitchAccount(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Tokens issued for a session are short lived and renewed with its refresh token.
+	expires := sessionTtl
+	if claims.SessionID != "" {
+		expires = user_auth.AccessTokenTTL
+	}
+
+	tkn, err := h.AuthRepo.SwitchAccount(ctx, claims, user_auth.SwitchAccountRequest{AccountID: params["account_id"]}, expires, v.Now)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case user_auth.ErrAuthenticationFailure, user_auth.ErrTwoFactorRequired:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusUnauthorized))
+		case user_auth.ErrForbidden, user_auth.ErrTwoFactorEnrollmentRequired:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrap(err, "switch account")
+		}
+	}
+
+	return web.RespondJson(ctx, w, tkn, http.StatusOK)
+}
+
+// Token godoc
+// @Summary Token handles a request to authenticate a user or an api client.
+// @Description Token generates an oauth2 accessToken using Basic Auth with a user's email and password. With the
+// @Description client_credentials grant type the accessToken is generated for the ID and secret of an api client instead.
+// @Description Users are also issued a refreshToken that can be exchanged once with the refresh_token grant type for a new
+// @Description accessToken and refreshToken. Users that enabled two-factor authentication must include the
+// @Description totp_code generated by their authenticator app or one of their recovery codes. Failed sign ins are
+// @Description throttled for each email and IP address, a 429 response includes the Retry-After header.
+// @Tags user
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param grant_type 	formData string false "Grant Type" Enums(password, client_credentials, refresh_token)
+// @Param username 		formData string false "Email"
+// @Param password 		formData string false "Password"
+// @Param client_id 	formData string false "Client ID"
+// @Param client_secret formData string false "Client Secret"
+// @Param refresh_token formData string false "Refresh Token"
+// @Param account_id 	formData string false "Account ID"
+// @Param totp_code 	formData string false "Two-factor authentication code or recovery code"
+// @Param scope 		formData string false "Scope" Enums(user, admin, drafter, approver, compliance, auditor)
+// @Success 200 {object} user_auth.Token
+// @Failure 400 {object} weberror.ErrorResponse
+// @Failure 401 {object} weberror.ErrorResponse
+// @Failure 429 {object} weberror.ErrorResponse
+// @Failure 500 {object} weberror.ErrorResponse
+// @Router /oauth/token [post]
+func (h *User) Token(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	v, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+	}
+
+	// Only the password, client credentials and refresh token grant types are supported.
+	switch gt := r.FormValue("grant_type"); gt {
+	case "", "password":
+	case "client_credentials":
+		return h.clientCredentialsToken(ctx, w, r, v.Now)
+	case "refresh_token":
+		return h.refreshToken(ctx, w, r, v.Now)
+	default:
+		err = errors.Errorf("Invalid grant_type %s, only password, client_credentials and refresh_token are supported", gt)
+		return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+	}
+
+	var req user_auth.OAuth2PasswordRequest
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	if err := decoder.Decode(&req, r.PostForm); err != nil {
+		return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+	}
+
+	// Fallback to Basic Auth when the credentials were not included in the form.
+	if req.Username == "" && req.Password == "" {
+		email, pass, ok := r.BasicAuth()
+		if !ok {
+			err := errors.New("Must provide email and password in Basic auth")
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusUnauthorized))
+		}
+		req.Username = email
+		req.Password = pass
+	}
+
+	// Validate the request before authenticating, the password is not included in any error response.
+	if err := webcontext.Validator().StructCtx(ctx, req); err != nil {
+		if verr, ok := weberror.NewValidationError(ctx, err); ok {
+			return web.RespondJsonError(ctx, w, verr)
+		}
+		return err
+	}
+
+	ipAddress := web.RequestRealIP(r)
+	if net.ParseIP(ipAddress) == nil {
+		ipAddress = ""
+	}
+
+	// Throttle sign ins for the email and the IP address of the client.
+	attempt := login_throttle.LoginAttemptRequest{
+		Email:     req.Username,
+		IPAddress: ipAddress,
+	}
+	if retryAfter, err := h.LoginThrottleRepo.Check(ctx, attempt, v.Now); err != nil {
+		switch errors.Cause(err) {
+		case login_throttle.ErrLocked, login_throttle.ErrTooManyAttempts:
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusTooManyRequests))
+		default:
+			return errors.Wrap(err, "checking login throttle")
+		}
+	}
+
+	tkn, err := h.AuthRepo.Authenticate(ctx, user_auth.AuthenticateRequest{
+		Email:     req.Username,
+		Password:  req.Password,
+		AccountID: req.AccountID,
+		TOTPCode:  req.TOTPCode,
+	}, user_auth.AccessTokenTTL, v.Now, req.Scope...)
+	if err != nil {
+		cause := errors.Cause(err)
+		if cause != user_auth.ErrAuthenticationFailure {
+			// The credentials were not rejected, don't count the attempt as a failure.
+			if rerr := h.LoginThrottleRepo.Release(ctx, attempt, v.Now); rerr != nil {
+				return errors.Wrap(rerr, "releasing login attempt")
+			}
+		}
+
+		switch cause {
+		case user_auth.ErrAuthenticationFailure:
+			// Record the failure, the email is locked out after too many.
+			if ferr := h.LoginThrottleRepo.Failure(ctx, attempt, v.Now); ferr != nil {
+				if errors.Cause(ferr) == login_throttle.ErrLocked {
+					return web.RespondJsonError(ctx, w, weberror.NewError(ctx, ferr, http.StatusTooManyRequests))
+				}
+				return errors.Wrap(ferr, "recording login failure")
+			}
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusUnauthorized))
+		case user_auth.ErrTwoFactorRequired:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusUnauthorized))
+		case user_auth.ErrForbidden, user_auth.ErrTwoFactorEnrollmentRequired:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrap(err, "authenticating")
+		}
+	}
+
+	err = h.LoginThrottleRepo.Success(ctx, attempt, v.Now)
+	if err != nil {
+		return errors.Wrap(err, "clearing login failures")
+	}
+
+	// Start a session for the user so the token can be refreshed and revoked.
+	userAgent := r.UserAgent()
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+
+	tkn, err = h.AuthRepo.StartSession(ctx, tkn, user_auth.SessionRequest{
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+	}, sessionTtl, v.Now)
+	if err != nil {
+		return errors.Wrap(err, "starting session")
+	}
+
+	return web.RespondJson(ctx, w, tkn, http.StatusOK)
+}
+
+// refreshToken handles the refresh token grant of Token, the refresh token provided can't be
+// used again.
+func (h *User) refreshToken(ctx context.Context, w http.ResponseWriter, r *http.Request, now time.Time) error {
+	var req user_auth.OAuth2RefreshTokenRequest
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	if err := decoder.Decode(&req, r.PostForm); err != nil {
+		return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+	}
+
+	if err := webcontext.Validator().StructCtx(ctx, req); err != nil {
+		if verr, ok := weberror.NewValidationError(ctx, err); ok {
+			return web.RespondJsonError(ctx, w, verr)
+		}
+		return err
+	}
+
+	tkn, err := h.AuthRepo.Refresh(ctx, auth.Claims{}, user_auth.RefreshRequest{
+		RefreshToken: req.RefreshToken,
+		AccountID:    req.AccountID,
+	}, user_auth.AccessTokenTTL, now, req.Scope...)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case user_auth.ErrAuthenticationFailure, user_auth.ErrTwoFactorRequired:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusUnauthorized))
+		case user_auth.ErrForbidden, user_auth.ErrTwoFactorEnrollmentRequired:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrap(err, "refreshing token")
+		}
+	}
+
+	return web.RespondJson(ctx, w, tkn, http.StatusOK)
+}
+
+// clientCredentialsToken handles the client credentials grant of Token for api clients.
+func (h *User) clientCredentialsToken(ctx context.Context, w http.ResponseWriter, r *http.Request, now time.Time) error {
+	var req user_auth.OAuth2ClientCredentialsRequest
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	if err := decoder.Decode(&req, r.PostForm); err != nil {
+		return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusBadRequest))
+	}
+
+	// Fallback to Basic Auth when the credentials were not included in the form.
+	if req.ClientID == "" && req.ClientSecret == "" {
+		clientID, secret, ok := r.BasicAuth()
+		if !ok {
+			err := errors.New("Must provide client ID and secret in Basic auth")
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusUnauthorized))
+		}
+		req.ClientID = clientID
+		req.ClientSecret = secret
+	}
+
+	// Validate the request before authenticating, the secret is not included in any error response.
+	if err := webcontext.Validator().StructCtx(ctx, req); err != nil {
+		if verr, ok := weberror.NewValidationError(ctx, err); ok {
+			return web.RespondJsonError(ctx, w, verr)
+		}
+		return err
+	}
+
+	tkn, err := h.AuthRepo.AuthenticateClient(ctx, user_auth.AuthenticateClientRequest{
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+	}, sessionTtl, now, req.Scope...)
+	if err != nil {
+		cause := errors.Cause(err)
+		switch cause {
+		case user_auth.ErrAuthenticationFailure:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusUnauthorized))
+		case user_auth.ErrForbidden:
+			return web.RespondJsonError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+		default:
+			if verr, ok := weberror.NewValidationError(ctx, err); ok {
+				return web.RespondJsonError(ctx, w, verr)
+			}
+
+			return errors.Wrapf(err, "authenticating client %s", req.ClientID)
+		}
+	}
+
+	return web.RespondJson(ctx, w, tkn, http.StatusOK)
+}
@@ -0,0 +1,860 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	_ "net/http/pprof"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"exitor-dapp/cmd/exitor-api/docs"
+	"exitor-dapp/cmd/exitor-api/handlers"
+	"exitor-dapp/internal/account"
+	"exitor-dapp/internal/account/account_preference"
+	"exitor-dapp/internal/account/account_role"
+	"exitor-dapp/internal/account/api_client"
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/createasset"
+	"exitor-dapp/internal/keystore"
+	"exitor-dapp/internal/login_throttle"
+	"exitor-dapp/internal/mid"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/flag"
+	"exitor-dapp/internal/platform/notify"
+	"exitor-dapp/internal/platform/web/webcontext"
+	"exitor-dapp/internal/signup"
+	"exitor-dapp/internal/user"
+	"exitor-dapp/internal/user_account"
+	"exitor-dapp/internal/user_account/invite"
+	"exitor-dapp/internal/user_auth"
+	"exitor-dapp/internal/user_session"
+	"exitor-dapp/internal/user_totp"
+	"exitor-dapp/internal/wallet"
+	"exitor-dapp/internal/webroute"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/go-redis/redis"
+	"github.com/gorilla/securecookie"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"gitlab.com/geeks-accelerator/oss/devops/pkg/devdeploy"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	awstrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/aws/aws-sdk-go/aws"
+	sqltrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql"
+	redistrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-redis/redis"
+	sqlxtrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/jmoiron/sqlx"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// build is the git version of this program. It is set using build flags in the makefile.
+var build = "develop"
+
+// service is the name of the program used for logging, tracing and the
+// the prefix used for loading env variables
+// ie: export WEB_API_ENV=dev
+var service = "WEB_API"
+
+// algorandNetworkConfig defines the config values that can be used to override
+// the defaults for an Algorand network, ie: WEB_API_ALGORAND_TESTNET_API_KEY
+type algorandNetworkConfig struct {
+	AlgodURL     string `envconfig:"ALGOD_URL" example:"https://testnet-algorand.api.purestake.io/ps2"`
+	AlgodToken   string `envconfig:"ALGOD_TOKEN" json:"-"` // don't print
+	IndexerURL   string `envconfig:"INDEXER_URL" example:"https://testnet-algorand.api.purestake.io/idx2"`
+	IndexerToken string `envconfig:"INDEXER_TOKEN" json:"-"` // don't print
+	ApiKeyHeader string `envconfig:"API_KEY_HEADER" example:"X-API-Key"`
+	ApiKey       string `envconfig:"API_KEY" json:"-"` // don't print
+	ExplorerURL  string `envconfig:"EXPLORER_URL" example:"https://goalseeker.purestake.io/algorand/testnet"`
+}
+
+// apply overrides the values of the network config with any values set.
+func (c algorandNetworkConfig) apply(n algosdk.NetworkConfig) algosdk.NetworkConfig {
+	if c.AlgodURL != "" {
+		n.AlgodURL = c.AlgodURL
+	}
+	if c.AlgodToken != "" {
+		n.AlgodToken = c.AlgodToken
+	}
+	if c.IndexerURL != "" {
+		n.IndexerURL = c.IndexerURL
+	}
+	if c.IndexerToken != "" {
+		n.IndexerToken = c.IndexerToken
+	}
+	if c.ApiKeyHeader != "" {
+		n.ApiKeyHeader = c.ApiKeyHeader
+	}
+	if c.ApiKey != "" {
+		n.ApiKey = c.ApiKey
+	}
+	if c.ExplorerURL != "" {
+		n.ExplorerURL = c.ExplorerURL
+	}
+	return n
+}
+
+// @title Exitor API
+// @version 1.0
+// @description This is the API for managing Exitor accounts, users and the Algorand Standard Assets created by them.
+
+// @license.name Apache 2.0
+// @license.url http://www.apache.org/licenses/LICENSE-2.0.html
+
+// @BasePath /v1
+
+// @securitydefinitions.oauth2.password OAuth2Password
+// @tokenUrl /v1/oauth/token
+// @scope.user Grants basic privileges with role of user.
+// @scope.admin Grants administrative privileges with role of admin.
+func main() {
+
+	// =========================================================================
+	// Logging
+	log.SetFlags(log.LstdFlags | log.Lmicroseconds | log.Lshortfile)
+	log.SetPrefix(service + " : ")
+	log := log.New(os.Stdout, log.Prefix(), log.Flags())
+
+	// =========================================================================
+	// Configuration
+	var cfg struct {
+		Env  string `default:"dev" envconfig:"ENV"`
+		HTTP struct {
+			Host         string        `default:"0.0.0.0:3001" envconfig:"HOST"`
+			ReadTimeout  time.Duration `default:"10s" envconfig:"READ_TIMEOUT"`
+			WriteTimeout time.Duration `default:"10s" envconfig:"WRITE_TIMEOUT"`
+		}
+		HTTPS struct {
+			Host         string        `default:"" envconfig:"HOST"`
+			ReadTimeout  time.Duration `default:"5s" envconfig:"READ_TIMEOUT"`
+			WriteTimeout time.Duration `default:"5s" envconfig:"WRITE_TIMEOUT"`
+		}
+		Service struct {
+			Name            string        `default:"web-api" envconfig:"SERVICE_NAME"`
+			BaseUrl         string        `default:"" envconfig:"BASE_URL"  example:"http://api.example.saasstartupkit.com"`
+			HostNames       []string      `envconfig:"HOST_NAMES" example:"alternative-subdomain.example.saasstartupkit.com"`
+			EnableHTTPS     bool          `default:"false" envconfig:"ENABLE_HTTPS"`
+			DebugHost       string        `default:"0.0.0.0:4001" envconfig:"DEBUG_HOST"`
+			ShutdownTimeout time.Duration `default:"5s" envconfig:"SHUTDOWN_TIMEOUT"`
+			ScaleToZero     time.Duration `envconfig:"SCALE_TO_ZERO"`
+		}
+		Project struct {
+			Name              string `default:"" envconfig:"PROJECT_NAME"`
+			SharedTemplateDir string `default:"../../resources/templates/shared" envconfig:"SHARED_TEMPLATE_DIR"`
+			SharedSecretKey   string `default:"" envconfig:"SHARED_SECRET_KEY"`
+			EmailSender       string `default:"test@example.saasstartupkit.com" envconfig:"EMAIL_SENDER"`
+			WebAppBaseUrl     string `default:"http://127.0.0.1:3000" envconfig:"WEB_APP_BASE_URL"  example:"http://example.saasstartupkit.com"`
+		}
+		Redis struct {
+			Host            string        `default:":6379" envconfig:"HOST"`
+			DB              int           `default:"1" envconfig:"DB"`
+			DialTimeout     time.Duration `default:"5s" envconfig:"DIAL_TIMEOUT"`
+			MaxmemoryPolicy string        `envconfig:"MAXMEMORY_POLICY"`
+		}
+		DB struct {
+			Host       string `default:"127.0.0.1:5433" envconfig:"HOST"`
+			User       string `default:"postgres" envconfig:"USER"`
+			Pass       string `default:"postgres" envconfig:"PASS" json:"-"` // don't print
+			Database   string `default:"shared" envconfig:"DATABASE"`
+			Driver     string `default:"postgres" envconfig:"DRIVER"`
+			Timezone   string `default:"utc" envconfig:"TIMEZONE"`
+			DisableTLS bool   `default:"true" envconfig:"DISABLE_TLS"`
+		}
+		Trace struct {
+			Host          string  `default:"127.0.0.1" envconfig:"DD_TRACE_AGENT_HOSTNAME"`
+			Port          int     `default:"8126" envconfig:"DD_TRACE_AGENT_PORT"`
+			AnalyticsRate float64 `default:"0.10" envconfig:"ANALYTICS_RATE"`
+		}
+		Aws struct {
+			AccessKeyID                string `envconfig:"AWS_ACCESS_KEY_ID"`              // WEB_API_AWS_AWS_ACCESS_KEY_ID or AWS_ACCESS_KEY_ID
+			SecretAccessKey            string `envconfig:"AWS_SECRET_ACCESS_KEY" json:"-"` // don't print
+			Region                     string `default:"us-west-2" envconfig:"AWS_DEFAULT_REGION"`
+			S3BucketPrivate            string `envconfig:"S3_BUCKET_PRIVATE"`
+			S3BucketPublic             string `envconfig:"S3_BUCKET_PUBLIC"`
+			SecretsManagerConfigPrefix string `default:"" envconfig:"SECRETS_MANAGER_CONFIG_PREFIX"`
+
+			// Get an AWS session from an implicit source if no explicit
+			// configuration is provided. This is useful for taking advantage of
+			// EC2/ECS instance roles.
+			UseRole bool `envconfig:"AWS_USE_ROLE"`
+		}
+		Auth struct {
+			UseAwsSecretManager bool          `default:"false" envconfig:"USE_AWS_SECRET_MANAGER"`
+			UseDbStorage        bool          `default:"false" envconfig:"USE_DB_STORAGE"`
+			KeyExpiration       time.Duration `default:"3600s" envconfig:"KEY_EXPIRATION"`
+			// TokenTTL is the longest duration tokens are issued for, keys stored in the
+			// database are kept to verify tokens until it has elapsed.
+			TokenTTL       time.Duration `default:"36h" envconfig:"TOKEN_TTL"`
+			RotateInterval time.Duration `default:"60s" envconfig:"ROTATE_INTERVAL"`
+		}
+		Algorand struct {
+			Network string `default:"testnet" envconfig:"NETWORK" example:"testnet"`
+			// Signer is one of external, custodial, keystore or test. Only external and
+			// custodial are permitted in prod, transactions for accounts not owned by
+			// Exitor are then signed offline by the owner of the account.
+			Signer           string                `default:"external" envconfig:"SIGNER" example:"keystore"`
+			KeystoreDir      string                `default:"./.keystore" envconfig:"KEYSTORE_DIR"`
+			KeystorePassword string                `envconfig:"KEYSTORE_PASSWORD" json:"-"` // don't print
+			Mainnet          algorandNetworkConfig `envconfig:"MAINNET"`
+			Testnet          algorandNetworkConfig `envconfig:"TESTNET"`
+			Betanet          algorandNetworkConfig `envconfig:"BETANET"`
+			Sandbox          algorandNetworkConfig `envconfig:"SANDBOX"`
+			// MetadataBaseUrl is the short base URL of the web app the ARC-3 metadata of assets
+			// is served from. The asset URL is limited to 32 bytes, when empty the metadata of
+			// assets is not published.
+			MetadataBaseUrl string `envconfig:"METADATA_BASE_URL" example:"https://exitor.io"`
+		}
+		Keystore struct {
+			// MasterKeyStorage is one of secret, file or aws. The secret storage derives
+			// the master key from the shared secret key of the project. MasterKeyDir is
+			// required by the file storage.
+			MasterKeyStorage    string        `default:"secret" envconfig:"MASTER_KEY_STORAGE" example:"aws"`
+			MasterKeyDir        string        `envconfig:"MASTER_KEY_DIR" example:"/var/lib/exitor/master-keys"`
+			MasterKeyExpiration time.Duration `default:"0s" envconfig:"MASTER_KEY_EXPIRATION" example:"2160h"`
+			// RotateInterval is how often a new master key is generated once the current
+			// one has expired and the keys still using a previous master key are re-encrypted.
+			RotateInterval time.Duration `default:"1h" envconfig:"ROTATE_INTERVAL"`
+			// PreviousSharedSecretKeys are needed to rotate keys after the shared secret
+			// key has changed.
+			PreviousSharedSecretKeys []string `envconfig:"PREVIOUS_SHARED_SECRET_KEYS" json:"-"` // don't print
+		}
+		BuildInfo struct {
+			CiCommitRefName  string `envconfig:"CI_COMMIT_REF_NAME"`
+			CiCommitShortSha string `envconfig:"CI_COMMIT_SHORT_SHA"`
+			CiCommitSha      string `envconfig:"CI_COMMIT_SHA"`
+			CiCommitTag      string `envconfig:"CI_COMMIT_TAG"`
+			CiJobId          string `envconfig:"CI_JOB_ID"`
+			CiJobUrl         string `envconfig:"CI_JOB_URL"`
+			CiPipelineId     string `envconfig:"CI_PIPELINE_ID"`
+			CiPipelineUrl    string `envconfig:"CI_PIPELINE_URL"`
+		}
+	}
+
+	// For additional details refer to https://github.com/kelseyhightower/envconfig
+	if err := envconfig.Process(service, &cfg); err != nil {
+		log.Fatalf("main : Parsing Config : %+v", err)
+	}
+
+	if err := flag.Process(&cfg); err != nil {
+		if err != flag.ErrHelp {
+			log.Fatalf("main : Parsing Command Line : %+v", err)
+		}
+		return // We displayed help.
+	}
+
+	// =========================================================================
+	// Config Validation & Defaults
+
+	// AWS access keys are required, if roles are enabled, remove any placeholders.
+	if cfg.Aws.UseRole {
+		cfg.Aws.AccessKeyID = ""
+		cfg.Aws.SecretAccessKey = ""
+
+		// Get an AWS session from an implicit source if no explicit
+		// configuration is provided. This is useful for taking advantage of
+		// EC2/ECS instance roles.
+		if cfg.Aws.Region == "" {
+			sess := session.Must(session.NewSession())
+			md := ec2metadata.New(sess)
+
+			var err error
+			cfg.Aws.Region, err = md.Region()
+			if err != nil {
+				log.Fatalf("main : Load region of ecs metadata : %+v", err)
+			}
+		}
+	}
+
+	// Set the default AWS Secrets Manager prefix used for name to store config files that will be persisted across
+	// deployments and distributed to each instance of the service running.
+	if cfg.Aws.SecretsManagerConfigPrefix == "" {
+		var pts []string
+		if cfg.Project.Name != "" {
+			pts = append(pts, cfg.Project.Name)
+		}
+		pts = append(pts, cfg.Env)
+
+		cfg.Aws.SecretsManagerConfigPrefix = filepath.Join(pts...)
+	}
+
+	// If base URL is empty, set the default value from the HTTP Host
+	if cfg.Service.BaseUrl == "" {
+		baseUrl := cfg.HTTP.Host
+		if !strings.HasPrefix(baseUrl, "http") {
+			if strings.HasPrefix(baseUrl, "0.0.0.0:") {
+				pts := strings.Split(baseUrl, ":")
+				pts[0] = "127.0.0.1"
+				baseUrl = strings.Join(pts, ":")
+			} else if strings.HasPrefix(baseUrl, ":") {
+				baseUrl = "127.0.0.1" + baseUrl
+			}
+			baseUrl = "http://" + baseUrl
+		}
+		cfg.Service.BaseUrl = baseUrl
+	}
+
+	// When HTTPS is not specifically enabled, but an HTTP host is set, enable HTTPS.
+	if !cfg.Service.EnableHTTPS && cfg.HTTPS.Host != "" {
+		cfg.Service.EnableHTTPS = true
+	}
+
+	// Determine the primary host by parsing host from the base app URL.
+	baseSiteUrl, err := url.Parse(cfg.Service.BaseUrl)
+	if err != nil {
+		log.Fatalf("main : Parse service base URL : %s : %+v", cfg.Service.BaseUrl, err)
+	}
+
+	// Drop any ports from the base app URL.
+	var primaryServiceHost string
+	if strings.Contains(baseSiteUrl.Host, ":") {
+		primaryServiceHost, _, err = net.SplitHostPort(baseSiteUrl.Host)
+		if err != nil {
+			log.Fatalf("main : SplitHostPort : %s : %+v", baseSiteUrl.Host, err)
+		}
+	} else {
+		primaryServiceHost = baseSiteUrl.Host
+	}
+
+	// =========================================================================
+	// Log Service Info
+
+	// Print the build version for our logs. Also expose it under /debug/vars.
+	expvar.NewString("build").Set(build)
+	log.Printf("main : Started : Service Initializing version %q", build)
+	defer log.Println("main : Completed")
+
+	// Print the config for our logs. It's important to any credentials in the config
+	// that could expose a security risk are excluded from being json encoded by
+	// applying the tag `json:"-"` to the struct var.
+	{
+		cfgJSON, err := json.MarshalIndent(cfg, "", "    ")
+		if err != nil {
+			log.Fatalf("main : Marshalling Config to JSON : %+v", err)
+		}
+		log.Printf("main : Config : %v\n", string(cfgJSON))
+	}
+
+	// =========================================================================
+	// Init AWS Session
+	var awsSession *session.Session
+	if cfg.Aws.UseRole {
+		// Get an AWS session from an implicit source if no explicit
+		// configuration is provided. This is useful for taking advantage of
+		// EC2/ECS instance roles.
+		awsSession = session.Must(session.NewSession())
+		if cfg.Aws.Region != "" {
+			awsSession.Config.WithRegion(cfg.Aws.Region)
+		}
+
+		log.Printf("main : AWS : Using role.\n")
+
+	} else if cfg.Aws.AccessKeyID != "" {
+		creds := credentials.NewStaticCredentials(cfg.Aws.AccessKeyID, cfg.Aws.SecretAccessKey, "")
+		awsSession = session.New(&aws.Config{Region: aws.String(cfg.Aws.Region), Credentials: creds})
+
+		log.Printf("main : AWS : Using static credentials\n")
+	}
+
+	// Wrap the AWS session to enable tracing.
+	if awsSession != nil {
+		awsSession = awstrace.WrapSession(awsSession)
+	}
+
+	// =========================================================================
+	// Shared Secret Key used for encrypting sessions and links.
+
+	// Set the secret key if not provided in the config.
+	if cfg.Project.SharedSecretKey == "" {
+
+		// AWS secrets manager ID for storing the session key. This is optional and only will be used
+		// if a valid AWS session is provided.
+		secretID := filepath.Join(cfg.Aws.SecretsManagerConfigPrefix, "SharedSecretKey")
+
+		// If AWS is enabled, check the Secrets Manager for the session key.
+		if awsSession != nil {
+			cfg.Project.SharedSecretKey, err = devdeploy.SecretManagerGetString(awsSession, secretID)
+			if err != nil && errors.Cause(err) != devdeploy.ErrSecreteNotFound {
+				log.Fatalf("main : Session : %+v", err)
+			}
+		}
+
+		// If the session key is still empty, generate a new key.
+		if cfg.Project.SharedSecretKey == "" {
+			cfg.Project.SharedSecretKey = string(securecookie.GenerateRandomKey(32))
+
+			if awsSession != nil {
+				err = devdeploy.SecretManagerPutString(awsSession, secretID, cfg.Project.SharedSecretKey)
+				if err != nil {
+					log.Fatalf("main : Session : %+v", err)
+				}
+			}
+		}
+	}
+
+	// =========================================================================
+	// Start Redis
+	// Ensure the eviction policy on the redis cluster is set correctly.
+	// 		AWS Elastic cache redis clusters by default have the volatile-lru.
+	// 			volatile-lru: evict keys by trying to remove the less recently used (LRU) keys first, but only among keys that have an expire set, in order to make space for the new data added.
+	// 			allkeys-lru: evict keys by trying to remove the less recently used (LRU) keys first, in order to make space for the new data added.
+	//		Recommended to have eviction policy set to allkeys-lru
+	log.Println("main : Started : Initialize Redis")
+	redisClient := redistrace.NewClient(&redis.Options{
+		Addr:        cfg.Redis.Host,
+		DB:          cfg.Redis.DB,
+		DialTimeout: cfg.Redis.DialTimeout,
+	})
+	defer redisClient.Close()
+
+	evictPolicyConfigKey := "maxmemory-policy"
+
+	// if the maxmemory policy is set for redis, make sure its set on the cluster
+	// default not set and will based on the redis config values defined on the server
+	if cfg.Redis.MaxmemoryPolicy != "" {
+		err := redisClient.ConfigSet(evictPolicyConfigKey, cfg.Redis.MaxmemoryPolicy).Err()
+		if err != nil && !strings.Contains(err.Error(), "unknown command") {
+			log.Fatalf("main : redis : ConfigSet maxmemory-policy : %+v", err)
+		}
+	} else {
+		evictPolicy, err := redisClient.ConfigGet(evictPolicyConfigKey).Result()
+		if err != nil && !strings.Contains(err.Error(), "unknown command") {
+			log.Fatalf("main : redis : ConfigGet maxmemory-policy : %+v", err)
+		} else if evictPolicy != nil && len(evictPolicy) > 0 && evictPolicy[1] != "allkeys-lru" {
+			log.Printf("main : redis : ConfigGet maxmemory-policy : recommended to be set to allkeys-lru to avoid OOM")
+		}
+	}
+
+	// =========================================================================
+	// Start Database
+	var dbUrl url.URL
+	{
+		// Query parameters.
+		var q url.Values = make(map[string][]string)
+
+		// Handle SSL Mode
+		if cfg.DB.DisableTLS {
+			q.Set("sslmode", "disable")
+		} else {
+			q.Set("sslmode", "require")
+		}
+
+		q.Set("timezone", cfg.DB.Timezone)
+
+		// Construct url.
+		dbUrl = url.URL{
+			Scheme:   cfg.DB.Driver,
+			User:     url.UserPassword(cfg.DB.User, cfg.DB.Pass),
+			Host:     cfg.DB.Host,
+			Path:     cfg.DB.Database,
+			RawQuery: q.Encode(),
+		}
+	}
+	log.Println("main : Started : Initialize Database")
+
+	// Register informs the sqlxtrace package of the driver that we will be using in our program.
+	// It uses a default service name, in the below case "postgres.db". To use a custom service
+	// name use RegisterWithServiceName.
+	sqltrace.Register(cfg.DB.Driver, &pq.Driver{}, sqltrace.WithServiceName(service))
+	masterDb, err := sqlxtrace.Open(cfg.DB.Driver, dbUrl.String())
+	if err != nil {
+		log.Fatalf("main : Register DB : %s : %+v", cfg.DB.Driver, err)
+	}
+	defer masterDb.Close()
+
+	// Enable AWS to auto pause the DB when no activity.
+	masterDb.SetConnMaxLifetime(time.Hour)
+
+	// =========================================================================
+	// Notify Email
+	var notifyEmail notify.Email
+	if awsSession != nil {
+		// Send emails with AWS SES. Alternative to use SMTP with notify.NewEmailSmtp.
+		notifyEmail, err = notify.NewEmailAws(awsSession, cfg.Project.SharedTemplateDir, cfg.Project.EmailSender)
+		if err != nil {
+			log.Fatalf("main : Notify Email : %+v", err)
+		}
+
+		err = notifyEmail.Verify()
+		if err != nil {
+			switch errors.Cause(err) {
+			case notify.ErrAwsSesIdentityNotVerified:
+				log.Printf("main : Notify Email : %s\n", err)
+			case notify.ErrAwsSesSendingDisabled:
+				log.Printf("main : Notify Email : %s\n", err)
+			default:
+				log.Fatalf("main : Notify Email Verify : %+v", err)
+			}
+		}
+	} else {
+		notifyEmail = notify.NewEmailDisabled()
+	}
+
+	// =========================================================================
+	// Init keystore for custodial accounts and the auth keys stored in the database
+	log.Printf("main : Started : Initialize keystore : %s", cfg.Keystore.MasterKeyStorage)
+	var masterKeys keystore.MasterKeyStorage
+	switch cfg.Keystore.MasterKeyStorage {
+	case "secret":
+		masterKeys, err = keystore.NewMasterKeySecret(cfg.Project.SharedSecretKey, cfg.Keystore.PreviousSharedSecretKeys...)
+	case "file":
+		masterKeys, err = keystore.NewMasterKeyFile(cfg.Keystore.MasterKeyDir, time.Now().UTC(), cfg.Keystore.MasterKeyExpiration)
+	case "aws":
+		secretName := filepath.Join(cfg.Aws.SecretsManagerConfigPrefix, "keystore")
+		masterKeys, err = keystore.NewMasterKeyAws(awsSession, secretName, time.Now().UTC(), cfg.Keystore.MasterKeyExpiration)
+	default:
+		err = errors.Errorf("invalid master key storage %s", cfg.Keystore.MasterKeyStorage)
+	}
+	if err != nil {
+		log.Fatalf("main : Keystore : %+v", err)
+	}
+	keystoreRepo := keystore.NewRepository(masterDb, masterKeys)
+
+	// Keys still using a previous master key are re-encrypted by the web app on start and
+	// by the keystore rotator of every instance.
+
+	// =========================================================================
+	// Init new Authenticator
+	var authenticator *auth.Authenticator
+	if cfg.Auth.UseAwsSecretManager {
+		secretName := filepath.Join(cfg.Aws.SecretsManagerConfigPrefix, "authenticator")
+		authenticator, err = auth.NewAuthenticatorAws(awsSession, secretName, time.Now().UTC(), cfg.Auth.KeyExpiration)
+	} else if cfg.Auth.UseDbStorage {
+		// Keys stored in the database are shared by all the instances of the web app and the API,
+		// they are encrypted with the master keys of the keystore.
+		authenticator, err = auth.NewAuthenticatorDB(masterDb, keystore.NewAuthKeyEncrypter(masterKeys), time.Now().UTC(), cfg.Auth.KeyExpiration, cfg.Auth.TokenTTL)
+	} else {
+		authenticator, err = auth.NewAuthenticatorFile("", time.Now().UTC(), cfg.Auth.KeyExpiration)
+	}
+	if err != nil {
+		log.Fatalf("main : Constructing authenticator : %+v", err)
+	}
+
+	// =========================================================================
+	// Init Algorand client
+	log.Println("main : Started : Initialize Algorand client")
+	algoClient, err := algosdk.New()
+	if err != nil {
+		log.Fatalf("main : Algorand client : %+v", err)
+	}
+	for _, n := range algosdk.DefaultNetworkConfigs() {
+		switch n.Name {
+		case algosdk.NetworkMainnet:
+			n = cfg.Algorand.Mainnet.apply(n)
+		case algosdk.NetworkTestnet:
+			n = cfg.Algorand.Testnet.apply(n)
+		case algosdk.NetworkBetanet:
+			n = cfg.Algorand.Betanet.apply(n)
+		case algosdk.NetworkSandbox:
+			n = cfg.Algorand.Sandbox.apply(n)
+		}
+
+		if err := algoClient.Register(n); err != nil {
+			log.Fatalf("main : Algorand client : Register %s : %+v", n.Name, err)
+		}
+	}
+	if err := algoClient.SelectNetwork(cfg.Algorand.Network); err != nil {
+		log.Fatalf("main : Algorand client : %+v", err)
+	}
+
+	// =========================================================================
+	// Init Algorand signer
+	log.Printf("main : Started : Initialize Algorand signer : %s", cfg.Algorand.Signer)
+	if webcontext.Env(cfg.Env) == webcontext.Env_Prod && cfg.Algorand.Signer != algosdk.SignerExternal && cfg.Algorand.Signer != algosdk.SignerCustodial {
+		log.Fatalf("main : Algorand signer : %s signer is not permitted in %s", cfg.Algorand.Signer, cfg.Env)
+	}
+	var algoSigner algosdk.Signer
+	switch cfg.Algorand.Signer {
+	case algosdk.SignerExternal:
+		algoSigner = algosdk.NewExternalSigner()
+	case algosdk.SignerCustodial:
+		algoSigner = keystoreRepo
+	case algosdk.SignerKeystore:
+		keystoreSigner, err := algosdk.NewLocalKeystoreSigner(cfg.Algorand.KeystoreDir, cfg.Algorand.KeystorePassword)
+		if err != nil {
+			log.Fatalf("main : Algorand signer : %+v", err)
+		}
+
+		addrs, err := keystoreSigner.Addresses()
+		if err != nil {
+			log.Fatalf("main : Algorand signer : %+v", err)
+		}
+		log.Printf("main : Algorand signer : Keystore %s : Accounts %v", cfg.Algorand.KeystoreDir, addrs)
+
+		algoSigner = keystoreSigner
+	case algosdk.SignerTest:
+		testSigner := algosdk.NewTestSigner(1)
+		log.Printf("main : Algorand signer : Generated accounts %v", testSigner.Addresses())
+
+		algoSigner = testSigner
+	default:
+		log.Fatalf("main : Algorand signer : Invalid signer %s, must be one of %v", cfg.Algorand.Signer, algosdk.Signer_Values)
+	}
+
+	// =========================================================================
+	// Init repositories and AppContext
+
+	webRoute, err := webroute.New(cfg.Service.BaseUrl, cfg.Project.WebAppBaseUrl)
+	if err != nil {
+		log.Fatalf("main : WebRoute : %s : %+v", cfg.Service.BaseUrl, err)
+	}
+
+	usrRepo := user.NewRepository(masterDb, webRoute.UserResetPassword, notifyEmail, cfg.Project.SharedSecretKey)
+	usrAccRepo := user_account.NewRepository(masterDb)
+	accRepo := account.NewRepository(masterDb)
+	accPrefRepo := account_preference.NewRepository(masterDb)
+	accRoleRepo := account_role.NewRepository(masterDb)
+	walletRepo := wallet.NewRepository(masterDb)
+	apiClientRepo := api_client.NewRepository(masterDb)
+	userSessionRepo := user_session.NewRepository(masterDb)
+	userTotpRepo := user_totp.NewRepository(masterDb)
+	loginThrottleRepo := login_throttle.NewRepository(masterDb, redisClient, webRoute.UserUnlock, notifyEmail, cfg.Project.SharedSecretKey)
+	authRepo := user_auth.NewRepository(masterDb, authenticator, usrRepo, usrAccRepo, accPrefRepo, accRoleRepo, walletRepo, apiClientRepo, userSessionRepo, userTotpRepo)
+	signupRepo := signup.NewRepository(masterDb, usrRepo, usrAccRepo, accRepo)
+	inviteRepo := invite.NewRepository(masterDb, usrRepo, usrAccRepo, accRepo, webRoute.UserInviteAccept, notifyEmail, cfg.Project.SharedSecretKey)
+	createassetRepo := createasset.NewRepository(masterDb, algoClient, algoSigner, cfg.Algorand.MetadataBaseUrl)
+
+	// Reject the access tokens of revoked sessions on every request.
+	authenticator.SessionChecker = userSessionRepo.CheckSession
+
+	appCtx := &handlers.AppContext{
+		Log:               log,
+		Env:               cfg.Env,
+		MasterDB:          masterDb,
+		Redis:             redisClient,
+		UserRepo:          usrRepo,
+		UserAccountRepo:   usrAccRepo,
+		AccountRepo:       accRepo,
+		AccountRoleRepo:   accRoleRepo,
+		AuthRepo:          authRepo,
+		SignupRepo:        signupRepo,
+		InviteRepo:        inviteRepo,
+		CreateassetRepo:   createassetRepo,
+		LoginThrottleRepo: loginThrottleRepo,
+		Authenticator:     authenticator,
+	}
+
+	// =========================================================================
+	// Load middlewares that need to be configured specific for the service.
+
+	// Init redirect middleware to ensure all requests go to the primary domain contained in the base URL.
+	if primaryServiceHost != "127.0.0.1" && primaryServiceHost != "localhost" {
+		redirect := mid.DomainNameRedirect(mid.DomainNameRedirectConfig{
+			RedirectConfig: mid.RedirectConfig{
+				Code: http.StatusMovedPermanently,
+				Skipper: func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) bool {
+					if r.URL.Path == "/ping" {
+						return true
+					}
+					return false
+				},
+			},
+			DomainName:   primaryServiceHost,
+			HTTPSEnabled: cfg.Service.EnableHTTPS,
+		})
+		appCtx.PostAppMiddleware = append(appCtx.PostAppMiddleware, redirect)
+	}
+
+	// Add the translator middleware for localization.
+	appCtx.PostAppMiddleware = append(appCtx.PostAppMiddleware, mid.Translator(webcontext.UniversalTranslator()))
+
+	// =========================================================================
+	// Swagger
+	// The API docs are served by the service at /docs, the host is the one of the base URL.
+	docs.SwaggerInfo.Host = baseSiteUrl.Host
+	if baseSiteUrl.Scheme != "" {
+		docs.SwaggerInfo.Schemes = []string{baseSiteUrl.Scheme}
+	}
+
+	// =========================================================================
+	// Start Tracing Support
+	th := fmt.Sprintf("%s:%d", cfg.Trace.Host, cfg.Trace.Port)
+	log.Printf("main : Tracing Started : %s", th)
+	sr := tracer.NewRateSampler(cfg.Trace.AnalyticsRate)
+	tracer.Start(tracer.WithAgentAddr(th), tracer.WithSampler(sr))
+	defer tracer.Stop()
+
+	// =========================================================================
+	// Start Debug Service. Not concerned with shutting this down when the
+	// application is being shutdown.
+	//
+	// /debug/vars - Added to the default mux by the expvars package.
+	// /debug/pprof - Added to the default mux by the net/http/pprof package.
+	if cfg.Service.DebugHost != "" {
+		go func() {
+			log.Printf("main : Debug Listening %s", cfg.Service.DebugHost)
+			log.Printf("main : Debug Listener closed : %v", http.ListenAndServe(cfg.Service.DebugHost, http.DefaultServeMux))
+		}()
+	}
+
+	// =========================================================================
+	// ECS Task registration for services that don't use an AWS Elastic Load Balancer.
+	err = devdeploy.EcsServiceTaskInit(log, awsSession)
+	if err != nil {
+		log.Fatalf("main : Ecs Service Task init : %+v", err)
+	}
+
+	// Optional to scale down the service after X seconds. This is useful for a stage env when after 1 hours, review
+	// should have been completed. The service will automatically scale back to zero for avoid extra costs. The
+	// deploy stage can always be executed again to bring the service back online.
+	if cfg.Service.ScaleToZero.Seconds() > 0 {
+		log.Printf("Scaling service to 0 tasks after %v seconds\n", cfg.Service.ScaleToZero.Seconds())
+
+		exitTimer := time.NewTimer(cfg.Service.ScaleToZero)
+		go func() {
+			<-exitTimer.C
+			log.Printf("exitTimer expired, scaling awsSession task to 0")
+			err = devdeploy.EcsServiceSetDesiredCount(log, awsSession, 0)
+			if err != nil {
+				log.Fatalf("main : Ecs Service scale down : %+v", err)
+			}
+		}()
+	}
+
+	// =========================================================================
+	// Start Auth Key Rotator
+	// Generates new keys before the current one expires and loads the keys of other instances.
+	rotatorCtx, rotatorCancel := context.WithCancel(context.Background())
+	defer rotatorCancel()
+
+	go auth.NewKeyRotator(authenticator, log, cfg.Auth.RotateInterval).Run(rotatorCtx)
+
+	// =========================================================================
+	// Start Keystore Key Rotator
+	// Generates a new master key once the current one expires and re-encrypts the keys
+	// of custodial accounts with it.
+	go keystore.NewKeyRotator(keystoreRepo, log, cfg.Keystore.RotateInterval).Run(rotatorCtx)
+
+	// =========================================================================
+	// Start API Service
+
+	// Make a channel to listen for an interrupt or terminate signal from the OS.
+	// Use a buffered channel because the signal package requires it.
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	// Make a channel to listen for errors coming from the listener. Use a
+	// buffered channel so the goroutine can exit if we don't collect this error.
+	serverErrors := make(chan error, 1)
+
+	// Make an list of HTTP servers for both HTTP and HTTPS requests.
+	var httpServers []*http.Server
+
+	// Start the HTTP service listening for requests.
+	if cfg.HTTP.Host != "" {
+		api := &http.Server{
+			Addr:           cfg.HTTP.Host,
+			Handler:        handlers.API(shutdown, appCtx),
+			ReadTimeout:    cfg.HTTP.ReadTimeout,
+			WriteTimeout:   cfg.HTTP.WriteTimeout,
+			MaxHeaderBytes: 1 << 20,
+		}
+		httpServers = append(httpServers, api)
+
+		go func() {
+			log.Printf("main : API Listening %s", cfg.HTTP.Host)
+			serverErrors <- api.ListenAndServe()
+		}()
+	}
+
+	// Start the HTTPS service listening for requests with an SSL Cert auto generated with Let's Encrypt.
+	if cfg.HTTPS.Host != "" {
+		api := &http.Server{
+			Addr:           cfg.HTTPS.Host,
+			Handler:        handlers.API(shutdown, appCtx),
+			ReadTimeout:    cfg.HTTPS.ReadTimeout,
+			WriteTimeout:   cfg.HTTPS.WriteTimeout,
+			MaxHeaderBytes: 1 << 20,
+		}
+
+		// Generate a unique list of hostnames.
+		var hosts []string
+		if primaryServiceHost != "" {
+			hosts = append(hosts, primaryServiceHost)
+		}
+		for _, h := range cfg.Service.HostNames {
+			h = strings.TrimSpace(h)
+			if h != "" && h != primaryServiceHost {
+				hosts = append(hosts, h)
+			}
+		}
+
+		// Enable autocert to store certs via Secret Manager.
+		secretPrefix := filepath.Join(cfg.Aws.SecretsManagerConfigPrefix, "autocert")
+
+		// Local file cache to reduce requests hitting Secret Manager.
+		localCache := autocert.DirCache(os.TempDir())
+
+		cache, err := devdeploy.NewSecretManagerAutocertCache(log, awsSession, secretPrefix, localCache)
+		if err != nil {
+			log.Fatalf("main : HTTPS : %+v", err)
+		}
+
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(hosts...),
+			Cache:      cache,
+		}
+		api.TLSConfig = &tls.Config{GetCertificate: m.GetCertificate}
+		api.TLSConfig.NextProtos = append(api.TLSConfig.NextProtos, acme.ALPNProto)
+
+		httpServers = append(httpServers, api)
+
+		go func() {
+			log.Printf("main : API Listening %s with SSL cert for hosts %s", cfg.HTTPS.Host, strings.Join(hosts, ", "))
+			serverErrors <- api.ListenAndServeTLS("", "")
+		}()
+	}
+
+	// =========================================================================
+	// Shutdown
+
+	// Blocking main and waiting for shutdown.
+	select {
+	case err := <-serverErrors:
+		log.Fatalf("main : Error starting server: %+v", err)
+
+	case sig := <-shutdown:
+		log.Printf("main : %v : Start shutdown..", sig)
+
+		// Stop rotating keys.
+		rotatorCancel()
+
+		// Create context for Shutdown call.
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Service.ShutdownTimeout)
+		defer cancel()
+
+		// Handle closing connections for both possible HTTP servers.
+		for _, api := range httpServers {
+
+			// Asking listener to shutdown and load shed.
+			err := api.Shutdown(ctx)
+			if err != nil {
+				log.Printf("main : Graceful shutdown did not complete in %v : %v", cfg.Service.ShutdownTimeout, err)
+				err = api.Close()
+			}
+
+		}
+
+		// Log the status of this shutdown.
+		switch {
+		case sig == syscall.SIGSTOP:
+			log.Fatal("main : Integrity issue caused shutdown")
+		case err != nil:
+			log.Fatalf("main : Could not stop server gracefully : %+v", err)
+		}
+	}
+}
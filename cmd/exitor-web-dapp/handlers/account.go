@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	"exitor-dapp/internal/account"
@@ -58,9 +59,10 @@ func (h *Account) View(ctx context.Context, w http.ResponseWriter, r *http.Reque
 
 type AccountUpdateRequest struct {
 	account.AccountUpdateRequest
-	PreferenceDatetimeFormat string
-	PreferenceDateFormat     string
-	PreferenceTimeFormat     string
+	PreferenceDatetimeFormat   string
+	PreferenceDateFormat       string
+	PreferenceTimeFormat       string
+	PreferenceRequireTwoFactor bool
 }
 
 // Update handles allowing the current user to update their account.
@@ -89,9 +91,10 @@ func (h *Account) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 		}
 
 		var (
-			preferenceDatetimeFormat string
-			preferenceDateFormat     string
-			preferenceTimeFormat     string
+			preferenceDatetimeFormat   string
+			preferenceDateFormat       string
+			preferenceTimeFormat       string
+			preferenceRequireTwoFactor bool
 		)
 
 		for _, pref := range prefs {
@@ -102,6 +105,8 @@ func (h *Account) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 				preferenceDateFormat = pref.Value
 			case account_preference.AccountPreference_Time_Format:
 				preferenceTimeFormat = pref.Value
+			case account_preference.AccountPreference_Require_Two_Factor:
+				preferenceRequireTwoFactor = pref.Value == "true"
 			}
 		}
 
@@ -119,6 +124,14 @@ func (h *Account) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 			}
 			req.ID = claims.Audience
 
+			// Ensure the admin doesn't lock themselves out by requiring a code they can't provide.
+			if req.PreferenceRequireTwoFactor && !preferenceRequireTwoFactor && !claims.TwoFactor {
+				err = errors.New("Two-factor authentication is required")
+				data["error"] = weberror.NewErrorMessage(ctx, err, http.StatusBadRequest,
+					"Sign in with two-factor authentication before requiring it for all users of the account.")
+				return false, nil
+			}
+
 			err = h.AccountRepo.Update(ctx, claims, req.AccountUpdateRequest, ctxValues.Now)
 			if err != nil {
 				switch errors.Cause(err) {
@@ -201,6 +214,22 @@ func (h *Account) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 				}
 			}
 
+			if preferenceRequireTwoFactor != req.PreferenceRequireTwoFactor {
+				err = h.AccountPrefRepo.Set(ctx, claims, account_preference.AccountPreferenceSetRequest{
+					AccountID: claims.Audience,
+					Name:      account_preference.AccountPreference_Require_Two_Factor,
+					Value:     strconv.FormatBool(req.PreferenceRequireTwoFactor),
+				}, ctxValues.Now)
+				if err != nil {
+					if verr, ok := weberror.NewValidationError(ctx, err); ok {
+						data["validationErrors"] = verr.(*weberror.Error)
+						return false, nil
+					} else {
+						return false, err
+					}
+				}
+			}
+
 			// Update the access token to include the updated claims.
 			if updateClaims {
 				ctx, err = updateContextClaims(ctx, h.Authenticator, claims)
@@ -244,6 +273,7 @@ func (h *Account) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 			req.PreferenceDatetimeFormat = preferenceDatetimeFormat
 			req.PreferenceDateFormat = preferenceDateFormat
 			req.PreferenceTimeFormat = preferenceTimeFormat
+			req.PreferenceRequireTwoFactor = preferenceRequireTwoFactor
 		}
 
 		data["account"] = acc.Response(ctx)
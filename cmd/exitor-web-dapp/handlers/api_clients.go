@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"exitor-dapp/internal/account/api_client"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web"
+	"exitor-dapp/internal/platform/web/webcontext"
+	"exitor-dapp/internal/platform/web/weberror"
+	"exitor-dapp/internal/user_account"
+
+	"github.com/pkg/errors"
+)
+
+// ApiClients represents the api client method handler set.
+type ApiClients struct {
+	ApiClientRepo *api_client.Repository
+	Renderer      web.Renderer
+}
+
+func urlApiClientsIndex() string {
+	return fmt.Sprintf("/account/api-clients")
+}
+
+// Index handles listing the api clients of the account. Admins can create new clients, rotate
+// their secret and revoke them. The secret is displayed on the page returned by the request
+// that generated it and can't be retrieved afterwards.
+func (h *ApiClients) Index(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := new(api_client.ApiClientCreateRequest)
+	data := make(map[string]interface{})
+	f := func() (bool, error) {
+		if r.Method == http.MethodPost {
+			err := r.ParseForm()
+			if err != nil {
+				return false, err
+			}
+
+			switch r.PostForm.Get("action") {
+			case "create":
+				req.AccountID = claims.Audience
+				req.Name = strings.TrimSpace(r.PostForm.Get("Name"))
+				req.Scopes = r.PostForm["Scopes"]
+
+				m, err := h.ApiClientRepo.Create(ctx, claims, *req, ctxValues.Now)
+				if err != nil {
+					switch errors.Cause(err) {
+					case api_client.ErrForbidden:
+						return false, err
+					default:
+						if verr, ok := weberror.NewValidationError(ctx, err); ok {
+							data["validationErrors"] = verr.(*weberror.Error)
+							return false, nil
+						} else {
+							return false, err
+						}
+					}
+				}
+				data["created"] = m.Response(ctx)
+
+				// Reset the form so the client is not created twice.
+				req = new(api_client.ApiClientCreateRequest)
+
+			case "rotate":
+				m, err := h.ApiClientRepo.Rotate(ctx, claims, api_client.ApiClientRotateRequest{
+					ID: r.PostForm.Get("id"),
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+				data["created"] = m.Response(ctx)
+
+			case "revoke":
+				err = h.ApiClientRepo.Revoke(ctx, claims, api_client.ApiClientRevokeRequest{
+					ID: r.PostForm.Get("id"),
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+
+				webcontext.SessionFlashSuccess(ctx,
+					"API Client Revoked",
+					"The client can no longer be used to authenticate.")
+
+				return true, web.Redirect(ctx, w, r, urlApiClientsIndex(), http.StatusFound)
+			}
+		}
+
+		return false, nil
+	}
+
+	end, err := f()
+	if err != nil {
+		return web.RenderError(ctx, w, r, err, h.Renderer, TmplLayoutBase, TmplContentErrorGeneric, web.MIMETextHTMLCharsetUTF8)
+	} else if end {
+		return nil
+	}
+
+	res, err := h.ApiClientRepo.Find(ctx, claims, api_client.ApiClientFindRequest{
+		Order: []string{"created_at"},
+	})
+	if err != nil {
+		return err
+	}
+	data["apiClients"] = res.Response(ctx)
+
+	data["form"] = req
+
+	// Scopes are limited to the roles of users.
+	var selectedScopes []interface{}
+	for _, s := range req.Scopes {
+		selectedScopes = append(selectedScopes, s)
+	}
+	data["scopes"] = web.NewEnumMultiResponse(ctx, selectedScopes, user_account.UserAccountRole_ValuesInterface()...)
+
+	if verr, ok := weberror.NewValidationError(ctx, webcontext.Validator().Struct(api_client.ApiClientCreateRequest{})); ok {
+		data["validationDefaults"] = verr.(*weberror.Error)
+	}
+
+	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "account-api-clients.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
+}
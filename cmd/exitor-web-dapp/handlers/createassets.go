@@ -1,22 +1,23 @@
 package handlers
-// To change Createassets to createassets
+
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/algorand/go-algorand-sdk/client/algod"
-	"github.com/algorand/go-algorand-sdk/crypto"
-	"github.com/algorand/go-algorand-sdk/mnemonic"
-	"github.com/algorand/go-algorand-sdk/transaction"
-
-	"exitor-dapp/internal/Createasset"
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/createasset"
 	"exitor-dapp/internal/platform/auth"
 	"exitor-dapp/internal/platform/datatable"
 	"exitor-dapp/internal/platform/web"
 	"exitor-dapp/internal/platform/web/webcontext"
 	"exitor-dapp/internal/platform/web/weberror"
+	"exitor-dapp/internal/wallet"
 
 	"github.com/gorilla/schema"
 	"github.com/pkg/errors"
@@ -26,8 +27,10 @@ import (
 // Createassets represents the Createasset API method handler set.
 type Createassets struct {
 	CreateassetRepo *createasset.Repository
-	Redis         *redis.Client
-	Renderer      web.Renderer
+	WalletRepo      *wallet.Repository
+	AlgoClient      *algosdk.Client
+	Redis           *redis.Client
+	Renderer        web.Renderer
 }
 
 func urlCreateassetsIndex() string {
@@ -38,12 +41,24 @@ func urlCreateassetsCreate() string {
 	return fmt.Sprintf("/createassets/create")
 }
 
-func urlCreateassetsView(createdassetID string) string {
-	return fmt.Sprintf("/createassets/%s", createdassetID)
+func urlCreateassetsView(createdAssetID string) string {
+	return fmt.Sprintf("/createassets/%s", createdAssetID)
+}
+
+func urlCreateassetsTxn(createdAssetID string) string {
+	return fmt.Sprintf("/createassets/%s/txn", createdAssetID)
+}
+
+func urlCreateassetsManage(createdAssetID string) string {
+	return fmt.Sprintf("/createassets/%s/manage", createdAssetID)
+}
+
+func urlCreateassetsHolding(createdAssetID string) string {
+	return fmt.Sprintf("/createassets/%s/holding", createdAssetID)
 }
 
-func urlCreateassetsUpdate(createdassetID string) string {
-	return fmt.Sprintf("/createassets/%s/update", createdassetID)
+func urlCreateassetsAssetTxn(createdAssetID, txnID string) string {
+	return fmt.Sprintf("/createassets/%s/txns/%s", createdAssetID, txnID)
 }
 
 // Index handles listing all the Createassets for the current account.
@@ -54,7 +69,17 @@ func (h *Createassets) Index(ctx context.Context, w http.ResponseWriter, r *http
 		return err
 	}
 
-	statusOpts := web.NewEnumResponse(ctx, nil, Createasset.Createassetstatus_ValuesInterface()...)
+	mintStatusOpts := web.NewEnumResponse(ctx, nil, createasset.CreatedAssetMintStatus_ValuesInterface()...)
+
+	mintStatusFilterItems := []datatable.FilterOptionItem{}
+	for _, opt := range mintStatusOpts.Options {
+		mintStatusFilterItems = append(mintStatusFilterItems, datatable.FilterOptionItem{
+			Display: opt.Title,
+			Value:   opt.Value,
+		})
+	}
+
+	statusOpts := web.NewEnumResponse(ctx, nil, createasset.CreatedAssetStatus_ValuesInterface()...)
 
 	statusFilterItems := []datatable.FilterOptionItem{}
 	for _, opt := range statusOpts.Options {
@@ -64,35 +89,70 @@ func (h *Createassets) Index(ctx context.Context, w http.ResponseWriter, r *http
 		})
 	}
 
-	// Below, we will transform to represent the parameters required for asset creation on Algorand
 	fields := []datatable.DisplayField{
 		{Field: "id", Title: "ID", Visible: false, Searchable: true, Orderable: true, Filterable: false},
-		{Field: "assetname", Title: "AssetName", Visible: true, Searchable: true, Orderable: true, Filterable: true, FilterPlaceholder: "filter Name"},
+		{Field: "asset_name", Title: "Asset Name", Visible: true, Searchable: true, Orderable: true, Filterable: true, FilterPlaceholder: "filter Name"},
+		{Field: "unit_name", Title: "Unit Name", Visible: true, Searchable: true, Orderable: true, Filterable: true, FilterPlaceholder: "filter Unit"},
+		{Field: "asset_index", Title: "Asset ID", Visible: true, Searchable: true, Orderable: true, Filterable: false},
+		{Field: "network", Title: "Network", Visible: true, Searchable: true, Orderable: true, Filterable: false},
+		{Field: "mint_status", Title: "Mint Status", Visible: true, Searchable: true, Orderable: true, Filterable: true, FilterPlaceholder: "All Mint Statuses", FilterItems: mintStatusFilterItems},
 		{Field: "status", Title: "Status", Visible: true, Searchable: true, Orderable: true, Filterable: true, FilterPlaceholder: "All Statuses", FilterItems: statusFilterItems},
 		{Field: "updated_at", Title: "Last Updated", Visible: true, Searchable: true, Orderable: true, Filterable: false},
 		{Field: "created_at", Title: "Created", Visible: true, Searchable: true, Orderable: true, Filterable: false},
 	}
 
-	mapFunc := func(q *Createasset.Createasset, cols []datatable.DisplayField) (resp []datatable.ColumnValue, err error) {
+	mapFunc := func(q *createasset.CreatedAsset, cols []datatable.DisplayField) (resp []datatable.ColumnValue, err error) {
 		for i := 0; i < len(cols); i++ {
 			col := cols[i]
 			var v datatable.ColumnValue
 			switch col.Field {
 			case "id":
 				v.Value = fmt.Sprintf("%s", q.ID)
-			case "assetname":
-				v.Value = q.Name
+			case "asset_name":
+				v.Value = q.AssetName
 				v.Formatted = fmt.Sprintf("<a href='%s'>%s</a>", urlCreateassetsView(q.ID), v.Value)
+			case "unit_name":
+				v.Value = q.UnitName
+				v.Formatted = v.Value
+			case "asset_index":
+				if q.AssetIndex > 0 {
+					v.Value = fmt.Sprintf("%d", q.AssetIndex)
+				}
+				v.Formatted = v.Value
+			case "network":
+				v.Value = q.Network
+				v.Formatted = v.Value
+			case "mint_status":
+				v.Value = q.MintStatus.String()
+
+				var subStatusClass string
+				var subStatusIcon string
+				switch q.MintStatus {
+				case createasset.CreatedAssetMintStatus_Draft:
+					subStatusClass = "text-gray"
+					subStatusIcon = "far fa-circle"
+				case createasset.CreatedAssetMintStatus_Submitted:
+					subStatusClass = "text-aqua"
+					subStatusIcon = "fas fa-circle-notch"
+				case createasset.CreatedAssetMintStatus_Confirmed:
+					subStatusClass = "text-green"
+					subStatusIcon = "fas fa-check-circle"
+				case createasset.CreatedAssetMintStatus_Failed:
+					subStatusClass = "text-red"
+					subStatusIcon = "fas fa-exclamation-circle"
+				}
+
+				v.Formatted = fmt.Sprintf("<span class='cell-font-status %s'><i class='%s mr-1'></i>%s</span>", subStatusClass, subStatusIcon, web.EnumValueTitle(v.Value))
 			case "status":
 				v.Value = q.Status.String()
 
 				var subStatusClass string
 				var subStatusIcon string
 				switch q.Status {
-				case Createasset.Createassetstatus_Active:
+				case createasset.CreatedAssetStatus_Active:
 					subStatusClass = "text-green"
 					subStatusIcon = "far fa-dot-circle"
-				case Createasset.Createassetstatus_Disabled:
+				case createasset.CreatedAssetStatus_Disabled:
 					subStatusClass = "text-orange"
 					subStatusIcon = "far fa-circle"
 				}
@@ -116,7 +176,7 @@ func (h *Createassets) Index(ctx context.Context, w http.ResponseWriter, r *http
 	}
 
 	loadFunc := func(ctx context.Context, sorting string, fields []datatable.DisplayField) (resp [][]datatable.ColumnValue, err error) {
-		res, err := h.CreateassetRepo.Find(ctx, claims, Createasset.CreateassetFindRequest{
+		res, err := h.CreateassetRepo.Find(ctx, claims, createasset.CreatedAssetFindRequest{
 			Where: "account_id = ?",
 			Args:  []interface{}{claims.Audience},
 			Order: strings.Split(sorting, ","),
@@ -128,7 +188,7 @@ func (h *Createassets) Index(ctx context.Context, w http.ResponseWriter, r *http
 		for _, a := range res {
 			l, err := mapFunc(a, fields)
 			if err != nil {
-				return resp, errors.Wrapf(err, "Failed to map Createasset for display.")
+				return resp, errors.Wrapf(err, "Failed to map created asset for display.")
 			}
 
 			resp = append(resp, l)
@@ -154,90 +214,120 @@ func (h *Createassets) Index(ctx context.Context, w http.ResponseWriter, r *http
 	}
 
 	data := map[string]interface{}{
-		"datatable":           dt.Response(),
+		"datatable":             dt.Response(),
 		"urlCreateassetsCreate": urlCreateassetsCreate(),
 	}
 
 	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "createassets-index.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
 }
 
-const algodAddress = ""
-const psToken = ""
-
-// Create a throw-away account for this example - 
-// check that it has funds before running the program
-const mn = "..." // To include mnemonic
-const ownerAddress = "..."// can also be derived from mnemonic, I will hardcode to make it easier
-
-// Will create an algod client in the main.go file
-// It has to pass
-
-
-
-
-type SignTx struct {
-	Renderer web.Renderer
-}
+// Create handles creating a new asset for the account. The asset is stored as a draft
+// until its asset create transaction is signed and submitted to the network.
+func (h *Createassets) Create(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
 
-// Prompt the user for a passphrase,
-// so we can sign transaction
-/*func (s SignTx) FlashForPassphrase(ctx contex.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
-	if r.Method == http.MethodGet {
-		webcontext.SessionFlashInfo(ctx,
-		"Please enter your Algorand Account Passphrase")
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
 	}
 
-	data := make(map[string]interface{})
-
-	type SignAssetTx struct {
-		walletAddress string `json:"algorand_wallet_address" validate:"required"`
-		passphrase string `json:"passphrase" validate:"required"`
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
 	}
 
-	req := new(SignAssetTx)
-	f := func() error {
-
+	//
+	req := new(createasset.CreatedAssetCreateRequest)
+	data := make(map[string]interface{})
+	f := func() (bool, error) {
 		if r.Method == http.MethodPost {
 			err := r.ParseForm()
 			if err != nil {
-					return err
+				return false, err
 			}
 
 			decoder := schema.NewDecoder()
+			decoder.IgnoreUnknownKeys(true)
+
 			if err := decoder.Decode(req, r.PostForm); err != nil {
-					return err
+				return false, err
 			}
+			req.AccountID = claims.Audience
+
+			// The form always includes rows for legal documents, skip the ones left blank.
+			var docs []createasset.CreatedAssetDocument
+			for _, d := range req.LegalDocuments {
+				if d.Name != "" || d.URL != "" || d.SHA256 != "" {
+					docs = append(docs, d)
+				}
+			}
+			req.LegalDocuments = docs
 
-			if err := webcontext.Validator().Struct(req); err != nil {
-					if ne, ok := weberror.NewValidationError(ctx, err); ok {
-							data["validationErrors"] = ne.(*weberror.Error)
-							return nil
+			m, err := h.CreateassetRepo.Create(ctx, claims, *req, ctxValues.Now)
+			if err != nil {
+				switch errors.Cause(err) {
+				default:
+					if verr, ok := weberror.NewValidationError(ctx, err); ok {
+						data["validationErrors"] = verr.(*weberror.Error)
+						return false, nil
 					} else {
-							return err
+						return false, err
 					}
+				}
 			}
+
+			// Display a success message to the user.
+			webcontext.SessionFlashSuccess(ctx,
+				"Asset Created",
+				"Asset successfully saved as a draft. Download the asset create transaction, sign it offline and upload the signed transaction to mint the asset.")
+
+			return true, web.Redirect(ctx, w, r, urlCreateassetsView(m.ID), http.StatusFound)
 		}
 
+		return false, nil
+	}
+
+	end, err := f()
+	if err != nil {
+		return web.RenderError(ctx, w, r, err, h.Renderer, TmplLayoutBase, TmplContentErrorGeneric, web.MIMETextHTMLCharsetUTF8)
+	} else if end {
 		return nil
 	}
 
-	if err := f(); err != nil {
-			return web.RenderError(ctx, w, r, err, h.Renderer, TmplLayoutBase, TmplContentErrorGeneric, web.MIMETextHTMLCharsetUTF8)
+	// Default the creator to the issuer of the account.
+	if r.Method != http.MethodPost {
+		issuer, err := h.WalletRepo.DefaultIssuer(ctx, claims, claims.Audience)
+		if err == nil {
+			req.CreatorAddress = issuer.Address
+		} else if errors.Cause(err) != wallet.ErrNotFound {
+			return err
+		}
+	}
+
+	// Display empty rows for legal documents to be added.
+	for len(req.LegalDocuments) < 3 {
+		req.LegalDocuments = append(req.LegalDocuments, createasset.CreatedAssetDocument{})
+	}
+
+	// Suggest the multisig accounts of the account for the roles of the asset.
+	multisigs, err := h.WalletRepo.FindMultisigs(ctx, claims, claims.Audience)
+	if err != nil {
+		return err
 	}
+	data["multisigs"] = multisigs.Response(ctx)
 
 	data["form"] = req
 
-	if verr, ok := weberror.NewValidationError(ctx, webcontext.Validator().Struct(SignAssetTx{})); ok {
-			data["validationDefaults"] = verr.(*weberror.Error)
+	if verr, ok := weberror.NewValidationError(ctx, webcontext.Validator().Struct(createasset.CreatedAssetCreateRequest{})); ok {
+		data["validationDefaults"] = verr.(*weberror.Error)
 	}
 
-	return h.Renderer.Render(ctx, w, r,TmplLayoutBase, "flash-for-passphrase-message.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
+	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "createassets-create.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
 }
-*/
 
-// Create handles creating a new Asset for the account.
-// Also includes the Algorand Implementation for Asset Creation which has to succeed as well
-func (h *Createassets) Create(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+// View handles displaying a created asset and the progress of minting it.
+func (h *Createassets) View(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	createdAssetID := params["created_asset_id"]
 
 	ctxValues, err := webcontext.ContextValues(ctx)
 	if err != nil {
@@ -249,8 +339,6 @@ func (h *Createassets) Create(ctx context.Context, w http.ResponseWriter, r *htt
 		return err
 	}
 
-	//
-	req := new(Createasset.CreateassetCreateRequest)
 	data := make(map[string]interface{})
 	f := func() (bool, error) {
 		if r.Method == http.MethodPost {
@@ -259,58 +347,70 @@ func (h *Createassets) Create(ctx context.Context, w http.ResponseWriter, r *htt
 				return false, err
 			}
 
-			decoder := schema.NewDecoder()
-			decoder.IgnoreUnknownKeys(true)
+			switch r.PostForm.Get("action") {
+			case "archive":
+				err = h.CreateassetRepo.Archive(ctx, claims, createasset.CreatedAssetArchiveRequest{
+					ID: createdAssetID,
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
 
-			if err := decoder.Decode(req, r.PostForm); err != nil {
-				return false, err
-			}
-			req.AccountID = claims.Audience
+				webcontext.SessionFlashSuccess(ctx,
+					"Asset Archive",
+					"Asset successfully archive.")
 
-			usr, err := h.CreateassetRepo.Create(ctx, claims, *req, ctxValues.Now)
-			if err != nil {
-				switch errors.Cause(err) {
-				default:
-					if verr, ok := weberror.NewValidationError(ctx, err); ok {
-						data["validationErrors"] = verr.(*weberror.Error)
-						return false, nil
-					} else {
+				return true, web.Redirect(ctx, w, r, urlCreateassetsIndex(), http.StatusFound)
+			case "mint":
+				_, err = h.CreateassetRepo.Mint(ctx, claims, createasset.CreatedAssetMintRequest{
+					ID: createdAssetID,
+				}, ctxValues.Now)
+				if err != nil {
+					switch errors.Cause(err) {
+					case createasset.ErrForbidden:
 						return false, err
+					case algosdk.ErrExternalSigningRequired, algosdk.ErrSignerAccountNotFound:
+						webcontext.SessionFlashInfo(ctx,
+							"Sign Offline",
+							"The creator account can't be signed for by Exitor. Download the unsigned transaction, sign it offline and upload the signed transaction.")
+					default:
+						webcontext.SessionFlashError(ctx,
+							"Asset Mint Failed",
+							errors.Cause(err).Error())
 					}
+				} else {
+					webcontext.SessionFlashSuccess(ctx,
+						"Asset Submitted",
+						"Asset successfully submitted to the network, it will be available once the transaction is confirmed.")
 				}
-			}
-
-			// We make a transaction during the createasset operation
-			coinTotalIssuance := uint64(1000000)
-			coinDecimalsForDisplay := uint32(0)
-			accountsAreDefaultFrozen := false
-			managerAddress := ownerAddress
-			assetReserveAddress := ""
-			addressWithFreezingPrivileges := ownerAddress
-			addressWithClawbackPrivileges := ownerAddress
-			assetUnitName :+ "biztoken"
-			assetUrl := ""
-			assetMetadataHash := ""
-			tx, err := transaction.MakeAssetCreateTxn(ownerAddress, txParams.Fee, txParams.LastRound, txParams.LastRound+10, nil,
-			txParams.GenesisID, base64.stdEncoding.EncodeToString(txParams.GenesisHash),
-			coinTotalIssuance, coinDecimalsForDisplay, accountsAreDefaultFrozen, managerAddress,
-			assetReserveAddress, addressWithFreezingPrivileges, addressWithClawbackPrivileges,
-			assetUnitName, assetName, assetUrl, assetMetadataHash)
 
-			if err != nil {
-				fmt.Printf("Error creating transaction: %s\n", err)
-				return
-			}
-
-			// Sign the Transaction
-			_, bytes, err := crypto.SignTran
+				return true, web.Redirect(ctx, w, r, urlCreateassetsView(createdAssetID), http.StatusFound)
+			case "reconcile":
+				_, err = h.CreateassetRepo.Reconcile(ctx, claims, createdAssetID, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
 
-			// Display a success message to the Createasset.
-			webcontext.SessionFlashSuccess(ctx,
-				"Createasset Created",
-				"Createasset successfully created.")
+				return true, web.Redirect(ctx, w, r, urlCreateassetsView(createdAssetID), http.StatusFound)
+			case "sync_holders":
+				holders, err := h.CreateassetRepo.SyncHolders(ctx, claims, createdAssetID, ctxValues.Now)
+				if err != nil {
+					switch errors.Cause(err) {
+					case createasset.ErrForbidden:
+						return false, err
+					default:
+						webcontext.SessionFlashError(ctx,
+							"Holders Sync Failed",
+							errors.Cause(err).Error())
+					}
+				} else {
+					webcontext.SessionFlashSuccess(ctx,
+						"Holders Synced",
+						fmt.Sprintf("Found %d holders of the asset on the network.", len(holders)))
+				}
 
-			return true, web.Redirect(ctx, w, r, urlCreateassetsView(usr.ID), http.StatusFound)
+				return true, web.Redirect(ctx, w, r, urlCreateassetsView(createdAssetID), http.StatusFound)
+			}
 		}
 
 		return false, nil
@@ -323,19 +423,239 @@ func (h *Createassets) Create(ctx context.Context, w http.ResponseWriter, r *htt
 		return nil
 	}
 
-	data["form"] = req
+	m, err := h.CreateassetRepo.ReadByID(ctx, claims, createdAssetID)
+	if err != nil {
+		return err
+	}
 
-	if verr, ok := weberror.NewValidationError(ctx, webcontext.Validator().Struct(Createasset.CreateassetCreateRequest{})); ok {
-		data["validationDefaults"] = verr.(*weberror.Error)
+	// The holders of the asset are displayed as a datatable loaded from the last snapshot.
+	fields := []datatable.DisplayField{
+		{Field: "address", Title: "Address", Visible: true, Searchable: true, Orderable: true, Filterable: true, FilterPlaceholder: "filter Address"},
+		{Field: "amount", Title: "Amount", Visible: true, Searchable: false, Orderable: true, Filterable: false},
+		{Field: "share", Title: "Share", Visible: true, Searchable: false, Orderable: true, Filterable: false, OrderFields: []string{"amount"}},
+		{Field: "frozen", Title: "Frozen", Visible: true, Searchable: true, Orderable: true, Filterable: true, FilterPlaceholder: "All", FilterItems: []datatable.FilterOptionItem{
+			{Display: "Frozen", Value: "true"},
+			{Display: "Not Frozen", Value: "false"},
+		}},
+		{Field: "opted_in_round", Title: "Opted In Round", Visible: true, Searchable: false, Orderable: true, Filterable: false},
+		{Field: "round", Title: "Snapshot Round", Visible: false, Searchable: false, Orderable: true, Filterable: false},
 	}
 
-	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "createassets-create.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
+	mapFunc := func(q *createasset.CreatedAssetHolder, cols []datatable.DisplayField) (resp []datatable.ColumnValue, err error) {
+		for i := 0; i < len(cols); i++ {
+			col := cols[i]
+			var v datatable.ColumnValue
+			switch col.Field {
+			case "address":
+				v.Value = q.Address
+				v.Formatted = fmt.Sprintf("<span class='text-monospace'>%s</span>", v.Value)
+			case "amount":
+				v.Value = fmt.Sprintf("%d", q.Amount)
+				v.Formatted = v.Value
+			case "share":
+				if m.Total > 0 {
+					v.Value = fmt.Sprintf("%.2f%%", float64(q.Amount)*100/float64(m.Total))
+				}
+				v.Formatted = v.Value
+			case "frozen":
+				v.Value = fmt.Sprintf("%v", q.Frozen)
+				if q.Frozen {
+					v.Formatted = "<span class='cell-font-status text-aqua'><i class='fas fa-snowflake mr-1'></i>Frozen</span>"
+				}
+			case "opted_in_round":
+				v.Value = fmt.Sprintf("%d", q.OptedInRound)
+				v.Formatted = v.Value
+			case "round":
+				v.Value = fmt.Sprintf("%d", q.Round)
+				v.Formatted = v.Value
+			default:
+				return resp, errors.Errorf("Failed to map value for %s.", col.Field)
+			}
+			resp = append(resp, v)
+		}
+
+		return resp, nil
+	}
+
+	loadFunc := func(ctx context.Context, sorting string, fields []datatable.DisplayField) (resp [][]datatable.ColumnValue, err error) {
+		res, err := h.CreateassetRepo.FindHolders(ctx, claims, createasset.CreatedAssetHolderFindRequest{
+			CreatedAssetID: createdAssetID,
+			Order:          strings.Split(sorting, ","),
+		})
+		if err != nil {
+			return resp, err
+		}
+
+		for _, a := range res {
+			l, err := mapFunc(a, fields)
+			if err != nil {
+				return resp, errors.Wrapf(err, "Failed to map created asset holder for display.")
+			}
+
+			resp = append(resp, l)
+		}
+
+		return resp, nil
+	}
+
+	dt, err := datatable.New(ctx, w, r, h.Redis, fields, loadFunc)
+	if err != nil {
+		return err
+	}
+
+	if dt.HasCache() {
+		return nil
+	}
+
+	if ok, err := dt.Render(); ok {
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+	data["datatable"] = dt.Response()
+
+	data["createdAsset"] = m.Response(ctx)
+	data["urlCreateassetsView"] = urlCreateassetsView(createdAssetID)
+	data["urlCreateassetsTxn"] = urlCreateassetsTxn(createdAssetID)
+	data["urlCreateassetsManage"] = urlCreateassetsManage(createdAssetID)
+	data["urlCreateassetsHolding"] = urlCreateassetsHolding(createdAssetID)
+
+	// Link to the transaction and asset on the block explorer for the network.
+	if network, err := h.AlgoClient.Network(m.Network); err == nil {
+		if m.TxID != "" {
+			data["urlExplorerTransaction"] = network.TransactionUrl(m.TxID)
+		}
+		if m.AssetIndex > 0 {
+			data["urlExplorerAsset"] = network.AssetUrl(m.AssetIndex)
+		}
+	}
+
+	// Check the hosted metadata document still matches the hash bound on-chain.
+	if m.MintStatus == createasset.CreatedAssetMintStatus_Confirmed && m.URL != "" && len(m.MetadataHash) > 0 {
+		res, err := h.CreateassetRepo.VerifyMetadata(ctx, claims, createdAssetID)
+		if err == nil {
+			data["metadataVerification"] = res
+		} else {
+			switch errors.Cause(err) {
+			case createasset.ErrMetadataNotPublished, createasset.ErrAssetNotMinted:
+			default:
+				return err
+			}
+		}
+	}
+
+	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "createassets-view.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
 }
 
-// View handles displaying a Createasset.
-func (h *Createassets) View(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+// Metadata serves the ARC-3 metadata document published for the asset with the index on the
+// current network. The document is public so it can be fetched by wallets and explorers.
+func (h *Createassets) Metadata(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	assetIndex, err := strconv.ParseUint(params["asset_index"], 10, 64)
+	if err != nil {
+		return weberror.NewError(ctx, errors.Errorf("invalid asset index %s", params["asset_index"]), http.StatusNotFound)
+	}
+
+	network, err := h.AlgoClient.Current()
+	if err != nil {
+		return err
+	}
+
+	dat, err := h.CreateassetRepo.ReadMetadata(ctx, network.Name, assetIndex)
+	if err != nil {
+		if errors.Cause(err) == createasset.ErrNotFound {
+			return weberror.NewError(ctx, err, http.StatusNotFound)
+		}
+		return err
+	}
+
+	return web.Respond(ctx, w, dat, http.StatusOK, web.MIMEApplicationJSONCharsetUTF8)
+}
+
+// Txn handles offline signing of the asset create transaction. The unsigned transaction
+// is downloaded as msgpack, or base64 when format=base64 is requested, and the signed
+// transaction is uploaded as a file or pasted as base64.
+func (h *Createassets) Txn(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	createdAssetID := params["created_asset_id"]
+
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if r.Method == http.MethodGet {
+		utx, err := h.CreateassetRepo.UnsignedCreateTxn(ctx, claims, createasset.CreatedAssetMintRequest{
+			ID: createdAssetID,
+		}, ctxValues.Now)
+		if err != nil {
+			return err
+		}
+
+		if r.URL.Query().Get("format") == "base64" {
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", utx.Filename()+".b64"))
+			return web.RespondText(ctx, w, utx.Base64(), http.StatusOK)
+		}
+
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", utx.Filename()))
+		return web.Respond(ctx, w, utx.Txn, http.StatusOK, web.MIMEOctetStream)
+	}
 
-	CreateassetID := params["Createasset_id"]
+	f := func() error {
+		req := createasset.CreatedAssetSignedTxnRequest{
+			ID: createdAssetID,
+		}
+
+		req.SignedTxn, err = signedTxnUpload(r)
+		if err != nil {
+			return err
+		}
+
+		_, err = h.CreateassetRepo.SubmitSignedTxn(ctx, claims, req, ctxValues.Now)
+		if err != nil {
+			switch errors.Cause(err) {
+			case createasset.ErrSignedTxnMismatch, createasset.ErrSignedTxnInvalidSignature, createasset.ErrInvalidMintStatus:
+				webcontext.SessionFlashError(ctx,
+					"Signed Transaction Rejected",
+					err.Error())
+				return nil
+			default:
+				if _, ok := weberror.NewValidationError(ctx, err); ok {
+					webcontext.SessionFlashError(ctx,
+						"Signed Transaction Required",
+						"Upload the signed transaction file or paste the base64 encoded signed transaction.")
+					return nil
+				}
+				return err
+			}
+		}
+
+		webcontext.SessionFlashSuccess(ctx,
+			"Asset Submitted",
+			"Signed transaction successfully submitted to the network, the asset will be available once the transaction is confirmed.")
+
+		return nil
+	}
+
+	if err := f(); err != nil {
+		return web.RenderError(ctx, w, r, err, h.Renderer, TmplLayoutBase, TmplContentErrorGeneric, web.MIMETextHTMLCharsetUTF8)
+	}
+
+	return web.Redirect(ctx, w, r, urlCreateassetsView(createdAssetID), http.StatusFound)
+}
+
+// Manage handles reconfiguring, freezing, clawing back and destroying a created asset
+// once it has been minted. Transactions that Exitor can't sign for are kept as drafts
+// to be signed offline.
+func (h *Createassets) Manage(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	createdAssetID := params["created_asset_id"]
 
 	ctxValues, err := webcontext.ContextValues(ctx)
 	if err != nil {
@@ -355,21 +675,213 @@ func (h *Createassets) View(ctx context.Context, w http.ResponseWriter, r *http.
 				return false, err
 			}
 
-			switch r.PostForm.Get("action") {
-			case "archive":
-				err = h.CreateassetRepo.Archive(ctx, claims, Createasset.CreateassetArchiveRequest{
-					ID: CreateassetID,
+			var txn *createasset.CreatedAssetTxn
+			switch action := r.PostForm.Get("action"); action {
+			case "reconfigure":
+				req := createasset.CreatedAssetConfigRequest{
+					ID: createdAssetID,
+				}
+
+				// Only the addresses included in the form are changed, a blank address is cleared.
+				for field, addr := range map[string]**string{
+					"ManagerAddress":  &req.ManagerAddress,
+					"ReserveAddress":  &req.ReserveAddress,
+					"FreezeAddress":   &req.FreezeAddress,
+					"ClawbackAddress": &req.ClawbackAddress,
+				} {
+					if vals, ok := r.PostForm[field]; ok && len(vals) > 0 {
+						v := strings.TrimSpace(vals[0])
+						*addr = &v
+					}
+				}
+
+				txn, err = h.CreateassetRepo.Reconfigure(ctx, claims, req, ctxValues.Now)
+			case "freeze", "unfreeze":
+				txn, err = h.CreateassetRepo.Freeze(ctx, claims, createasset.CreatedAssetFreezeRequest{
+					ID:      createdAssetID,
+					Address: strings.TrimSpace(r.PostForm.Get("Address")),
+					Frozen:  action == "freeze",
 				}, ctxValues.Now)
+			case "clawback":
+				req := createasset.CreatedAssetClawbackRequest{}
+
+				decoder := schema.NewDecoder()
+				decoder.IgnoreUnknownKeys(true)
+
+				if err := decoder.Decode(&req, r.PostForm); err != nil {
+					return false, err
+				}
+				req.ID = createdAssetID
+				req.Address = strings.TrimSpace(req.Address)
+				req.ReceiverAddress = strings.TrimSpace(req.ReceiverAddress)
+
+				txn, err = h.CreateassetRepo.Clawback(ctx, claims, req, ctxValues.Now)
+			case "transfer", "issue":
+				// The holder is selected by the confirmed opt-in of the receiving user.
+				var optIn *createasset.CreatedAssetTxn
+				optIn, err = h.CreateassetRepo.ReadTxn(ctx, claims, createasset.CreatedAssetTxnReadRequest{
+					CreatedAssetID: createdAssetID,
+					ID:             r.PostForm.Get("OptInID"),
+				})
 				if err != nil {
 					return false, err
+				} else if optIn.Type != createasset.CreatedAssetTxnType_OptIn || optIn.UserID == nil {
+					return false, errors.WithStack(createasset.ErrNotFound)
+				}
+
+				decoder := schema.NewDecoder()
+				decoder.IgnoreUnknownKeys(true)
+
+				if action == "transfer" {
+					req := createasset.CreatedAssetTransferRequest{}
+					if err := decoder.Decode(&req, r.PostForm); err != nil {
+						return false, err
+					}
+					req.ID = createdAssetID
+					req.UserID = *optIn.UserID
+					req.Address = optIn.SenderAddress
+
+					txn, err = h.CreateassetRepo.Transfer(ctx, claims, req, ctxValues.Now)
+					break
+				}
+
+				req := createasset.CreatedAssetIssueRequest{}
+				if err := decoder.Decode(&req, r.PostForm); err != nil {
+					return false, err
+				}
+				req.ID = createdAssetID
+				req.UserID = *optIn.UserID
+				req.Address = optIn.SenderAddress
+
+				var group createasset.CreatedAssetTxns
+				group, err = h.CreateassetRepo.Issue(ctx, claims, req, ctxValues.Now)
+				if err == nil {
+					// Report the first member of the group that still has to be signed offline.
+					txn = group[0]
+					for _, t := range group {
+						if t.Status == createasset.CreatedAssetTxnStatus_Draft && len(t.SignedTxn) == 0 {
+							txn = t
+							break
+						}
+					}
+				}
+			case "sync_whitelist":
+				var issued createasset.CreatedAssetTxns
+				issued, err = h.CreateassetRepo.SyncWhitelist(ctx, claims, createdAssetID, ctxValues.Now)
+				if err != nil {
+					break
 				}
 
 				webcontext.SessionFlashSuccess(ctx,
-					"Createasset Archive",
-					"Createasset successfully archive.")
+					"Whitelist Synced",
+					fmt.Sprintf("%d freeze transactions issued for the holdings to match the users approved by KYC.", len(issued)))
+
+				return true, web.Redirect(ctx, w, r, urlCreateassetsManage(createdAssetID), http.StatusFound)
+			case "vesting_grant":
+				// The holder is selected by the confirmed opt-in of the user, same as a transfer.
+				var optIn *createasset.CreatedAssetTxn
+				optIn, err = h.CreateassetRepo.ReadTxn(ctx, claims, createasset.CreatedAssetTxnReadRequest{
+					CreatedAssetID: createdAssetID,
+					ID:             r.PostForm.Get("OptInID"),
+				})
+				if err != nil {
+					return false, err
+				} else if optIn.Type != createasset.CreatedAssetTxnType_OptIn || optIn.UserID == nil {
+					return false, errors.WithStack(createasset.ErrNotFound)
+				}
 
-				return true, web.Redirect(ctx, w, r, urlCreateassetsIndex(), http.StatusFound)
+				var req createasset.CreatedAssetVestingGrantCreateRequest
+				req, err = vestingGrantForm(ctx, r)
+				if err != nil {
+					return false, err
+				}
+				req.CreatedAssetID = createdAssetID
+				req.UserID = *optIn.UserID
+				req.Address = optIn.SenderAddress
+
+				_, err = h.CreateassetRepo.CreateVestingGrant(ctx, claims, req, ctxValues.Now)
+				if err != nil {
+					break
+				}
+
+				webcontext.SessionFlashSuccess(ctx,
+					"Vesting Grant Created",
+					"The vested units will be released from the reserve as each tranche vests.")
+
+				return true, web.Redirect(ctx, w, r, urlCreateassetsManage(createdAssetID), http.StatusFound)
+			case "release_grant":
+				txn, err = h.CreateassetRepo.ReleaseVestingGrant(ctx, claims, createasset.CreatedAssetVestingGrantRequest{
+					CreatedAssetID: createdAssetID,
+					ID:             r.PostForm.Get("grant_id"),
+				}, ctxValues.Now)
+			case "accelerate_grant", "cancel_grant":
+				req := createasset.CreatedAssetVestingGrantRequest{
+					CreatedAssetID: createdAssetID,
+					ID:             r.PostForm.Get("grant_id"),
+				}
+
+				msg := "The accelerated units will be released from the reserve."
+				if action == "accelerate_grant" {
+					_, err = h.CreateassetRepo.AccelerateVestingGrant(ctx, claims, req, ctxValues.Now)
+				} else {
+					_, err = h.CreateassetRepo.CancelVestingGrant(ctx, claims, req, ctxValues.Now)
+					msg = "Vesting stopped, the units vested before cancelling will still be released."
+				}
+				if err != nil {
+					break
+				}
+
+				webcontext.SessionFlashSuccess(ctx, "Vesting Grant Updated", msg)
+
+				return true, web.Redirect(ctx, w, r, urlCreateassetsManage(createdAssetID), http.StatusFound)
+			case "destroy":
+				txn, err = h.CreateassetRepo.Destroy(ctx, claims, createasset.CreatedAssetDestroyRequest{
+					ID: createdAssetID,
+				}, ctxValues.Now)
+			case "reconcile":
+				_, err = h.CreateassetRepo.ReconcileTxn(ctx, claims, createasset.CreatedAssetTxnReadRequest{
+					CreatedAssetID: createdAssetID,
+					ID:             r.PostForm.Get("txn_id"),
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+
+				return true, web.Redirect(ctx, w, r, urlCreateassetsManage(createdAssetID), http.StatusFound)
+			default:
+				return false, nil
 			}
+
+			if err != nil {
+				switch errors.Cause(err) {
+				case createasset.ErrForbidden:
+					return false, err
+				case createasset.ErrAssetNotMinted, createasset.ErrAssetDestroyed, createasset.ErrAssetAddressNotSet, createasset.ErrNotOptedIn,
+					createasset.ErrNotWhitelisted, createasset.ErrVestingTotalExceeded, createasset.ErrVestingGrantCancelled,
+					createasset.ErrVestingGrantAccelerated, createasset.ErrNothingToRelease:
+					webcontext.SessionFlashError(ctx,
+						"Transaction Not Allowed",
+						err.Error())
+				default:
+					if verr, ok := weberror.NewValidationError(ctx, err); ok {
+						weberror.SessionFlashError(ctx, verr)
+					} else {
+						webcontext.SessionFlashError(ctx,
+							"Transaction Failed",
+							errors.Cause(err).Error())
+					}
+				}
+			} else if txn.Status == createasset.CreatedAssetTxnStatus_Draft {
+				webcontext.SessionFlashInfo(ctx,
+					"Sign Offline",
+					fmt.Sprintf("Exitor can't sign for %s. Download the unsigned transaction, sign it offline and upload the signed transaction.", txn.SenderAddress))
+			} else {
+				webcontext.SessionFlashSuccess(ctx,
+					"Transaction Submitted",
+					"Transaction successfully submitted to the network, the asset will be updated once the transaction is confirmed.")
+			}
+
+			return true, web.Redirect(ctx, w, r, urlCreateassetsManage(createdAssetID), http.StatusFound)
 		}
 
 		return false, nil
@@ -382,21 +894,78 @@ func (h *Createassets) View(ctx context.Context, w http.ResponseWriter, r *http.
 		return nil
 	}
 
-	prj, err := h.CreateassetRepo.ReadByID(ctx, claims, CreateassetID)
+	m, err := h.CreateassetRepo.ReadByID(ctx, claims, createdAssetID)
+	if err != nil {
+		return err
+	}
+	data["createdAsset"] = m.Response(ctx)
+
+	txns, err := h.CreateassetRepo.FindTxns(ctx, claims, createdAssetID)
+	if err != nil {
+		return err
+	}
+
+	type txnRow struct {
+		*createasset.CreatedAssetTxnResponse
+		URL            string
+		URLExplorerTxn string
+	}
+
+	network, _ := h.AlgoClient.Network(m.Network)
+
+	var (
+		rows    []txnRow
+		holders []*createasset.CreatedAssetTxnResponse
+	)
+	for _, t := range txns {
+		if t.Type == createasset.CreatedAssetTxnType_OptIn && t.Status == createasset.CreatedAssetTxnStatus_Confirmed {
+			holders = append(holders, t.Response(ctx))
+		}
+
+		row := txnRow{
+			CreatedAssetTxnResponse: t.Response(ctx),
+			URL:                     urlCreateassetsAssetTxn(createdAssetID, t.ID),
+		}
+		if network != nil && t.TxID != "" {
+			row.URLExplorerTxn = network.TransactionUrl(t.TxID)
+		}
+		rows = append(rows, row)
+	}
+	data["txns"] = rows
+	data["holders"] = holders
+
+	// Only the users approved by KYC can hold assets that are frozen by default.
+	if m.DefaultFrozen {
+		whitelist, err := h.CreateassetRepo.FindWhitelist(ctx, claims, createdAssetID, ctxValues.Now)
+		if err != nil {
+			return err
+		}
+		data["whitelist"] = whitelist
+	}
+
+	grants, err := h.CreateassetRepo.FindVestingGrants(ctx, claims, createdAssetID)
+	if err != nil {
+		return err
+	}
+	data["vestingGrants"] = grants.Response(ctx, ctxValues.Now)
+
+	multisigs, err := h.WalletRepo.FindMultisigs(ctx, claims, claims.Audience)
 	if err != nil {
 		return err
 	}
-	data["Createasset"] = prj.Response(ctx)
-	data["urlCreateassetsView"] = urlCreateassetsView(CreateassetID)
-	data["urlCreateassetsUpdate"] = urlCreateassetsUpdate(CreateassetID)
+	data["multisigs"] = multisigs.Response(ctx)
 
-	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "Createassets-view.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
+	data["urlCreateassetsView"] = urlCreateassetsView(createdAssetID)
+	data["urlCreateassetsManage"] = urlCreateassetsManage(createdAssetID)
+
+	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "createassets-manage.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
 }
 
-// Update handles updating a Createasset for the account.
-func (h *Createassets) Update(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+// Holding handles an investor opting in to a created asset with their address and lists
+// the units of the asset they have been sent.
+func (h *Createassets) Holding(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
 
-	CreateassetID := params["Createasset_id"]
+	createdAssetID := params["created_asset_id"]
 
 	ctxValues, err := webcontext.ContextValues(ctx)
 	if err != nil {
@@ -408,8 +977,6 @@ func (h *Createassets) Update(ctx context.Context, w http.ResponseWriter, r *htt
 		return err
 	}
 
-	//
-	req := new(Createasset.CreateassetUpdateRequest)
 	data := make(map[string]interface{})
 	f := func() (bool, error) {
 		if r.Method == http.MethodPost {
@@ -418,33 +985,43 @@ func (h *Createassets) Update(ctx context.Context, w http.ResponseWriter, r *htt
 				return false, err
 			}
 
-			decoder := schema.NewDecoder()
-			decoder.IgnoreUnknownKeys(true)
-
-			if err := decoder.Decode(req, r.PostForm); err != nil {
-				return false, err
+			if r.PostForm.Get("action") != "opt_in" {
+				return false, nil
 			}
-			req.ID = CreateassetID
 
-			err = h.CreateassetRepo.Update(ctx, claims, *req, ctxValues.Now)
+			txn, err := h.CreateassetRepo.OptIn(ctx, claims, createasset.CreatedAssetOptInRequest{
+				ID:      createdAssetID,
+				UserID:  claims.Subject,
+				Address: strings.TrimSpace(r.PostForm.Get("Address")),
+			}, ctxValues.Now)
 			if err != nil {
 				switch errors.Cause(err) {
+				case createasset.ErrForbidden:
+					return false, err
+				case createasset.ErrAssetNotMinted, createasset.ErrAssetDestroyed:
+					webcontext.SessionFlashError(ctx,
+						"Opt-In Not Allowed",
+						err.Error())
 				default:
 					if verr, ok := weberror.NewValidationError(ctx, err); ok {
-						data["validationErrors"] = verr.(*weberror.Error)
-						return false, nil
+						weberror.SessionFlashError(ctx, verr)
 					} else {
-						return false, err
+						webcontext.SessionFlashError(ctx,
+							"Opt-In Failed",
+							errors.Cause(err).Error())
 					}
 				}
+			} else if txn.Status == createasset.CreatedAssetTxnStatus_Draft {
+				webcontext.SessionFlashInfo(ctx,
+					"Sign Offline",
+					"Download the opt-in transaction, sign it with your wallet and upload the signed transaction.")
+			} else {
+				webcontext.SessionFlashSuccess(ctx,
+					"Opt-In Submitted",
+					"Your address will be able to receive the asset once the opt-in is confirmed.")
 			}
 
-			// Display a success message to the Createasset.
-			webcontext.SessionFlashSuccess(ctx,
-				"Createasset Updated",
-				"Createasset successfully updated.")
-
-			return true, web.Redirect(ctx, w, r, urlCreateassetsView(req.ID), http.StatusFound)
+			return true, web.Redirect(ctx, w, r, urlCreateassetsHolding(createdAssetID), http.StatusFound)
 		}
 
 		return false, nil
@@ -457,23 +1034,225 @@ func (h *Createassets) Update(ctx context.Context, w http.ResponseWriter, r *htt
 		return nil
 	}
 
-	prj, err := h.CreateassetRepo.ReadByID(ctx, claims, CreateassetID)
+	m, err := h.CreateassetRepo.ReadByID(ctx, claims, createdAssetID)
 	if err != nil {
 		return err
 	}
-	data["Createasset"] = prj.Response(ctx)
+	data["createdAsset"] = m.Response(ctx)
 
-	data["urlCreateassetsView"] = urlCreateassetsView(CreateassetID)
+	txns, err := h.CreateassetRepo.FindUserTxns(ctx, claims, createdAssetID, claims.Subject)
+	if err != nil {
+		return err
+	}
 
-	if req.ID == "" {
-		req.Name = &prj.Name
-		req.Status = &prj.Status
+	type txnRow struct {
+		*createasset.CreatedAssetTxnResponse
+		URL string
 	}
-	data["form"] = req
 
-	if verr, ok := weberror.NewValidationError(ctx, webcontext.Validator().Struct(Createasset.CreateassetUpdateRequest{})); ok {
-		data["validationDefaults"] = verr.(*weberror.Error)
+	var (
+		rows     []txnRow
+		received uint64
+	)
+	for _, t := range txns {
+		if t.Type == createasset.CreatedAssetTxnType_Transfer && t.Status == createasset.CreatedAssetTxnStatus_Confirmed {
+			received += t.Amount
+		}
+		rows = append(rows, txnRow{
+			CreatedAssetTxnResponse: t.Response(ctx),
+			URL:                     urlCreateassetsAssetTxn(createdAssetID, t.ID),
+		})
+	}
+	data["txns"] = rows
+	data["received"] = received
+
+	// Holdings of assets that are frozen by default are only unfrozen once the user is approved by KYC.
+	if m.DefaultFrozen {
+		whitelist, err := h.CreateassetRepo.FindWhitelist(ctx, claims, createdAssetID, ctxValues.Now)
+		if err != nil {
+			return err
+		}
+		data["whitelisted"] = whitelist[claims.Subject]
+	}
+
+	// Vested units are released to the holder from the reserve as each tranche vests.
+	grants, err := h.CreateassetRepo.FindUserVestingGrants(ctx, claims, createdAssetID, claims.Subject)
+	if err != nil {
+		return err
+	}
+	data["vestingGrants"] = grants.Response(ctx, ctxValues.Now)
+
+	// Suggest the addresses the user has proven they own.
+	wallets, err := h.WalletRepo.Find(ctx, claims, wallet.WalletAddressFindRequest{
+		Where: "user_id = ? and verified_at is not null",
+		Args:  []interface{}{claims.Subject},
+		Order: []string{"created_at"},
+	})
+	if err != nil {
+		return err
+	}
+	data["wallets"] = wallets.Response(ctx)
+
+	data["urlCreateassetsView"] = urlCreateassetsView(createdAssetID)
+
+	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "createassets-holding.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
+}
+
+// AssetTxn handles offline signing of a transaction that manages a created asset. It
+// works the same as Txn but for the transactions listed on the manage and holding pages.
+func (h *Createassets) AssetTxn(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	createdAssetID := params["created_asset_id"]
+	txnID := params["txn_id"]
+
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if r.Method == http.MethodGet {
+		utx, err := h.CreateassetRepo.UnsignedAssetTxn(ctx, claims, createasset.CreatedAssetTxnReadRequest{
+			CreatedAssetID: createdAssetID,
+			ID:             txnID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if r.URL.Query().Get("format") == "base64" {
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", utx.Filename()+".b64"))
+			return web.RespondText(ctx, w, utx.Base64(), http.StatusOK)
+		}
+
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", utx.Filename()))
+		return web.Respond(ctx, w, utx.Txn, http.StatusOK, web.MIMEOctetStream)
+	}
+
+	f := func() error {
+		req := createasset.CreatedAssetTxnSignedRequest{
+			CreatedAssetID: createdAssetID,
+			ID:             txnID,
+		}
+
+		req.SignedTxn, err = signedTxnUpload(r)
+		if err != nil {
+			return err
+		}
+
+		txn, err := h.CreateassetRepo.SubmitSignedAssetTxn(ctx, claims, req, ctxValues.Now)
+		if err != nil {
+			switch errors.Cause(err) {
+			case createasset.ErrSignedTxnMismatch, createasset.ErrSignedTxnInvalidSignature, createasset.ErrInvalidTxnStatus:
+				webcontext.SessionFlashError(ctx,
+					"Signed Transaction Rejected",
+					err.Error())
+				return nil
+			default:
+				if _, ok := weberror.NewValidationError(ctx, err); ok {
+					webcontext.SessionFlashError(ctx,
+						"Signed Transaction Required",
+						"Upload the signed transaction file or paste the base64 encoded signed transaction.")
+					return nil
+				}
+				return err
+			}
+		}
+
+		if txn.Status == createasset.CreatedAssetTxnStatus_Draft {
+			if res := txn.Response(ctx); res.Multisig && !res.Signed {
+				// The members of a multisig sign one after the other.
+				webcontext.SessionFlashInfo(ctx,
+					"Signature Saved",
+					fmt.Sprintf("%d of the %d signatures required have been collected, download the transaction again for the next member to sign.", res.Signatures, res.Threshold))
+			} else {
+				webcontext.SessionFlashInfo(ctx,
+					"Transaction Signed",
+					"Signed transaction successfully saved, the group will be submitted once the rest of its transactions are signed.")
+			}
+			return nil
+		}
+
+		webcontext.SessionFlashSuccess(ctx,
+			"Transaction Submitted",
+			"Signed transaction successfully submitted to the network, the asset will be updated once the transaction is confirmed.")
+
+		return nil
+	}
+
+	if err := f(); err != nil {
+		return web.RenderError(ctx, w, r, err, h.Renderer, TmplLayoutBase, TmplContentErrorGeneric, web.MIMETextHTMLCharsetUTF8)
+	}
+
+	// Investors sign their opt-in from the holding page.
+	if !claims.HasPermission(auth.PermissionAssetRead) {
+		return web.Redirect(ctx, w, r, urlCreateassetsHolding(createdAssetID), http.StatusFound)
+	}
+
+	return web.Redirect(ctx, w, r, urlCreateassetsManage(createdAssetID), http.StatusFound)
+}
+
+// signedTxnUpload returns the signed transaction uploaded as the SignedTxnFile file or
+// pasted as base64 in the SignedTxn field.
+func signedTxnUpload(r *http.Request) ([]byte, error) {
+	// Signed transactions are small, limit the size of the upload.
+	if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+		return nil, err
+	}
+
+	if file, _, err := r.FormFile("SignedTxnFile"); err == nil {
+		defer file.Close()
+
+		stx, err := ioutil.ReadAll(io.LimitReader(file, 1<<20))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if len(stx) > 0 {
+			return stx, nil
+		}
+	} else if err != http.ErrMissingFile && err != http.ErrNotMultipart {
+		return nil, errors.WithStack(err)
+	}
+
+	return []byte(strings.TrimSpace(r.FormValue("SignedTxn"))), nil
+}
+
+// vestingGrantForm parses the total, start date and schedule of a vesting grant from the
+// posted form.
+func vestingGrantForm(ctx context.Context, r *http.Request) (createasset.CreatedAssetVestingGrantCreateRequest, error) {
+	var req createasset.CreatedAssetVestingGrantCreateRequest
+
+	total, err := strconv.ParseUint(strings.TrimSpace(r.PostForm.Get("Total")), 10, 64)
+	if err != nil {
+		return req, weberror.NewErrorMessage(ctx, err, http.StatusBadRequest, "Total must be a number of units.")
+	}
+	req.Total = total
+
+	req.StartDate, err = time.Parse("2006-01-02", r.PostForm.Get("StartDate"))
+	if err != nil {
+		return req, weberror.NewErrorMessage(ctx, err, http.StatusBadRequest, "Start date must be a date.")
+	}
+
+	for field, months := range map[string]*int{
+		"CliffMonths":         &req.CliffMonths,
+		"PeriodMonths":        &req.PeriodMonths,
+		"IntervalMonths":      &req.IntervalMonths,
+		"AccelerationPercent": &req.AccelerationPercent,
+	} {
+		v := strings.TrimSpace(r.PostForm.Get(field))
+		if v == "" {
+			continue
+		}
+
+		*months, err = strconv.Atoi(v)
+		if err != nil {
+			return req, weberror.NewErrorMessage(ctx, err, http.StatusBadRequest, fmt.Sprintf("%s must be a number.", field))
+		}
 	}
 
-	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "Createassets-update.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
+	return req, nil
 }
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web"
+)
+
+// Jwks publishes the public keys used to verify auth tokens.
+type Jwks struct {
+	Authenticator *auth.Authenticator
+}
+
+// Keys returns the JSON Web Key Set of the authenticator. It includes the keys of tokens that
+// have not yet expired so other services can verify tokens signed before a rotation.
+func (h *Jwks) Keys(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	// Allow the keys to be cached for a short period, new keys are loaded on rotation.
+	w.Header().Set("Cache-Control", "public, max-age=60")
+
+	return web.RespondJson(ctx, w, h.Authenticator.JWKS(), http.StatusOK)
+}
@@ -11,10 +11,14 @@ import (
 
 	"exitor-dapp/internal/account"
 	"exitor-dapp/internal/account/account_preference"
-	"exitor-dapp/internal/Createasset"
+	"exitor-dapp/internal/account/api_client"
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/createasset"
 	"exitor-dapp/internal/geonames"
+	"exitor-dapp/internal/login_throttle"
 	"exitor-dapp/internal/mid"
 	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/ratelimit"
 	"exitor-dapp/internal/platform/web"
 	"exitor-dapp/internal/platform/web/webcontext"
 	"exitor-dapp/internal/platform/web/weberror"
@@ -23,6 +27,10 @@ import (
 	"exitor-dapp/internal/user_account"
 	"exitor-dapp/internal/user_account/invite"
 	"exitor-dapp/internal/user_auth"
+	"exitor-dapp/internal/user_kyc"
+	"exitor-dapp/internal/user_session"
+	"exitor-dapp/internal/user_totp"
+	"exitor-dapp/internal/wallet"
 	"exitor-dapp/internal/webroute"
 
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -50,9 +58,16 @@ type AppContext struct {
 	AuthRepo          *user_auth.Repository
 	SignupRepo        *signup.Repository
 	InviteRepo        *invite.Repository
-	CreateassetRepo     *Createasset.Repository// from checklists, will review
+	CreateassetRepo   *createasset.Repository
+	WalletRepo        *wallet.Repository
+	ApiClientRepo     *api_client.Repository
+	UserSessionRepo   *user_session.Repository
+	UserTotpRepo      *user_totp.Repository
+	UserKycRepo       *user_kyc.Repository
+	LoginThrottleRepo *login_throttle.Repository
 	GeoRepo           *geonames.Repository
 	Authenticator     *auth.Authenticator
+	AlgoClient        *algosdk.Client
 	StaticDir         string
 	TemplateDir       string
 	Renderer          web.Renderer
@@ -102,6 +117,12 @@ func APP(shutdown chan os.Signal, appCtx *AppContext) http.Handler {
 		WaitHandler: serverless.Pending,
 	})
 
+	// rateLimitMid limits the requests a client can make to sensitive routes that are not
+	// authenticated by its IP address. Sign ins with a password are throttled by the handler.
+	rateLimitMid := mid.RateLimit(mid.RateLimitConfig{
+		Limiter: ratelimit.New(appCtx.Redis, "rate_limit:web", 30, time.Minute*15),
+	})
+
 	// Build a sitemap.
 	sm := stm.NewSitemap(1)
 	sm.SetVerbose(false)
@@ -121,64 +142,100 @@ func APP(shutdown chan os.Signal, appCtx *AppContext) http.Handler {
 		sm.Add(loc)
 	}
 
-	// Register Createasset management pages.
+	// Register created asset management pages.
 	p := Createassets{
 		CreateassetRepo: appCtx.CreateassetRepo,
-		Redis:         appCtx.Redis,
-		Renderer:      appCtx.Renderer,
+		WalletRepo:      appCtx.WalletRepo,
+		AlgoClient:      appCtx.AlgoClient,
+		Redis:           appCtx.Redis,
+		Renderer:        appCtx.Renderer,
 	}
-	app.Handle("POST", "/Createassets/:Createasset_id/update", p.Update, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("GET", "/Createassets/:Createasset_id/update", p.Update, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("POST", "/Createassets/:Createasset_id", p.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("GET", "/Createassets/:Createasset_id", p.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
-	app.Handle("POST", "/Createassets/create", p.Create, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("GET", "/Createassets/create", p.Create, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("GET", "/Createassets", p.Index, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("POST", "/createassets/:created_asset_id/txns/:txn_id", p.AssetTxn, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("GET", "/createassets/:created_asset_id/txns/:txn_id", p.AssetTxn, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("POST", "/createassets/:created_asset_id/holding", p.Holding, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("GET", "/createassets/:created_asset_id/holding", p.Holding, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("POST", "/createassets/:created_asset_id/manage", p.Manage, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetRead))
+	app.Handle("GET", "/createassets/:created_asset_id/manage", p.Manage, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetRead))
+	app.Handle("POST", "/createassets/:created_asset_id/txn", p.Txn, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetMint))
+	app.Handle("GET", "/createassets/:created_asset_id/txn", p.Txn, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetMint))
+	app.Handle("POST", "/createassets/:created_asset_id", p.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetRead))
+	app.Handle("GET", "/createassets/:created_asset_id", p.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("POST", "/createassets/create", p.Create, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetDraft))
+	app.Handle("GET", "/createassets/create", p.Create, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAssetDraft))
+	app.Handle("GET", "/createassets", p.Index, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+
+	// Register the metadata documents published for created assets. This route is not authenticated.
+	app.Handle("GET", "/m/:asset_index", p.Metadata)
 
 	// Register user management pages.
 	us := Users{
 		UserRepo:        appCtx.UserRepo,
 		UserAccountRepo: appCtx.UserAccountRepo,
 		AuthRepo:        appCtx.AuthRepo,
+		UserSessionRepo: appCtx.UserSessionRepo,
+		UserTotpRepo:    appCtx.UserTotpRepo,
+		UserKycRepo:     appCtx.UserKycRepo,
 		InviteRepo:      appCtx.InviteRepo,
 		GeoRepo:         appCtx.GeoRepo,
 		Redis:           appCtx.Redis,
 		Renderer:        appCtx.Renderer,
 	}
-	app.Handle("POST", "/users/:user_id/update", us.Update, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("GET", "/users/:user_id/update", us.Update, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("POST", "/users/:user_id", us.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
+	app.Handle("POST", "/users/:user_id/update", us.Update, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("GET", "/users/:user_id/update", us.Update, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("POST", "/users/:user_id", us.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage, auth.PermissionUserKyc))
 	app.Handle("GET", "/users/:user_id", us.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
-	app.Handle("POST", "/users/invite/:hash", us.InviteAccept)
+	app.Handle("POST", "/users/invite/:hash", us.InviteAccept, rateLimitMid)
 	app.Handle("GET", "/users/invite/:hash", us.InviteAccept)
-	app.Handle("POST", "/users/invite", us.Invite, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("GET", "/users/invite", us.Invite, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("POST", "/users/create", us.Create, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("GET", "/users/create", us.Create, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
+	app.Handle("POST", "/users/invite", us.Invite, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("GET", "/users/invite", us.Invite, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("POST", "/users/create", us.Create, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("GET", "/users/create", us.Create, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
 	app.Handle("GET", "/users", us.Index, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
 
+	// Register wallet address pages.
+	wa := Wallets{
+		WalletRepo: appCtx.WalletRepo,
+		Renderer:   appCtx.Renderer,
+	}
+	app.Handle("POST", "/user/wallets/:wallet_address_id/connect", wa.Connect, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("GET", "/user/wallets/:wallet_address_id/connect", wa.Connect, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("POST", "/user/wallets", wa.Index, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("GET", "/user/wallets", wa.Index, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+
 	// Register user management and authentication endpoints.
 	u := UserRepos{
-		UserRepo:        appCtx.UserRepo,
-		UserAccountRepo: appCtx.UserAccountRepo,
-		AccountRepo:     appCtx.AccountRepo,
-		AuthRepo:        appCtx.AuthRepo,
-		GeoRepo:         appCtx.GeoRepo,
-		Renderer:        appCtx.Renderer,
+		UserRepo:          appCtx.UserRepo,
+		UserAccountRepo:   appCtx.UserAccountRepo,
+		UserSessionRepo:   appCtx.UserSessionRepo,
+		UserTotpRepo:      appCtx.UserTotpRepo,
+		LoginThrottleRepo: appCtx.LoginThrottleRepo,
+		AccountRepo:       appCtx.AccountRepo,
+		AuthRepo:          appCtx.AuthRepo,
+		GeoRepo:           appCtx.GeoRepo,
+		Renderer:          appCtx.Renderer,
 	}
 	app.Handle("POST", "/user/login", u.Login)
 	app.Handle("GET", "/user/login", u.Login, waitDbMid)
-	app.Handle("GET", "/user/logout", u.Logout)
-	app.Handle("POST", "/user/reset-password/:hash", u.ResetConfirm)
+	app.Handle("POST", "/user/login/wallet", u.LoginWallet, rateLimitMid)
+	app.Handle("GET", "/user/login/wallet", u.LoginWallet, waitDbMid)
+	app.Handle("POST", "/user/login/two-factor", u.LoginTwoFactor, rateLimitMid)
+	app.Handle("GET", "/user/login/two-factor", u.LoginTwoFactor)
+	app.Handle("GET", "/user/unlock/:hash", u.Unlock)
+	app.Handle("GET", "/user/logout", u.Logout, mid.AuthenticateSessionOptional(appCtx.Authenticator))
+	app.Handle("POST", "/user/reset-password/:hash", u.ResetConfirm, rateLimitMid)
 	app.Handle("GET", "/user/reset-password/:hash", u.ResetConfirm)
 	app.Handle("POST", "/user/reset-password", u.ResetPassword)
 	app.Handle("GET", "/user/reset-password", u.ResetPassword)
 	app.Handle("POST", "/user/update", u.Update, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
 	app.Handle("GET", "/user/update", u.Update, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
 	app.Handle("GET", "/user/account", u.Account, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
-	app.Handle("GET", "/user/virtual-login/:user_id", u.VirtualLogin, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("POST", "/user/virtual-login", u.VirtualLogin, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("GET", "/user/virtual-login", u.VirtualLogin, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
+	app.Handle("POST", "/user/sessions", u.Sessions, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("GET", "/user/sessions", u.Sessions, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("POST", "/user/two-factor", u.TwoFactor, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("GET", "/user/two-factor", u.TwoFactor, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
+	app.Handle("GET", "/user/virtual-login/:user_id", u.VirtualLogin, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("POST", "/user/virtual-login", u.VirtualLogin, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
+	app.Handle("GET", "/user/virtual-login", u.VirtualLogin, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionUserManage))
 	app.Handle("GET", "/user/virtual-logout", u.VirtualLogout, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
 	app.Handle("GET", "/user/switch-account/:account_id", u.SwitchAccount, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
 	app.Handle("POST", "/user/switch-account", u.SwitchAccount, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
@@ -186,6 +243,14 @@ func APP(shutdown chan os.Signal, appCtx *AppContext) http.Handler {
 	app.Handle("POST", "/user", u.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
 	app.Handle("GET", "/user", u.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasAuth())
 
+	// Register api client management pages.
+	ac := ApiClients{
+		ApiClientRepo: appCtx.ApiClientRepo,
+		Renderer:      appCtx.Renderer,
+	}
+	app.Handle("POST", "/account/api-clients", ac.Index, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAccountManage))
+	app.Handle("GET", "/account/api-clients", ac.Index, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAccountManage))
+
 	// Register account management endpoints.
 	acc := Account{
 		AccountRepo:     appCtx.AccountRepo,
@@ -195,10 +260,10 @@ func APP(shutdown chan os.Signal, appCtx *AppContext) http.Handler {
 		GeoRepo:         appCtx.GeoRepo,
 		Renderer:        appCtx.Renderer,
 	}
-	app.Handle("POST", "/account/update", acc.Update, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("GET", "/account/update", acc.Update, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("POST", "/account", acc.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
-	app.Handle("GET", "/account", acc.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasRole(auth.RoleAdmin))
+	app.Handle("POST", "/account/update", acc.Update, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAccountManage))
+	app.Handle("GET", "/account/update", acc.Update, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAccountManage))
+	app.Handle("POST", "/account", acc.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAccountManage))
+	app.Handle("GET", "/account", acc.View, mid.AuthenticateSessionRequired(appCtx.Authenticator), mid.HasPermission(auth.PermissionAccountRead))
 
 	// Register signup endpoints.
 	s := Signup{
@@ -208,7 +273,7 @@ func APP(shutdown chan os.Signal, appCtx *AppContext) http.Handler {
 		Renderer:   appCtx.Renderer,
 	}
 	// This route is not authenticated
-	app.Handle("POST", "/signup", s.Step1)
+	app.Handle("POST", "/signup", s.Step1, rateLimitMid)
 	app.Handle("GET", "/signup", s.Step1, waitDbMid)
 
 	// Register example endpoints.
@@ -254,6 +319,12 @@ func APP(shutdown chan os.Signal, appCtx *AppContext) http.Handler {
 	app.Handle("GET", "/v1/health", check.Health)
 	app.Handle("GET", "/ping", check.Ping)
 
+	// Register the public keys used to verify auth tokens. This route is not authenticated.
+	jwks := Jwks{
+		Authenticator: appCtx.Authenticator,
+	}
+	app.Handle("GET", "/.well-known/jwks.json", jwks.Keys)
+
 	// Add sitemap entries for Root.
 	smLocAddModified(stm.URL{{"loc", "/"}, {"changefreq", "weekly"}, {"mobile", true}, {"priority", 0.9}}, "site-index.gohtml")
 	smLocAddModified(stm.URL{{"loc", "/pricing"}, {"changefreq", "monthly"}, {"mobile", true}, {"priority", 0.8}}, "site-pricing.gohtml")
@@ -74,14 +74,13 @@ func (h *Signup) Step1(ctx context.Context, w http.ResponseWriter, r *http.Reque
 			token, err := h.AuthRepo.Authenticate(ctx, user_auth.AuthenticateRequest{
 				Email:    req.User.Email,
 				Password: req.User.Password,
-				WalletAddress: req.User.WalletAddress, // Require Algorand Wallet Address
-			}, time.Hour, ctxValues.Now)
+			}, user_auth.AccessTokenTTL, ctxValues.Now)
 			if err != nil {
 				return false, err
 			}
 
 			// Add the token to the users session.
-			err = handleSessionToken(ctx, w, r, token)
+			err = handleSessionToken(ctx, w, r, h.AuthRepo, token, time.Hour)
 			if err != nil {
 				return false, err
 			}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -10,6 +12,7 @@ import (
 
 	"exitor-dapp/internal/account"
 	"exitor-dapp/internal/geonames"
+	"exitor-dapp/internal/login_throttle"
 	"exitor-dapp/internal/platform/auth"
 	"exitor-dapp/internal/platform/web"
 	"exitor-dapp/internal/platform/web/webcontext"
@@ -17,7 +20,10 @@ import (
 	"exitor-dapp/internal/user"
 	"exitor-dapp/internal/user_account"
 	"exitor-dapp/internal/user_auth"
+	"exitor-dapp/internal/user_session"
+	"exitor-dapp/internal/user_totp"
 
+	"github.com/dustin/go-humanize/english"
 	"github.com/gorilla/schema"
 	"github.com/gorilla/sessions"
 	"github.com/jmoiron/sqlx"
@@ -26,20 +32,60 @@ import (
 
 // User represents the User API method handler set.
 type UserRepos struct {
-	UserRepo        *user.Repository
-	AuthRepo        *user_auth.Repository
-	UserAccountRepo *user_account.Repository
-	AccountRepo     *account.Repository
-	GeoRepo         *geonames.Repository
-	MasterDB        *sqlx.DB
-	Renderer        web.Renderer
-	SecretKey       string
+	UserRepo          *user.Repository
+	AuthRepo          *user_auth.Repository
+	UserAccountRepo   *user_account.Repository
+	UserSessionRepo   *user_session.Repository
+	UserTotpRepo      *user_totp.Repository
+	LoginThrottleRepo *login_throttle.Repository
+	AccountRepo       *account.Repository
+	GeoRepo           *geonames.Repository
+	MasterDB          *sqlx.DB
+	Renderer          web.Renderer
+	SecretKey         string
 }
 
 func urlUserVirtualLogin(userID string) string {
 	return fmt.Sprintf("/user/virtual-login/%s", userID)
 }
 
+func urlUserSessions() string {
+	return fmt.Sprintf("/user/sessions")
+}
+
+func urlUserLoginTwoFactor() string {
+	return fmt.Sprintf("/user/login/two-factor")
+}
+
+func urlUserTwoFactor() string {
+	return fmt.Sprintf("/user/two-factor")
+}
+
+// loginAttemptRequest returns the request to throttle a sign in for the email from the client.
+func loginAttemptRequest(r *http.Request, email string) login_throttle.LoginAttemptRequest {
+	ipAddress := web.RequestRealIP(r)
+	if net.ParseIP(ipAddress) == nil {
+		ipAddress = ""
+	}
+
+	return login_throttle.LoginAttemptRequest{
+		Email:     email,
+		IPAddress: ipAddress,
+	}
+}
+
+// loginThrottleMessage returns the message displayed when a sign in was throttled.
+func loginThrottleMessage(err error, retryAfter time.Duration) (string, bool) {
+	switch errors.Cause(err) {
+	case login_throttle.ErrLocked:
+		return "Too many failed attempts. Sign in has been locked, use the link emailed to you to unlock it or try again later.", true
+	case login_throttle.ErrTooManyAttempts:
+		secs := int(math.Ceil(retryAfter.Seconds()))
+		return fmt.Sprintf("Too many failed attempts. Try again in %s.", english.Plural(secs, "second", "")), true
+	}
+	return "", false
+}
+
 // UserLoginRequest extends the AuthenicateRequest with the RememberMe flag.
 type UserLoginRequest struct {
 	user_auth.AuthenticateRequest
@@ -75,18 +121,59 @@ func (h UserRepos) Login(ctx context.Context, w http.ResponseWriter, r *http.Req
 				sessionTTL = time.Hour * 36
 			}
 
-			// Authenticated the user.
-			token, err := h.AuthRepo.Authenticate(ctx, user_auth.AuthenticateRequest{
+			authReq := user_auth.AuthenticateRequest{
 				Email:    req.Email,
 				Password: req.Password,
-			}, sessionTTL, ctxValues.Now)
+			}
+
+			// Validate the request before the attempt is throttled.
+			err = webcontext.Validator().StructCtx(ctx, authReq)
+			if err != nil {
+				if verr, ok := weberror.NewValidationError(ctx, err); ok {
+					data["validationErrors"] = verr.(*weberror.Error)
+					return false, nil
+				} else {
+					return false, err
+				}
+			}
+
+			// Throttle sign ins for the email and the IP address of the client.
+			attempt := loginAttemptRequest(r, req.Email)
+			if retryAfter, err := h.LoginThrottleRepo.Check(ctx, attempt, ctxValues.Now); err != nil {
+				if msg, ok := loginThrottleMessage(err, retryAfter); ok {
+					data["error"] = weberror.NewErrorMessage(ctx, err, http.StatusTooManyRequests, msg)
+					return false, nil
+				}
+				return false, err
+			}
+
+			// Authenticated the user.
+			token, err := h.AuthRepo.Authenticate(ctx, authReq, user_auth.AccessTokenTTL, ctxValues.Now)
 			if err != nil {
+				if errors.Cause(err) != user_auth.ErrAuthenticationFailure {
+					// The credentials were not rejected, don't count the attempt as a failure.
+					if rerr := h.LoginThrottleRepo.Release(ctx, attempt, ctxValues.Now); rerr != nil {
+						return false, rerr
+					}
+				}
+
 				switch errors.Cause(err) {
 				case user.ErrForbidden:
 					return false, web.RespondError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
 				case user_auth.ErrAuthenticationFailure:
+					// Record the failure, the email is locked out after too many.
+					if err := h.LoginThrottleRepo.Failure(ctx, attempt, ctxValues.Now); err != nil {
+						if msg, ok := loginThrottleMessage(err, 0); ok {
+							data["error"] = weberror.NewErrorMessage(ctx, err, http.StatusTooManyRequests, msg)
+							return false, nil
+						}
+						return false, err
+					}
+
 					data["error"] = weberror.NewErrorMessage(ctx, err, http.StatusUnauthorized, "Authentication failure. Try again.")
 					return false, nil
+				case user_auth.ErrTwoFactorRequired, user_auth.ErrTwoFactorEnrollmentRequired:
+					return true, handleTwoFactorChallenge(ctx, w, r, token, req.RememberMe)
 				default:
 					if verr, ok := weberror.NewValidationError(ctx, err); ok {
 						data["validationErrors"] = verr.(*weberror.Error)
@@ -97,8 +184,13 @@ func (h UserRepos) Login(ctx context.Context, w http.ResponseWriter, r *http.Req
 				}
 			}
 
+			err = h.LoginThrottleRepo.Success(ctx, attempt, ctxValues.Now)
+			if err != nil {
+				return false, err
+			}
+
 			// Add the token to the users session.
-			err = handleSessionToken(ctx, w, r, token)
+			err = handleSessionToken(ctx, w, r, h.AuthRepo, token, sessionTTL)
 			if err != nil {
 				return false, err
 			}
@@ -134,9 +226,333 @@ func (h UserRepos) Login(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "user-login.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
 }
 
+// UserLoginWalletRequest extends the AuthenticateWalletRequest with the RememberMe flag.
+type UserLoginWalletRequest struct {
+	user_auth.AuthenticateWalletRequest
+	RememberMe bool
+}
+
+// LoginWallet handles authenticating a user with the key of an Algorand address they have
+// verified. The address is posted first to issue the challenge which is then signed with the
+// wallet and posted back with the signature.
+func (h UserRepos) LoginWallet(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	//
+	req := new(UserLoginWalletRequest)
+	data := make(map[string]interface{})
+	f := func() (bool, error) {
+
+		if r.Method == http.MethodPost {
+			err := r.ParseForm()
+			if err != nil {
+				return false, err
+			}
+
+			decoder := schema.NewDecoder()
+			decoder.IgnoreUnknownKeys(true)
+			if err := decoder.Decode(req, r.PostForm); err != nil {
+				return false, err
+			}
+			req.Address = strings.TrimSpace(req.Address)
+			req.Signature = strings.TrimSpace(req.Signature)
+
+			// Issue the challenge for the address when no signature was provided.
+			if r.PostForm.Get("action") == "challenge" {
+				challenge, err := h.AuthRepo.WalletChallenge(ctx, user_auth.WalletChallengeRequest{
+					Address: req.Address,
+				}, ctxValues.Now)
+				if err != nil {
+					if verr, ok := weberror.NewValidationError(ctx, err); ok {
+						data["validationErrors"] = verr.(*weberror.Error)
+						return false, nil
+					} else {
+						return false, err
+					}
+				}
+				data["challenge"] = challenge
+
+				return false, nil
+			}
+
+			sessionTTL := time.Hour
+			if req.RememberMe {
+				sessionTTL = time.Hour * 36
+			}
+
+			// Authenticated the user.
+			token, err := h.AuthRepo.AuthenticateWallet(ctx, req.AuthenticateWalletRequest, user_auth.AccessTokenTTL, ctxValues.Now)
+			if err != nil {
+				switch errors.Cause(err) {
+				case user.ErrForbidden:
+					return false, web.RespondError(ctx, w, weberror.NewError(ctx, err, http.StatusForbidden))
+				case user_auth.ErrAuthenticationFailure:
+					data["error"] = weberror.NewErrorMessage(ctx, err, http.StatusUnauthorized, "Authentication failure. Request a new message and try again.")
+					return false, nil
+				case user_auth.ErrTwoFactorRequired, user_auth.ErrTwoFactorEnrollmentRequired:
+					return true, handleTwoFactorChallenge(ctx, w, r, token, req.RememberMe)
+				default:
+					if verr, ok := weberror.NewValidationError(ctx, err); ok {
+						data["validationErrors"] = verr.(*weberror.Error)
+						return false, nil
+					} else {
+						return false, err
+					}
+				}
+			}
+
+			// Add the token to the users session.
+			err = handleSessionToken(ctx, w, r, h.AuthRepo, token, sessionTTL)
+			if err != nil {
+				return false, err
+			}
+
+			redirectUri := "/"
+			if qv := r.URL.Query().Get("redirect"); qv != "" {
+				redirectUri, err = url.QueryUnescape(qv)
+				if err != nil {
+					return false, err
+				}
+			}
+
+			// Redirect the user to the dashboard.
+			return true, web.Redirect(ctx, w, r, redirectUri, http.StatusFound)
+		}
+
+		return false, nil
+	}
+
+	end, err := f()
+	if err != nil {
+		return web.RenderError(ctx, w, r, err, h.Renderer, TmplLayoutBase, TmplContentErrorGeneric, web.MIMETextHTMLCharsetUTF8)
+	} else if end {
+		return nil
+	}
+
+	data["form"] = req
+
+	if verr, ok := weberror.NewValidationError(ctx, webcontext.Validator().Struct(UserLoginWalletRequest{})); ok {
+		data["validationDefaults"] = verr.(*weberror.Error)
+	}
+
+	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "user-login-wallet.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
+}
+
+// UserLoginTwoFactorRequest contains the code entered to complete a sign in.
+type UserLoginTwoFactorRequest struct {
+	Code string `validate:"required,max=20"`
+}
+
+// LoginTwoFactor handles the second step of a sign in for users that must provide a code from
+// their authenticator app or one of their recovery codes. Users of accounts that require two-factor
+// authentication that have not enrolled yet scan the secret and complete enrollment with their
+// first code.
+func (h UserRepos) LoginTwoFactor(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	//
+	req := new(UserLoginTwoFactorRequest)
+	data := make(map[string]interface{})
+	f := func() (bool, error) {
+
+		// Load the challenge issued when the password was verified.
+		var ch *user_totp.UserTotpChallenge
+		challengeID, rememberMe, ok := webcontext.ContextTwoFactorChallenge(ctx)
+		if ok {
+			ch, err = h.UserTotpRepo.ReadChallenge(ctx, challengeID, ctxValues.Now)
+			if err != nil {
+				switch errors.Cause(err) {
+				case user_totp.ErrNotFound, user_totp.ErrChallengeExpired:
+					ok = false
+				default:
+					return false, err
+				}
+			}
+		}
+		if !ok {
+			webcontext.SessionFlashWarning(ctx,
+				"Sign In Expired",
+				"Sign in again to continue.")
+			return true, web.Redirect(ctx, w, r, "/user/login", http.StatusFound)
+		}
+
+		enrolled, err := h.UserTotpRepo.IsEnrolled(ctx, ch.UserID)
+		if err != nil {
+			return false, err
+		}
+
+		if r.Method == http.MethodPost {
+			err := r.ParseForm()
+			if err != nil {
+				return false, err
+			}
+
+			decoder := schema.NewDecoder()
+			decoder.IgnoreUnknownKeys(true)
+			if err := decoder.Decode(req, r.PostForm); err != nil {
+				return false, err
+			}
+
+			sessionTTL := time.Hour
+			if rememberMe {
+				sessionTTL = time.Hour * 36
+			}
+
+			// Throttle the codes for the email of the user, the same as the password.
+			usr, err := h.UserRepo.ReadByID(ctx, auth.Claims{}, ch.UserID)
+			if err != nil {
+				return false, err
+			}
+			attempt := loginAttemptRequest(r, usr.Email)
+			if retryAfter, err := h.LoginThrottleRepo.Check(ctx, attempt, ctxValues.Now); err != nil {
+				if msg, ok := loginThrottleMessage(err, retryAfter); ok {
+					if errors.Cause(err) == login_throttle.ErrLocked {
+						// The user has to sign in again once the lockout is removed.
+						webcontext.SessionClearTwoFactorChallenge(webcontext.ContextSession(ctx))
+						webcontext.SessionFlashError(ctx, "Sign In Locked", msg)
+						return true, web.Redirect(ctx, w, r, "/user/login", http.StatusFound)
+					}
+					data["error"] = weberror.NewErrorMessage(ctx, err, http.StatusTooManyRequests, msg)
+					return false, nil
+				}
+				return false, err
+			}
+
+			token, err := h.AuthRepo.AuthenticateTwoFactor(ctx, user_auth.AuthenticateTwoFactorRequest{
+				ChallengeID: ch.ID,
+				Code:        strings.TrimSpace(req.Code),
+			}, user_auth.AccessTokenTTL, ctxValues.Now)
+			if err != nil {
+				switch errors.Cause(err) {
+				case user_auth.ErrAuthenticationFailure:
+					// Record the failure for the email of the user, the same as an invalid password.
+					if err := h.LoginThrottleRepo.Failure(ctx, attempt, ctxValues.Now); err != nil {
+						if msg, ok := loginThrottleMessage(err, 0); ok {
+							// The user has to sign in again once the lockout is removed.
+							webcontext.SessionClearTwoFactorChallenge(webcontext.ContextSession(ctx))
+							webcontext.SessionFlashError(ctx, "Sign In Locked", msg)
+							return true, web.Redirect(ctx, w, r, "/user/login", http.StatusFound)
+						}
+						return false, err
+					}
+
+					data["error"] = weberror.NewErrorMessage(ctx, err, http.StatusUnauthorized, "Invalid code. Try again.")
+				default:
+					// The code was not rejected, don't count the attempt as a failure.
+					if rerr := h.LoginThrottleRepo.Release(ctx, attempt, ctxValues.Now); rerr != nil {
+						return false, rerr
+					}
+
+					if verr, ok := weberror.NewValidationError(ctx, err); ok {
+						data["validationErrors"] = verr.(*weberror.Error)
+					} else {
+						return false, err
+					}
+				}
+			} else {
+				// Clear any failed sign ins for the email of the user.
+				err = h.LoginThrottleRepo.Success(ctx, attempt, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+
+				// The challenge was completed, remove it before the session is saved with the token.
+				sess := webcontext.ContextSession(ctx)
+				webcontext.SessionClearTwoFactorChallenge(sess)
+
+				// Add the token to the users session.
+				err = handleSessionToken(ctx, w, r, h.AuthRepo, token, sessionTTL)
+				if err != nil {
+					return false, err
+				}
+
+				// Enrollment was confirmed with the code, send the user to generate their recovery codes.
+				if !enrolled {
+					webcontext.SessionFlashSuccess(ctx,
+						"Two-Factor Authentication Enabled",
+						"Generate recovery codes to sign in when you don't have access to your authenticator app.")
+					return true, web.Redirect(ctx, w, r, urlUserTwoFactor(), http.StatusFound)
+				}
+
+				redirectUri := "/"
+				if qv := r.URL.Query().Get("redirect"); qv != "" {
+					redirectUri, err = url.QueryUnescape(qv)
+					if err != nil {
+						return false, err
+					}
+				}
+
+				// Redirect the user to the dashboard.
+				return true, web.Redirect(ctx, w, r, redirectUri, http.StatusFound)
+			}
+		}
+
+		// The account requires two-factor authentication, display the secret for the user to enroll.
+		if !enrolled {
+			m, err := h.UserTotpRepo.Enroll(ctx, auth.Claims{}, user_totp.UserTotpEnrollRequest{
+				UserID: ch.UserID,
+			}, ctxValues.Now)
+			if err != nil {
+				return false, err
+			}
+
+			// Load the user without any claims applied.
+			usr, err := h.UserRepo.ReadByID(ctx, auth.Claims{}, ch.UserID)
+			if err != nil {
+				return false, err
+			}
+
+			data["enroll"] = true
+			data["secret"] = m.Secret
+			data["provisioningUri"] = m.ProvisioningURI(h.UserTotpRepo.Issuer, usr.Email)
+		}
+
+		return false, nil
+	}
+
+	end, err := f()
+	if err != nil {
+		return web.RenderError(ctx, w, r, err, h.Renderer, TmplLayoutBase, TmplContentErrorGeneric, web.MIMETextHTMLCharsetUTF8)
+	} else if end {
+		return nil
+	}
+
+	data["form"] = req
+
+	if verr, ok := weberror.NewValidationError(ctx, webcontext.Validator().Struct(UserLoginTwoFactorRequest{})); ok {
+		data["validationDefaults"] = verr.(*weberror.Error)
+	}
+
+	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "user-login-two-factor.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
+}
+
 // Logout handles removing authentication for the user.
 func (h *UserRepos) Logout(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
 
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Revoke the session so its refresh token can't be used again.
+	claims, _ := auth.ClaimsFromContext(ctx)
+	if claims.SessionID != "" {
+		err = h.UserSessionRepo.Revoke(ctx, claims, user_session.UserSessionRevokeRequest{
+			ID: claims.SessionID,
+		}, ctxValues.Now)
+		if err != nil && errors.Cause(err) != user_session.ErrNotFound {
+			return err
+		}
+	}
+
 	sess := webcontext.ContextSession(ctx)
 
 	// Set the access token to empty to logout the user.
@@ -150,6 +566,273 @@ func (h *UserRepos) Logout(ctx context.Context, w http.ResponseWriter, r *http.R
 	return web.Redirect(ctx, w, r, "/", http.StatusFound)
 }
 
+// Sessions handles listing the active sessions of the current user. Any of the sessions can be
+// revoked, including the current one, or all of them to sign out everywhere.
+func (h *UserRepos) Sessions(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	userID := user_session.SessionUserID(claims)
+
+	data := make(map[string]interface{})
+	f := func() (bool, error) {
+		if r.Method == http.MethodPost {
+			err := r.ParseForm()
+			if err != nil {
+				return false, err
+			}
+
+			var signedOut bool
+			switch r.PostForm.Get("action") {
+			case "revoke":
+				sessionID := r.PostForm.Get("id")
+
+				err = h.UserSessionRepo.Revoke(ctx, claims, user_session.UserSessionRevokeRequest{
+					ID: sessionID,
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+
+				if sessionID != claims.SessionID {
+					webcontext.SessionFlashSuccess(ctx,
+						"Session Revoked",
+						"The device will be signed out when its access expires.")
+
+					return true, web.Redirect(ctx, w, r, urlUserSessions(), http.StatusFound)
+				}
+				signedOut = true
+
+			case "revoke-all":
+				err = h.UserSessionRepo.RevokeAll(ctx, claims, user_session.UserSessionRevokeAllRequest{
+					UserID: userID,
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+				signedOut = true
+			}
+
+			if signedOut {
+				// The current session was revoked, remove the tokens from the session.
+				sess := webcontext.ContextSession(ctx)
+				sess = webcontext.SessionDestroy(sess)
+
+				webcontext.SessionFlashSuccess(ctx,
+					"Signed Out",
+					"Your sessions have been revoked, sign in again to continue.")
+
+				if err := sess.Save(r, w); err != nil {
+					return false, err
+				}
+
+				return true, web.Redirect(ctx, w, r, "/user/login", http.StatusFound)
+			}
+		}
+
+		return false, nil
+	}
+
+	end, err := f()
+	if err != nil {
+		return web.RenderError(ctx, w, r, err, h.Renderer, TmplLayoutBase, TmplContentErrorGeneric, web.MIMETextHTMLCharsetUTF8)
+	} else if end {
+		return nil
+	}
+
+	userSessions, err := h.UserSessionRepo.FindActiveByUserID(ctx, claims, userID, ctxValues.Now)
+	if err != nil {
+		return err
+	}
+
+	var res []*user_session.UserSessionResponse
+	for _, s := range userSessions {
+		sr := s.Response(ctx)
+		sr.Current = s.ID == claims.SessionID
+		res = append(res, sr)
+	}
+	data["sessions"] = res
+
+	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "user-sessions.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
+}
+
+// UserTwoFactorRequest contains the code entered to manage two-factor authentication.
+type UserTwoFactorRequest struct {
+	Code string `validate:"required,max=20"`
+}
+
+// TwoFactor handles enrolling the current user in two-factor authentication with an authenticator
+// app. Once enabled, new recovery codes can be generated or it can be disabled by entering a code.
+func (h *UserRepos) TwoFactor(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Only the user can manage their two-factor authentication, not an admin during a virtual login.
+	canManage := claims.RootUserID == "" || claims.RootUserID == claims.Subject
+
+	//
+	req := new(UserTwoFactorRequest)
+	data := make(map[string]interface{})
+	f := func() (bool, error) {
+		if r.Method == http.MethodPost {
+			err := r.ParseForm()
+			if err != nil {
+				return false, err
+			}
+
+			decoder := schema.NewDecoder()
+			decoder.IgnoreUnknownKeys(true)
+			if err := decoder.Decode(req, r.PostForm); err != nil {
+				return false, err
+			}
+			req.Code = strings.TrimSpace(req.Code)
+
+			// handleCodeErr displays the errors for a code that was entered.
+			handleCodeErr := func(err error) (bool, error) {
+				switch errors.Cause(err) {
+				case user_totp.ErrInvalidCode:
+					data["error"] = weberror.NewErrorMessage(ctx, err, http.StatusUnauthorized, "Invalid code. Try again.")
+					return false, nil
+				default:
+					if verr, ok := weberror.NewValidationError(ctx, err); ok {
+						data["validationErrors"] = verr.(*weberror.Error)
+						return false, nil
+					} else {
+						return false, err
+					}
+				}
+			}
+
+			switch r.PostForm.Get("action") {
+			case "enroll":
+				_, err = h.UserTotpRepo.Enroll(ctx, claims, user_totp.UserTotpEnrollRequest{
+					UserID: claims.Subject,
+				}, ctxValues.Now)
+				if err != nil && errors.Cause(err) != user_totp.ErrAlreadyEnrolled {
+					return false, err
+				}
+
+				return true, web.Redirect(ctx, w, r, urlUserTwoFactor(), http.StatusFound)
+
+			case "confirm":
+				err = h.UserTotpRepo.Confirm(ctx, claims, user_totp.UserTotpConfirmRequest{
+					UserID: claims.Subject,
+					Code:   req.Code,
+				}, ctxValues.Now)
+				if err != nil {
+					return handleCodeErr(err)
+				}
+
+				codes, err := h.UserTotpRepo.RegenerateRecoveryCodes(ctx, claims, user_totp.UserTotpRecoveryCodesRequest{
+					UserID: claims.Subject,
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+				data["recoveryCodes"] = codes
+
+				webcontext.SessionFlashSuccess(ctx,
+					"Two-Factor Authentication Enabled",
+					"A code will be required the next time you sign in.")
+
+			case "recovery-codes":
+				err = h.UserTotpRepo.Verify(ctx, user_totp.UserTotpVerifyRequest{
+					UserID: claims.Subject,
+					Code:   req.Code,
+				}, ctxValues.Now)
+				if err != nil {
+					return handleCodeErr(err)
+				}
+
+				codes, err := h.UserTotpRepo.RegenerateRecoveryCodes(ctx, claims, user_totp.UserTotpRecoveryCodesRequest{
+					UserID: claims.Subject,
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+				data["recoveryCodes"] = codes
+
+			case "disable":
+				err = h.UserTotpRepo.Verify(ctx, user_totp.UserTotpVerifyRequest{
+					UserID: claims.Subject,
+					Code:   req.Code,
+				}, ctxValues.Now)
+				if err != nil {
+					return handleCodeErr(err)
+				}
+
+				err = h.UserTotpRepo.Reset(ctx, claims, user_totp.UserTotpResetRequest{
+					UserID: claims.Subject,
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+
+				webcontext.SessionFlashSuccess(ctx,
+					"Two-Factor Authentication Disabled",
+					"A code will no longer be required to sign in.")
+
+				return true, web.Redirect(ctx, w, r, urlUserTwoFactor(), http.StatusFound)
+			}
+		}
+
+		return false, nil
+	}
+
+	end, err := f()
+	if err != nil {
+		return web.RenderError(ctx, w, r, err, h.Renderer, TmplLayoutBase, TmplContentErrorGeneric, web.MIMETextHTMLCharsetUTF8)
+	} else if end {
+		return nil
+	}
+
+	m, err := h.UserTotpRepo.ReadByUserID(ctx, claims, claims.Subject)
+	if err != nil && errors.Cause(err) != user_totp.ErrNotFound {
+		return err
+	}
+
+	if m != nil {
+		data["totp"] = m.Response(ctx)
+		data["totpEnabled"] = m.IsVerified()
+
+		// Display the secret until the user has confirmed enrollment with a code.
+		if !m.IsVerified() && canManage {
+			usr, err := h.UserRepo.ReadByID(ctx, claims, claims.Subject)
+			if err != nil {
+				return err
+			}
+
+			data["secret"] = m.Secret
+			data["provisioningUri"] = m.ProvisioningURI(h.UserTotpRepo.Issuer, usr.Email)
+		}
+	}
+
+	data["canManage"] = canManage
+	data["form"] = req
+
+	if verr, ok := weberror.NewValidationError(ctx, webcontext.Validator().Struct(UserTwoFactorRequest{})); ok {
+		data["validationDefaults"] = verr.(*weberror.Error)
+	}
+
+	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "user-two-factor.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
+}
+
 // ResetPassword allows a user to perform forgot password.
 func (h *UserRepos) ResetPassword(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
 
@@ -174,9 +857,22 @@ func (h *UserRepos) ResetPassword(ctx context.Context, w http.ResponseWriter, r
 				return err
 			}
 
-			_, err = h.UserRepo.ResetPassword(ctx, *req, ctxValues.Now)
+			// Limit the reset emails sent to the email and requested from the IP address of the client.
+			attempt := loginAttemptRequest(r, req.Email)
+			retryAfter, err := h.LoginThrottleRepo.ResetPasswordAttempt(ctx, login_throttle.ResetPasswordAttemptRequest{
+				Email:     attempt.Email,
+				IPAddress: attempt.IPAddress,
+			}, ctxValues.Now)
+			if err == nil {
+				_, err = h.UserRepo.ResetPassword(ctx, *req, ctxValues.Now)
+			}
 			if err != nil {
 				switch errors.Cause(err) {
+				case login_throttle.ErrTooManyAttempts:
+					secs := int(math.Ceil(retryAfter.Seconds()))
+					data["error"] = weberror.NewErrorMessage(ctx, err, http.StatusTooManyRequests,
+						fmt.Sprintf("Too many password resets requested. Try again in %s.", english.Plural(secs, "second", "")))
+					return nil
 				default:
 					if verr, ok := weberror.NewValidationError(ctx, err); ok {
 						data["validationErrors"] = verr.(*weberror.Error)
@@ -210,6 +906,43 @@ func (h *UserRepos) ResetPassword(ctx context.Context, w http.ResponseWriter, r
 	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "user-reset-password.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
 }
 
+// Unlock removes the lockout of an email after too many failed sign ins once the user has clicked
+// on the link emailed.
+func (h *UserRepos) Unlock(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.LoginThrottleRepo.Unlock(ctx, login_throttle.UnlockRequest{
+		UnlockHash: params["hash"],
+	}, ctxValues.Now)
+	if err != nil {
+		switch errors.Cause(err) {
+		case login_throttle.ErrUnlockExpired:
+			webcontext.SessionFlashError(ctx,
+				"Unlock Expired",
+				"The link has expired, sign in is unlocked automatically once the lockout ends.")
+			return web.Redirect(ctx, w, r, "/user/login", http.StatusFound)
+		default:
+			if _, ok := weberror.NewValidationError(ctx, err); ok {
+				webcontext.SessionFlashError(ctx,
+					"Invalid Unlock Link",
+					"The link is invalid, copy the complete link from the email.")
+				return web.Redirect(ctx, w, r, "/user/login", http.StatusFound)
+			}
+			return web.RenderError(ctx, w, r, err, h.Renderer, TmplLayoutBase, TmplContentErrorGeneric, web.MIMETextHTMLCharsetUTF8)
+		}
+	}
+
+	webcontext.SessionFlashSuccess(ctx,
+		"Sign In Unlocked",
+		"You can sign in to your account again.")
+
+	return web.Redirect(ctx, w, r, "/user/login", http.StatusFound)
+}
+
 // ResetConfirm handles changing a users password after they have clicked on the link emailed.
 func (h *UserRepos) ResetConfirm(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
 
@@ -261,8 +994,13 @@ func (h *UserRepos) ResetConfirm(ctx context.Context, w http.ResponseWriter, r *
 			token, err := h.AuthRepo.Authenticate(ctx, user_auth.AuthenticateRequest{
 				Email:    u.Email,
 				Password: req.Password,
-			}, time.Hour, ctxValues.Now)
+			}, user_auth.AccessTokenTTL, ctxValues.Now)
 			if err != nil {
+				switch errors.Cause(err) {
+				case user_auth.ErrTwoFactorRequired, user_auth.ErrTwoFactorEnrollmentRequired:
+					return true, handleTwoFactorChallenge(ctx, w, r, token, false)
+				}
+
 				if verr, ok := weberror.NewValidationError(ctx, err); ok {
 					data["validationErrors"] = verr.(*weberror.Error)
 					return false, nil
@@ -272,7 +1010,7 @@ func (h *UserRepos) ResetConfirm(ctx context.Context, w http.ResponseWriter, r *
 			}
 
 			// Add the token to the users session.
-			err = handleSessionToken(ctx, w, r, token)
+			err = handleSessionToken(ctx, w, r, h.AuthRepo, token, time.Hour)
 			if err != nil {
 				return false, err
 			}
@@ -544,12 +1282,9 @@ func (h *UserRepos) VirtualLogin(ctx context.Context, w http.ResponseWriter, r *
 
 		if req.UserID != "" {
 			sess := webcontext.ContextSession(ctx)
-			var expires time.Duration
-			if sess != nil && sess.Options != nil {
-				expires = time.Second * time.Duration(sess.Options.MaxAge)
-			} else {
-				expires = time.Hour
-			}
+
+			// The access token is short lived and renewed with the refresh token of the session.
+			expires := user_auth.AccessTokenTTL
 
 			// Perform the account switch.
 			tkn, err := h.AuthRepo.VirtualLogin(ctx, claims, *req, expires, ctxValues.Now)
@@ -651,12 +1386,8 @@ func (h *UserRepos) VirtualLogout(ctx context.Context, w http.ResponseWriter, r
 
 	sess := webcontext.ContextSession(ctx)
 
-	var expires time.Duration
-	if sess != nil && sess.Options != nil {
-		expires = time.Second * time.Duration(sess.Options.MaxAge)
-	} else {
-		expires = time.Hour
-	}
+	// The access token is short lived and renewed with the refresh token of the session.
+	expires := user_auth.AccessTokenTTL
 
 	tkn, err := h.AuthRepo.VirtualLogout(ctx, claims, expires, ctxValues.Now)
 	if err != nil {
@@ -750,12 +1481,9 @@ func (h *UserRepos) SwitchAccount(ctx context.Context, w http.ResponseWriter, r
 
 		if req.AccountID != "" {
 			sess := webcontext.ContextSession(ctx)
-			var expires time.Duration
-			if sess != nil && sess.Options != nil {
-				expires = time.Second * time.Duration(sess.Options.MaxAge)
-			} else {
-				expires = time.Hour
-			}
+
+			// The access token is short lived and renewed with the refresh token of the session.
+			expires := user_auth.AccessTokenTTL
 
 			// Perform the account switch.
 			tkn, err := h.AuthRepo.SwitchAccount(ctx, claims, *req, expires, ctxValues.Now)
@@ -816,22 +1544,48 @@ func (h *UserRepos) SwitchAccount(ctx context.Context, w http.ResponseWriter, r
 	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "user-switch-account.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
 }
 
-// handleSessionToken persists the access token to the session for request authentication.
-func handleSessionToken(ctx context.Context, w http.ResponseWriter, r *http.Request, token user_auth.Token) error {
+// handleSessionToken starts a session for the token and persists the access token and refresh
+// token to the session for request authentication. The session can be refreshed until the
+// session TTL has elapsed or it's revoked.
+func handleSessionToken(ctx context.Context, w http.ResponseWriter, r *http.Request, authRepo *user_auth.Repository, token user_auth.Token, sessionTTL time.Duration) error {
 	if token.AccessToken == "" {
 		return errors.New("accessToken is required.")
 	}
 
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Record the device of the session so the user can recognize it when listed.
+	userAgent := r.UserAgent()
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+	ipAddress := web.RequestRealIP(r)
+	if net.ParseIP(ipAddress) == nil {
+		ipAddress = ""
+	}
+
+	token, err = authRepo.StartSession(ctx, token, user_auth.SessionRequest{
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+	}, sessionTTL, ctxValues.Now)
+	if err != nil {
+		return err
+	}
+
 	sess := webcontext.ContextSession(ctx)
 
 	sess.Options = &sessions.Options{
 		Path:     "/",
-		MaxAge:   int(token.TTL.Seconds()),
-		HttpOnly: false,
+		MaxAge:   int(sessionTTL.Seconds()),
+		HttpOnly: true,
 	}
 
 	sess = webcontext.SessionInit(sess,
 		token.AccessToken)
+	sess = webcontext.SessionUpdateRefreshToken(sess, token.RefreshToken)
 	if err := sess.Save(r, w); err != nil {
 		return err
 	}
@@ -839,6 +1593,25 @@ func handleSessionToken(ctx context.Context, w http.ResponseWriter, r *http.Requ
 	return nil
 }
 
+// handleTwoFactorChallenge persists the challenge issued when the first factor was verified to
+// the session and redirects the user to enter their code.
+func handleTwoFactorChallenge(ctx context.Context, w http.ResponseWriter, r *http.Request, token user_auth.Token, rememberMe bool) error {
+	if token.TwoFactorChallenge == "" {
+		return errors.New("twoFactorChallenge is required.")
+	}
+
+	sess := webcontext.ContextSession(ctx)
+	webcontext.SessionUpdateTwoFactorChallenge(sess, token.TwoFactorChallenge, rememberMe)
+
+	// The redirect saves the session.
+	redirectUri := urlUserLoginTwoFactor()
+	if qv := r.URL.Query().Get("redirect"); qv != "" {
+		redirectUri += "?redirect=" + url.QueryEscape(qv)
+	}
+
+	return web.Redirect(ctx, w, r, redirectUri, http.StatusFound)
+}
+
 // updateContextClaims updates the claims in the context.
 func updateContextClaims(ctx context.Context, authenticator *auth.Authenticator, claims auth.Claims) (context.Context, error) {
 	tkn, err := authenticator.GenerateToken(claims)
@@ -18,6 +18,9 @@ import (
 	"exitor-dapp/internal/user_account"
 	"exitor-dapp/internal/user_account/invite"
 	"exitor-dapp/internal/user_auth"
+	"exitor-dapp/internal/user_kyc"
+	"exitor-dapp/internal/user_session"
+	"exitor-dapp/internal/user_totp"
 
 	"github.com/dustin/go-humanize/english"
 	"github.com/gorilla/schema"
@@ -32,6 +35,9 @@ type Users struct {
 	AccountRepo     *account.Repository
 	UserAccountRepo *user_account.Repository
 	AuthRepo        *user_auth.Repository
+	UserSessionRepo *user_session.Repository
+	UserTotpRepo    *user_totp.Repository
+	UserKycRepo     *user_kyc.Repository
 	InviteRepo      *invite.Repository
 	GeoRepo         *geonames.Repository
 	MasterDB        *sqlx.DB
@@ -62,13 +68,13 @@ func urlUsersUpdate(userID string) string {
 // UserCreateRequest extends the UserCreateRequest with a list of roles.
 type UserCreateRequest struct {
 	user.UserCreateRequest
-	Roles user_account.UserAccountRoles `json:"roles" validate:"required,dive,oneof=admin user" enums:"admin,user" swaggertype:"array,string" example:"admin"`
+	Roles user_account.UserAccountRoles `json:"roles" validate:"required,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"admin"`
 }
 
 // UserUpdateRequest extends the UserUpdateRequest with a list of roles.
 type UserUpdateRequest struct {
 	user.UserUpdateRequest
-	Roles user_account.UserAccountRoles `json:"roles" validate:"required,dive,oneof=admin user" enums:"admin,user" swaggertype:"array,string" example:"admin"`
+	Roles user_account.UserAccountRoles `json:"roles" validate:"required,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"admin"`
 }
 
 // Index handles listing all the users for the current account.
@@ -337,11 +343,75 @@ func (h *Users) View(ctx context.Context, w http.ResponseWriter, r *http.Request
 					return false, err
 				}
 
+				// Sign out the archived user from all their devices.
+				err = h.UserSessionRepo.RevokeAll(ctx, claims, user_session.UserSessionRevokeAllRequest{
+					UserID: userID,
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+
 				webcontext.SessionFlashSuccess(ctx,
 					"User Archive",
 					"User successfully archive.")
 
 				return true, web.Redirect(ctx, w, r, urlUsersIndex(), http.StatusFound)
+
+			case "revoke-sessions":
+				err = h.UserSessionRepo.RevokeAll(ctx, claims, user_session.UserSessionRevokeAllRequest{
+					UserID: userID,
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+
+				webcontext.SessionFlashSuccess(ctx,
+					"Sessions Revoked",
+					"The user will be signed out of all their devices when their access expires.")
+
+				return true, web.Redirect(ctx, w, r, urlUsersView(userID), http.StatusFound)
+
+			case "reset-two-factor":
+				err = h.UserTotpRepo.Reset(ctx, claims, user_totp.UserTotpResetRequest{
+					UserID: userID,
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+
+				webcontext.SessionFlashSuccess(ctx,
+					"Two-Factor Authentication Reset",
+					"The user can sign in with their password and enroll again.")
+
+				return true, web.Redirect(ctx, w, r, urlUsersView(userID), http.StatusFound)
+
+			case "kyc":
+				req := user_kyc.UserKycReviewRequest{
+					UserID: userID,
+					Status: user_kyc.UserKycStatus(r.PostForm.Get("status")),
+					Note:   r.PostForm.Get("note"),
+				}
+
+				if v := r.PostForm.Get("expires_at"); v != "" {
+					expires, err := time.Parse("2006-01-02", v)
+					if err != nil {
+						return false, weberror.NewErrorMessage(ctx, err, http.StatusBadRequest, "Expiry must be a date.")
+					}
+					req.ExpiresAt = &expires
+				}
+
+				m, err := h.UserKycRepo.Review(ctx, claims, req, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+
+				msg := "Holdings of default frozen assets will be frozen for the user."
+				if m.IsApproved(ctxValues.Now) {
+					msg = "Holdings of default frozen assets will be unfrozen for the user."
+				}
+				webcontext.SessionFlashSuccess(ctx, "KYC Status Updated", msg)
+
+				return true, web.Redirect(ctx, w, r, urlUsersView(userID), http.StatusFound)
 			}
 		}
 
@@ -374,6 +444,34 @@ func (h *Users) View(ctx context.Context, w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	// Only admins and the user can view the status of their two-factor authentication.
+	userTotp, err := h.UserTotpRepo.ReadByUserID(ctx, claims, userID)
+	if err != nil {
+		switch errors.Cause(err) {
+		case user_totp.ErrNotFound:
+			data["canViewTotp"] = true
+		case user_totp.ErrForbidden:
+		default:
+			return err
+		}
+	} else {
+		data["canViewTotp"] = true
+		data["userTotpEnabled"] = userTotp.IsVerified()
+	}
+
+	// The KYC status is only shown to the users that can view the users of the account.
+	if h.UserKycRepo.CanReadUserKyc(ctx, claims, userID, claims.Audience) == nil {
+		data["canViewKyc"] = true
+
+		userKyc, err := h.UserKycRepo.Read(ctx, claims, userID, claims.Audience)
+		if err != nil && errors.Cause(err) != user_kyc.ErrNotFound {
+			return err
+		} else if userKyc != nil {
+			data["userKyc"] = userKyc.Response(ctx, ctxValues.Now)
+		}
+	}
+	data["kycStatuses"] = user_kyc.UserKycStatus_ValuesInterface()
+
 	data["urlUsersView"] = urlUsersView(userID)
 	data["urlUsersUpdate"] = urlUsersUpdate(userID)
 	data["urlUserVirtualLogin"] = urlUserVirtualLogin(userID)
@@ -712,8 +810,13 @@ func (h *Users) InviteAccept(ctx context.Context, w http.ResponseWriter, r *http
 				Email:     usr.Email,
 				Password:  req.Password,
 				AccountID: hash.AccountID,
-			}, time.Hour, ctxValues.Now)
+			}, user_auth.AccessTokenTTL, ctxValues.Now)
 			if err != nil {
+				switch errors.Cause(err) {
+				case user_auth.ErrTwoFactorRequired, user_auth.ErrTwoFactorEnrollmentRequired:
+					return true, handleTwoFactorChallenge(ctx, w, r, token, false)
+				}
+
 				if verr, ok := weberror.NewValidationError(ctx, err); ok {
 					data["validationErrors"] = verr.(*weberror.Error)
 					return false, nil
@@ -723,7 +826,7 @@ func (h *Users) InviteAccept(ctx context.Context, w http.ResponseWriter, r *http
 			}
 
 			// Add the token to the users session.
-			err = handleSessionToken(ctx, w, r, token)
+			err = handleSessionToken(ctx, w, r, h.AuthRepo, token, time.Hour)
 			if err != nil {
 				return false, err
 			}
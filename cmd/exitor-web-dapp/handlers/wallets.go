@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web"
+	"exitor-dapp/internal/platform/web/webcontext"
+	"exitor-dapp/internal/platform/web/weberror"
+	"exitor-dapp/internal/wallet"
+
+	"github.com/pkg/errors"
+)
+
+// Wallets represents the Wallet address method handler set.
+type Wallets struct {
+	WalletRepo *wallet.Repository
+	Renderer   web.Renderer
+}
+
+func urlWalletsIndex() string {
+	return fmt.Sprintf("/user/wallets")
+}
+
+func urlWalletsConnect(walletAddressID string) string {
+	return fmt.Sprintf("/user/wallets/%s/connect", walletAddressID)
+}
+
+// Index handles listing the wallet addresses linked by the current user. Admins also see the
+// addresses of the account and can set the default issuer of the account. The multisig accounts
+// of the account are listed for all users, users with the permission to manage the account can
+// define new ones.
+func (h *Wallets) Index(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := new(wallet.WalletAddressCreateRequest)
+	msReq := new(wallet.WalletMultisigCreateRequest)
+	data := make(map[string]interface{})
+	f := func() (bool, error) {
+		if r.Method == http.MethodPost {
+			err := r.ParseForm()
+			if err != nil {
+				return false, err
+			}
+
+			switch r.PostForm.Get("action") {
+			case "link":
+				req.AccountID = claims.Audience
+				req.Address = strings.TrimSpace(r.PostForm.Get("Address"))
+				req.Label = strings.TrimSpace(r.PostForm.Get("Label"))
+
+				// Admins link addresses to the account unless it's their own wallet.
+				if !claims.HasRole(auth.RoleAdmin) || r.PostForm.Get("Owner") != "account" {
+					req.UserID = &claims.Subject
+				}
+
+				m, err := h.WalletRepo.Create(ctx, claims, *req, ctxValues.Now)
+				if err != nil {
+					switch errors.Cause(err) {
+					case wallet.ErrForbidden:
+						return false, err
+					default:
+						if verr, ok := weberror.NewValidationError(ctx, err); ok {
+							data["validationErrors"] = verr.(*weberror.Error)
+							return false, nil
+						} else {
+							return false, err
+						}
+					}
+				}
+
+				webcontext.SessionFlashInfo(ctx,
+					"Address Linked",
+					"Sign the message with your wallet to prove you own the address.")
+
+				return true, web.Redirect(ctx, w, r, urlWalletsConnect(m.ID), http.StatusFound)
+
+			case "default_issuer":
+				err = h.WalletRepo.SetDefaultIssuer(ctx, claims, wallet.WalletAddressDefaultIssuerRequest{
+					ID: r.PostForm.Get("id"),
+				}, ctxValues.Now)
+				if err != nil {
+					switch errors.Cause(err) {
+					case wallet.ErrNotVerified:
+						webcontext.SessionFlashError(ctx,
+							"Address Not Verified",
+							"Only addresses that have been verified can issue assets.")
+					default:
+						return false, err
+					}
+				} else {
+					webcontext.SessionFlashSuccess(ctx,
+						"Default Issuer Updated",
+						"New assets of the account will be created by the address.")
+				}
+
+				return true, web.Redirect(ctx, w, r, urlWalletsIndex(), http.StatusFound)
+
+			case "archive":
+				err = h.WalletRepo.Archive(ctx, claims, wallet.WalletAddressArchiveRequest{
+					ID: r.PostForm.Get("id"),
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+
+				webcontext.SessionFlashSuccess(ctx,
+					"Address Removed",
+					"The address is no longer linked to your account.")
+
+				return true, web.Redirect(ctx, w, r, urlWalletsIndex(), http.StatusFound)
+
+			case "multisig":
+				msReq.AccountID = claims.Audience
+				msReq.Label = strings.TrimSpace(r.PostForm.Get("Label"))
+				msReq.Threshold, _ = strconv.Atoi(r.PostForm.Get("Threshold"))
+
+				// Members are listed one per line in the order that derives the address.
+				for _, l := range strings.Split(r.PostForm.Get("Members"), "\n") {
+					if l = strings.TrimSpace(l); l != "" {
+						msReq.Members = append(msReq.Members, l)
+					}
+				}
+
+				m, err := h.WalletRepo.CreateMultisig(ctx, claims, *msReq, ctxValues.Now)
+				if err != nil {
+					switch errors.Cause(err) {
+					case wallet.ErrForbidden:
+						return false, err
+					case wallet.ErrInvalidMultisig, wallet.ErrMultisigExists:
+						webcontext.SessionFlashError(ctx,
+							"Invalid Multisig",
+							err.Error())
+						return true, web.Redirect(ctx, w, r, urlWalletsIndex(), http.StatusFound)
+					default:
+						if verr, ok := weberror.NewValidationError(ctx, err); ok {
+							data["multisigValidationErrors"] = verr.(*weberror.Error)
+							return false, nil
+						} else {
+							return false, err
+						}
+					}
+				}
+
+				webcontext.SessionFlashSuccess(ctx,
+					"Multisig Created",
+					fmt.Sprintf("Transactions sent from %s must be signed by %d of its %d members.", m.Address, m.Threshold, len(m.Members)))
+
+				return true, web.Redirect(ctx, w, r, urlWalletsIndex(), http.StatusFound)
+
+			case "archive_multisig":
+				err = h.WalletRepo.ArchiveMultisig(ctx, claims, wallet.WalletMultisigArchiveRequest{
+					ID: r.PostForm.Get("id"),
+				}, ctxValues.Now)
+				if err != nil {
+					return false, err
+				}
+
+				webcontext.SessionFlashSuccess(ctx,
+					"Multisig Removed",
+					"The multisig is no longer listed for the account.")
+
+				return true, web.Redirect(ctx, w, r, urlWalletsIndex(), http.StatusFound)
+			}
+		}
+
+		return false, nil
+	}
+
+	end, err := f()
+	if err != nil {
+		return web.RenderError(ctx, w, r, err, h.Renderer, TmplLayoutBase, TmplContentErrorGeneric, web.MIMETextHTMLCharsetUTF8)
+	} else if end {
+		return nil
+	}
+
+	res, err := h.WalletRepo.Find(ctx, claims, wallet.WalletAddressFindRequest{
+		Order: []string{"default_issuer desc", "created_at"},
+	})
+	if err != nil {
+		return err
+	}
+
+	type walletRow struct {
+		*wallet.WalletAddressResponse
+		URLConnect string
+	}
+
+	var rows []walletRow
+	for _, m := range res {
+		rows = append(rows, walletRow{
+			WalletAddressResponse: m.Response(ctx),
+			URLConnect:            urlWalletsConnect(m.ID),
+		})
+	}
+	data["wallets"] = rows
+
+	multisigs, err := h.WalletRepo.FindMultisigs(ctx, claims, claims.Audience)
+	if err != nil {
+		return err
+	}
+	data["multisigs"] = multisigs.Response(ctx)
+
+	data["form"] = req
+	data["multisigForm"] = msReq
+	data["multisigMembers"] = strings.Join(msReq.Members, "\n")
+
+	if verr, ok := weberror.NewValidationError(ctx, webcontext.Validator().Struct(wallet.WalletAddressCreateRequest{})); ok {
+		data["validationDefaults"] = verr.(*weberror.Error)
+	}
+
+	if verr, ok := weberror.NewValidationError(ctx, webcontext.Validator().Struct(wallet.WalletMultisigCreateRequest{})); ok {
+		data["multisigValidationDefaults"] = verr.(*weberror.Error)
+	}
+
+	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "user-wallets.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
+}
+
+// Connect handles proving the ownership of a wallet address. A new challenge is issued each
+// time the page is displayed and the signature of the message is verified when posted.
+func (h *Wallets) Connect(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+
+	walletAddressID := params["wallet_address_id"]
+
+	ctxValues, err := webcontext.ContextValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	claims, err := auth.ClaimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string]interface{})
+	f := func() (bool, error) {
+		if r.Method == http.MethodPost {
+			err := r.ParseForm()
+			if err != nil {
+				return false, err
+			}
+
+			_, err = h.WalletRepo.Verify(ctx, claims, wallet.WalletAddressVerifyRequest{
+				ID:        walletAddressID,
+				Signature: strings.TrimSpace(r.PostForm.Get("Signature")),
+			}, ctxValues.Now)
+			if err != nil {
+				switch errors.Cause(err) {
+				case wallet.ErrForbidden:
+					return false, err
+				case wallet.ErrChallengeExpired:
+					webcontext.SessionFlashError(ctx,
+						"Message Expired",
+						"The message has expired, sign the new message below.")
+				case auth.ErrInvalidAlgorandSignature:
+					webcontext.SessionFlashError(ctx,
+						"Invalid Signature",
+						"The message was not signed by the key of the address.")
+				default:
+					if verr, ok := weberror.NewValidationError(ctx, err); ok {
+						weberror.SessionFlashError(ctx, verr)
+					} else {
+						return false, err
+					}
+				}
+
+				return true, web.Redirect(ctx, w, r, urlWalletsConnect(walletAddressID), http.StatusFound)
+			}
+
+			webcontext.SessionFlashSuccess(ctx,
+				"Address Verified",
+				"You have proven you own the address.")
+
+			return true, web.Redirect(ctx, w, r, urlWalletsIndex(), http.StatusFound)
+		}
+
+		return false, nil
+	}
+
+	end, err := f()
+	if err != nil {
+		return web.RenderError(ctx, w, r, err, h.Renderer, TmplLayoutBase, TmplContentErrorGeneric, web.MIMETextHTMLCharsetUTF8)
+	} else if end {
+		return nil
+	}
+
+	m, err := h.WalletRepo.ReadByID(ctx, claims, walletAddressID)
+	if err != nil {
+		return err
+	}
+	data["wallet"] = m.Response(ctx)
+
+	if !m.IsVerified() {
+		challenge, err := h.WalletRepo.Challenge(ctx, claims, walletAddressID, ctxValues.Now)
+		if err != nil {
+			return err
+		}
+		data["challenge"] = challenge
+	}
+
+	data["urlWalletsIndex"] = urlWalletsIndex()
+
+	return h.Renderer.Render(ctx, w, r, TmplLayoutBase, "algorandauth.gohtml", web.MIMETextHTMLCharsetUTF8, http.StatusOK, data)
+}
@@ -20,11 +20,16 @@ import (
 	"syscall"
 	"time"
 
-	"exitor-dapp/cmd/web-app/handlers"
+	"exitor-dapp/cmd/exitor-web-dapp/handlers"
 	"exitor-dapp/internal/account"
 	"exitor-dapp/internal/account/account_preference"
-	"exitor-dapp/internal/checklist"
+	"exitor-dapp/internal/account/account_role"
+	"exitor-dapp/internal/account/api_client"
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/createasset"
 	"exitor-dapp/internal/geonames"
+	"exitor-dapp/internal/keystore"
+	"exitor-dapp/internal/login_throttle"
 	"exitor-dapp/internal/mid"
 	"exitor-dapp/internal/platform/auth"
 	"exitor-dapp/internal/platform/flag"
@@ -39,15 +44,12 @@ import (
 	"exitor-dapp/internal/user_account"
 	"exitor-dapp/internal/user_account/invite"
 	"exitor-dapp/internal/user_auth"
+	"exitor-dapp/internal/user_kyc"
+	"exitor-dapp/internal/user_session"
+	"exitor-dapp/internal/user_totp"
+	"exitor-dapp/internal/wallet"
 	"exitor-dapp/internal/webroute"
 
-	"encoding/base64"
-
-	"github.com/algorand/go-algorand-sdk/client/algod"
-	"github.com/algorand/go-algorand-sdk/crypto"
-	"github.com/algorand/go-algorand-sdk/mnemonic"
-	"github.com/algorand/go-algorand-sdk/transaction"
-
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
@@ -76,32 +78,45 @@ var build = "develop"
 // ie: export WEB_APP_ENV=dev
 var service = "WEB_APP"
 
-const algodAddress = "https://testnet-algorand.api.purestake.io/ps1"
-const psToken = "..."
-
-
-// Initialize throw-away account for this example - check
-// that it has funds before running the program
-const mnemonic = "..."
-const ownerAddress = "..."// Will hardcode this or derive it from mnemonic
-
-func main() {
+// algorandNetworkConfig defines the config values that can be used to override
+// the defaults for an Algorand network, ie: WEB_APP_ALGORAND_TESTNET_API_KEY
+type algorandNetworkConfig struct {
+	AlgodURL     string `envconfig:"ALGOD_URL" example:"https://testnet-algorand.api.purestake.io/ps2"`
+	AlgodToken   string `envconfig:"ALGOD_TOKEN" json:"-"` // don't print
+	IndexerURL   string `envconfig:"INDEXER_URL" example:"https://testnet-algorand.api.purestake.io/idx2"`
+	IndexerToken string `envconfig:"INDEXER_TOKEN" json:"-"` // don't print
+	ApiKeyHeader string `envconfig:"API_KEY_HEADER" example:"X-API-Key"`
+	ApiKey       string `envconfig:"API_KEY" json:"-"` // don't print
+	ExplorerURL  string `envconfig:"EXPLORER_URL" example:"https://goalseeker.purestake.io/algorand/testnet"`
+}
 
-	// Initialize Algod and Algod.Client during Startup
-	var headers [] *algod.Header
-	headers = append(headers, &algod.Header{"X-API-Key", psToken})
-	algodClient, err := algod.MakeClientWithHeaders(algodAddress, "", headers)
-	if err != nil {
-		fmt.Printf("failed to make algod client: %s\n", err)
-		return
+// apply overrides the values of the network config with any values set.
+func (c algorandNetworkConfig) apply(n algosdk.NetworkConfig) algosdk.NetworkConfig {
+	if c.AlgodURL != "" {
+		n.AlgodURL = c.AlgodURL
 	}
-
-	// Recover private key from the mnemonic
-	fromAddrPvtKey, err := mnemonic.ToPrivateKey(mnemonic)
-	if err != nil {
-		fmt.Printf("error getting suggested tx params: %s\n", err)
+	if c.AlgodToken != "" {
+		n.AlgodToken = c.AlgodToken
+	}
+	if c.IndexerURL != "" {
+		n.IndexerURL = c.IndexerURL
+	}
+	if c.IndexerToken != "" {
+		n.IndexerToken = c.IndexerToken
 	}
+	if c.ApiKeyHeader != "" {
+		n.ApiKeyHeader = c.ApiKeyHeader
+	}
+	if c.ApiKey != "" {
+		n.ApiKey = c.ApiKey
+	}
+	if c.ExplorerURL != "" {
+		n.ExplorerURL = c.ExplorerURL
+	}
+	return n
+}
 
+func main() {
 
 	// =========================================================================
 	// Logging
@@ -184,7 +199,50 @@ func main() {
 		}
 		Auth struct {
 			UseAwsSecretManager bool          `default:"false" envconfig:"USE_AWS_SECRET_MANAGER"`
+			UseDbStorage        bool          `default:"false" envconfig:"USE_DB_STORAGE"`
 			KeyExpiration       time.Duration `default:"3600s" envconfig:"KEY_EXPIRATION"`
+			// TokenTTL is the longest duration tokens are issued for, keys stored in the
+			// database are kept to verify tokens until it has elapsed.
+			TokenTTL       time.Duration `default:"36h" envconfig:"TOKEN_TTL"`
+			RotateInterval time.Duration `default:"60s" envconfig:"ROTATE_INTERVAL"`
+		}
+		Algorand struct {
+			Network       string        `default:"testnet" envconfig:"NETWORK" example:"testnet"`
+			WatchInterval time.Duration `default:"5s" envconfig:"WATCH_INTERVAL"`
+			// WhitelistInterval is how often the holdings of assets that are frozen by default
+			// are frozen and unfrozen to match the users approved by KYC.
+			WhitelistInterval time.Duration `default:"60s" envconfig:"WHITELIST_INTERVAL"`
+			// VestingInterval is how often the units of vesting grants that have vested are
+			// released from the reserve of their asset.
+			VestingInterval time.Duration `default:"1h" envconfig:"VESTING_INTERVAL"`
+			// Signer is one of external, custodial, keystore or test. Only external and
+			// custodial are permitted in prod, transactions for accounts not owned by
+			// Exitor are then signed offline by the owner of the account.
+			Signer           string                `default:"external" envconfig:"SIGNER" example:"keystore"`
+			KeystoreDir      string                `default:"./.keystore" envconfig:"KEYSTORE_DIR"`
+			KeystorePassword string                `envconfig:"KEYSTORE_PASSWORD" json:"-"` // don't print
+			Mainnet          algorandNetworkConfig `envconfig:"MAINNET"`
+			Testnet          algorandNetworkConfig `envconfig:"TESTNET"`
+			Betanet          algorandNetworkConfig `envconfig:"BETANET"`
+			Sandbox          algorandNetworkConfig `envconfig:"SANDBOX"`
+			// MetadataBaseUrl is the short base URL of the web app the ARC-3 metadata of assets
+			// is served from. The asset URL is limited to 32 bytes, when empty the metadata of
+			// assets is not published.
+			MetadataBaseUrl string `envconfig:"METADATA_BASE_URL" example:"https://exitor.io"`
+		}
+		Keystore struct {
+			// MasterKeyStorage is one of secret, file or aws. The secret storage derives
+			// the master key from the shared secret key of the project. MasterKeyDir is
+			// required by the file storage.
+			MasterKeyStorage    string        `default:"secret" envconfig:"MASTER_KEY_STORAGE" example:"aws"`
+			MasterKeyDir        string        `envconfig:"MASTER_KEY_DIR" example:"/var/lib/exitor/master-keys"`
+			MasterKeyExpiration time.Duration `default:"0s" envconfig:"MASTER_KEY_EXPIRATION" example:"2160h"`
+			// RotateInterval is how often a new master key is generated once the current
+			// one has expired and the keys still using a previous master key are re-encrypted.
+			RotateInterval time.Duration `default:"1h" envconfig:"ROTATE_INTERVAL"`
+			// PreviousSharedSecretKeys are needed to rotate keys after the shared secret
+			// key has changed.
+			PreviousSharedSecretKeys []string `envconfig:"PREVIOUS_SHARED_SECRET_KEYS" json:"-"` // don't print
 		}
 		BuildInfo struct {
 			CiCommitRefName  string `envconfig:"CI_COMMIT_REF_NAME"`
@@ -457,12 +515,43 @@ func main() {
 		notifyEmail = notify.NewEmailDisabled()
 	}
 
+	// =========================================================================
+	// Init keystore for custodial accounts and the auth keys stored in the database
+	log.Printf("main : Started : Initialize keystore : %s", cfg.Keystore.MasterKeyStorage)
+	var masterKeys keystore.MasterKeyStorage
+	switch cfg.Keystore.MasterKeyStorage {
+	case "secret":
+		masterKeys, err = keystore.NewMasterKeySecret(cfg.Project.SharedSecretKey, cfg.Keystore.PreviousSharedSecretKeys...)
+	case "file":
+		masterKeys, err = keystore.NewMasterKeyFile(cfg.Keystore.MasterKeyDir, time.Now().UTC(), cfg.Keystore.MasterKeyExpiration)
+	case "aws":
+		secretName := filepath.Join(cfg.Aws.SecretsManagerConfigPrefix, "keystore")
+		masterKeys, err = keystore.NewMasterKeyAws(awsSession, secretName, time.Now().UTC(), cfg.Keystore.MasterKeyExpiration)
+	default:
+		err = errors.Errorf("invalid master key storage %s", cfg.Keystore.MasterKeyStorage)
+	}
+	if err != nil {
+		log.Fatalf("main : Keystore : %+v", err)
+	}
+	keystoreRepo := keystore.NewRepository(masterDb, masterKeys)
+
+	// Re-encrypt any keys still using a previous master key, only the master key ID is logged.
+	rotated, err := keystoreRepo.Rotate(context.Background(), auth.Claims{}, time.Now().UTC())
+	if err != nil {
+		log.Fatalf("main : Keystore : Rotate : %+v", err)
+	}
+	log.Printf("main : Keystore : Master key %s : Rotated %d keys", masterKeys.Current().ID(), rotated)
+
 	// =========================================================================
 	// Init new Authenticator
 	var authenticator *auth.Authenticator
 	if cfg.Auth.UseAwsSecretManager {
 		secretName := filepath.Join(cfg.Aws.SecretsManagerConfigPrefix, "authenticator")
 		authenticator, err = auth.NewAuthenticatorAws(awsSession, secretName, time.Now().UTC(), cfg.Auth.KeyExpiration)
+	} else if cfg.Auth.UseDbStorage {
+		// Keys stored in the database are shared by all the instances of the web app and the API,
+		// they are encrypted with the master keys of the keystore.
+		authenticator, err = auth.NewAuthenticatorDB(masterDb, keystore.NewAuthKeyEncrypter(masterKeys), time.Now().UTC(), cfg.Auth.KeyExpiration, cfg.Auth.TokenTTL)
 	} else {
 		authenticator, err = auth.NewAuthenticatorFile("", time.Now().UTC(), cfg.Auth.KeyExpiration)
 	}
@@ -470,12 +559,73 @@ func main() {
 		log.Fatalf("main : Constructing authenticator : %+v", err)
 	}
 
+	// =========================================================================
+	// Init Algorand client
+	log.Println("main : Started : Initialize Algorand client")
+	algoClient, err := algosdk.New()
+	if err != nil {
+		log.Fatalf("main : Algorand client : %+v", err)
+	}
+	for _, n := range algosdk.DefaultNetworkConfigs() {
+		switch n.Name {
+		case algosdk.NetworkMainnet:
+			n = cfg.Algorand.Mainnet.apply(n)
+		case algosdk.NetworkTestnet:
+			n = cfg.Algorand.Testnet.apply(n)
+		case algosdk.NetworkBetanet:
+			n = cfg.Algorand.Betanet.apply(n)
+		case algosdk.NetworkSandbox:
+			n = cfg.Algorand.Sandbox.apply(n)
+		}
+
+		if err := algoClient.Register(n); err != nil {
+			log.Fatalf("main : Algorand client : Register %s : %+v", n.Name, err)
+		}
+	}
+	if err := algoClient.SelectNetwork(cfg.Algorand.Network); err != nil {
+		log.Fatalf("main : Algorand client : %+v", err)
+	}
+
+	// =========================================================================
+	// Init Algorand signer
+	log.Printf("main : Started : Initialize Algorand signer : %s", cfg.Algorand.Signer)
+	if webcontext.Env(cfg.Env) == webcontext.Env_Prod && cfg.Algorand.Signer != algosdk.SignerExternal && cfg.Algorand.Signer != algosdk.SignerCustodial {
+		log.Fatalf("main : Algorand signer : %s signer is not permitted in %s", cfg.Algorand.Signer, cfg.Env)
+	}
+	var algoSigner algosdk.Signer
+	switch cfg.Algorand.Signer {
+	case algosdk.SignerExternal:
+		algoSigner = algosdk.NewExternalSigner()
+	case algosdk.SignerCustodial:
+		algoSigner = keystoreRepo
+	case algosdk.SignerKeystore:
+		keystoreSigner, err := algosdk.NewLocalKeystoreSigner(cfg.Algorand.KeystoreDir, cfg.Algorand.KeystorePassword)
+		if err != nil {
+			log.Fatalf("main : Algorand signer : %+v", err)
+		}
+
+		addrs, err := keystoreSigner.Addresses()
+		if err != nil {
+			log.Fatalf("main : Algorand signer : %+v", err)
+		}
+		log.Printf("main : Algorand signer : Keystore %s : Accounts %v", cfg.Algorand.KeystoreDir, addrs)
+
+		algoSigner = keystoreSigner
+	case algosdk.SignerTest:
+		testSigner := algosdk.NewTestSigner(1)
+		log.Printf("main : Algorand signer : Generated accounts %v", testSigner.Addresses())
+
+		algoSigner = testSigner
+	default:
+		log.Fatalf("main : Algorand signer : Invalid signer %s, must be one of %v", cfg.Algorand.Signer, algosdk.Signer_Values)
+	}
+
 	// =========================================================================
 	// Init repositories and AppContext
 
 	webRoute, err := webroute.New(cfg.Project.WebApiBaseUrl, cfg.Service.BaseUrl)
 	if err != nil {
-		log.Fatalf("main : checklist routes : %+v", cfg.Service.BaseUrl, err)
+		log.Fatalf("main : WebRoute : %s : %+v", cfg.Service.BaseUrl, err)
 	}
 
 	usrRepo := user.NewRepository(masterDb, webRoute.UserResetPassword, notifyEmail, cfg.Project.SharedSecretKey)
@@ -483,31 +633,48 @@ func main() {
 	accRepo := account.NewRepository(masterDb)
 	geoRepo := geonames.NewRepository(masterDb)
 	accPrefRepo := account_preference.NewRepository(masterDb)
-	authRepo := user_auth.NewRepository(masterDb, authenticator, usrRepo, usrAccRepo, accPrefRepo)
+	accRoleRepo := account_role.NewRepository(masterDb)
+	walletRepo := wallet.NewRepository(masterDb)
+	apiClientRepo := api_client.NewRepository(masterDb)
+	userSessionRepo := user_session.NewRepository(masterDb)
+	userTotpRepo := user_totp.NewRepository(masterDb)
+	userKycRepo := user_kyc.NewRepository(masterDb)
+	loginThrottleRepo := login_throttle.NewRepository(masterDb, redisClient, webRoute.UserUnlock, notifyEmail, cfg.Project.SharedSecretKey)
+	authRepo := user_auth.NewRepository(masterDb, authenticator, usrRepo, usrAccRepo, accPrefRepo, accRoleRepo, walletRepo, apiClientRepo, userSessionRepo, userTotpRepo)
 	signupRepo := signup.NewRepository(masterDb, usrRepo, usrAccRepo, accRepo)
 	inviteRepo := invite.NewRepository(masterDb, usrRepo, usrAccRepo, accRepo, webRoute.UserInviteAccept, notifyEmail, cfg.Project.SharedSecretKey)
-	chklstRepo := checklist.NewRepository(masterDb)
+	createassetRepo := createasset.NewRepository(masterDb, algoClient, algoSigner, cfg.Algorand.MetadataBaseUrl)
+
+	// Reject the access tokens of revoked sessions on every request.
+	authenticator.SessionChecker = userSessionRepo.CheckSession
 
 	appCtx := &handlers.AppContext{
-		Log:             log,
-		Env:             cfg.Env,
-		MasterDB:        masterDb,
-		MasterDbHost:    cfg.DB.Host,
-		Redis:           redisClient,
-		TemplateDir:     cfg.Service.TemplateDir,
-		StaticDir:       cfg.Service.StaticFiles.Dir,
-		WebRoute:        webRoute,
-		UserRepo:        usrRepo,
-		UserAccountRepo: usrAccRepo,
-		AccountRepo:     accRepo,
-		AccountPrefRepo: accPrefRepo,
-		AuthRepo:        authRepo,
-		GeoRepo:         geoRepo,
-		SignupRepo:      signupRepo,
-		InviteRepo:      inviteRepo,
-		ChecklistRepo:   chklstRepo,
-		Authenticator:   authenticator,
-		AwsSession:      awsSession,
+		Log:               log,
+		Env:               cfg.Env,
+		MasterDB:          masterDb,
+		MasterDbHost:      cfg.DB.Host,
+		Redis:             redisClient,
+		TemplateDir:       cfg.Service.TemplateDir,
+		StaticDir:         cfg.Service.StaticFiles.Dir,
+		WebRoute:          webRoute,
+		UserRepo:          usrRepo,
+		UserAccountRepo:   usrAccRepo,
+		AccountRepo:       accRepo,
+		AccountPrefRepo:   accPrefRepo,
+		AuthRepo:          authRepo,
+		GeoRepo:           geoRepo,
+		SignupRepo:        signupRepo,
+		InviteRepo:        inviteRepo,
+		CreateassetRepo:   createassetRepo,
+		WalletRepo:        walletRepo,
+		ApiClientRepo:     apiClientRepo,
+		UserSessionRepo:   userSessionRepo,
+		UserTotpRepo:      userTotpRepo,
+		UserKycRepo:       userKycRepo,
+		LoginThrottleRepo: loginThrottleRepo,
+		Authenticator:     authenticator,
+		AlgoClient:        algoClient,
+		AwsSession:        awsSession,
 	}
 
 	// =========================================================================
@@ -548,6 +715,27 @@ func main() {
 	sessionStore := sessions.NewCookieStore([]byte(cfg.Project.SharedSecretKey))
 	appCtx.PostAppMiddleware = append(appCtx.PostAppMiddleware, mid.Session(sessionStore, cfg.Service.SessionName))
 
+	// Exchange the refresh token stored in the session when the access token expires. Revoked
+	// sessions can't be refreshed which signs out the user.
+	appCtx.PostAppMiddleware = append(appCtx.PostAppMiddleware, mid.RefreshSession(authenticator,
+		func(ctx context.Context, claims auth.Claims, refreshToken string) (string, string, error) {
+			tkn, err := authRepo.Refresh(ctx, claims, user_auth.RefreshRequest{
+				RefreshToken: refreshToken,
+			}, user_auth.AccessTokenTTL, time.Now())
+			if err != nil {
+				switch errors.Cause(err) {
+				case user_auth.ErrAuthenticationFailure, user_auth.ErrForbidden, user_auth.ErrTwoFactorRequired:
+					return "", "", nil
+				default:
+					return "", "", err
+				}
+			}
+			return tkn.AccessToken, tkn.RefreshToken, nil
+		}))
+
+	// Require the CSRF token of the session for all requests that submit forms.
+	appCtx.PostAppMiddleware = append(appCtx.PostAppMiddleware, mid.Csrf(mid.DefaultCsrfConfig))
+
 	// =========================================================================
 	// URL Formatter
 
@@ -1061,6 +1249,31 @@ func main() {
 		}()
 	}
 
+	// =========================================================================
+	// Start Created Asset Watcher
+	// Reconciles assets submitted to the network until their transaction is confirmed and
+	// syncs the holdings of default frozen assets with their whitelist. The vested units of
+	// vesting grants are released from the reserve of their asset.
+	watcherCtx, watcherCancel := context.WithCancel(context.Background())
+	defer watcherCancel()
+
+	go createasset.NewWatcher(createassetRepo, log, cfg.Algorand.WatchInterval, cfg.Algorand.WhitelistInterval,
+		cfg.Algorand.VestingInterval).Run(watcherCtx)
+
+	// =========================================================================
+	// Start Auth Key Rotator
+	// Generates new keys before the current one expires and loads the keys of other instances.
+	rotatorCtx, rotatorCancel := context.WithCancel(context.Background())
+	defer rotatorCancel()
+
+	go auth.NewKeyRotator(authenticator, log, cfg.Auth.RotateInterval).Run(rotatorCtx)
+
+	// =========================================================================
+	// Start Keystore Key Rotator
+	// Generates a new master key once the current one expires and re-encrypts the keys
+	// of custodial accounts with it.
+	go keystore.NewKeyRotator(keystoreRepo, log, cfg.Keystore.RotateInterval).Run(rotatorCtx)
+
 	// =========================================================================
 	// Start APP Service
 
@@ -1074,11 +1287,11 @@ func main() {
 	serverErrors := make(chan error, 1)
 
 	// Make an list of HTTP servers for both HTTP and HTTPS requests.
-	var httpServers []http.Server
+	var httpServers []*http.Server
 
 	// Start the HTTP service listening for requests.
 	if cfg.HTTP.Host != "" {
-		api := http.Server{
+		api := &http.Server{
 			Addr:           cfg.HTTP.Host,
 			Handler:        handlers.APP(shutdown, appCtx),
 			ReadTimeout:    cfg.HTTP.ReadTimeout,
@@ -1095,7 +1308,7 @@ func main() {
 
 	// Start the HTTPS service listening for requests with an SSL Cert auto generated with Let's Encrypt.
 	if cfg.HTTPS.Host != "" {
-		api := http.Server{
+		api := &http.Server{
 			Addr:           cfg.HTTPS.Host,
 			Handler:        handlers.APP(shutdown, appCtx),
 			ReadTimeout:    cfg.HTTPS.ReadTimeout,
@@ -1153,6 +1366,12 @@ func main() {
 	case sig := <-shutdown:
 		log.Printf("main : %v : Start shutdown..", sig)
 
+		// Stop reconciling submitted assets.
+		watcherCancel()
+
+		// Stop rotating keys.
+		rotatorCancel()
+
 		// Create context for Shutdown call.
 		ctx, cancel := context.WithTimeout(context.Background(), cfg.Service.ShutdownTimeout)
 		defer cancel()
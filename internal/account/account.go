@@ -75,8 +75,8 @@ func CanModifyAccount(ctx context.Context, claims auth.Claims, dbConn *sqlx.DB,
 	// has the correct access to the account.
 	if claims.Audience != "" {
 		if claims.Audience == accountID {
-			// Admin users can update accounts they have access to.
-			if !claims.HasRole(auth.RoleAdmin) {
+			// Users that can manage the account can update accounts they have access to.
+			if !claims.HasPermission(auth.PermissionAccountManage) {
 				return errors.WithStack(ErrForbidden)
 			}
 		} else {
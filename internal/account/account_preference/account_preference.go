@@ -262,6 +262,9 @@ func Validator() *validator.Validate {
 			}
 
 			return true
+
+		case AccountPreference_Require_Two_Factor:
+			return val == "true" || val == "false"
 		}
 
 		return false
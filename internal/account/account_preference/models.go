@@ -27,7 +27,7 @@ func NewRepository(db *sqlx.DB) *Repository {
 // AccountPreference represents an account setting.
 type AccountPreference struct {
 	AccountID  string                `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	Name       AccountPreferenceName `json:"name" validate:"required,oneof=datetime_format date_format time_format" swaggertype:"string" enums:"datetime_format,date_format,time_format" example:"datetime_format"`
+	Name       AccountPreferenceName `json:"name" validate:"required,oneof=datetime_format date_format time_format require_two_factor" swaggertype:"string" enums:"datetime_format,date_format,time_format,require_two_factor" example:"datetime_format"`
 	Value      string                `json:"value" validate:"required,preference_value" example:"2006-01-02 at 3:04PM MST"`
 	CreatedAt  time.Time             `json:"created_at"`
 	UpdatedAt  time.Time             `json:"updated_at"`
@@ -70,14 +70,14 @@ func (m *AccountPreference) Response(ctx context.Context) *AccountPreferenceResp
 // AccountPreferenceReadRequest contains information needed to read an Account Preference.
 type AccountPreferenceReadRequest struct {
 	AccountID       string                `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	Name            AccountPreferenceName `json:"name" validate:"required,oneof=datetime_format date_format time_format" swaggertype:"string" enums:"datetime_format,date_format,time_format" example:"datetime_format"`
+	Name            AccountPreferenceName `json:"name" validate:"required,oneof=datetime_format date_format time_format require_two_factor" swaggertype:"string" enums:"datetime_format,date_format,time_format,require_two_factor" example:"datetime_format"`
 	IncludeArchived bool                  `json:"include-archived" example:"false"`
 }
 
 // AccountPreferenceSetRequest contains information needed to create a new Account Preference.
 type AccountPreferenceSetRequest struct {
 	AccountID string                `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	Name      AccountPreferenceName `json:"name" validate:"required,oneof=datetime_format date_format time_format" swaggertype:"string" enums:"datetime_format,date_format,time_format" example:"datetime_format"`
+	Name      AccountPreferenceName `json:"name" validate:"required,oneof=datetime_format date_format time_format require_two_factor" swaggertype:"string" enums:"datetime_format,date_format,time_format,require_two_factor" example:"datetime_format"`
 	Value     string                `json:"value" validate:"required,preference_value" example:"2006-01-02 at 3:04PM MST"`
 }
 
@@ -85,13 +85,13 @@ type AccountPreferenceSetRequest struct {
 // This will archive (soft-delete) the existing database entry.
 type AccountPreferenceArchiveRequest struct {
 	AccountID string                `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	Name      AccountPreferenceName `json:"name" validate:"required,oneof=datetime_format date_format time_format" swaggertype:"string" enums:"datetime_format,date_format,time_format" example:"datetime_format"`
+	Name      AccountPreferenceName `json:"name" validate:"required,oneof=datetime_format date_format time_format require_two_factor" swaggertype:"string" enums:"datetime_format,date_format,time_format,require_two_factor" example:"datetime_format"`
 }
 
 // AccountPreferenceDeleteRequest defines the information needed to delete an account preference.
 type AccountPreferenceDeleteRequest struct {
 	AccountID string                `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	Name      AccountPreferenceName `json:"name" validate:"required,oneof=datetime_format date_format time_format" swaggertype:"string" enums:"datetime_format,date_format,time_format" example:"datetime_format"`
+	Name      AccountPreferenceName `json:"name" validate:"required,oneof=datetime_format date_format time_format require_two_factor" swaggertype:"string" enums:"datetime_format,date_format,time_format,require_two_factor" example:"datetime_format"`
 }
 
 // AccountPreferenceFindRequest defines the possible options to search for accounts. By default
@@ -128,11 +128,18 @@ var (
 	AccountPreference_Time_Format_Default                           = "3:04PM MST"
 )
 
+// Account Preference Require Two Factor, when set to true all the users of the account must sign
+// in with a two-factor authentication code.
+var (
+	AccountPreference_Require_Two_Factor AccountPreferenceName = "require_two_factor"
+)
+
 // AccountPreferenceName_Values provides list of valid AccountPreferenceName values.
 var AccountPreferenceName_Values = []AccountPreferenceName{
 	AccountPreference_Datetime_Format,
 	AccountPreference_Date_Format,
 	AccountPreference_Time_Format,
+	AccountPreference_Require_Two_Factor,
 }
 
 // AccountPreferenceName_ValuesInterface returns the AccountPreferenceName options as a slice interface.
@@ -158,7 +165,7 @@ func (s *AccountPreferenceName) Scan(value interface{}) error {
 func (s AccountPreferenceName) Value() (driver.Value, error) {
 	v := validator.New()
 
-	errs := v.Var(s, "required,oneof=datetime_format date_format time_format")
+	errs := v.Var(s, "required,oneof=datetime_format date_format time_format require_two_factor")
 	if errs != nil {
 		return nil, errs
 	}
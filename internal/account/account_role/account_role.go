@@ -0,0 +1,247 @@
+package account_role
+
+import (
+	"context"
+	"time"
+
+	"exitor-dapp/internal/account"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	// The database table for AccountRole
+	accountRoleTableName = "account_roles"
+	// The database table for User Account
+	userAccountTableName = "users_accounts"
+)
+
+// The list of columns needed for find
+var accountRoleMapColumns = "account_id,role,permissions,created_at,updated_at"
+
+// applyClaimsSelect applies a sub-query to the provided query to enforce ACL based on
+// the claims provided.
+//  1. All role types can access their user ID
+//  2. Any user with the same account ID
+//  3. No claims, request is internal, no ACL applied
+func applyClaimsSelect(ctx context.Context, claims auth.Claims, query *sqlbuilder.SelectBuilder) error {
+	// Claims are empty, don't apply any ACL
+	if claims.Audience == "" && claims.Subject == "" {
+		return nil
+	}
+
+	// Build select statement for users_accounts table
+	subQuery := sqlbuilder.NewSelectBuilder().Select("account_id").From(userAccountTableName)
+
+	var or []string
+	if claims.Audience != "" {
+		or = append(or, subQuery.Equal("account_id", claims.Audience))
+	}
+	if claims.Subject != "" {
+		or = append(or, subQuery.Equal("user_id", claims.Subject))
+	}
+
+	// Append sub query
+	if len(or) > 0 {
+		subQuery.Where(subQuery.Or(or...))
+		query.Where(query.In("account_id", subQuery))
+	}
+
+	return nil
+}
+
+// canAssign ensures the claims can assign the permissions of the roles of the account. Only admins
+// can, otherwise a user granted the permission to manage the account by a custom set could grant
+// themselves any other permission.
+func canAssign(ctx context.Context, claims auth.Claims, dbConn *sqlx.DB, accountID string) error {
+	if claims.Audience != "" && !claims.HasRole(auth.RoleAdmin) {
+		return errors.WithStack(account.ErrForbidden)
+	}
+
+	return account.CanModifyAccount(ctx, claims, dbConn, accountID)
+}
+
+// FindByAccountID gets the sets of permissions assigned to the roles of an account from the database.
+func (repo *Repository) FindByAccountID(ctx context.Context, claims auth.Claims, req AccountRoleFindByAccountIDRequest) (AccountRoles, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.account_role.FindByAccountID")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter base select query by account ID
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("account_id", req.AccountID))
+	query.OrderBy("role")
+
+	return find(ctx, claims, repo.DbConn, query, []interface{}{})
+}
+
+// find internal method for getting all the account roles from the database using a select query.
+func find(ctx context.Context, claims auth.Claims, dbConn *sqlx.DB, query *sqlbuilder.SelectBuilder, args []interface{}) (AccountRoles, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.account_role.Find")
+	defer span.Finish()
+
+	query.Select(accountRoleMapColumns)
+	query.From(accountRoleTableName)
+
+	// Check to see if a sub query needs to be applied for the claims
+	err := applyClaimsSelect(ctx, claims, query)
+	if err != nil {
+		return nil, err
+	}
+	queryStr, queryArgs := query.Build()
+	queryStr = dbConn.Rebind(queryStr)
+	args = append(args, queryArgs...)
+
+	// fetch all places from the db
+	rows, err := dbConn.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find account roles failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	// iterate over each row
+	resp := AccountRoles{}
+	for rows.Next() {
+		var a AccountRole
+		err = rows.Scan(&a.AccountID, &a.Role, &a.Permissions, &a.CreatedAt, &a.UpdatedAt)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+		resp = append(resp, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find account roles failed")
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// PermissionSets returns the sets of permissions assigned to the roles of the account, the roles
+// without one are granted their default permissions.
+func (repo *Repository) PermissionSets(ctx context.Context, accountID string) (auth.RolePermissionSets, error) {
+	roles, err := repo.FindByAccountID(ctx, auth.Claims{}, AccountRoleFindByAccountIDRequest{
+		AccountID: accountID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make(auth.RolePermissionSets)
+	for _, r := range roles {
+		sets[r.Role] = []string(r.Permissions)
+	}
+
+	return sets, nil
+}
+
+// Set assigns the set of permissions of a role for an account in place of its default
+// permissions. Users with the role are granted the new set once their token is refreshed.
+func (repo *Repository) Set(ctx context.Context, claims auth.Claims, req AccountRoleSetRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.account_role.Set")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the claims can assign the permissions of the roles of the account.
+	err = canAssign(ctx, claims, repo.DbConn, req.AccountID)
+	if err != nil {
+		return err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	// Build the insert SQL statement.
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(accountRoleTableName)
+	query.Cols("account_id", "role", "permissions", "created_at", "updated_at")
+	query.Values(req.AccountID, req.Role, pq.StringArray(req.Permissions), now, now)
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+
+	sql = sql + " ON CONFLICT ON CONSTRAINT account_roles_pkey DO UPDATE set permissions = EXCLUDED.permissions, updated_at = EXCLUDED.updated_at "
+
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "set permissions of role %s for account %s failed", req.Role, req.AccountID)
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes the set of permissions assigned to a role for an account so it's granted its
+// default permissions again.
+func (repo *Repository) Delete(ctx context.Context, claims auth.Claims, req AccountRoleDeleteRequest) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.account_role.Delete")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the claims can assign the permissions of the roles of the account.
+	err = canAssign(ctx, claims, repo.DbConn, req.AccountID)
+	if err != nil {
+		return err
+	}
+
+	// Build the delete SQL statement.
+	query := sqlbuilder.NewDeleteBuilder()
+	query.DeleteFrom(accountRoleTableName)
+	query.Where(query.And(
+		query.Equal("account_id", req.AccountID),
+		query.Equal("role", req.Role),
+	))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "delete permissions of role %s for account %s failed", req.Role, req.AccountID)
+		return err
+	}
+
+	return nil
+}
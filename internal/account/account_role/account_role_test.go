@@ -0,0 +1,203 @@
+package account_role
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"exitor-dapp/internal/account"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/tests"
+	"exitor-dapp/internal/platform/web/weberror"
+	"exitor-dapp/internal/user_account"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+var (
+	test *tests.Test
+	repo *Repository
+)
+
+// TestMain is the entry point for testing.
+func TestMain(m *testing.M) {
+	os.Exit(testMain(m))
+}
+
+func testMain(m *testing.M) int {
+	test = tests.New()
+	defer test.TearDown()
+
+	repo = NewRepository(test.MasterDB)
+
+	return m.Run()
+}
+
+// TestSetValidation ensures the role of admin and unknown permissions can't be assigned.
+func TestSetValidation(t *testing.T) {
+
+	var roleTests = []struct {
+		name string
+		req  AccountRoleSetRequest
+	}{
+		{"Required Fields", AccountRoleSetRequest{}},
+		{"Role Admin", AccountRoleSetRequest{
+			AccountID:   uuid.NewRandom().String(),
+			Role:        auth.RoleAdmin,
+			Permissions: []string{auth.PermissionAssetRead},
+		}},
+		{"Unknown Permission", AccountRoleSetRequest{
+			AccountID:   uuid.NewRandom().String(),
+			Role:        auth.RoleAuditor,
+			Permissions: []string{auth.PermissionAssetRead, "asset:everything"},
+		}},
+	}
+
+	now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Log("Given the need ensure all validation tags are working for account role set.")
+	{
+		for i, tt := range roleTests {
+			t.Logf("\tTest: %d\tWhen running test: %s", i, tt.name)
+			{
+				err := repo.Set(tests.Context(), auth.Claims{}, tt.req, now)
+				if _, ok := weberror.NewValidationError(tests.Context(), err); !ok {
+					t.Logf("\t\tGot : %+v", err)
+					t.Fatalf("\t%s\tSet should fail validation.", tests.Failed)
+				}
+				t.Logf("\t%s\tSet ok.", tests.Success)
+			}
+		}
+	}
+}
+
+// TestCrud validates only admins of the account can assign the permissions of its roles and
+// the roles with a custom set are granted it in place of their defaults.
+func TestCrud(t *testing.T) {
+	defer tests.Recover(t)
+
+	ctx := tests.Context()
+	now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	// Create a test user and account.
+	usrAcc, err := user_account.MockUserAccount(ctx, test.MasterDB, now, user_account.UserAccountRole_Admin)
+	if err != nil {
+		t.Log("Got :", err)
+		t.Fatalf("%s\tCreate account failed.", tests.Failed)
+	}
+
+	// claims returns the claims of a user of the account with the roles and permissions.
+	claims := func(roles []string, permissions []string) auth.Claims {
+		return auth.Claims{
+			Roles:       roles,
+			Permissions: permissions,
+			StandardClaims: jwt.StandardClaims{
+				Audience: usrAcc.AccountID,
+				Subject:  usrAcc.UserID,
+			},
+		}
+	}
+
+	req := AccountRoleSetRequest{
+		AccountID:   usrAcc.AccountID,
+		Role:        auth.RoleAuditor,
+		Permissions: []string{auth.PermissionAssetRead, auth.PermissionAssetMint},
+	}
+
+	t.Log("Given the need to assign the permissions of a role.")
+	{
+		// A user granted the permission to manage the account by a custom set isn't an admin.
+		err = repo.Set(ctx, claims([]string{auth.RoleAuditor}, auth.Permissions), req, now)
+		if errors.Cause(err) != account.ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", account.ErrForbidden)
+			t.Fatalf("\t%s\tSet by a user that isn't an admin should be forbidden.", tests.Failed)
+		}
+
+		// An admin of another account can't assign the permissions.
+		other := claims([]string{auth.RoleAdmin}, nil)
+		other.Audience = uuid.NewRandom().String()
+		other.Subject = uuid.NewRandom().String()
+		err = repo.Set(ctx, other, req, now)
+		if errors.Cause(err) != account.ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", account.ErrForbidden)
+			t.Fatalf("\t%s\tSet by an admin of another account should be forbidden.", tests.Failed)
+		}
+		t.Logf("\t%s\tSet forbidden ok.", tests.Success)
+
+		err = repo.Set(ctx, claims([]string{auth.RoleAdmin}, nil), req, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSet failed.", tests.Failed)
+		}
+
+		// Setting the role again replaces its permissions.
+		req.Permissions = []string{auth.PermissionAssetRead, auth.PermissionAssetFreeze}
+		err = repo.Set(ctx, claims([]string{auth.RoleAdmin}, nil), req, now.Add(time.Minute))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSet failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tSet ok.", tests.Success)
+
+		roles, err := repo.FindByAccountID(ctx, claims([]string{auth.RoleUser}, nil), AccountRoleFindByAccountIDRequest{
+			AccountID: usrAcc.AccountID,
+		})
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tFindByAccountID failed.", tests.Failed)
+		} else if len(roles) != 1 || roles[0].Role != req.Role || !cmp.Equal([]string(roles[0].Permissions), req.Permissions) {
+			t.Logf("\t\tGot : %+v", roles)
+			t.Logf("\t\tWant: %+v", req)
+			t.Fatalf("\t%s\tFindByAccountID result does not match.", tests.Failed)
+		}
+		t.Logf("\t%s\tFindByAccountID ok.", tests.Success)
+
+		sets, err := repo.PermissionSets(ctx, usrAcc.AccountID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tPermissionSets failed.", tests.Failed)
+		} else if !sets.HasPermission([]string{auth.RoleAuditor}, auth.PermissionAssetFreeze) || sets.HasPermission([]string{auth.RoleAuditor}, auth.PermissionAccountRead) {
+			t.Logf("\t\tGot : %+v", sets)
+			t.Fatalf("\t%s\tExpected the role to be granted the custom set.", tests.Failed)
+		} else if !sets.HasPermission([]string{auth.RoleCompliance}, auth.PermissionUserKyc) {
+			t.Logf("\t\tGot : %+v", sets)
+			t.Fatalf("\t%s\tExpected the other roles to be granted their defaults.", tests.Failed)
+		}
+		t.Logf("\t%s\tPermissionSets ok.", tests.Success)
+	}
+
+	t.Log("Given the need to restore the default permissions of a role.")
+	{
+		delReq := AccountRoleDeleteRequest{
+			AccountID: usrAcc.AccountID,
+			Role:      auth.RoleAuditor,
+		}
+
+		err = repo.Delete(ctx, claims([]string{auth.RoleUser}, nil), delReq)
+		if errors.Cause(err) != account.ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", account.ErrForbidden)
+			t.Fatalf("\t%s\tDelete by a user that isn't an admin should be forbidden.", tests.Failed)
+		}
+
+		err = repo.Delete(ctx, claims([]string{auth.RoleAdmin}, nil), delReq)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tDelete failed.", tests.Failed)
+		}
+
+		sets, err := repo.PermissionSets(ctx, usrAcc.AccountID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tPermissionSets failed.", tests.Failed)
+		} else if sets.HasPermission([]string{auth.RoleAuditor}, auth.PermissionAssetFreeze) || !sets.HasPermission([]string{auth.RoleAuditor}, auth.PermissionAccountRead) {
+			t.Logf("\t\tGot : %+v", sets)
+			t.Fatalf("\t%s\tExpected the role to be granted its defaults.", tests.Failed)
+		}
+		t.Logf("\t%s\tDelete ok.", tests.Success)
+	}
+}
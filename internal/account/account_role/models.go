@@ -0,0 +1,93 @@
+package account_role
+
+import (
+	"context"
+	"time"
+
+	"exitor-dapp/internal/platform/web"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Repository defines the required dependencies for AccountRole.
+type Repository struct {
+	DbConn *sqlx.DB
+}
+
+// NewRepository creates a new Repository that defines dependencies for AccountRole.
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{
+		DbConn: db,
+	}
+}
+
+// AccountRole represents the set of permissions an admin assigned to a role for their account in
+// place of the default permissions of the role.
+type AccountRole struct {
+	AccountID   string         `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Role        string         `json:"role" validate:"required,oneof=user drafter approver compliance auditor" enums:"user,drafter,approver,compliance,auditor" example:"auditor"`
+	Permissions pq.StringArray `json:"permissions" validate:"required,dive,oneof=asset:read asset:draft asset:mint asset:transfer asset:freeze asset:manage user:read user:manage user:kyc account:read account:manage" swaggertype:"array,string" example:"asset:read,user:read"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// AccountRoleResponse represents the set of permissions assigned to a role that is returned for display.
+type AccountRoleResponse struct {
+	AccountID   string           `json:"account_id" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Role        string           `json:"role" example:"auditor"`
+	Permissions []string         `json:"permissions" example:"asset:read,user:read"`
+	CreatedAt   web.TimeResponse `json:"created_at"` // CreatedAt contains multiple format options for display.
+	UpdatedAt   web.TimeResponse `json:"updated_at"` // UpdatedAt contains multiple format options for display.
+}
+
+// Response transforms AccountRole and AccountRoleResponse that is used for display.
+// Additional filtering by context values or translations could be applied.
+func (m *AccountRole) Response(ctx context.Context) *AccountRoleResponse {
+	if m == nil {
+		return nil
+	}
+
+	return &AccountRoleResponse{
+		AccountID:   m.AccountID,
+		Role:        m.Role,
+		Permissions: []string(m.Permissions),
+		CreatedAt:   web.NewTimeResponse(ctx, m.CreatedAt),
+		UpdatedAt:   web.NewTimeResponse(ctx, m.UpdatedAt),
+	}
+}
+
+// AccountRoles a list of AccountRoles.
+type AccountRoles []*AccountRole
+
+// Response transforms a list of AccountRoles to a list of AccountRoleResponses.
+func (m *AccountRoles) Response(ctx context.Context) []*AccountRoleResponse {
+	var l []*AccountRoleResponse
+	if m != nil && len(*m) > 0 {
+		for _, n := range *m {
+			l = append(l, n.Response(ctx))
+		}
+	}
+
+	return l
+}
+
+// AccountRoleSetRequest contains the information needed to assign the set of permissions of a
+// role for an account. The role of admin always has every permission so it can't be assigned.
+type AccountRoleSetRequest struct {
+	AccountID   string   `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Role        string   `json:"role" validate:"required,oneof=user drafter approver compliance auditor" enums:"user,drafter,approver,compliance,auditor" example:"auditor"`
+	Permissions []string `json:"permissions" validate:"required,dive,oneof=asset:read asset:draft asset:mint asset:transfer asset:freeze asset:manage user:read user:manage user:kyc account:read account:manage" swaggertype:"array,string" example:"asset:read,user:read"`
+}
+
+// AccountRoleDeleteRequest defines the information needed to remove the set of permissions
+// assigned to a role for an account so it's granted its default permissions again.
+type AccountRoleDeleteRequest struct {
+	AccountID string `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Role      string `json:"role" validate:"required,oneof=user drafter approver compliance auditor" enums:"user,drafter,approver,compliance,auditor" example:"auditor"`
+}
+
+// AccountRoleFindByAccountIDRequest defines the information needed to find the sets of
+// permissions assigned to the roles of an account.
+type AccountRoleFindByAccountIDRequest struct {
+	AccountID string `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+}
@@ -0,0 +1,553 @@
+package api_client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* API clients are the machine credentials of an account. Backend services
+authenticate with the ID and secret of a client using the OAuth2 client
+credentials grant and are issued tokens limited to the scopes of the client.
+The secret is only returned when it's generated, a lost secret must be rotated. */
+
+const (
+	// The database table for api clients
+	ApiClientTableName = "api_clients"
+)
+
+var (
+	// ErrNotFound abstracts the postgres not found error.
+	ErrNotFound = errors.New("Entity not found")
+
+	// ErrForbidden occurs when a user tries to do something that is forbidden to them according to our access control policies.
+	ErrForbidden = errors.New("Attempted action is not allowed")
+
+	// ErrInvalidCredentials occurs when the client ID and secret don't match an active api client.
+	ErrInvalidCredentials = errors.New("Invalid api client credentials")
+)
+
+// CanModifyApiClient determines if claims has the authority to manage the api clients of the
+// account. Only users that can manage the account can, api clients can not manage other api clients.
+func CanModifyApiClient(ctx context.Context, claims auth.Claims, accountID string) error {
+	// If the request has claims from a specific account, ensure that the claims
+	// has the correct access to the account.
+	if claims.Audience != "" {
+		if claims.Audience != accountID {
+			return errors.WithStack(ErrForbidden)
+		} else if !claims.HasPermission(auth.PermissionAccountManage) || claims.IsClient() {
+			return errors.WithStack(ErrForbidden)
+		}
+	}
+
+	return nil
+}
+
+// applyClaimsSelect applies a sub-query to the provided query to enforce ACL based on the
+// claims provided.
+//  1. No claims, request is internal, no ACL applied
+//  2. Users that can manage the account can access all the api clients of their account
+//  3. Users and api clients can't access any
+func applyClaimsSelect(ctx context.Context, claims auth.Claims, query *sqlbuilder.SelectBuilder) error {
+	// if claims are empty, don't apply any ACL
+	if claims.Audience == "" {
+		return nil
+	}
+
+	if !claims.HasPermission(auth.PermissionAccountManage) || claims.IsClient() {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	query.Where(query.Equal("account_id", claims.Audience))
+
+	return nil
+}
+
+// apiClientMapColumns is the list of columns needed for find.
+var apiClientMapColumns = "id,account_id,name,scopes,secret_hash,last_used_at,rotated_at,revoked_at,created_at,updated_at"
+
+// selectQuery constructs a base select query for ApiClient.
+func selectQuery() *sqlbuilder.SelectBuilder {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Select(apiClientMapColumns)
+	query.From(ApiClientTableName)
+	return query
+}
+
+// findRequestQuery generates the select query for the given find request.
+func findRequestQuery(req ApiClientFindRequest) (*sqlbuilder.SelectBuilder, []interface{}) {
+	query := selectQuery()
+
+	if req.Where != "" {
+		query.Where(query.And(req.Where))
+	}
+
+	if len(req.Order) > 0 {
+		query.OrderBy(req.Order...)
+	}
+
+	if req.Limit != nil {
+		query.Limit(int(*req.Limit))
+	}
+
+	if req.Offset != nil {
+		query.Offset(int(*req.Offset))
+	}
+
+	return query, req.Args
+}
+
+// Find gets all the api clients from the database based on the request params.
+func (repo *Repository) Find(ctx context.Context, claims auth.Claims, req ApiClientFindRequest) (ApiClients, error) {
+	query, args := findRequestQuery(req)
+	return find(ctx, claims, repo.DbConn, query, args, req.IncludeRevoked)
+}
+
+// find internal method for getting all the api clients from the database using a select query.
+func find(ctx context.Context, claims auth.Claims, dbConn *sqlx.DB, query *sqlbuilder.SelectBuilder, args []interface{}, includeRevoked bool) (ApiClients, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.account.api_client.Find")
+	defer span.Finish()
+
+	query.Select(apiClientMapColumns)
+	query.From(ApiClientTableName)
+	if !includeRevoked {
+		query.Where(query.IsNull("revoked_at"))
+	}
+
+	// Check to see if a sub query needs to be applied for the claims
+	err := applyClaimsSelect(ctx, claims, query)
+	if err != nil {
+		return nil, err
+	}
+
+	queryStr, queryArgs := query.Build()
+	queryStr = dbConn.Rebind(queryStr)
+	args = append(args, queryArgs...)
+
+	// Fetch all entries from the db.
+	rows, err := dbConn.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find api clients failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Iterate over each row.
+	resp := []*ApiClient{}
+	for rows.Next() {
+		var m ApiClient
+		err = rows.Scan(&m.ID, &m.AccountID, &m.Name, &m.Scopes, &m.SecretHash, &m.LastUsedAt, &m.RotatedAt,
+			&m.RevokedAt, &m.CreatedAt, &m.UpdatedAt)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+
+		resp = append(resp, &m)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find api clients failed")
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ReadByID gets the specified api client by ID from the database.
+func (repo *Repository) ReadByID(ctx context.Context, claims auth.Claims, id string) (*ApiClient, error) {
+	return repo.Read(ctx, claims, ApiClientReadRequest{
+		ID:             id,
+		IncludeRevoked: false,
+	})
+}
+
+// Read gets the specified api client from the database.
+func (repo *Repository) Read(ctx context.Context, claims auth.Claims, req ApiClientReadRequest) (*ApiClient, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.account.api_client.Read")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter base select query by id.
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("id", req.ID))
+
+	res, err := find(ctx, claims, repo.DbConn, query, []interface{}{}, req.IncludeRevoked)
+	if err != nil {
+		return nil, err
+	} else if res == nil || len(res) == 0 {
+		err = errors.WithMessagef(ErrNotFound, "api client %s not found", req.ID)
+		return nil, err
+	}
+
+	u := res[0]
+	return u, nil
+}
+
+// generateSecret returns a new random client secret and its bcrypt hash.
+func generateSecret() (string, []byte, error) {
+	sb := make([]byte, 32)
+	if _, err := rand.Read(sb); err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	secret := hex.EncodeToString(sb)
+
+	secretHash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "generating secret hash")
+	}
+
+	return secret, secretHash, nil
+}
+
+// Create inserts a new api client into the database. The returned api client includes the
+// client secret, it is not possible to retrieve it afterwards.
+func (repo *Repository) Create(ctx context.Context, claims auth.Claims, req ApiClientCreateRequest, now time.Time) (*ApiClient, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.account.api_client.Create")
+	defer span.Finish()
+
+	if claims.Audience != "" && req.AccountID == "" {
+		// Set the accountId from claims.
+		req.AccountID = claims.Audience
+	}
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can manage the api clients of the account.
+	err = CanModifyApiClient(ctx, claims, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	secret, secretHash, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	m := ApiClient{
+		ID:           uuid.NewRandom().String(),
+		AccountID:    req.AccountID,
+		Name:         req.Name,
+		Scopes:       req.Scopes,
+		SecretHash:   secretHash,
+		ClientSecret: secret,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	// Build the insert SQL statement.
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(ApiClientTableName)
+	query.Cols("id", "account_id", "name", "scopes", "secret_hash", "created_at", "updated_at")
+	query.Values(m.ID, m.AccountID, m.Name, m.Scopes, m.SecretHash, m.CreatedAt, m.UpdatedAt)
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "create api client failed")
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Update replaces the name and scopes of an api client in the database. Tokens already
+// issued to the client keep the scopes they were issued with until they expire.
+func (repo *Repository) Update(ctx context.Context, claims auth.Claims, req ApiClientUpdateRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.account.api_client.Update")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	m, err := repo.ReadByID(ctx, claims, req.ID)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the claims can manage the api clients of the account.
+	err = CanModifyApiClient(ctx, claims, m.AccountID)
+	if err != nil {
+		return err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(ApiClientTableName)
+
+	var fields []string
+	if req.Name != nil {
+		fields = append(fields, query.Assign("name", req.Name))
+	}
+	if req.Scopes != nil {
+		fields = append(fields, query.Assign("scopes", pq.Array(*req.Scopes)))
+	}
+
+	// If there's nothing to update we can quit early.
+	if len(fields) == 0 {
+		return nil
+	}
+
+	// Append the updated_at field
+	fields = append(fields, query.Assign("updated_at", now))
+
+	query.Set(fields...)
+	query.Where(query.Equal("id", req.ID))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "update api client %s failed", req.ID)
+		return err
+	}
+
+	return nil
+}
+
+// Rotate replaces the secret of an api client. The previous secret can no longer be used to
+// authenticate and the returned api client includes the new client secret.
+func (repo *Repository) Rotate(ctx context.Context, claims auth.Claims, req ApiClientRotateRequest, now time.Time) (*ApiClient, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.account.api_client.Rotate")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.ReadByID(ctx, claims, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can manage the api clients of the account.
+	err = CanModifyApiClient(ctx, claims, m.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	secret, secretHash, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(ApiClientTableName)
+	query.Set(
+		query.Assign("secret_hash", secretHash),
+		query.Assign("rotated_at", now),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.Equal("id", m.ID))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "rotate api client %s failed", m.ID)
+		return nil, err
+	}
+
+	m.SecretHash = secretHash
+	m.ClientSecret = secret
+	m.RotatedAt = &pq.NullTime{Time: now, Valid: true}
+	m.UpdatedAt = now
+
+	return m, nil
+}
+
+// Revoke disables an api client, its secret can no longer be used to authenticate. Revoked
+// clients are kept so their usage remains auditable.
+func (repo *Repository) Revoke(ctx context.Context, claims auth.Claims, req ApiClientRevokeRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.account.api_client.Revoke")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	m, err := repo.ReadByID(ctx, claims, req.ID)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the claims can manage the api clients of the account.
+	err = CanModifyApiClient(ctx, claims, m.AccountID)
+	if err != nil {
+		return err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(ApiClientTableName)
+	query.Set(
+		query.Assign("revoked_at", now),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.Equal("id", m.ID))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "revoke api client %s failed", m.ID)
+		return err
+	}
+
+	return nil
+}
+
+// Authenticate verifies the secret of an api client that has not been revoked and records
+// when the client was last used.
+func (repo *Repository) Authenticate(ctx context.Context, req ApiClientAuthenticateRequest, now time.Time) (*ApiClient, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.account.api_client.Authenticate")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.ReadByID(ctx, auth.Claims{}, req.ClientID)
+	if err != nil {
+		if errors.Cause(err) == ErrNotFound {
+			return nil, errors.WithStack(ErrInvalidCredentials)
+		}
+		return nil, err
+	}
+
+	// Compare the provided secret with the saved hash. Use the bcrypt comparison
+	// function so it is cryptographically secure.
+	if err := bcrypt.CompareHashAndPassword(m.SecretHash, []byte(req.ClientSecret)); err != nil {
+		return nil, errors.WithStack(ErrInvalidCredentials)
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	// Build the update SQL statement. The updated_at is left untouched as the client
+	// itself was not modified.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(ApiClientTableName)
+	query.Set(query.Assign("last_used_at", now))
+	query.Where(query.Equal("id", m.ID))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "update last used of api client %s failed", m.ID)
+		return nil, err
+	}
+
+	m.LastUsedAt = &pq.NullTime{Time: now, Valid: true}
+
+	return m, nil
+}
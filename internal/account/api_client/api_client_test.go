@@ -0,0 +1,213 @@
+package api_client
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"exitor-dapp/internal/account"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/tests"
+	"exitor-dapp/internal/platform/web/weberror"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+var test *tests.Test
+
+// TestMain is the entry point for testing.
+func TestMain(m *testing.M) {
+	os.Exit(testMain(m))
+}
+
+func testMain(m *testing.M) int {
+	test = tests.New()
+	defer test.TearDown()
+	return m.Run()
+}
+
+// TestApiClient validates an admin managing the api clients of their account and the clients
+// authenticating with their secret.
+func TestApiClient(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.March, 21, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	repo := NewRepository(test.MasterDB)
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	userClaims := auth.Claims{
+		Roles: []string{auth.RoleUser},
+		StandardClaims: jwt.StandardClaims{
+			Audience: acc.ID,
+			Subject:  uuid.NewRandom().String(),
+		},
+	}
+	adminClaims := auth.Claims{
+		Roles: []string{auth.RoleAdmin},
+		StandardClaims: jwt.StandardClaims{
+			Audience: acc.ID,
+			Subject:  uuid.NewRandom().String(),
+		},
+	}
+
+	t.Log("Given the need to manage the api clients of an account.")
+	{
+		_, err := repo.Create(ctx, userClaims, ApiClientCreateRequest{
+			Name:   "Payouts Service",
+			Scopes: []string{auth.RoleUser},
+		}, now)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tCreate by a user should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreate by a user rejected.", tests.Success)
+
+		_, err = repo.Create(ctx, adminClaims, ApiClientCreateRequest{
+			Name:   "Payouts Service",
+			Scopes: []string{"owner"},
+		}, now)
+		if _, ok := weberror.NewValidationError(ctx, err); !ok {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate with an invalid scope should be a validation error.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreate with an invalid scope rejected.", tests.Success)
+
+		created, err := repo.Create(ctx, adminClaims, ApiClientCreateRequest{
+			Name:   "Payouts Service",
+			Scopes: []string{auth.RoleUser},
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+		} else if created.AccountID != acc.ID || created.ClientSecret == "" {
+			t.Logf("\t\tGot : %+v", created)
+			t.Fatalf("\t%s\tExpected a client of the account with a secret.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreate ok.", tests.Success)
+
+		read, err := repo.ReadByID(ctx, adminClaims, created.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRead failed.", tests.Failed)
+		} else if read.ClientSecret != "" || string(read.SecretHash) == created.ClientSecret {
+			t.Fatalf("\t%s\tExpected only the hash of the secret to be stored.", tests.Failed)
+		}
+		t.Logf("\t%s\tRead ok.", tests.Success)
+
+		_, err = repo.Authenticate(ctx, ApiClientAuthenticateRequest{
+			ClientID:     created.ID,
+			ClientSecret: created.ClientSecret + "x",
+		}, now)
+		if errors.Cause(err) != ErrInvalidCredentials {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrInvalidCredentials)
+			t.Fatalf("\t%s\tAuthenticate with a wrong secret should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticate with a wrong secret rejected.", tests.Success)
+
+		usedAt := now.Add(time.Minute)
+		authed, err := repo.Authenticate(ctx, ApiClientAuthenticateRequest{
+			ClientID:     created.ID,
+			ClientSecret: created.ClientSecret,
+		}, usedAt)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tAuthenticate failed.", tests.Failed)
+		}
+
+		read, err = repo.ReadByID(ctx, adminClaims, authed.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRead failed.", tests.Failed)
+		} else if read.LastUsedAt == nil || !read.LastUsedAt.Time.Equal(usedAt) {
+			t.Logf("\t\tGot : %+v", read.LastUsedAt)
+			t.Logf("\t\tWant: %+v", usedAt)
+			t.Fatalf("\t%s\tExpected the last used time to be recorded.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticate ok.", tests.Success)
+
+		newName := "Payroll Service"
+		newScopes := []string{auth.RoleAdmin, auth.RoleUser}
+		err = repo.Update(ctx, adminClaims, ApiClientUpdateRequest{
+			ID:     created.ID,
+			Name:   &newName,
+			Scopes: &newScopes,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tUpdate failed.", tests.Failed)
+		}
+
+		read, err = repo.ReadByID(ctx, adminClaims, created.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRead failed.", tests.Failed)
+		} else if read.Name != newName || len(read.Scopes) != len(newScopes) {
+			t.Logf("\t\tGot : %+v", read)
+			t.Fatalf("\t%s\tExpected the name and scopes to be updated.", tests.Failed)
+		}
+		t.Logf("\t%s\tUpdate ok.", tests.Success)
+
+		rotated, err := repo.Rotate(ctx, adminClaims, ApiClientRotateRequest{ID: created.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRotate failed.", tests.Failed)
+		} else if rotated.ClientSecret == "" || rotated.ClientSecret == created.ClientSecret {
+			t.Fatalf("\t%s\tExpected a new secret.", tests.Failed)
+		}
+
+		_, err = repo.Authenticate(ctx, ApiClientAuthenticateRequest{
+			ClientID:     created.ID,
+			ClientSecret: created.ClientSecret,
+		}, now)
+		if errors.Cause(err) != ErrInvalidCredentials {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrInvalidCredentials)
+			t.Fatalf("\t%s\tAuthenticate with the previous secret should fail.", tests.Failed)
+		}
+
+		_, err = repo.Authenticate(ctx, ApiClientAuthenticateRequest{
+			ClientID:     created.ID,
+			ClientSecret: rotated.ClientSecret,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tAuthenticate with the new secret failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tRotate ok.", tests.Success)
+
+		err = repo.Revoke(ctx, adminClaims, ApiClientRevokeRequest{ID: created.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRevoke failed.", tests.Failed)
+		}
+
+		_, err = repo.Authenticate(ctx, ApiClientAuthenticateRequest{
+			ClientID:     created.ID,
+			ClientSecret: rotated.ClientSecret,
+		}, now)
+		if errors.Cause(err) != ErrInvalidCredentials {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrInvalidCredentials)
+			t.Fatalf("\t%s\tAuthenticate after revoke should fail.", tests.Failed)
+		}
+
+		if _, err := repo.ReadByID(ctx, adminClaims, created.ID); errors.Cause(err) != ErrNotFound {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrNotFound)
+			t.Fatalf("\t%s\tRead after revoke should not find the client.", tests.Failed)
+		}
+		t.Logf("\t%s\tRevoke ok.", tests.Success)
+	}
+}
@@ -0,0 +1,161 @@
+package api_client
+
+import (
+	"context"
+	"time"
+
+	"exitor-dapp/internal/platform/web"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Repository defines the required dependencies for ApiClient.
+type Repository struct {
+	DbConn *sqlx.DB
+}
+
+// NewRepository creates a new Repository that defines dependencies for ApiClient.
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{
+		DbConn: db,
+	}
+}
+
+// ApiClient represents the machine credentials of an account used to authenticate with the
+// OAuth2 client credentials grant. The ID is used as the client ID and only the hash of the
+// secret is stored.
+type ApiClient struct {
+	ID           string         `json:"id" validate:"required,uuid" example:"0d4f7c5a-6b0e-4d59-9a1f-2f3c1d8e4b7a"`
+	AccountID    string         `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Name         string         `json:"name" validate:"required,max=200" example:"Payouts Service"`
+	Scopes       pq.StringArray `json:"scopes" validate:"required,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"user"`
+	SecretHash   []byte         `json:"-" truss:"api-hide"`
+	ClientSecret string         `json:"-" truss:"api-hide"`
+	LastUsedAt   *pq.NullTime   `json:"last_used_at,omitempty" truss:"api-read"`
+	RotatedAt    *pq.NullTime   `json:"rotated_at,omitempty" truss:"api-read"`
+	RevokedAt    *pq.NullTime   `json:"revoked_at,omitempty" truss:"api-hide"`
+	CreatedAt    time.Time      `json:"created_at" truss:"api-read"`
+	UpdatedAt    time.Time      `json:"updated_at" truss:"api-read"`
+}
+
+// IsRevoked returns true when the client can no longer be used to authenticate.
+func (m *ApiClient) IsRevoked() bool {
+	return m.RevokedAt != nil && m.RevokedAt.Valid && !m.RevokedAt.Time.IsZero()
+}
+
+// ApiClientResponse represents an api client that is returned for display. The client secret is
+// only included in the response of the request that generated it.
+type ApiClientResponse struct {
+	ID           string            `json:"id" example:"0d4f7c5a-6b0e-4d59-9a1f-2f3c1d8e4b7a"`
+	AccountID    string            `json:"account_id" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Name         string            `json:"name" example:"Payouts Service"`
+	Scopes       []string          `json:"scopes" example:"user"`
+	ClientSecret string            `json:"client_secret,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Revoked      bool              `json:"revoked" example:"false"`
+	LastUsedAt   *web.TimeResponse `json:"last_used_at,omitempty"` // LastUsedAt contains multiple format options for display.
+	RotatedAt    *web.TimeResponse `json:"rotated_at,omitempty"`   // RotatedAt contains multiple format options for display.
+	RevokedAt    *web.TimeResponse `json:"revoked_at,omitempty"`   // RevokedAt contains multiple format options for display.
+	CreatedAt    web.TimeResponse  `json:"created_at"`             // CreatedAt contains multiple format options for display.
+	UpdatedAt    web.TimeResponse  `json:"updated_at"`             // UpdatedAt contains multiple format options for display.
+}
+
+// Response transforms ApiClient to the ApiClientResponse that is used for display.
+func (m *ApiClient) Response(ctx context.Context) *ApiClientResponse {
+	if m == nil {
+		return nil
+	}
+
+	r := &ApiClientResponse{
+		ID:           m.ID,
+		AccountID:    m.AccountID,
+		Name:         m.Name,
+		Scopes:       m.Scopes,
+		ClientSecret: m.ClientSecret,
+		Revoked:      m.IsRevoked(),
+		CreatedAt:    web.NewTimeResponse(ctx, m.CreatedAt),
+		UpdatedAt:    web.NewTimeResponse(ctx, m.UpdatedAt),
+	}
+
+	if m.LastUsedAt != nil && !m.LastUsedAt.Time.IsZero() {
+		at := web.NewTimeResponse(ctx, m.LastUsedAt.Time)
+		r.LastUsedAt = &at
+	}
+
+	if m.RotatedAt != nil && !m.RotatedAt.Time.IsZero() {
+		at := web.NewTimeResponse(ctx, m.RotatedAt.Time)
+		r.RotatedAt = &at
+	}
+
+	if m.IsRevoked() {
+		at := web.NewTimeResponse(ctx, m.RevokedAt.Time)
+		r.RevokedAt = &at
+	}
+
+	return r
+}
+
+// ApiClients a list of ApiClients.
+type ApiClients []*ApiClient
+
+// Response transforms a list of ApiClients to a list of ApiClientResponses.
+func (m *ApiClients) Response(ctx context.Context) []*ApiClientResponse {
+	var l []*ApiClientResponse
+	if m != nil && len(*m) > 0 {
+		for _, n := range *m {
+			l = append(l, n.Response(ctx))
+		}
+	}
+
+	return l
+}
+
+// ApiClientCreateRequest contains information needed to create a new api client for an account.
+type ApiClientCreateRequest struct {
+	AccountID string   `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Name      string   `json:"name" validate:"required,max=200" example:"Payouts Service"`
+	Scopes    []string `json:"scopes" validate:"required,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"user"`
+}
+
+// ApiClientReadRequest defines the information needed to read an api client.
+type ApiClientReadRequest struct {
+	ID             string `json:"id" validate:"required,uuid" example:"0d4f7c5a-6b0e-4d59-9a1f-2f3c1d8e4b7a"`
+	IncludeRevoked bool   `json:"include-revoked" example:"false"`
+}
+
+// ApiClientUpdateRequest defines what information may be provided to modify an existing
+// api client. All fields are optional so clients can send just the fields they want
+// changed. It uses pointer fields so we can differentiate between a field that
+// was not provided and a field that was provided as explicitly blank.
+type ApiClientUpdateRequest struct {
+	ID     string    `json:"id" validate:"required,uuid" example:"0d4f7c5a-6b0e-4d59-9a1f-2f3c1d8e4b7a"`
+	Name   *string   `json:"name,omitempty" validate:"omitempty,max=200" example:"Payouts Service"`
+	Scopes *[]string `json:"scopes,omitempty" validate:"omitempty,min=1,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"user"`
+}
+
+// ApiClientRotateRequest defines the information needed to replace the secret of an api client.
+type ApiClientRotateRequest struct {
+	ID string `json:"id" validate:"required,uuid" example:"0d4f7c5a-6b0e-4d59-9a1f-2f3c1d8e4b7a"`
+}
+
+// ApiClientRevokeRequest defines the information needed to revoke an api client.
+type ApiClientRevokeRequest struct {
+	ID string `json:"id" validate:"required,uuid" example:"0d4f7c5a-6b0e-4d59-9a1f-2f3c1d8e4b7a"`
+}
+
+// ApiClientAuthenticateRequest defines the credentials of an api client.
+type ApiClientAuthenticateRequest struct {
+	ClientID     string `json:"client_id" validate:"required" example:"0d4f7c5a-6b0e-4d59-9a1f-2f3c1d8e4b7a"`
+	ClientSecret string `json:"client_secret" validate:"required" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// ApiClientFindRequest defines the possible options to search for api clients. By default
+// revoked clients will be excluded from response.
+type ApiClientFindRequest struct {
+	Where          string        `json:"where" example:"name = ?"`
+	Args           []interface{} `json:"args" swaggertype:"array,string" example:"Payouts Service"`
+	Order          []string      `json:"order" example:"created_at desc"`
+	Limit          *uint         `json:"limit" example:"10"`
+	Offset         *uint         `json:"offset" example:"20"`
+	IncludeRevoked bool          `json:"include-revoked" example:"false"`
+}
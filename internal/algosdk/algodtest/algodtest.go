@@ -0,0 +1,586 @@
+// Package algodtest provides an in-process fake of the algod and indexer REST APIs
+// so code depending on algosdk can be tested without a running Algorand node.
+package algodtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"exitor-dapp/internal/algosdk"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/types"
+)
+
+const (
+	// Token is the algod API token required by the fake server.
+	Token = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	// IndexerToken is the indexer API token required by the fake server.
+	IndexerToken = "1111111111111111111111111111111111111111111111111111111111111111"
+
+	// GenesisID is the genesis ID reported by the fake server.
+	GenesisID = "algodtest-v1"
+)
+
+// PendingTxn is a transaction that has been submitted to the fake server.
+type PendingTxn struct {
+	SignedTxn      types.SignedTxn
+	SubmittedRound uint64
+	ConfirmedRound uint64
+	AssetIndex     uint64
+	PoolError      string
+}
+
+// Server is a fake algod node. Each request made to wait for a block advances
+// the round by one, submitted transactions are confirmed once ConfirmAfter
+// rounds have passed.
+type Server struct {
+	*httptest.Server
+
+	// ConfirmAfter is the number of rounds after submission a transaction is confirmed.
+	ConfirmAfter uint64
+
+	// Reject can be set to return a pool error for a submitted transaction. A non
+	// empty result marks the transaction as removed from the pool.
+	Reject func(stx types.SignedTxn) string
+
+	// Refuse can be set to fail the submission of a transaction with an error, like a
+	// node that is unavailable. A non empty result is returned as the error and the
+	// transaction is never added to the pool.
+	Refuse func(stx types.SignedTxn) string
+
+	// Drop can be set to lose the response to the submission of a transaction, like a
+	// connection that fails after the node accepted it. The transaction is added to the
+	// pool when the result is true but the connection is closed without a response.
+	Drop func(stx types.SignedTxn) bool
+
+	mtx          sync.Mutex
+	round        uint64
+	nextAssetIdx uint64
+	accounts     map[string]models.Account
+	assets       map[uint64]models.Asset
+	holdings     map[uint64][]*models.MiniAssetHolding
+	pending      map[string]*PendingTxn
+	forgotten    map[string]bool
+	sent         []types.SignedTxn
+}
+
+// NewServer starts a new fake algod server. The server should be closed when
+// no longer needed.
+func NewServer() *Server {
+	s := &Server{
+		ConfirmAfter: 1,
+		round:        1000,
+		nextAssetIdx: 1,
+		accounts:     make(map[string]models.Account),
+		assets:       make(map[uint64]models.Asset),
+		holdings:     make(map[uint64][]*models.MiniAssetHolding),
+		pending:      make(map[string]*PendingTxn),
+		forgotten:    make(map[string]bool),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Config returns the network config for connecting to the fake server.
+func (s *Server) Config(name string) algosdk.NetworkConfig {
+	return algosdk.NetworkConfig{
+		Name:         name,
+		Label:        strings.ToUpper(name),
+		AlgodURL:     s.URL,
+		AlgodToken:   Token,
+		IndexerURL:   s.URL,
+		IndexerToken: IndexerToken,
+		ExplorerURL:  "https://explorer.example.com/" + name,
+	}
+}
+
+// Round returns the current round of the fake server.
+func (s *Server) Round() uint64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.round
+}
+
+// Advance moves the fake server forward by the number of rounds and confirms any
+// pending transactions.
+func (s *Server) Advance(rounds uint64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.round += rounds
+	s.confirmPending()
+}
+
+// SetAccount adds or replaces the account information returned for an address.
+func (s *Server) SetAccount(acc models.Account) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.accounts[acc.Address] = acc
+}
+
+// SetAsset adds or replaces an asset.
+func (s *Server) SetAsset(asset models.Asset) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.assets[asset.Index] = asset
+	if asset.Index >= s.nextAssetIdx {
+		s.nextAssetIdx = asset.Index + 1
+	}
+}
+
+// SetHolding adds or replaces the balance of an address for an asset.
+func (s *Server) SetHolding(assetID uint64, holding models.MiniAssetHolding) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	*s.holding(assetID, holding.Address) = holding
+}
+
+// holding returns the balance of an address for an asset, adding it when the address
+// has not opted in.
+func (s *Server) holding(assetID uint64, address string) *models.MiniAssetHolding {
+	for _, h := range s.holdings[assetID] {
+		if h.Address == address {
+			return h
+		}
+	}
+
+	h := &models.MiniAssetHolding{Address: address, OptedInAtRound: s.round}
+	s.holdings[assetID] = append(s.holdings[assetID], h)
+	return h
+}
+
+// hasHolding returns true when the address has opted in to the asset.
+func (s *Server) hasHolding(assetID uint64, address string) bool {
+	for _, h := range s.holdings[assetID] {
+		if h.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// Sent returns all the signed transactions that have been submitted.
+func (s *Server) Sent() []types.SignedTxn {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]types.SignedTxn{}, s.sent...)
+}
+
+// Pending returns the submitted transaction by ID.
+func (s *Server) Pending(txID string) (PendingTxn, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	p, ok := s.pending[txID]
+	if !ok {
+		return PendingTxn{}, false
+	}
+	return *p, true
+}
+
+// Forget removes a submitted transaction from the pool like a node does once it's
+// confirmed or dropped. The pending endpoint no longer knows about the transaction, a
+// confirmed transaction can still be found in the indexer and an unconfirmed one will
+// never be confirmed.
+func (s *Server) Forget(txID string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if p, ok := s.pending[txID]; ok && p.ConfirmedRound == 0 && p.PoolError == "" {
+		p.PoolError = "transaction dropped"
+	}
+	s.forgotten[txID] = true
+}
+
+// serveHTTP routes the request to the matching algod endpoint.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	pts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	// The indexer shares the server with algod but has its own token.
+	indexer := (len(pts) == 4 && pts[1] == "assets" && pts[3] == "balances") ||
+		(r.Method == http.MethodGet && r.URL.Path == "/v2/transactions")
+	if (indexer && r.Header.Get("X-Indexer-API-Token") != IndexerToken) || (!indexer && r.Header.Get("X-Algo-API-Token") != Token) {
+		writeError(w, http.StatusUnauthorized, "Invalid API Token")
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && indexer && pts[1] == "transactions":
+		s.handleSearchTransactions(w, r)
+	case r.Method == http.MethodGet && indexer:
+		s.handleAssetBalances(w, r, pts[2])
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/status":
+		writeJSON(w, s.status())
+	case r.Method == http.MethodGet && len(pts) == 4 && pts[1] == "status" && pts[2] == "wait-for-block-after":
+		s.handleWaitForBlock(w, pts[3])
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/transactions/params":
+		writeJSON(w, models.TransactionParametersResponse{
+			ConsensusVersion: "future",
+			Fee:              0,
+			GenesisHash:      make([]byte, 32),
+			GenesisId:        GenesisID,
+			LastRound:        s.round,
+			MinFee:           1000,
+		})
+	case r.Method == http.MethodGet && len(pts) == 3 && pts[1] == "accounts":
+		s.handleAccount(w, pts[2])
+	case r.Method == http.MethodGet && len(pts) == 3 && pts[1] == "assets":
+		s.handleAsset(w, pts[2])
+	case r.Method == http.MethodPost && r.URL.Path == "/v2/transactions":
+		s.handleSend(w, r)
+	case r.Method == http.MethodGet && len(pts) == 4 && pts[1] == "transactions" && pts[2] == "pending":
+		s.handlePending(w, r, pts[3])
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+// status returns the current node status.
+func (s *Server) status() models.NodeStatus {
+	return models.NodeStatus{LastRound: s.round}
+}
+
+// handleWaitForBlock advances the round and confirms any pending transactions.
+func (s *Server) handleWaitForBlock(w http.ResponseWriter, v string) {
+	round, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if s.round <= round {
+		s.round = round
+	}
+	s.round++
+	s.confirmPending()
+
+	writeJSON(w, s.status())
+}
+
+// handleAccount returns the account information for an address.
+func (s *Server) handleAccount(w http.ResponseWriter, address string) {
+	if _, err := types.DecodeAddress(address); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	acc, ok := s.accounts[address]
+	if !ok {
+		acc = models.Account{Address: address, Status: "Offline"}
+	}
+	acc.Round = s.round
+
+	writeJSON(w, acc)
+}
+
+// handleAsset returns the asset by index.
+func (s *Server) handleAsset(w http.ResponseWriter, v string) {
+	idx, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	asset, ok := s.assets[idx]
+	if !ok {
+		writeError(w, http.StatusNotFound, "asset does not exist")
+		return
+	}
+
+	writeJSON(w, asset)
+}
+
+// handleAssetBalances returns a page of the balances of an asset like the indexer. The
+// next token is the offset of the page.
+func (s *Server) handleAssetBalances(w http.ResponseWriter, r *http.Request, v string) {
+	idx, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var offset, limit int
+	if n := r.URL.Query().Get("next"); n != "" {
+		if offset, err = strconv.Atoi(n); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	l := s.holdings[idx]
+	if offset > len(l) {
+		offset = len(l)
+	}
+	if limit <= 0 || offset+limit > len(l) {
+		limit = len(l) - offset
+	}
+
+	res := models.AssetBalancesResponse{CurrentRound: s.round}
+	for _, h := range l[offset : offset+limit] {
+		res.Balances = append(res.Balances, *h)
+	}
+	if offset+limit < len(l) {
+		res.NextToken = strconv.Itoa(offset + limit)
+	}
+
+	writeJSON(w, res)
+}
+
+// handleSearchTransactions returns the confirmed transaction matching the txid like the
+// indexer, transactions that were not confirmed are never indexed.
+func (s *Server) handleSearchTransactions(w http.ResponseWriter, r *http.Request) {
+	res := models.TransactionsResponse{CurrentRound: s.round}
+
+	txID := r.URL.Query().Get("txid")
+	if p, ok := s.pending[txID]; ok && p.ConfirmedRound > 0 {
+		res.Transactions = append(res.Transactions, models.Transaction{
+			Id:                txID,
+			ConfirmedRound:    p.ConfirmedRound,
+			CreatedAssetIndex: p.AssetIndex,
+		})
+	}
+
+	writeJSON(w, res)
+}
+
+// handleSend decodes the submitted signed transactions and adds them to the pool.
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	dat, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var stxns []types.SignedTxn
+	dec := msgpack.NewDecoder(bytes.NewReader(dat))
+	for {
+		var stx types.SignedTxn
+		if err := dec.Decode(&stx); err == io.EOF {
+			break
+		} else if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		stxns = append(stxns, stx)
+	}
+
+	if len(stxns) == 0 {
+		writeError(w, http.StatusBadRequest, "no transactions submitted")
+		return
+	}
+
+	if s.Refuse != nil {
+		for _, stx := range stxns {
+			if msg := s.Refuse(stx); msg != "" {
+				writeError(w, http.StatusServiceUnavailable, msg)
+				return
+			}
+		}
+	}
+
+	var txIDs []string
+	for _, stx := range stxns {
+		txID := crypto.TransactionIDString(stx.Txn)
+		if _, ok := s.pending[txID]; ok {
+			writeError(w, http.StatusBadRequest, "transaction already in ledger: "+txID)
+			return
+		}
+		txIDs = append(txIDs, txID)
+	}
+
+	// The members of a group must all be submitted together in order.
+	groups := make(map[types.Digest][]types.Transaction)
+	for _, stx := range stxns {
+		if stx.Txn.Group != (types.Digest{}) {
+			groups[stx.Txn.Group] = append(groups[stx.Txn.Group], stx.Txn)
+		}
+	}
+	for gid, txns := range groups {
+		for i := range txns {
+			txns[i].Group = types.Digest{}
+		}
+		if computed, err := crypto.ComputeGroupID(txns); err != nil || computed != gid {
+			writeError(w, http.StatusBadRequest, "incomplete transaction group")
+			return
+		}
+	}
+
+	// A rejected member of a group rejects the whole group.
+	rejected := make(map[types.Digest]string)
+	poolErrors := make([]string, len(stxns))
+	for i, stx := range stxns {
+		if s.Reject != nil {
+			poolErrors[i] = s.Reject(stx)
+		}
+		if poolErrors[i] != "" && stx.Txn.Group != (types.Digest{}) {
+			rejected[stx.Txn.Group] = poolErrors[i]
+		}
+	}
+
+	for i, stx := range stxns {
+		p := &PendingTxn{
+			SignedTxn:      stx,
+			SubmittedRound: s.round,
+			PoolError:      poolErrors[i],
+		}
+		if msg, ok := rejected[stx.Txn.Group]; ok {
+			p.PoolError = msg
+		}
+
+		s.pending[txIDs[i]] = p
+		s.sent = append(s.sent, stx)
+	}
+
+	s.confirmPending()
+
+	if s.Drop != nil {
+		for _, stx := range stxns {
+			if !s.Drop(stx) {
+				continue
+			}
+			if hj, ok := w.(http.Hijacker); ok {
+				if conn, _, err := hj.Hijack(); err == nil {
+					conn.Close()
+					return
+				}
+			}
+			writeError(w, http.StatusServiceUnavailable, "response lost")
+			return
+		}
+	}
+
+	writeJSON(w, models.PostTransactionsResponse{Txid: txIDs[0]})
+}
+
+// handlePending returns the pool status of a submitted transaction.
+func (s *Server) handlePending(w http.ResponseWriter, r *http.Request, txID string) {
+	p, ok := s.pending[txID]
+	if !ok || s.forgotten[txID] {
+		writeError(w, http.StatusNotFound, "txn does not exist")
+		return
+	}
+
+	res := models.PendingTransactionInfoResponse{
+		AssetIndex:     p.AssetIndex,
+		ConfirmedRound: p.ConfirmedRound,
+		PoolError:      p.PoolError,
+	}
+
+	if r.URL.Query().Get("format") == "msgpack" {
+		w.Header().Set("Content-Type", "application/msgpack")
+		w.WriteHeader(http.StatusOK)
+		w.Write(msgpack.Encode(res))
+		return
+	}
+
+	writeJSON(w, res)
+}
+
+// confirmPending confirms the transactions in the pool that have waited for
+// ConfirmAfter rounds and applies their effects.
+func (s *Server) confirmPending() {
+	for _, p := range s.pending {
+		if p.ConfirmedRound > 0 || p.PoolError != "" || s.round < p.SubmittedRound+s.ConfirmAfter {
+			continue
+		}
+		p.ConfirmedRound = s.round
+
+		txn := p.SignedTxn.Txn
+		switch {
+		case txn.Type == types.AssetConfigTx && txn.ConfigAsset == 0:
+			p.AssetIndex = s.nextAssetIdx
+			s.nextAssetIdx++
+
+			// All the units are held by the creator.
+			s.holding(p.AssetIndex, txn.Sender.String()).Amount = txn.AssetParams.Total
+
+			s.assets[p.AssetIndex] = models.Asset{
+				Index: p.AssetIndex,
+				Params: models.AssetParams{
+					Clawback:      addressString(txn.AssetParams.Clawback),
+					Creator:       txn.Sender.String(),
+					Decimals:      uint64(txn.AssetParams.Decimals),
+					DefaultFrozen: txn.AssetParams.DefaultFrozen,
+					Freeze:        addressString(txn.AssetParams.Freeze),
+					Manager:       addressString(txn.AssetParams.Manager),
+					MetadataHash:  metadataHash(txn.AssetParams.MetadataHash),
+					Name:          txn.AssetParams.AssetName,
+					Reserve:       addressString(txn.AssetParams.Reserve),
+					Total:         txn.AssetParams.Total,
+					UnitName:      txn.AssetParams.UnitName,
+					Url:           txn.AssetParams.URL,
+				},
+			}
+		case txn.Type == types.AssetTransferTx:
+			// Revocations move the units from the asset sender instead of the sender.
+			from := txn.Sender
+			if !txn.AssetSender.IsZero() {
+				from = txn.AssetSender
+			}
+
+			// Opting in to an asset that is frozen by default starts with a frozen holding.
+			if from == txn.AssetReceiver && !s.hasHolding(uint64(txn.XferAsset), txn.AssetReceiver.String()) {
+				s.holding(uint64(txn.XferAsset), txn.AssetReceiver.String()).IsFrozen = s.assets[uint64(txn.XferAsset)].Params.DefaultFrozen
+			}
+
+			receiver := s.holding(uint64(txn.XferAsset), txn.AssetReceiver.String())
+			if from != txn.AssetReceiver {
+				sender := s.holding(uint64(txn.XferAsset), from.String())
+				sender.Amount -= txn.AssetAmount
+				receiver.Amount += txn.AssetAmount
+			}
+		case txn.Type == types.AssetFreezeTx:
+			s.holding(uint64(txn.FreezeAsset), txn.FreezeAccount.String()).IsFrozen = txn.AssetFrozen
+		}
+	}
+}
+
+// addressString returns the encoded address or an empty string for the zero address.
+func addressString(a types.Address) string {
+	if a.IsZero() {
+		return ""
+	}
+	return a.String()
+}
+
+// metadataHash returns the hash as a slice or nil when not set.
+func metadataHash(h [32]byte) []byte {
+	if h == [32]byte{} {
+		return nil
+	}
+	return h[:]
+}
+
+// writeJSON writes the value as a JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an algod formatted error response.
+func writeError(w http.ResponseWriter, statusCode int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"message": msg})
+}
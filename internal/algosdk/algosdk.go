@@ -2,198 +2,357 @@ package algosdk
 
 import (
 	"context"
-	"crypto/ed25519"
-	json "encoding/json"
+	"encoding/json"
 	"fmt"
-	"net/url"
-	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"exitor-dapp/internal/platform/web/webcontext"
 
-	"github.com/algorand/go-algorand-sdk/client/algod"
 	"github.com/algorand/go-algorand-sdk/client/v2/algod"
 	"github.com/algorand/go-algorand-sdk/client/v2/common"
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/client/v2/indexer"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
 
-const algodAddress = ""
-const psToken = ""//Purestake API key
+var (
+	// ErrNetworkNotFound occurs when a network is requested by a name that has not been registered.
+	ErrNetworkNotFound = errors.New("Algorand network not found")
+
+	// ErrNoCurrentNetwork occurs when no network has been selected as the current network.
+	ErrNoCurrentNetwork = errors.New("No Algorand network selected")
 
-// Networks data structure to hold name, server, label, explorer
+	// ErrNoIndexer occurs when the indexer is used for a network without an indexer URL.
+	ErrNoIndexer = errors.New("No indexer configured for Algorand network")
 
+	// ErrTransactionRejected occurs when the node refused a submitted transaction so it
+	// will never be confirmed. Other errors from a submission, like a timeout, don't tell
+	// whether the node accepted the transaction.
+	ErrTransactionRejected = errors.New("Algorand transaction rejected")
+)
 
-type Networks struct {
-	name  string
-	server url
-	label string
-	explorer url
+// Network is a registered Algorand network with an initialized algod client.
+type Network struct {
+	NetworkConfig
+	algod   *algod.Client
+	indexer *indexer.Client
+	headers []*common.Header
 }
 
-/*testnet := Networks{'testnet', 'https://testnet-algorand.api.purestake.io/ps1',
-						'TESTNET', 'https://goalseeker.purestake.io/algorand/testnet'}
-						
-mainnet := Networks{'mainnet', 'https://mainnet-algorand.api.purestake.io/ps1',
-						'MAINNET', 'https://goalseeker.purestake.io/algorand/mainnet'}
-*/
-type AlgoSDK struct {
-	client
-	network
-	Networks
+// Client holds a registry of named Algorand networks and the network currently
+// selected for use by the application.
+type Client struct {
+	mtx      sync.RWMutex
+	networks map[string]*Network
+	current  string
 }
 
-func (a *AlgoSDK) setNetwork(name) {
+// New creates a new Client with the provided networks registered. The first
+// network provided will be selected as the current network.
+func New(cfgs ...NetworkConfig) (*Client, error) {
+	c := &Client{
+		networks: make(map[string]*Network),
+	}
 
-	// let's initialize testnet and mainnet networks
-	testnet := Networks{'testnet', 'https://testnet-algorand.api.purestake.io/ps1',
-						'TESTNET', 'https://goalseeker.purestake.io/algorand/testnet'}
-						
-	mainnet := Networks{'mainnet', 'https://mainnet-algorand.api.purestake.io/ps1',
-						'MAINNET', 'https://goalseeker.purestake.io/algorand/mainnet'}
-	
-	if name == testnet.name {
-		a.network := testnet
-	} else if name == mainnet.name {
-		a.network := mainnet.server 
+	for _, cfg := range cfgs {
+		if err := c.Register(cfg); err != nil {
+			return nil, err
+		}
 	}
-	return a.network
+
+	return c, nil
 }
 
-func (a *AlgoSDK) getExplorer() {
-	const algodAddress := setNetWork()
+// NewNetwork initializes the algod client for the supplied network config.
+func NewNetwork(cfg NetworkConfig) (*Network, error) {
+	// Validate the config.
+	err := webcontext.Validator().Struct(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-}
+	n := &Network{
+		NetworkConfig: cfg,
+	}
 
-func (a *AlgoSDK) getCurrentNetwork() {
-	return a.network
-}
+	// Sort the header keys so requests are constructed the same every time.
+	hdrs := cfg.Headers()
+	var keys []string
+	for k := range hdrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		n.headers = append(n.headers, &common.Header{Key: k, Value: hdrs[k]})
+	}
+
+	// The algod and indexer clients are both a common client with their own token header.
+	algodClient, err := common.MakeClientWithHeaders(cfg.AlgodURL, "X-Algo-API-Token", cfg.AlgodToken, n.headers)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to make algod client for network %s", cfg.Name)
+	}
+	n.algod = (*algod.Client)(algodClient)
 
-func (a *AlgoSDK) selectNetwork(name) {
-	for network, values := range getNetworks() {
-		if network.name === name {
-			setAlgodClient(network.server)
+	if cfg.IndexerURL != "" {
+		indexerClient, err := common.MakeClientWithHeaders(cfg.IndexerURL, "X-Indexer-API-Token", cfg.IndexerToken, n.headers)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to make indexer client for network %s", cfg.Name)
 		}
+		n.indexer = (*indexer.Client)(indexerClient)
 	}
+
+	return n, nil
 }
 
-func (n *Networks) getNetworks() {
-	testNet := Networks{'testnet', 'https://testnet-algorand.api.purestake.io/ps1',
-						'TESTNET', 'https://goalseeker.purestake.io/algorand/testnet'}
-						
-	mainNet := Networks{'mainnet', 'https://mainnet-algorand.api.purestake.io/ps1',
-						'MAINNET', 'https://goalseeker.purestake.io/algorand/mainnet'}
+// Register adds the network to the registry, replacing any existing network with
+// the same name. When no network is currently selected, the network is selected.
+func (c *Client) Register(cfg NetworkConfig) error {
+	n, err := NewNetwork(cfg)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
 
-	return testNet, mainNet
+	c.networks[n.Name] = n
+	if c.current == "" {
+		c.current = n.Name
+	}
+
+	return nil
 }
 
-func (a *AlgoSDK) setAlgodClient(Networks.server) {
-	// Set up Headers first
-	testNet, mainNet := getNetworks()
-	const algodAddressTestNet := testNet.server
-	const algodAddressMainNet := MainNet.server
-	var headers []*algod.Header
-	headers = append(headers, &algod.Header{"X-API-Key", psToken})
-	algodClient, err := algod.MakeClientWithHeaders(algodAddressTestNet, "", headers )
-	if err != nil {
-			fmt.Printf("failed to make algod client: %s\n", err)
-			return
+// Networks returns the sorted names of all the registered networks.
+func (c *Client) Networks() []string {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	var l []string
+	for k := range c.networks {
+		l = append(l, k)
 	}
-	client := algodClient
-	return client
+	sort.Strings(l)
+
+	return l
 }
 
-func (a *AlgoSDK) getCurrentNetwork() {
-	return a.network
+// SelectNetwork sets the network used by default for all requests.
+func (c *Client) SelectNetwork(name string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if _, ok := c.networks[name]; !ok {
+		return errors.WithMessagef(ErrNetworkNotFound, "network %s not registered", name)
+	}
+	c.current = name
+
+	return nil
 }
 
-/* Convert from Javascript: 
-selectNetwork(name) {
-        const networks = this.getNetworks();
-        networks.forEach((network) => {
-            if (network.name === name) {
-                this.network = network;
-                this.setClient(network.server);
-            }
-        })
-    }
-	*/
-func (a AlgoSDK) selectNetwork(networks) {
-	//selectNetwork(networks == networks[testnet])
+// Network returns the registered network by name.
+func (c *Client) Network(name string) (*Network, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	n, ok := c.networks[name]
+	if !ok {
+		return nil, errors.WithMessagef(ErrNetworkNotFound, "network %s not registered", name)
+	}
+
+	return n, nil
 }
 
+// Current returns the currently selected network.
+func (c *Client) Current() (*Network, error) {
+	c.mtx.RLock()
+	name := c.current
+	c.mtx.RUnlock()
+
+	if name == "" {
+		return nil, errors.WithStack(ErrNoCurrentNetwork)
+	}
 
-/* Convert from Javascipt:
-getExplorer() {
-        const network = this.getCurrentNetwork();
-        return this.network.explorer;
-    }
-*/
-func(a *AlgoSDK) getExplorer() {
-	const network = getCurrentNetwork();
-	
-	return network.explorer;
+	return c.Network(name)
 }
 
-func (a *AlgoSDK) getAssetUrl(id) {
-	return getExplorer() + '/asset/' + id;
+// Algod returns the underlying algod client for the network.
+func (n *Network) Algod() *algod.Client {
+	return n.algod
 }
 
-func (a *AlgoSDK) getCurrentNetwork() {
-	return network
+// Indexer returns the underlying indexer client for the network, nil when the network
+// has no indexer.
+func (n *Network) Indexer() *indexer.Client {
+	return n.indexer
 }
 
-func (a *AlgoSDK) setClient(server) {
-	p := *AlgoSDK
-	//p.client = new algod.Client(token, server, port);
+// ExplorerUrl returns the base URL of the block explorer for the network.
+func (n *Network) ExplorerUrl() string {
+	return n.explorerUrl()
 }
 
-func (a *AlgoSDK) getClient() {
-	return //p.client
+// AssetUrl returns the block explorer URL for an asset.
+func (n *Network) AssetUrl(assetID uint64) string {
+	return n.explorerUrl("asset", strconv.FormatUint(assetID, 10))
 }
 
-/* Implement this as well:
-mnemonicToSecretKey(mnemonic) {
-        return sdk.mnemonicToSecretKey(mnemonic);
-    }
-*/
+// TransactionUrl returns the block explorer URL for a transaction.
+func (n *Network) TransactionUrl(txID string) string {
+	return n.explorerUrl("transaction", txID)
+}
 
-func (a AlgoSDK) getAccountInformation(address) {
-	return getClient().accountInformation(address)
+// AddressUrl returns the block explorer URL for an account address.
+func (n *Network) AddressUrl(address string) string {
+	return n.explorerUrl("address", address)
 }
 
-func (a AlgoSDK) getAssetInformation(assetID) {
-	return getClient().assetInformation(assetID);
+// Status returns the current status of the node, including the last round.
+func (n *Network) Status(ctx context.Context) (models.NodeStatus, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.algosdk.Status")
+	defer span.Finish()
+
+	res, err := n.algod.Status().Do(ctx)
+	if err != nil {
+		return models.NodeStatus{}, errors.Wrapf(err, "get node status on %s failed", n.Name)
+	}
+
+	return res, nil
 }
 
-func (a AlgoSDK) getChangingParams() {
-	const cp = {
-		fee: 0,
-		firstRound: 0,
-		lastRound: 0,
-		genID: "",
-		genHash: ""
+// AccountInformation returns the balances and assets held by an account.
+func (n *Network) AccountInformation(ctx context.Context, address string) (models.Account, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.algosdk.AccountInformation")
+	defer span.Finish()
+
+	res, err := n.algod.AccountInformation(address).Do(ctx)
+	if err != nil {
+		return models.Account{}, errors.Wrapf(err, "get account information for %s on %s failed", address, n.Name)
 	}
 
-	let params = // await this.getClient().getTransactionParams();
-	// cp.firstRound = params.lastRound;
-	// cp.lastRound = cp.firstRound + parseInt(1000);
-	// let sFee = await this.getClient().suggestedFee();
-	/* cp.fee = sFee.fee;
-	cp.genID = params.genesisID;
-	cp.genHash = params.genesishashb64;
+	return res, nil
+}
+
+// AssetInformation returns the current parameters of an asset.
+func (n *Network) AssetInformation(ctx context.Context, assetID uint64) (models.Asset, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.algosdk.AssetInformation")
+	defer span.Finish()
+
+	res, err := n.algod.GetAssetByID(assetID).Do(ctx)
+	if err != nil {
+		return models.Asset{}, errors.Wrapf(err, "get asset information for %d on %s failed", assetID, n.Name)
+	}
+
+	return res, nil
+}
+
+// SuggestedParams returns the parameters required to construct a new transaction.
+func (n *Network) SuggestedParams(ctx context.Context) (types.SuggestedParams, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.algosdk.SuggestedParams")
+	defer span.Finish()
+
+	res, err := n.algod.SuggestedParams().Do(ctx)
+	if err != nil {
+		return types.SuggestedParams{}, errors.Wrapf(err, "get suggested params on %s failed", n.Name)
+	}
 
-	return cp; */
+	return res, nil
 }
 
-func(a AlgoSDK) waitForConfirmation(txId) {
-	/* let lastRound = (await this.getClient().status()).lastRound;
-	while (true) {
-		const pendingInfo = await this.getClient().pendingTransactionInformation(txId);
-		if (pendingInfo.round !== null && pendingInfo.rround > 0) {
-			// The completedt transaction
-			console.log("Transaction " + pendingInfo.tx + " confirmed in round " + pendingInfo.round);
-			break;
+// SendRawTransaction broadcasts msgpack encoded signed transactions to the network
+// and returns the ID of the transaction.
+func (n *Network) SendRawTransaction(ctx context.Context, rawTxn []byte) (string, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.algosdk.SendRawTransaction")
+	defer span.Finish()
+
+	txID, err := n.algod.SendRawTransaction(rawTxn).Do(ctx)
+	if err != nil {
+		if msg, ok := rejection(err); ok {
+			return "", errors.Wrapf(ErrTransactionRejected, "send raw transaction on %s failed: %s", n.Name, msg)
 		}
-		lastRound++;
-		await this.getClient().statusAfterBlock(lastRound);
-		*/
+		return "", errors.Wrapf(err, "send raw transaction on %s failed", n.Name)
+	}
+
+	return txID, nil
+}
+
+// rejection returns the message of an error response from the node that refused a
+// submission. Only a client error with a decodable body is a rejection, the node
+// reporting the transaction is already in the ledger means it was accepted before.
+func rejection(err error) (string, bool) {
+	// The SDK formats error responses as "HTTP <code>: <body>".
+	var code int
+	if _, serr := fmt.Sscanf(err.Error(), "HTTP %d:", &code); serr != nil || code < 400 || code >= 500 {
+		return "", false
+	}
+
+	var res struct {
+		Message string `json:"message"`
+	}
+	body := strings.TrimPrefix(err.Error(), fmt.Sprintf("HTTP %d: ", code))
+	if jerr := json.Unmarshal([]byte(body), &res); jerr != nil || res.Message == "" {
+		return "", false
+	} else if strings.Contains(res.Message, "already in ledger") {
+		return "", false
 	}
+
+	return res.Message, true
+}
+
+// AssetBalances returns a page of the accounts holding an asset from the indexer. The
+// next token of the response is empty once the last page has been returned.
+func (n *Network) AssetBalances(ctx context.Context, assetID uint64, limit uint64, next string) (models.AssetBalancesResponse, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.algosdk.AssetBalances")
+	defer span.Finish()
+
+	if n.indexer == nil {
+		return models.AssetBalancesResponse{}, errors.WithMessagef(ErrNoIndexer, "network %s", n.Name)
+	}
+
+	req := n.indexer.LookupAssetBalances(assetID).Limit(limit)
+	if next != "" {
+		req = req.NextToken(next)
+	}
+
+	res, err := req.Do(ctx)
+	if err != nil {
+		return models.AssetBalancesResponse{}, errors.Wrapf(err, "get balances for asset %d on %s failed", assetID, n.Name)
+	}
+
+	return res, nil
 }
 
+// LookupTransaction returns the confirmation of a transaction from the indexer along with
+// the round the indexer has caught up to. A nil Confirmation with no error indicates the
+// indexer has no record of the transaction as of that round.
+func (n *Network) LookupTransaction(ctx context.Context, txID string) (*Confirmation, uint64, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.algosdk.LookupTransaction")
+	defer span.Finish()
+
+	if n.indexer == nil {
+		return nil, 0, errors.WithMessagef(ErrNoIndexer, "network %s", n.Name)
+	}
+
+	res, err := n.indexer.SearchForTransactions().TXID(txID).Do(ctx)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "lookup transaction %s on %s failed", txID, n.Name)
+	}
+
+	for _, tx := range res.Transactions {
+		if tx.Id == txID && tx.ConfirmedRound > 0 {
+			return &Confirmation{
+				TxID:           txID,
+				ConfirmedRound: tx.ConfirmedRound,
+				AssetIndex:     tx.CreatedAssetIndex,
+			}, res.CurrentRound, nil
+		}
+	}
 
+	return nil, res.CurrentRound, nil
+}
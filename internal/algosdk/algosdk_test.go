@@ -0,0 +1,664 @@
+package algosdk_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/algosdk/algodtest"
+	"exitor-dapp/internal/platform/tests"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/pkg/errors"
+)
+
+var test *tests.Test
+
+// TestMain is the entry point for testing.
+func TestMain(m *testing.M) {
+	os.Exit(testMain(m))
+}
+
+func testMain(m *testing.M) int {
+	tests.DisableDb = true
+
+	test = tests.New()
+	defer test.TearDown()
+
+	return m.Run()
+}
+
+// TestClientRegistry validates registering and selecting networks.
+func TestClientRegistry(t *testing.T) {
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	t.Log("Given the need to register multiple Algorand networks.")
+	{
+		c, err := algosdk.New(srv.Config(algosdk.NetworkSandbox), srv.Config(algosdk.NetworkTestnet))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tNew failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tNew ok.", tests.Success)
+
+		if exp, got := 2, len(c.Networks()); exp != got {
+			t.Log("\t\tGot :", got)
+			t.Log("\t\tWant:", exp)
+			t.Fatalf("\t%s\tShould have registered all networks.", tests.Failed)
+		}
+
+		n, err := c.Current()
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCurrent failed.", tests.Failed)
+		} else if n.Name != algosdk.NetworkSandbox {
+			t.Log("\t\tGot :", n.Name)
+			t.Log("\t\tWant:", algosdk.NetworkSandbox)
+			t.Fatalf("\t%s\tFirst network should be selected by default.", tests.Failed)
+		}
+		t.Logf("\t%s\tCurrent ok.", tests.Success)
+
+		if err := c.SelectNetwork(algosdk.NetworkTestnet); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSelectNetwork failed.", tests.Failed)
+		}
+
+		n, err = c.Current()
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCurrent failed.", tests.Failed)
+		} else if n.Name != algosdk.NetworkTestnet {
+			t.Log("\t\tGot :", n.Name)
+			t.Log("\t\tWant:", algosdk.NetworkTestnet)
+			t.Fatalf("\t%s\tSelected network should be current.", tests.Failed)
+		}
+		t.Logf("\t%s\tSelectNetwork ok.", tests.Success)
+
+		err = c.SelectNetwork(algosdk.NetworkMainnet)
+		if errors.Cause(err) != algosdk.ErrNetworkNotFound {
+			t.Log("\t\tGot :", err)
+			t.Log("\t\tWant:", algosdk.ErrNetworkNotFound)
+			t.Fatalf("\t%s\tSelectNetwork with unregistered network should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tSelectNetwork unregistered network ok.", tests.Success)
+
+		if exp, got := "https://explorer.example.com/testnet/asset/42", n.AssetUrl(42); exp != got {
+			t.Log("\t\tGot :", got)
+			t.Log("\t\tWant:", exp)
+			t.Fatalf("\t%s\tAssetUrl failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tAssetUrl ok.", tests.Success)
+	}
+
+	t.Log("Given the need to validate network configs.")
+	{
+		_, err := algosdk.New(algosdk.NetworkConfig{Name: "invalid", AlgodURL: "not a url"})
+		if err == nil {
+			t.Fatalf("\t%s\tNew with invalid config should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tNew with invalid config ok.", tests.Success)
+	}
+}
+
+// TestNetworkRequests validates the requests made to algod.
+func TestNetworkRequests(t *testing.T) {
+	ctx := context.Background()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	c, err := algosdk.New(srv.Config(algosdk.NetworkSandbox))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew failed.", tests.Failed)
+	}
+
+	n, err := c.Current()
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCurrent failed.", tests.Failed)
+	}
+
+	creator := crypto.GenerateAccount()
+
+	t.Log("Given the need to query the network.")
+	{
+		status, err := n.Status(ctx)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tStatus failed.", tests.Failed)
+		} else if status.LastRound != srv.Round() {
+			t.Log("\t\tGot :", status.LastRound)
+			t.Log("\t\tWant:", srv.Round())
+			t.Fatalf("\t%s\tStatus should return the last round.", tests.Failed)
+		}
+		t.Logf("\t%s\tStatus ok.", tests.Success)
+
+		srv.SetAccount(models.Account{Address: creator.Address.String(), Amount: 5000000})
+
+		acc, err := n.AccountInformation(ctx, creator.Address.String())
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tAccountInformation failed.", tests.Failed)
+		} else if acc.Amount != 5000000 {
+			t.Log("\t\tGot :", acc.Amount)
+			t.Log("\t\tWant:", 5000000)
+			t.Fatalf("\t%s\tAccountInformation should return the balance.", tests.Failed)
+		}
+		t.Logf("\t%s\tAccountInformation ok.", tests.Success)
+
+		_, err = n.AssetInformation(ctx, 999)
+		if err == nil {
+			t.Fatalf("\t%s\tAssetInformation for an unknown asset should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tAssetInformation unknown asset ok.", tests.Success)
+	}
+
+	t.Log("Given the need to submit a signed asset create transaction.")
+	{
+		params, err := n.SuggestedParams(ctx)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSuggestedParams failed.", tests.Failed)
+		} else if params.GenesisID != algodtest.GenesisID {
+			t.Log("\t\tGot :", params.GenesisID)
+			t.Log("\t\tWant:", algodtest.GenesisID)
+			t.Fatalf("\t%s\tSuggestedParams should return the genesis ID.", tests.Failed)
+		}
+		t.Logf("\t%s\tSuggestedParams ok.", tests.Success)
+
+		addr := creator.Address.String()
+		txn, err := future.MakeAssetCreateTxn(addr, nil, params, 1000, 0, false, addr, addr, addr, addr, "EXT", "Exitor Test", "", "")
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tMakeAssetCreateTxn failed.", tests.Failed)
+		}
+
+		expTxID, stx, err := crypto.SignTransaction(creator.PrivateKey, txn)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+		}
+
+		txID, err := n.SendRawTransaction(ctx, stx)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSendRawTransaction failed.", tests.Failed)
+		} else if txID != expTxID {
+			t.Log("\t\tGot :", txID)
+			t.Log("\t\tWant:", expTxID)
+			t.Fatalf("\t%s\tSendRawTransaction should return the transaction ID.", tests.Failed)
+		}
+		t.Logf("\t%s\tSendRawTransaction ok.", tests.Success)
+
+		if exp, got := 1, len(srv.Sent()); exp != got {
+			t.Log("\t\tGot :", got)
+			t.Log("\t\tWant:", exp)
+			t.Fatalf("\t%s\tServer should have received the transaction.", tests.Failed)
+		}
+		t.Logf("\t%s\tServer received transaction ok.", tests.Success)
+	}
+}
+
+// TestSendRawTransactionErrors validates only a submission refused by the node is
+// reported as rejected, other errors don't tell whether the node accepted it.
+func TestSendRawTransactionErrors(t *testing.T) {
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	c, err := algosdk.New(srv.Config(algosdk.NetworkSandbox))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew failed.", tests.Failed)
+	}
+
+	n, err := c.Current()
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCurrent failed.", tests.Failed)
+	}
+
+	creator := crypto.GenerateAccount()
+
+	// sign returns a new signed asset create transaction.
+	sign := func(t *testing.T, name string) []byte {
+		params, err := n.SuggestedParams(context.Background())
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSuggestedParams failed.", tests.Failed)
+		}
+
+		addr := creator.Address.String()
+		txn, err := future.MakeAssetCreateTxn(addr, nil, params, 1000, 0, false, addr, addr, addr, addr, "EXT", name, "", "")
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tMakeAssetCreateTxn failed.", tests.Failed)
+		}
+
+		_, stx, err := crypto.SignTransaction(creator.PrivateKey, txn)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+		}
+
+		return stx
+	}
+
+	t.Log("Given the need to tell a rejected submission from one with an unknown outcome.")
+	{
+		if _, err := n.SendRawTransaction(context.Background(), []byte("invalid")); errors.Cause(err) != algosdk.ErrTransactionRejected {
+			t.Log("\t\tGot :", err)
+			t.Log("\t\tWant:", algosdk.ErrTransactionRejected)
+			t.Fatalf("\t%s\tSendRawTransaction should reject an invalid transaction.", tests.Failed)
+		}
+		t.Logf("\t%s\tSendRawTransaction rejected ok.", tests.Success)
+
+		stx := sign(t, "Duplicate")
+		if _, err := n.SendRawTransaction(context.Background(), stx); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSendRawTransaction failed.", tests.Failed)
+		}
+		if _, err := n.SendRawTransaction(context.Background(), stx); err == nil || errors.Cause(err) == algosdk.ErrTransactionRejected {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSendRawTransaction should not reject a transaction already in the ledger.", tests.Failed)
+		}
+		t.Logf("\t%s\tSendRawTransaction already in ledger ok.", tests.Success)
+
+		srv.Refuse = func(stx types.SignedTxn) string { return "node unavailable" }
+		if _, err := n.SendRawTransaction(context.Background(), sign(t, "Refused")); err == nil || errors.Cause(err) == algosdk.ErrTransactionRejected {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSendRawTransaction should not reject when the node is unavailable.", tests.Failed)
+		}
+		srv.Refuse = nil
+		t.Logf("\t%s\tSendRawTransaction unavailable ok.", tests.Success)
+
+		sent := len(srv.Sent())
+		srv.Drop = func(stx types.SignedTxn) bool { return true }
+		if _, err := n.SendRawTransaction(context.Background(), sign(t, "Dropped")); err == nil || errors.Cause(err) == algosdk.ErrTransactionRejected {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSendRawTransaction should not reject when the response is lost.", tests.Failed)
+		}
+		srv.Drop = nil
+
+		if exp, got := sent+1, len(srv.Sent()); exp != got {
+			t.Log("\t\tGot :", got)
+			t.Log("\t\tWant:", exp)
+			t.Fatalf("\t%s\tServer should have accepted the transaction.", tests.Failed)
+		}
+		t.Logf("\t%s\tSendRawTransaction lost response ok.", tests.Success)
+	}
+}
+
+// TestWaitForConfirmation validates polling for a submitted transaction.
+func TestWaitForConfirmation(t *testing.T) {
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	c, err := algosdk.New(srv.Config(algosdk.NetworkSandbox))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew failed.", tests.Failed)
+	}
+
+	n, err := c.Current()
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCurrent failed.", tests.Failed)
+	}
+
+	creator := crypto.GenerateAccount()
+
+	// submit sends a new signed asset create transaction to the fake server.
+	submit := func(t *testing.T, name string) string {
+		params, err := n.SuggestedParams(context.Background())
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSuggestedParams failed.", tests.Failed)
+		}
+
+		addr := creator.Address.String()
+		txn, err := future.MakeAssetCreateTxn(addr, nil, params, 1000, 0, false, addr, addr, addr, addr, "EXT", name, "", "")
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tMakeAssetCreateTxn failed.", tests.Failed)
+		}
+
+		_, stx, err := crypto.SignTransaction(creator.PrivateKey, txn)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+		}
+
+		txID, err := n.SendRawTransaction(context.Background(), stx)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSendRawTransaction failed.", tests.Failed)
+		}
+
+		return txID
+	}
+
+	t.Log("Given the need to wait for an asset create transaction to be confirmed.")
+	{
+		srv.ConfirmAfter = 2
+		startRound := srv.Round()
+
+		txID := submit(t, "Confirmed")
+
+		res, err := n.WaitForConfirmation(context.Background(), txID, 5)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tWaitForConfirmation failed.", tests.Failed)
+		}
+
+		if exp, got := startRound+2, res.ConfirmedRound; exp != got {
+			t.Log("\t\tGot :", got)
+			t.Log("\t\tWant:", exp)
+			t.Fatalf("\t%s\tShould return the confirmed round.", tests.Failed)
+		}
+
+		if res.AssetIndex == 0 {
+			t.Fatalf("\t%s\tShould return the created asset index.", tests.Failed)
+		}
+
+		asset, err := n.AssetInformation(context.Background(), res.AssetIndex)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tAssetInformation failed.", tests.Failed)
+		} else if asset.Params.Name != "Confirmed" {
+			t.Log("\t\tGot :", asset.Params.Name)
+			t.Log("\t\tWant:", "Confirmed")
+			t.Fatalf("\t%s\tAssetInformation should return the created asset.", tests.Failed)
+		}
+		t.Logf("\t%s\tWaitForConfirmation ok.", tests.Success)
+	}
+
+	t.Log("Given the need to report transactions rejected by the pool.")
+	{
+		srv.ConfirmAfter = 1
+		srv.Reject = func(stx types.SignedTxn) string {
+			return "overspend"
+		}
+		txID := submit(t, "Rejected")
+
+		_, err := n.WaitForConfirmation(context.Background(), txID, 5)
+		if errors.Cause(err) != algosdk.ErrTxnPoolRejected {
+			t.Log("\t\tGot :", err)
+			t.Log("\t\tWant:", algosdk.ErrTxnPoolRejected)
+			t.Fatalf("\t%s\tWaitForConfirmation should fail with pool error.", tests.Failed)
+		}
+		srv.Reject = nil
+		t.Logf("\t%s\tWaitForConfirmation pool error ok.", tests.Success)
+	}
+
+	t.Log("Given the need to limit the number of rounds waited.")
+	{
+		srv.ConfirmAfter = 100
+		startRound := srv.Round()
+
+		txID := submit(t, "Timeout")
+
+		_, err := n.WaitForConfirmation(context.Background(), txID, 3)
+		if errors.Cause(err) != algosdk.ErrTxnNotConfirmed {
+			t.Log("\t\tGot :", err)
+			t.Log("\t\tWant:", algosdk.ErrTxnNotConfirmed)
+			t.Fatalf("\t%s\tWaitForConfirmation should fail after max rounds.", tests.Failed)
+		}
+
+		if exp, got := startRound+3, srv.Round(); exp != got {
+			t.Log("\t\tGot :", got)
+			t.Log("\t\tWant:", exp)
+			t.Fatalf("\t%s\tShould only wait for the max rounds.", tests.Failed)
+		}
+		t.Logf("\t%s\tWaitForConfirmation max rounds ok.", tests.Success)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = n.WaitForConfirmation(ctx, txID, 3)
+		if errors.Cause(err) != context.Canceled {
+			t.Log("\t\tGot :", err)
+			t.Log("\t\tWant:", context.Canceled)
+			t.Fatalf("\t%s\tWaitForConfirmation should fail when context cancelled.", tests.Failed)
+		}
+		t.Logf("\t%s\tWaitForConfirmation context cancelled ok.", tests.Success)
+	}
+}
+
+// TestTransactionStatus validates a transaction the node no longer knows about is looked
+// up in the indexer and only reported expired once the indexer has passed its last
+// valid round.
+func TestTransactionStatus(t *testing.T) {
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	c, err := algosdk.New(srv.Config(algosdk.NetworkSandbox))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew failed.", tests.Failed)
+	}
+
+	n, err := c.Current()
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCurrent failed.", tests.Failed)
+	}
+
+	creator := crypto.GenerateAccount()
+
+	// submit sends a new signed asset create transaction to the fake server and returns
+	// its ID and last valid round.
+	submit := func(t *testing.T, name string) (string, uint64) {
+		params, err := n.SuggestedParams(context.Background())
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSuggestedParams failed.", tests.Failed)
+		}
+
+		addr := creator.Address.String()
+		txn, err := future.MakeAssetCreateTxn(addr, nil, params, 1000, 0, false, addr, addr, addr, addr, "EXT", name, "", "")
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tMakeAssetCreateTxn failed.", tests.Failed)
+		}
+
+		_, stx, err := crypto.SignTransaction(creator.PrivateKey, txn)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+		}
+
+		txID, err := n.SendRawTransaction(context.Background(), stx)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSendRawTransaction failed.", tests.Failed)
+		}
+
+		return txID, uint64(txn.LastValid)
+	}
+
+	t.Log("Given the need to find confirmed transactions that have left the pool.")
+	{
+		srv.ConfirmAfter = 1
+
+		txID, lastValid := submit(t, "Forgotten")
+		srv.Advance(1)
+		srv.Forget(txID)
+
+		_, err := n.PendingTransaction(context.Background(), txID)
+		if err == nil {
+			t.Fatalf("\t%s\tPendingTransaction should fail once forgotten.", tests.Failed)
+		}
+
+		res, err := n.TransactionStatus(context.Background(), txID, lastValid)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tTransactionStatus failed.", tests.Failed)
+		} else if res == nil || res.AssetIndex == 0 {
+			t.Logf("\t\tGot : %+v", res)
+			t.Fatalf("\t%s\tTransactionStatus should return the indexed confirmation.", tests.Failed)
+		}
+		t.Logf("\t%s\tTransactionStatus forgotten confirmed ok.", tests.Success)
+	}
+
+	t.Log("Given the need to only expire transactions past their last valid round.")
+	{
+		srv.ConfirmAfter = 1 << 32
+		defer func() { srv.ConfirmAfter = 1 }()
+
+		txID, lastValid := submit(t, "Dropped")
+		srv.Forget(txID)
+
+		res, err := n.TransactionStatus(context.Background(), txID, lastValid)
+		if err != nil || res != nil {
+			t.Logf("\t\tGot : %+v %v", res, err)
+			t.Fatalf("\t%s\tTransactionStatus should be pending until the last valid round.", tests.Failed)
+		}
+		t.Logf("\t%s\tTransactionStatus pending ok.", tests.Success)
+
+		srv.Advance(lastValid - srv.Round() + 1)
+
+		_, err = n.TransactionStatus(context.Background(), txID, lastValid)
+		if errors.Cause(err) != algosdk.ErrTxnExpired {
+			t.Log("\t\tGot :", err)
+			t.Log("\t\tWant:", algosdk.ErrTxnExpired)
+			t.Fatalf("\t%s\tTransactionStatus should fail once expired.", tests.Failed)
+		}
+		t.Logf("\t%s\tTransactionStatus expired ok.", tests.Success)
+	}
+}
+
+// TestSigners validates each signer type signs with the key of the sender or
+// refuses to sign.
+func TestSigners(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tTempDir failed.", tests.Failed)
+	}
+	defer os.RemoveAll(dir)
+
+	testSigner := algosdk.NewTestSigner(1)
+
+	keystoreSigner, err := algosdk.NewLocalKeystoreSigner(dir, "dev-password")
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNewLocalKeystoreSigner failed.", tests.Failed)
+	}
+	keystoreAddr, err := keystoreSigner.Generate()
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tGenerate failed.", tests.Failed)
+	}
+
+	wrongPasswordSigner, err := algosdk.NewLocalKeystoreSigner(dir, "wrong-password")
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNewLocalKeystoreSigner failed.", tests.Failed)
+	}
+
+	// makeTxn returns a payment transaction sent by the address.
+	makeTxn := func(t *testing.T, sender string) types.Transaction {
+		params := types.SuggestedParams{
+			Fee:             1000,
+			FirstRoundValid: 1000,
+			LastRoundValid:  2000,
+			GenesisID:       algodtest.GenesisID,
+			GenesisHash:     make([]byte, 32),
+			FlatFee:         true,
+		}
+
+		txn, err := future.MakePaymentTxn(sender, crypto.GenerateAccount().Address.String(), 1, nil, "", params)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tMakePaymentTxn failed.", tests.Failed)
+		}
+		return txn
+	}
+
+	var signerTests = []struct {
+		name   string
+		signer algosdk.Signer
+		sender string
+		err    error
+	}{
+		{"External", algosdk.NewExternalSigner(), testSigner.Addresses()[0], algosdk.ErrExternalSigningRequired},
+		{"Test", testSigner, testSigner.Addresses()[0], nil},
+		{"TestUnknownSender", testSigner, crypto.GenerateAccount().Address.String(), algosdk.ErrSignerAccountNotFound},
+		{"Keystore", keystoreSigner, keystoreAddr, nil},
+		{"KeystoreUnknownSender", keystoreSigner, testSigner.Addresses()[0], algosdk.ErrSignerAccountNotFound},
+		{"KeystoreWrongPassword", wrongPasswordSigner, keystoreAddr, algosdk.ErrKeystorePassword},
+	}
+
+	t.Log("Given the need to sign transactions without passphrases reaching the web app.")
+	{
+		for i, tt := range signerTests {
+			t.Logf("\tTest: %d\tWhen running test: %s", i, tt.name)
+			{
+				txn := makeTxn(t, tt.sender)
+
+				raw, err := tt.signer.SignTransaction(ctx, txn)
+				if errors.Cause(err) != tt.err {
+					t.Logf("\t\tGot : %+v", err)
+					t.Logf("\t\tWant: %+v", tt.err)
+					t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+				} else if tt.err != nil {
+					t.Logf("\t%s\tSignTransaction refused ok.", tests.Success)
+					continue
+				}
+
+				var stx types.SignedTxn
+				if err := msgpack.Decode(raw, &stx); err != nil {
+					t.Log("\t\tGot :", err)
+					t.Fatalf("\t%s\tDecode signed transaction failed.", tests.Failed)
+				}
+
+				msg := append([]byte("TX"), msgpack.Encode(stx.Txn)...)
+				if !ed25519.Verify(ed25519.PublicKey(txn.Sender[:]), msg, stx.Sig[:]) {
+					t.Fatalf("\t%s\tSignature should be made by the sender.", tests.Failed)
+				}
+				t.Logf("\t%s\tSignTransaction ok.", tests.Success)
+			}
+		}
+	}
+}
+
+// TestAccountFromPrivateKey validates the account is derived from a private key and keys
+// with a public key that doesn't match their seed are rejected.
+func TestAccountFromPrivateKey(t *testing.T) {
+	acc := crypto.GenerateAccount()
+	other := crypto.GenerateAccount()
+
+	t.Log("Given the need to import private keys of accounts.")
+	{
+		res, err := algosdk.AccountFromPrivateKey(acc.PrivateKey)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tAccountFromPrivateKey failed.", tests.Failed)
+		} else if res.Address != acc.Address || !res.PublicKey.Equal(acc.PublicKey) {
+			t.Log("\t\tGot :", res.Address.String())
+			t.Log("\t\tWant:", acc.Address.String())
+			t.Fatalf("\t%s\tAccountFromPrivateKey should return the account of the key.", tests.Failed)
+		}
+		t.Logf("\t%s\tAccountFromPrivateKey ok.", tests.Success)
+
+		// Pair the seed of the account with the public key of another account.
+		mismatched := append(append(ed25519.PrivateKey{}, acc.PrivateKey.Seed()...), other.PublicKey...)
+		for _, sk := range []ed25519.PrivateKey{mismatched, acc.PrivateKey[:32]} {
+			if _, err := algosdk.AccountFromPrivateKey(sk); err == nil {
+				t.Fatalf("\t%s\tAccountFromPrivateKey should reject an invalid key.", tests.Failed)
+			}
+		}
+		t.Logf("\t%s\tAccountFromPrivateKey invalid key rejected ok.", tests.Success)
+	}
+}
@@ -0,0 +1,154 @@
+package algosdk
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// DefaultConfirmationRounds is the number of rounds to wait for a transaction to be
+// confirmed when no budget is provided. Transactions are typically confirmed within
+// a few rounds of being submitted.
+const DefaultConfirmationRounds uint64 = 10
+
+var (
+	// ErrTxnPoolRejected occurs when a submitted transaction was removed from the
+	// transaction pool without being confirmed.
+	ErrTxnPoolRejected = errors.New("Transaction rejected by the transaction pool")
+
+	// ErrTxnNotConfirmed occurs when a transaction was not confirmed within the
+	// allowed number of rounds.
+	ErrTxnNotConfirmed = errors.New("Transaction not confirmed")
+
+	// ErrTxnExpired occurs when a transaction was not confirmed before its last valid
+	// round and can never be confirmed.
+	ErrTxnExpired = errors.New("Transaction expired")
+)
+
+// Confirmation contains the details of a transaction once confirmed on the network.
+type Confirmation struct {
+	TxID           string `json:"tx_id"`
+	ConfirmedRound uint64 `json:"confirmed_round"`
+	// AssetIndex is the ID of the asset created by the transaction, only set for
+	// asset create transactions.
+	AssetIndex uint64 `json:"asset_index,omitempty"`
+}
+
+// PendingTransaction returns the current state of a submitted transaction. A nil
+// Confirmation with no error indicates the transaction is still pending.
+func (n *Network) PendingTransaction(ctx context.Context, txID string) (*Confirmation, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.algosdk.PendingTransaction")
+	defer span.Finish()
+
+	res, _, err := n.algod.PendingTransactionInformation(txID).Do(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get pending transaction %s on %s failed", txID, n.Name)
+	}
+
+	if res.PoolError != "" {
+		return nil, errors.WithMessagef(ErrTxnPoolRejected, "transaction %s : %s", txID, res.PoolError)
+	} else if res.ConfirmedRound == 0 {
+		return nil, nil
+	}
+
+	return &Confirmation{
+		TxID:           txID,
+		ConfirmedRound: res.ConfirmedRound,
+		AssetIndex:     res.AssetIndex,
+	}, nil
+}
+
+// TransactionStatus returns the confirmation of a submitted transaction that is valid
+// up to lastValidRound. A nil Confirmation with no error indicates the transaction may
+// still be confirmed. The node forgets about a transaction once it leaves the pool, so
+// a transaction the node doesn't report as confirmed is looked up in the indexer and
+// ErrTxnExpired is only returned once the indexer has passed the last valid round
+// without a record of it. Without an indexer an expired transaction can't be told
+// apart from a confirmed one and is reported as pending.
+func (n *Network) TransactionStatus(ctx context.Context, txID string, lastValidRound uint64) (*Confirmation, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.algosdk.TransactionStatus")
+	defer span.Finish()
+
+	res, err := n.PendingTransaction(ctx, txID)
+	if err != nil && errors.Cause(err) == ErrTxnPoolRejected {
+		return nil, err
+	} else if res != nil {
+		return res, nil
+	}
+
+	// A transaction still in the pool can't have been confirmed before the last valid round.
+	if err == nil {
+		status, err := n.Status(ctx)
+		if err != nil {
+			return nil, err
+		} else if status.LastRound <= lastValidRound {
+			return nil, nil
+		}
+	}
+
+	res, round, err := n.LookupTransaction(ctx, txID)
+	if err != nil {
+		if errors.Cause(err) == ErrNoIndexer {
+			return nil, nil
+		}
+		return nil, err
+	} else if res != nil {
+		return res, nil
+	} else if round > lastValidRound {
+		return nil, errors.WithMessagef(ErrTxnExpired, "transaction %s was not confirmed before round %d", txID, lastValidRound)
+	}
+
+	return nil, nil
+}
+
+// WaitForConfirmation polls the network round by round until the transaction is
+// confirmed, rejected from the pool, the context is cancelled or maxRounds have
+// passed. When maxRounds is zero, DefaultConfirmationRounds is used.
+func (n *Network) WaitForConfirmation(ctx context.Context, txID string, maxRounds uint64) (*Confirmation, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.algosdk.WaitForConfirmation")
+	defer span.Finish()
+
+	if maxRounds == 0 {
+		maxRounds = DefaultConfirmationRounds
+	}
+
+	status, err := n.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	startRound := status.LastRound
+	round := startRound
+
+	for {
+		res, err := n.PendingTransaction(ctx, txID)
+		if err != nil {
+			return nil, err
+		} else if res != nil {
+			return res, nil
+		}
+
+		if round >= startRound+maxRounds {
+			return nil, errors.WithMessagef(ErrTxnNotConfirmed, "transaction %s not confirmed after %d rounds", txID, maxRounds)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		// Block until the next round has been reached.
+		status, err := n.algod.StatusAfterBlock(round).Do(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, errors.WithStack(ctx.Err())
+			}
+			return nil, errors.Wrapf(err, "wait for block after %d on %s failed", round, n.Name)
+		}
+
+		if status.LastRound > round {
+			round = status.LastRound
+		} else {
+			round++
+		}
+	}
+}
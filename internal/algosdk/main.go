@@ -1,17 +0,0 @@
-package main
-
-import (
-	"encoding/base64"
-	"fmt"
-
-	"github.com/algorand/go-algorand-sdk/client/algod"
-	"github.com/algorand/go-algorand-sdk/crypto"
-	"github.com/algorand/go-algorand-sdk/mnemonic"
-	"github.com/algorand/go-algorand-sdk/transaction"
-
-)
-
-
-func main() {
-	//Create
-}
\ No newline at end of file
@@ -0,0 +1,108 @@
+package algosdk
+
+import (
+	"strings"
+)
+
+// Network names for the Algorand networks Exitor knows how to connect to.
+const (
+	// NetworkMainnet is the Algorand production network.
+	NetworkMainnet = "mainnet"
+	// NetworkTestnet is the public Algorand test network.
+	NetworkTestnet = "testnet"
+	// NetworkBetanet is the Algorand network used to preview new protocol features.
+	NetworkBetanet = "betanet"
+	// NetworkSandbox is a private network running locally, ie: the algorand/sandbox docker setup.
+	NetworkSandbox = "sandbox"
+)
+
+// Network_Values provides the list of network names registered by default.
+var Network_Values = []string{
+	NetworkMainnet,
+	NetworkTestnet,
+	NetworkBetanet,
+	NetworkSandbox,
+}
+
+// NetworkConfig defines the details required to connect to an Algorand network
+// and to link to a block explorer for it.
+type NetworkConfig struct {
+	// Name is the unique key the network is registered under, ie: testnet.
+	Name string `json:"name" validate:"required" example:"testnet"`
+	// Label is the display name for the network.
+	Label string `json:"label" example:"TESTNET"`
+	// AlgodURL is the base URL of the algod REST API.
+	AlgodURL string `json:"algod_url" validate:"required,url" example:"https://testnet-algorand.api.purestake.io/ps2"`
+	// AlgodToken is the value sent with the X-Algo-API-Token header, only required
+	// when connecting to a node directly.
+	AlgodToken string `json:"-"`
+	// IndexerURL is the base URL of the indexer REST API.
+	IndexerURL string `json:"indexer_url" validate:"omitempty,url" example:"https://testnet-algorand.api.purestake.io/idx2"`
+	// IndexerToken is the value sent with the X-Indexer-API-Token header, only
+	// required when connecting to an indexer directly.
+	IndexerToken string `json:"-"`
+	// ApiKeyHeader is the name of the header used by hosted API services, ie: X-API-Key for PureStake.
+	ApiKeyHeader string `json:"api_key_header" example:"X-API-Key"`
+	// ApiKey is the value sent with the ApiKeyHeader.
+	ApiKey string `json:"-"`
+	// ExplorerURL is the base URL of a block explorer for the network.
+	ExplorerURL string `json:"explorer_url" validate:"omitempty,url" example:"https://goalseeker.purestake.io/algorand/testnet"`
+}
+
+// Headers returns the additional headers to be included with every request
+// made to the network.
+func (c NetworkConfig) Headers() map[string]string {
+	hdrs := make(map[string]string)
+	if c.ApiKeyHeader != "" && c.ApiKey != "" {
+		hdrs[c.ApiKeyHeader] = c.ApiKey
+	}
+	return hdrs
+}
+
+// explorerUrl joins the explorer base URL with the supplied path parts.
+func (c NetworkConfig) explorerUrl(pts ...string) string {
+	if c.ExplorerURL == "" {
+		return ""
+	}
+	return strings.TrimRight(c.ExplorerURL, "/") + "/" + strings.Join(pts, "/")
+}
+
+// DefaultNetworkConfigs returns the connection details for the public networks
+// using the PureStake API service and a local sandbox. The API key still needs
+// to be set before the PureStake networks can be used.
+func DefaultNetworkConfigs() []NetworkConfig {
+	return []NetworkConfig{
+		{
+			Name:         NetworkMainnet,
+			Label:        "MAINNET",
+			AlgodURL:     "https://mainnet-algorand.api.purestake.io/ps2",
+			IndexerURL:   "https://mainnet-algorand.api.purestake.io/idx2",
+			ApiKeyHeader: "X-API-Key",
+			ExplorerURL:  "https://goalseeker.purestake.io/algorand/mainnet",
+		},
+		{
+			Name:         NetworkTestnet,
+			Label:        "TESTNET",
+			AlgodURL:     "https://testnet-algorand.api.purestake.io/ps2",
+			IndexerURL:   "https://testnet-algorand.api.purestake.io/idx2",
+			ApiKeyHeader: "X-API-Key",
+			ExplorerURL:  "https://goalseeker.purestake.io/algorand/testnet",
+		},
+		{
+			Name:         NetworkBetanet,
+			Label:        "BETANET",
+			AlgodURL:     "https://betanet-algorand.api.purestake.io/ps2",
+			IndexerURL:   "https://betanet-algorand.api.purestake.io/idx2",
+			ApiKeyHeader: "X-API-Key",
+			ExplorerURL:  "https://goalseeker.purestake.io/algorand/betanet",
+		},
+		{
+			Name:         NetworkSandbox,
+			Label:        "SANDBOX",
+			AlgodURL:     "http://127.0.0.1:4001",
+			AlgodToken:   "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			IndexerURL:   "http://127.0.0.1:8980",
+			IndexerToken: "",
+		},
+	}
+}
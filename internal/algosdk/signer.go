@@ -0,0 +1,339 @@
+package algosdk
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// Signer types that can be selected by config.
+const (
+	SignerExternal  = "external"
+	SignerCustodial = "custodial"
+	SignerKeystore  = "keystore"
+	SignerTest      = "test"
+)
+
+// Signer_Values provides list of valid signer types.
+var Signer_Values = []string{
+	SignerExternal,
+	SignerCustodial,
+	SignerKeystore,
+	SignerTest,
+}
+
+var (
+	// ErrExternalSigningRequired occurs when a transaction can't be signed by the
+	// application and must be signed offline by the owner of the account.
+	ErrExternalSigningRequired = errors.New("Transaction must be signed externally")
+
+	// ErrSignerAccountNotFound occurs when the signer doesn't hold the key for the
+	// sender of a transaction.
+	ErrSignerAccountNotFound = errors.New("Signer account not found")
+
+	// ErrKeystorePassword occurs when a key in the local keystore can't be
+	// decrypted with the provided password.
+	ErrKeystorePassword = errors.New("Invalid keystore password")
+)
+
+// Signer signs transactions on behalf of Algorand accounts. Passphrases and keys
+// must never be sent to the web app, a Signer either holds the keys itself or
+// requires the transaction to be signed externally.
+type Signer interface {
+	// SignTransaction signs the transaction with the key of the sender and returns
+	// the msgpack encoded signed transaction.
+	SignTransaction(ctx context.Context, tx types.Transaction) ([]byte, error)
+}
+
+// ExternalSigner never signs transactions, they must be downloaded and signed
+// offline. Along with the custodial keystore for accounts owned by Exitor, this
+// is the only signer permitted in production.
+type ExternalSigner struct{}
+
+// NewExternalSigner creates a new ExternalSigner.
+func NewExternalSigner() *ExternalSigner {
+	return &ExternalSigner{}
+}
+
+// SignTransaction always returns ErrExternalSigningRequired.
+func (s *ExternalSigner) SignTransaction(ctx context.Context, tx types.Transaction) ([]byte, error) {
+	return nil, errors.WithMessagef(ErrExternalSigningRequired, "sender %s", tx.Sender.String())
+}
+
+// TestSigner signs transactions with keys generated in memory. The keys are lost
+// when the process exits so it should only be used with a sandbox network.
+type TestSigner struct {
+	mtx      sync.RWMutex
+	accounts map[types.Address]crypto.Account
+}
+
+// NewTestSigner creates a new TestSigner with the number of accounts generated.
+func NewTestSigner(n int) *TestSigner {
+	s := &TestSigner{
+		accounts: make(map[types.Address]crypto.Account),
+	}
+	for i := 0; i < n; i++ {
+		s.Generate()
+	}
+	return s
+}
+
+// Generate creates a new account that the signer can sign for.
+func (s *TestSigner) Generate() crypto.Account {
+	acc := crypto.GenerateAccount()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.accounts[acc.Address] = acc
+
+	return acc
+}
+
+// Addresses returns the sorted addresses of the accounts of the signer.
+func (s *TestSigner) Addresses() []string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	var l []string
+	for a := range s.accounts {
+		l = append(l, a.String())
+	}
+	sort.Strings(l)
+
+	return l
+}
+
+// SignTransaction signs the transaction with the generated key of the sender.
+func (s *TestSigner) SignTransaction(ctx context.Context, tx types.Transaction) ([]byte, error) {
+	s.mtx.RLock()
+	acc, ok := s.accounts[tx.Sender]
+	s.mtx.RUnlock()
+
+	if !ok {
+		return nil, errors.WithMessagef(ErrSignerAccountNotFound, "sender %s", tx.Sender.String())
+	}
+
+	_, stx, err := crypto.SignTransaction(acc.PrivateKey, tx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sign transaction for %s failed", tx.Sender.String())
+	}
+
+	return stx, nil
+}
+
+// keystoreFile is the JSON stored for each key in the local keystore. The private
+// key is encrypted with AES-GCM using a key derived from the password with scrypt.
+type keystoreFile struct {
+	Address    string `json:"address"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+	ScryptN    int    `json:"scrypt_n"`
+	ScryptR    int    `json:"scrypt_r"`
+	ScryptP    int    `json:"scrypt_p"`
+}
+
+// Scrypt parameters used for encrypting new keys in the local keystore.
+const (
+	keystoreScryptN = 1 << 15
+	keystoreScryptR = 8
+	keystoreScryptP = 1
+)
+
+// LocalKeystoreSigner signs transactions with keys stored encrypted in a local
+// directory, one file per address. It's intended for development where keys are
+// generated or imported by the developer.
+type LocalKeystoreSigner struct {
+	dir      string
+	password []byte
+}
+
+// NewLocalKeystoreSigner creates a new LocalKeystoreSigner for the directory. The
+// directory is created if it doesn't exist.
+func NewLocalKeystoreSigner(dir, password string) (*LocalKeystoreSigner, error) {
+	if password == "" {
+		return nil, errors.New("Keystore password is required")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "create keystore dir %s failed", dir)
+	}
+
+	return &LocalKeystoreSigner{
+		dir:      dir,
+		password: []byte(password),
+	}, nil
+}
+
+// Generate creates a new account and stores its key in the keystore.
+func (s *LocalKeystoreSigner) Generate() (string, error) {
+	acc := crypto.GenerateAccount()
+	if err := s.Import(acc.PrivateKey); err != nil {
+		return "", err
+	}
+	return acc.Address.String(), nil
+}
+
+// AccountFromPrivateKey returns the account of the ed25519 private key. The public key
+// embedded in the private key must be the one derived from its seed.
+func AccountFromPrivateKey(sk ed25519.PrivateKey) (crypto.Account, error) {
+	if len(sk) != ed25519.PrivateKeySize {
+		return crypto.Account{}, errors.Errorf("private key is %d bytes, want %d", len(sk), ed25519.PrivateKeySize)
+	}
+
+	pk := sk.Public().(ed25519.PublicKey)
+	if !ed25519.NewKeyFromSeed(sk.Seed()).Public().(ed25519.PublicKey).Equal(pk) {
+		return crypto.Account{}, errors.New("public key does not match the seed of the private key")
+	}
+
+	var addr types.Address
+	copy(addr[:], pk)
+
+	return crypto.Account{
+		PublicKey:  pk,
+		PrivateKey: sk,
+		Address:    addr,
+	}, nil
+}
+
+// Import encrypts the private key and stores it in the keystore.
+func (s *LocalKeystoreSigner) Import(sk ed25519.PrivateKey) error {
+	acc, err := AccountFromPrivateKey(sk)
+	if err != nil {
+		return errors.Wrap(err, "invalid private key")
+	}
+
+	f := keystoreFile{
+		Address: acc.Address.String(),
+		Salt:    make([]byte, 32),
+		ScryptN: keystoreScryptN,
+		ScryptR: keystoreScryptR,
+		ScryptP: keystoreScryptP,
+	}
+	if _, err := io.ReadFull(rand.Reader, f.Salt); err != nil {
+		return errors.WithStack(err)
+	}
+
+	gcm, err := s.cipher(f)
+	if err != nil {
+		return err
+	}
+
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, f.Nonce); err != nil {
+		return errors.WithStack(err)
+	}
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, sk, []byte(f.Address))
+
+	dat, err := json.Marshal(f)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := ioutil.WriteFile(s.filePath(f.Address), dat, 0600); err != nil {
+		return errors.Wrapf(err, "write keystore file for %s failed", f.Address)
+	}
+
+	return nil
+}
+
+// Addresses returns the sorted addresses stored in the keystore.
+func (s *LocalKeystoreSigner) Addresses() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var l []string
+	for _, f := range files {
+		l = append(l, filepath.Base(f[:len(f)-len(".json")]))
+	}
+	sort.Strings(l)
+
+	return l, nil
+}
+
+// SignTransaction decrypts the key of the sender and signs the transaction.
+func (s *LocalKeystoreSigner) SignTransaction(ctx context.Context, tx types.Transaction) ([]byte, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.algosdk.LocalKeystoreSigner.SignTransaction")
+	defer span.Finish()
+
+	sk, err := s.privateKey(tx.Sender.String())
+	if err != nil {
+		return nil, err
+	}
+
+	_, stx, err := crypto.SignTransaction(sk, tx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sign transaction for %s failed", tx.Sender.String())
+	}
+
+	return stx, nil
+}
+
+// privateKey loads and decrypts the private key for the address.
+func (s *LocalKeystoreSigner) privateKey(address string) (ed25519.PrivateKey, error) {
+	dat, err := ioutil.ReadFile(s.filePath(address))
+	if os.IsNotExist(err) {
+		return nil, errors.WithMessagef(ErrSignerAccountNotFound, "sender %s", address)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "read keystore file for %s failed", address)
+	}
+
+	var f keystoreFile
+	if err := json.Unmarshal(dat, &f); err != nil {
+		return nil, errors.Wrapf(err, "decode keystore file for %s failed", address)
+	}
+
+	gcm, err := s.cipher(f)
+	if err != nil {
+		return nil, err
+	}
+
+	sk, err := gcm.Open(nil, f.Nonce, f.Ciphertext, []byte(f.Address))
+	if err != nil {
+		return nil, errors.WithMessagef(ErrKeystorePassword, "address %s", address)
+	}
+
+	return ed25519.PrivateKey(sk), nil
+}
+
+// cipher derives the encryption key for the keystore file from the password.
+func (s *LocalKeystoreSigner) cipher(f keystoreFile) (cipher.AEAD, error) {
+	key, err := scrypt.Key(s.password, f.Salt, f.ScryptN, f.ScryptR, f.ScryptP, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "derive keystore key failed")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return gcm, nil
+}
+
+// filePath returns the path of the keystore file for the address.
+func (s *LocalKeystoreSigner) filePath(address string) string {
+	return filepath.Join(s.dir, filepath.Base(address)+".json")
+}
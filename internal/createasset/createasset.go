@@ -3,13 +3,13 @@ package createasset
 import (
 	"context"
 	"database/sql"
-	"fmt"
-	"io/ioutil"
+	"encoding/hex"
 	"time"
 
 	"exitor-dapp/internal/platform/auth"
 	"exitor-dapp/internal/platform/web/webcontext"
 
+	"github.com/algorand/go-algorand-sdk/crypto"
 	"github.com/huandu/go-sqlbuilder"
 	"github.com/jmoiron/sqlx"
 	"github.com/pborman/uuid"
@@ -27,74 +27,75 @@ Algorand Blockchain */
 
 const (
 	// The database table for created assets
-	CreatedAssetTableName = "CreatedAsset"
+	CreatedAssetTableName = "created_assets"
 )
 
 var (
 	// ErrNotFound abstracts the postgres not found error
 	ErrNotFound = errors.New("Entity not found")
 
-	// ErrForbidden occurs when a user tries to do sth that is 
+	// ErrForbidden occurs when a user tries to do sth that is
 	// forbidden to them according to Exitor's access control
 	// policies
 	ErrForbidden = errors.New("Attempted action is not allowed")
 )
 
-// CanReadAsset determines if claims has the authority to access the specified asset by id.
+// CanReadCreatedAsset determines if claims has the authority to access the specified asset by id.
 func (repo *Repository) CanReadCreatedAsset(ctx context.Context, claims auth.Claims, id string) error {
-
-		// if the request has claims from a specific created asset, ensure
-		// that the claim has the correct access to the asset
-		if claims.Audience != "" {
-			// select id from CreatedAsset where account_id = [accountID]
-			query := sqlbuilder.NewSelectBuilder().Select("id").From(CreatedAssetTableName)
-			query.Where(query.And(
-					query.Equal("account_id", claims.Audience),
-					query.Equal("ID",id),
-					//query.Equal("assetCreator") OR query.Equal("assetManager")
-			))
-
-			queryStr, args := query.Build()
-			queryStr = repo.DbConn.Rebind(queryStr)
-			var id string 
-			err := repo.DbConn.QueryRowContext(ctx, queryStr, args...).Scan(&id)
-			if err != nil && err != sql.ErrNoRows {
-				err = errors.Wrapf(err, "query - %s", query.String())
-				return err
-			}
-
-			// When there is no id returned, then the current claim user
-			// does not have access to the specified created asset
-			if id == "" {
-				return errors.WithStack(ErrForbidden)
-			} 
-		}
-
-		return nil
-}
-
-func (repo *Repository) CanModifyCreatedAsset(ctx context.Context, claims auth.Claims, id string) error {
-	    err := repo.CanReadCreatedAsset(ctx, claims, id)
-		if err != nil {
+	// If the request has claims from a specific account, ensure that the claims
+	// has the correct access to the asset.
+	if claims.Audience != "" {
+		// select id from created_assets where account_id = [accountID] and id = [id]
+		query := sqlbuilder.NewSelectBuilder().Select("id").From(CreatedAssetTableName)
+		query.Where(query.And(
+			query.Equal("account_id", claims.Audience),
+			query.Equal("id", id),
+		))
+		queryStr, args := query.Build()
+		queryStr = repo.DbConn.Rebind(queryStr)
+
+		var createdAssetID string
+		err := repo.DbConn.QueryRowContext(ctx, queryStr, args...).Scan(&createdAssetID)
+		if err != nil && err != sql.ErrNoRows {
+			err = errors.Wrapf(err, "query - %s", query.String())
 			return err
 		}
 
-		// Admin user can update an asset they have access to
-		if !claims.HasRole(auth.RoleAdmin) {
+		// When there is no id returned, then the current claim user
+		// does not have access to the specified created asset
+		if createdAssetID == "" {
 			return errors.WithStack(ErrForbidden)
 		}
+	}
 
-		return nil
+	return nil
 }
-// Clawback function will be implemented when 
-// an asset already recorded on the chain is modified
 
+// CanModifyCreatedAsset determines if claims has the authority to modify the specified asset by id.
+// The claims must have at least one of the permissions, by default the permission to draft assets.
+func (repo *Repository) CanModifyCreatedAsset(ctx context.Context, claims auth.Claims, id string, permissions ...string) error {
+	err := repo.CanReadCreatedAsset(ctx, claims, id)
+	if err != nil {
+		return err
+	}
+
+	if len(permissions) == 0 {
+		permissions = []string{auth.PermissionAssetDraft}
+	}
+
+	// Users can update an asset they have access to when their roles grant the permission.
+	if claims.Audience != "" && !claims.HasPermission(permissions...) {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	return nil
+}
 
 // applyClaimsSelect applies a sub-query to the provided query to enforce ACL based on the
 // claims provided.
-// 1. No claims, request is internal, no ACL applied
-// 2. All role types can access their user ID
-func applyClaimsSelect(ctx context.Context, claims auth.Claims, query * sqlbuilder.SelectBuilder) error {
+//  1. No claims, request is internal, no ACL applied
+//  2. All role types can access assets of their account
+func applyClaimsSelect(ctx context.Context, claims auth.Claims, query *sqlbuilder.SelectBuilder) error {
 	// if claims are empty, don't apply any ACL
 	if claims.Audience == "" {
 		return nil
@@ -104,27 +105,23 @@ func applyClaimsSelect(ctx context.Context, claims auth.Claims, query * sqlbuild
 	return nil
 }
 
+// createdAssetMapColumns is the list of columns needed for find.
+var createdAssetMapColumns = "id,account_id,network,asset_index,unit_name,asset_name,total,decimals,default_frozen,url,metadata_hash," +
+	"description,image_url,legal_documents,metadata," +
+	"creator_address,manager_address,reserve_address,freeze_address,clawback_address,tx_id,confirmed_round,status,mint_status,mint_error," +
+	"last_valid_round,unsigned_txn,destroyed_at,created_at,updated_at,archived_at"
 
-// TODO Function that claws back and asset created
-// The function must call the required parameters as per Algorand's
-// asset parameters
-
-
-// createdassetsMapColumns is the list of columns needed for find
-var createdassetsMapColumns = "id,account_id,assetname,walletaddress,status,created_at,updated_at,archived_at"
-// Will change above to fit the needed params
-
+// selectQuery constructs a base select query for CreatedAsset.
 func selectQuery() *sqlbuilder.SelectBuilder {
 	query := sqlbuilder.NewSelectBuilder()
-	query.Select(createdassetsMapColumns)
+	query.Select(createdAssetMapColumns)
 	query.From(CreatedAssetTableName)
 	return query
 }
 
-
-// findRequestQuery generates the select query for the given find request
+// findRequestQuery generates the select query for the given find request.
 // TODO: Need to figure out why we cannot parse the args when appending the where to
-// the query
+// the query.
 func findRequestQuery(req CreatedAssetFindRequest) (*sqlbuilder.SelectBuilder, []interface{}) {
 	query := selectQuery()
 
@@ -147,73 +144,72 @@ func findRequestQuery(req CreatedAssetFindRequest) (*sqlbuilder.SelectBuilder, [
 	return query, req.Args
 }
 
-// Find() gets all the createdassets from the database based
-// on the request params
-func (repo *Repository) Find(ctx context.Context, claims auth.Claims, req CreatedAssetFindRequest) (CreatedAsset, error) {
+// Find gets all the created assets from the database based on the request params.
+func (repo *Repository) Find(ctx context.Context, claims auth.Claims, req CreatedAssetFindRequest) (CreatedAssets, error) {
 	query, args := findRequestQuery(req)
 	return find(ctx, claims, repo.DbConn, query, args, req.IncludeArchived)
 }
 
+// find internal method for getting all the created assets from the database using a select query.
+func find(ctx context.Context, claims auth.Claims, dbConn *sqlx.DB, query *sqlbuilder.SelectBuilder, args []interface{}, includedArchived bool) (CreatedAssets, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Find")
+	defer span.Finish()
 
-// this is find, an internal method for getting all the created assets from the 
-// database using a select query
-func find(ctx context.Context, claims auth.Claims, dbConn *sqlx.DB, query *sqlbuilder.SelectBuilder, args []interface{}, includedArchived bool) (CreatedAsset, error) {
-		span, ctx := tracer.StartSpanFromContext(ctx, "internal.createdasset.Find")
-		defer span.Finish()
+	query.Select(createdAssetMapColumns)
+	query.From(CreatedAssetTableName)
+	if !includedArchived {
+		query.Where(query.IsNull("archived_at"))
+	}
 
-		query.Select(createdassetsMapColumns)
-		query.From(CreatedAssetTableName)
-		if !includedArchived {
-				query.Where(query.IsNull("archived_at"))
-		}
+	// Check to see if a sub query needs to be applied for the claims
+	err := applyClaimsSelect(ctx, claims, query)
+	if err != nil {
+		return nil, err
+	}
 
-		// Check to see if a sub query needs to be applied for the claims
-		err := applyClaimsSeelect(ctx, claims, query)
-		if err != nil {
-				return nil, err
-		}
+	queryStr, queryArgs := query.Build()
+	queryStr = dbConn.Rebind(queryStr)
+	args = append(args, queryArgs...)
 
-		queryStr, queryArgs := query.Build()
-		queryStr = dbConn.Rebind(queryStr)
-		args = append(args, queryArgs...)
-		// Fetch all entries from the db.
-		rows, err := dbConn.QueryContext(ctx, queryStr, args...)
+	// Fetch all entries from the db.
+	rows, err := dbConn.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find created assets failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Iterate over each row.
+	resp := []*CreatedAsset{}
+	for rows.Next() {
+		var (
+			m   CreatedAsset
+			err error
+		)
+		err = rows.Scan(&m.ID, &m.AccountID, &m.Network, &m.AssetIndex, &m.UnitName, &m.AssetName, &m.Total, &m.Decimals,
+			&m.DefaultFrozen, &m.URL, &m.MetadataHash, &m.Description, &m.ImageURL, &m.LegalDocuments, &m.Metadata, &m.CreatorAddress, &m.ManagerAddress, &m.ReserveAddress,
+			&m.FreezeAddress, &m.ClawbackAddress, &m.TxID, &m.ConfirmedRound, &m.Status, &m.MintStatus, &m.MintError,
+			&m.LastValidRound, &m.UnsignedTxn, &m.DestroyedAt, &m.CreatedAt, &m.UpdatedAt, &m.ArchivedAt)
 		if err != nil {
-				err = errors.Wrapf(err, "query - %s", query.String())
-				err = errors.WithMessage(err, "find created assets failed")
-				return nil, err
-		}
-		defer rows.Close()
-
-
-		// Iterate over each row
-		resp := []*CreatedAsset{}
-		for rows.Next() {
-				var (
-						m CreatedAsset
-						err error
-				)
-				err = rows.Scan(&m.ID, &m.AccountID, &m.Name, &m.Status, &m.CreatedAt,&m.UpdatedAt, &m.ArchivedAt)
-				if err != nil {
-						err = errors.Wrapf(err, "query - %s", query.String())
-						return nil, err
-				}
-
-				resp = append(resp, &m)
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
 		}
 
-		err = rows.Err()
-		if err != nil {
-				err = errors.Wrapf(err, "query - %s", query.String())
-				err = errors.WithMessage(err, "find created assets failed")
-				return nil, err
-		}
+		resp = append(resp, &m)
+	}
 
-		return resp, nil
-}
+	err = rows.Err()
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find created assets failed")
+		return nil, err
+	}
 
+	return resp, nil
+}
 
-// ReadByID gets the specified created asset by ID from the database
+// ReadByID gets the specified created asset by ID from the database.
 func (repo *Repository) ReadByID(ctx context.Context, claims auth.Claims, id string) (*CreatedAsset, error) {
 	return repo.Read(ctx, claims, CreatedAssetReadRequest{
 		ID:              id,
@@ -221,329 +217,329 @@ func (repo *Repository) ReadByID(ctx context.Context, claims auth.Claims, id str
 	})
 }
 
-// Read gets the specified created assets from the database
+// Read gets the specified created asset from the database.
 func (repo *Repository) Read(ctx context.Context, claims auth.Claims, req CreatedAssetReadRequest) (*CreatedAsset, error) {
-		span, ctx := tracer.StartSpanFromContext(ctx, "internal.createdasset.Read")
-		defer span.Finish()
-
-		// Validate the request
-		v := webcontext.Validator()
-		err := v.Struct(req)
-		if err != nil {
-				return nil, err
-		}
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Read")
+	defer span.Finish()
 
-		// Filter base select query by id
-		query := sqlbuilder.NewSelectBuilder()
-		query.Where(query.Equal("id", req.ID))
-
-
-		res, err := find(ctx, claims, repo.DbConn, query, []interface{}{}, req.IncludeArchived)
-		if err != nil {
-				return nil, err
-		} else if res == nil || len(res) == 0 {
-				err = errors.WithMessagef(ErrNotFound, "created asset %s not found", req.ID)
-				return nil, err
-		}
-
-		u := res[0]
-		return u, nil
-}
-
-// We have to construct the transaction first
-// We initialized AlgodClient in the main() function
-
-// We will hard-code the mnemonic just to test this
-// implementation, however, this is not the best and most secure way
-// I am thinking of creating an asset tx, writing it to a file as unsigned
-// and a user typing in or writing it onto the file
-// then uploading it as signed, it's decoded and
-// the asset transaction is signed
-const mn = "..."
-
-// Makes a transaction on Algorand, and then reads it into the database at the same time
-func (repo *Repository) CreateAssetOnAlgorand(ctx context.Context, claims auth.Claims, req CreatedAssetCreate, now time.Time) (* CreatedAsset, error) {
-	mAlgorand := CreatedAsset{
-		ID:        uuid.NewRandom().String(),
-		AccountID: req.AccountID,
-		WalletAddress: req.WalletAddress,
-		Total:		req.Total,
-		AssetName:      req.AssetName,
-		Decimals: 	req.Decimals,
-		DefaultFrozen: req.DefaultFrozen,
-		URL:			req.URL,
-		Status:    CreatedAssetStatus_Active,
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
-	// We need to derive the mnemonic of the
-	// account in order to sign a transaction
-	fromAddrPvtKey, err := mnemonic.ToPrivateKey(mn)
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
 	if err != nil {
-			fmt.Printf("error getting suggested tx params: %s\n", err)
-			return
+		return nil, err
 	}
 
-	// However, to ensure security and
-	// trustless Asset Tx, we will write the unsigned
-	// Asset Tx to a file and the user will have to sign it
-	// offline
-
-	// Let's begin by constructing the transaction
-	txParams, err := algodClient.SuggestedParams().Do(context.Background())
-	if err != nil {
-		fmt.Printf("Error getting suggested tx params: %s\n", err)
-		return
-	}
-
-
-	//txParams = true
-	//txParams.Fee = 100
-	assetTotalIssuance := uint64(mAlgorand.Total)
-	assetDecimalsForDisplay := uint32(mAlgorand.Decimals)
-	accountsAreDefaultFrozen := mAlgorand.DefaultFrozen
-	managerAddress := mAlgorand.WalletAddress
-	assetReserveAddress := ""
-	addressWithFreezingPrivileges := mAlgorand.WalletAddress
-	addressWithClawbackPrivileges := mAlgorand.WalletAddress
-	assetName := mAlgorand.AssetName
-	assetUrl := mAlgorand.URL
-	assetMetadataHash := ""
-
-	tx, err := transaction.MakeAssetCreateTxn(mAlgorand.WalletAddress, txParams.Fee, assetTotalIssuance, assetDecimalsForDisplay,
-			accountsAreDefaultFrozen, managerAddress, assetReserveAddress, addressWithFreezingPrivileges, addressWithClawbackPrivileges,
-			assetName, assetUrl, assetMetadataHash)
-	if err != nil {
-			fmt.Printf("Error creating transaction: %\n", err)
-			return
-	}
+	// Filter base select query by id.
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("id", req.ID))
 
-	// This code signs Asset Tx using hard-coded mnemonic
-	_, bytes, err := crypto.SignTransaction(fromAddrPvtKey, tx)
+	res, err := find(ctx, claims, repo.DbConn, query, []interface{}{}, req.IncludeArchived)
 	if err != nil {
-			fmt.Printf("Failed to sign transaction: %s\n", err)
-			return
+		return nil, err
+	} else if res == nil || len(res) == 0 {
+		err = errors.WithMessagef(ErrNotFound, "created asset %s not found", req.ID)
+		return nil, err
 	}
 
+	u := res[0]
+	return u, nil
+}
 
-	// Let's finally broadcast the Asset Tx to the network
-	txHeaders := append([]*algod.Header{}, &algod.Header{"Content-Type", "application/x-binary"})
-	sendResponse, err := algodClient.SendRawTransaction(bytes, txHeaders...)
-	if err != nil {
-			fmt.Printf("failed to send transaction: %s\n", err)
-			return
-	}
+// Create inserts a new created asset into the database.
+func (repo *Repository) Create(ctx context.Context, claims auth.Claims, req CreatedAssetCreateRequest, now time.Time) (*CreatedAsset, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Create")
+	defer span.Finish()
 
-	fmt.Printf("Transaction successful with ID: %s\n", sendResponse.TxID)
+	if claims.Audience != "" {
+		// Users that can draft assets can create them for the account they have access to.
+		if !claims.HasPermission(auth.PermissionAssetDraft) {
+			return nil, errors.WithStack(ErrForbidden)
+		}
 
-	/*
-	unsignedTx := types.SignedTxn{
-		Txn: tx,
+		if req.AccountID != "" {
+			// Request accountId must match claims.
+			if req.AccountID != claims.Audience {
+				return nil, errors.WithStack(ErrForbidden)
+			}
+		} else {
+			// Set the accountId from claims.
+			req.AccountID = claims.Audience
+		}
 	}
 
-	// Let's save the unsigned transaction to file
-	err = ioutil.WriteFile("./unsigned.txn", msgpack.Encode(unsignedTx), 0644)
-	if err == nil {
-		fmt.Printf("Saved unsigned asset transaction to file\n")
-		return
+	// Default to the network currently selected for the application.
+	if req.Network == "" && repo.AlgoClient != nil {
+		network, err := repo.AlgoClient.Current()
+		if err != nil {
+			return nil, err
+		}
+		req.Network = network.Name
 	}
-	fmt.Printf("Failed in saving asset tx to file, error %s\n", err)
-
-	/* Let's sign the transaction
-	_, bytes, err := crypto.SignTransaction()*/
-}
 
-/*
-func (repo *Repository) SignAssetTx(ctx context.Context, claims auth.Claims, req CreateAssetOnAlgorand, now time.Time) (*CreatedAsset, error) {
-	// First, let's read the unsigned asset transaction from file
-	/*dat, err := ioutil.ReadFile("./unsigned.txn")
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
 	if err != nil {
-		fmt.Printf("Error reading transaction from file: %s\n", err)
-		return
+		return nil, err
 	}
 
-	var unsignedTxRaw types.SignedTxn
-	var unsignedTxn types.transaction // Transaction
-	msgpack.Decode(dat, &unsignedTxRaw)
-	unsignedTxn = unsignedTxRaw.Txn
-
-	// Let's now sign the transaction for the asset creation process
-	// The next code should read the mnemonic passphrase that was entered offline and together with the wallet address
-	// It should then sign the Asset Tx using those credentials and destroy the file forever
-
-	// Let's derive privkey from hard-coded mnemonic
-	
-}*/
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
 
+	// Always store the time as UTC.
+	now = now.UTC()
 
-// Create inserts a new created asset into the database
-func (repo *Repository) Create(ctx context.Context, claims auth.Claims, req CreatedAssetCreateRequest, now time.Time) (*CreatedAsset, error) {
-		span, ctx := tracer.StartSpanFromContext(ctx, "internal.createdasset.Create")
-		defer span.Finish()
-		if claims.Audience != "" {
-			// Admin users can update created assets they have access to
-			if !claims.HasRole(auth.RoleAdmin) {
-					return nil, errors.WithStack(ErrForbidden)
-			}
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
 
-			if req.AccountID != "" {
-				// Request accountId must match claims
-				if req.AccountID != claims.Audience {
-						return nil, errors.WithStack(ErrForbidden)
-				}
-		
-			} else {
-					// Set the accountId from claims
-					req.AccountID = claims.Audience
-			}
-		}
+	m := CreatedAsset{
+		ID:              uuid.NewRandom().String(),
+		AccountID:       req.AccountID,
+		Network:         req.Network,
+		UnitName:        req.UnitName,
+		AssetName:       req.AssetName,
+		Total:           req.Total,
+		Decimals:        req.Decimals,
+		DefaultFrozen:   req.DefaultFrozen,
+		URL:             req.URL,
+		Description:     req.Description,
+		ImageURL:        req.ImageURL,
+		LegalDocuments:  req.LegalDocuments,
+		CreatorAddress:  req.CreatorAddress,
+		ManagerAddress:  req.ManagerAddress,
+		ReserveAddress:  req.ReserveAddress,
+		FreezeAddress:   req.FreezeAddress,
+		ClawbackAddress: req.ClawbackAddress,
+		Status:          CreatedAssetStatus_Active,
+		MintStatus:      CreatedAssetMintStatus_Draft,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
 
-		// Validate the request
-		v := webcontext.Validator()
-		err := v.Struct(req)
+	if req.MetadataHash != "" {
+		m.MetadataHash, err = hex.DecodeString(req.MetadataHash)
 		if err != nil {
-				return nil, err
-		}
-
-		// If now empty set it to the current time
-		if now.IsZero() {
-				now = time.Now()
+			return nil, errors.Wrap(err, "decode metadata hash failed")
 		}
-
-		// Always store the time as UTC
-		now = now.UTC()
-		// Postgres truncates times to milliseconds when storing. We and do the same
-	// here so the value we return is consistent with what we store.
-	now = now.Truncate(time.Millisecond)
-	m := CreatedAsset{
-		ID:        uuid.NewRandom().String(),
-		AccountID: req.AccountID,
-		WalletAddress: req.WalletAddress,
-		Total:		req.Total,
-		AssetName:      req.AssetName,
-		Decimals: 	req.Decimals,
-		DefaultFrozen: req.DefaultFrozen,
-		URL:			req.URL,
-		Status:    CreatedAssetStatus_Active,
-		CreatedAt: now,
-		UpdatedAt: now,
 	}
 
 	if req.Status != nil {
-			m.Status = *req.Status
+		m.Status = *req.Status
 	}
 
 	// Build the insert SQL statement.
 	query := sqlbuilder.NewInsertBuilder()
 	query.InsertInto(CreatedAssetTableName)
-	query.Cols(
-		"id",
-		"account_id",
-		"algorand_wallet_address",
-		"total_assetIssuance",
-		"assetName",
-		"assetDecimalsDenomination",
-		"defaultAssetsFrozen",
-		"assetUrl",
-		"status",
-		"created_at",
-		"updated_at",
-		"archived_at",
-	)
-
-	query.Values(
-		m.ID,
-		m.AccountID,
-		m.WalletAddress,
-		m.Total,
-		m.AssetName,
-		m.Decimals,
-		m.DefaultFrozen,
-		m.URL,
-		m.Status,
-		m.CreatedAt,
-		m.UpdatedAt,
-		m.ArchivedAt,
-	)
-
-	// Execute the query with the provided context
+	query.Cols("id", "account_id", "network", "asset_index", "unit_name", "asset_name", "total", "decimals", "default_frozen", "url",
+		"metadata_hash", "description", "image_url", "legal_documents", "creator_address", "manager_address", "reserve_address",
+		"freeze_address", "clawback_address", "tx_id", "confirmed_round", "status", "mint_status", "mint_error", "last_valid_round",
+		"created_at", "updated_at")
+	query.Values(m.ID, m.AccountID, m.Network, m.AssetIndex, m.UnitName, m.AssetName, m.Total, m.Decimals, m.DefaultFrozen, m.URL,
+		m.MetadataHash, m.Description, m.ImageURL, m.LegalDocuments, m.CreatorAddress, m.ManagerAddress, m.ReserveAddress, m.FreezeAddress, m.ClawbackAddress, m.TxID,
+		m.ConfirmedRound, m.Status.String(), m.MintStatus.String(), m.MintError, m.LastValidRound, m.CreatedAt, m.UpdatedAt)
+
+	// Execute the query with the provided context.
 	sql, args := query.Build()
 	sql = repo.DbConn.Rebind(sql)
 	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
 	if err != nil {
-			err = errors.Wrapf(err, "query - %s", query.String())
-			err = errors.WithMessage(err, "create asset failed")
-			return nil, err
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "create asset failed")
+		return nil, err
 	}
 
 	return &m, nil
 }
 
-/* TO:DO a function that gets a created asset already issued in the database,
-and then records it onto the algorand blockchain */
-/* we could import the asset creation function already created in the purestake library */
+// Update replaces a created asset in the database.
+func (repo *Repository) Update(ctx context.Context, claims auth.Claims, req CreatedAssetUpdateRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Update")
+	defer span.Finish()
 
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
+	}
 
-// Update replaces a created asset in the database
-func (repo *Repository) Update(ctx context.Context, claims auth.Claims, req CreatedAssetUpdateRequest, now time.Time) error {
-		span, ctx := tracer.StartSpanFromContext(ctx, "internal.createdasset.Update")
-		defer span.Finish()
+	// Ensure the claims can modify the created asset specified in the request.
+	err = repo.CanModifyCreatedAsset(ctx, claims, req.ID)
+	if err != nil {
+		return err
+	}
 
-		// Validate the request.
-		v := webcontext.Validator()
-		err := v.Struct(req)
+	// The roles of the asset and the details published in its metadata are fixed once it's
+	// submitted, the roles of a minted asset are changed on chain with Reconfigure.
+	unminted := req.ManagerAddress != nil || req.ReserveAddress != nil || req.FreezeAddress != nil || req.ClawbackAddress != nil ||
+		req.Description != nil || req.ImageURL != nil || req.LegalDocuments != nil
+	if unminted {
+		m, err := repo.ReadByID(ctx, claims, req.ID)
 		if err != nil {
-				return err
+			return err
 		}
 
-		// Ensure the claims can modify the created asset specified in the request.
-		err = repo.CanModifyCreatedAsset((ctx, claims, req.ID))
-		if err != nil {
-				return err
+		if m.MintStatus != CreatedAssetMintStatus_Draft && m.MintStatus != CreatedAssetMintStatus_Failed {
+			return errors.WithMessagef(ErrInvalidMintStatus, "roles and metadata of created asset %s can't be updated once %s", m.ID, m.MintStatus)
 		}
+	}
 
-		// If now empty set it to the current time
-		if now.IsZero() {
-				now = time.Now()
-		}
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
 
-		// Always store the time as UTC
-		now = now.UTC()
-		// Postgres truncates times to milliseconds when storing. We and do the same
-		// here so the value we return is consistent with what we store.
-		now = now.Truncate(time.Millisecond)
-		// Build the update SQL statement.
-		query := sqlbuilder.NewUpdateBuilder()
-		query.Update(createdCreatedAssetTableName)
-		var fields []string
-		if req.Name != nil {
-			fields = append(fields, query.Assign("Asset Name", req.AssetName))
-		}
+	// Always store the time as UTC.
+	now = now.UTC()
 
-		if req.Status != nil {
-			fields = append(fields, query.Assign("status", req.Status))
-		}
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
 
-		// If there's nothing to update we can quit early.
-		if len(fields) == 0 {
-			return nil
-		}
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(CreatedAssetTableName)
+
+	var fields []string
+	if req.ManagerAddress != nil {
+		fields = append(fields, query.Assign("manager_address", *req.ManagerAddress))
+	}
+	if req.ReserveAddress != nil {
+		fields = append(fields, query.Assign("reserve_address", *req.ReserveAddress))
+	}
+	if req.FreezeAddress != nil {
+		fields = append(fields, query.Assign("freeze_address", *req.FreezeAddress))
+	}
+	if req.ClawbackAddress != nil {
+		fields = append(fields, query.Assign("clawback_address", *req.ClawbackAddress))
+	}
+	if req.Description != nil {
+		fields = append(fields, query.Assign("description", *req.Description))
+	}
+	if req.ImageURL != nil {
+		fields = append(fields, query.Assign("image_url", *req.ImageURL))
+	}
+	if req.LegalDocuments != nil {
+		fields = append(fields, query.Assign("legal_documents", CreatedAssetDocuments(*req.LegalDocuments)))
+	}
+	if req.Status != nil {
+		fields = append(fields, query.Assign("status", req.Status))
+	}
+
+	// If there's nothing to update we can quit early.
+	if len(fields) == 0 {
+		return nil
+	}
 
-		// Append the updated_at field
-		fields = append(fields, query.Assign("updated_at", now))
-		query.Set(fields...)
+	// Append the updated_at field
+	fields = append(fields, query.Assign("updated_at", now))
+
+	query.Set(fields...)
+	if unminted {
+		// Guard against the asset being submitted since it was read.
+		query.Where(query.And(
+			query.Equal("id", req.ID),
+			query.In("mint_status", CreatedAssetMintStatus_Draft.String(), CreatedAssetMintStatus_Failed.String()),
+		))
+	} else {
 		query.Where(query.Equal("id", req.ID))
-		// Execute the query with the provided context.
-		sql, args := query.Build()
-		sql = repo.DbConn.Rebind(sql)
-		_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	}
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	res, err := repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "update created asset %s failed", req.ID)
+		return err
+	}
+
+	if unminted {
+		n, err := res.RowsAffected()
 		if err != nil {
-			err = errors.Wrapf(err, "query - %s", query.String())
-			err = errors.WithMessagef(err, "update created asset %s failed", req.ID)
-			return err
+			return errors.WithStack(err)
+		} else if n == 0 {
+			return errors.WithMessagef(ErrInvalidMintStatus, "created asset %s was submitted while being updated", req.ID)
 		}
+	}
 
-		return nil
+	return nil
+}
+
+// Archive soft deleted the created asset from the database.
+func (repo *Repository) Archive(ctx context.Context, claims auth.Claims, req CreatedAssetArchiveRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Archive")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
 	}
 
-}
\ No newline at end of file
+	// Ensure the claims can modify the created asset specified in the request.
+	err = repo.CanModifyCreatedAsset(ctx, claims, req.ID, auth.PermissionAssetManage)
+	if err != nil {
+		return err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(CreatedAssetTableName)
+	query.Set(
+		query.Assign("archived_at", now),
+	)
+	query.Where(query.Equal("id", req.ID))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "archive created asset %s failed", req.ID)
+		return err
+	}
+
+	return nil
+}
+
+// MockCreatedAsset returns a fake CreatedAsset for testing.
+func MockCreatedAsset(ctx context.Context, dbConn *sqlx.DB, accountID string, now time.Time) (*CreatedAsset, error) {
+	repo := &Repository{
+		DbConn: dbConn,
+	}
+
+	addr := crypto.GenerateAccount().Address.String()
+
+	req := CreatedAssetCreateRequest{
+		AccountID:       accountID,
+		Network:         "sandbox",
+		UnitName:        "MOCK",
+		AssetName:       "Mock " + uuid.NewRandom().String()[0:8],
+		Total:           1000000,
+		CreatorAddress:  addr,
+		ManagerAddress:  addr,
+		ReserveAddress:  addr,
+		FreezeAddress:   addr,
+		ClawbackAddress: addr,
+	}
+	return repo.Create(ctx, auth.Claims{}, req, now)
+}
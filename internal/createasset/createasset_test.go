@@ -1,14 +1,39 @@
-/* Note: Changed from Createasset to createasset */
 package createasset
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"exitor-dapp/internal/account"
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/algosdk/algodtest"
 	"exitor-dapp/internal/platform/auth"
 	"exitor-dapp/internal/platform/tests"
+	"exitor-dapp/internal/user"
+	"exitor-dapp/internal/user_kyc"
+	"exitor-dapp/internal/wallet"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/google/go-cmp/cmp"
 	"github.com/huandu/go-sqlbuilder"
+	"github.com/lib/pq"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
 )
 
 var (
@@ -25,7 +50,7 @@ func testMain(m *testing.M) int {
 	test = tests.New()
 	defer test.TearDown()
 
-	repo = NewRepository(test.MasterDB)
+	repo = NewRepository(test.MasterDB, nil, nil, "")
 
 	return m.Run()
 }
@@ -38,7 +63,7 @@ func TestFindRequestQuery(t *testing.T) {
 		offset uint = 34
 	)
 
-	req := CreateassetFindRequest{
+	req := CreatedAssetFindRequest{
 		Where: "field1 = ? or field2 = ?",
 		Args: []interface{}{
 			"lee brown",
@@ -54,7 +79,7 @@ func TestFindRequestQuery(t *testing.T) {
 		Offset: &offset,
 	}
 
-	expected := "SELECT " + CreateassetMapColumns + " FROM " + CreateassetTableName + " WHERE (field1 = ? or field2 = ?) ORDER BY id asc, created_at desc LIMIT 12 OFFSET 34"
+	expected := "SELECT " + createdAssetMapColumns + " FROM " + CreatedAssetTableName + " WHERE (field1 = ? or field2 = ?) ORDER BY id asc, created_at desc LIMIT 12 OFFSET 34"
 	res, args := findRequestQuery(req)
 	if diff := cmp.Diff(res.String(), expected); diff != "" {
 		t.Fatalf("\t%s\tExpected result query to match. Diff:\n%s", tests.Failed, diff)
@@ -73,7 +98,25 @@ func TestApplyClaimsSelect(t *testing.T) {
 		claims      auth.Claims
 		expectedSql string
 		error       error
-	}{}
+	}{
+		{"EmptyClaims",
+			auth.Claims{},
+			"SELECT " + createdAssetMapColumns + " FROM " + CreatedAssetTableName,
+			nil,
+		},
+		{"RoleAccount",
+			auth.Claims{
+				Roles: []string{auth.RoleAdmin},
+				StandardClaims: jwt.StandardClaims{
+					Subject:  "user1",
+					Audience: "acc1",
+				},
+			},
+			"SELECT " + createdAssetMapColumns + " FROM " + CreatedAssetTableName + " WHERE account_id = 'acc1'",
+			nil,
+		},
+	}
+
 	t.Log("Given the need to validate ACLs are enforced by claims to a select query.")
 	{
 		for i, tt := range claimTests {
@@ -105,3 +148,3406 @@ func TestApplyClaimsSelect(t *testing.T) {
 		}
 	}
 }
+
+// TestCrud validates every column of a created asset is persisted by Create and
+// Update and returned by Read and Find.
+func TestCrud(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.January, 25, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	creator := crypto.GenerateAccount().Address.String()
+	manager := crypto.GenerateAccount().Address.String()
+
+	var crudTests = []struct {
+		name      string
+		claims    auth.Claims
+		createErr error
+		updateErr error
+	}{
+		{"EmptyClaims", auth.Claims{}, nil, nil},
+		{"RoleAdminSameAccount",
+			auth.Claims{
+				Roles: []string{auth.RoleAdmin},
+				StandardClaims: jwt.StandardClaims{
+					Audience: acc.ID,
+					Subject:  uuid.NewRandom().String(),
+				},
+			},
+			nil, nil,
+		},
+		{"RoleUserSameAccount",
+			auth.Claims{
+				Roles: []string{auth.RoleUser},
+				StandardClaims: jwt.StandardClaims{
+					Audience: acc.ID,
+					Subject:  uuid.NewRandom().String(),
+				},
+			},
+			ErrForbidden, ErrForbidden,
+		},
+	}
+
+	t.Log("Given the need to ensure claims are applied as ACL for create, read and update created assets.")
+	{
+		for i, tt := range crudTests {
+			t.Logf("\tTest: %d\tWhen running test: %s", i, tt.name)
+			{
+				req := CreatedAssetCreateRequest{
+					AccountID:       acc.ID,
+					Network:         "sandbox",
+					UnitName:        "KJL",
+					AssetName:       "Kwa Jeff Limited",
+					Total:           1000000,
+					Decimals:        2,
+					DefaultFrozen:   true,
+					URL:             "https://exitor.io",
+					MetadataHash:    "c0535e4be2b79ffd93291305436bf889314e4a3faec05ecffcbb7df31ad9e51a",
+					CreatorAddress:  creator,
+					ManagerAddress:  creator,
+					ReserveAddress:  creator,
+					FreezeAddress:   creator,
+					ClawbackAddress: creator,
+				}
+
+				created, err := repo.Create(ctx, tt.claims, req, now)
+				if err != nil && errors.Cause(err) != tt.createErr {
+					t.Logf("\t\tGot : %+v", err)
+					t.Logf("\t\tWant: %+v", tt.createErr)
+					t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+				} else if tt.createErr != nil {
+					// Create with mock so update can be validated against an existing record.
+					created, err = MockCreatedAsset(ctx, test.MasterDB, acc.ID, now)
+					if err != nil {
+						t.Log("\t\tGot :", err)
+						t.Fatalf("\t%s\tMockCreatedAsset failed.", tests.Failed)
+					}
+				}
+				t.Logf("\t%s\tCreate ok.", tests.Success)
+
+				// Read the created asset back without claims and ensure every column was stored.
+				read, err := repo.ReadByID(ctx, auth.Claims{}, created.ID)
+				if err != nil {
+					t.Log("\t\tGot :", err)
+					t.Fatalf("\t%s\tReadByID failed.", tests.Failed)
+				} else if diff := cmp.Diff(read, created); diff != "" {
+					t.Fatalf("\t%s\tExpected read to match created. Diff:\n%s", tests.Failed, diff)
+				}
+				t.Logf("\t%s\tReadByID ok.", tests.Success)
+
+				status := CreatedAssetStatus_Disabled
+				updateReq := CreatedAssetUpdateRequest{
+					ID:             created.ID,
+					ManagerAddress: &manager,
+					Status:         &status,
+				}
+
+				updatedTime := now.Add(time.Hour)
+				err = repo.Update(ctx, tt.claims, updateReq, updatedTime)
+				if err != nil && errors.Cause(err) != tt.updateErr {
+					t.Logf("\t\tGot : %+v", err)
+					t.Logf("\t\tWant: %+v", tt.updateErr)
+					t.Fatalf("\t%s\tUpdate failed.", tests.Failed)
+				} else if tt.updateErr != nil {
+					t.Logf("\t%s\tUpdate forbidden ok.", tests.Success)
+					continue
+				}
+				t.Logf("\t%s\tUpdate ok.", tests.Success)
+
+				expected := *read
+				expected.ManagerAddress = manager
+				expected.Status = status
+				expected.UpdatedAt = updatedTime
+
+				res, err := repo.Find(ctx, tt.claims, CreatedAssetFindRequest{
+					Where: "id = ?",
+					Args:  []interface{}{created.ID},
+				})
+				if err != nil {
+					t.Log("\t\tGot :", err)
+					t.Fatalf("\t%s\tFind failed.", tests.Failed)
+				} else if len(res) != 1 {
+					t.Log("\t\tGot :", len(res))
+					t.Log("\t\tWant:", 1)
+					t.Fatalf("\t%s\tFind should return the updated asset.", tests.Failed)
+				} else if diff := cmp.Diff(res[0], &expected); diff != "" {
+					t.Fatalf("\t%s\tExpected find result to match. Diff:\n%s", tests.Failed, diff)
+				}
+				t.Logf("\t%s\tFind ok.", tests.Success)
+
+				err = repo.Archive(ctx, tt.claims, CreatedAssetArchiveRequest{ID: created.ID}, now)
+				if err != nil {
+					t.Log("\t\tGot :", err)
+					t.Fatalf("\t%s\tArchive failed.", tests.Failed)
+				}
+
+				_, err = repo.ReadByID(ctx, tt.claims, created.ID)
+				if errors.Cause(err) != ErrNotFound {
+					t.Logf("\t\tGot : %+v", err)
+					t.Logf("\t\tWant: %+v", ErrNotFound)
+					t.Fatalf("\t%s\tArchived asset should not be found.", tests.Failed)
+				}
+				t.Logf("\t%s\tArchive ok.", tests.Success)
+			}
+		}
+	}
+}
+
+// TestUpdateSubmitted validates the roles and metadata of a created asset can't be
+// updated once it's submitted while the status still can.
+func TestUpdateSubmitted(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.January, 26, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	updateRepo := NewRepository(test.MasterDB, algoClient, signer, "")
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	creator := signer.Generate().Address.String()
+	created, err := updateRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:      acc.ID,
+		Network:        "sandbox",
+		UnitName:       "UPD",
+		AssetName:      "Update " + uuid.NewRandom().String()[0:8],
+		Total:          1000000,
+		CreatorAddress: creator,
+		ManagerAddress: creator,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	_, err = updateRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMint failed.", tests.Failed)
+	}
+
+	manager := crypto.GenerateAccount().Address.String()
+	description := "Updated after submit."
+
+	t.Log("Given the need to keep a submitted asset matching its create transaction.")
+	{
+		for i, req := range []CreatedAssetUpdateRequest{
+			{ID: created.ID, ManagerAddress: &manager},
+			{ID: created.ID, ReserveAddress: &manager},
+			{ID: created.ID, FreezeAddress: &manager},
+			{ID: created.ID, ClawbackAddress: &manager},
+			{ID: created.ID, Description: &description},
+		} {
+			err = updateRepo.Update(ctx, auth.Claims{}, req, now)
+			if errors.Cause(err) != ErrInvalidMintStatus {
+				t.Logf("\t\tGot : %+v", err)
+				t.Logf("\t\tWant: %+v", ErrInvalidMintStatus)
+				t.Fatalf("\t%s\tUpdate %d of submitted asset should be rejected.", tests.Failed, i)
+			}
+		}
+		t.Logf("\t%s\tUpdate roles and metadata rejected ok.", tests.Success)
+
+		status := CreatedAssetStatus_Disabled
+		err = updateRepo.Update(ctx, auth.Claims{}, CreatedAssetUpdateRequest{ID: created.ID, Status: &status}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tUpdate status failed.", tests.Failed)
+		}
+
+		read, err := updateRepo.ReadByID(ctx, auth.Claims{}, created.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReadByID failed.", tests.Failed)
+		} else if read.ManagerAddress != creator || read.Status != status {
+			t.Logf("\t\tGot : %s %s", read.ManagerAddress, read.Status)
+			t.Logf("\t\tWant: %s %s", creator, status)
+			t.Fatalf("\t%s\tOnly the status should be updated.", tests.Failed)
+		}
+		t.Logf("\t%s\tUpdate status ok.", tests.Success)
+	}
+}
+
+// TestMint validates a created asset moves from draft to submitted when minted and
+// is confirmed or failed by Reconcile, and that a failed asset can be minted again.
+func TestMint(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.February, 1, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	mintRepo := NewRepository(test.MasterDB, algoClient, signer, "")
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	// mockSignableAsset creates a draft asset with a creator the signer holds the key for.
+	mockSignableAsset := func() (*CreatedAsset, error) {
+		creator := signer.Generate().Address.String()
+		return mintRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+			AccountID:      acc.ID,
+			Network:        "sandbox",
+			UnitName:       "MINT",
+			AssetName:      "Mint " + uuid.NewRandom().String()[0:8],
+			Total:          1000000,
+			CreatorAddress: creator,
+			ManagerAddress: creator,
+		}, now)
+	}
+
+	t.Log("Given the need to mint created assets on Algorand.")
+	{
+		created, err := mockSignableAsset()
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+		} else if created.MintStatus != CreatedAssetMintStatus_Draft {
+			t.Logf("\t\tGot : %s", created.MintStatus)
+			t.Logf("\t\tWant: %s", CreatedAssetMintStatus_Draft)
+			t.Fatalf("\t%s\tCreated asset should be a draft.", tests.Failed)
+		}
+
+		t.Log("\tWhen the asset create transaction is confirmed.")
+		{
+			minted, err := mintRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tMint failed.", tests.Failed)
+			} else if minted.MintStatus != CreatedAssetMintStatus_Submitted || minted.TxID == "" {
+				t.Logf("\t\tGot : %s %s", minted.MintStatus, minted.TxID)
+				t.Fatalf("\t%s\tMinted asset should be submitted with the transaction ID.", tests.Failed)
+			}
+			t.Logf("\t%s\tMint ok.", tests.Success)
+
+			_, err = mintRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+			if errors.Cause(err) != ErrInvalidMintStatus {
+				t.Logf("\t\tGot : %+v", err)
+				t.Logf("\t\tWant: %+v", ErrInvalidMintStatus)
+				t.Fatalf("\t%s\tSubmitted asset should not be minted again.", tests.Failed)
+			}
+			t.Logf("\t%s\tMint again rejected ok.", tests.Success)
+
+			srv.Advance(1)
+
+			err = mintRepo.ReconcileSubmitted(ctx, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+			}
+
+			confirmed, err := mintRepo.ReadByID(ctx, auth.Claims{}, created.ID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReadByID failed.", tests.Failed)
+			}
+
+			p, _ := srv.Pending(minted.TxID)
+			expected := *minted
+			expected.MintStatus = CreatedAssetMintStatus_Confirmed
+			expected.AssetIndex = p.AssetIndex
+			expected.ConfirmedRound = p.ConfirmedRound
+			if diff := cmp.Diff(confirmed, &expected); diff != "" {
+				t.Fatalf("\t%s\tExpected confirmed asset to match. Diff:\n%s", tests.Failed, diff)
+			} else if confirmed.AssetIndex == 0 {
+				t.Fatalf("\t%s\tConfirmed asset should have an asset index.", tests.Failed)
+			}
+			t.Logf("\t%s\tReconcile confirmed ok.", tests.Success)
+		}
+
+		t.Log("\tWhen the asset create transaction is rejected and then retried.")
+		{
+			created, err := mockSignableAsset()
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+			}
+
+			srv.Reject = func(stx types.SignedTxn) string {
+				return "overspend"
+			}
+
+			minted, err := mintRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tMint failed.", tests.Failed)
+			}
+
+			failed, err := mintRepo.Reconcile(ctx, auth.Claims{}, created.ID, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReconcile failed.", tests.Failed)
+			} else if failed.MintStatus != CreatedAssetMintStatus_Failed || failed.MintError == "" || failed.AssetIndex != 0 {
+				t.Logf("\t\tGot : %s %s", failed.MintStatus, failed.MintError)
+				t.Fatalf("\t%s\tRejected asset should be failed with an error.", tests.Failed)
+			}
+			t.Logf("\t%s\tReconcile failed ok.", tests.Success)
+
+			srv.Reject = nil
+			srv.Advance(1)
+
+			retried, err := mintRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tMint retry failed.", tests.Failed)
+			} else if retried.MintStatus != CreatedAssetMintStatus_Submitted || retried.MintError != "" || retried.TxID == minted.TxID {
+				t.Logf("\t\tGot : %s %s %s", retried.MintStatus, retried.MintError, retried.TxID)
+				t.Fatalf("\t%s\tRetried asset should be submitted with a new transaction.", tests.Failed)
+			}
+
+			srv.Advance(1)
+
+			confirmed, err := mintRepo.Reconcile(ctx, auth.Claims{}, created.ID, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReconcile failed.", tests.Failed)
+			} else if confirmed.MintStatus != CreatedAssetMintStatus_Confirmed || confirmed.AssetIndex == 0 {
+				t.Logf("\t\tGot : %s %d", confirmed.MintStatus, confirmed.AssetIndex)
+				t.Fatalf("\t%s\tRetried asset should be confirmed.", tests.Failed)
+			}
+			t.Logf("\t%s\tMint retry ok.", tests.Success)
+		}
+
+		t.Log("\tWhen the asset create transaction expires.")
+		{
+			created, err := mockSignableAsset()
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+			}
+
+			// Never confirm the transaction so it's left pending until the last valid round.
+			srv.ConfirmAfter = 1 << 32
+			defer func() { srv.ConfirmAfter = 1 }()
+
+			minted, err := mintRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tMint failed.", tests.Failed)
+			}
+
+			pending, err := mintRepo.Reconcile(ctx, auth.Claims{}, created.ID, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReconcile failed.", tests.Failed)
+			} else if pending.MintStatus != CreatedAssetMintStatus_Submitted {
+				t.Logf("\t\tGot : %s", pending.MintStatus)
+				t.Fatalf("\t%s\tPending asset should remain submitted.", tests.Failed)
+			}
+
+			srv.Advance(minted.LastValidRound - srv.Round() + 1)
+
+			expired, err := mintRepo.Reconcile(ctx, auth.Claims{}, created.ID, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReconcile failed.", tests.Failed)
+			} else if expired.MintStatus != CreatedAssetMintStatus_Failed || expired.MintError == "" {
+				t.Logf("\t\tGot : %s %s", expired.MintStatus, expired.MintError)
+				t.Fatalf("\t%s\tExpired asset should be failed.", tests.Failed)
+			}
+			t.Logf("\t%s\tReconcile expired ok.", tests.Success)
+		}
+	}
+}
+
+// TestReconcileForgotten validates a created asset is only failed once its transaction
+// provably expired when the node no longer knows about the transaction or the response
+// to its submission was lost.
+func TestReconcileForgotten(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.February, 2, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	mintRepo := NewRepository(test.MasterDB, algoClient, signer, "")
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	// mintAsset creates a draft asset with a creator the signer holds the key for and mints it.
+	mintAsset := func() *CreatedAsset {
+		creator := signer.Generate().Address.String()
+		created, err := mintRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+			AccountID:      acc.ID,
+			Network:        "sandbox",
+			UnitName:       "LOST",
+			AssetName:      "Forgotten " + uuid.NewRandom().String()[0:8],
+			Total:          1000000,
+			CreatorAddress: creator,
+			ManagerAddress: creator,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+		}
+
+		minted, err := mintRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tMint failed.", tests.Failed)
+		}
+		return minted
+	}
+
+	t.Log("Given the need to confirm a minted asset after its transaction left the pool.")
+	{
+		minted := mintAsset()
+
+		srv.Advance(1)
+		srv.Forget(minted.TxID)
+
+		confirmed, err := mintRepo.Reconcile(ctx, auth.Claims{}, minted.ID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcile failed.", tests.Failed)
+		}
+
+		p, _ := srv.Pending(minted.TxID)
+		if confirmed.MintStatus != CreatedAssetMintStatus_Confirmed || confirmed.AssetIndex != p.AssetIndex {
+			t.Logf("\t\tGot : %s %d", confirmed.MintStatus, confirmed.AssetIndex)
+			t.Logf("\t\tWant: %s %d", CreatedAssetMintStatus_Confirmed, p.AssetIndex)
+			t.Fatalf("\t%s\tForgotten asset should be confirmed from the indexer.", tests.Failed)
+		}
+		t.Logf("\t%s\tReconcile forgotten confirmed ok.", tests.Success)
+	}
+
+	t.Log("Given the need to confirm a minted asset when the response to its submission was lost.")
+	{
+		creator := signer.Generate().Address.String()
+		created, err := mintRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+			AccountID:      acc.ID,
+			Network:        "sandbox",
+			UnitName:       "LOST",
+			AssetName:      "Lost response " + uuid.NewRandom().String()[0:8],
+			Total:          1000000,
+			CreatorAddress: creator,
+			ManagerAddress: creator,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+		}
+
+		srv.Drop = func(stx types.SignedTxn) bool { return true }
+		_, err = mintRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+		srv.Drop = nil
+		if err == nil {
+			t.Fatalf("\t%s\tMint should fail when the response is lost.", tests.Failed)
+		}
+
+		submitted, err := mintRepo.ReadByID(ctx, auth.Claims{}, created.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReadByID failed.", tests.Failed)
+		} else if submitted.MintStatus != CreatedAssetMintStatus_Submitted || submitted.TxID == "" {
+			t.Logf("\t\tGot : %s %s", submitted.MintStatus, submitted.TxID)
+			t.Fatalf("\t%s\tAsset should remain submitted when the send outcome is unknown.", tests.Failed)
+		}
+
+		_, err = mintRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+		if errors.Cause(err) != ErrInvalidMintStatus {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrInvalidMintStatus)
+			t.Fatalf("\t%s\tAsset with an unknown send outcome should not be minted again.", tests.Failed)
+		}
+		t.Logf("\t%s\tMint lost response submitted ok.", tests.Success)
+
+		srv.Advance(1)
+
+		confirmed, err := mintRepo.Reconcile(ctx, auth.Claims{}, created.ID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcile failed.", tests.Failed)
+		}
+
+		p, _ := srv.Pending(submitted.TxID)
+		if confirmed.MintStatus != CreatedAssetMintStatus_Confirmed || confirmed.AssetIndex != p.AssetIndex || confirmed.AssetIndex == 0 {
+			t.Logf("\t\tGot : %s %d", confirmed.MintStatus, confirmed.AssetIndex)
+			t.Logf("\t\tWant: %s %d", CreatedAssetMintStatus_Confirmed, p.AssetIndex)
+			t.Fatalf("\t%s\tAsset should be confirmed once its transaction is.", tests.Failed)
+		}
+		t.Logf("\t%s\tReconcile lost response confirmed ok.", tests.Success)
+	}
+
+	t.Log("Given the need to fail a minted asset whose transaction was dropped.")
+	{
+		srv.ConfirmAfter = 1 << 32
+		defer func() { srv.ConfirmAfter = 1 }()
+
+		minted := mintAsset()
+		srv.Forget(minted.TxID)
+
+		pending, err := mintRepo.Reconcile(ctx, auth.Claims{}, minted.ID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcile failed.", tests.Failed)
+		} else if pending.MintStatus != CreatedAssetMintStatus_Submitted {
+			t.Logf("\t\tGot : %s", pending.MintStatus)
+			t.Fatalf("\t%s\tDropped asset should remain submitted until the last valid round.", tests.Failed)
+		}
+		t.Logf("\t%s\tReconcile dropped pending ok.", tests.Success)
+
+		srv.Advance(minted.LastValidRound - srv.Round() + 1)
+
+		err = mintRepo.ReconcileSubmitted(ctx, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+		}
+
+		expired, err := mintRepo.ReadByID(ctx, auth.Claims{}, minted.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReadByID failed.", tests.Failed)
+		} else if expired.MintStatus != CreatedAssetMintStatus_Failed || expired.MintError == "" {
+			t.Logf("\t\tGot : %s %s", expired.MintStatus, expired.MintError)
+			t.Fatalf("\t%s\tDropped asset should be failed once expired.", tests.Failed)
+		}
+		t.Logf("\t%s\tReconcile dropped expired ok.", tests.Success)
+	}
+}
+
+// TestOfflineSigning validates the unsigned asset create transaction can be downloaded
+// and only the same transaction signed by its sender is accepted for submission.
+func TestOfflineSigning(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.February, 8, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+	offlineRepo := NewRepository(test.MasterDB, algoClient, algosdk.NewExternalSigner(), "")
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	creator := crypto.GenerateAccount()
+
+	created, err := offlineRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:      acc.ID,
+		Network:        "sandbox",
+		UnitName:       "OFF",
+		AssetName:      "Offline Signed",
+		Total:          1000,
+		CreatorAddress: creator.Address.String(),
+		ManagerAddress: creator.Address.String(),
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	t.Log("Given the need to sign the asset create transaction offline.")
+	{
+		_, err = offlineRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+		if errors.Cause(err) != algosdk.ErrExternalSigningRequired {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", algosdk.ErrExternalSigningRequired)
+			t.Fatalf("\t%s\tMint should require external signing.", tests.Failed)
+		}
+		t.Logf("\t%s\tMint requires external signing ok.", tests.Success)
+
+		utx, err := offlineRepo.UnsignedCreateTxn(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tUnsignedCreateTxn failed.", tests.Failed)
+		}
+
+		again, err := offlineRepo.UnsignedCreateTxn(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tUnsignedCreateTxn failed.", tests.Failed)
+		} else if diff := cmp.Diff(again, utx); diff != "" {
+			t.Fatalf("\t%s\tExpected the issued transaction to be returned again. Diff:\n%s", tests.Failed, diff)
+		}
+		t.Logf("\t%s\tUnsignedCreateTxn ok.", tests.Success)
+
+		var tx types.Transaction
+		if err := msgpack.Decode(utx.Txn, &tx); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tDecode unsigned transaction failed.", tests.Failed)
+		}
+
+		tampered := tx
+		tampered.AssetParams.Total = 1000000
+
+		var signTests = []struct {
+			name string
+			sign func() ([]byte, error)
+			err  error
+		}{
+			{"WrongSigner",
+				func() ([]byte, error) {
+					_, stx, err := crypto.SignTransaction(crypto.GenerateAccount().PrivateKey, tx)
+					return stx, err
+				},
+				ErrSignedTxnInvalidSignature,
+			},
+			{"TamperedTxn",
+				func() ([]byte, error) {
+					_, stx, err := crypto.SignTransaction(creator.PrivateKey, tampered)
+					return stx, err
+				},
+				ErrSignedTxnMismatch,
+			},
+			{"Base64SignedByCreator",
+				func() ([]byte, error) {
+					_, stx, err := crypto.SignTransaction(creator.PrivateKey, tx)
+					return []byte(base64.StdEncoding.EncodeToString(stx)), err
+				},
+				nil,
+			},
+		}
+
+		for i, tt := range signTests {
+			t.Logf("\tTest: %d\tWhen running test: %s", i, tt.name)
+			{
+				stx, err := tt.sign()
+				if err != nil {
+					t.Log("\t\tGot :", err)
+					t.Fatalf("\t%s\tSign transaction failed.", tests.Failed)
+				}
+
+				res, err := offlineRepo.SubmitSignedTxn(ctx, auth.Claims{}, CreatedAssetSignedTxnRequest{
+					ID:        created.ID,
+					SignedTxn: stx,
+				}, now)
+				if errors.Cause(err) != tt.err {
+					t.Logf("\t\tGot : %+v", err)
+					t.Logf("\t\tWant: %+v", tt.err)
+					t.Fatalf("\t%s\tSubmitSignedTxn failed.", tests.Failed)
+				} else if tt.err != nil {
+					t.Logf("\t%s\tSubmitSignedTxn rejected ok.", tests.Success)
+					continue
+				}
+
+				if res.MintStatus != CreatedAssetMintStatus_Submitted || res.TxID != utx.TxID || len(res.UnsignedTxn) > 0 {
+					t.Logf("\t\tGot : %s %s", res.MintStatus, res.TxID)
+					t.Logf("\t\tWant: %s %s", CreatedAssetMintStatus_Submitted, utx.TxID)
+					t.Fatalf("\t%s\tSigned transaction should be submitted.", tests.Failed)
+				}
+				t.Logf("\t%s\tSubmitSignedTxn ok.", tests.Success)
+			}
+		}
+
+		srv.Advance(1)
+
+		confirmed, err := offlineRepo.Reconcile(ctx, auth.Claims{}, created.ID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcile failed.", tests.Failed)
+		} else if confirmed.MintStatus != CreatedAssetMintStatus_Confirmed || confirmed.AssetIndex == 0 {
+			t.Logf("\t\tGot : %s %d", confirmed.MintStatus, confirmed.AssetIndex)
+			t.Fatalf("\t%s\tOffline signed asset should be confirmed.", tests.Failed)
+		}
+		t.Logf("\t%s\tReconcile ok.", tests.Success)
+	}
+}
+
+// TestLifecycle validates managing a minted asset with config, freeze, clawback and
+// destroy transactions.
+func TestLifecycle(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.February, 22, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	lifecycleRepo := NewRepository(test.MasterDB, algoClient, signer, "")
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	// The freeze address is held offline.
+	creator := signer.Generate().Address.String()
+	freezer := crypto.GenerateAccount()
+	holder := crypto.GenerateAccount().Address.String()
+
+	created, err := lifecycleRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:       acc.ID,
+		Network:         "sandbox",
+		UnitName:        "LIFE",
+		AssetName:       "Lifecycle " + uuid.NewRandom().String()[0:8],
+		Total:           1000000,
+		CreatorAddress:  creator,
+		ManagerAddress:  creator,
+		FreezeAddress:   freezer.Address.String(),
+		ClawbackAddress: creator,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	// confirm advances the network and reconciles the submitted transaction.
+	confirm := func(t *testing.T, txn *CreatedAssetTxn) *CreatedAssetTxn {
+		srv.Advance(1)
+
+		err := lifecycleRepo.ReconcileSubmitted(ctx, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+		}
+
+		res, err := lifecycleRepo.ReadTxn(ctx, auth.Claims{}, CreatedAssetTxnReadRequest{CreatedAssetID: created.ID, ID: txn.ID})
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReadTxn failed.", tests.Failed)
+		} else if res.Status != CreatedAssetTxnStatus_Confirmed || res.ConfirmedRound == 0 {
+			t.Logf("\t\tGot : %s %d", res.Status, res.ConfirmedRound)
+			t.Fatalf("\t%s\tTransaction should be confirmed.", tests.Failed)
+		}
+		return res
+	}
+
+	t.Log("Given the need to manage an asset after it was minted.")
+	{
+		_, err = lifecycleRepo.Freeze(ctx, auth.Claims{}, CreatedAssetFreezeRequest{ID: created.ID, Address: holder, Frozen: true}, now)
+		if errors.Cause(err) != ErrAssetNotMinted {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrAssetNotMinted)
+			t.Fatalf("\t%s\tFreeze before minting should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tFreeze before minting rejected ok.", tests.Success)
+
+		if _, err := lifecycleRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tMint failed.", tests.Failed)
+		}
+		srv.Advance(1)
+		if err := lifecycleRepo.ReconcileSubmitted(ctx, now); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+		}
+
+		t.Log("\tWhen the freeze address is signed for offline.")
+		{
+			draft, err := lifecycleRepo.Freeze(ctx, auth.Claims{}, CreatedAssetFreezeRequest{ID: created.ID, Address: holder, Frozen: true}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFreeze failed.", tests.Failed)
+			} else if draft.Status != CreatedAssetTxnStatus_Draft {
+				t.Logf("\t\tGot : %s", draft.Status)
+				t.Logf("\t\tWant: %s", CreatedAssetTxnStatus_Draft)
+				t.Fatalf("\t%s\tFreeze should be left as a draft.", tests.Failed)
+			}
+
+			utx, err := lifecycleRepo.UnsignedAssetTxn(ctx, auth.Claims{}, CreatedAssetTxnReadRequest{CreatedAssetID: created.ID, ID: draft.ID})
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tUnsignedAssetTxn failed.", tests.Failed)
+			}
+
+			var tx types.Transaction
+			if err := msgpack.Decode(utx.Txn, &tx); err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tDecode unsigned transaction failed.", tests.Failed)
+			}
+
+			_, stx, err := crypto.SignTransaction(freezer.PrivateKey, tx)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+			}
+
+			submitted, err := lifecycleRepo.SubmitSignedAssetTxn(ctx, auth.Claims{}, CreatedAssetTxnSignedRequest{
+				CreatedAssetID: created.ID,
+				ID:             draft.ID,
+				SignedTxn:      stx,
+			}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tSubmitSignedAssetTxn failed.", tests.Failed)
+			} else if submitted.Status != CreatedAssetTxnStatus_Submitted || len(submitted.UnsignedTxn) > 0 {
+				t.Logf("\t\tGot : %s", submitted.Status)
+				t.Fatalf("\t%s\tFreeze should be submitted.", tests.Failed)
+			}
+
+			confirm(t, submitted)
+			t.Logf("\t%s\tFreeze signed offline ok.", tests.Success)
+		}
+
+		t.Log("\tWhen the asset is reconfigured to remove the clawback address.")
+		{
+			clawback, err := lifecycleRepo.Clawback(ctx, auth.Claims{}, CreatedAssetClawbackRequest{ID: created.ID, Address: holder, Amount: 10}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tClawback failed.", tests.Failed)
+			} else if clawback.ReceiverAddress != creator {
+				t.Logf("\t\tGot : %s", clawback.ReceiverAddress)
+				t.Logf("\t\tWant: %s", creator)
+				t.Fatalf("\t%s\tClawback should return units to the creator without a reserve.", tests.Failed)
+			}
+			confirm(t, clawback)
+			t.Logf("\t%s\tClawback ok.", tests.Success)
+
+			config, err := lifecycleRepo.Reconfigure(ctx, auth.Claims{}, CreatedAssetConfigRequest{ID: created.ID, ClawbackAddress: tests.StringPointer("")}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReconfigure failed.", tests.Failed)
+			}
+			confirm(t, config)
+
+			reconfigured, err := lifecycleRepo.ReadByID(ctx, auth.Claims{}, created.ID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReadByID failed.", tests.Failed)
+			} else if reconfigured.ClawbackAddress != "" || reconfigured.FreezeAddress != freezer.Address.String() {
+				t.Logf("\t\tGot : %s %s", reconfigured.ClawbackAddress, reconfigured.FreezeAddress)
+				t.Fatalf("\t%s\tOnly the clawback address should be cleared.", tests.Failed)
+			}
+			t.Logf("\t%s\tReconfigure ok.", tests.Success)
+
+			_, err = lifecycleRepo.Clawback(ctx, auth.Claims{}, CreatedAssetClawbackRequest{ID: created.ID, Address: holder, Amount: 10}, now)
+			if errors.Cause(err) != ErrAssetAddressNotSet {
+				t.Logf("\t\tGot : %+v", err)
+				t.Logf("\t\tWant: %+v", ErrAssetAddressNotSet)
+				t.Fatalf("\t%s\tClawback without a clawback address should fail.", tests.Failed)
+			}
+			t.Logf("\t%s\tClawback without address rejected ok.", tests.Success)
+		}
+
+		t.Log("\tWhen the asset is destroyed.")
+		{
+			destroy, err := lifecycleRepo.Destroy(ctx, auth.Claims{}, CreatedAssetDestroyRequest{ID: created.ID}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tDestroy failed.", tests.Failed)
+			}
+			confirm(t, destroy)
+
+			destroyed, err := lifecycleRepo.ReadByID(ctx, auth.Claims{}, created.ID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReadByID failed.", tests.Failed)
+			} else if destroyed.DestroyedAt == nil || !destroyed.DestroyedAt.Valid {
+				t.Fatalf("\t%s\tAsset should be marked as destroyed.", tests.Failed)
+			}
+			t.Logf("\t%s\tDestroy ok.", tests.Success)
+
+			_, err = lifecycleRepo.Freeze(ctx, auth.Claims{}, CreatedAssetFreezeRequest{ID: created.ID, Address: holder}, now)
+			if errors.Cause(err) != ErrAssetDestroyed {
+				t.Logf("\t\tGot : %+v", err)
+				t.Logf("\t\tWant: %+v", ErrAssetDestroyed)
+				t.Fatalf("\t%s\tFreeze after destroy should fail.", tests.Failed)
+			}
+
+			txns, err := lifecycleRepo.FindTxns(ctx, auth.Claims{}, created.ID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFindTxns failed.", tests.Failed)
+			} else if len(txns) != 4 {
+				t.Logf("\t\tGot : %d", len(txns))
+				t.Logf("\t\tWant: %d", 4)
+				t.Fatalf("\t%s\tExpected all the transactions of the asset.", tests.Failed)
+			}
+			t.Logf("\t%s\tFindTxns ok.", tests.Success)
+		}
+	}
+}
+
+// TestReconcileTxnForgotten validates a created asset transaction the node no longer
+// knows about, or whose submission response was lost, is confirmed from the network and
+// only failed once provably expired.
+func TestReconcileTxnForgotten(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.February, 23, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	lifecycleRepo := NewRepository(test.MasterDB, algoClient, signer, "")
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	creator := signer.Generate().Address.String()
+	created, err := lifecycleRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:       acc.ID,
+		Network:         "sandbox",
+		UnitName:        "LOST",
+		AssetName:       "Forgotten " + uuid.NewRandom().String()[0:8],
+		Total:           1000000,
+		CreatorAddress:  creator,
+		ManagerAddress:  creator,
+		FreezeAddress:   creator,
+		ClawbackAddress: creator,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	if _, err := lifecycleRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now); err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMint failed.", tests.Failed)
+	}
+	srv.Advance(1)
+	if err := lifecycleRepo.ReconcileSubmitted(ctx, now); err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+	}
+
+	t.Log("Given the need to confirm a transaction after it left the pool.")
+	{
+		config, err := lifecycleRepo.Reconfigure(ctx, auth.Claims{}, CreatedAssetConfigRequest{ID: created.ID, ClawbackAddress: tests.StringPointer("")}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconfigure failed.", tests.Failed)
+		}
+
+		srv.Advance(1)
+		srv.Forget(config.TxID)
+
+		confirmed, err := lifecycleRepo.ReconcileTxn(ctx, auth.Claims{}, CreatedAssetTxnReadRequest{CreatedAssetID: created.ID, ID: config.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileTxn failed.", tests.Failed)
+		} else if confirmed.Status != CreatedAssetTxnStatus_Confirmed {
+			t.Logf("\t\tGot : %s %s", confirmed.Status, confirmed.Error)
+			t.Logf("\t\tWant: %s", CreatedAssetTxnStatus_Confirmed)
+			t.Fatalf("\t%s\tForgotten transaction should be confirmed from the indexer.", tests.Failed)
+		}
+
+		m, err := lifecycleRepo.ReadByID(ctx, auth.Claims{}, created.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReadByID failed.", tests.Failed)
+		} else if m.ClawbackAddress != "" {
+			t.Logf("\t\tGot : %s", m.ClawbackAddress)
+			t.Fatalf("\t%s\tConfirmed reconfigure should clear the clawback address.", tests.Failed)
+		}
+		t.Logf("\t%s\tReconcileTxn forgotten confirmed ok.", tests.Success)
+	}
+
+	t.Log("Given the need to confirm a transaction when the response to its submission was lost.")
+	{
+		srv.Drop = func(stx types.SignedTxn) bool { return true }
+		_, err := lifecycleRepo.Reconfigure(ctx, auth.Claims{}, CreatedAssetConfigRequest{ID: created.ID, ManagerAddress: tests.StringPointer(creator)}, now)
+		srv.Drop = nil
+		if err == nil {
+			t.Fatalf("\t%s\tReconfigure should fail when the response is lost.", tests.Failed)
+		}
+
+		txns, err := lifecycleRepo.FindTxns(ctx, auth.Claims{}, created.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tFindTxns failed.", tests.Failed)
+		}
+
+		var config *CreatedAssetTxn
+		for _, tx := range txns {
+			if tx.Type == CreatedAssetTxnType_Config && tx.Status != CreatedAssetTxnStatus_Confirmed {
+				config = tx
+			}
+		}
+		if config == nil || config.Status != CreatedAssetTxnStatus_Submitted {
+			t.Logf("\t\tGot : %+v", config)
+			t.Fatalf("\t%s\tTransaction should remain submitted when the send outcome is unknown.", tests.Failed)
+		}
+		t.Logf("\t%s\tReconfigure lost response submitted ok.", tests.Success)
+
+		srv.Advance(1)
+
+		confirmed, err := lifecycleRepo.ReconcileTxn(ctx, auth.Claims{}, CreatedAssetTxnReadRequest{CreatedAssetID: created.ID, ID: config.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileTxn failed.", tests.Failed)
+		} else if confirmed.Status != CreatedAssetTxnStatus_Confirmed {
+			t.Logf("\t\tGot : %s %s", confirmed.Status, confirmed.Error)
+			t.Logf("\t\tWant: %s", CreatedAssetTxnStatus_Confirmed)
+			t.Fatalf("\t%s\tTransaction should be confirmed once it is on chain.", tests.Failed)
+		}
+		t.Logf("\t%s\tReconcileTxn lost response confirmed ok.", tests.Success)
+	}
+
+	t.Log("Given the need to fail a transaction that was dropped.")
+	{
+		srv.ConfirmAfter = 1 << 32
+		defer func() { srv.ConfirmAfter = 1 }()
+
+		config, err := lifecycleRepo.Reconfigure(ctx, auth.Claims{}, CreatedAssetConfigRequest{ID: created.ID, FreezeAddress: tests.StringPointer("")}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconfigure failed.", tests.Failed)
+		}
+		srv.Forget(config.TxID)
+
+		pending, err := lifecycleRepo.ReconcileTxn(ctx, auth.Claims{}, CreatedAssetTxnReadRequest{CreatedAssetID: created.ID, ID: config.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileTxn failed.", tests.Failed)
+		} else if pending.Status != CreatedAssetTxnStatus_Submitted {
+			t.Logf("\t\tGot : %s", pending.Status)
+			t.Fatalf("\t%s\tDropped transaction should remain submitted until the last valid round.", tests.Failed)
+		}
+		t.Logf("\t%s\tReconcileTxn dropped pending ok.", tests.Success)
+
+		srv.Advance(config.LastValidRound - srv.Round() + 1)
+
+		expired, err := lifecycleRepo.ReconcileTxn(ctx, auth.Claims{}, CreatedAssetTxnReadRequest{CreatedAssetID: created.ID, ID: config.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileTxn failed.", tests.Failed)
+		} else if expired.Status != CreatedAssetTxnStatus_Failed || expired.Error == "" {
+			t.Logf("\t\tGot : %s %s", expired.Status, expired.Error)
+			t.Fatalf("\t%s\tDropped transaction should be failed once expired.", tests.Failed)
+		}
+
+		m, err := lifecycleRepo.ReadByID(ctx, auth.Claims{}, created.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReadByID failed.", tests.Failed)
+		} else if m.FreezeAddress != creator {
+			t.Logf("\t\tGot : %s", m.FreezeAddress)
+			t.Logf("\t\tWant: %s", creator)
+			t.Fatalf("\t%s\tExpired reconfigure should not change the freeze address.", tests.Failed)
+		}
+		t.Logf("\t%s\tReconcileTxn dropped expired ok.", tests.Success)
+	}
+}
+
+// TestDistribution validates an investor opting in to an asset with their own key and
+// receiving units of the asset from the issuer.
+func TestDistribution(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.February, 29, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	distRepo := NewRepository(test.MasterDB, algoClient, signer, "")
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	investor, err := user.MockUser(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockUser failed.", tests.Failed)
+	}
+
+	// The key of the investor is never held by Exitor.
+	creator := signer.Generate().Address.String()
+	wallet := crypto.GenerateAccount()
+
+	created, err := distRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:      acc.ID,
+		Network:        "sandbox",
+		UnitName:       "DIST",
+		AssetName:      "Distribution " + uuid.NewRandom().String()[0:8],
+		Total:          1000000,
+		CreatorAddress: creator,
+		ManagerAddress: creator,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	if _, err := distRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now); err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMint failed.", tests.Failed)
+	}
+
+	// confirm advances the network and reconciles the submitted transactions.
+	confirm := func(t *testing.T) {
+		srv.Advance(1)
+
+		err := distRepo.ReconcileSubmitted(ctx, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+		}
+	}
+	confirm(t)
+
+	newClaims := func(userID, role string) auth.Claims {
+		return auth.Claims{
+			Roles: []string{role},
+			StandardClaims: jwt.StandardClaims{
+				Subject:   userID,
+				Audience:  acc.ID,
+				IssuedAt:  now.Unix(),
+				ExpiresAt: now.Add(time.Hour).Unix(),
+			},
+		}
+	}
+	investorClaims := newClaims(investor.ID, auth.RoleUser)
+	adminClaims := newClaims(uuid.NewRandom().String(), auth.RoleAdmin)
+
+	t.Log("Given the need to distribute an asset to an investor.")
+	{
+		_, err = distRepo.Transfer(ctx, adminClaims, CreatedAssetTransferRequest{
+			ID: created.ID, UserID: investor.ID, Address: wallet.Address.String(), Amount: 100,
+		}, now)
+		if errors.Cause(err) != ErrNotOptedIn {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrNotOptedIn)
+			t.Fatalf("\t%s\tTransfer before opt-in should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tTransfer before opt-in rejected ok.", tests.Success)
+
+		_, err = distRepo.OptIn(ctx, investorClaims, CreatedAssetOptInRequest{
+			ID: created.ID, UserID: uuid.NewRandom().String(), Address: wallet.Address.String(),
+		}, now)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tOpt-in for another user should be forbidden.", tests.Failed)
+		}
+		t.Logf("\t%s\tOpt-in for another user forbidden ok.", tests.Success)
+
+		t.Log("\tWhen the investor signs the opt-in offline.")
+		{
+			draft, err := distRepo.OptIn(ctx, investorClaims, CreatedAssetOptInRequest{
+				ID: created.ID, UserID: investor.ID, Address: wallet.Address.String(),
+			}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tOptIn failed.", tests.Failed)
+			} else if draft.Status != CreatedAssetTxnStatus_Draft {
+				t.Logf("\t\tGot : %s", draft.Status)
+				t.Logf("\t\tWant: %s", CreatedAssetTxnStatus_Draft)
+				t.Fatalf("\t%s\tOpt-in should be left as a draft.", tests.Failed)
+			}
+
+			utx, err := distRepo.UnsignedAssetTxn(ctx, investorClaims, CreatedAssetTxnReadRequest{CreatedAssetID: created.ID, ID: draft.ID})
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tUnsignedAssetTxn failed.", tests.Failed)
+			}
+
+			var tx types.Transaction
+			if err := msgpack.Decode(utx.Txn, &tx); err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tDecode unsigned transaction failed.", tests.Failed)
+			} else if tx.Sender != wallet.Address || tx.AssetReceiver != wallet.Address || tx.AssetAmount != 0 {
+				t.Logf("\t\tGot : %s %s %d", tx.Sender, tx.AssetReceiver, tx.AssetAmount)
+				t.Fatalf("\t%s\tOpt-in should be a zero amount transfer to the sender.", tests.Failed)
+			}
+
+			_, stx, err := crypto.SignTransaction(wallet.PrivateKey, tx)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+			}
+
+			_, err = distRepo.SubmitSignedAssetTxn(ctx, investorClaims, CreatedAssetTxnSignedRequest{
+				CreatedAssetID: created.ID,
+				ID:             draft.ID,
+				SignedTxn:      stx,
+			}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tSubmitSignedAssetTxn failed.", tests.Failed)
+			}
+			confirm(t)
+
+			optIn, err := distRepo.OptIn(ctx, investorClaims, CreatedAssetOptInRequest{
+				ID: created.ID, UserID: investor.ID, Address: wallet.Address.String(),
+			}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tOptIn failed.", tests.Failed)
+			} else if optIn.ID != draft.ID || optIn.Status != CreatedAssetTxnStatus_Confirmed {
+				t.Logf("\t\tGot : %s %s", optIn.ID, optIn.Status)
+				t.Logf("\t\tWant: %s %s", draft.ID, CreatedAssetTxnStatus_Confirmed)
+				t.Fatalf("\t%s\tThe confirmed opt-in should be returned.", tests.Failed)
+			}
+			t.Logf("\t%s\tOptIn ok.", tests.Success)
+		}
+
+		t.Log("\tWhen the issuer sends units to the investor.")
+		{
+			_, err = distRepo.Transfer(ctx, investorClaims, CreatedAssetTransferRequest{
+				ID: created.ID, UserID: investor.ID, Address: wallet.Address.String(), Amount: 100,
+			}, now)
+			if errors.Cause(err) != ErrForbidden {
+				t.Logf("\t\tGot : %+v", err)
+				t.Logf("\t\tWant: %+v", ErrForbidden)
+				t.Fatalf("\t%s\tTransfer by the investor should be forbidden.", tests.Failed)
+			}
+
+			transfer, err := distRepo.Transfer(ctx, adminClaims, CreatedAssetTransferRequest{
+				ID: created.ID, UserID: investor.ID, Address: wallet.Address.String(), Amount: 100,
+			}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tTransfer failed.", tests.Failed)
+			} else if transfer.Status != CreatedAssetTxnStatus_Submitted || transfer.SenderAddress != creator {
+				t.Logf("\t\tGot : %s %s", transfer.Status, transfer.SenderAddress)
+				t.Fatalf("\t%s\tTransfer should be signed for the creator and submitted.", tests.Failed)
+			}
+			confirm(t)
+			t.Logf("\t%s\tTransfer ok.", tests.Success)
+
+			txns, err := distRepo.FindUserTxns(ctx, investorClaims, created.ID, investor.ID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFindUserTxns failed.", tests.Failed)
+			} else if len(txns) != 2 {
+				t.Logf("\t\tGot : %d", len(txns))
+				t.Logf("\t\tWant: %d", 2)
+				t.Fatalf("\t%s\tExpected the opt-in and transfer of the investor.", tests.Failed)
+			}
+			for _, txn := range txns {
+				if txn.Status != CreatedAssetTxnStatus_Confirmed {
+					t.Logf("\t\tGot : %s %s", txn.Type, txn.Status)
+					t.Fatalf("\t%s\tExpected the transactions to be confirmed.", tests.Failed)
+				}
+			}
+			t.Logf("\t%s\tFindUserTxns ok.", tests.Success)
+
+			others, err := distRepo.FindTxns(ctx, newClaims(uuid.NewRandom().String(), auth.RoleUser), created.ID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFindTxns failed.", tests.Failed)
+			} else if len(others) != 0 {
+				t.Logf("\t\tGot : %d", len(others))
+				t.Fatalf("\t%s\tOther users should not see the transactions of the investor.", tests.Failed)
+			}
+			t.Logf("\t%s\tFindTxns for other users ok.", tests.Success)
+		}
+	}
+}
+
+// TestHolders validates the snapshot of the holders of an asset is replaced with the
+// balances reported by the indexer.
+func TestHolders(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.March, 7, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	holdersRepo := NewRepository(test.MasterDB, algoClient, signer, "")
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	creator := signer.Generate().Address.String()
+	investor := crypto.GenerateAccount().Address.String()
+	frozen := crypto.GenerateAccount().Address.String()
+
+	created, err := holdersRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:      acc.ID,
+		Network:        "sandbox",
+		UnitName:       "HOLD",
+		AssetName:      "Holders " + uuid.NewRandom().String()[0:8],
+		Total:          1000,
+		CreatorAddress: creator,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	t.Log("Given the need to display the holders of an asset.")
+	{
+		_, err = holdersRepo.SyncHolders(ctx, auth.Claims{}, created.ID, now)
+		if errors.Cause(err) != ErrAssetNotMinted {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrAssetNotMinted)
+			t.Fatalf("\t%s\tSyncHolders before minting should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tSyncHolders before minting rejected ok.", tests.Success)
+
+		if _, err := holdersRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tMint failed.", tests.Failed)
+		}
+		srv.Advance(1)
+		if err := holdersRepo.ReconcileSubmitted(ctx, now); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+		}
+
+		minted, err := holdersRepo.ReadByID(ctx, auth.Claims{}, created.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReadByID failed.", tests.Failed)
+		}
+
+		srv.SetHolding(minted.AssetIndex, models.MiniAssetHolding{Address: creator, Amount: 850})
+		srv.SetHolding(minted.AssetIndex, models.MiniAssetHolding{Address: investor, Amount: 100})
+		srv.SetHolding(minted.AssetIndex, models.MiniAssetHolding{Address: frozen, Amount: 50, IsFrozen: true})
+
+		holders, err := holdersRepo.SyncHolders(ctx, auth.Claims{}, created.ID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSyncHolders failed.", tests.Failed)
+		}
+
+		var got []string
+		for _, h := range holders {
+			got = append(got, fmt.Sprintf("%s %d %v %d", h.Address, h.Amount, h.Frozen, h.Round))
+		}
+		expected := []string{
+			fmt.Sprintf("%s %d %v %d", creator, 850, false, srv.Round()),
+			fmt.Sprintf("%s %d %v %d", investor, 100, false, srv.Round()),
+			fmt.Sprintf("%s %d %v %d", frozen, 50, true, srv.Round()),
+		}
+		if diff := cmp.Diff(got, expected); diff != "" {
+			t.Fatalf("\t%s\tExpected holders to match the indexer. Diff:\n%s", tests.Failed, diff)
+		}
+		t.Logf("\t%s\tSyncHolders ok.", tests.Success)
+
+		// The investor opts out and returns their units to the creator.
+		srv.Advance(1)
+		srv.SetHolding(minted.AssetIndex, models.MiniAssetHolding{Address: creator, Amount: 950})
+		srv.SetHolding(minted.AssetIndex, models.MiniAssetHolding{Address: investor, Deleted: true})
+
+		holders, err = holdersRepo.SyncHolders(ctx, auth.Claims{}, created.ID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSyncHolders failed.", tests.Failed)
+		} else if len(holders) != 2 || holders[0].Address != creator || holders[0].Amount != 950 || holders[0].Round != srv.Round() {
+			for _, h := range holders {
+				t.Logf("\t\tGot : %s %d %d", h.Address, h.Amount, h.Round)
+			}
+			t.Fatalf("\t%s\tExpected the investor to be removed from the holders.", tests.Failed)
+		}
+		t.Logf("\t%s\tSyncHolders after opt-out ok.", tests.Success)
+
+		frozenOnly, err := holdersRepo.FindHolders(ctx, auth.Claims{}, CreatedAssetHolderFindRequest{
+			CreatedAssetID: created.ID,
+			Where:          "frozen = ?",
+			Args:           []interface{}{true},
+		})
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tFindHolders failed.", tests.Failed)
+		} else if len(frozenOnly) != 1 || frozenOnly[0].Address != frozen {
+			t.Logf("\t\tGot : %d", len(frozenOnly))
+			t.Fatalf("\t%s\tExpected only the frozen holder.", tests.Failed)
+		}
+		t.Logf("\t%s\tFindHolders ok.", tests.Success)
+	}
+}
+
+// TestIssue validates units of an asset are issued to an investor for a payment in an atomic
+// group that is only submitted once the investor and the issuer have both signed.
+func TestIssue(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.May, 2, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	issueRepo := NewRepository(test.MasterDB, algoClient, signer, "")
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	investor, err := user.MockUser(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockUser failed.", tests.Failed)
+	}
+
+	// The key of the investor is never held by Exitor.
+	creator := signer.Generate().Address.String()
+	wallet := crypto.GenerateAccount()
+
+	created, err := issueRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:      acc.ID,
+		Network:        "sandbox",
+		UnitName:       "ISSUE",
+		AssetName:      "Issue " + uuid.NewRandom().String()[0:8],
+		Total:          1000000,
+		CreatorAddress: creator,
+		ManagerAddress: creator,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	if _, err := issueRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now); err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMint failed.", tests.Failed)
+	}
+
+	// confirm advances the network and reconciles the submitted transactions.
+	confirm := func(t *testing.T) {
+		srv.Advance(1)
+
+		err := issueRepo.ReconcileSubmitted(ctx, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+		}
+	}
+	confirm(t)
+
+	newClaims := func(userID, role string) auth.Claims {
+		return auth.Claims{
+			Roles: []string{role},
+			StandardClaims: jwt.StandardClaims{
+				Subject:   userID,
+				Audience:  acc.ID,
+				IssuedAt:  now.Unix(),
+				ExpiresAt: now.Add(time.Hour).Unix(),
+			},
+		}
+	}
+	investorClaims := newClaims(investor.ID, auth.RoleUser)
+	adminClaims := newClaims(uuid.NewRandom().String(), auth.RoleAdmin)
+
+	// signOffline signs the draft member of a group with the key of the investor.
+	signOffline := func(t *testing.T, txn *CreatedAssetTxn) *CreatedAssetTxn {
+		utx, err := issueRepo.UnsignedAssetTxn(ctx, investorClaims, CreatedAssetTxnReadRequest{CreatedAssetID: created.ID, ID: txn.ID})
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tUnsignedAssetTxn failed.", tests.Failed)
+		}
+
+		var tx types.Transaction
+		if err := msgpack.Decode(utx.Txn, &tx); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tDecode unsigned transaction failed.", tests.Failed)
+		}
+
+		_, stx, err := crypto.SignTransaction(wallet.PrivateKey, tx)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+		}
+
+		res, err := issueRepo.SubmitSignedAssetTxn(ctx, investorClaims, CreatedAssetTxnSignedRequest{
+			CreatedAssetID: created.ID,
+			ID:             txn.ID,
+			SignedTxn:      stx,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSubmitSignedAssetTxn failed.", tests.Failed)
+		}
+
+		return res
+	}
+
+	t.Log("Given the need to issue an asset to an investor for a payment.")
+	{
+		req := CreatedAssetIssueRequest{
+			ID: created.ID, UserID: investor.ID, Address: wallet.Address.String(), Amount: 100, PaymentAmount: 2500000,
+		}
+
+		_, err = issueRepo.Issue(ctx, adminClaims, req, now)
+		if errors.Cause(err) != ErrNotOptedIn {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrNotOptedIn)
+			t.Fatalf("\t%s\tIssue before opt-in should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tIssue before opt-in rejected ok.", tests.Success)
+
+		optIn, err := issueRepo.OptIn(ctx, investorClaims, CreatedAssetOptInRequest{
+			ID: created.ID, UserID: investor.ID, Address: wallet.Address.String(),
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tOptIn failed.", tests.Failed)
+		}
+		signOffline(t, optIn)
+		confirm(t)
+
+		_, err = issueRepo.Issue(ctx, investorClaims, req, now)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tIssue by the investor should be forbidden.", tests.Failed)
+		}
+		t.Logf("\t%s\tIssue by the investor forbidden ok.", tests.Success)
+
+		t.Log("\tWhen the investor signs the payment of the group.")
+		{
+			group, err := issueRepo.Issue(ctx, adminClaims, req, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tIssue failed.", tests.Failed)
+			} else if len(group) != 2 || group[0].Type != CreatedAssetTxnType_Payment || group[1].Type != CreatedAssetTxnType_Transfer {
+				t.Logf("\t\tGot : %d", len(group))
+				t.Fatalf("\t%s\tIssue should be a group of the payment and the transfer.", tests.Failed)
+			}
+
+			payment, transfer := group[0], group[1]
+			if payment.GroupID == "" || payment.GroupID != transfer.GroupID {
+				t.Logf("\t\tGot : %s %s", payment.GroupID, transfer.GroupID)
+				t.Fatalf("\t%s\tMembers should share the group ID.", tests.Failed)
+			} else if payment.Status != CreatedAssetTxnStatus_Draft || len(payment.SignedTxn) > 0 {
+				t.Logf("\t\tGot : %s", payment.Status)
+				t.Fatalf("\t%s\tPayment should be left as a draft for the investor.", tests.Failed)
+			} else if transfer.Status != CreatedAssetTxnStatus_Draft || len(transfer.SignedTxn) == 0 {
+				t.Logf("\t\tGot : %s", transfer.Status)
+				t.Fatalf("\t%s\tTransfer should be signed for the creator and held.", tests.Failed)
+			} else if payment.SenderAddress != wallet.Address.String() || payment.ReceiverAddress != creator || payment.Amount != req.PaymentAmount {
+				t.Logf("\t\tGot : %s %s %d", payment.SenderAddress, payment.ReceiverAddress, payment.Amount)
+				t.Fatalf("\t%s\tPayment should be from the investor to the creator.", tests.Failed)
+			}
+
+			var txns []types.Transaction
+			for _, m := range group {
+				var tx types.Transaction
+				if err := msgpack.Decode(m.UnsignedTxn, &tx); err != nil {
+					t.Log("\t\tGot :", err)
+					t.Fatalf("\t%s\tDecode unsigned transaction failed.", tests.Failed)
+				}
+				txns = append(txns, tx)
+			}
+
+			gid := txns[0].Group
+			txns[0].Group, txns[1].Group = types.Digest{}, types.Digest{}
+			if expected, _ := crypto.ComputeGroupID(txns); gid != expected || base64.StdEncoding.EncodeToString(gid[:]) != payment.GroupID {
+				t.Logf("\t\tGot : %s", payment.GroupID)
+				t.Logf("\t\tWant: %s", base64.StdEncoding.EncodeToString(expected[:]))
+				t.Fatalf("\t%s\tGroup ID should be computed from the members.", tests.Failed)
+			}
+			t.Logf("\t%s\tIssue ok.", tests.Success)
+
+			sent := len(srv.Sent())
+			signed := signOffline(t, payment)
+			if signed.Status != CreatedAssetTxnStatus_Submitted || len(srv.Sent()) != sent+2 {
+				t.Logf("\t\tGot : %s %d", signed.Status, len(srv.Sent())-sent)
+				t.Fatalf("\t%s\tGroup should be submitted once all the members are signed.", tests.Failed)
+			}
+			confirm(t)
+
+			group, err = issueRepo.FindGroupTxns(ctx, adminClaims, created.ID, payment.GroupID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFindGroupTxns failed.", tests.Failed)
+			}
+			for _, m := range group {
+				if m.Status != CreatedAssetTxnStatus_Confirmed || len(m.SignedTxn) > 0 {
+					t.Logf("\t\tGot : %s %s", m.Type, m.Status)
+					t.Fatalf("\t%s\tExpected the members to be confirmed.", tests.Failed)
+				}
+			}
+			t.Logf("\t%s\tSubmit group ok.", tests.Success)
+		}
+
+		t.Log("\tWhen a member of the group is rejected by the network.")
+		{
+			req.Amount, req.PaymentAmount = 200, 5000000
+
+			group, err := issueRepo.Issue(ctx, adminClaims, req, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tIssue failed.", tests.Failed)
+			}
+
+			srv.Reject = func(stx types.SignedTxn) string {
+				if stx.Txn.Type == types.PaymentTx {
+					return "overspend"
+				}
+				return ""
+			}
+			defer func() { srv.Reject = nil }()
+
+			signOffline(t, group[0])
+			confirm(t)
+
+			group, err = issueRepo.FindGroupTxns(ctx, adminClaims, created.ID, group[0].GroupID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFindGroupTxns failed.", tests.Failed)
+			}
+			for _, m := range group {
+				if m.Status != CreatedAssetTxnStatus_Failed {
+					t.Logf("\t\tGot : %s %s", m.Type, m.Status)
+					t.Fatalf("\t%s\tExpected all the members to fail.", tests.Failed)
+				}
+			}
+			t.Logf("\t%s\tReject group ok.", tests.Success)
+		}
+	}
+}
+
+// failingSigner fails to sign every transaction, like a signer that is unavailable.
+type failingSigner struct{}
+
+// SignTransaction returns an error, implements algosdk.Signer.
+func (failingSigner) SignTransaction(ctx context.Context, tx types.Transaction) ([]byte, error) {
+	return nil, errors.New("signer unavailable")
+}
+
+// TestIssueFailed validates a group is failed when a member can't be signed and when it
+// isn't signed before its last valid round, but not when the response to its submission
+// was lost.
+func TestIssueFailed(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.May, 3, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	issueRepo := NewRepository(test.MasterDB, algoClient, signer, "")
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	investor, err := user.MockUser(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockUser failed.", tests.Failed)
+	}
+
+	creator := signer.Generate().Address.String()
+	wallet := crypto.GenerateAccount()
+
+	created, err := issueRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:      acc.ID,
+		Network:        "sandbox",
+		UnitName:       "ISSUE",
+		AssetName:      "Issue " + uuid.NewRandom().String()[0:8],
+		Total:          1000000,
+		CreatorAddress: creator,
+		ManagerAddress: creator,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	if _, err := issueRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now); err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMint failed.", tests.Failed)
+	}
+
+	// reconcile advances the network by the rounds and reconciles the transactions.
+	reconcile := func(t *testing.T, rounds uint64) {
+		srv.Advance(rounds)
+
+		err := issueRepo.ReconcileSubmitted(ctx, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+		}
+	}
+	reconcile(t, 1)
+
+	claims := auth.Claims{
+		Roles: []string{auth.RoleUser},
+		StandardClaims: jwt.StandardClaims{
+			Subject:   investor.ID,
+			Audience:  acc.ID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Hour).Unix(),
+		},
+	}
+	adminClaims := claims
+	adminClaims.Subject, adminClaims.Roles = uuid.NewRandom().String(), []string{auth.RoleAdmin}
+
+	optIn, err := issueRepo.OptIn(ctx, claims, CreatedAssetOptInRequest{
+		ID: created.ID, UserID: investor.ID, Address: wallet.Address.String(),
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tOptIn failed.", tests.Failed)
+	}
+
+	utx, err := issueRepo.UnsignedAssetTxn(ctx, claims, CreatedAssetTxnReadRequest{CreatedAssetID: created.ID, ID: optIn.ID})
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tUnsignedAssetTxn failed.", tests.Failed)
+	}
+
+	var tx types.Transaction
+	if err := msgpack.Decode(utx.Txn, &tx); err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tDecode unsigned transaction failed.", tests.Failed)
+	}
+
+	_, stx, err := crypto.SignTransaction(wallet.PrivateKey, tx)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+	}
+
+	_, err = issueRepo.SubmitSignedAssetTxn(ctx, claims, CreatedAssetTxnSignedRequest{
+		CreatedAssetID: created.ID,
+		ID:             optIn.ID,
+		SignedTxn:      stx,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tSubmitSignedAssetTxn failed.", tests.Failed)
+	}
+	reconcile(t, 1)
+
+	req := CreatedAssetIssueRequest{
+		ID: created.ID, UserID: investor.ID, Address: wallet.Address.String(), Amount: 100, PaymentAmount: 2500000,
+	}
+
+	// expectFailed ensures all the members of the group have failed.
+	expectFailed := func(t *testing.T, groupID string) {
+		group, err := issueRepo.FindGroupTxns(ctx, adminClaims, created.ID, groupID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tFindGroupTxns failed.", tests.Failed)
+		}
+		for _, m := range group {
+			if m.Status != CreatedAssetTxnStatus_Failed || m.Error == "" {
+				t.Logf("\t\tGot : %s %s %s", m.Type, m.Status, m.Error)
+				t.Fatalf("\t%s\tExpected all the members to fail.", tests.Failed)
+			}
+		}
+	}
+
+	t.Log("Given the need to not leave a group waiting for members that will never be signed.")
+	{
+		t.Log("\tWhen the signer fails to sign a member.")
+		{
+			issueRepo.Signer = failingSigner{}
+			_, err := issueRepo.Issue(ctx, adminClaims, req, now)
+			issueRepo.Signer = signer
+			if err == nil {
+				t.Fatalf("\t%s\tIssue should fail when a member can't be signed.", tests.Failed)
+			}
+
+			// The members were recorded before signing, find the group by the investor.
+			txns, err := issueRepo.FindTxns(ctx, adminClaims, created.ID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFindTxns failed.", tests.Failed)
+			}
+
+			var groupID string
+			for _, m := range txns {
+				if m.Type == CreatedAssetTxnType_Payment {
+					groupID = m.GroupID
+					break
+				}
+			}
+			if groupID == "" {
+				t.Fatalf("\t%s\tExpected the group to be recorded.", tests.Failed)
+			}
+			expectFailed(t, groupID)
+			t.Logf("\t%s\tSigner failure ok.", tests.Success)
+		}
+
+		t.Log("\tWhen the investor doesn't sign the payment before its last valid round.")
+		{
+			group, err := issueRepo.Issue(ctx, adminClaims, req, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tIssue failed.", tests.Failed)
+			}
+
+			// The drafts are kept while they can still be signed.
+			reconcile(t, 1)
+			res, err := issueRepo.FindGroupTxns(ctx, adminClaims, created.ID, group[0].GroupID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFindGroupTxns failed.", tests.Failed)
+			} else if res[0].Status != CreatedAssetTxnStatus_Draft || res[1].Status != CreatedAssetTxnStatus_Draft {
+				t.Logf("\t\tGot : %s %s", res[0].Status, res[1].Status)
+				t.Fatalf("\t%s\tExpected the members to still be drafts.", tests.Failed)
+			}
+
+			reconcile(t, group[0].LastValidRound-srv.Round()+1)
+			expectFailed(t, group[0].GroupID)
+			t.Logf("\t%s\tExpire group ok.", tests.Success)
+		}
+	}
+
+	t.Log("Given the need to not issue units twice when the response to the submission of a group is lost.")
+	{
+		group, err := issueRepo.Issue(ctx, adminClaims, req, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tIssue failed.", tests.Failed)
+		}
+
+		var payment *CreatedAssetTxn
+		for _, m := range group {
+			if m.Type == CreatedAssetTxnType_Payment {
+				payment = m
+			}
+		}
+
+		utx, err := issueRepo.UnsignedAssetTxn(ctx, claims, CreatedAssetTxnReadRequest{CreatedAssetID: created.ID, ID: payment.ID})
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tUnsignedAssetTxn failed.", tests.Failed)
+		}
+
+		var tx types.Transaction
+		if err := msgpack.Decode(utx.Txn, &tx); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tDecode unsigned transaction failed.", tests.Failed)
+		}
+
+		_, stx, err := crypto.SignTransaction(wallet.PrivateKey, tx)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+		}
+
+		srv.Drop = func(stx types.SignedTxn) bool { return true }
+		_, err = issueRepo.SubmitSignedAssetTxn(ctx, claims, CreatedAssetTxnSignedRequest{
+			CreatedAssetID: created.ID,
+			ID:             payment.ID,
+			SignedTxn:      stx,
+		}, now)
+		srv.Drop = nil
+		if err == nil {
+			t.Fatalf("\t%s\tSubmitSignedAssetTxn should fail when the response is lost.", tests.Failed)
+		}
+
+		// expectStatus ensures all the members of the group have the status.
+		expectStatus := func(t *testing.T, status CreatedAssetTxnStatus) {
+			res, err := issueRepo.FindGroupTxns(ctx, adminClaims, created.ID, payment.GroupID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFindGroupTxns failed.", tests.Failed)
+			}
+			for _, m := range res {
+				if m.Status != status {
+					t.Logf("\t\tGot : %s %s %s", m.Type, m.Status, m.Error)
+					t.Logf("\t\tWant: %s", status)
+					t.Fatalf("\t%s\tExpected all the members to have the status.", tests.Failed)
+				}
+			}
+		}
+
+		expectStatus(t, CreatedAssetTxnStatus_Submitted)
+		t.Logf("\t%s\tLost response submitted ok.", tests.Success)
+
+		reconcile(t, 1)
+		expectStatus(t, CreatedAssetTxnStatus_Confirmed)
+		t.Logf("\t%s\tLost response confirmed ok.", tests.Success)
+	}
+}
+
+// TestMultisig validates the manager of an asset held by a 2-of-3 multisig reconfiguring the
+// asset once two of its members have signed offline.
+func TestMultisig(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.May, 9, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	msRepo := NewRepository(test.MasterDB, algoClient, signer, "")
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	// The keys of the directors are never held by Exitor.
+	var (
+		directors []crypto.Account
+		members   []string
+	)
+	for i := 0; i < 3; i++ {
+		director := crypto.GenerateAccount()
+		directors = append(directors, director)
+		members = append(members, director.Address.String())
+	}
+
+	ms, err := wallet.NewRepository(test.MasterDB).CreateMultisig(ctx, auth.Claims{}, wallet.WalletMultisigCreateRequest{
+		AccountID: acc.ID,
+		Label:     "Board of Directors",
+		Threshold: 2,
+		Members:   members,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreateMultisig failed.", tests.Failed)
+	}
+
+	ma, err := ms.MultisigAccount()
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMultisigAccount failed.", tests.Failed)
+	}
+
+	creator := signer.Generate().Address.String()
+
+	created, err := msRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:      acc.ID,
+		Network:        "sandbox",
+		UnitName:       "MSIG",
+		AssetName:      "Multisig " + uuid.NewRandom().String()[0:8],
+		Total:          1000000,
+		CreatorAddress: creator,
+		ManagerAddress: ms.Address,
+		FreezeAddress:  ms.Address,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	if _, err := msRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now); err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMint failed.", tests.Failed)
+	}
+
+	// confirm advances the network and reconciles the submitted transactions.
+	confirm := func(t *testing.T) {
+		srv.Advance(1)
+
+		err := msRepo.ReconcileSubmitted(ctx, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+		}
+	}
+	confirm(t)
+
+	adminClaims := auth.Claims{
+		Roles: []string{auth.RoleAdmin},
+		StandardClaims: jwt.StandardClaims{
+			Subject:   uuid.NewRandom().String(),
+			Audience:  acc.ID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Hour).Unix(),
+		},
+	}
+
+	// partial downloads the transaction with the signatures collected so far for the next
+	// member to sign.
+	partial := func(t *testing.T, txn *CreatedAssetTxn) []byte {
+		utx, err := msRepo.UnsignedAssetTxn(ctx, adminClaims, CreatedAssetTxnReadRequest{CreatedAssetID: created.ID, ID: txn.ID})
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tUnsignedAssetTxn failed.", tests.Failed)
+		} else if !utx.Multisig {
+			t.Fatalf("\t%s\tExpected the transaction to be signed by the multisig.", tests.Failed)
+		}
+		return utx.Txn
+	}
+
+	submit := func(txn *CreatedAssetTxn, stx []byte) (*CreatedAssetTxn, error) {
+		return msRepo.SubmitSignedAssetTxn(ctx, adminClaims, CreatedAssetTxnSignedRequest{
+			CreatedAssetID: created.ID,
+			ID:             txn.ID,
+			SignedTxn:      stx,
+		}, now)
+	}
+
+	t.Log("Given the need for two of three directors to approve the management of an asset.")
+	{
+		reserve := creator
+
+		config, err := msRepo.Reconfigure(ctx, adminClaims, CreatedAssetConfigRequest{ID: created.ID, ReserveAddress: &reserve}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconfigure failed.", tests.Failed)
+		} else if res := config.Response(ctx); config.Status != CreatedAssetTxnStatus_Draft || !res.Multisig || res.Signatures != 0 || res.Threshold != 2 {
+			t.Logf("\t\tGot : %s %d of %d", config.Status, res.Signatures, res.Threshold)
+			t.Fatalf("\t%s\tExpected a draft to be signed by the members of the multisig.", tests.Failed)
+		}
+		t.Logf("\t%s\tReconfigure ok.", tests.Success)
+
+		t.Log("\tWhen a multisig that is not the manager signs.")
+		{
+			var pre types.SignedTxn
+			if err := msgpack.Decode(partial(t, config), &pre); err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tDecode multisig transaction failed.", tests.Failed)
+			}
+
+			outsider := crypto.GenerateAccount()
+			other, err := crypto.MultisigAccountWithParams(wallet.MultisigVersion, 2, []types.Address{outsider.Address, directors[0].Address, directors[1].Address})
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tMultisigAccountWithParams failed.", tests.Failed)
+			}
+
+			_, stx, err := crypto.SignMultisigTransaction(outsider.PrivateKey, other, pre.Txn)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tSignMultisigTransaction failed.", tests.Failed)
+			}
+
+			_, err = submit(config, stx)
+			if errors.Cause(err) != ErrSignedTxnInvalidSignature {
+				t.Logf("\t\tGot : %+v", err)
+				t.Logf("\t\tWant: %+v", ErrSignedTxnInvalidSignature)
+				t.Fatalf("\t%s\tSignature of another multisig should be rejected.", tests.Failed)
+			}
+			t.Logf("\t%s\tOther multisig rejected ok.", tests.Success)
+		}
+
+		t.Log("\tWhen the members sign one after the other.")
+		{
+			_, stx, err := crypto.AppendMultisigTransaction(directors[0].PrivateKey, ma, partial(t, config))
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tAppendMultisigTransaction failed.", tests.Failed)
+			}
+
+			sent := len(srv.Sent())
+			res, err := submit(config, stx)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tSubmitSignedAssetTxn failed.", tests.Failed)
+			} else if r := res.Response(ctx); res.Status != CreatedAssetTxnStatus_Draft || r.Signatures != 1 || len(srv.Sent()) != sent {
+				t.Logf("\t\tGot : %s %d of %d", res.Status, r.Signatures, r.Threshold)
+				t.Fatalf("\t%s\tExpected the transaction to wait for the threshold.", tests.Failed)
+			}
+			t.Logf("\t%s\tFirst signature ok.", tests.Success)
+
+			_, stx, err = crypto.AppendMultisigTransaction(directors[2].PrivateKey, ma, partial(t, config))
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tAppendMultisigTransaction failed.", tests.Failed)
+			}
+
+			res, err = submit(config, stx)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tSubmitSignedAssetTxn failed.", tests.Failed)
+			} else if res.Status != CreatedAssetTxnStatus_Submitted || len(srv.Sent()) != sent+1 {
+				t.Logf("\t\tGot : %s", res.Status)
+				t.Fatalf("\t%s\tExpected the transaction to be submitted once the threshold is met.", tests.Failed)
+			}
+
+			var signedCount int
+			for _, s := range srv.Sent()[sent].Msig.Subsigs {
+				if s.Sig != (types.Signature{}) {
+					signedCount++
+				}
+			}
+			if signedCount != 2 {
+				t.Logf("\t\tGot : %d", signedCount)
+				t.Fatalf("\t%s\tExpected the merged signatures of both members.", tests.Failed)
+			}
+			t.Logf("\t%s\tThreshold met ok.", tests.Success)
+
+			confirm(t)
+
+			m, err := msRepo.ReadByID(ctx, adminClaims, created.ID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReadByID failed.", tests.Failed)
+			} else if m.ReserveAddress != reserve || m.ManagerAddress != ms.Address {
+				t.Logf("\t\tGot : %s", m.ReserveAddress)
+				t.Logf("\t\tWant: %s", reserve)
+				t.Fatalf("\t%s\tExpected the asset to be reconfigured.", tests.Failed)
+			}
+			t.Logf("\t%s\tReconfigure confirmed ok.", tests.Success)
+		}
+	}
+}
+
+// roundTripFunc serves the requests of an http.Client without a network connection.
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// TestMetadata validates the ARC-3 metadata document is published and bound by its hash to the
+// asset create transaction, and the hosted document is verified against the on-chain hash.
+func TestMetadata(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.April, 25, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	metadataRepo := NewRepository(test.MasterDB, algoClient, signer, "https://exitor.test")
+
+	// Serve the metadata documents the same as the web app, the hosted document can be
+	// replaced to check it's no longer verified.
+	var hosted []byte
+	defer func(rt http.RoundTripper) { metadataClient.Transport = rt }(metadataClient.Transport)
+	metadataClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+
+		assetIndex, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, metadataURLPath), 10, 64)
+		if err != nil {
+			rec.WriteHeader(http.StatusNotFound)
+			return rec.Result(), nil
+		}
+
+		doc, err := metadataRepo.ReadMetadata(r.Context(), "sandbox", assetIndex)
+		if err != nil {
+			rec.WriteHeader(http.StatusNotFound)
+			return rec.Result(), nil
+		}
+		if hosted != nil {
+			doc = hosted
+		}
+
+		rec.Write(doc)
+		return rec.Result(), nil
+	})
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	creator := signer.Generate().Address.String()
+	created, err := metadataRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:      acc.ID,
+		Network:        "sandbox",
+		UnitName:       "META",
+		AssetName:      "Metadata " + uuid.NewRandom().String()[0:8],
+		Description:    "Shares of the fund issued to members.",
+		ImageURL:       "https://exitor.test/logo.png",
+		Total:          1000000,
+		Decimals:       2,
+		CreatorAddress: creator,
+		ManagerAddress: creator,
+		LegalDocuments: []CreatedAssetDocument{
+			{Name: "Prospectus", URL: "https://exitor.test/prospectus.pdf"},
+		},
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	t.Log("Given the need to publish the metadata of created assets.")
+	{
+		_, err = metadataRepo.VerifyMetadata(ctx, auth.Claims{}, created.ID)
+		if errors.Cause(err) != ErrMetadataNotPublished {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrMetadataNotPublished)
+			t.Fatalf("\t%s\tDraft asset should not have published metadata.", tests.Failed)
+		}
+		t.Logf("\t%s\tVerifyMetadata not published ok.", tests.Success)
+
+		minted, err := metadataRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tMint failed.", tests.Failed)
+		}
+
+		expectedURL := "https://exitor.test/m/{id}#arc3"
+		if minted.URL != expectedURL {
+			t.Logf("\t\tGot : %s", minted.URL)
+			t.Logf("\t\tWant: %s", expectedURL)
+			t.Fatalf("\t%s\tMinted asset URL does not match.", tests.Failed)
+		}
+
+		var md AssetMetadata
+		if err := json.Unmarshal(minted.Metadata, &md); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tDecode metadata failed.", tests.Failed)
+		}
+
+		expectedMd := NewAssetMetadata(minted, AssetMetadataIssuer{
+			Name:     acc.Name,
+			Address1: acc.Address1,
+			Address2: acc.Address2,
+			City:     acc.City,
+			Region:   acc.Region,
+			Country:  acc.Country,
+			Zipcode:  acc.Zipcode,
+		})
+		if diff := cmp.Diff(md, expectedMd); diff != "" {
+			t.Fatalf("\t%s\tExpected metadata to match. Diff:\n%s", tests.Failed, diff)
+		}
+
+		hash := sha256.Sum256(minted.Metadata)
+		stx := srv.Sent()[len(srv.Sent())-1]
+		if stx.Txn.AssetParams.URL != expectedURL || stx.Txn.AssetParams.MetadataHash != hash {
+			t.Logf("\t\tGot : %s %x", stx.Txn.AssetParams.URL, stx.Txn.AssetParams.MetadataHash)
+			t.Logf("\t\tWant: %s %x", expectedURL, hash)
+			t.Fatalf("\t%s\tAsset create transaction should bind the metadata hash.", tests.Failed)
+		}
+
+		var note arc69Metadata
+		if err := json.Unmarshal(stx.Txn.Note, &note); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tDecode note failed.", tests.Failed)
+		} else if note.Standard != "arc69" || note.Description != md.Description || len(note.Properties.LegalDocuments) != 1 {
+			t.Logf("\t\tGot : %s", string(stx.Txn.Note))
+			t.Fatalf("\t%s\tAsset create transaction should include the ARC-69 note.", tests.Failed)
+		}
+		t.Logf("\t%s\tMint publish metadata ok.", tests.Success)
+
+		srv.Advance(1)
+
+		confirmed, err := metadataRepo.Reconcile(ctx, auth.Claims{}, created.ID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcile failed.", tests.Failed)
+		}
+
+		expectedMetadataURL := fmt.Sprintf("https://exitor.test/m/%d", confirmed.AssetIndex)
+		if confirmed.MetadataURL() != expectedMetadataURL {
+			t.Logf("\t\tGot : %s", confirmed.MetadataURL())
+			t.Logf("\t\tWant: %s", expectedMetadataURL)
+			t.Fatalf("\t%s\tMetadata URL does not match.", tests.Failed)
+		}
+
+		res, err := metadataRepo.VerifyMetadata(ctx, auth.Claims{}, created.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tVerifyMetadata failed.", tests.Failed)
+		} else if !res.Verified || res.Hash != hex.EncodeToString(hash[:]) {
+			t.Logf("\t\tGot : %+v", res)
+			t.Fatalf("\t%s\tHosted metadata should be verified.", tests.Failed)
+		}
+		t.Logf("\t%s\tVerifyMetadata ok.", tests.Success)
+
+		hosted = []byte(`{"name":"Changed"}`)
+		res, err = metadataRepo.VerifyMetadata(ctx, auth.Claims{}, created.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tVerifyMetadata failed.", tests.Failed)
+		} else if res.Verified || res.Error == "" {
+			t.Logf("\t\tGot : %+v", res)
+			t.Fatalf("\t%s\tChanged metadata should not be verified.", tests.Failed)
+		}
+		t.Logf("\t%s\tVerifyMetadata changed ok.", tests.Success)
+	}
+
+	t.Log("Given the need to limit the size of the metadata URL.")
+	{
+		longRepo := NewRepository(test.MasterDB, algoClient, signer, "https://metadata.exitor.test")
+
+		draft, err := longRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+			AccountID:      acc.ID,
+			Network:        "sandbox",
+			AssetName:      "Metadata " + uuid.NewRandom().String()[0:8],
+			Total:          1000,
+			CreatorAddress: creator,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+		}
+
+		_, err = longRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: draft.ID}, now)
+		if errors.Cause(err) != ErrMetadataURLTooLong {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrMetadataURLTooLong)
+			t.Fatalf("\t%s\tMint with a long metadata URL should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tMint URL too long ok.", tests.Success)
+	}
+
+	t.Log("Given the need to limit the size of the ARC-69 note.")
+	{
+		docs := make([]CreatedAssetDocument, 20)
+		for i := range docs {
+			docs[i] = CreatedAssetDocument{Name: fmt.Sprintf("Document %d", i), URL: fmt.Sprintf("https://exitor.test/documents/%d.pdf", i)}
+		}
+
+		m := &CreatedAsset{ID: uuid.NewRandom().String(), AssetName: "Large", Description: "Description", LegalDocuments: docs}
+		m.Metadata, _ = json.Marshal(NewAssetMetadata(m, AssetMetadataIssuer{Name: "Exitor"}))
+
+		note, err := m.metadataNote()
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tmetadataNote failed.", tests.Failed)
+		} else if len(note) > noteMaxSize || strings.Contains(string(note), "legal_documents") || !strings.Contains(string(note), "Description") {
+			t.Logf("\t\tGot : %s", string(note))
+			t.Fatalf("\t%s\tNote should leave out the legal documents.", tests.Failed)
+		}
+		t.Logf("\t%s\tmetadataNote ok.", tests.Success)
+	}
+}
+
+// TestWhitelist validates the holdings of a default frozen asset are unfrozen once the holder is
+// approved by KYC and frozen again when the approval expires.
+func TestWhitelist(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.May, 16, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	whitelistRepo := NewRepository(test.MasterDB, algoClient, signer, "")
+	kycRepo := user_kyc.NewRepository(test.MasterDB)
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	investor, err := user.MockUser(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockUser failed.", tests.Failed)
+	}
+
+	// Exitor holds the key of the creator that is also the freeze manager.
+	creator := signer.Generate().Address.String()
+	holder := crypto.GenerateAccount()
+
+	created, err := whitelistRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:      acc.ID,
+		Network:        "sandbox",
+		UnitName:       "KYC",
+		AssetName:      "Whitelist " + uuid.NewRandom().String()[0:8],
+		Total:          1000000,
+		DefaultFrozen:  true,
+		CreatorAddress: creator,
+		ManagerAddress: creator,
+		FreezeAddress:  creator,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	if _, err := whitelistRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now); err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMint failed.", tests.Failed)
+	}
+
+	// confirm advances the network and reconciles the submitted transactions.
+	confirm := func(t *testing.T) {
+		srv.Advance(1)
+
+		err := whitelistRepo.ReconcileSubmitted(ctx, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+		}
+	}
+	confirm(t)
+
+	// isFrozen returns the frozen state of the holding reported by the indexer.
+	isFrozen := func(t *testing.T) bool {
+		holders, err := whitelistRepo.SyncHolders(ctx, auth.Claims{}, created.ID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSyncHolders failed.", tests.Failed)
+		}
+
+		for _, h := range holders {
+			if h.Address == holder.Address.String() {
+				return h.Frozen
+			}
+		}
+
+		t.Fatalf("\t%s\tExpected a holding for %s.", tests.Failed, holder.Address.String())
+		return false
+	}
+
+	complianceClaims := auth.Claims{
+		Roles: []string{auth.RoleCompliance},
+		StandardClaims: jwt.StandardClaims{
+			Subject:   uuid.NewRandom().String(),
+			Audience:  acc.ID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Hour).Unix(),
+		},
+	}
+
+	t.Log("Given the need to restrict a default frozen asset to investors approved by KYC.")
+	{
+		optIn, err := whitelistRepo.OptIn(ctx, auth.Claims{}, CreatedAssetOptInRequest{
+			ID: created.ID, UserID: investor.ID, Address: holder.Address.String(),
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tOptIn failed.", tests.Failed)
+		}
+
+		var tx types.Transaction
+		if err := msgpack.Decode(optIn.UnsignedTxn, &tx); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tDecode unsigned transaction failed.", tests.Failed)
+		}
+
+		_, stx, err := crypto.SignTransaction(holder.PrivateKey, tx)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+		}
+
+		_, err = whitelistRepo.SubmitSignedAssetTxn(ctx, auth.Claims{}, CreatedAssetTxnSignedRequest{
+			CreatedAssetID: created.ID,
+			ID:             optIn.ID,
+			SignedTxn:      stx,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSubmitSignedAssetTxn failed.", tests.Failed)
+		}
+		confirm(t)
+
+		if !isFrozen(t) {
+			t.Fatalf("\t%s\tExpected the holding to start frozen.", tests.Failed)
+		}
+		t.Logf("\t%s\tOptIn frozen by default ok.", tests.Success)
+
+		req := CreatedAssetTransferRequest{
+			ID: created.ID, UserID: investor.ID, Address: holder.Address.String(), Amount: 100,
+		}
+
+		_, err = whitelistRepo.Transfer(ctx, auth.Claims{}, req, now)
+		if errors.Cause(err) != ErrNotWhitelisted {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrNotWhitelisted)
+			t.Fatalf("\t%s\tTransfer to an investor that is not approved should fail.", tests.Failed)
+		}
+
+		issued, err := whitelistRepo.SyncWhitelist(ctx, complianceClaims, created.ID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSyncWhitelist failed.", tests.Failed)
+		} else if len(issued) != 0 {
+			t.Logf("\t\tGot : %d", len(issued))
+			t.Fatalf("\t%s\tExpected the holding that is not approved to be left frozen.", tests.Failed)
+		}
+		t.Logf("\t%s\tTransfer not whitelisted rejected ok.", tests.Success)
+
+		t.Log("\tWhen the investor is approved by KYC.")
+		{
+			expires := now.Add(time.Hour * 24)
+			_, err = kycRepo.Review(ctx, auth.Claims{}, user_kyc.UserKycReviewRequest{
+				UserID:    investor.ID,
+				AccountID: acc.ID,
+				Status:    user_kyc.UserKycStatus_Approved,
+				ExpiresAt: &expires,
+			}, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReview failed.", tests.Failed)
+			}
+
+			issued, err = whitelistRepo.SyncWhitelist(ctx, complianceClaims, created.ID, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tSyncWhitelist failed.", tests.Failed)
+			} else if len(issued) != 1 || issued[0].Frozen || issued[0].TargetAddress != holder.Address.String() {
+				t.Logf("\t\tGot : %+v", issued)
+				t.Fatalf("\t%s\tExpected the holding to be unfrozen.", tests.Failed)
+			} else if issued[0].Status != CreatedAssetTxnStatus_Submitted {
+				t.Logf("\t\tGot : %s", issued[0].Status)
+				t.Fatalf("\t%s\tExpected the unfreeze to be submitted.", tests.Failed)
+			}
+
+			// The unfreeze is not issued again while it's waiting to be confirmed.
+			issued, err = whitelistRepo.SyncWhitelist(ctx, complianceClaims, created.ID, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tSyncWhitelist failed.", tests.Failed)
+			} else if len(issued) != 0 {
+				t.Logf("\t\tGot : %d", len(issued))
+				t.Fatalf("\t%s\tExpected the pending unfreeze to be skipped.", tests.Failed)
+			}
+			confirm(t)
+
+			if isFrozen(t) {
+				t.Fatalf("\t%s\tExpected the holding to be unfrozen.", tests.Failed)
+			}
+
+			if _, err = whitelistRepo.Transfer(ctx, auth.Claims{}, req, now); err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tTransfer failed.", tests.Failed)
+			}
+			t.Logf("\t%s\tSyncWhitelist unfreeze ok.", tests.Success)
+
+			// Once the approval expires the holding is frozen again.
+			issued, err = whitelistRepo.SyncWhitelist(ctx, complianceClaims, created.ID, expires)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tSyncWhitelist failed.", tests.Failed)
+			} else if len(issued) != 1 || !issued[0].Frozen {
+				t.Logf("\t\tGot : %+v", issued)
+				t.Fatalf("\t%s\tExpected the holding to be frozen.", tests.Failed)
+			}
+			confirm(t)
+
+			if !isFrozen(t) {
+				t.Fatalf("\t%s\tExpected the holding to be frozen.", tests.Failed)
+			}
+			t.Logf("\t%s\tSyncWhitelist expired freeze ok.", tests.Success)
+		}
+	}
+}
+
+// TestVestingSchedule validates the units vested by the schedule of a vesting grant with a cliff,
+// the interval between tranches, acceleration and cancelling.
+func TestVestingSchedule(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	grant := func(interval int) *CreatedAssetVestingGrant {
+		return &CreatedAssetVestingGrant{
+			Total:               4800,
+			StartDate:           start,
+			CliffMonths:         12,
+			PeriodMonths:        48,
+			IntervalMonths:      interval,
+			AccelerationPercent: 50,
+		}
+	}
+
+	accelerated := grant(1)
+	accelerated.AcceleratedAt = &pq.NullTime{Time: time.Date(2021, time.February, 15, 0, 0, 0, 0, time.UTC), Valid: true}
+
+	cancelled := grant(1)
+	cancelled.CancelledAt = &pq.NullTime{Time: time.Date(2021, time.February, 15, 0, 0, 0, 0, time.UTC), Valid: true}
+
+	var vestingTests = []struct {
+		name  string
+		grant *CreatedAssetVestingGrant
+		at    time.Time
+		want  uint64
+	}{
+		{"before start", grant(1), start.AddDate(0, 0, -1), 0},
+		{"before cliff", grant(1), time.Date(2020, time.December, 31, 0, 0, 0, 0, time.UTC), 0},
+		{"at cliff", grant(1), time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), 1200},
+		{"monthly", grant(1), time.Date(2021, time.February, 15, 0, 0, 0, 0, time.UTC), 1300},
+		{"quarterly", grant(3), time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC), 1200},
+		{"quarterly tranche", grant(3), time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC), 1500},
+		{"end of period", grant(1), time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), 4800},
+		{"after period", grant(1), time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC), 4800},
+		{"accelerated", accelerated, time.Date(2021, time.February, 15, 0, 0, 0, 0, time.UTC), 3050},
+		{"after acceleration", accelerated, time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC), 3150},
+		{"accelerated end", accelerated, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), 4800},
+		{"cancelled", cancelled, time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC), 1300},
+	}
+
+	t.Log("Given the need to calculate the units vested by a vesting grant.")
+	{
+		for i, tt := range vestingTests {
+			t.Logf("\tTest: %d\tWhen %s.", i, tt.name)
+			{
+				if got := tt.grant.VestedAmount(tt.at); got != tt.want {
+					t.Logf("\t\tGot : %d", got)
+					t.Logf("\t\tWant: %d", tt.want)
+					t.Fatalf("\t%s\tVestedAmount failed.", tests.Failed)
+				}
+				t.Logf("\t%s\tVestedAmount ok.", tests.Success)
+			}
+		}
+
+		// The cliff vests at once and a tranche vests every month after it.
+		schedule := grant(1).Schedule()
+		if len(schedule) != 37 {
+			t.Logf("\t\tGot : %d", len(schedule))
+			t.Fatalf("\t%s\tExpected 37 tranches.", tests.Failed)
+		} else if first := schedule[0]; first.Amount != 1200 || !first.Date.Equal(start.AddDate(1, 0, 0)) {
+			t.Logf("\t\tGot : %+v", first)
+			t.Fatalf("\t%s\tExpected the cliff tranche.", tests.Failed)
+		} else if last := schedule[36]; last.Vested != 4800 || last.Amount != 100 {
+			t.Logf("\t\tGot : %+v", last)
+			t.Fatalf("\t%s\tExpected the last tranche to vest the total.", tests.Failed)
+		}
+
+		// Nothing vests after the grant is cancelled.
+		schedule = cancelled.Schedule()
+		if last := schedule[len(schedule)-1]; last.Vested != 1300 {
+			t.Logf("\t\tGot : %+v", last)
+			t.Fatalf("\t%s\tExpected the schedule to stop when cancelled.", tests.Failed)
+		}
+		t.Logf("\t%s\tSchedule ok.", tests.Success)
+	}
+}
+
+// TestVesting validates granting units of a created asset that vest, releasing them from the
+// reserve as they vest and accelerating and cancelling the grant.
+func TestVesting(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.May, 23, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	vestingRepo := NewRepository(test.MasterDB, algoClient, signer, "")
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	employee, err := user.MockUser(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockUser failed.", tests.Failed)
+	}
+
+	// Exitor holds the key of the creator that is also the reserve holding the units.
+	creator := signer.Generate().Address.String()
+	holder := crypto.GenerateAccount()
+
+	created, err := vestingRepo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:      acc.ID,
+		Network:        "sandbox",
+		UnitName:       "VEST",
+		AssetName:      "Vesting " + uuid.NewRandom().String()[0:8],
+		Total:          10000,
+		CreatorAddress: creator,
+		ManagerAddress: creator,
+		ReserveAddress: creator,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	if _, err := vestingRepo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: created.ID}, now); err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMint failed.", tests.Failed)
+	}
+
+	// confirm advances the network and reconciles the submitted transactions.
+	confirm := func(t *testing.T) {
+		srv.Advance(1)
+
+		err := vestingRepo.ReconcileSubmitted(ctx, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+		}
+	}
+	confirm(t)
+
+	newClaims := func(role string, subject string) auth.Claims {
+		return auth.Claims{
+			Roles: []string{role},
+			StandardClaims: jwt.StandardClaims{
+				Subject:   subject,
+				Audience:  acc.ID,
+				IssuedAt:  now.Unix(),
+				ExpiresAt: now.Add(time.Hour).Unix(),
+			},
+		}
+	}
+	adminClaims := newClaims(auth.RoleAdmin, uuid.NewRandom().String())
+	employeeClaims := newClaims(auth.RoleUser, employee.ID)
+
+	t.Log("Given the need to vest units of a created asset for an employee.")
+	{
+		// Start vesting 13 months ago so the cliff has passed.
+		req := CreatedAssetVestingGrantCreateRequest{
+			CreatedAssetID:      created.ID,
+			UserID:              employee.ID,
+			Address:             holder.Address.String(),
+			Total:               4800,
+			StartDate:           now.AddDate(0, -13, 0),
+			CliffMonths:         12,
+			PeriodMonths:        48,
+			IntervalMonths:      1,
+			AccelerationPercent: 50,
+		}
+
+		_, err = vestingRepo.CreateVestingGrant(ctx, employeeClaims, req, now)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tCreateVestingGrant by a user should fail.", tests.Failed)
+		}
+
+		g, err := vestingRepo.CreateVestingGrant(ctx, adminClaims, req, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreateVestingGrant failed.", tests.Failed)
+		}
+
+		over := req
+		over.Total = 6000
+		_, err = vestingRepo.CreateVestingGrant(ctx, adminClaims, over, now)
+		if errors.Cause(err) != ErrVestingTotalExceeded {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrVestingTotalExceeded)
+			t.Fatalf("\t%s\tCreateVestingGrant over the total should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreateVestingGrant ok.", tests.Success)
+
+		grantReq := CreatedAssetVestingGrantRequest{CreatedAssetID: created.ID, ID: g.ID}
+
+		// Nothing is released before the holder has opted in.
+		_, err = vestingRepo.ReleaseVestingGrant(ctx, adminClaims, grantReq, now)
+		if errors.Cause(err) != ErrNotOptedIn {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrNotOptedIn)
+			t.Fatalf("\t%s\tReleaseVestingGrant before the opt-in should fail.", tests.Failed)
+		}
+
+		optIn, err := vestingRepo.OptIn(ctx, auth.Claims{}, CreatedAssetOptInRequest{
+			ID: created.ID, UserID: employee.ID, Address: holder.Address.String(),
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tOptIn failed.", tests.Failed)
+		}
+
+		var tx types.Transaction
+		if err := msgpack.Decode(optIn.UnsignedTxn, &tx); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tDecode unsigned transaction failed.", tests.Failed)
+		}
+
+		_, stx, err := crypto.SignTransaction(holder.PrivateKey, tx)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+		}
+
+		_, err = vestingRepo.SubmitSignedAssetTxn(ctx, auth.Claims{}, CreatedAssetTxnSignedRequest{
+			CreatedAssetID: created.ID,
+			ID:             optIn.ID,
+			SignedTxn:      stx,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSubmitSignedAssetTxn failed.", tests.Failed)
+		}
+		confirm(t)
+
+		// The cliff and the month after it have vested.
+		txn, err := vestingRepo.ReleaseVestingGrant(ctx, adminClaims, grantReq, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReleaseVestingGrant failed.", tests.Failed)
+		} else if txn.Amount != 1300 || txn.SenderAddress != creator || txn.ReceiverAddress != holder.Address.String() {
+			t.Logf("\t\tGot : %+v", txn)
+			t.Fatalf("\t%s\tExpected 1300 units released from the reserve.", tests.Failed)
+		} else if txn.Status != CreatedAssetTxnStatus_Submitted {
+			t.Logf("\t\tGot : %s", txn.Status)
+			t.Fatalf("\t%s\tExpected the release to be submitted.", tests.Failed)
+		}
+		confirm(t)
+
+		_, err = vestingRepo.ReleaseVestingGrant(ctx, adminClaims, grantReq, now)
+		if errors.Cause(err) != ErrNothingToRelease {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrNothingToRelease)
+			t.Fatalf("\t%s\tReleaseVestingGrant twice should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tReleaseVestingGrant ok.", tests.Success)
+
+		// The worker releases the next tranche once it has vested.
+		next := now.AddDate(0, 1, 0)
+		if err := vestingRepo.ReleaseVested(ctx, next); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReleaseVested failed.", tests.Failed)
+		}
+		confirm(t)
+
+		g, err = vestingRepo.ReadVestingGrant(ctx, employeeClaims, grantReq)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReadVestingGrant by the employee failed.", tests.Failed)
+		} else if g.Released != 1400 || g.Releasable(next) != 0 {
+			t.Logf("\t\tGot : %d released", g.Released)
+			t.Fatalf("\t%s\tExpected 1400 units released.", tests.Failed)
+		}
+
+		res := g.Response(ctx, next)
+		if res.Vested != 1400 || res.Unvested != 3400 || res.NextVestingDate == nil {
+			t.Logf("\t\tGot : %+v", res)
+			t.Fatalf("\t%s\tExpected the vested and unvested units.", tests.Failed)
+		}
+		t.Logf("\t%s\tReleaseVested ok.", tests.Success)
+
+		_, err = vestingRepo.AccelerateVestingGrant(ctx, employeeClaims, grantReq, next)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tAccelerateVestingGrant by a user should fail.", tests.Failed)
+		}
+
+		// Half of the 3400 unvested units vest at once.
+		g, err = vestingRepo.AccelerateVestingGrant(ctx, adminClaims, grantReq, next)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tAccelerateVestingGrant failed.", tests.Failed)
+		} else if vested := g.VestedAmount(next); vested != 3100 {
+			t.Logf("\t\tGot : %d", vested)
+			t.Fatalf("\t%s\tExpected 3100 units vested.", tests.Failed)
+		}
+
+		_, err = vestingRepo.AccelerateVestingGrant(ctx, adminClaims, grantReq, next)
+		if errors.Cause(err) != ErrVestingGrantAccelerated {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrVestingGrantAccelerated)
+			t.Fatalf("\t%s\tAccelerateVestingGrant twice should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tAccelerateVestingGrant ok.", tests.Success)
+
+		g, err = vestingRepo.CancelVestingGrant(ctx, adminClaims, grantReq, next)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCancelVestingGrant failed.", tests.Failed)
+		}
+
+		// The units vested before cancelling are still released.
+		later := next.AddDate(1, 0, 0)
+		if err := vestingRepo.ReleaseVested(ctx, later); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReleaseVested failed.", tests.Failed)
+		}
+		confirm(t)
+
+		g, err = vestingRepo.ReadVestingGrant(ctx, adminClaims, grantReq)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReadVestingGrant failed.", tests.Failed)
+		} else if g.Released != 3100 || g.VestedAmount(later) != 3100 {
+			t.Logf("\t\tGot : %d released", g.Released)
+			t.Fatalf("\t%s\tExpected the vesting to stop at 3100 units.", tests.Failed)
+		}
+
+		_, err = vestingRepo.CancelVestingGrant(ctx, adminClaims, grantReq, later)
+		if errors.Cause(err) != ErrVestingGrantCancelled {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrVestingGrantCancelled)
+			t.Fatalf("\t%s\tCancelVestingGrant twice should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tCancelVestingGrant ok.", tests.Success)
+	}
+}
+
+// vestingTest is a minted created asset with a holder that has opted in, used to test
+// vesting grants.
+type vestingTest struct {
+	repo    *Repository
+	srv     *algodtest.Server
+	created *CreatedAsset
+	claims  auth.Claims
+	userID  string
+	holder  crypto.Account
+}
+
+// newVestingTest mints a created asset with the reserve address and opts in a holder.
+func newVestingTest(t *testing.T, srv *algodtest.Server, reserve func(creator string) string, now time.Time) *vestingTest {
+	ctx := tests.Context()
+
+	algoClient, err := algosdk.New(srv.Config("sandbox"))
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNew algosdk client failed.", tests.Failed)
+	}
+
+	signer := algosdk.NewTestSigner(0)
+	vt := &vestingTest{
+		repo:   NewRepository(test.MasterDB, algoClient, signer, ""),
+		srv:    srv,
+		holder: crypto.GenerateAccount(),
+	}
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	employee, err := user.MockUser(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockUser failed.", tests.Failed)
+	}
+	vt.userID = employee.ID
+
+	vt.claims = auth.Claims{
+		Roles: []string{auth.RoleAdmin},
+		StandardClaims: jwt.StandardClaims{
+			Subject:   uuid.NewRandom().String(),
+			Audience:  acc.ID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Hour).Unix(),
+		},
+	}
+
+	creator := signer.Generate().Address.String()
+	vt.created, err = vt.repo.Create(ctx, auth.Claims{}, CreatedAssetCreateRequest{
+		AccountID:      acc.ID,
+		Network:        "sandbox",
+		UnitName:       "VEST",
+		AssetName:      "Vesting " + uuid.NewRandom().String()[0:8],
+		Total:          10000,
+		CreatorAddress: creator,
+		ManagerAddress: creator,
+		ReserveAddress: reserve(creator),
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+	}
+
+	if _, err := vt.repo.Mint(ctx, auth.Claims{}, CreatedAssetMintRequest{ID: vt.created.ID}, now); err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMint failed.", tests.Failed)
+	}
+	vt.reconcile(t, 1, now)
+
+	optIn, err := vt.repo.OptIn(ctx, auth.Claims{}, CreatedAssetOptInRequest{
+		ID: vt.created.ID, UserID: vt.userID, Address: vt.holder.Address.String(),
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tOptIn failed.", tests.Failed)
+	}
+
+	var tx types.Transaction
+	if err := msgpack.Decode(optIn.UnsignedTxn, &tx); err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tDecode unsigned transaction failed.", tests.Failed)
+	}
+
+	_, stx, err := crypto.SignTransaction(vt.holder.PrivateKey, tx)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+	}
+
+	_, err = vt.repo.SubmitSignedAssetTxn(ctx, auth.Claims{}, CreatedAssetTxnSignedRequest{
+		CreatedAssetID: vt.created.ID,
+		ID:             optIn.ID,
+		SignedTxn:      stx,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tSubmitSignedAssetTxn failed.", tests.Failed)
+	}
+	vt.reconcile(t, 1, now)
+
+	return vt
+}
+
+// reconcile advances the network by the rounds and reconciles the transactions.
+func (vt *vestingTest) reconcile(t *testing.T, rounds uint64, now time.Time) {
+	vt.srv.Advance(rounds)
+
+	err := vt.repo.ReconcileSubmitted(tests.Context(), now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tReconcileSubmitted failed.", tests.Failed)
+	}
+}
+
+// grant creates a vesting grant for the holder that has 1300 units releasable at now.
+func (vt *vestingTest) grant(t *testing.T, now time.Time) *CreatedAssetVestingGrant {
+	g, err := vt.repo.CreateVestingGrant(tests.Context(), vt.claims, CreatedAssetVestingGrantCreateRequest{
+		CreatedAssetID: vt.created.ID,
+		UserID:         vt.userID,
+		Address:        vt.holder.Address.String(),
+		Total:          4800,
+		StartDate:      now.AddDate(0, -13, 0),
+		CliffMonths:    12,
+		PeriodMonths:   48,
+		IntervalMonths: 1,
+	}, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tCreateVestingGrant failed.", tests.Failed)
+	}
+	return g
+}
+
+// expectReleased ensures the units released for the vesting grant match.
+func (vt *vestingTest) expectReleased(t *testing.T, g *CreatedAssetVestingGrant, want uint64) {
+	res, err := vt.repo.ReadVestingGrant(tests.Context(), vt.claims, CreatedAssetVestingGrantRequest{CreatedAssetID: vt.created.ID, ID: g.ID})
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tReadVestingGrant failed.", tests.Failed)
+	} else if res.Released != want {
+		t.Logf("\t\tGot : %d", res.Released)
+		t.Logf("\t\tWant: %d", want)
+		t.Fatalf("\t%s\tReleased units don't match.", tests.Failed)
+	}
+}
+
+// TestVestingReleaseFailed validates the units of a release are released again when its
+// transfer fails to broadcast after the release was recorded or expires before it's signed.
+func TestVestingReleaseFailed(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.May, 30, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	t.Log("Given the need to release vested units again when their transfer fails.")
+	{
+		t.Log("\tWhen the transfer fails to broadcast.")
+		{
+			// Exitor holds the key of the creator that is also the reserve.
+			vt := newVestingTest(t, srv, func(creator string) string { return creator }, now)
+			g := vt.grant(t, now)
+			grantReq := CreatedAssetVestingGrantRequest{CreatedAssetID: vt.created.ID, ID: g.ID}
+
+			srv.Refuse = func(stx types.SignedTxn) string {
+				return "node unavailable"
+			}
+			_, err := vt.repo.ReleaseVestingGrant(ctx, vt.claims, grantReq, now)
+			srv.Refuse = nil
+			if err == nil {
+				t.Fatalf("\t%s\tReleaseVestingGrant should fail when the broadcast fails.", tests.Failed)
+			}
+
+			// The release was recorded before the broadcast and its transfer stays submitted
+			// as the node may have accepted it.
+			txns, err := vt.repo.FindTxns(ctx, vt.claims, vt.created.ID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFindTxns failed.", tests.Failed)
+			}
+			var transfer *CreatedAssetTxn
+			for _, m := range txns {
+				if m.Type != CreatedAssetTxnType_Transfer {
+					continue
+				} else if transfer != nil {
+					t.Fatalf("\t%s\tExpected a single transfer to be recorded.", tests.Failed)
+				}
+				transfer = m
+			}
+			if transfer == nil || transfer.Status != CreatedAssetTxnStatus_Submitted {
+				t.Logf("\t\tGot : %+v", transfer)
+				t.Fatalf("\t%s\tExpected the transfer to be submitted.", tests.Failed)
+			}
+			vt.expectReleased(t, g, 1300)
+
+			_, err = vt.repo.ReleaseVestingGrant(ctx, vt.claims, grantReq, now)
+			if errors.Cause(err) != ErrNothingToRelease {
+				t.Logf("\t\tGot : %+v", err)
+				t.Logf("\t\tWant: %+v", ErrNothingToRelease)
+				t.Fatalf("\t%s\tReleaseVestingGrant with a submitted transfer should fail.", tests.Failed)
+			}
+
+			// The node never received the transfer so it's failed once expired.
+			vt.reconcile(t, transfer.LastValidRound-srv.Round()+1, now)
+			vt.expectReleased(t, g, 0)
+
+			txn, err := vt.repo.ReleaseVestingGrant(ctx, vt.claims, grantReq, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReleaseVestingGrant failed.", tests.Failed)
+			} else if txn.Amount != 1300 || txn.Status != CreatedAssetTxnStatus_Submitted {
+				t.Logf("\t\tGot : %d %s", txn.Amount, txn.Status)
+				t.Fatalf("\t%s\tExpected the 1300 units to be released again.", tests.Failed)
+			}
+			vt.reconcile(t, 1, now)
+			vt.expectReleased(t, g, 1300)
+			t.Logf("\t%s\tBroadcast failure ok.", tests.Success)
+		}
+
+		t.Log("\tWhen the response to the broadcast is lost and the worker runs again.")
+		{
+			vt := newVestingTest(t, srv, func(creator string) string { return creator }, now)
+			g := vt.grant(t, now)
+
+			srv.Drop = func(stx types.SignedTxn) bool { return true }
+			err := vt.repo.ReleaseVested(ctx, now)
+			srv.Drop = nil
+			if err == nil {
+				t.Fatalf("\t%s\tReleaseVested should fail when the response is lost.", tests.Failed)
+			}
+
+			// The transfer may have been accepted so it counts as released until reconciled.
+			vt.expectReleased(t, g, 1300)
+
+			if err := vt.repo.ReleaseVested(ctx, now); err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReleaseVested failed.", tests.Failed)
+			}
+
+			txns, err := vt.repo.FindTxns(ctx, vt.claims, vt.created.ID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFindTxns failed.", tests.Failed)
+			}
+			var transfers int
+			for _, m := range txns {
+				if m.Type == CreatedAssetTxnType_Transfer {
+					transfers++
+				}
+			}
+			if transfers != 1 {
+				t.Logf("\t\tGot : %d", transfers)
+				t.Logf("\t\tWant: %d", 1)
+				t.Fatalf("\t%s\tThe worker should not release the units again.", tests.Failed)
+			}
+
+			vt.reconcile(t, 1, now)
+			vt.expectReleased(t, g, 1300)
+
+			txns, err = vt.repo.FindTxns(ctx, vt.claims, vt.created.ID)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFindTxns failed.", tests.Failed)
+			}
+			for _, m := range txns {
+				if m.Type == CreatedAssetTxnType_Transfer && m.Status != CreatedAssetTxnStatus_Confirmed {
+					t.Logf("\t\tGot : %s %s", m.Status, m.Error)
+					t.Fatalf("\t%s\tExpected the transfer to be confirmed.", tests.Failed)
+				}
+			}
+			t.Logf("\t%s\tLost response ok.", tests.Success)
+		}
+
+		t.Log("\tWhen the transfer from a reserve signed offline expires.")
+		{
+			reserve := crypto.GenerateAccount().Address.String()
+			vt := newVestingTest(t, srv, func(creator string) string { return reserve }, now)
+			g := vt.grant(t, now)
+			grantReq := CreatedAssetVestingGrantRequest{CreatedAssetID: vt.created.ID, ID: g.ID}
+
+			txn, err := vt.repo.ReleaseVestingGrant(ctx, vt.claims, grantReq, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tReleaseVestingGrant failed.", tests.Failed)
+			} else if txn.Status != CreatedAssetTxnStatus_Draft {
+				t.Logf("\t\tGot : %s", txn.Status)
+				t.Fatalf("\t%s\tExpected the transfer to be left for the reserve to sign.", tests.Failed)
+			}
+
+			// The draft counts as released until it expires.
+			vt.expectReleased(t, g, 1300)
+			_, err = vt.repo.ReleaseVestingGrant(ctx, vt.claims, grantReq, now)
+			if errors.Cause(err) != ErrNothingToRelease {
+				t.Logf("\t\tGot : %+v", err)
+				t.Logf("\t\tWant: %+v", ErrNothingToRelease)
+				t.Fatalf("\t%s\tReleaseVestingGrant with a pending draft should fail.", tests.Failed)
+			}
+
+			vt.reconcile(t, txn.LastValidRound-srv.Round()+1, now)
+			vt.expectReleased(t, g, 0)
+			t.Logf("\t%s\tDraft expired ok.", tests.Success)
+		}
+	}
+}
+
+// TestVestingGrantConcurrent validates grants created at the same time don't exceed the
+// total of the created asset.
+func TestVestingGrantConcurrent(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.June, 6, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	srv := algodtest.NewServer()
+	defer srv.Close()
+
+	vt := newVestingTest(t, srv, func(creator string) string { return creator }, now)
+
+	t.Log("Given the need to not grant more units than the total of the asset.")
+	{
+		// Only 3 grants of 3000 units fit in the total of 10000 units.
+		const attempts = 6
+
+		errs := make(chan error, attempts)
+		for i := 0; i < attempts; i++ {
+			go func() {
+				_, err := vt.repo.CreateVestingGrant(ctx, vt.claims, CreatedAssetVestingGrantCreateRequest{
+					CreatedAssetID: vt.created.ID,
+					UserID:         vt.userID,
+					Address:        vt.holder.Address.String(),
+					Total:          3000,
+					StartDate:      now,
+					PeriodMonths:   12,
+					IntervalMonths: 1,
+				}, now)
+				errs <- err
+			}()
+		}
+
+		var created int
+		for i := 0; i < attempts; i++ {
+			err := <-errs
+			switch errors.Cause(err) {
+			case nil:
+				created++
+			case ErrVestingTotalExceeded:
+			default:
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tCreateVestingGrant failed.", tests.Failed)
+			}
+		}
+
+		grants, err := vt.repo.FindVestingGrants(ctx, vt.claims, vt.created.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tFindVestingGrants failed.", tests.Failed)
+		} else if created != 3 || len(grants) != 3 {
+			t.Logf("\t\tGot : %d created, %d found", created, len(grants))
+			t.Logf("\t\tWant: %d", 3)
+			t.Fatalf("\t%s\tExpected only the grants within the total.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreateVestingGrant concurrent ok.", tests.Success)
+	}
+}
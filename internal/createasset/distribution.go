@@ -0,0 +1,167 @@
+package createasset
+
+import (
+	"context"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* A holder can only receive units of a created asset after accepting it with
+an opt-in transaction, a transfer of zero units to themselves. The investor
+signs the opt-in offline as Exitor does not hold their key, then an admin of
+the issuer sends units of the asset to the address. Both transactions are
+recorded in created_asset_txns against the receiving user. */
+
+var (
+	// ErrNotOptedIn occurs when units of a created asset are sent to an address that has
+	// not accepted the asset.
+	ErrNotOptedIn = errors.New("Address has not opted in to the created asset")
+)
+
+// OptIn prepares an opt-in transaction for the user to accept the created asset with the
+// address. Unless the signer of the repository holds the key of the address, the draft
+// is returned to be signed offline by the user. A pending or confirmed opt-in for the
+// address is returned instead of preparing a new one.
+func (repo *Repository) OptIn(ctx context.Context, claims auth.Claims, req CreatedAssetOptInRequest, now time.Time) (*CreatedAssetTxn, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.OptIn")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can read the created asset specified in the request.
+	err = repo.CanReadCreatedAsset(ctx, claims, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Users can only opt in for themselves, users that distribute the asset can prepare the
+	// opt-in for any user.
+	if claims.Audience != "" && req.UserID != claims.Subject && !claims.HasPermission(auth.PermissionAssetTransfer) {
+		return nil, errors.WithStack(ErrForbidden)
+	}
+
+	m, err := repo.mintedAsset(ctx, claims, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := repo.findOptIn(ctx, req.ID, req.UserID, req.Address,
+		CreatedAssetTxnStatus_Submitted, CreatedAssetTxnStatus_Confirmed)
+	if err != nil {
+		return nil, err
+	} else if existing != nil {
+		return existing, nil
+	}
+
+	t := &CreatedAssetTxn{
+		UserID:        &req.UserID,
+		Type:          CreatedAssetTxnType_OptIn,
+		SenderAddress: req.Address,
+		TargetAddress: req.Address,
+	}
+
+	return repo.issueTxn(ctx, m, t, func(params types.SuggestedParams) (types.Transaction, error) {
+		return future.MakeAssetAcceptanceTxn(t.SenderAddress, nil, params, m.AssetIndex)
+	}, now)
+}
+
+// Transfer sends units of the created asset from the creator to an address the user has
+// opted in with. Assets that are frozen by default can only be sent to users that are
+// whitelisted and must also be unfrozen for the address before the network will accept
+// the transfer.
+func (repo *Repository) Transfer(ctx context.Context, claims auth.Claims, req CreatedAssetTransferRequest, now time.Time) (*CreatedAssetTxn, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Transfer")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.manageableAsset(ctx, claims, req.ID, auth.PermissionAssetTransfer)
+	if err != nil {
+		return nil, err
+	}
+
+	optIn, err := repo.findOptIn(ctx, req.ID, req.UserID, req.Address, CreatedAssetTxnStatus_Confirmed)
+	if err != nil {
+		return nil, err
+	} else if optIn == nil {
+		return nil, errors.WithMessagef(ErrNotOptedIn, "address %s of user %s", req.Address, req.UserID)
+	}
+
+	err = repo.checkWhitelisted(ctx, m, req.UserID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// All the units of an asset are held by the creator when it's minted.
+	t := &CreatedAssetTxn{
+		UserID:          &req.UserID,
+		Type:            CreatedAssetTxnType_Transfer,
+		SenderAddress:   m.CreatorAddress,
+		ReceiverAddress: req.Address,
+		Amount:          req.Amount,
+	}
+
+	return repo.issueTxn(ctx, m, t, func(params types.SuggestedParams) (types.Transaction, error) {
+		return future.MakeAssetTransferTxn(t.SenderAddress, t.ReceiverAddress, t.Amount, nil, params, "", m.AssetIndex)
+	}, now)
+}
+
+// FindUserTxns gets the opt-in and transfer transactions of the created asset for the
+// receiving user, most recent first.
+func (repo *Repository) FindUserTxns(ctx context.Context, claims auth.Claims, createdAssetID, userID string) (CreatedAssetTxns, error) {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("created_asset_id", createdAssetID),
+		query.Equal("user_id", userID),
+	))
+	query.OrderBy("created_at desc")
+
+	return findTxns(ctx, claims, repo.DbConn, query)
+}
+
+// findOptIn returns the most recent opt-in of the user to the created asset with the
+// address that has one of the statuses.
+func (repo *Repository) findOptIn(ctx context.Context, createdAssetID, userID, address string, statuses ...CreatedAssetTxnStatus) (*CreatedAssetTxn, error) {
+	var in []interface{}
+	for _, s := range statuses {
+		in = append(in, s.String())
+	}
+
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("created_asset_id", createdAssetID),
+		query.Equal("user_id", userID),
+		query.Equal("type", CreatedAssetTxnType_OptIn.String()),
+		query.Equal("sender_address", address),
+		query.In("status", in...),
+	))
+	query.OrderBy("created_at desc")
+	query.Limit(1)
+
+	res, err := findTxns(ctx, auth.Claims{}, repo.DbConn, query)
+	if err != nil {
+		return nil, err
+	} else if len(res) == 0 {
+		return nil, nil
+	}
+
+	return res[0], nil
+}
@@ -0,0 +1,342 @@
+package createasset
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"time"
+
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* Issuing units of a created asset for cash is an atomic group of two
+transactions, the payment from the investor to the creator in ALGO or an
+asset like USDC and the transfer of the units from the creator to the
+investor. The network confirms both or neither. Each member is recorded in
+created_asset_txns with the ID of the group and is signed by its own sender,
+Exitor signs the members it holds the key for and the rest are signed
+offline. Signed members are held until the whole group is signed and then
+the group is broadcast together. A group that can't be signed, or isn't
+signed before its last valid round, fails as a whole. */
+
+var (
+	// ErrGroupNotSigned occurs when a group is submitted before all of its members are signed.
+	ErrGroupNotSigned = errors.New("Not all transactions of the group have been signed")
+)
+
+// Issue prepares an atomic group that transfers units of the created asset to an address the
+// user has opted in with and the payment for them from the same address to the creator. The
+// transfer is signed by Exitor when it holds the key of the creator, the payment is left as a
+// draft to be signed offline by the investor unless Exitor holds the key for it too.
+func (repo *Repository) Issue(ctx context.Context, claims auth.Claims, req CreatedAssetIssueRequest, now time.Time) (CreatedAssetTxns, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Issue")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.manageableAsset(ctx, claims, req.ID, auth.PermissionAssetTransfer)
+	if err != nil {
+		return nil, err
+	}
+
+	optIn, err := repo.findOptIn(ctx, req.ID, req.UserID, req.Address, CreatedAssetTxnStatus_Confirmed)
+	if err != nil {
+		return nil, err
+	} else if optIn == nil {
+		return nil, errors.WithMessagef(ErrNotOptedIn, "address %s of user %s", req.Address, req.UserID)
+	}
+
+	err = repo.checkWhitelisted(ctx, m, req.UserID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := repo.AlgoClient.Network(m.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := network.SuggestedParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &CreatedAssetTxn{
+		UserID:          &req.UserID,
+		Type:            CreatedAssetTxnType_Payment,
+		SenderAddress:   req.Address,
+		ReceiverAddress: m.CreatorAddress,
+		Amount:          req.PaymentAmount,
+		PaymentAssetID:  req.PaymentAssetID,
+	}
+
+	var paymentTx types.Transaction
+	if payment.PaymentAssetID > 0 {
+		paymentTx, err = future.MakeAssetTransferTxn(payment.SenderAddress, payment.ReceiverAddress, payment.Amount, nil, params, "", payment.PaymentAssetID)
+	} else {
+		paymentTx, err = future.MakePaymentTxn(payment.SenderAddress, payment.ReceiverAddress, payment.Amount, nil, "", params)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to make asset %s transaction", payment.Type)
+	}
+
+	// All the units of an asset are held by the creator when it's minted.
+	transfer := &CreatedAssetTxn{
+		UserID:          &req.UserID,
+		Type:            CreatedAssetTxnType_Transfer,
+		SenderAddress:   m.CreatorAddress,
+		ReceiverAddress: req.Address,
+		Amount:          req.Amount,
+	}
+
+	transferTx, err := future.MakeAssetTransferTxn(transfer.SenderAddress, transfer.ReceiverAddress, transfer.Amount, nil, params, "", m.AssetIndex)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to make asset %s transaction", transfer.Type)
+	}
+
+	group := CreatedAssetTxns{payment, transfer}
+	txns := []types.Transaction{paymentTx, transferTx}
+
+	gid, err := crypto.ComputeGroupID(txns)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compute group ID")
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	for i, t := range group {
+		txns[i].Group = gid
+		setDraftTxn(m, t, txns[i], now)
+		t.GroupID = base64.StdEncoding.EncodeToString(gid[:])
+		t.GroupIndex = i
+
+		err = repo.setMultisigTxn(ctx, t, txns[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Record all the members of the group or none of them.
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	for _, t := range group {
+		err = insertTxn(ctx, dbTx, t)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = dbTx.Commit()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Sign the members Exitor holds the keys for, the group is submitted once the last
+	// member is signed.
+	for i, t := range group {
+		stx, err := repo.signer().SignTransaction(ctx, txns[i])
+		if err != nil {
+			switch errors.Cause(err) {
+			case algosdk.ErrExternalSigningRequired, algosdk.ErrSignerAccountNotFound:
+				// Leave the transaction as a draft to be signed offline.
+				continue
+			}
+
+			// The group can't be completed without this member.
+			if ferr := repo.failGroupTxns(ctx, group, err, now); ferr != nil {
+				return nil, ferr
+			}
+			return nil, err
+		}
+
+		err = repo.signGroupTxn(ctx, network, t, stx, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return repo.FindGroupTxns(ctx, claims, m.ID, payment.GroupID)
+}
+
+// failGroupTxns marks the draft members of the group as failed so the group is not left
+// waiting for a member that will never be signed.
+func (repo *Repository) failGroupTxns(ctx context.Context, group CreatedAssetTxns, cause error, now time.Time) error {
+	for _, t := range group {
+		_, err := updateTxnStatus(ctx, repo.DbConn, t.ID, txnStatusUpdate{
+			From:  []CreatedAssetTxnStatus{CreatedAssetTxnStatus_Draft},
+			To:    CreatedAssetTxnStatus_Failed,
+			Error: cause.Error(),
+		}, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindGroupTxns gets the members of the group of created asset transactions in the order
+// they are submitted.
+func (repo *Repository) FindGroupTxns(ctx context.Context, claims auth.Claims, createdAssetID, groupID string) (CreatedAssetTxns, error) {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("created_asset_id", createdAssetID),
+		query.Equal("group_id", groupID),
+	))
+	query.OrderBy("group_index asc")
+
+	res, err := findTxns(ctx, claims, repo.DbConn, query)
+	if err != nil {
+		return nil, err
+	} else if len(res) == 0 {
+		return nil, errors.WithMessagef(ErrNotFound, "created asset transaction group %s not found", groupID)
+	}
+
+	return res, nil
+}
+
+// signGroupTxn holds the signed member of a group until the rest of the members are signed.
+// Once the last member is signed the whole group is submitted.
+func (repo *Repository) signGroupTxn(ctx context.Context, network *algosdk.Network, t *CreatedAssetTxn, stx []byte, now time.Time) error {
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	// Build the update SQL statement, the member can be signed again until it's submitted.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(CreatedAssetTxnTableName)
+	query.Set(
+		query.Assign("signed_txn", stx),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.And(
+		query.Equal("id", t.ID),
+		query.Equal("status", CreatedAssetTxnStatus_Draft.String()),
+	))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	res, err := repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "store signed transaction for created asset transaction %s failed", t.ID)
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.WithStack(err)
+	} else if n == 0 {
+		return errors.WithMessagef(ErrInvalidTxnStatus, "created asset transaction %s was already submitted", t.ID)
+	}
+
+	group, err := repo.FindGroupTxns(ctx, auth.Claims{}, t.CreatedAssetID, t.GroupID)
+	if err != nil {
+		return err
+	}
+
+	for _, gt := range group {
+		if len(gt.SignedTxn) == 0 {
+			// Wait for the rest of the members to be signed.
+			return nil
+		}
+	}
+
+	return repo.submitGroup(ctx, network, group, now)
+}
+
+// submitGroup marks all the members of the group as submitted and broadcasts the signed
+// members to the network together. The network either confirms all of them or none.
+func (repo *Repository) submitGroup(ctx context.Context, network *algosdk.Network, group CreatedAssetTxns, now time.Time) error {
+	var signed bytes.Buffer
+	for _, t := range group {
+		if len(t.SignedTxn) == 0 {
+			return errors.WithMessagef(ErrGroupNotSigned, "created asset transaction %s", t.ID)
+		}
+		signed.Write(t.SignedTxn)
+	}
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	for _, t := range group {
+		ok, err := updateTxnStatus(ctx, dbTx, t.ID, txnStatusUpdate{
+			From:             []CreatedAssetTxnStatus{CreatedAssetTxnStatus_Draft},
+			To:               CreatedAssetTxnStatus_Submitted,
+			ClearUnsignedTxn: true,
+		}, now)
+		if err != nil {
+			return err
+		} else if !ok {
+			return errors.WithMessagef(ErrInvalidTxnStatus, "created asset transaction %s was already submitted", t.ID)
+		}
+	}
+
+	err = dbTx.Commit()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := network.SendRawTransaction(ctx, signed.Bytes()); err != nil {
+		// Any other error, like a timeout, doesn't tell whether the node accepted the
+		// group. The members stay submitted until they're reconciled past their last
+		// valid round so the investor can't be issued units twice.
+		if errors.Cause(err) != algosdk.ErrTransactionRejected {
+			return err
+		}
+
+		// The node refused the group so none of the members will be confirmed.
+		for _, t := range group {
+			_, uerr := updateTxnStatus(ctx, repo.DbConn, t.ID, txnStatusUpdate{
+				From:  []CreatedAssetTxnStatus{CreatedAssetTxnStatus_Submitted},
+				To:    CreatedAssetTxnStatus_Failed,
+				Error: err.Error(),
+			}, now)
+			if uerr != nil {
+				return uerr
+			}
+		}
+		return err
+	}
+
+	return nil
+}
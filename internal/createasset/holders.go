@@ -0,0 +1,248 @@
+package createasset
+
+import (
+	"context"
+	"time"
+
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/algorand/go-algorand-sdk/client/v2/common/models"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* The holders of a created asset are read from the indexer of its network and
+stored in created_asset_holders as a snapshot, the cap table of the asset. Each
+sync replaces the snapshot with the balances at the round reported by the
+indexer so the registry can be displayed without querying the network. */
+
+const (
+	// The database table for created asset holders
+	CreatedAssetHolderTableName = "created_asset_holders"
+
+	// holderPageLimit is the number of balances requested from the indexer per page.
+	holderPageLimit = 1000
+)
+
+// SyncHolders replaces the snapshot of the holders of the created asset with the
+// balances reported by the indexer. Addresses that have opted out of the asset since
+// the last snapshot are removed.
+func (repo *Repository) SyncHolders(ctx context.Context, claims auth.Claims, id string, now time.Time) (CreatedAssetHolders, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.SyncHolders")
+	defer span.Finish()
+
+	// Ensure the claims can refresh the holders of the created asset specified in the request.
+	err := repo.CanModifyCreatedAsset(ctx, claims, id, auth.PermissionAssetRead)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.ReadByID(ctx, claims, id)
+	if err != nil {
+		return nil, err
+	} else if m.AssetIndex == 0 {
+		return nil, errors.WithMessagef(ErrAssetNotMinted, "created asset %s is %s", m.ID, m.MintStatus)
+	}
+
+	network, err := repo.AlgoClient.Network(m.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, round, err := assetBalances(ctx, network, m.AssetIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	for _, b := range balances {
+		// Build the insert SQL statement.
+		query := sqlbuilder.NewInsertBuilder()
+		query.InsertInto(CreatedAssetHolderTableName)
+		query.Cols("id", "created_asset_id", "account_id", "address", "amount", "frozen", "opted_in_round", "round",
+			"created_at", "updated_at")
+		query.Values(uuid.NewRandom().String(), m.ID, m.AccountID, b.Address, b.Amount, b.IsFrozen, b.OptedInAtRound, round,
+			now, now)
+
+		// Execute the query with the provided context.
+		sql, args := query.Build()
+		sql = dbTx.Rebind(sql)
+
+		sql = sql + " ON CONFLICT (created_asset_id, address) DO UPDATE set amount = EXCLUDED.amount, frozen = EXCLUDED.frozen, " +
+			"opted_in_round = EXCLUDED.opted_in_round, round = EXCLUDED.round, updated_at = EXCLUDED.updated_at "
+
+		_, err = dbTx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			err = errors.WithMessagef(err, "store holder %s of created asset %s failed", b.Address, m.ID)
+			return nil, err
+		}
+	}
+
+	// Remove the holders that were not included in the snapshot.
+	query := sqlbuilder.NewDeleteBuilder()
+	query.DeleteFrom(CreatedAssetHolderTableName)
+	query.Where(query.And(
+		query.Equal("created_asset_id", m.ID),
+		query.NotEqual("round", round),
+	))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = dbTx.Rebind(sql)
+	_, err = dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "remove previous holders of created asset %s failed", m.ID)
+		return nil, err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return repo.FindHolders(ctx, claims, CreatedAssetHolderFindRequest{
+		CreatedAssetID: m.ID,
+		Order:          []string{"amount desc", "address"},
+	})
+}
+
+// createdAssetHolderMapColumns is the list of columns needed for find.
+var createdAssetHolderMapColumns = "id,created_asset_id,account_id,address,amount,frozen,opted_in_round,round,created_at,updated_at"
+
+// FindHolders gets the holders of the created asset from the last snapshot.
+func (repo *Repository) FindHolders(ctx context.Context, claims auth.Claims, req CreatedAssetHolderFindRequest) (CreatedAssetHolders, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.FindHolders")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	query := sqlbuilder.NewSelectBuilder()
+	query.Select(createdAssetHolderMapColumns)
+	query.From(CreatedAssetHolderTableName)
+
+	// The args of the request are for the where added first.
+	if req.Where != "" {
+		query.Where(query.And(req.Where))
+	}
+	query.Where(query.Equal("created_asset_id", req.CreatedAssetID))
+
+	if len(req.Order) > 0 {
+		query.OrderBy(req.Order...)
+	}
+	if req.Limit != nil {
+		query.Limit(int(*req.Limit))
+	}
+	if req.Offset != nil {
+		query.Offset(int(*req.Offset))
+	}
+
+	return findHolders(ctx, claims, repo.DbConn, query, req.Args)
+}
+
+// findHolders internal method for getting the created asset holders from the database
+// using a select query.
+func findHolders(ctx context.Context, claims auth.Claims, dbConn *sqlx.DB, query *sqlbuilder.SelectBuilder, args []interface{}) (CreatedAssetHolders, error) {
+	// Check to see if a sub query needs to be applied for the claims
+	err := applyClaimsSelect(ctx, claims, query)
+	if err != nil {
+		return nil, err
+	}
+
+	queryStr, queryArgs := query.Build()
+	queryStr = dbConn.Rebind(queryStr)
+	args = append(args, queryArgs...)
+
+	// Fetch all entries from the db.
+	rows, err := dbConn.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find created asset holders failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Iterate over each row.
+	resp := []*CreatedAssetHolder{}
+	for rows.Next() {
+		var h CreatedAssetHolder
+		err = rows.Scan(&h.ID, &h.CreatedAssetID, &h.AccountID, &h.Address, &h.Amount, &h.Frozen, &h.OptedInRound,
+			&h.Round, &h.CreatedAt, &h.UpdatedAt)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+
+		resp = append(resp, &h)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find created asset holders failed")
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// assetBalances reads all the pages of the balances of the asset from the indexer of the
+// network and the round they were reported at. Addresses that have opted out are excluded.
+func assetBalances(ctx context.Context, network *algosdk.Network, assetIndex uint64) ([]models.MiniAssetHolding, uint64, error) {
+	var (
+		balances []models.MiniAssetHolding
+		round    uint64
+		next     string
+	)
+	for {
+		res, err := network.AssetBalances(ctx, assetIndex, holderPageLimit, next)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// The snapshot is taken at the round of the first page.
+		if round == 0 {
+			round = res.CurrentRound
+		}
+
+		for _, b := range res.Balances {
+			if !b.Deleted {
+				balances = append(balances, b)
+			}
+		}
+
+		if res.NextToken == "" || len(res.Balances) == 0 {
+			break
+		}
+		next = res.NextToken
+	}
+
+	return balances, round, nil
+}
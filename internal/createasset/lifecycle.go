@@ -0,0 +1,955 @@
+package createasset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* Once minted, a created asset is managed with asset config, freeze,
+revocation (clawback) and destroy transactions. Each transaction is sent
+from the address of the asset with the authority for it and is recorded in
+created_asset_txns before it's submitted. When the signer of the repository
+can't sign for the address the transaction is left as a draft to be signed
+offline. The effects on the created asset are applied once the network has
+confirmed the transaction. */
+
+const (
+	// The database table for created asset transactions
+	CreatedAssetTxnTableName = "created_asset_txns"
+)
+
+var (
+	// ErrAssetNotMinted occurs when a created asset is managed before it has been confirmed
+	// on the network.
+	ErrAssetNotMinted = errors.New("Created asset has not been minted")
+
+	// ErrAssetDestroyed occurs when a created asset is managed after it was destroyed.
+	ErrAssetDestroyed = errors.New("Created asset has been destroyed")
+
+	// ErrAssetAddressNotSet occurs when the address with the authority for a transaction
+	// has not been set or was cleared for the created asset.
+	ErrAssetAddressNotSet = errors.New("Created asset address is not set")
+
+	// ErrInvalidTxnStatus occurs when a created asset transaction is not in the status
+	// required for the requested action.
+	ErrInvalidTxnStatus = errors.New("Invalid status for created asset transaction")
+)
+
+// Reconfigure submits an asset config transaction from the manager to change the
+// addresses of the created asset.
+func (repo *Repository) Reconfigure(ctx context.Context, claims auth.Claims, req CreatedAssetConfigRequest, now time.Time) (*CreatedAssetTxn, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Reconfigure")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.manageableAsset(ctx, claims, req.ID, auth.PermissionAssetManage)
+	if err != nil {
+		return nil, err
+	} else if m.ManagerAddress == "" {
+		return nil, errors.WithMessagef(ErrAssetAddressNotSet, "created asset %s has no manager, it can't be reconfigured", m.ID)
+	}
+
+	t := &CreatedAssetTxn{
+		Type:            CreatedAssetTxnType_Config,
+		SenderAddress:   m.ManagerAddress,
+		ManagerAddress:  m.ManagerAddress,
+		ReserveAddress:  m.ReserveAddress,
+		FreezeAddress:   m.FreezeAddress,
+		ClawbackAddress: m.ClawbackAddress,
+	}
+	if req.ManagerAddress != nil {
+		t.ManagerAddress = *req.ManagerAddress
+	}
+	if req.ReserveAddress != nil {
+		t.ReserveAddress = *req.ReserveAddress
+	}
+	if req.FreezeAddress != nil {
+		t.FreezeAddress = *req.FreezeAddress
+	}
+	if req.ClawbackAddress != nil {
+		t.ClawbackAddress = *req.ClawbackAddress
+	}
+
+	// ARC-69 uses the note of the latest config transaction, so the metadata is included again.
+	note, err := m.metadataNote()
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.issueTxn(ctx, m, t, func(params types.SuggestedParams) (types.Transaction, error) {
+		return future.MakeAssetConfigTxn(t.SenderAddress, note, params, m.AssetIndex,
+			t.ManagerAddress, t.ReserveAddress, t.FreezeAddress, t.ClawbackAddress, false)
+	}, now)
+}
+
+// Freeze submits an asset freeze transaction from the freeze address to freeze or
+// unfreeze the created asset for a holder.
+func (repo *Repository) Freeze(ctx context.Context, claims auth.Claims, req CreatedAssetFreezeRequest, now time.Time) (*CreatedAssetTxn, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Freeze")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.manageableAsset(ctx, claims, req.ID, auth.PermissionAssetFreeze)
+	if err != nil {
+		return nil, err
+	} else if m.FreezeAddress == "" {
+		return nil, errors.WithMessagef(ErrAssetAddressNotSet, "created asset %s has no freeze address, it can't be frozen", m.ID)
+	}
+
+	t := &CreatedAssetTxn{
+		Type:          CreatedAssetTxnType_Freeze,
+		SenderAddress: m.FreezeAddress,
+		TargetAddress: req.Address,
+		Frozen:        req.Frozen,
+	}
+
+	return repo.issueTxn(ctx, m, t, func(params types.SuggestedParams) (types.Transaction, error) {
+		return future.MakeAssetFreezeTxn(t.SenderAddress, nil, params, m.AssetIndex, t.TargetAddress, t.Frozen)
+	}, now)
+}
+
+// Clawback submits an asset revocation transaction from the clawback address to move
+// units of the created asset from a holder back to the reserve or the receiver.
+func (repo *Repository) Clawback(ctx context.Context, claims auth.Claims, req CreatedAssetClawbackRequest, now time.Time) (*CreatedAssetTxn, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Clawback")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.manageableAsset(ctx, claims, req.ID, auth.PermissionAssetFreeze)
+	if err != nil {
+		return nil, err
+	} else if m.ClawbackAddress == "" {
+		return nil, errors.WithMessagef(ErrAssetAddressNotSet, "created asset %s has no clawback address, it can't be revoked", m.ID)
+	}
+
+	t := &CreatedAssetTxn{
+		Type:            CreatedAssetTxnType_Clawback,
+		SenderAddress:   m.ClawbackAddress,
+		TargetAddress:   req.Address,
+		ReceiverAddress: req.ReceiverAddress,
+		Amount:          req.Amount,
+	}
+	if t.ReceiverAddress == "" {
+		t.ReceiverAddress = m.ReserveAddress
+	}
+	if t.ReceiverAddress == "" {
+		t.ReceiverAddress = m.CreatorAddress
+	}
+
+	return repo.issueTxn(ctx, m, t, func(params types.SuggestedParams) (types.Transaction, error) {
+		return future.MakeAssetRevocationTxn(t.SenderAddress, t.TargetAddress, t.Amount, t.ReceiverAddress, nil, params, m.AssetIndex)
+	}, now)
+}
+
+// Destroy submits an asset destroy transaction from the manager. The created asset is
+// marked as destroyed once the transaction is confirmed.
+func (repo *Repository) Destroy(ctx context.Context, claims auth.Claims, req CreatedAssetDestroyRequest, now time.Time) (*CreatedAssetTxn, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Destroy")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.manageableAsset(ctx, claims, req.ID, auth.PermissionAssetManage)
+	if err != nil {
+		return nil, err
+	} else if m.ManagerAddress == "" {
+		return nil, errors.WithMessagef(ErrAssetAddressNotSet, "created asset %s has no manager, it can't be destroyed", m.ID)
+	}
+
+	t := &CreatedAssetTxn{
+		Type:          CreatedAssetTxnType_Destroy,
+		SenderAddress: m.ManagerAddress,
+	}
+
+	return repo.issueTxn(ctx, m, t, func(params types.SuggestedParams) (types.Transaction, error) {
+		return future.MakeAssetDestroyTxn(t.SenderAddress, nil, params, m.AssetIndex)
+	}, now)
+}
+
+// manageableAsset ensures the claims have the permission to modify the created asset and
+// that it exists on the network.
+func (repo *Repository) manageableAsset(ctx context.Context, claims auth.Claims, id, permission string) (*CreatedAsset, error) {
+	// Ensure the claims can modify the created asset specified in the request.
+	err := repo.CanModifyCreatedAsset(ctx, claims, id, permission)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.mintedAsset(ctx, claims, id)
+}
+
+// mintedAsset reads the created asset and ensures it exists on the network.
+func (repo *Repository) mintedAsset(ctx context.Context, claims auth.Claims, id string) (*CreatedAsset, error) {
+	m, err := repo.ReadByID(ctx, claims, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.MintStatus != CreatedAssetMintStatus_Confirmed || m.AssetIndex == 0 {
+		return nil, errors.WithMessagef(ErrAssetNotMinted, "created asset %s is %s", m.ID, m.MintStatus)
+	} else if m.DestroyedAt != nil && m.DestroyedAt.Valid {
+		return nil, errors.WithMessagef(ErrAssetDestroyed, "created asset %s", m.ID)
+	}
+
+	return m, nil
+}
+
+// issueTxn builds the transaction for the created asset, records it as a draft and
+// submits it when the signer of the repository can sign for the sender. Otherwise the
+// draft is returned so it can be signed offline.
+func (repo *Repository) issueTxn(ctx context.Context, m *CreatedAsset, t *CreatedAssetTxn, build func(params types.SuggestedParams) (types.Transaction, error), now time.Time) (*CreatedAssetTxn, error) {
+	network, tx, err := repo.draftTxn(ctx, m, t, build, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertTxn(ctx, repo.DbConn, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.signTxn(ctx, network, t, tx, now)
+}
+
+// draftTxn builds the transaction for the created asset and sets the fields of the draft
+// created asset transaction for it. The draft is not recorded.
+func (repo *Repository) draftTxn(ctx context.Context, m *CreatedAsset, t *CreatedAssetTxn, build func(params types.SuggestedParams) (types.Transaction, error), now time.Time) (*algosdk.Network, types.Transaction, error) {
+	network, err := repo.AlgoClient.Network(m.Network)
+	if err != nil {
+		return nil, types.Transaction{}, err
+	}
+
+	params, err := network.SuggestedParams(ctx)
+	if err != nil {
+		return nil, types.Transaction{}, err
+	}
+
+	tx, err := build(params)
+	if err != nil {
+		return nil, types.Transaction{}, errors.Wrapf(err, "failed to make asset %s transaction", t.Type)
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	setDraftTxn(m, t, tx, now)
+
+	err = repo.setMultisigTxn(ctx, t, tx)
+	if err != nil {
+		return nil, types.Transaction{}, err
+	}
+
+	return network, tx, nil
+}
+
+// signTxn submits the recorded draft when the signer of the repository can sign for the
+// sender. Otherwise the draft is returned so it can be signed offline.
+func (repo *Repository) signTxn(ctx context.Context, network *algosdk.Network, t *CreatedAssetTxn, tx types.Transaction, now time.Time) (*CreatedAssetTxn, error) {
+	stx, err := repo.signer().SignTransaction(ctx, tx)
+	if err != nil {
+		switch errors.Cause(err) {
+		case algosdk.ErrExternalSigningRequired, algosdk.ErrSignerAccountNotFound:
+			// Leave the transaction as a draft to be signed offline.
+			return t, nil
+		}
+		return nil, err
+	}
+
+	return repo.submitTxn(ctx, network, t, stx, now)
+}
+
+// setDraftTxn sets the fields of the created asset transaction for the unsigned transaction
+// built for the created asset.
+func setDraftTxn(m *CreatedAsset, t *CreatedAssetTxn, tx types.Transaction, now time.Time) {
+	t.ID = uuid.NewRandom().String()
+	t.CreatedAssetID = m.ID
+	t.AccountID = m.AccountID
+	t.Status = CreatedAssetTxnStatus_Draft
+	t.TxID = crypto.TransactionIDString(tx)
+	t.LastValidRound = uint64(tx.LastValid)
+	t.UnsignedTxn = msgpack.Encode(tx)
+	t.CreatedAt = now
+	t.UpdatedAt = now
+}
+
+// insertTxn records the created asset transaction in the database.
+func insertTxn(ctx context.Context, dbConn sqlx.ExtContext, t *CreatedAssetTxn) error {
+	// Build the insert SQL statement.
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(CreatedAssetTxnTableName)
+	query.Cols("id", "created_asset_id", "account_id", "user_id", "type", "sender_address", "target_address", "receiver_address", "amount",
+		"frozen", "manager_address", "reserve_address", "freeze_address", "clawback_address", "payment_asset_id", "group_id", "group_index",
+		"status", "error", "tx_id", "last_valid_round", "confirmed_round", "unsigned_txn", "signed_txn", "multisig_txn", "created_at", "updated_at")
+	query.Values(t.ID, t.CreatedAssetID, t.AccountID, t.UserID, t.Type.String(), t.SenderAddress, t.TargetAddress, t.ReceiverAddress, t.Amount,
+		t.Frozen, t.ManagerAddress, t.ReserveAddress, t.FreezeAddress, t.ClawbackAddress, t.PaymentAssetID, t.GroupID, t.GroupIndex,
+		t.Status.String(), t.Error, t.TxID, t.LastValidRound, t.ConfirmedRound, t.UnsignedTxn, t.SignedTxn, t.MultisigTxn, t.CreatedAt, t.UpdatedAt)
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = dbConn.Rebind(sql)
+	_, err := dbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "create asset %s transaction for created asset %s failed", t.Type, t.CreatedAssetID)
+		return err
+	}
+
+	return nil
+}
+
+// signer returns the signer of the repository, transactions are signed offline when not set.
+func (repo *Repository) signer() algosdk.Signer {
+	if repo.Signer == nil {
+		return algosdk.NewExternalSigner()
+	}
+	return repo.Signer
+}
+
+// submitTxn marks the created asset transaction as submitted and broadcasts the signed
+// transaction to the network.
+func (repo *Repository) submitTxn(ctx context.Context, network *algosdk.Network, t *CreatedAssetTxn, stx []byte, now time.Time) (*CreatedAssetTxn, error) {
+	ok, err := updateTxnStatus(ctx, repo.DbConn, t.ID, txnStatusUpdate{
+		From:             []CreatedAssetTxnStatus{CreatedAssetTxnStatus_Draft},
+		To:               CreatedAssetTxnStatus_Submitted,
+		ClearUnsignedTxn: true,
+	}, now)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errors.WithMessagef(ErrInvalidTxnStatus, "created asset transaction %s was already submitted", t.ID)
+	}
+
+	if _, err := network.SendRawTransaction(ctx, stx); err != nil {
+		// Any other error, like a timeout, doesn't tell whether the node accepted the
+		// transaction. It stays submitted until it's reconciled past its last valid round.
+		if errors.Cause(err) != algosdk.ErrTransactionRejected {
+			return nil, err
+		}
+
+		// The node refused the transaction so it will never be confirmed.
+		_, uerr := updateTxnStatus(ctx, repo.DbConn, t.ID, txnStatusUpdate{
+			From:  []CreatedAssetTxnStatus{CreatedAssetTxnStatus_Submitted},
+			To:    CreatedAssetTxnStatus_Failed,
+			Error: err.Error(),
+		}, now)
+		if uerr != nil {
+			return nil, uerr
+		}
+		return nil, err
+	}
+
+	return repo.ReadTxn(ctx, auth.Claims{}, CreatedAssetTxnReadRequest{
+		CreatedAssetID: t.CreatedAssetID,
+		ID:             t.ID,
+	})
+}
+
+// createdAssetTxnMapColumns is the list of columns needed for find.
+var createdAssetTxnMapColumns = "id,created_asset_id,account_id,user_id,type,sender_address,target_address,receiver_address,amount,frozen," +
+	"manager_address,reserve_address,freeze_address,clawback_address,payment_asset_id,group_id,group_index,status,error,tx_id," +
+	"last_valid_round,confirmed_round,unsigned_txn,signed_txn,multisig_txn,created_at,updated_at"
+
+// FindTxns gets the transactions of the created asset, most recent first.
+func (repo *Repository) FindTxns(ctx context.Context, claims auth.Claims, createdAssetID string) (CreatedAssetTxns, error) {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("created_asset_id", createdAssetID))
+	query.OrderBy("created_at desc")
+
+	return findTxns(ctx, claims, repo.DbConn, query)
+}
+
+// applyTxnClaimsSelect applies a sub-query to the provided query to enforce ACL based on
+// the claims provided.
+//  1. No claims, request is internal, no ACL applied
+//  2. Users that can read all assets can access the transactions of assets of their account
+//  3. All other users can only access the transactions they are the receiving user of
+func applyTxnClaimsSelect(ctx context.Context, claims auth.Claims, query *sqlbuilder.SelectBuilder) error {
+	err := applyClaimsSelect(ctx, claims, query)
+	if err != nil {
+		return err
+	}
+
+	if claims.Audience != "" && !claims.HasPermission(auth.PermissionAssetRead) {
+		query.Where(query.Equal("user_id", claims.Subject))
+	}
+
+	return nil
+}
+
+// findTxns internal method for getting the created asset transactions from the database
+// using a select query.
+func findTxns(ctx context.Context, claims auth.Claims, dbConn *sqlx.DB, query *sqlbuilder.SelectBuilder) (CreatedAssetTxns, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.FindTxns")
+	defer span.Finish()
+
+	query.Select(createdAssetTxnMapColumns)
+	query.From(CreatedAssetTxnTableName)
+
+	// Check to see if a sub query needs to be applied for the claims
+	err := applyTxnClaimsSelect(ctx, claims, query)
+	if err != nil {
+		return nil, err
+	}
+
+	queryStr, args := query.Build()
+	queryStr = dbConn.Rebind(queryStr)
+
+	// Fetch all entries from the db.
+	rows, err := dbConn.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find created asset transactions failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Iterate over each row.
+	resp := []*CreatedAssetTxn{}
+	for rows.Next() {
+		var t CreatedAssetTxn
+		err = rows.Scan(&t.ID, &t.CreatedAssetID, &t.AccountID, &t.UserID, &t.Type, &t.SenderAddress, &t.TargetAddress, &t.ReceiverAddress,
+			&t.Amount, &t.Frozen, &t.ManagerAddress, &t.ReserveAddress, &t.FreezeAddress, &t.ClawbackAddress, &t.PaymentAssetID,
+			&t.GroupID, &t.GroupIndex, &t.Status, &t.Error, &t.TxID, &t.LastValidRound, &t.ConfirmedRound, &t.UnsignedTxn, &t.SignedTxn, &t.MultisigTxn,
+			&t.CreatedAt, &t.UpdatedAt)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+
+		resp = append(resp, &t)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find created asset transactions failed")
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ReadTxn gets the specified created asset transaction from the database.
+func (repo *Repository) ReadTxn(ctx context.Context, claims auth.Claims, req CreatedAssetTxnReadRequest) (*CreatedAssetTxn, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.ReadTxn")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter base select query by id.
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("id", req.ID),
+		query.Equal("created_asset_id", req.CreatedAssetID),
+	))
+
+	res, err := findTxns(ctx, claims, repo.DbConn, query)
+	if err != nil {
+		return nil, err
+	} else if res == nil || len(res) == 0 {
+		err = errors.WithMessagef(ErrNotFound, "created asset transaction %s not found", req.ID)
+		return nil, err
+	}
+
+	return res[0], nil
+}
+
+// canSignTxn determines if claims has the authority to sign the created asset transaction
+// offline. Receiving users can only sign their own opt-in and payment transactions, all
+// others require the permission for the type of transaction.
+func (repo *Repository) canSignTxn(ctx context.Context, claims auth.Claims, t *CreatedAssetTxn) error {
+	switch t.Type {
+	case CreatedAssetTxnType_OptIn, CreatedAssetTxnType_Payment:
+		if claims.Audience != "" && t.UserID != nil && *t.UserID == claims.Subject {
+			return nil
+		}
+	}
+
+	return repo.CanModifyCreatedAsset(ctx, claims, t.CreatedAssetID, txnPermission(t.Type))
+}
+
+// txnPermission returns the permission required to issue a created asset transaction of the type.
+func txnPermission(txnType CreatedAssetTxnType) string {
+	switch txnType {
+	case CreatedAssetTxnType_Freeze, CreatedAssetTxnType_Clawback:
+		return auth.PermissionAssetFreeze
+	case CreatedAssetTxnType_OptIn, CreatedAssetTxnType_Transfer, CreatedAssetTxnType_Payment:
+		return auth.PermissionAssetTransfer
+	default:
+		return auth.PermissionAssetManage
+	}
+}
+
+// UnsignedAssetTxn returns the transaction of a draft created asset transaction so it can
+// be signed offline. For a multisig sender the signatures collected so far are included.
+func (repo *Repository) UnsignedAssetTxn(ctx context.Context, claims auth.Claims, req CreatedAssetTxnReadRequest) (*UnsignedTxn, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.UnsignedAssetTxn")
+	defer span.Finish()
+
+	t, err := repo.ReadTxn(ctx, claims, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can sign the created asset transaction.
+	err = repo.canSignTxn(ctx, claims, t)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Status != CreatedAssetTxnStatus_Draft || len(t.UnsignedTxn) == 0 {
+		return nil, errors.WithMessagef(ErrInvalidTxnStatus, "created asset transaction %s is %s", t.ID, t.Status)
+	}
+
+	// The members of a multisig sender append their signature to the ones collected so far.
+	if len(t.MultisigTxn) > 0 {
+		return &UnsignedTxn{
+			CreatedAssetID: t.CreatedAssetID,
+			TxID:           t.TxID,
+			LastValidRound: t.LastValidRound,
+			Multisig:       true,
+			Txn:            t.MultisigTxn,
+		}, nil
+	}
+
+	return &UnsignedTxn{
+		CreatedAssetID: t.CreatedAssetID,
+		TxID:           t.TxID,
+		LastValidRound: t.LastValidRound,
+		Txn:            t.UnsignedTxn,
+	}, nil
+}
+
+// SubmitSignedAssetTxn verifies the uploaded signed transaction is the draft created asset
+// transaction and is signed by its sender, then broadcasts it to the network. The member of
+// a group is held until all the members are signed and then the group is broadcast. The
+// signatures of the members of a multisig sender are merged until the threshold is met.
+func (repo *Repository) SubmitSignedAssetTxn(ctx context.Context, claims auth.Claims, req CreatedAssetTxnSignedRequest, now time.Time) (*CreatedAssetTxn, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.SubmitSignedAssetTxn")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := repo.ReadTxn(ctx, claims, CreatedAssetTxnReadRequest{
+		CreatedAssetID: req.CreatedAssetID,
+		ID:             req.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can sign the created asset transaction.
+	err = repo.canSignTxn(ctx, claims, t)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Status != CreatedAssetTxnStatus_Draft || len(t.UnsignedTxn) == 0 {
+		return nil, errors.WithMessagef(ErrInvalidTxnStatus, "created asset transaction %s is %s", t.ID, t.Status)
+	}
+
+	stx, err := DecodeSignedTxn(req.SignedTxn)
+	if err != nil {
+		return nil, err
+	}
+
+	if !stx.Msig.Blank() {
+		// Partial signatures are held until the threshold of the multisig is met.
+		merged, err := repo.mergeMultisigTxn(ctx, t, stx, now)
+		if err != nil {
+			return nil, err
+		} else if merged == nil {
+			return repo.ReadTxn(ctx, claims, CreatedAssetTxnReadRequest{
+				CreatedAssetID: t.CreatedAssetID,
+				ID:             t.ID,
+			})
+		}
+		stx = *merged
+	} else {
+		err = VerifySignedTxn(stx, t.UnsignedTxn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m, err := repo.ReadByID(ctx, claims, t.CreatedAssetID)
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := repo.AlgoClient.Network(m.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.GroupID != "" {
+		err = repo.signGroupTxn(ctx, network, t, msgpack.Encode(stx), now)
+		if err != nil {
+			return nil, err
+		}
+
+		return repo.ReadTxn(ctx, claims, CreatedAssetTxnReadRequest{
+			CreatedAssetID: t.CreatedAssetID,
+			ID:             t.ID,
+		})
+	}
+
+	return repo.submitTxn(ctx, network, t, msgpack.Encode(stx), now)
+}
+
+// ReconcileTxn checks the network for a submitted created asset transaction.
+func (repo *Repository) ReconcileTxn(ctx context.Context, claims auth.Claims, req CreatedAssetTxnReadRequest, now time.Time) (*CreatedAssetTxn, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.ReconcileTxn")
+	defer span.Finish()
+
+	t, err := repo.ReadTxn(ctx, claims, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Status != CreatedAssetTxnStatus_Submitted {
+		return t, nil
+	}
+
+	m, err := repo.ReadByID(ctx, claims, t.CreatedAssetID)
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := repo.AlgoClient.Network(m.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repo.reconcileTxn(ctx, network, t, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.ReadTxn(ctx, claims, req)
+}
+
+// reconcileSubmittedTxns reconciles all the created asset transactions that are waiting
+// to be confirmed and expires the drafts that can no longer be submitted, the first error
+// is returned.
+func (repo *Repository) reconcileSubmittedTxns(ctx context.Context, now time.Time) error {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("status", CreatedAssetTxnStatus_Submitted.String()))
+	query.OrderBy("updated_at asc")
+
+	submitted, err := findTxns(ctx, auth.Claims{}, repo.DbConn, query)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, t := range submitted {
+		m, err := repo.ReadByID(ctx, auth.Claims{}, t.CreatedAssetID)
+		if err == nil {
+			var network *algosdk.Network
+			network, err = repo.AlgoClient.Network(m.Network)
+			if err == nil {
+				err = repo.reconcileTxn(ctx, network, t, now)
+			}
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = errors.WithMessagef(err, "reconcile created asset transaction %s failed", t.ID)
+		}
+	}
+
+	if err := repo.expireDraftTxns(ctx, now); err != nil && firstErr == nil {
+		firstErr = err
+	}
+
+	return firstErr
+}
+
+// expireDraftTxns marks the draft created asset transactions as failed once the network
+// has passed their last valid round, they can no longer be signed and submitted. The
+// members of a group share the same last valid round so they expire together.
+func (repo *Repository) expireDraftTxns(ctx context.Context, now time.Time) error {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("status", CreatedAssetTxnStatus_Draft.String()))
+	query.OrderBy("updated_at asc")
+
+	drafts, err := findTxns(ctx, auth.Claims{}, repo.DbConn, query)
+	if err != nil {
+		return err
+	}
+
+	// The last round of each network, only requested once.
+	lastRounds := make(map[string]uint64)
+
+	var firstErr error
+	for _, t := range drafts {
+		err := repo.expireDraftTxn(ctx, t, lastRounds, now)
+		if err != nil && firstErr == nil {
+			firstErr = errors.WithMessagef(err, "expire created asset transaction %s failed", t.ID)
+		}
+	}
+
+	return firstErr
+}
+
+// expireDraftTxn marks the draft created asset transaction as failed when the last round
+// of its network is past its last valid round.
+func (repo *Repository) expireDraftTxn(ctx context.Context, t *CreatedAssetTxn, lastRounds map[string]uint64, now time.Time) error {
+	m, err := repo.ReadByID(ctx, auth.Claims{}, t.CreatedAssetID)
+	if err != nil {
+		return err
+	}
+
+	lastRound, ok := lastRounds[m.Network]
+	if !ok {
+		network, err := repo.AlgoClient.Network(m.Network)
+		if err != nil {
+			return err
+		}
+
+		status, err := network.Status(ctx)
+		if err != nil {
+			return err
+		}
+		lastRound = status.LastRound
+		lastRounds[m.Network] = lastRound
+	}
+
+	if lastRound <= t.LastValidRound {
+		return nil
+	}
+
+	_, err = updateTxnStatus(ctx, repo.DbConn, t.ID, txnStatusUpdate{
+		From:  []CreatedAssetTxnStatus{CreatedAssetTxnStatus_Draft},
+		To:    CreatedAssetTxnStatus_Failed,
+		Error: fmt.Sprintf("Transaction expired before it was submitted, last valid round %d", t.LastValidRound),
+	}, now)
+	return err
+}
+
+// reconcileTxn updates the status of a submitted created asset transaction from the
+// network and applies its effects to the created asset once confirmed. The transaction
+// is only marked as failed when it was rejected or provably expired, so its effects are
+// never lost once confirmed.
+func (repo *Repository) reconcileTxn(ctx context.Context, network *algosdk.Network, t *CreatedAssetTxn, now time.Time) error {
+	confirmation, err := network.TransactionStatus(ctx, t.TxID, t.LastValidRound)
+	if err != nil {
+		switch errors.Cause(err) {
+		case algosdk.ErrTxnPoolRejected, algosdk.ErrTxnExpired:
+			_, err = updateTxnStatus(ctx, repo.DbConn, t.ID, txnStatusUpdate{
+				From:  []CreatedAssetTxnStatus{CreatedAssetTxnStatus_Submitted},
+				To:    CreatedAssetTxnStatus_Failed,
+				Error: err.Error(),
+			}, now)
+		}
+		return err
+	} else if confirmation == nil {
+		// The transaction is still pending.
+		return nil
+	}
+
+	return repo.confirmTxn(ctx, t, confirmation.ConfirmedRound, now)
+}
+
+// confirmTxn marks the created asset transaction as confirmed and applies its effects
+// to the created asset in a single database transaction.
+func (repo *Repository) confirmTxn(ctx context.Context, t *CreatedAssetTxn, confirmedRound uint64, now time.Time) error {
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	ok, err := updateTxnStatus(ctx, dbTx, t.ID, txnStatusUpdate{
+		From:           []CreatedAssetTxnStatus{CreatedAssetTxnStatus_Submitted},
+		To:             CreatedAssetTxnStatus_Confirmed,
+		ConfirmedRound: &confirmedRound,
+	}, now)
+	if err != nil {
+		return err
+	} else if !ok {
+		// Already reconciled.
+		return nil
+	}
+
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(CreatedAssetTableName)
+
+	switch t.Type {
+	case CreatedAssetTxnType_Config:
+		query.Set(
+			query.Assign("manager_address", t.ManagerAddress),
+			query.Assign("reserve_address", t.ReserveAddress),
+			query.Assign("freeze_address", t.FreezeAddress),
+			query.Assign("clawback_address", t.ClawbackAddress),
+			query.Assign("updated_at", now),
+		)
+	case CreatedAssetTxnType_Destroy:
+		query.Set(
+			query.Assign("destroyed_at", now),
+			query.Assign("updated_at", now),
+		)
+	default:
+		// Freeze, clawback, opt-in, transfer and payment only change the holdings of the asset.
+		return errors.WithStack(dbTx.Commit())
+	}
+	query.Where(query.Equal("id", t.CreatedAssetID))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = dbTx.Rebind(sql)
+	_, err = dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "apply asset %s transaction to created asset %s failed", t.Type, t.CreatedAssetID)
+		return err
+	}
+
+	return errors.WithStack(dbTx.Commit())
+}
+
+// txnStatusUpdate defines a transition of the status of a created asset transaction.
+type txnStatusUpdate struct {
+	// From is the list of statuses the transaction is allowed to transition from.
+	From           []CreatedAssetTxnStatus
+	To             CreatedAssetTxnStatus
+	Error          string
+	ConfirmedRound *uint64
+	// ClearUnsignedTxn removes the transaction issued for offline signing and the signed
+	// member of a group.
+	ClearUnsignedTxn bool
+}
+
+// updateTxnStatus transitions the status of the created asset transaction. The update is
+// only applied when the current status is one of the From statuses. Returns false when
+// the transition was not applied.
+func updateTxnStatus(ctx context.Context, dbConn sqlx.ExtContext, id string, req txnStatusUpdate, now time.Time) (bool, error) {
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	if len(req.Error) > mintErrorMaxLength {
+		req.Error = req.Error[:mintErrorMaxLength]
+	}
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(CreatedAssetTxnTableName)
+
+	fields := []string{
+		query.Assign("status", req.To.String()),
+		query.Assign("error", req.Error),
+	}
+	if req.ConfirmedRound != nil {
+		fields = append(fields, query.Assign("confirmed_round", *req.ConfirmedRound))
+	}
+	if req.ClearUnsignedTxn {
+		fields = append(fields, query.Assign("unsigned_txn", nil), query.Assign("signed_txn", nil), query.Assign("multisig_txn", nil))
+	}
+	fields = append(fields, query.Assign("updated_at", now))
+
+	var from []interface{}
+	for _, s := range req.From {
+		from = append(from, s.String())
+	}
+
+	query.Set(fields...)
+	query.Where(query.And(
+		query.Equal("id", id),
+		query.In("status", from...),
+	))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = dbConn.Rebind(sql)
+	res, err := dbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "update status for created asset transaction %s failed", id)
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return n > 0, nil
+}
@@ -0,0 +1,399 @@
+package createasset
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	// The database table for Account
+	accountTableName = "accounts"
+
+	// metadataURLAssetID is replaced with the asset index by clients that fetch the metadata
+	// document, the index is not known when the asset create transaction is signed.
+	metadataURLAssetID = "{id}"
+
+	// metadataURLPath is the path of the web app that serves the metadata documents.
+	metadataURLPath = "/m/"
+
+	// metadataURLSuffix identifies the URL of an asset as an ARC-3 metadata document.
+	metadataURLSuffix = "#arc3"
+
+	// metadataMaxSize is the largest metadata document fetched when it's verified.
+	metadataMaxSize = 1 << 20
+
+	// noteMaxSize is the largest note that can be included in a transaction.
+	noteMaxSize = 1024
+)
+
+var (
+	// ErrMetadataNotPublished occurs when the created asset does not have a metadata document
+	// bound to it by a URL and metadata hash.
+	ErrMetadataNotPublished = errors.New("Metadata has not been published for created asset")
+
+	// ErrMetadataURLTooLong occurs when the URL the metadata is served from exceeds the size
+	// of the asset URL.
+	ErrMetadataURLTooLong = errors.New("Metadata URL is too long")
+
+	// metadataClient is used to fetch the hosted metadata documents when they are verified.
+	metadataClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// AssetMetadata is the JSON metadata document of a created asset as defined by ARC-3.
+// https://github.com/algorandfoundation/ARCs/blob/main/ARCs/arc-0003.md
+type AssetMetadata struct {
+	Name          string                  `json:"name"`
+	UnitName      string                  `json:"unitName,omitempty"`
+	Description   string                  `json:"description,omitempty"`
+	Decimals      uint32                  `json:"decimals"`
+	Image         string                  `json:"image,omitempty"`
+	ImageMimetype string                  `json:"image_mimetype,omitempty"`
+	Properties    AssetMetadataProperties `json:"properties"`
+}
+
+// AssetMetadataProperties are the properties of the metadata document that describe the
+// issuer of the asset and its legal documents.
+type AssetMetadataProperties struct {
+	Issuer         AssetMetadataIssuer    `json:"issuer"`
+	LegalDocuments []CreatedAssetDocument `json:"legal_documents,omitempty"`
+}
+
+// AssetMetadataIssuer is the account on Exitor that issued the asset.
+type AssetMetadataIssuer struct {
+	Name     string `json:"name"`
+	Address1 string `json:"address1,omitempty"`
+	Address2 string `json:"address2,omitempty"`
+	City     string `json:"city,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Country  string `json:"country,omitempty"`
+	Zipcode  string `json:"zipcode,omitempty"`
+}
+
+// arc69Metadata is the metadata included in the note of asset config transactions as defined
+// by ARC-69 for wallets and explorers that don't fetch the document from the asset URL.
+// https://github.com/algorandfoundation/ARCs/blob/main/ARCs/arc-0069.md
+type arc69Metadata struct {
+	Standard    string                  `json:"standard"`
+	Description string                  `json:"description,omitempty"`
+	MediaURL    string                  `json:"media_url,omitempty"`
+	MimeType    string                  `json:"mime_type,omitempty"`
+	Properties  AssetMetadataProperties `json:"properties"`
+}
+
+// CreatedAssetMetadataVerification is the result of comparing the hash of the hosted metadata
+// document of a created asset with the metadata hash bound to the asset.
+type CreatedAssetMetadataVerification struct {
+	URL          string `json:"url" example:"https://exitor.io/m/13169404"`
+	Hash         string `json:"hash" example:"hex encoded SHA-256 hash of the hosted document"`
+	MetadataHash string `json:"metadata_hash" example:"hex encoded metadata hash of the asset"`
+	Verified     bool   `json:"verified" example:"true"`
+	Error        string `json:"error,omitempty"`
+}
+
+// NewAssetMetadata returns the ARC-3 metadata document for the created asset.
+func NewAssetMetadata(m *CreatedAsset, issuer AssetMetadataIssuer) AssetMetadata {
+	md := AssetMetadata{
+		Name:        m.AssetName,
+		UnitName:    m.UnitName,
+		Description: m.Description,
+		Decimals:    m.Decimals,
+		Image:       m.ImageURL,
+		Properties: AssetMetadataProperties{
+			Issuer:         issuer,
+			LegalDocuments: m.LegalDocuments,
+		},
+	}
+
+	if md.Image != "" {
+		md.ImageMimetype = mime.TypeByExtension(path.Ext(md.Image))
+	}
+
+	return md
+}
+
+// metadataURL returns the URL of the metadata documents served by the web app.
+func (repo *Repository) metadataURL() string {
+	return strings.TrimRight(repo.MetadataBaseURL, "/") + metadataURLPath + metadataURLAssetID + metadataURLSuffix
+}
+
+// hostsMetadata returns whether the metadata document of the created asset is published by
+// Exitor. It's not when the asset was created with a URL or metadata hash of its own.
+func (repo *Repository) hostsMetadata(m *CreatedAsset) bool {
+	if repo.MetadataBaseURL == "" {
+		return false
+	}
+
+	return len(m.Metadata) > 0 || (m.URL == "" && len(m.MetadataHash) == 0)
+}
+
+// publishMetadata generates the metadata document for a draft or failed created asset and
+// binds it with the URL and metadata hash used for the asset create transaction. It's
+// generated again each time a new transaction is built so changes to the account are
+// included until the asset is minted.
+func (repo *Repository) publishMetadata(ctx context.Context, m *CreatedAsset, now time.Time) error {
+	if !repo.hostsMetadata(m) {
+		return nil
+	}
+
+	metadataURL := repo.metadataURL()
+	if len(metadataURL) > types.AssetURLMaxLen {
+		return errors.WithMessagef(ErrMetadataURLTooLong, "%s exceeds %d bytes", metadataURL, types.AssetURLMaxLen)
+	}
+
+	issuer, err := repo.metadataIssuer(ctx, m.AccountID)
+	if err != nil {
+		return err
+	}
+
+	doc, err := json.Marshal(NewAssetMetadata(m, issuer))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	hash := sha256.Sum256(doc)
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	// Build the update SQL statement, the metadata can't change once the asset is submitted.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(CreatedAssetTableName)
+	query.Set(
+		query.Assign("url", metadataURL),
+		query.Assign("metadata_hash", hash[:]),
+		query.Assign("metadata", doc),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.And(
+		query.Equal("id", m.ID),
+		query.In("mint_status", CreatedAssetMintStatus_Draft.String(), CreatedAssetMintStatus_Failed.String()),
+	))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	res, err := repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "publish metadata for created asset %s failed", m.ID)
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.WithStack(err)
+	} else if n == 0 {
+		return errors.WithMessagef(ErrInvalidMintStatus, "created asset %s is already being minted", m.ID)
+	}
+
+	m.URL = metadataURL
+	m.MetadataHash = hash[:]
+	m.Metadata = doc
+	m.UpdatedAt = now
+
+	return nil
+}
+
+// metadataIssuer returns the details of the account that issued the asset.
+func (repo *Repository) metadataIssuer(ctx context.Context, accountID string) (AssetMetadataIssuer, error) {
+	// select name, address1, address2, city, region, country, zipcode from accounts where id = [accountID]
+	query := sqlbuilder.NewSelectBuilder().Select("name,address1,address2,city,region,country,zipcode").From(accountTableName)
+	query.Where(query.Equal("id", accountID))
+	queryStr, args := query.Build()
+	queryStr = repo.DbConn.Rebind(queryStr)
+
+	var issuer AssetMetadataIssuer
+	err := repo.DbConn.QueryRowContext(ctx, queryStr, args...).Scan(&issuer.Name, &issuer.Address1, &issuer.Address2,
+		&issuer.City, &issuer.Region, &issuer.Country, &issuer.Zipcode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = errors.WithMessagef(ErrNotFound, "account %s not found", accountID)
+			return issuer, err
+		}
+		err = errors.Wrapf(err, "query - %s", query.String())
+		return issuer, err
+	}
+
+	return issuer, nil
+}
+
+// metadataNote returns the ARC-69 note for asset config transactions of the created asset
+// from its published metadata document. The legal documents and then the description are
+// left out when the note would exceed the size allowed for a transaction.
+func (m *CreatedAsset) metadataNote() ([]byte, error) {
+	if len(m.Metadata) == 0 {
+		return nil, nil
+	}
+
+	var md AssetMetadata
+	if err := json.Unmarshal(m.Metadata, &md); err != nil {
+		return nil, errors.Wrapf(err, "decode metadata for created asset %s failed", m.ID)
+	}
+
+	note := arc69Metadata{
+		Standard:    "arc69",
+		Description: md.Description,
+		MediaURL:    md.Image,
+		MimeType:    md.ImageMimetype,
+		Properties:  md.Properties,
+	}
+
+	for {
+		dat, err := json.Marshal(note)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if len(dat) <= noteMaxSize {
+			return dat, nil
+		}
+
+		if len(note.Properties.LegalDocuments) > 0 {
+			note.Properties.LegalDocuments = nil
+		} else if note.Description != "" {
+			note.Description = ""
+		} else {
+			return nil, errors.Errorf("metadata note for created asset %s exceeds %d bytes", m.ID, noteMaxSize)
+		}
+	}
+}
+
+// ReadMetadata returns the published metadata document of the created asset with the asset
+// index on the network. It does not require claims so the document can be fetched by
+// wallets and explorers.
+func (repo *Repository) ReadMetadata(ctx context.Context, network string, assetIndex uint64) ([]byte, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.ReadMetadata")
+	defer span.Finish()
+
+	// select metadata from created_assets where network = [network] and asset_index = [assetIndex] and metadata is not null
+	query := sqlbuilder.NewSelectBuilder().Select("metadata").From(CreatedAssetTableName)
+	query.Where(query.And(
+		query.Equal("network", network),
+		query.Equal("asset_index", assetIndex),
+		query.IsNotNull("metadata"),
+	))
+	queryStr, args := query.Build()
+	queryStr = repo.DbConn.Rebind(queryStr)
+
+	var doc []byte
+	err := repo.DbConn.QueryRowContext(ctx, queryStr, args...).Scan(&doc)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = errors.WithMessagef(ErrNotFound, "metadata for asset %d on %s not found", assetIndex, network)
+			return nil, err
+		}
+		err = errors.Wrapf(err, "query - %s", query.String())
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// VerifyMetadata fetches the metadata document of the created asset from its URL and checks
+// its SHA-256 hash matches the metadata hash of the asset on the network, or the hash stored
+// for the asset when it has not been confirmed. A document that can't be fetched or doesn't
+// match is reported by the verification rather than returned as an error.
+func (repo *Repository) VerifyMetadata(ctx context.Context, claims auth.Claims, id string) (*CreatedAssetMetadataVerification, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.VerifyMetadata")
+	defer span.Finish()
+
+	m, err := repo.ReadByID(ctx, claims, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.URL == "" || len(m.MetadataHash) == 0 {
+		return nil, errors.WithMessagef(ErrMetadataNotPublished, "created asset %s", m.ID)
+	}
+
+	res := &CreatedAssetMetadataVerification{
+		URL:          m.MetadataURL(),
+		MetadataHash: hex.EncodeToString(m.MetadataHash),
+	}
+
+	if res.URL == "" {
+		return nil, errors.WithMessagef(ErrAssetNotMinted, "metadata URL for created asset %s requires the asset index", m.ID)
+	}
+
+	// The hash bound to the asset on the network is what wallets and explorers check against.
+	if m.AssetIndex > 0 && repo.AlgoClient != nil {
+		network, err := repo.AlgoClient.Network(m.Network)
+		if err != nil {
+			return nil, err
+		}
+
+		asset, err := network.AssetInformation(ctx, m.AssetIndex)
+		if err != nil {
+			return nil, err
+		}
+		res.MetadataHash = hex.EncodeToString(asset.Params.MetadataHash)
+	}
+
+	doc, err := fetchMetadata(ctx, res.URL)
+	if err != nil {
+		res.Error = err.Error()
+		return res, nil
+	}
+
+	hash := sha256.Sum256(doc)
+	res.Hash = hex.EncodeToString(hash[:])
+
+	if res.Hash != res.MetadataHash {
+		res.Error = "hash of the hosted document does not match the metadata hash of the asset"
+		return res, nil
+	}
+	res.Verified = true
+
+	return res, nil
+}
+
+// fetchMetadata downloads the metadata document from the URL.
+func fetchMetadata(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res, err := metadataClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fetch %s responded with status %d", url, res.StatusCode)
+	}
+
+	doc, err := ioutil.ReadAll(io.LimitReader(res.Body, metadataMaxSize+1))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	} else if len(doc) > metadataMaxSize {
+		return nil, errors.Errorf("fetch %s exceeds %d bytes", url, metadataMaxSize)
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,337 @@
+package createasset
+
+import (
+	"context"
+	"time"
+
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// mintErrorMaxLength is the size of the mint_error column and the error column of
+// created asset transactions.
+const mintErrorMaxLength = 500
+
+// ErrInvalidMintStatus occurs when a created asset is not in the mint status required
+// for the requested action, ie: minting an asset that was already submitted.
+var ErrInvalidMintStatus = errors.New("Invalid mint status for created asset")
+
+// MakeCreateTxn builds the asset create transaction for the created asset using the
+// suggested params of the network. The published metadata of the asset is included
+// as the ARC-69 note of the transaction.
+func (repo *Repository) MakeCreateTxn(ctx context.Context, network *algosdk.Network, m *CreatedAsset) (types.Transaction, error) {
+	txParams, err := network.SuggestedParams(ctx)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	note, err := m.metadataNote()
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	tx, err := future.MakeAssetCreateTxn(m.CreatorAddress, note, txParams, m.Total, m.Decimals, m.DefaultFrozen,
+		m.ManagerAddress, m.ReserveAddress, m.FreezeAddress, m.ClawbackAddress, m.UnitName, m.AssetName, m.URL,
+		string(m.MetadataHash))
+	if err != nil {
+		return types.Transaction{}, errors.Wrap(err, "failed to make asset create transaction")
+	}
+
+	return tx, nil
+}
+
+// Mint signs and submits the asset create transaction for a draft or failed created
+// asset with the Signer of the repository. The asset is marked as submitted with the
+// transaction ID before being broadcast, it's left to Reconcile to mark the asset as
+// confirmed once the network has accepted it. When the transaction must be signed
+// externally, algosdk.ErrExternalSigningRequired is returned.
+func (repo *Repository) Mint(ctx context.Context, claims auth.Claims, req CreatedAssetMintRequest, now time.Time) (*CreatedAsset, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Mint")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can modify the created asset specified in the request.
+	err = repo.CanModifyCreatedAsset(ctx, claims, req.ID, auth.PermissionAssetMint)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.ReadByID(ctx, claims, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.MintStatus != CreatedAssetMintStatus_Draft && m.MintStatus != CreatedAssetMintStatus_Failed {
+		return nil, errors.WithMessagef(ErrInvalidMintStatus, "created asset %s is %s", m.ID, m.MintStatus)
+	}
+
+	signer := repo.Signer
+	if signer == nil {
+		signer = algosdk.NewExternalSigner()
+	}
+
+	network, err := repo.AlgoClient.Network(m.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bind the metadata to the asset before the transaction is built.
+	err = repo.publishMetadata(ctx, m, now)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := repo.MakeCreateTxn(ctx, network, m)
+	if err != nil {
+		return nil, err
+	}
+
+	stx, err := signer.SignTransaction(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.submit(ctx, network, m, tx, stx, now)
+}
+
+// submit records the transaction on the created asset and broadcasts the signed
+// transaction to the network.
+func (repo *Repository) submit(ctx context.Context, network *algosdk.Network, m *CreatedAsset, tx types.Transaction, stx []byte, now time.Time) (*CreatedAsset, error) {
+	txID := crypto.TransactionIDString(tx)
+	lastValid := uint64(tx.LastValid)
+
+	// Store the transaction ID before it's sent so the asset can always be reconciled
+	// even when the response from the node is lost.
+	ok, err := repo.updateMintStatus(ctx, m.ID, mintStatusUpdate{
+		From:           []CreatedAssetMintStatus{CreatedAssetMintStatus_Draft, CreatedAssetMintStatus_Failed},
+		To:             CreatedAssetMintStatus_Submitted,
+		TxID:           &txID,
+		LastValidRound: &lastValid,
+		// Any transaction issued for offline signing can no longer be used.
+		ClearUnsignedTxn: true,
+	}, now)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errors.WithMessagef(ErrInvalidMintStatus, "created asset %s is already being minted", m.ID)
+	}
+
+	if _, err := network.SendRawTransaction(ctx, stx); err != nil {
+		// Any other error, like a timeout, doesn't tell whether the node accepted the
+		// transaction. The asset stays submitted until it's reconciled so it can't be
+		// minted twice.
+		if errors.Cause(err) != algosdk.ErrTransactionRejected {
+			return nil, err
+		}
+
+		// The node refused the transaction so it will never be confirmed.
+		_, uerr := repo.updateMintStatus(ctx, m.ID, mintStatusUpdate{
+			From:  []CreatedAssetMintStatus{CreatedAssetMintStatus_Submitted},
+			To:    CreatedAssetMintStatus_Failed,
+			Error: err.Error(),
+		}, now)
+		if uerr != nil {
+			return nil, uerr
+		}
+		return nil, err
+	}
+
+	return repo.ReadByID(ctx, auth.Claims{}, m.ID)
+}
+
+// Reconcile checks the network for the transaction of a submitted created asset. When
+// confirmed, the asset index and confirmed round are stored. When the transaction was
+// rejected or can no longer be confirmed the asset is marked as failed so it can be
+// minted again.
+func (repo *Repository) Reconcile(ctx context.Context, claims auth.Claims, id string, now time.Time) (*CreatedAsset, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.Reconcile")
+	defer span.Finish()
+
+	m, err := repo.ReadByID(ctx, claims, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.MintStatus != CreatedAssetMintStatus_Submitted {
+		return m, nil
+	}
+
+	network, err := repo.AlgoClient.Network(m.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repo.reconcile(ctx, network, m, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.ReadByID(ctx, claims, id)
+}
+
+// ReconcileSubmitted reconciles all the created assets and created asset transactions
+// that are waiting for their transaction to be confirmed. An error for one asset does
+// not prevent the others from being reconciled, the first error is returned.
+func (repo *Repository) ReconcileSubmitted(ctx context.Context, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.ReconcileSubmitted")
+	defer span.Finish()
+
+	submitted, err := repo.Find(ctx, auth.Claims{}, CreatedAssetFindRequest{
+		Where: "mint_status = ?",
+		Args:  []interface{}{CreatedAssetMintStatus_Submitted.String()},
+		Order: []string{"updated_at asc"},
+	})
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, m := range submitted {
+		network, err := repo.AlgoClient.Network(m.Network)
+		if err == nil {
+			err = repo.reconcile(ctx, network, m, now)
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = errors.WithMessagef(err, "reconcile created asset %s failed", m.ID)
+		}
+	}
+
+	if err := repo.reconcileSubmittedTxns(ctx, now); err != nil && firstErr == nil {
+		firstErr = err
+	}
+
+	return firstErr
+}
+
+// reconcile updates the mint status of a submitted created asset from the network. The
+// asset is only marked as failed when the transaction was rejected or provably expired,
+// failing an asset that was actually created would lead to it being minted twice.
+func (repo *Repository) reconcile(ctx context.Context, network *algosdk.Network, m *CreatedAsset, now time.Time) error {
+	confirmation, err := network.TransactionStatus(ctx, m.TxID, m.LastValidRound)
+	if err != nil {
+		switch errors.Cause(err) {
+		case algosdk.ErrTxnPoolRejected, algosdk.ErrTxnExpired:
+			_, err = repo.updateMintStatus(ctx, m.ID, mintStatusUpdate{
+				From:  []CreatedAssetMintStatus{CreatedAssetMintStatus_Submitted},
+				To:    CreatedAssetMintStatus_Failed,
+				Error: err.Error(),
+			}, now)
+		}
+		return err
+	} else if confirmation == nil {
+		// The transaction is still pending.
+		return nil
+	}
+
+	_, err = repo.updateMintStatus(ctx, m.ID, mintStatusUpdate{
+		From:           []CreatedAssetMintStatus{CreatedAssetMintStatus_Submitted},
+		To:             CreatedAssetMintStatus_Confirmed,
+		AssetIndex:     &confirmation.AssetIndex,
+		ConfirmedRound: &confirmation.ConfirmedRound,
+	}, now)
+	return err
+}
+
+// mintStatusUpdate defines a transition of the mint status of a created asset.
+type mintStatusUpdate struct {
+	// From is the list of mint statuses the asset is allowed to transition from.
+	From           []CreatedAssetMintStatus
+	To             CreatedAssetMintStatus
+	Error          string
+	TxID           *string
+	LastValidRound *uint64
+	AssetIndex     *uint64
+	ConfirmedRound *uint64
+	// ClearUnsignedTxn removes the transaction issued for offline signing.
+	ClearUnsignedTxn bool
+}
+
+// updateMintStatus transitions the mint status of the created asset. The update is
+// only applied when the current mint status is one of the From statuses, ensuring
+// concurrent submissions and reconciliations can't overwrite each other. Returns
+// false when the transition was not applied.
+func (repo *Repository) updateMintStatus(ctx context.Context, id string, req mintStatusUpdate, now time.Time) (bool, error) {
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	if len(req.Error) > mintErrorMaxLength {
+		req.Error = req.Error[:mintErrorMaxLength]
+	}
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(CreatedAssetTableName)
+
+	fields := []string{
+		query.Assign("mint_status", req.To.String()),
+		query.Assign("mint_error", req.Error),
+	}
+	if req.TxID != nil {
+		fields = append(fields, query.Assign("tx_id", *req.TxID))
+	}
+	if req.LastValidRound != nil {
+		fields = append(fields, query.Assign("last_valid_round", *req.LastValidRound))
+	}
+	if req.AssetIndex != nil {
+		fields = append(fields, query.Assign("asset_index", *req.AssetIndex))
+	}
+	if req.ConfirmedRound != nil {
+		fields = append(fields, query.Assign("confirmed_round", *req.ConfirmedRound))
+	}
+	if req.ClearUnsignedTxn {
+		fields = append(fields, query.Assign("unsigned_txn", nil))
+	}
+	fields = append(fields, query.Assign("updated_at", now))
+
+	var from []interface{}
+	for _, s := range req.From {
+		from = append(from, s.String())
+	}
+
+	query.Set(fields...)
+	query.Where(query.And(
+		query.Equal("id", id),
+		query.In("mint_status", from...),
+	))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	res, err := repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "update mint status for created asset %s failed", id)
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return n > 0, nil
+}
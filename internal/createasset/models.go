@@ -2,10 +2,18 @@ package createasset
 
 import (
 	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
-	"database/sql/driver"
+	"exitor-dapp/internal/algosdk"
 	"exitor-dapp/internal/platform/web"
+
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
@@ -14,141 +22,332 @@ import (
 
 // Repository defines the required dependencies for CreatedAsset.
 type Repository struct {
-	DbConn *sqlx.DB
+	DbConn     *sqlx.DB
+	AlgoClient *algosdk.Client
+	// Signer signs asset transactions for Mint, when nil transactions must be
+	// signed offline.
+	Signer algosdk.Signer
+	// MetadataBaseURL is the base URL of the web app that serves the metadata documents
+	// of created assets, when empty the metadata of assets is not published.
+	MetadataBaseURL string
 }
 
 // NewRepository creates a new Repository that defines dependencies for CreatedAsset.
-func NewRepository(db *sqlx.DB) *Repository {
+func NewRepository(db *sqlx.DB, algoClient *algosdk.Client, signer algosdk.Signer, metadataBaseUrl string) *Repository {
 	return &Repository{
-		DbConn: db,
+		DbConn:          db,
+		AlgoClient:      algoClient,
+		Signer:          signer,
+		MetadataBaseURL: metadataBaseUrl,
 	}
 }
 
-// CreatedAsset represents the required params
-// and wokflow to mint an asset successfully on Exitor
-// refernce Algorand Asset Creation Params: 
+// CreatedAsset represents an Algorand Standard Asset minted by an account on Exitor.
+// The asset parameters mirror the parameters of the asset create transaction.
+// https://developer.algorand.org/docs/features/asa/#asset-parameters
+//
+// An asset is minted in two phases. It's first stored as a draft, then the asset
+// create transaction is submitted and its ID recorded. Once the network confirms
+// the transaction the asset index and confirmed round are filled in by Reconcile.
 type CreatedAsset struct {
-		ID 			 	string       		`json:"id" validate:"required,uuid" example:"928981298-162y2-612y2u12"`
-		AccountID    	string       	`json:"account_id" validate:"required,uuid" truss:"api-create"`
-		WalletAddress 	string      		`json:"wallet_address" validate:"required,uuid" truss:"api-create"`
-		Total           uint64          `json:"total_assetissuance" validate:"required,uuid" example:"the total base units of the asset being created" truss:"api-create"`
-		AssetName    	string       		`json:"assetName validate:"required" example:"Kwa Jeff Limited"`
-		Decimals        uint32           `json:assetDecimalsDenomination validate:"required,uuid" truss:"api-create"`
-		DefaultFrozen   bool             `json:defaultAssetsFrozen validate:"required,uuid" truss:"api-create"`
-		URL             string          `json:assetUrl validate:"required" truss:"api-create"`
-		Status       AssetCreationStatus  `json:"status" validate:"omitempty,oneof=active disabled"  enums:"active, disabled" swaggertype:"string" example:"active"`
-		CreatedAt  time.Time       `json:"created_at" truss:"api-read"`
-		UpdatedAt  time.Time       `json:"updated_at" truss:"api-read"`
-		ArchivedAt *pq.NullTime    `json:"archived_at,omitempty" truss:"api-hide"`
-}
-
-// CreatedAssetResponse is the workflow/params that is returned for display once 
-// the asset is created
+	ID              string                 `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	AccountID       string                 `json:"account_id" validate:"required,uuid" truss:"api-create"`
+	Network         string                 `json:"network" validate:"required" example:"testnet"`
+	AssetIndex      uint64                 `json:"asset_index" example:"13169404" truss:"api-read"`
+	UnitName        string                 `json:"unit_name" validate:"omitempty,max=8" example:"KJL"`
+	AssetName       string                 `json:"asset_name" validate:"required,max=32" example:"Kwa Jeff Limited"`
+	Total           uint64                 `json:"total" validate:"required" example:"1000000"`
+	Decimals        uint32                 `json:"decimals" validate:"omitempty,max=19" example:"0"`
+	DefaultFrozen   bool                   `json:"default_frozen" example:"false"`
+	URL             string                 `json:"url" validate:"omitempty,url,max=32" example:"https://exitor.io"`
+	MetadataHash    []byte                 `json:"metadata_hash,omitempty" swaggertype:"string"`
+	Description     string                 `json:"description" validate:"omitempty,max=1000" example:"Ordinary shares of Kwa Jeff Limited."`
+	ImageURL        string                 `json:"image_url" validate:"omitempty,url,max=500" example:"https://exitor.io/images/kjl.png"`
+	LegalDocuments  CreatedAssetDocuments  `json:"legal_documents" validate:"omitempty,dive"`
+	Metadata        []byte                 `json:"-" truss:"api-hide"`
+	CreatorAddress  string                 `json:"creator_address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	ManagerAddress  string                 `json:"manager_address" validate:"omitempty,algorand_address"`
+	ReserveAddress  string                 `json:"reserve_address" validate:"omitempty,algorand_address"`
+	FreezeAddress   string                 `json:"freeze_address" validate:"omitempty,algorand_address"`
+	ClawbackAddress string                 `json:"clawback_address" validate:"omitempty,algorand_address"`
+	TxID            string                 `json:"tx_id" truss:"api-read"`
+	ConfirmedRound  uint64                 `json:"confirmed_round" truss:"api-read"`
+	Status          CreatedAssetStatus     `json:"status" validate:"omitempty,oneof=active disabled" enums:"active,disabled" swaggertype:"string" example:"active"`
+	MintStatus      CreatedAssetMintStatus `json:"mint_status" validate:"omitempty,oneof=draft submitted confirmed failed" enums:"draft,submitted,confirmed,failed" swaggertype:"string" example:"confirmed" truss:"api-read"`
+	MintError       string                 `json:"mint_error,omitempty" truss:"api-read"`
+	LastValidRound  uint64                 `json:"last_valid_round" truss:"api-read"`
+	UnsignedTxn     []byte                 `json:"-" truss:"api-hide"`
+	DestroyedAt     *pq.NullTime           `json:"destroyed_at,omitempty" truss:"api-read"`
+	CreatedAt       time.Time              `json:"created_at" truss:"api-read"`
+	UpdatedAt       time.Time              `json:"updated_at" truss:"api-read"`
+	ArchivedAt      *pq.NullTime           `json:"archived_at,omitempty" truss:"api-hide"`
+}
+
+// CreatedAssetResponse represents a created asset that is returned for display.
 type CreatedAssetResponse struct {
-	ID 			 	string       		`json:"id" validate:"required,uuid" example:"928981298-162y2-612y2u12"`
-	AccountID    	string       	`json:"account_id" validate:"required,uuid" truss:"api-create"`
-	WalletAddress 	string      		`json:"wallet_address" validate:"required,uuid" truss:"api-create"`
-	Total           uint64          `json:"total_assetissuance" validate:"required,uuid" example:"the total base units of the asset being created" truss:"api-create"`
-	AssetName    	string       		`json:"assetName validate:"required" example:"Kwa Jeff Limited"`
-	Decimals        uint32           `json:assetDecimalsDenomination validate:"required,uuid" truss:"api-create"`
-	DefaultFrozen   bool             `json:defaultAssetsFrozen validate:"required,uuid" truss:"api-create"`
-	AssetURL             string          `json:assetUrl validate:"required" truss:"api-create"`
-	AssetManagerAddress     string // Address for Exitor Org 
-	Status     web.EnumResponse  `json:"status"`                // Status is enum with values [active, disabled].
-	CreatedAt  web.TimeResponse  `json:"created_at"`            // CreatedAt contains multiple format options for display.
-	UpdatedAt  web.TimeResponse  `json:"updated_at"`            // UpdatedAt contains multiple format options for display.
-	ArchivedAt *web.TimeResponse `json:"archived_at,omitempty"` // ArchivedAt contains multiple format options for display.
-}
-
-
-// Function to transform CreatedAsset and CreatedAssetResponse for display
+	ID              string                 `json:"id" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	AccountID       string                 `json:"account_id" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Network         string                 `json:"network" example:"testnet"`
+	AssetIndex      uint64                 `json:"asset_index" example:"13169404"`
+	UnitName        string                 `json:"unit_name" example:"KJL"`
+	AssetName       string                 `json:"asset_name" example:"Kwa Jeff Limited"`
+	Total           uint64                 `json:"total" example:"1000000"`
+	Decimals        uint32                 `json:"decimals" example:"0"`
+	DefaultFrozen   bool                   `json:"default_frozen" example:"false"`
+	URL             string                 `json:"url" example:"https://exitor.io"`
+	MetadataHash    string                 `json:"metadata_hash,omitempty" example:"hex encoded hash"`
+	MetadataURL     string                 `json:"metadata_url,omitempty" example:"https://exitor.io/m/13169404"`
+	Description     string                 `json:"description" example:"Ordinary shares of Kwa Jeff Limited."`
+	ImageURL        string                 `json:"image_url" example:"https://exitor.io/images/kjl.png"`
+	LegalDocuments  []CreatedAssetDocument `json:"legal_documents"`
+	CreatorAddress  string                 `json:"creator_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	ManagerAddress  string                 `json:"manager_address"`
+	ReserveAddress  string                 `json:"reserve_address"`
+	FreezeAddress   string                 `json:"freeze_address"`
+	ClawbackAddress string                 `json:"clawback_address"`
+	TxID            string                 `json:"tx_id"`
+	ConfirmedRound  uint64                 `json:"confirmed_round"`
+	Status          web.EnumResponse       `json:"status"`      // Status is enum with values [active, disabled].
+	MintStatus      web.EnumResponse       `json:"mint_status"` // MintStatus is enum with values [draft, submitted, confirmed, failed].
+	MintError       string                 `json:"mint_error,omitempty"`
+	LastValidRound  uint64                 `json:"last_valid_round"`
+	DestroyedAt     *web.TimeResponse      `json:"destroyed_at,omitempty"` // DestroyedAt contains multiple format options for display.
+	CreatedAt       web.TimeResponse       `json:"created_at"`             // CreatedAt contains multiple format options for display.
+	UpdatedAt       web.TimeResponse       `json:"updated_at"`             // UpdatedAt contains multiple format options for display.
+	ArchivedAt      *web.TimeResponse      `json:"archived_at,omitempty"`  // ArchivedAt contains multiple format options for display.
+}
+
+// Response transforms CreatedAsset to the CreatedAssetResponse that is used for display.
+// Additional filtering by context values or translations could be applied.
 func (m *CreatedAsset) Response(ctx context.Context) *CreatedAssetResponse {
 	if m == nil {
 		return nil
 	}
 
 	r := &CreatedAssetResponse{
-		ID: 	m.ID,
-		AccountID: m.AccountID,
-		AssetName:   m.AssetName,
-		Status:   web.NewEnumResponse(ctx, m.Status, CreatedAssetStatus_ValuesInterface()...),
-		CreatedAt web.NewTimeResponse(ctx, m.CreatedAt),
-		UpdatedAt web.NewTimeResponse(ctx, m.UpdatedAt),
+		ID:              m.ID,
+		AccountID:       m.AccountID,
+		Network:         m.Network,
+		AssetIndex:      m.AssetIndex,
+		UnitName:        m.UnitName,
+		AssetName:       m.AssetName,
+		Total:           m.Total,
+		Decimals:        m.Decimals,
+		DefaultFrozen:   m.DefaultFrozen,
+		URL:             m.URL,
+		MetadataURL:     m.MetadataURL(),
+		Description:     m.Description,
+		ImageURL:        m.ImageURL,
+		LegalDocuments:  m.LegalDocuments,
+		CreatorAddress:  m.CreatorAddress,
+		ManagerAddress:  m.ManagerAddress,
+		ReserveAddress:  m.ReserveAddress,
+		FreezeAddress:   m.FreezeAddress,
+		ClawbackAddress: m.ClawbackAddress,
+		TxID:            m.TxID,
+		ConfirmedRound:  m.ConfirmedRound,
+		Status:          web.NewEnumResponse(ctx, m.Status, CreatedAssetStatus_ValuesInterface()...),
+		MintStatus:      web.NewEnumResponse(ctx, m.MintStatus, CreatedAssetMintStatus_ValuesInterface()...),
+		MintError:       m.MintError,
+		LastValidRound:  m.LastValidRound,
+		CreatedAt:       web.NewTimeResponse(ctx, m.CreatedAt),
+		UpdatedAt:       web.NewTimeResponse(ctx, m.UpdatedAt),
+	}
+
+	if len(m.MetadataHash) > 0 {
+		r.MetadataHash = hex.EncodeToString(m.MetadataHash)
+	}
+
+	if m.DestroyedAt != nil && !m.DestroyedAt.Time.IsZero() {
+		at := web.NewTimeResponse(ctx, m.DestroyedAt.Time)
+		r.DestroyedAt = &at
 	}
 
-	if m.ArchivedAt != nil && !m.ArchiveAt.Time.IsZero() {
-			at := web.NeWTimeResponse(ctx, m.ArchiveAt.Time)
-			r.ArchiveAt = &at
+	if m.ArchivedAt != nil && !m.ArchivedAt.Time.IsZero() {
+		at := web.NewTimeResponse(ctx, m.ArchivedAt.Time)
+		r.ArchivedAt = &at
 	}
 
 	return r
 }
 
-// a list of created assets
+// MetadataURL returns the URL the metadata document of the created asset is fetched from. The
+// {id} placeholder of ARC-3 is replaced with the asset index, so the URL is empty when it
+// contains the placeholder and the asset has not been confirmed.
+func (m *CreatedAsset) MetadataURL() string {
+	u := m.URL
+	if i := strings.Index(u, "#"); i >= 0 {
+		u = u[:i]
+	}
+
+	if strings.Contains(u, metadataURLAssetID) {
+		if m.AssetIndex == 0 {
+			return ""
+		}
+		u = strings.Replace(u, metadataURLAssetID, strconv.FormatUint(m.AssetIndex, 10), -1)
+	}
+
+	return u
+}
+
+// CreatedAssets a list of CreatedAssets.
 type CreatedAssets []*CreatedAsset
 
-// The Response Function transforms a list of Created Assets(under CreatedAssets) to a list of CreatedAssetResponses
-func (m *CreatedAssets) Response(ctx contex.Context) []*CreatedAssetResponse {
-		var l []*CreatedAssetResponse 
-		if m != nil && len(*m) > 0 {
-				for _, n := range *m {
-					l = append(l, n.Response(ctx))
-				}
+// Response transforms a list of CreatedAssets to a list of CreatedAssetResponses.
+func (m *CreatedAssets) Response(ctx context.Context) []*CreatedAssetResponse {
+	var l []*CreatedAssetResponse
+	if m != nil && len(*m) > 0 {
+		for _, n := range *m {
+			l = append(l, n.Response(ctx))
 		}
+	}
 
-		return l
+	return l
 }
 
-// CreatedAssetCreateRequest contains information needed to create a new Asset
+// CreatedAssetCreateRequest contains information needed to create a new CreatedAsset.
 type CreatedAssetCreateRequest struct {
-		ID 			 	string       		`json:"id" validate:"required,uuid" example:"928981298-162y2-612y2u12"`
-		AccountID    	string       	`json:"account_id" validate:"required,uuid" truss:"api-create"`
-		WalletAddress 	string      		`json:"algorand_wallet_address" validate:"required,uuid" truss:"api-create"`
-		Total           uint64          `json:"total_assetIssuance" validate:"required,uuid" example:"the total base units of the asset being created" truss:"api-create"`
-		AssetName    	string       		`json:"assetName validate:"required" example:"Kwa Jeff Limited"`
-		Decimals        uint32           `json:assetDecimalsDenomination validate:"required,uuid" truss:"api-create"`
-		DefaultFrozen   bool             `json:defaultAssetsFrozen validate:"required,uuid" truss:"api-create"`
-		URL             url          `json:assetUrl validate:"required" truss:"api-create"`
-		// converted url from string to url
-		Status       AssetCreationStatus  `json:"status" validate:"omitempty,oneof=active disabled"  enums:"active, disabled" swaggertype:"string" example:"active"`
-		CreatedAt  time.Time       `json:"created_at" truss:"api-read"`
-		UpdatedAt  time.Time       `json:"updated_at" truss:"api-read"`
-		ArchivedAt *pq.NullTime    `json:"archived_at,omitempty" truss:"api-hide"`
-}
-
-
-// CreatedAssetReadRequest defines the information need to read a created asset
+	AccountID       string                 `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Network         string                 `json:"network" validate:"required" example:"testnet"`
+	UnitName        string                 `json:"unit_name" validate:"omitempty,max=8" example:"KJL"`
+	AssetName       string                 `json:"asset_name" validate:"required,max=32" example:"Kwa Jeff Limited"`
+	Total           uint64                 `json:"total" validate:"required" example:"1000000"`
+	Decimals        uint32                 `json:"decimals" validate:"omitempty,max=19" example:"0"`
+	DefaultFrozen   bool                   `json:"default_frozen" example:"false"`
+	URL             string                 `json:"url" validate:"omitempty,url,max=32" example:"https://exitor.io"`
+	MetadataHash    string                 `json:"metadata_hash" validate:"omitempty,hexadecimal,len=64" example:"hex encoded SHA-256 hash"`
+	Description     string                 `json:"description" validate:"omitempty,max=1000" example:"Ordinary shares of Kwa Jeff Limited."`
+	ImageURL        string                 `json:"image_url" validate:"omitempty,url,max=500" example:"https://exitor.io/images/kjl.png"`
+	LegalDocuments  []CreatedAssetDocument `json:"legal_documents" validate:"omitempty,dive"`
+	CreatorAddress  string                 `json:"creator_address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	ManagerAddress  string                 `json:"manager_address" validate:"omitempty,algorand_address"`
+	ReserveAddress  string                 `json:"reserve_address" validate:"omitempty,algorand_address"`
+	FreezeAddress   string                 `json:"freeze_address" validate:"omitempty,algorand_address"`
+	ClawbackAddress string                 `json:"clawback_address" validate:"omitempty,algorand_address"`
+	Status          *CreatedAssetStatus    `json:"status,omitempty" validate:"omitempty,oneof=active disabled" enums:"active,disabled" swaggertype:"string" example:"active"`
+}
+
+// CreatedAssetDocument is a legal document of a created asset, ie: the prospectus or the
+// shareholders agreement, that is listed in the metadata of the asset.
+type CreatedAssetDocument struct {
+	Name   string `json:"name" validate:"required,max=200" example:"Prospectus"`
+	URL    string `json:"url" validate:"required,url,max=500" example:"https://exitor.io/docs/kjl-prospectus.pdf"`
+	SHA256 string `json:"sha256,omitempty" validate:"omitempty,hexadecimal,len=64" example:"hex encoded SHA-256 hash of the document"`
+}
+
+// CreatedAssetDocuments a list of CreatedAssetDocuments that is stored as JSON.
+type CreatedAssetDocuments []CreatedAssetDocument
+
+// Scan supports reading the CreatedAssetDocuments value from the database.
+func (s *CreatedAssetDocuments) Scan(value interface{}) error {
+	asBytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("Scan source is not []byte")
+	}
+
+	var l CreatedAssetDocuments
+	if err := json.Unmarshal(asBytes, &l); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// An empty list is kept as nil to match assets that were created without documents.
+	if len(l) == 0 {
+		l = nil
+	}
+	*s = l
+
+	return nil
+}
+
+// Value converts the CreatedAssetDocuments value to be stored in the database.
+func (s CreatedAssetDocuments) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return "[]", nil
+	}
+
+	dat, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return string(dat), nil
+}
+
+// CreatedAssetReadRequest defines the information needed to read a created asset.
 type CreatedAssetReadRequest struct {
 	ID              string `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
 	IncludeArchived bool   `json:"include-archived" example:"false"`
-	// Will call the indexer during this struct's implementation
-	AssetID    		int     // Algorand Indexer needs an assetID to query the asset created
 }
 
-// Any updates will need to include permission
-// from the manager address and clawback address
+// CreatedAssetUpdateRequest defines what information may be provided to modify an existing
+// created asset. All fields are optional so clients can send just the fields they want
+// changed. It uses pointer fields so we can differentiate between a field that was not
+// provided and a field that was provided as explicitly blank. Normally we do not want
+// to use pointers to basic types but we make exceptions around marshalling/unmarshalling.
+// The asset index and transaction details are managed by minting and can't be updated. The
+// role addresses, description, image and legal documents can only be updated until the asset
+// is submitted, the roles of a minted asset are changed with a reconfigure transaction.
 type CreatedAssetUpdateRequest struct {
-	ID     string           `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
-	Name   *string          `json:"name,omitempty" validate:"omitempty" example:"Rocket Launch to Moon"`
-	Status *a created assetStatus `json:"status,omitempty" validate:"omitempty,oneof=active disabled" enums:"active,disabled" swaggertype:"string" example:"disabled"`
+	ID              string                  `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	Description     *string                 `json:"description,omitempty" validate:"omitempty,max=1000" example:"Ordinary shares of Kwa Jeff Limited."`
+	ImageURL        *string                 `json:"image_url,omitempty" validate:"omitempty,url,max=500" example:"https://exitor.io/images/kjl.png"`
+	LegalDocuments  *[]CreatedAssetDocument `json:"legal_documents,omitempty" validate:"omitempty,dive"`
+	ManagerAddress  *string                 `json:"manager_address,omitempty" validate:"omitempty,algorand_address"`
+	ReserveAddress  *string                 `json:"reserve_address,omitempty" validate:"omitempty,algorand_address"`
+	FreezeAddress   *string                 `json:"freeze_address,omitempty" validate:"omitempty,algorand_address"`
+	ClawbackAddress *string                 `json:"clawback_address,omitempty" validate:"omitempty,algorand_address"`
+	Status          *CreatedAssetStatus     `json:"status,omitempty" validate:"omitempty,oneof=active disabled" enums:"active,disabled" swaggertype:"string" example:"disabled"`
 }
 
-// CreatedAssetArchiveRequest defines the information needed to archive a created asset. This will archive (soft-delete) the
-// existing database entry.
-type CreatedAssetArchiveRequest struct {
+// CreatedAssetMintRequest defines the information needed to submit the asset create
+// transaction for a draft created asset or to retry a failed one.
+type CreatedAssetMintRequest struct {
 	ID string `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
 }
 
-// CreatedAssetDeleteRequest defines the information needed to delete a created asset.
-type CreatedAssetDeleteRequest struct {
+// CreatedAssetSignedTxnRequest defines the information needed to submit an asset create
+// transaction that was signed offline. SignedTxn is the msgpack encoded signed
+// transaction, either raw or base64 encoded.
+type CreatedAssetSignedTxnRequest struct {
+	ID        string `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	SignedTxn []byte `json:"signed_txn" validate:"required" swaggertype:"string" example:"base64 encoded signed transaction"`
+}
+
+// UnsignedTxn is an asset create transaction issued for a created asset to be
+// signed offline.
+type UnsignedTxn struct {
+	CreatedAssetID string `json:"created_asset_id"`
+	TxID           string `json:"tx_id"`
+	LastValidRound uint64 `json:"last_valid_round"`
+	// Multisig is true when the sender is a multisig and Txn is the msgpack encoded signed
+	// transaction with the signatures of the members collected so far.
+	Multisig bool `json:"multisig"`
+	// Txn is the msgpack encoded transaction.
+	Txn []byte `json:"txn"`
+}
+
+// Base64 returns the msgpack encoded transaction as base64.
+func (t *UnsignedTxn) Base64() string {
+	return base64.StdEncoding.EncodeToString(t.Txn)
+}
+
+// Filename returns the name of the file the transaction should be downloaded as,
+// the .txn extension is what goal clerk sign expects.
+func (t *UnsignedTxn) Filename() string {
+	return fmt.Sprintf("%s-%s.txn", t.CreatedAssetID, t.TxID)
+}
+
+// CreatedAssetArchiveRequest defines the information needed to archive a created asset. This will archive (soft-delete) the
+// existing database entry.
+type CreatedAssetArchiveRequest struct {
 	ID string `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
 }
 
 // CreatedAssetFindRequest defines the possible options to search for created assets. By default
 // archived created asset will be excluded from response.
 type CreatedAssetFindRequest struct {
-	Where           string        `json:"where" example:"name = ? and status = ?"`
-	Args            []interface{} `json:"args" swaggertype:"array,string" example:"Moon Launch,active"`
+	Where           string        `json:"where" example:"asset_name = ? and status = ?"`
+	Args            []interface{} `json:"args" swaggertype:"array,string" example:"Kwa Jeff Limited,active"`
 	Order           []string      `json:"order" example:"created_at desc"`
 	Limit           *uint         `json:"limit" example:"10"`
 	Offset          *uint         `json:"offset" example:"20"`
@@ -162,11 +361,11 @@ type CreatedAssetStatus string
 const (
 	// CreatedAssetStatus_Active defines the status of active for a created asset.
 	CreatedAssetStatus_Active CreatedAssetStatus = "active"
-	// a created assetStatus_Disabled defines the status of disabled for a created asset.
+	// CreatedAssetStatus_Disabled defines the status of disabled for a created asset.
 	CreatedAssetStatus_Disabled CreatedAssetStatus = "disabled"
 )
 
-// a CreatedAssetStatus_Values provides list of valid CreatedAssetStatus values.
+// CreatedAssetStatus_Values provides list of valid CreatedAssetStatus values.
 var CreatedAssetStatus_Values = []CreatedAssetStatus{
 	CreatedAssetStatus_Active,
 	CreatedAssetStatus_Disabled,
@@ -208,4 +407,627 @@ func (s CreatedAssetStatus) String() string {
 	return string(s)
 }
 
+// CreatedAssetMintStatus represents the progress of minting a created asset on Algorand.
+type CreatedAssetMintStatus string
+
+// CreatedAssetMintStatus values define the mint_status field of the created asset.
+const (
+	// CreatedAssetMintStatus_Draft defines the mint status for an asset that has not been submitted.
+	CreatedAssetMintStatus_Draft CreatedAssetMintStatus = "draft"
+	// CreatedAssetMintStatus_Submitted defines the mint status for an asset waiting to be confirmed.
+	CreatedAssetMintStatus_Submitted CreatedAssetMintStatus = "submitted"
+	// CreatedAssetMintStatus_Confirmed defines the mint status for an asset that exists on the network.
+	CreatedAssetMintStatus_Confirmed CreatedAssetMintStatus = "confirmed"
+	// CreatedAssetMintStatus_Failed defines the mint status for an asset whose transaction was not confirmed.
+	CreatedAssetMintStatus_Failed CreatedAssetMintStatus = "failed"
+)
+
+// CreatedAssetMintStatus_Values provides list of valid CreatedAssetMintStatus values.
+var CreatedAssetMintStatus_Values = []CreatedAssetMintStatus{
+	CreatedAssetMintStatus_Draft,
+	CreatedAssetMintStatus_Submitted,
+	CreatedAssetMintStatus_Confirmed,
+	CreatedAssetMintStatus_Failed,
+}
+
+// CreatedAssetMintStatus_ValuesInterface returns the CreatedAssetMintStatus options as a slice interface.
+func CreatedAssetMintStatus_ValuesInterface() []interface{} {
+	var l []interface{}
+	for _, v := range CreatedAssetMintStatus_Values {
+		l = append(l, v.String())
+	}
+	return l
+}
+
+// Scan supports reading the CreatedAssetMintStatus value from the database.
+func (s *CreatedAssetMintStatus) Scan(value interface{}) error {
+	asBytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("Scan source is not []byte")
+	}
+
+	*s = CreatedAssetMintStatus(string(asBytes))
+	return nil
+}
+
+// Value converts the CreatedAssetMintStatus value to be stored in the database.
+func (s CreatedAssetMintStatus) Value() (driver.Value, error) {
+	v := validator.New()
+	errs := v.Var(s, "required,oneof=draft submitted confirmed failed")
+	if errs != nil {
+		return nil, errs
+	}
+
+	return string(s), nil
+}
+
+// String converts the CreatedAssetMintStatus value to a string.
+func (s CreatedAssetMintStatus) String() string {
+	return string(s)
+}
+
+// CreatedAssetTxn represents a transaction submitted to manage a created asset after it
+// was minted, ie: reconfiguring the addresses of the asset or freezing the asset for a
+// holder. Like minting, the transaction is recorded before it's submitted and its
+// effects are applied to the created asset once the network has confirmed it. Members of
+// an atomic group share the group ID, each is signed on its own and the signed member is
+// held until the whole group can be submitted. When the sender is a multisig the partial
+// signatures of its members are merged until the threshold of signatures is met.
+type CreatedAssetTxn struct {
+	ID              string                `json:"id" validate:"required,uuid" example:"7b2a6a3e-3a4f-4f5e-9a35-5b3c9f5a2d11"`
+	CreatedAssetID  string                `json:"created_asset_id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	AccountID       string                `json:"account_id" validate:"required,uuid" truss:"api-create"`
+	UserID          *string               `json:"user_id,omitempty" validate:"omitempty,uuid"`
+	Type            CreatedAssetTxnType   `json:"type" validate:"required,oneof=config freeze clawback destroy opt_in transfer payment" enums:"config,freeze,clawback,destroy,opt_in,transfer,payment" swaggertype:"string" example:"freeze"`
+	SenderAddress   string                `json:"sender_address" validate:"required,algorand_address"`
+	TargetAddress   string                `json:"target_address" validate:"omitempty,algorand_address"`
+	ReceiverAddress string                `json:"receiver_address" validate:"omitempty,algorand_address"`
+	Amount          uint64                `json:"amount" example:"100"`
+	Frozen          bool                  `json:"frozen" example:"true"`
+	ManagerAddress  string                `json:"manager_address" validate:"omitempty,algorand_address"`
+	ReserveAddress  string                `json:"reserve_address" validate:"omitempty,algorand_address"`
+	FreezeAddress   string                `json:"freeze_address" validate:"omitempty,algorand_address"`
+	ClawbackAddress string                `json:"clawback_address" validate:"omitempty,algorand_address"`
+	PaymentAssetID  uint64                `json:"payment_asset_id" example:"31566704"`
+	GroupID         string                `json:"group_id,omitempty" truss:"api-read"`
+	GroupIndex      int                   `json:"group_index" truss:"api-read"`
+	Status          CreatedAssetTxnStatus `json:"status" validate:"omitempty,oneof=draft submitted confirmed failed" enums:"draft,submitted,confirmed,failed" swaggertype:"string" example:"confirmed" truss:"api-read"`
+	Error           string                `json:"error,omitempty" truss:"api-read"`
+	TxID            string                `json:"tx_id" truss:"api-read"`
+	LastValidRound  uint64                `json:"last_valid_round" truss:"api-read"`
+	ConfirmedRound  uint64                `json:"confirmed_round" truss:"api-read"`
+	UnsignedTxn     []byte                `json:"-" truss:"api-hide"`
+	SignedTxn       []byte                `json:"-" truss:"api-hide"`
+	MultisigTxn     []byte                `json:"-" truss:"api-hide"`
+	CreatedAt       time.Time             `json:"created_at" truss:"api-read"`
+	UpdatedAt       time.Time             `json:"updated_at" truss:"api-read"`
+}
+
+// CreatedAssetTxnResponse represents a created asset transaction that is returned for display.
+type CreatedAssetTxnResponse struct {
+	ID              string           `json:"id" example:"7b2a6a3e-3a4f-4f5e-9a35-5b3c9f5a2d11"`
+	CreatedAssetID  string           `json:"created_asset_id" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	AccountID       string           `json:"account_id" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	UserID          string           `json:"user_id,omitempty" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Type            web.EnumResponse `json:"type"` // Type is enum with values [config, freeze, clawback, destroy, opt_in, transfer, payment].
+	SenderAddress   string           `json:"sender_address"`
+	TargetAddress   string           `json:"target_address,omitempty"`
+	ReceiverAddress string           `json:"receiver_address,omitempty"`
+	Amount          uint64           `json:"amount,omitempty"`
+	Frozen          bool             `json:"frozen"`
+	ManagerAddress  string           `json:"manager_address,omitempty"`
+	ReserveAddress  string           `json:"reserve_address,omitempty"`
+	FreezeAddress   string           `json:"freeze_address,omitempty"`
+	ClawbackAddress string           `json:"clawback_address,omitempty"`
+	PaymentAsset    string           `json:"payment_asset,omitempty" example:"ALGO"`
+	GroupID         string           `json:"group_id,omitempty"`
+	GroupIndex      int              `json:"group_index"`
+	Signed          bool             `json:"signed"`
+	Multisig        bool             `json:"multisig"`
+	Signatures      int              `json:"signatures,omitempty" example:"1"`
+	Threshold       int              `json:"threshold,omitempty" example:"2"`
+	Status          web.EnumResponse `json:"status"` // Status is enum with values [draft, submitted, confirmed, failed].
+	Error           string           `json:"error,omitempty"`
+	TxID            string           `json:"tx_id"`
+	LastValidRound  uint64           `json:"last_valid_round"`
+	ConfirmedRound  uint64           `json:"confirmed_round"`
+	CreatedAt       web.TimeResponse `json:"created_at"` // CreatedAt contains multiple format options for display.
+	UpdatedAt       web.TimeResponse `json:"updated_at"` // UpdatedAt contains multiple format options for display.
+}
+
+// Response transforms CreatedAssetTxn to the CreatedAssetTxnResponse that is used for display.
+func (m *CreatedAssetTxn) Response(ctx context.Context) *CreatedAssetTxnResponse {
+	if m == nil {
+		return nil
+	}
+
+	r := &CreatedAssetTxnResponse{
+		ID:              m.ID,
+		CreatedAssetID:  m.CreatedAssetID,
+		AccountID:       m.AccountID,
+		Type:            web.NewEnumResponse(ctx, m.Type, CreatedAssetTxnType_ValuesInterface()...),
+		SenderAddress:   m.SenderAddress,
+		TargetAddress:   m.TargetAddress,
+		ReceiverAddress: m.ReceiverAddress,
+		Amount:          m.Amount,
+		Frozen:          m.Frozen,
+		ManagerAddress:  m.ManagerAddress,
+		ReserveAddress:  m.ReserveAddress,
+		FreezeAddress:   m.FreezeAddress,
+		ClawbackAddress: m.ClawbackAddress,
+		GroupID:         m.GroupID,
+		GroupIndex:      m.GroupIndex,
+		Signed:          len(m.SignedTxn) > 0,
+		Status:          web.NewEnumResponse(ctx, m.Status, CreatedAssetTxnStatus_ValuesInterface()...),
+		Error:           m.Error,
+		TxID:            m.TxID,
+		LastValidRound:  m.LastValidRound,
+		ConfirmedRound:  m.ConfirmedRound,
+		CreatedAt:       web.NewTimeResponse(ctx, m.CreatedAt),
+		UpdatedAt:       web.NewTimeResponse(ctx, m.UpdatedAt),
+	}
+
+	if m.UserID != nil {
+		r.UserID = *m.UserID
+	}
+
+	if len(m.MultisigTxn) > 0 {
+		r.Multisig = true
+		r.Signatures, r.Threshold = multisigSignatures(m.MultisigTxn)
+	}
+
+	if m.Type == CreatedAssetTxnType_Payment {
+		if m.PaymentAssetID > 0 {
+			r.PaymentAsset = fmt.Sprintf("%d", m.PaymentAssetID)
+		} else {
+			r.PaymentAsset = "ALGO"
+		}
+	}
+
+	return r
+}
+
+// CreatedAssetTxns a list of CreatedAssetTxns.
+type CreatedAssetTxns []*CreatedAssetTxn
+
+// Response transforms a list of CreatedAssetTxns to a list of CreatedAssetTxnResponses.
+func (m *CreatedAssetTxns) Response(ctx context.Context) []*CreatedAssetTxnResponse {
+	var l []*CreatedAssetTxnResponse
+	if m != nil && len(*m) > 0 {
+		for _, n := range *m {
+			l = append(l, n.Response(ctx))
+		}
+	}
+
+	return l
+}
+
+// CreatedAssetConfigRequest defines the information needed to change the addresses of
+// a created asset. Fields that are not provided keep their current value, an address
+// provided as blank is cleared and can never be set again.
+type CreatedAssetConfigRequest struct {
+	ID              string  `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	ManagerAddress  *string `json:"manager_address,omitempty" validate:"omitempty,algorand_address"`
+	ReserveAddress  *string `json:"reserve_address,omitempty" validate:"omitempty,algorand_address"`
+	FreezeAddress   *string `json:"freeze_address,omitempty" validate:"omitempty,algorand_address"`
+	ClawbackAddress *string `json:"clawback_address,omitempty" validate:"omitempty,algorand_address"`
+}
+
+// CreatedAssetFreezeRequest defines the information needed to freeze or unfreeze a
+// created asset for a holder.
+type CreatedAssetFreezeRequest struct {
+	ID      string `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	Address string `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Frozen  bool   `json:"frozen" example:"true"`
+}
+
+// CreatedAssetClawbackRequest defines the information needed to revoke units of a created
+// asset from a holder. When the receiver is not provided the units are returned to the
+// reserve, or the creator when the asset has no reserve.
+type CreatedAssetClawbackRequest struct {
+	ID              string `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	Address         string `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	ReceiverAddress string `json:"receiver_address" validate:"omitempty,algorand_address"`
+	Amount          uint64 `json:"amount" validate:"required" example:"100"`
+}
+
+// CreatedAssetDestroyRequest defines the information needed to destroy a created asset.
+// All the units must be held by the creator for the network to accept it.
+type CreatedAssetDestroyRequest struct {
+	ID string `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+}
+
+// CreatedAssetOptInRequest defines the information needed for a user to accept a created
+// asset with an address they hold the key of.
+type CreatedAssetOptInRequest struct {
+	ID      string `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	UserID  string `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Address string `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+}
+
+// CreatedAssetTransferRequest defines the information needed to send units of a created
+// asset to an address a user has opted in with.
+type CreatedAssetTransferRequest struct {
+	ID      string `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	UserID  string `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Address string `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Amount  uint64 `json:"amount" validate:"required" example:"100"`
+}
+
+// CreatedAssetIssueRequest defines the information needed to issue units of a created asset
+// to an address a user has opted in with in exchange for a payment. The payment is made in
+// ALGO, or the asset with the payment asset ID such as USDC, to the creator of the asset.
+type CreatedAssetIssueRequest struct {
+	ID             string `json:"id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	UserID         string `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Address        string `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Amount         uint64 `json:"amount" validate:"required" example:"100"`
+	PaymentAmount  uint64 `json:"payment_amount" validate:"required" example:"2500000"`
+	PaymentAssetID uint64 `json:"payment_asset_id" example:"31566704"`
+}
+
+// CreatedAssetTxnReadRequest defines the information needed to read a created asset transaction.
+type CreatedAssetTxnReadRequest struct {
+	CreatedAssetID string `json:"created_asset_id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	ID             string `json:"id" validate:"required,uuid" example:"7b2a6a3e-3a4f-4f5e-9a35-5b3c9f5a2d11"`
+}
+
+// CreatedAssetTxnSignedRequest defines the information needed to submit a created asset
+// transaction that was signed offline.
+type CreatedAssetTxnSignedRequest struct {
+	CreatedAssetID string `json:"created_asset_id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	ID             string `json:"id" validate:"required,uuid" example:"7b2a6a3e-3a4f-4f5e-9a35-5b3c9f5a2d11"`
+	SignedTxn      []byte `json:"signed_txn" validate:"required" swaggertype:"string" example:"base64 encoded signed transaction"`
+}
+
+// CreatedAssetTxnType represents the type of transaction for managing a created asset.
+type CreatedAssetTxnType string
+
+// CreatedAssetTxnType values define the type field of the created asset transaction.
+const (
+	// CreatedAssetTxnType_Config defines the type for changing the addresses of an asset.
+	CreatedAssetTxnType_Config CreatedAssetTxnType = "config"
+	// CreatedAssetTxnType_Freeze defines the type for freezing or unfreezing an asset for a holder.
+	CreatedAssetTxnType_Freeze CreatedAssetTxnType = "freeze"
+	// CreatedAssetTxnType_Clawback defines the type for revoking units of an asset from a holder.
+	CreatedAssetTxnType_Clawback CreatedAssetTxnType = "clawback"
+	// CreatedAssetTxnType_Destroy defines the type for destroying an asset.
+	CreatedAssetTxnType_Destroy CreatedAssetTxnType = "destroy"
+	// CreatedAssetTxnType_OptIn defines the type for a holder accepting an asset.
+	CreatedAssetTxnType_OptIn CreatedAssetTxnType = "opt_in"
+	// CreatedAssetTxnType_Transfer defines the type for sending units of an asset to a holder.
+	CreatedAssetTxnType_Transfer CreatedAssetTxnType = "transfer"
+	// CreatedAssetTxnType_Payment defines the type for a holder paying for units of an asset.
+	CreatedAssetTxnType_Payment CreatedAssetTxnType = "payment"
+)
+
+// CreatedAssetTxnType_Values provides list of valid CreatedAssetTxnType values.
+var CreatedAssetTxnType_Values = []CreatedAssetTxnType{
+	CreatedAssetTxnType_Config,
+	CreatedAssetTxnType_Freeze,
+	CreatedAssetTxnType_Clawback,
+	CreatedAssetTxnType_Destroy,
+	CreatedAssetTxnType_OptIn,
+	CreatedAssetTxnType_Transfer,
+	CreatedAssetTxnType_Payment,
+}
+
+// CreatedAssetTxnType_ValuesInterface returns the CreatedAssetTxnType options as a slice interface.
+func CreatedAssetTxnType_ValuesInterface() []interface{} {
+	var l []interface{}
+	for _, v := range CreatedAssetTxnType_Values {
+		l = append(l, v.String())
+	}
+	return l
+}
+
+// Scan supports reading the CreatedAssetTxnType value from the database.
+func (s *CreatedAssetTxnType) Scan(value interface{}) error {
+	asBytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("Scan source is not []byte")
+	}
+
+	*s = CreatedAssetTxnType(string(asBytes))
+	return nil
+}
+
+// Value converts the CreatedAssetTxnType value to be stored in the database.
+func (s CreatedAssetTxnType) Value() (driver.Value, error) {
+	v := validator.New()
+	errs := v.Var(s, "required,oneof=config freeze clawback destroy opt_in transfer")
+	if errs != nil {
+		return nil, errs
+	}
+
+	return string(s), nil
+}
+
+// String converts the CreatedAssetTxnType value to a string.
+func (s CreatedAssetTxnType) String() string {
+	return string(s)
+}
+
+// CreatedAssetTxnStatus represents the progress of a created asset transaction on Algorand.
+type CreatedAssetTxnStatus string
+
+// CreatedAssetTxnStatus values define the status field of the created asset transaction.
+const (
+	// CreatedAssetTxnStatus_Draft defines the status for a transaction waiting to be signed.
+	CreatedAssetTxnStatus_Draft CreatedAssetTxnStatus = "draft"
+	// CreatedAssetTxnStatus_Submitted defines the status for a transaction waiting to be confirmed.
+	CreatedAssetTxnStatus_Submitted CreatedAssetTxnStatus = "submitted"
+	// CreatedAssetTxnStatus_Confirmed defines the status for a transaction confirmed by the network.
+	CreatedAssetTxnStatus_Confirmed CreatedAssetTxnStatus = "confirmed"
+	// CreatedAssetTxnStatus_Failed defines the status for a transaction that will never be confirmed.
+	CreatedAssetTxnStatus_Failed CreatedAssetTxnStatus = "failed"
+)
+
+// CreatedAssetTxnStatus_Values provides list of valid CreatedAssetTxnStatus values.
+var CreatedAssetTxnStatus_Values = []CreatedAssetTxnStatus{
+	CreatedAssetTxnStatus_Draft,
+	CreatedAssetTxnStatus_Submitted,
+	CreatedAssetTxnStatus_Confirmed,
+	CreatedAssetTxnStatus_Failed,
+}
+
+// CreatedAssetTxnStatus_ValuesInterface returns the CreatedAssetTxnStatus options as a slice interface.
+func CreatedAssetTxnStatus_ValuesInterface() []interface{} {
+	var l []interface{}
+	for _, v := range CreatedAssetTxnStatus_Values {
+		l = append(l, v.String())
+	}
+	return l
+}
+
+// Scan supports reading the CreatedAssetTxnStatus value from the database.
+func (s *CreatedAssetTxnStatus) Scan(value interface{}) error {
+	asBytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("Scan source is not []byte")
+	}
+
+	*s = CreatedAssetTxnStatus(string(asBytes))
+	return nil
+}
+
+// Value converts the CreatedAssetTxnStatus value to be stored in the database.
+func (s CreatedAssetTxnStatus) Value() (driver.Value, error) {
+	v := validator.New()
+	errs := v.Var(s, "required,oneof=draft submitted confirmed failed")
+	if errs != nil {
+		return nil, errs
+	}
 
+	return string(s), nil
+}
+
+// String converts the CreatedAssetTxnStatus value to a string.
+func (s CreatedAssetTxnStatus) String() string {
+	return string(s)
+}
+
+// CreatedAssetHolder represents the balance of an address holding a created asset as
+// reported by the indexer at the round of the last snapshot.
+type CreatedAssetHolder struct {
+	ID             string    `json:"id" validate:"required,uuid" example:"4c2b9d0e-5c55-4e0a-a1c4-3f5d6e7a8b9c"`
+	CreatedAssetID string    `json:"created_asset_id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	AccountID      string    `json:"account_id" validate:"required,uuid" truss:"api-create"`
+	Address        string    `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Amount         uint64    `json:"amount" example:"100"`
+	Frozen         bool      `json:"frozen" example:"false"`
+	OptedInRound   uint64    `json:"opted_in_round" example:"8291045"`
+	Round          uint64    `json:"round" example:"8291201"`
+	CreatedAt      time.Time `json:"created_at" truss:"api-read"`
+	UpdatedAt      time.Time `json:"updated_at" truss:"api-read"`
+}
+
+// CreatedAssetHolderResponse represents a holder of a created asset that is returned for display.
+type CreatedAssetHolderResponse struct {
+	ID             string           `json:"id" example:"4c2b9d0e-5c55-4e0a-a1c4-3f5d6e7a8b9c"`
+	CreatedAssetID string           `json:"created_asset_id" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	Address        string           `json:"address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Amount         uint64           `json:"amount" example:"100"`
+	Frozen         bool             `json:"frozen" example:"false"`
+	OptedInRound   uint64           `json:"opted_in_round" example:"8291045"`
+	Round          uint64           `json:"round" example:"8291201"`
+	CreatedAt      web.TimeResponse `json:"created_at"` // CreatedAt contains multiple format options for display.
+	UpdatedAt      web.TimeResponse `json:"updated_at"` // UpdatedAt contains multiple format options for display.
+}
+
+// Response transforms CreatedAssetHolder to the CreatedAssetHolderResponse that is used for display.
+func (m *CreatedAssetHolder) Response(ctx context.Context) *CreatedAssetHolderResponse {
+	if m == nil {
+		return nil
+	}
+
+	return &CreatedAssetHolderResponse{
+		ID:             m.ID,
+		CreatedAssetID: m.CreatedAssetID,
+		Address:        m.Address,
+		Amount:         m.Amount,
+		Frozen:         m.Frozen,
+		OptedInRound:   m.OptedInRound,
+		Round:          m.Round,
+		CreatedAt:      web.NewTimeResponse(ctx, m.CreatedAt),
+		UpdatedAt:      web.NewTimeResponse(ctx, m.UpdatedAt),
+	}
+}
+
+// CreatedAssetHolders a list of CreatedAssetHolders.
+type CreatedAssetHolders []*CreatedAssetHolder
+
+// Response transforms a list of CreatedAssetHolders to a list of CreatedAssetHolderResponses.
+func (m *CreatedAssetHolders) Response(ctx context.Context) []*CreatedAssetHolderResponse {
+	var l []*CreatedAssetHolderResponse
+	if m != nil && len(*m) > 0 {
+		for _, n := range *m {
+			l = append(l, n.Response(ctx))
+		}
+	}
+
+	return l
+}
+
+// CreatedAssetHolderFindRequest defines the possible options to search for the holders
+// of a created asset.
+type CreatedAssetHolderFindRequest struct {
+	CreatedAssetID string        `json:"created_asset_id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	Where          string        `json:"where" example:"frozen = ?"`
+	Args           []interface{} `json:"args" swaggertype:"array,string" example:"true"`
+	Order          []string      `json:"order" example:"amount desc"`
+	Limit          *uint         `json:"limit" example:"10"`
+	Offset         *uint         `json:"offset" example:"20"`
+}
+
+// CreatedAssetVestingGrant represents units of a created asset granted to a user that vest over
+// time, ie: the equity of a founder or an employee. Nothing vests before the cliff, after it the
+// units vest in tranches every interval until the end of the period. Acceleration vests the
+// percent of the unvested units once it's triggered and cancelling the grant stops the vesting.
+type CreatedAssetVestingGrant struct {
+	ID                  string       `json:"id" validate:"required,uuid" example:"2b0f5c8e-6d3a-4f7b-9e1c-8a4d2f6b0c3e"`
+	CreatedAssetID      string       `json:"created_asset_id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	AccountID           string       `json:"account_id" validate:"required,uuid" truss:"api-create"`
+	UserID              string       `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Address             string       `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Total               uint64       `json:"total" validate:"required" example:"48000"`
+	StartDate           time.Time    `json:"start_date" validate:"required" example:"2020-05-01T00:00:00Z"`
+	CliffMonths         int          `json:"cliff_months" example:"12"`
+	PeriodMonths        int          `json:"period_months" validate:"required" example:"48"`
+	IntervalMonths      int          `json:"interval_months" validate:"required" example:"1"`
+	AccelerationPercent int          `json:"acceleration_percent" example:"100"`
+	AcceleratedAt       *pq.NullTime `json:"accelerated_at,omitempty" truss:"api-read"`
+	CancelledAt         *pq.NullTime `json:"cancelled_at,omitempty" truss:"api-read"`
+	Released            uint64       `json:"released" truss:"api-read"`
+	CreatedAt           time.Time    `json:"created_at" truss:"api-read"`
+	UpdatedAt           time.Time    `json:"updated_at" truss:"api-read"`
+}
+
+// CreatedAssetVestingGrantResponse represents a vesting grant that is returned for display.
+type CreatedAssetVestingGrantResponse struct {
+	ID                  string                                `json:"id" example:"2b0f5c8e-6d3a-4f7b-9e1c-8a4d2f6b0c3e"`
+	CreatedAssetID      string                                `json:"created_asset_id" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	UserID              string                                `json:"user_id" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Address             string                                `json:"address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Total               uint64                                `json:"total" example:"48000"`
+	Vested              uint64                                `json:"vested" example:"12000"`
+	Unvested            uint64                                `json:"unvested" example:"36000"`
+	Released            uint64                                `json:"released" example:"11000"`
+	Releasable          uint64                                `json:"releasable" example:"1000"`
+	StartDate           web.TimeResponse                      `json:"start_date"` // StartDate contains multiple format options for display.
+	CliffMonths         int                                   `json:"cliff_months" example:"12"`
+	PeriodMonths        int                                   `json:"period_months" example:"48"`
+	IntervalMonths      int                                   `json:"interval_months" example:"1"`
+	AccelerationPercent int                                   `json:"acceleration_percent" example:"100"`
+	NextVestingDate     *web.TimeResponse                     `json:"next_vesting_date,omitempty"` // NextVestingDate contains multiple format options for display.
+	AcceleratedAt       *web.TimeResponse                     `json:"accelerated_at,omitempty"`    // AcceleratedAt contains multiple format options for display.
+	CancelledAt         *web.TimeResponse                     `json:"cancelled_at,omitempty"`      // CancelledAt contains multiple format options for display.
+	Schedule            []*CreatedAssetVestingTrancheResponse `json:"schedule"`
+	CreatedAt           web.TimeResponse                      `json:"created_at"` // CreatedAt contains multiple format options for display.
+}
+
+// Response transforms CreatedAssetVestingGrant to the CreatedAssetVestingGrantResponse that is
+// used for display with the units vested at now.
+func (m *CreatedAssetVestingGrant) Response(ctx context.Context, now time.Time) *CreatedAssetVestingGrantResponse {
+	if m == nil {
+		return nil
+	}
+
+	vested := m.VestedAmount(now)
+
+	r := &CreatedAssetVestingGrantResponse{
+		ID:                  m.ID,
+		CreatedAssetID:      m.CreatedAssetID,
+		UserID:              m.UserID,
+		Address:             m.Address,
+		Total:               m.Total,
+		Vested:              vested,
+		Unvested:            m.Total - vested,
+		Released:            m.Released,
+		StartDate:           web.NewTimeResponse(ctx, m.StartDate),
+		CliffMonths:         m.CliffMonths,
+		PeriodMonths:        m.PeriodMonths,
+		IntervalMonths:      m.IntervalMonths,
+		AccelerationPercent: m.AccelerationPercent,
+		CreatedAt:           web.NewTimeResponse(ctx, m.CreatedAt),
+	}
+
+	if vested > m.Released {
+		r.Releasable = vested - m.Released
+	}
+
+	for _, t := range m.Schedule() {
+		r.Schedule = append(r.Schedule, &CreatedAssetVestingTrancheResponse{
+			Date:   web.NewTimeResponse(ctx, t.Date),
+			Amount: t.Amount,
+			Vested: t.Vested,
+		})
+
+		if r.NextVestingDate == nil && t.Date.After(now) {
+			at := web.NewTimeResponse(ctx, t.Date)
+			r.NextVestingDate = &at
+		}
+	}
+
+	if m.AcceleratedAt != nil && m.AcceleratedAt.Valid {
+		at := web.NewTimeResponse(ctx, m.AcceleratedAt.Time)
+		r.AcceleratedAt = &at
+	}
+
+	if m.isCancelled() {
+		at := web.NewTimeResponse(ctx, m.CancelledAt.Time)
+		r.CancelledAt = &at
+	}
+
+	return r
+}
+
+// CreatedAssetVestingGrants a list of CreatedAssetVestingGrants.
+type CreatedAssetVestingGrants []*CreatedAssetVestingGrant
+
+// Response transforms a list of CreatedAssetVestingGrants to a list of CreatedAssetVestingGrantResponses.
+func (m *CreatedAssetVestingGrants) Response(ctx context.Context, now time.Time) []*CreatedAssetVestingGrantResponse {
+	var l []*CreatedAssetVestingGrantResponse
+	if m != nil && len(*m) > 0 {
+		for _, n := range *m {
+			l = append(l, n.Response(ctx, now))
+		}
+	}
+
+	return l
+}
+
+// CreatedAssetVestingTranche is a date of the schedule of a vesting grant when units vest.
+type CreatedAssetVestingTranche struct {
+	Date   time.Time `json:"date"`
+	Amount uint64    `json:"amount" example:"1000"`
+	Vested uint64    `json:"vested" example:"13000"`
+}
+
+// CreatedAssetVestingTrancheResponse represents a tranche of a vesting grant that is returned for display.
+type CreatedAssetVestingTrancheResponse struct {
+	Date   web.TimeResponse `json:"date"` // Date contains multiple format options for display.
+	Amount uint64           `json:"amount" example:"1000"`
+	Vested uint64           `json:"vested" example:"13000"`
+}
+
+// CreatedAssetVestingGrantCreateRequest contains information needed to grant units of a created
+// asset that vest for a user. The vested units are sent from the reserve to the address.
+type CreatedAssetVestingGrantCreateRequest struct {
+	CreatedAssetID      string    `json:"created_asset_id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	UserID              string    `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Address             string    `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Total               uint64    `json:"total" validate:"required" example:"48000"`
+	StartDate           time.Time `json:"start_date" validate:"required" example:"2020-05-01T00:00:00Z"`
+	CliffMonths         int       `json:"cliff_months" validate:"omitempty,min=0,ltefield=PeriodMonths" example:"12"`
+	PeriodMonths        int       `json:"period_months" validate:"required,min=1,max=600" example:"48"`
+	IntervalMonths      int       `json:"interval_months" validate:"required,min=1,ltefield=PeriodMonths" example:"1"`
+	AccelerationPercent int       `json:"acceleration_percent" validate:"omitempty,min=0,max=100" example:"100"`
+}
+
+// CreatedAssetVestingGrantRequest defines the vesting grant of a created asset an action is
+// taken for.
+type CreatedAssetVestingGrantRequest struct {
+	CreatedAssetID string `json:"created_asset_id" validate:"required,uuid" example:"985f1746-1d9f-459f-a2d9-fc53ece5ae86"`
+	ID             string `json:"id" validate:"required,uuid" example:"2b0f5c8e-6d3a-4f7b-9e1c-8a4d2f6b0c3e"`
+}
@@ -0,0 +1,217 @@
+package createasset
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"time"
+
+	"exitor-dapp/internal/wallet"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/pkg/errors"
+)
+
+/* The manager, freeze and clawback roles of an asset can be held by a
+multisig account of the account, ie: 2-of-3 directors. A transaction sent
+from a multisig is recorded with the multisig transaction that has no
+signatures yet. Each member downloads it, appends their signature offline
+and uploads it again. The partial signatures are merged and once the
+threshold is met the transaction is submitted like any other signed
+transaction. */
+
+// setMultisigTxn sets the multisig transaction to be signed by the members when the sender of
+// the created asset transaction is a multisig of the account.
+func (repo *Repository) setMultisigTxn(ctx context.Context, t *CreatedAssetTxn, tx types.Transaction) error {
+	ms, err := wallet.FindMultisigByAddress(ctx, repo.DbConn, t.AccountID, t.SenderAddress)
+	if err != nil {
+		if errors.Cause(err) == wallet.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+
+	ma, err := ms.MultisigAccount()
+	if err != nil {
+		return err
+	}
+
+	stx := types.SignedTxn{
+		Txn: tx,
+		Msig: types.MultisigSig{
+			Version:   ma.Version,
+			Threshold: ma.Threshold,
+		},
+	}
+	for _, pk := range ma.Pks {
+		stx.Msig.Subsigs = append(stx.Msig.Subsigs, types.MultisigSubsig{Key: pk})
+	}
+
+	t.MultisigTxn = msgpack.Encode(stx)
+
+	return nil
+}
+
+// mergeMultisigTxn verifies the partially signed multisig transaction and merges its signatures
+// with the ones already collected for the draft created asset transaction. The merged
+// transaction is only returned once the threshold of signatures is met.
+func (repo *Repository) mergeMultisigTxn(ctx context.Context, t *CreatedAssetTxn, stx types.SignedTxn, now time.Time) (*types.SignedTxn, error) {
+	err := VerifyMultisigTxn(stx, t.UnsignedTxn)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	// Lock the transaction so the signatures of members uploaded at the same time are not lost.
+	var collected []byte
+	{
+		query := sqlbuilder.NewSelectBuilder().Select("multisig_txn").From(CreatedAssetTxnTableName)
+		query.Where(query.And(
+			query.Equal("id", t.ID),
+			query.Equal("status", CreatedAssetTxnStatus_Draft.String()),
+		))
+		queryStr, args := query.Build()
+		queryStr = dbTx.Rebind(queryStr) + " FOR UPDATE"
+
+		err = dbTx.QueryRowContext(ctx, queryStr, args...).Scan(&collected)
+		if err == sql.ErrNoRows {
+			return nil, errors.WithMessagef(ErrInvalidTxnStatus, "created asset transaction %s was already submitted", t.ID)
+		} else if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+	}
+
+	partial := msgpack.Encode(stx)
+	if len(collected) > 0 {
+		_, partial, err = crypto.MergeMultisigTransactions(collected, partial)
+		if err != nil {
+			return nil, errors.WithMessage(ErrSignedTxnMismatch, err.Error())
+		}
+	}
+
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(CreatedAssetTxnTableName)
+	query.Set(
+		query.Assign("multisig_txn", partial),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.Equal("id", t.ID))
+
+	sql, args := query.Build()
+	sql = dbTx.Rebind(sql)
+	_, err = dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "store multisig transaction for created asset transaction %s failed", t.ID)
+		return nil, err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if signed, threshold := multisigSignatures(partial); signed < threshold {
+		// Wait for the rest of the members to sign.
+		return nil, nil
+	}
+
+	var merged types.SignedTxn
+	if err := msgpack.Decode(partial, &merged); err != nil {
+		return nil, errors.Wrapf(err, "decode multisig transaction for created asset transaction %s failed", t.ID)
+	}
+
+	return &merged, nil
+}
+
+// VerifyMultisigTxn ensures the transaction of the partially signed multisig transaction is the
+// issued msgpack encoded transaction, the multisig is the sender of the transaction or the
+// account the sender has been rekeyed to and each of the signatures was made by its member.
+func VerifyMultisigTxn(stx types.SignedTxn, issued []byte) error {
+	if !bytes.Equal(msgpack.Encode(stx.Txn), issued) {
+		return errors.WithStack(ErrSignedTxnMismatch)
+	}
+
+	ma := crypto.MultisigAccount{
+		Version:   stx.Msig.Version,
+		Threshold: stx.Msig.Threshold,
+	}
+	for _, s := range stx.Msig.Subsigs {
+		ma.Pks = append(ma.Pks, s.Key)
+	}
+
+	addr, err := ma.Address()
+	if err != nil {
+		return errors.WithMessage(ErrSignedTxnInvalidSignature, err.Error())
+	}
+
+	signer := stx.Txn.Sender
+	if !stx.AuthAddr.IsZero() {
+		signer = stx.AuthAddr
+	}
+
+	if addr != signer {
+		return errors.WithMessagef(ErrSignedTxnInvalidSignature, "multisig %s is not %s", addr.String(), signer.String())
+	}
+
+	msg := append(append([]byte{}, txnSignPrefix...), issued...)
+
+	var signed int
+	for _, s := range stx.Msig.Subsigs {
+		if s.Sig == (types.Signature{}) {
+			continue
+		}
+
+		if !ed25519.Verify(ed25519.PublicKey(s.Key), msg, s.Sig[:]) {
+			var member types.Address
+			copy(member[:], s.Key)
+			return errors.WithMessagef(ErrSignedTxnInvalidSignature, "signature was not made by member %s", member.String())
+		}
+		signed++
+	}
+
+	if signed == 0 {
+		return errors.WithMessage(ErrSignedTxnInvalidSignature, "transaction has not been signed by any member of the multisig")
+	}
+
+	return nil
+}
+
+// multisigSignatures returns the number of members that have signed the msgpack encoded
+// multisig transaction and the number of signatures required.
+func multisigSignatures(b []byte) (int, int) {
+	var stx types.SignedTxn
+	if err := msgpack.Decode(b, &stx); err != nil {
+		return 0, 0
+	}
+
+	var signed int
+	for _, s := range stx.Msig.Subsigs {
+		if s.Sig != (types.Signature{}) {
+			signed++
+		}
+	}
+
+	return signed, int(stx.Msig.Threshold)
+}
@@ -0,0 +1,245 @@
+package createasset
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* Offline signing lets the creator of an asset sign the asset create
+transaction without their keys ever reaching Exitor. The unsigned
+transaction is issued and stored on the created asset, it's signed
+with goal clerk sign or a wallet and the signed transaction is uploaded
+and checked against what was issued before being broadcast. */
+
+var (
+	// ErrSignedTxnMismatch occurs when an uploaded signed transaction is not the
+	// transaction that was issued for the created asset.
+	ErrSignedTxnMismatch = errors.New("Signed transaction does not match the issued transaction")
+
+	// ErrSignedTxnInvalidSignature occurs when the signature of an uploaded signed
+	// transaction was not made by the sender of the transaction.
+	ErrSignedTxnInvalidSignature = errors.New("Signed transaction has an invalid signature")
+)
+
+// txnSignPrefix is the domain separation prefix for signing transactions.
+var txnSignPrefix = []byte("TX")
+
+// UnsignedCreateTxn issues the unsigned asset create transaction for a draft or failed
+// created asset so it can be signed offline. The issued transaction is returned again
+// until it expires so a transaction signed from an earlier download is still accepted.
+func (repo *Repository) UnsignedCreateTxn(ctx context.Context, claims auth.Claims, req CreatedAssetMintRequest, now time.Time) (*UnsignedTxn, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.UnsignedCreateTxn")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can modify the created asset specified in the request.
+	err = repo.CanModifyCreatedAsset(ctx, claims, req.ID, auth.PermissionAssetMint)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.ReadByID(ctx, claims, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.MintStatus != CreatedAssetMintStatus_Draft && m.MintStatus != CreatedAssetMintStatus_Failed {
+		return nil, errors.WithMessagef(ErrInvalidMintStatus, "created asset %s is %s", m.ID, m.MintStatus)
+	}
+
+	network, err := repo.AlgoClient.Network(m.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.UnsignedTxn) > 0 {
+		status, err := network.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if status.LastRound < m.LastValidRound {
+			var tx types.Transaction
+			if err := msgpack.Decode(m.UnsignedTxn, &tx); err != nil {
+				return nil, errors.Wrapf(err, "decode unsigned transaction for created asset %s failed", m.ID)
+			}
+
+			return &UnsignedTxn{
+				CreatedAssetID: m.ID,
+				TxID:           crypto.TransactionIDString(tx),
+				LastValidRound: m.LastValidRound,
+				Txn:            m.UnsignedTxn,
+			}, nil
+		}
+	}
+
+	// Bind the metadata to the asset before the transaction is built.
+	err = repo.publishMetadata(ctx, m, now)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := repo.MakeCreateTxn(ctx, network, m)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &UnsignedTxn{
+		CreatedAssetID: m.ID,
+		TxID:           crypto.TransactionIDString(tx),
+		LastValidRound: uint64(tx.LastValid),
+		Txn:            msgpack.Encode(tx),
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	// Build the update SQL statement, the asset must not have been submitted since it
+	// was read.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(CreatedAssetTableName)
+	query.Set(
+		query.Assign("unsigned_txn", res.Txn),
+		query.Assign("last_valid_round", res.LastValidRound),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.And(
+		query.Equal("id", m.ID),
+		query.In("mint_status", CreatedAssetMintStatus_Draft.String(), CreatedAssetMintStatus_Failed.String()),
+	))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	dbRes, err := repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "store unsigned transaction for created asset %s failed", m.ID)
+		return nil, err
+	}
+
+	if n, err := dbRes.RowsAffected(); err != nil {
+		return nil, errors.WithStack(err)
+	} else if n == 0 {
+		return nil, errors.WithMessagef(ErrInvalidMintStatus, "created asset %s is already being minted", m.ID)
+	}
+
+	return res, nil
+}
+
+// SubmitSignedTxn verifies the uploaded signed transaction is the transaction issued
+// by UnsignedCreateTxn and is signed by its sender, then broadcasts it to the network.
+func (repo *Repository) SubmitSignedTxn(ctx context.Context, claims auth.Claims, req CreatedAssetSignedTxnRequest, now time.Time) (*CreatedAsset, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.SubmitSignedTxn")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can modify the created asset specified in the request.
+	err = repo.CanModifyCreatedAsset(ctx, claims, req.ID, auth.PermissionAssetMint)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.ReadByID(ctx, claims, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.MintStatus != CreatedAssetMintStatus_Draft && m.MintStatus != CreatedAssetMintStatus_Failed {
+		return nil, errors.WithMessagef(ErrInvalidMintStatus, "created asset %s is %s", m.ID, m.MintStatus)
+	} else if len(m.UnsignedTxn) == 0 {
+		return nil, errors.WithMessagef(ErrSignedTxnMismatch, "no transaction has been issued for created asset %s", m.ID)
+	}
+
+	stx, err := DecodeSignedTxn(req.SignedTxn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = VerifySignedTxn(stx, m.UnsignedTxn)
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := repo.AlgoClient.Network(m.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.submit(ctx, network, m, stx.Txn, msgpack.Encode(stx), now)
+}
+
+// DecodeSignedTxn decodes a msgpack encoded signed transaction. The transaction can
+// also be base64 encoded as it's output by most wallets.
+func DecodeSignedTxn(b []byte) (types.SignedTxn, error) {
+	if raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b))); err == nil {
+		b = raw
+	}
+
+	var stx types.SignedTxn
+	if err := msgpack.Decode(b, &stx); err != nil {
+		return stx, errors.Wrap(err, "decode signed transaction failed")
+	}
+
+	return stx, nil
+}
+
+// VerifySignedTxn ensures the transaction of the signed transaction is the issued
+// msgpack encoded transaction and it was signed by the sender of the transaction or
+// the account the sender has been rekeyed to.
+func VerifySignedTxn(stx types.SignedTxn, issued []byte) error {
+	if !bytes.Equal(msgpack.Encode(stx.Txn), issued) {
+		return errors.WithStack(ErrSignedTxnMismatch)
+	}
+
+	if stx.Sig == (types.Signature{}) {
+		return errors.WithMessage(ErrSignedTxnInvalidSignature, "transaction must be signed by a single account")
+	}
+
+	signer := stx.Txn.Sender
+	if !stx.AuthAddr.IsZero() {
+		signer = stx.AuthAddr
+	}
+
+	msg := append(append([]byte{}, txnSignPrefix...), issued...)
+	if !ed25519.Verify(ed25519.PublicKey(signer[:]), msg, stx.Sig[:]) {
+		return errors.WithMessagef(ErrSignedTxnInvalidSignature, "signature was not made by %s", signer.String())
+	}
+
+	return nil
+}
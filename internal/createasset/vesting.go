@@ -0,0 +1,694 @@
+package createasset
+
+import (
+	"context"
+	"database/sql"
+	"math/bits"
+	"sort"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* The equity of founders and employees is granted as units of a created asset
+that vest over time. A grant records the holder, the total units, the start
+date, the cliff and the period in months, and the interval between tranches.
+Nothing vests before the cliff, at the cliff the units of the months passed
+vest at once and after it a tranche vests every interval until the end of
+the period. Acceleration vests the percent of the unvested units at once,
+ie: on a change of control, and cancelling a grant stops the vesting when
+the holder leaves. The vested units are released by transfers from the
+reserve of the asset that are prepared in the background as tranches vest,
+releases Exitor can't sign are left as drafts to be signed offline. */
+
+const (
+	// The database table for created asset vesting grants
+	CreatedAssetVestingGrantTableName = "created_asset_vesting_grants"
+	// The database table for the releases of created asset vesting grants
+	CreatedAssetVestingReleaseTableName = "created_asset_vesting_releases"
+)
+
+var (
+	// ErrVestingTotalExceeded occurs when the units granted exceed the total of the created asset.
+	ErrVestingTotalExceeded = errors.New("Vesting grants exceed the total of the created asset")
+
+	// ErrVestingGrantCancelled occurs when a vesting grant that has been cancelled is changed.
+	ErrVestingGrantCancelled = errors.New("Vesting grant has been cancelled")
+
+	// ErrVestingGrantAccelerated occurs when a vesting grant can't be accelerated, it has
+	// already been accelerated or has no acceleration.
+	ErrVestingGrantAccelerated = errors.New("Vesting grant can't be accelerated")
+
+	// ErrNothingToRelease occurs when all the vested units of a vesting grant have been released.
+	ErrNothingToRelease = errors.New("No vested units of the vesting grant to release")
+)
+
+// isCancelled returns true when the vesting grant has been cancelled.
+func (m *CreatedAssetVestingGrant) isCancelled() bool {
+	return m.CancelledAt != nil && m.CancelledAt.Valid
+}
+
+// isAccelerated returns true when the vesting grant has been accelerated.
+func (m *CreatedAssetVestingGrant) isAccelerated() bool {
+	return m.AcceleratedAt != nil && m.AcceleratedAt.Valid
+}
+
+// VestedAmount returns the units of the vesting grant that have vested at the time. The
+// vesting stops when the grant is cancelled and the accelerated units vest on top of the
+// schedule once the grant is accelerated.
+func (m *CreatedAssetVestingGrant) VestedAmount(at time.Time) uint64 {
+	if m.isCancelled() && at.After(m.CancelledAt.Time) {
+		at = m.CancelledAt.Time
+	}
+
+	vested := m.scheduledAmount(at)
+
+	if m.isAccelerated() && !m.AcceleratedAt.Time.After(at) {
+		unvested := m.Total - m.scheduledAmount(m.AcceleratedAt.Time)
+		vested += mulDiv(unvested, uint64(m.AccelerationPercent), 100)
+		if vested > m.Total {
+			vested = m.Total
+		}
+	}
+
+	return vested
+}
+
+// Releasable returns the units of the vesting grant that have vested at the time and have
+// not been released yet.
+func (m *CreatedAssetVestingGrant) Releasable(at time.Time) uint64 {
+	vested := m.VestedAmount(at)
+	if vested <= m.Released {
+		return 0
+	}
+	return vested - m.Released
+}
+
+// Schedule returns the tranches of the vesting grant, the dates the vested units increase.
+func (m *CreatedAssetVestingGrant) Schedule() []CreatedAssetVestingTranche {
+	var dates []time.Time
+	for month := 1; month <= m.PeriodMonths; month++ {
+		dates = append(dates, m.StartDate.AddDate(0, month, 0))
+	}
+
+	if m.isAccelerated() {
+		dates = append(dates, m.AcceleratedAt.Time)
+		sort.Slice(dates, func(i, j int) bool {
+			return dates[i].Before(dates[j])
+		})
+	}
+
+	var (
+		tranches []CreatedAssetVestingTranche
+		prev     uint64
+	)
+	for _, d := range dates {
+		vested := m.VestedAmount(d)
+		if vested > prev {
+			tranches = append(tranches, CreatedAssetVestingTranche{
+				Date:   d,
+				Amount: vested - prev,
+				Vested: vested,
+			})
+			prev = vested
+		}
+	}
+
+	return tranches
+}
+
+// scheduledAmount returns the units of the vesting grant that have vested at the time
+// according to its schedule.
+func (m *CreatedAssetVestingGrant) scheduledAmount(at time.Time) uint64 {
+	months := monthsBetween(m.StartDate, at)
+	if months < 0 || months < m.CliffMonths || m.PeriodMonths <= 0 {
+		return 0
+	} else if months >= m.PeriodMonths {
+		return m.Total
+	}
+
+	if m.IntervalMonths > 1 {
+		months -= months % m.IntervalMonths
+	}
+
+	return mulDiv(m.Total, uint64(months), uint64(m.PeriodMonths))
+}
+
+// monthsBetween returns the number of whole months that have passed from start at the time.
+func monthsBetween(start, at time.Time) int {
+	if at.Before(start) {
+		return -1
+	}
+
+	months := (at.Year()-start.Year())*12 + int(at.Month()) - int(start.Month())
+	if start.AddDate(0, months, 0).After(at) {
+		months--
+	}
+
+	return months
+}
+
+// mulDiv returns a * b / c without overflowing, the result must fit in an uint64.
+func mulDiv(a, b, c uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	q, _ := bits.Div64(hi, lo, c)
+	return q
+}
+
+// createdAssetVestingGrantMapColumns is the list of columns needed for find. The units
+// released are the sum of the releases whose transfer has not failed. Drafts and submitted
+// transfers count as released until they're reconciled, a transfer is only failed once the
+// node rejected it or the watcher found it expired past its last valid round.
+var createdAssetVestingGrantMapColumns = "id,created_asset_id,account_id,user_id,address,total,start_date,cliff_months,period_months," +
+	"interval_months,acceleration_percent,accelerated_at,cancelled_at,created_at,updated_at," +
+	"coalesce((select sum(r.amount) from " + CreatedAssetVestingReleaseTableName + " r join " + CreatedAssetTxnTableName + " t " +
+	"on t.id = r.created_asset_txn_id where r.vesting_grant_id = " + CreatedAssetVestingGrantTableName + ".id " +
+	"and t.status != '" + string(CreatedAssetTxnStatus_Failed) + "'), 0) as released"
+
+// CreateVestingGrant grants units of the created asset to a user that vest over time. The
+// units are released from the reserve of the asset to the address of the user.
+func (repo *Repository) CreateVestingGrant(ctx context.Context, claims auth.Claims, req CreatedAssetVestingGrantCreateRequest, now time.Time) (*CreatedAssetVestingGrant, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.CreateVestingGrant")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.manageableAsset(ctx, claims, req.CreatedAssetID, auth.PermissionAssetTransfer)
+	if err != nil {
+		return nil, err
+	} else if m.ReserveAddress == "" {
+		return nil, errors.WithMessagef(ErrAssetAddressNotSet, "created asset %s has no reserve address, vested units can't be released", m.ID)
+	}
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	// Lock the created asset so grants created at the same time can't exceed its total.
+	{
+		query := sqlbuilder.NewSelectBuilder().Select("id").From(CreatedAssetTableName)
+		query.Where(query.Equal("id", m.ID))
+		queryStr, args := query.Build()
+		queryStr = dbTx.Rebind(queryStr) + " FOR UPDATE"
+
+		var lockedID string
+		err = dbTx.QueryRowContext(ctx, queryStr, args...).Scan(&lockedID)
+		if err == sql.ErrNoRows {
+			return nil, errors.WithMessagef(ErrNotFound, "created asset %s not found", m.ID)
+		} else if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+	}
+
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("created_asset_id", m.ID),
+		query.IsNull("cancelled_at"),
+	))
+
+	grants, err := findVestingGrants(ctx, auth.Claims{}, dbTx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// The reserve must hold the units of all the grants that have not been cancelled.
+	var granted uint64
+	for _, g := range grants {
+		granted += g.Total
+	}
+	if granted > m.Total || req.Total > m.Total-granted {
+		return nil, errors.WithMessagef(ErrVestingTotalExceeded, "%d of %d units of created asset %s are already granted", granted, m.Total, m.ID)
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	g := CreatedAssetVestingGrant{
+		ID:                  uuid.NewRandom().String(),
+		CreatedAssetID:      m.ID,
+		AccountID:           m.AccountID,
+		UserID:              req.UserID,
+		Address:             req.Address,
+		Total:               req.Total,
+		StartDate:           req.StartDate.UTC().Truncate(time.Millisecond),
+		CliffMonths:         req.CliffMonths,
+		PeriodMonths:        req.PeriodMonths,
+		IntervalMonths:      req.IntervalMonths,
+		AccelerationPercent: req.AccelerationPercent,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+
+	// Build the insert SQL statement.
+	insert := sqlbuilder.NewInsertBuilder()
+	insert.InsertInto(CreatedAssetVestingGrantTableName)
+	insert.Cols("id", "created_asset_id", "account_id", "user_id", "address", "total", "start_date", "cliff_months", "period_months",
+		"interval_months", "acceleration_percent", "created_at", "updated_at")
+	insert.Values(g.ID, g.CreatedAssetID, g.AccountID, g.UserID, g.Address, g.Total, g.StartDate, g.CliffMonths, g.PeriodMonths,
+		g.IntervalMonths, g.AccelerationPercent, g.CreatedAt, g.UpdatedAt)
+
+	// Execute the query with the provided context.
+	sql, args := insert.Build()
+	sql = dbTx.Rebind(sql)
+	_, err = dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", insert.String())
+		err = errors.WithMessagef(err, "create vesting grant for created asset %s failed", m.ID)
+		return nil, err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &g, nil
+}
+
+// FindVestingGrants gets the vesting grants of the created asset, users that can't read all
+// the assets of the account only get their own.
+func (repo *Repository) FindVestingGrants(ctx context.Context, claims auth.Claims, createdAssetID string) (CreatedAssetVestingGrants, error) {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("created_asset_id", createdAssetID))
+	query.OrderBy("created_at asc")
+
+	return findVestingGrants(ctx, claims, repo.DbConn, query)
+}
+
+// FindUserVestingGrants gets the vesting grants of the created asset for the user.
+func (repo *Repository) FindUserVestingGrants(ctx context.Context, claims auth.Claims, createdAssetID, userID string) (CreatedAssetVestingGrants, error) {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("created_asset_id", createdAssetID),
+		query.Equal("user_id", userID),
+	))
+	query.OrderBy("created_at asc")
+
+	return findVestingGrants(ctx, claims, repo.DbConn, query)
+}
+
+// ReadVestingGrant gets the specified vesting grant of the created asset from the database.
+func (repo *Repository) ReadVestingGrant(ctx context.Context, claims auth.Claims, req CreatedAssetVestingGrantRequest) (*CreatedAssetVestingGrant, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.ReadVestingGrant")
+	defer span.Finish()
+
+	return readVestingGrant(ctx, claims, repo.DbConn, req)
+}
+
+// readVestingGrant gets the vesting grant using the connection or transaction.
+func readVestingGrant(ctx context.Context, claims auth.Claims, dbConn sqlx.ExtContext, req CreatedAssetVestingGrantRequest) (*CreatedAssetVestingGrant, error) {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("id", req.ID),
+		query.Equal("created_asset_id", req.CreatedAssetID),
+	))
+
+	res, err := findVestingGrants(ctx, claims, dbConn, query)
+	if err != nil {
+		return nil, err
+	} else if len(res) == 0 {
+		err = errors.WithMessagef(ErrNotFound, "vesting grant %s not found", req.ID)
+		return nil, err
+	}
+
+	return res[0], nil
+}
+
+// findVestingGrants internal method for getting the vesting grants from the database using
+// a select query.
+func findVestingGrants(ctx context.Context, claims auth.Claims, dbConn sqlx.ExtContext, query *sqlbuilder.SelectBuilder) (CreatedAssetVestingGrants, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.FindVestingGrants")
+	defer span.Finish()
+
+	query.Select(createdAssetVestingGrantMapColumns)
+	query.From(CreatedAssetVestingGrantTableName)
+
+	// Check to see if a sub query needs to be applied for the claims
+	err := applyTxnClaimsSelect(ctx, claims, query)
+	if err != nil {
+		return nil, err
+	}
+
+	queryStr, args := query.Build()
+	queryStr = dbConn.Rebind(queryStr)
+
+	// Fetch all entries from the db.
+	rows, err := dbConn.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find vesting grants failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Iterate over each row.
+	resp := []*CreatedAssetVestingGrant{}
+	for rows.Next() {
+		var g CreatedAssetVestingGrant
+		err = rows.Scan(&g.ID, &g.CreatedAssetID, &g.AccountID, &g.UserID, &g.Address, &g.Total, &g.StartDate, &g.CliffMonths,
+			&g.PeriodMonths, &g.IntervalMonths, &g.AccelerationPercent, &g.AcceleratedAt, &g.CancelledAt, &g.CreatedAt, &g.UpdatedAt,
+			&g.Released)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+
+		resp = append(resp, &g)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find vesting grants failed")
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// AccelerateVestingGrant vests the acceleration percent of the units of the vesting grant that
+// are unvested at once. A grant can only be accelerated once.
+func (repo *Repository) AccelerateVestingGrant(ctx context.Context, claims auth.Claims, req CreatedAssetVestingGrantRequest, now time.Time) (*CreatedAssetVestingGrant, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.AccelerateVestingGrant")
+	defer span.Finish()
+
+	return repo.updateVestingGrant(ctx, claims, req, "accelerated_at", func(g *CreatedAssetVestingGrant) error {
+		if g.isAccelerated() {
+			return errors.WithMessagef(ErrVestingGrantAccelerated, "vesting grant %s was already accelerated", g.ID)
+		} else if g.AccelerationPercent == 0 {
+			return errors.WithMessagef(ErrVestingGrantAccelerated, "vesting grant %s has no acceleration", g.ID)
+		}
+		return nil
+	}, now)
+}
+
+// CancelVestingGrant stops the vesting of the vesting grant, ie: when the holder leaves. The
+// units vested before it was cancelled are still released.
+func (repo *Repository) CancelVestingGrant(ctx context.Context, claims auth.Claims, req CreatedAssetVestingGrantRequest, now time.Time) (*CreatedAssetVestingGrant, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.CancelVestingGrant")
+	defer span.Finish()
+
+	return repo.updateVestingGrant(ctx, claims, req, "cancelled_at", func(g *CreatedAssetVestingGrant) error {
+		return nil
+	}, now)
+}
+
+// updateVestingGrant sets the time column of the vesting grant to now once check passes.
+// Grants that have been cancelled can't be changed.
+func (repo *Repository) updateVestingGrant(ctx context.Context, claims auth.Claims, req CreatedAssetVestingGrantRequest, column string, check func(g *CreatedAssetVestingGrant) error, now time.Time) (*CreatedAssetVestingGrant, error) {
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can modify the created asset specified in the request.
+	err = repo.CanModifyCreatedAsset(ctx, claims, req.CreatedAssetID, auth.PermissionAssetManage)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := readVestingGrant(ctx, claims, repo.DbConn, req)
+	if err != nil {
+		return nil, err
+	} else if g.isCancelled() {
+		return nil, errors.WithMessagef(ErrVestingGrantCancelled, "vesting grant %s", g.ID)
+	}
+
+	err = check(g)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	// The column is only set once, a concurrent update leaves no rows to update.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(CreatedAssetVestingGrantTableName)
+	query.Set(
+		query.Assign(column, now),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.And(
+		query.Equal("id", g.ID),
+		query.IsNull(column),
+		query.IsNull("cancelled_at"),
+	))
+
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	res, err := repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "update %s for vesting grant %s failed", column, g.ID)
+		return nil, err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, errors.WithStack(err)
+	} else if n == 0 {
+		return nil, errors.WithMessagef(ErrVestingGrantCancelled, "vesting grant %s was changed", g.ID)
+	}
+
+	switch column {
+	case "accelerated_at":
+		g.AcceleratedAt = &pq.NullTime{Time: now, Valid: true}
+	case "cancelled_at":
+		g.CancelledAt = &pq.NullTime{Time: now, Valid: true}
+	}
+	g.UpdatedAt = now
+
+	return g, nil
+}
+
+// ReleaseVestingGrant transfers the units of the vesting grant that have vested and have not
+// been released yet from the reserve of the created asset to the holder.
+func (repo *Repository) ReleaseVestingGrant(ctx context.Context, claims auth.Claims, req CreatedAssetVestingGrantRequest, now time.Time) (*CreatedAssetTxn, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.ReleaseVestingGrant")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.manageableAsset(ctx, claims, req.CreatedAssetID, auth.PermissionAssetTransfer)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.releaseGrant(ctx, m, req.ID, now)
+}
+
+// ReleaseVested prepares the release transfers of all the vesting grants with units that have
+// vested and have not been released yet, the first error is returned. Grants whose holder has
+// not opted in or is not whitelisted yet are released once they are.
+func (repo *Repository) ReleaseVested(ctx context.Context, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.ReleaseVested")
+	defer span.Finish()
+
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.LessEqualThan("start_date", now.UTC()))
+	query.OrderBy("created_at asc")
+
+	grants, err := findVestingGrants(ctx, auth.Claims{}, repo.DbConn, query)
+	if err != nil {
+		return err
+	}
+
+	assets := make(map[string]*CreatedAsset)
+
+	var firstErr error
+	for _, g := range grants {
+		if g.Releasable(now) == 0 {
+			continue
+		}
+
+		m, ok := assets[g.CreatedAssetID]
+		if !ok {
+			m, err = repo.mintedAsset(ctx, auth.Claims{}, g.CreatedAssetID)
+			if err != nil && errors.Cause(err) != ErrAssetDestroyed {
+				if firstErr == nil {
+					firstErr = errors.WithMessagef(err, "release vesting grant %s failed", g.ID)
+				}
+				continue
+			}
+			assets[g.CreatedAssetID] = m
+		}
+		if m == nil {
+			// Nothing can be released once the asset has been destroyed.
+			continue
+		}
+
+		_, err = repo.releaseGrant(ctx, m, g.ID, now)
+		switch errors.Cause(err) {
+		case nil, ErrNotOptedIn, ErrNotWhitelisted, ErrNothingToRelease:
+			continue
+		}
+
+		if firstErr == nil {
+			firstErr = errors.WithMessagef(err, "release vesting grant %s failed", g.ID)
+		}
+	}
+
+	return firstErr
+}
+
+// releaseGrant issues the transfer of the releasable units of the vesting grant from the
+// reserve of the created asset and records the release. The grant is locked until the
+// release and its draft transfer are recorded so units are never released twice, the
+// transfer is only signed and broadcast once they are.
+func (repo *Repository) releaseGrant(ctx context.Context, m *CreatedAsset, id string, now time.Time) (*CreatedAssetTxn, error) {
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	// Lock the vesting grant so the worker and a manual release don't both release the units.
+	{
+		query := sqlbuilder.NewSelectBuilder().Select("id").From(CreatedAssetVestingGrantTableName)
+		query.Where(query.And(
+			query.Equal("id", id),
+			query.Equal("created_asset_id", m.ID),
+		))
+		queryStr, args := query.Build()
+		queryStr = dbTx.Rebind(queryStr) + " FOR UPDATE"
+
+		var lockedID string
+		err = dbTx.QueryRowContext(ctx, queryStr, args...).Scan(&lockedID)
+		if err == sql.ErrNoRows {
+			return nil, errors.WithMessagef(ErrNotFound, "vesting grant %s not found", id)
+		} else if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+	}
+
+	g, err := readVestingGrant(ctx, auth.Claims{}, dbTx, CreatedAssetVestingGrantRequest{
+		CreatedAssetID: m.ID,
+		ID:             id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	amount := g.Releasable(now)
+	if amount == 0 {
+		return nil, errors.WithMessagef(ErrNothingToRelease, "vesting grant %s", g.ID)
+	} else if m.ReserveAddress == "" {
+		return nil, errors.WithMessagef(ErrAssetAddressNotSet, "created asset %s has no reserve address, vested units can't be released", m.ID)
+	}
+
+	optIn, err := repo.findOptIn(ctx, m.ID, g.UserID, g.Address, CreatedAssetTxnStatus_Confirmed)
+	if err != nil {
+		return nil, err
+	} else if optIn == nil {
+		return nil, errors.WithMessagef(ErrNotOptedIn, "address %s of user %s", g.Address, g.UserID)
+	}
+
+	err = repo.checkWhitelisted(ctx, m, g.UserID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &CreatedAssetTxn{
+		UserID:          &g.UserID,
+		Type:            CreatedAssetTxnType_Transfer,
+		SenderAddress:   m.ReserveAddress,
+		ReceiverAddress: g.Address,
+		Amount:          amount,
+	}
+
+	network, tx, err := repo.draftTxn(ctx, m, t, func(params types.SuggestedParams) (types.Transaction, error) {
+		return future.MakeAssetTransferTxn(t.SenderAddress, t.ReceiverAddress, t.Amount, nil, params, "", m.AssetIndex)
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertTxn(ctx, dbTx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the insert SQL statement.
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(CreatedAssetVestingReleaseTableName)
+	query.Cols("id", "vesting_grant_id", "created_asset_txn_id", "amount", "created_at")
+	query.Values(uuid.NewRandom().String(), g.ID, t.ID, amount, now)
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = dbTx.Rebind(sql)
+	_, err = dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "record release of vesting grant %s failed", g.ID)
+		return nil, err
+	}
+
+	// The release is recorded with its draft before the transfer is broadcast. A transfer
+	// that is rejected or expires no longer counts as released and the units are released
+	// again, one whose broadcast had an unknown outcome stays released until reconciled.
+	if err := dbTx.Commit(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return repo.signTxn(ctx, network, t, tx, now)
+}
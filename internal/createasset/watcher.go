@@ -0,0 +1,88 @@
+package createasset
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DefaultWatchInterval is how often submitted assets are reconciled when no interval
+// is provided. A new round is produced on Algorand roughly every 4.5 seconds.
+const DefaultWatchInterval = 5 * time.Second
+
+// DefaultWhitelistInterval is how often the holdings of assets that are frozen by default
+// are synced with their whitelist when no interval is provided.
+const DefaultWhitelistInterval = time.Minute
+
+// DefaultVestingInterval is how often the vested units of vesting grants are released when
+// no interval is provided. Tranches vest monthly so an hour is frequent enough.
+const DefaultVestingInterval = time.Hour
+
+// Watcher reconciles submitted created assets in the background so their asset
+// index and confirmed round are filled in once the network confirms them. The
+// holdings of assets that are frozen by default are kept in sync with their
+// whitelist and the units of vesting grants are released as they vest.
+type Watcher struct {
+	Repo              *Repository
+	Log               *log.Logger
+	Interval          time.Duration
+	WhitelistInterval time.Duration
+	VestingInterval   time.Duration
+}
+
+// NewWatcher creates a new Watcher for the repository.
+func NewWatcher(repo *Repository, log *log.Logger, interval, whitelistInterval, vestingInterval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	if whitelistInterval <= 0 {
+		whitelistInterval = DefaultWhitelistInterval
+	}
+	if vestingInterval <= 0 {
+		vestingInterval = DefaultVestingInterval
+	}
+
+	return &Watcher{
+		Repo:              repo,
+		Log:               log,
+		Interval:          interval,
+		WhitelistInterval: whitelistInterval,
+		VestingInterval:   vestingInterval,
+	}
+}
+
+// Run reconciles the submitted created assets every interval, syncs the whitelists every
+// whitelist interval and releases the vested units every vesting interval until the
+// context is cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	whitelistTicker := time.NewTicker(w.WhitelistInterval)
+	defer whitelistTicker.Stop()
+
+	vestingTicker := time.NewTicker(w.VestingInterval)
+	defer vestingTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.Repo.ReconcileSubmitted(ctx, time.Now())
+			if err != nil && ctx.Err() == nil {
+				w.Log.Printf("createasset : Watcher : %+v", err)
+			}
+		case <-whitelistTicker.C:
+			err := w.Repo.SyncWhitelists(ctx, time.Now())
+			if err != nil && ctx.Err() == nil {
+				w.Log.Printf("createasset : Watcher : %+v", err)
+			}
+		case <-vestingTicker.C:
+			err := w.Repo.ReleaseVested(ctx, time.Now())
+			if err != nil && ctx.Err() == nil {
+				w.Log.Printf("createasset : Watcher : %+v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package createasset
+
+import (
+	"context"
+	"time"
+
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/user_kyc"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* Created assets that are frozen by default are regulated securities, every
+holding starts frozen when the holder opts in. The users of the account that
+passed their KYC review form the whitelist of the asset. The freeze manager
+unfreezes the holdings of the addresses the whitelisted users opted in with
+and freezes every other holding again, ie: once the approval of a user is
+revoked or expires. The holdings are compared with the whitelist in the
+background and the freeze transactions needed are issued, freezes that Exitor
+can't sign are left as drafts to be signed offline. */
+
+var (
+	// ErrNotWhitelisted occurs when units of a created asset that is frozen by default are
+	// sent to a user that has not been approved by the KYC review of the account.
+	ErrNotWhitelisted = errors.New("User is not approved to hold the created asset")
+)
+
+// checkWhitelisted ensures the user is approved to hold the created asset when it's frozen by
+// default.
+func (repo *Repository) checkWhitelisted(ctx context.Context, m *CreatedAsset, userID string, now time.Time) error {
+	if !m.DefaultFrozen {
+		return nil
+	}
+
+	kyc, err := user_kyc.NewRepository(repo.DbConn).Read(ctx, auth.Claims{}, userID, m.AccountID)
+	if err != nil && errors.Cause(err) != user_kyc.ErrNotFound {
+		return err
+	}
+
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	if !kyc.IsApproved(now.UTC()) {
+		return errors.WithMessagef(ErrNotWhitelisted, "user %s", userID)
+	}
+
+	return nil
+}
+
+// FindWhitelist gets the set of users that are approved to hold the created asset, the users of
+// its account that passed their KYC review.
+func (repo *Repository) FindWhitelist(ctx context.Context, claims auth.Claims, id string, now time.Time) (map[string]bool, error) {
+	m, err := repo.ReadByID(ctx, claims, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return user_kyc.ApprovedUserIDs(ctx, repo.DbConn, m.AccountID, now)
+}
+
+// SyncWhitelist issues the freeze transactions needed for the holdings of the created asset to
+// match its whitelist. Assets that are not frozen by default have no whitelist.
+func (repo *Repository) SyncWhitelist(ctx context.Context, claims auth.Claims, id string, now time.Time) (CreatedAssetTxns, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.SyncWhitelist")
+	defer span.Finish()
+
+	m, err := repo.manageableAsset(ctx, claims, id, auth.PermissionAssetFreeze)
+	if err != nil {
+		return nil, err
+	} else if !m.DefaultFrozen {
+		return nil, nil
+	} else if m.FreezeAddress == "" {
+		return nil, errors.WithMessagef(ErrAssetAddressNotSet, "created asset %s has no freeze address, it can't be frozen", m.ID)
+	}
+
+	network, err := repo.AlgoClient.Network(m.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.syncWhitelist(ctx, network, m, now)
+}
+
+// SyncWhitelists syncs the holdings of all the minted created assets that are frozen by
+// default with their whitelist, the first error is returned.
+func (repo *Repository) SyncWhitelists(ctx context.Context, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.createasset.SyncWhitelists")
+	defer span.Finish()
+
+	assets, err := repo.Find(ctx, auth.Claims{}, CreatedAssetFindRequest{
+		Where: "mint_status = ? and default_frozen = ? and freeze_address != '' and destroyed_at is null",
+		Args:  []interface{}{CreatedAssetMintStatus_Confirmed.String(), true},
+		Order: []string{"updated_at asc"},
+	})
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, m := range assets {
+		network, err := repo.AlgoClient.Network(m.Network)
+		if err == nil {
+			_, err = repo.syncWhitelist(ctx, network, m, now)
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = errors.WithMessagef(err, "sync whitelist of created asset %s failed", m.ID)
+		}
+	}
+
+	return firstErr
+}
+
+// syncWhitelist compares the holdings of the created asset reported by the indexer with the
+// users of the account that are approved and freezes or unfreezes the holdings that differ.
+// The roles of the asset are never frozen and holdings with a freeze transaction that is
+// still pending are skipped.
+func (repo *Repository) syncWhitelist(ctx context.Context, network *algosdk.Network, m *CreatedAsset, now time.Time) (CreatedAssetTxns, error) {
+	approved, err := user_kyc.ApprovedUserIDs(ctx, repo.DbConn, m.AccountID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, round, err := assetBalances(ctx, network, m.AssetIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("created_asset_id", m.ID),
+		query.In("type", CreatedAssetTxnType_OptIn.String(), CreatedAssetTxnType_Freeze.String()),
+		query.NotEqual("status", CreatedAssetTxnStatus_Failed.String()),
+	))
+	query.OrderBy("created_at asc")
+
+	txns, err := findTxns(ctx, auth.Claims{}, repo.DbConn, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		whitelisted = make(map[string]bool)
+		pending     = make(map[string]bool)
+		frozen      = make(map[string]bool)
+	)
+	for _, t := range txns {
+		switch {
+		case t.Type == CreatedAssetTxnType_OptIn && t.Status == CreatedAssetTxnStatus_Confirmed:
+			if t.UserID != nil && approved[*t.UserID] {
+				whitelisted[t.SenderAddress] = true
+			}
+		case t.Type == CreatedAssetTxnType_Freeze && t.Status == CreatedAssetTxnStatus_Confirmed:
+			// The indexer can lag behind, freezes confirmed after the round of the balances
+			// are applied on top of them.
+			if t.ConfirmedRound > round {
+				frozen[t.TargetAddress] = t.Frozen
+			}
+		case t.Type == CreatedAssetTxnType_Freeze:
+			pending[t.TargetAddress] = true
+		}
+	}
+
+	roles := map[string]bool{
+		m.CreatorAddress:  true,
+		m.ManagerAddress:  true,
+		m.ReserveAddress:  true,
+		m.FreezeAddress:   true,
+		m.ClawbackAddress: true,
+	}
+
+	var issued CreatedAssetTxns
+	for _, b := range balances {
+		if roles[b.Address] || pending[b.Address] {
+			continue
+		}
+
+		isFrozen := b.IsFrozen
+		if f, ok := frozen[b.Address]; ok {
+			isFrozen = f
+		}
+
+		if want := !whitelisted[b.Address]; isFrozen != want {
+			t, err := repo.Freeze(ctx, auth.Claims{}, CreatedAssetFreezeRequest{
+				ID:      m.ID,
+				Address: b.Address,
+				Frozen:  want,
+			}, now)
+			if err != nil {
+				return issued, err
+			}
+			issued = append(issued, t)
+		}
+	}
+
+	return issued, nil
+}
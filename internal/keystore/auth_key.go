@@ -0,0 +1,82 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// AuthKeyEncrypter encrypts the private keys of the authenticator stored in the database
+// with the master keys, implements auth.KeyEncrypter.
+type AuthKeyEncrypter struct {
+	MasterKeys MasterKeyStorage
+}
+
+// NewAuthKeyEncrypter returns an encrypter for the private keys of the authenticator.
+func NewAuthKeyEncrypter(masterKeys MasterKeyStorage) *AuthKeyEncrypter {
+	return &AuthKeyEncrypter{MasterKeys: masterKeys}
+}
+
+// EncryptKey encrypts the private key with the current master key. The ciphertext is
+// prefixed with the ID of the master key and the nonce so it can be decrypted after the
+// master key has been rotated.
+func (e *AuthKeyEncrypter) EncryptKey(id string, key []byte) ([]byte, error) {
+	mk := e.MasterKeys.Current()
+	if mk == nil {
+		return nil, errors.WithMessage(ErrMasterKeyNotFound, "no current master key")
+	} else if len(mk.ID()) > 255 {
+		return nil, errors.Errorf("master key ID %s is too long", mk.ID())
+	}
+
+	gcm, err := newGCM(mk)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := append([]byte{byte(len(mk.ID()))}, mk.ID()...)
+	res = append(res, nonce...)
+	return gcm.Seal(res, nonce, key, authAdditionalData(id, mk.ID())), nil
+}
+
+// DecryptKey decrypts a private key encrypted by EncryptKey with the master key it was
+// encrypted with.
+func (e *AuthKeyEncrypter) DecryptKey(id string, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) == 0 || len(ciphertext) < 1+int(ciphertext[0]) {
+		return nil, errors.WithMessagef(ErrDecryptFailed, "auth private key %s", id)
+	}
+	masterKeyID := string(ciphertext[1 : 1+int(ciphertext[0])])
+	ciphertext = ciphertext[1+len(masterKeyID):]
+
+	mk, ok := e.MasterKeys.Keys()[masterKeyID]
+	if !ok {
+		return nil, errors.WithMessagef(ErrMasterKeyNotFound, "master key %s for auth private key %s", masterKeyID, id)
+	}
+
+	gcm, err := newGCM(mk)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.WithMessagef(ErrDecryptFailed, "auth private key %s", id)
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+
+	key, err := gcm.Open(nil, nonce, ciphertext, authAdditionalData(id, mk.ID()))
+	if err != nil {
+		return nil, errors.WithMessagef(ErrDecryptFailed, "auth private key %s", id)
+	}
+
+	return key, nil
+}
+
+// authAdditionalData returns the data authenticated with an encrypted auth private key.
+func authAdditionalData(id, masterKeyID string) []byte {
+	return []byte("auth:" + id + ":" + masterKeyID)
+}
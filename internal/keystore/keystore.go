@@ -0,0 +1,518 @@
+package keystore
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"time"
+
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* Keystore holds the keys of the Algorand accounts owned by Exitor. Each
+private key is encrypted with AES-GCM using the current master key and
+the ID of the master key is stored with it, so master keys can be rotated
+by re-encrypting the keys still using an old one. Private keys and master
+keys are never returned, formatted or logged. */
+
+const (
+	// The database table for custodial accounts
+	CustodialAccountTableName = "custodial_accounts"
+)
+
+var (
+	// ErrNotFound abstracts the postgres not found error
+	ErrNotFound = errors.New("Entity not found")
+
+	// ErrForbidden occurs when a user tries to do sth that is
+	// forbidden to them according to Exitor's access control
+	// policies
+	ErrForbidden = errors.New("Attempted action is not allowed")
+
+	// ErrMasterKeyNotFound occurs when the master key a private key was encrypted
+	// with is not loaded.
+	ErrMasterKeyNotFound = errors.New("Master key not found")
+
+	// ErrDecryptFailed occurs when a private key can't be decrypted with its master key.
+	ErrDecryptFailed = errors.New("Failed to decrypt private key")
+)
+
+// CanManageCustodialAccounts determines if claims has the authority to create, archive
+// and rotate custodial accounts. Custodial accounts belong to Exitor and not to any
+// account on Exitor, so they can only be managed internally.
+func (repo *Repository) CanManageCustodialAccounts(ctx context.Context, claims auth.Claims) error {
+	if claims.Audience != "" {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	return nil
+}
+
+// custodialAccountMapColumns is the list of columns needed for find.
+var custodialAccountMapColumns = "id,address,label,master_key_id,nonce,ciphertext,created_at,updated_at,archived_at"
+
+// selectQuery constructs a base select query for CustodialAccount.
+func selectQuery() *sqlbuilder.SelectBuilder {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Select(custodialAccountMapColumns)
+	query.From(CustodialAccountTableName)
+	return query
+}
+
+// findRequestQuery generates the select query for the given find request.
+func findRequestQuery(req CustodialAccountFindRequest) (*sqlbuilder.SelectBuilder, []interface{}) {
+	query := selectQuery()
+
+	if req.Where != "" {
+		query.Where(query.And(req.Where))
+	}
+
+	if len(req.Order) > 0 {
+		query.OrderBy(req.Order...)
+	}
+
+	if req.Limit != nil {
+		query.Limit(int(*req.Limit))
+	}
+
+	if req.Offset != nil {
+		query.Offset(int(*req.Offset))
+	}
+
+	return query, req.Args
+}
+
+// Find gets all the custodial accounts from the database based on the request params.
+func (repo *Repository) Find(ctx context.Context, claims auth.Claims, req CustodialAccountFindRequest) (CustodialAccounts, error) {
+	query, args := findRequestQuery(req)
+	return find(ctx, claims, repo.DbConn, query, args, req.IncludeArchived)
+}
+
+// find internal method for getting all the custodial accounts from the database using a select query.
+func find(ctx context.Context, claims auth.Claims, dbConn *sqlx.DB, query *sqlbuilder.SelectBuilder, args []interface{}, includedArchived bool) (CustodialAccounts, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.keystore.Find")
+	defer span.Finish()
+
+	query.Select(custodialAccountMapColumns)
+	query.From(CustodialAccountTableName)
+	if !includedArchived {
+		query.Where(query.IsNull("archived_at"))
+	}
+
+	queryStr, queryArgs := query.Build()
+	queryStr = dbConn.Rebind(queryStr)
+	args = append(args, queryArgs...)
+
+	// Fetch all entries from the db.
+	rows, err := dbConn.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find custodial accounts failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Iterate over each row.
+	resp := []*CustodialAccount{}
+	for rows.Next() {
+		var m CustodialAccount
+		err = rows.Scan(&m.ID, &m.Address, &m.Label, &m.MasterKeyID, &m.Nonce, &m.Ciphertext, &m.CreatedAt, &m.UpdatedAt, &m.ArchivedAt)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+
+		resp = append(resp, &m)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find custodial accounts failed")
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ReadByAddress gets the specified custodial account by address from the database.
+func (repo *Repository) ReadByAddress(ctx context.Context, claims auth.Claims, address string) (*CustodialAccount, error) {
+	return repo.Read(ctx, claims, CustodialAccountReadRequest{
+		Address:         address,
+		IncludeArchived: false,
+	})
+}
+
+// Read gets the specified custodial account from the database.
+func (repo *Repository) Read(ctx context.Context, claims auth.Claims, req CustodialAccountReadRequest) (*CustodialAccount, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.keystore.Read")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter base select query by address.
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("address", req.Address))
+
+	res, err := find(ctx, claims, repo.DbConn, query, []interface{}{}, req.IncludeArchived)
+	if err != nil {
+		return nil, err
+	} else if res == nil || len(res) == 0 {
+		err = errors.WithMessagef(ErrNotFound, "custodial account %s not found", req.Address)
+		return nil, err
+	}
+
+	u := res[0]
+	return u, nil
+}
+
+// Create generates a new account and stores its private key encrypted.
+func (repo *Repository) Create(ctx context.Context, claims auth.Claims, req CustodialAccountCreateRequest, now time.Time) (*CustodialAccount, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.keystore.Create")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := crypto.GenerateAccount()
+	defer wipe(acc.PrivateKey)
+
+	return repo.Import(ctx, claims, CustodialAccountImportRequest{
+		Label:      req.Label,
+		PrivateKey: acc.PrivateKey,
+	}, now)
+}
+
+// Import stores the private key of an existing account encrypted.
+func (repo *Repository) Import(ctx context.Context, claims auth.Claims, req CustodialAccountImportRequest, now time.Time) (*CustodialAccount, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.keystore.Import")
+	defer span.Finish()
+
+	// Ensure the claims can manage custodial accounts.
+	err := repo.CanManageCustodialAccounts(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err = v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	acc, err := algosdk.AccountFromPrivateKey(req.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid private key")
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	m := CustodialAccount{
+		ID:        uuid.NewRandom().String(),
+		Address:   acc.Address.String(),
+		Label:     req.Label,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	m.MasterKeyID, m.Nonce, m.Ciphertext, err = repo.encrypt(m.Address, req.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the insert SQL statement.
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(CustodialAccountTableName)
+	query.Cols("id", "address", "label", "master_key_id", "nonce", "ciphertext", "created_at", "updated_at")
+	query.Values(m.ID, m.Address, m.Label, m.MasterKeyID, m.Nonce, m.Ciphertext, m.CreatedAt, m.UpdatedAt)
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		// The query is not included as the values contain the encrypted key.
+		err = errors.WithMessagef(err, "create custodial account %s failed", m.Address)
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Archive soft deleted the custodial account from the database.
+func (repo *Repository) Archive(ctx context.Context, claims auth.Claims, req CustodialAccountArchiveRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.keystore.Archive")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the claims can manage custodial accounts.
+	err = repo.CanManageCustodialAccounts(ctx, claims)
+	if err != nil {
+		return err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(CustodialAccountTableName)
+	query.Set(
+		query.Assign("archived_at", now),
+	)
+	query.Where(query.Equal("address", req.Address))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "archive custodial account %s failed", req.Address)
+		return err
+	}
+
+	return nil
+}
+
+// Rotate re-encrypts all the private keys that are not encrypted with the current master
+// key. Once rotated, the previous master keys are no longer needed. Storage engines that
+// implement MasterKeyRotator first generate a new master key when the current one has
+// expired. Returns the number of keys rotated.
+func (repo *Repository) Rotate(ctx context.Context, claims auth.Claims, now time.Time) (int, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.keystore.Rotate")
+	defer span.Finish()
+
+	// Ensure the claims can manage custodial accounts.
+	err := repo.CanManageCustodialAccounts(ctx, claims)
+	if err != nil {
+		return 0, err
+	}
+
+	if rs, ok := repo.MasterKeys.(MasterKeyRotator); ok {
+		if err := rs.Rotate(ctx, now); err != nil {
+			return 0, err
+		}
+	}
+
+	cur := repo.MasterKeys.Current()
+	if cur == nil {
+		return 0, errors.WithMessage(ErrMasterKeyNotFound, "no current master key")
+	}
+
+	accs, err := repo.Find(ctx, claims, CustodialAccountFindRequest{
+		Where:           "master_key_id != ?",
+		Args:            []interface{}{cur.ID()},
+		IncludeArchived: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	var rotated int
+	for _, m := range accs {
+		sk, err := repo.decrypt(m)
+		if err != nil {
+			return rotated, err
+		}
+
+		masterKeyID, nonce, ciphertext, err := repo.encrypt(m.Address, sk)
+		wipe(sk)
+		if err != nil {
+			return rotated, err
+		}
+
+		// Build the update SQL statement, the key must not have been rotated since it
+		// was read.
+		query := sqlbuilder.NewUpdateBuilder()
+		query.Update(CustodialAccountTableName)
+		query.Set(
+			query.Assign("master_key_id", masterKeyID),
+			query.Assign("nonce", nonce),
+			query.Assign("ciphertext", ciphertext),
+			query.Assign("updated_at", now),
+		)
+		query.Where(query.And(
+			query.Equal("id", m.ID),
+			query.Equal("master_key_id", m.MasterKeyID),
+		))
+
+		// Execute the query with the provided context.
+		sql, args := query.Build()
+		sql = repo.DbConn.Rebind(sql)
+		res, err := repo.DbConn.ExecContext(ctx, sql, args...)
+		if err != nil {
+			// The query is not included as the values contain the encrypted key.
+			err = errors.WithMessagef(err, "rotate custodial account %s failed", m.Address)
+			return rotated, err
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			return rotated, errors.WithStack(err)
+		} else if n > 0 {
+			rotated++
+		}
+	}
+
+	return rotated, nil
+}
+
+// SignTransaction signs the transaction with the key of the sender when the sender is
+// a custodial account, implements algosdk.Signer.
+func (repo *Repository) SignTransaction(ctx context.Context, tx types.Transaction) ([]byte, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.keystore.SignTransaction")
+	defer span.Finish()
+
+	m, err := repo.ReadByAddress(ctx, auth.Claims{}, tx.Sender.String())
+	if err != nil {
+		if errors.Cause(err) == ErrNotFound {
+			return nil, errors.WithMessagef(algosdk.ErrSignerAccountNotFound, "sender %s", tx.Sender.String())
+		}
+		return nil, err
+	}
+
+	sk, err := repo.decrypt(m)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(sk)
+
+	_, stx, err := crypto.SignTransaction(sk, tx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "sign transaction for %s failed", m.Address)
+	}
+
+	return stx, nil
+}
+
+// encrypt encrypts the private key with the current master key. The address and the
+// ID of the master key are authenticated with the ciphertext so an encrypted key
+// can't be moved to another account.
+func (repo *Repository) encrypt(address string, sk ed25519.PrivateKey) (string, []byte, []byte, error) {
+	mk := repo.MasterKeys.Current()
+	if mk == nil {
+		return "", nil, nil, errors.WithMessage(ErrMasterKeyNotFound, "no current master key")
+	}
+
+	gcm, err := newGCM(mk)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", nil, nil, errors.WithStack(err)
+	}
+
+	ciphertext := gcm.Seal(nil, nonce, sk, additionalData(address, mk.ID()))
+
+	return mk.ID(), nonce, ciphertext, nil
+}
+
+// decrypt decrypts the private key of the custodial account with the master key it was
+// encrypted with.
+func (repo *Repository) decrypt(m *CustodialAccount) (ed25519.PrivateKey, error) {
+	mk, ok := repo.MasterKeys.Keys()[m.MasterKeyID]
+	if !ok {
+		return nil, errors.WithMessagef(ErrMasterKeyNotFound, "master key %s for custodial account %s", m.MasterKeyID, m.Address)
+	}
+
+	gcm, err := newGCM(mk)
+	if err != nil {
+		return nil, err
+	}
+
+	sk, err := gcm.Open(nil, m.Nonce, m.Ciphertext, additionalData(m.Address, mk.ID()))
+	if err != nil {
+		return nil, errors.WithMessagef(ErrDecryptFailed, "custodial account %s", m.Address)
+	}
+
+	return ed25519.PrivateKey(sk), nil
+}
+
+// newGCM returns the AES-GCM cipher for the master key.
+func newGCM(mk *MasterKey) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(mk.key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid master key %s", mk.ID())
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return gcm, nil
+}
+
+// additionalData returns the data authenticated with an encrypted private key.
+func additionalData(address, masterKeyID string) []byte {
+	return []byte(address + ":" + masterKeyID)
+}
+
+// wipe zeros the private key so it doesn't linger in memory.
+func wipe(sk ed25519.PrivateKey) {
+	for i := range sk {
+		sk[i] = 0
+	}
+}
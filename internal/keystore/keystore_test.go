@@ -0,0 +1,261 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"exitor-dapp/internal/algosdk"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/tests"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/encoding/msgpack"
+	"github.com/algorand/go-algorand-sdk/future"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+var test *tests.Test
+
+// TestMain is the entry point for testing.
+func TestMain(m *testing.M) {
+	os.Exit(testMain(m))
+}
+
+func testMain(m *testing.M) int {
+	test = tests.New()
+	defer test.TearDown()
+	return m.Run()
+}
+
+// TestMasterKeyFile validates master keys are persisted and rotated once expired.
+func TestMasterKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore-master-keys")
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tTempDir failed.", tests.Failed)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, time.February, 15, 0, 0, 0, 0, time.UTC)
+	expiration := time.Hour * 24 * 30
+
+	t.Log("Given the need to persist and rotate master keys on the local file system.")
+	{
+		first, err := NewMasterKeyFile(dir, now, expiration)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tNewMasterKeyFile failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tNewMasterKeyFile ok.", tests.Success)
+
+		reloaded, err := NewMasterKeyFile(dir, now.Add(time.Hour), expiration)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tNewMasterKeyFile failed.", tests.Failed)
+		} else if reloaded.Current().ID() != first.Current().ID() || len(reloaded.Keys()) != 1 {
+			t.Logf("\t\tGot : %s", reloaded.Current().ID())
+			t.Logf("\t\tWant: %s", first.Current().ID())
+			t.Fatalf("\t%s\tExpected the current master key to be reloaded.", tests.Failed)
+		}
+		t.Logf("\t%s\tReload master key ok.", tests.Success)
+
+		rotated, err := NewMasterKeyFile(dir, now.Add(expiration*3), expiration)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tNewMasterKeyFile failed.", tests.Failed)
+		} else if rotated.Current().ID() == first.Current().ID() {
+			t.Fatalf("\t%s\tExpected a new master key to be generated.", tests.Failed)
+		} else if _, ok := rotated.Keys()[first.Current().ID()]; !ok {
+			t.Fatalf("\t%s\tExpected the expired master key to still be loaded.", tests.Failed)
+		}
+		t.Logf("\t%s\tRotate master key ok.", tests.Success)
+
+		// The storage of a running instance picks up the key generated by another one.
+		err = first.Rotate(tests.Context(), now.Add(expiration*3))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRotate failed.", tests.Failed)
+		} else if first.Current().ID() != rotated.Current().ID() || len(first.Keys()) != 2 {
+			t.Logf("\t\tGot : %s", first.Current().ID())
+			t.Logf("\t\tWant: %s", rotated.Current().ID())
+			t.Fatalf("\t%s\tExpected the running storage to load the new master key.", tests.Failed)
+		}
+		t.Logf("\t%s\tRotate running storage ok.", tests.Success)
+
+		_, err = NewMasterKeyFile("", now, expiration)
+		if err == nil {
+			t.Fatalf("\t%s\tExpected NewMasterKeyFile to fail without a local dir.", tests.Failed)
+		}
+		t.Logf("\t%s\tRequire local dir ok.", tests.Success)
+
+		formatted := fmt.Sprintf("%v %+v %#v %s", rotated.Current(), rotated.Current(), rotated.Current(), rotated.Keys())
+		for _, mk := range rotated.Keys() {
+			if bytes.Contains([]byte(formatted), mk.key) || bytes.Contains([]byte(formatted), []byte(fmt.Sprintf("%x", mk.key))) {
+				t.Logf("\t\tGot : %s", formatted)
+				t.Fatalf("\t%s\tFormatted master keys should not contain key material.", tests.Failed)
+			}
+		}
+		t.Logf("\t%s\tFormat master key ok.", tests.Success)
+	}
+}
+
+// TestCustodialAccount validates custodial accounts are stored encrypted, sign
+// transactions for their address and survive the rotation of the master key.
+func TestCustodialAccount(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.February, 15, 0, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	oldMasterKeys, err := NewMasterKeySecret("old-shared-secret-key")
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNewMasterKeySecret failed.", tests.Failed)
+	}
+	repo := NewRepository(test.MasterDB, oldMasterKeys)
+
+	// makeTxn returns a payment transaction sent by the address.
+	makeTxn := func(t *testing.T, sender string) types.Transaction {
+		txn, err := future.MakePaymentTxn(sender, crypto.GenerateAccount().Address.String(), 1, nil, "", types.SuggestedParams{
+			Fee:             1000,
+			FirstRoundValid: 1000,
+			LastRoundValid:  2000,
+			GenesisHash:     make([]byte, 32),
+			FlatFee:         true,
+		})
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tMakePaymentTxn failed.", tests.Failed)
+		}
+		return txn
+	}
+
+	// verify ensures the signed transaction was signed by the sender.
+	verify := func(t *testing.T, txn types.Transaction, raw []byte) {
+		var stx types.SignedTxn
+		if err := msgpack.Decode(raw, &stx); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tDecode signed transaction failed.", tests.Failed)
+		}
+
+		msg := append([]byte("TX"), msgpack.Encode(stx.Txn)...)
+		if !ed25519.Verify(ed25519.PublicKey(txn.Sender[:]), msg, stx.Sig[:]) {
+			t.Fatalf("\t%s\tSignature should be made by the sender.", tests.Failed)
+		}
+	}
+
+	t.Log("Given the need to store the keys of Exitor owned accounts.")
+	{
+		accountClaims := auth.Claims{
+			Roles: []string{auth.RoleAdmin},
+			StandardClaims: jwt.StandardClaims{
+				Subject:   uuid.NewRandom().String(),
+				Audience:  uuid.NewRandom().String(),
+				IssuedAt:  now.Unix(),
+				ExpiresAt: now.Add(time.Hour).Unix(),
+			},
+		}
+
+		_, err = repo.Create(ctx, accountClaims, CustodialAccountCreateRequest{Label: "Asset Manager"}, now)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tCreate with account claims should be forbidden.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreate with account claims forbidden ok.", tests.Success)
+
+		m, err := repo.Create(ctx, auth.Claims{}, CustodialAccountCreateRequest{Label: "Asset Manager"}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+		} else if m.MasterKeyID != oldMasterKeys.Current().ID() {
+			t.Logf("\t\tGot : %s", m.MasterKeyID)
+			t.Logf("\t\tWant: %s", oldMasterKeys.Current().ID())
+			t.Fatalf("\t%s\tCreate should encrypt with the current master key.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreate ok.", tests.Success)
+
+		txn := makeTxn(t, m.Address)
+		raw, err := repo.SignTransaction(ctx, txn)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSignTransaction failed.", tests.Failed)
+		}
+		verify(t, txn, raw)
+		t.Logf("\t%s\tSignTransaction ok.", tests.Success)
+
+		_, err = repo.SignTransaction(ctx, makeTxn(t, crypto.GenerateAccount().Address.String()))
+		if errors.Cause(err) != algosdk.ErrSignerAccountNotFound {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", algosdk.ErrSignerAccountNotFound)
+			t.Fatalf("\t%s\tSignTransaction for an unknown sender should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tSignTransaction unknown sender ok.", tests.Success)
+
+		// Rotate the shared secret, keeping the old one until the keys are rotated.
+		newMasterKeys, err := NewMasterKeySecret("new-shared-secret-key", "old-shared-secret-key")
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tNewMasterKeySecret failed.", tests.Failed)
+		}
+		repo.MasterKeys = newMasterKeys
+
+		n, err := repo.Rotate(ctx, auth.Claims{}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRotate failed.", tests.Failed)
+		} else if n < 1 {
+			t.Logf("\t\tGot : %d", n)
+			t.Fatalf("\t%s\tRotate should re-encrypt the custodial account.", tests.Failed)
+		}
+
+		rotated, err := repo.ReadByAddress(ctx, auth.Claims{}, m.Address)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReadByAddress failed.", tests.Failed)
+		} else if rotated.MasterKeyID != newMasterKeys.Current().ID() || bytes.Equal(rotated.Ciphertext, m.Ciphertext) {
+			t.Logf("\t\tGot : %s", rotated.MasterKeyID)
+			t.Logf("\t\tWant: %s", newMasterKeys.Current().ID())
+			t.Fatalf("\t%s\tRotate should encrypt with the current master key.", tests.Failed)
+		}
+		t.Logf("\t%s\tRotate ok.", tests.Success)
+
+		// The old shared secret is no longer needed.
+		repo.MasterKeys, err = NewMasterKeySecret("new-shared-secret-key")
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tNewMasterKeySecret failed.", tests.Failed)
+		}
+
+		raw, err = repo.SignTransaction(ctx, txn)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSignTransaction after rotate failed.", tests.Failed)
+		}
+		verify(t, txn, raw)
+		t.Logf("\t%s\tSignTransaction after rotate ok.", tests.Success)
+
+		err = repo.Archive(ctx, auth.Claims{}, CustodialAccountArchiveRequest{Address: m.Address}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tArchive failed.", tests.Failed)
+		}
+
+		_, err = repo.SignTransaction(ctx, txn)
+		if errors.Cause(err) != algosdk.ErrSignerAccountNotFound {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", algosdk.ErrSignerAccountNotFound)
+			t.Fatalf("\t%s\tSignTransaction for an archived account should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tArchive ok.", tests.Success)
+	}
+}
@@ -0,0 +1,505 @@
+package keystore
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/hkdf"
+)
+
+// masterKeySize is the size of the AES-256 master keys.
+const masterKeySize = 32
+
+// MasterKey is a key used to encrypt the private keys of custodial accounts. The key
+// material is never included when the master key is formatted so it can't end up in
+// the logs.
+type MasterKey struct {
+	id  string
+	key []byte
+}
+
+// ID returns the ID of the master key that is stored with each encrypted key.
+func (k *MasterKey) ID() string {
+	if k == nil {
+		return ""
+	}
+	return k.id
+}
+
+// String returns the ID of the master key, implements fmt.Stringer.
+func (k *MasterKey) String() string {
+	return "MasterKey(" + k.ID() + ")"
+}
+
+// GoString returns the ID of the master key, implements fmt.GoStringer.
+func (k *MasterKey) GoString() string {
+	return k.String()
+}
+
+// MasterKeyStorage provides the ability to persist master keys to custom locations.
+type MasterKeyStorage interface {
+	// Keys returns a map of master keys by ID.
+	Keys() map[string]*MasterKey
+	// Current returns the master key new keys should be encrypted with.
+	Current() *MasterKey
+}
+
+// MasterKeyRotator is implemented by storage engines that can rotate master keys while
+// the service is running. Instances sharing the storage load the keys generated by each
+// other.
+type MasterKeyRotator interface {
+	MasterKeyStorage
+	// Rotate generates a new current master key when the current one has expired and
+	// reloads all the master keys.
+	Rotate(ctx context.Context, now time.Time) error
+}
+
+// MasterKeySecret is a storage engine that derives the master keys from shared secrets.
+type MasterKeySecret struct {
+	// Map of keys by ID.
+	keys map[string]*MasterKey
+	// The current active key to be used.
+	curMasterKey *MasterKey
+}
+
+// Keys returns a map of master keys by ID.
+func (s *MasterKeySecret) Keys() map[string]*MasterKey {
+	if s == nil || s.keys == nil {
+		return map[string]*MasterKey{}
+	}
+	return s.keys
+}
+
+// Current returns the master key derived from the current secret.
+func (s *MasterKeySecret) Current() *MasterKey {
+	if s == nil {
+		return nil
+	}
+	return s.curMasterKey
+}
+
+// NewMasterKeySecret implements the interface MasterKeyStorage to derive master keys
+// from the shared secret key of the project. When the secret is rotated, the previous
+// secrets should be included until the keys encrypted with them have been rotated.
+func NewMasterKeySecret(secret string, previous ...string) (*MasterKeySecret, error) {
+	if secret == "" {
+		return nil, errors.New("secret cannot be empty")
+	}
+
+	storage := &MasterKeySecret{
+		keys: make(map[string]*MasterKey),
+	}
+
+	for i, s := range append([]string{secret}, previous...) {
+		if s == "" {
+			continue
+		}
+
+		key := make([]byte, masterKeySize)
+		kdf := hkdf.New(sha256.New, []byte(s), nil, []byte("exitor-keystore-master-key"))
+		if _, err := io.ReadFull(kdf, key); err != nil {
+			return nil, errors.Wrap(err, "failed to derive master key")
+		}
+
+		// The ID is a hash of the derived key so it changes when the secret is rotated
+		// without exposing the secret.
+		sum := sha256.Sum256(key)
+		mk := &MasterKey{
+			id:  "secret-" + hex.EncodeToString(sum[:8]),
+			key: key,
+		}
+
+		storage.keys[mk.id] = mk
+		if i == 0 {
+			storage.curMasterKey = mk
+		}
+	}
+
+	return storage, nil
+}
+
+// MasterKeyFile is a storage engine that stores master keys on the local file system.
+type MasterKeyFile struct {
+	// Local directory for storing master keys.
+	localDir string
+	// Age after which a new master key is generated.
+	keyExpiration time.Duration
+
+	mu sync.RWMutex
+	// Map of keys by ID.
+	keys map[string]*MasterKey
+	// The current active key to be used.
+	curMasterKey *MasterKey
+}
+
+// Keys returns a map of master keys by ID.
+func (s *MasterKeyFile) Keys() map[string]*MasterKey {
+	if s == nil {
+		return map[string]*MasterKey{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.keys == nil {
+		return map[string]*MasterKey{}
+	}
+	return s.keys
+}
+
+// Current returns the most recently generated master key.
+func (s *MasterKeyFile) Current() *MasterKey {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.curMasterKey
+}
+
+// NewMasterKeyFile implements the interface MasterKeyStorage to support persisting master
+// keys to the local file system. A new master key is generated when the current key is
+// older than the key expiration. Unlike the keys used for signing JWTs, expired master
+// keys are always loaded as they are still needed to decrypt keys until those have been
+// rotated.
+// It will error if:
+// - The local dir is blank.
+func NewMasterKeyFile(localDir string, now time.Time, keyExpiration time.Duration) (*MasterKeyFile, error) {
+	if localDir == "" {
+		return nil, errors.New("local dir cannot be empty")
+	}
+
+	if _, err := os.Stat(localDir); os.IsNotExist(err) {
+		err = os.MkdirAll(localDir, 0700)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create storage directory %s", localDir)
+		}
+	}
+
+	storage := &MasterKeyFile{
+		localDir:      localDir,
+		keyExpiration: keyExpiration,
+	}
+
+	if err := storage.Rotate(context.Background(), now); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// Rotate reloads the master keys from the local directory and generates a new key when
+// the current one has expired, implements MasterKeyRotator.
+func (s *MasterKeyFile) Rotate(ctx context.Context, now time.Time) error {
+	keys := make(map[string]*MasterKey)
+
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+
+	// Time threshold to create a new key. If a current key exists and the
+	// created date of the key is before this value, a new key will be created.
+	var activeCreatedDate time.Time
+	if s.keyExpiration.Seconds() > 0 {
+		activeCreatedDate = now.UTC().Add(s.keyExpiration * -1)
+	}
+
+	// Values used to format filename.
+	filePrefix := "keystore_"
+	fileExt := ".masterkey"
+
+	files, err := ioutil.ReadDir(s.localDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list files in directory %s", s.localDir)
+	}
+
+	// The current key id if there is an active one.
+	var curKeyId string
+
+	// The max created data to determine the most recent key.
+	var lastCreatedDate time.Time
+
+	for _, f := range files {
+		if !strings.HasPrefix(f.Name(), filePrefix) || !strings.HasSuffix(f.Name(), fileExt) {
+			continue
+		}
+
+		// Extract the created timestamp and kID from the filename.
+		fname := strings.TrimSuffix(f.Name(), fileExt)
+		pts := strings.Split(fname, "_")
+		if len(pts) != 3 {
+			return errors.Errorf("unable to parse filename %s", f.Name())
+		}
+		createdAt := pts[1]
+		kID := pts[2]
+
+		// Covert string timestamp to int.
+		createdAtSecs, err := strconv.Atoi(createdAt)
+		if err != nil {
+			return errors.Wrapf(err, "failed parse timestamp from %s", f.Name())
+		}
+		ts := time.Unix(int64(createdAtSecs), 0)
+
+		filePath := filepath.Join(s.localDir, f.Name())
+		dat, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return errors.Wrapf(err, "failed read file %s", f.Name())
+		} else if len(dat) != masterKeySize {
+			return errors.Errorf("invalid master key in file %s", f.Name())
+		}
+
+		keys[kID] = &MasterKey{id: kID, key: dat}
+
+		if lastCreatedDate.IsZero() || ts.UTC().Unix() > lastCreatedDate.UTC().Unix() {
+			curKeyId = kID
+			lastCreatedDate = ts.UTC()
+		}
+	}
+
+	if !activeCreatedDate.IsZero() && lastCreatedDate.UTC().Unix() < activeCreatedDate.UTC().Unix() {
+		curKeyId = ""
+	}
+
+	// If there are no keys or the current key needs to be rotated, generate a new key.
+	if curKeyId == "" {
+		key, err := generateMasterKey()
+		if err != nil {
+			return err
+		}
+
+		curKeyId = uuid.NewRandom().String()
+
+		fname := fmt.Sprintf("%s%d_%s%s", filePrefix, now.UTC().Unix(), curKeyId, fileExt)
+
+		filePath := filepath.Join(s.localDir, fname)
+
+		err = ioutil.WriteFile(filePath, key, 0600)
+		if err != nil {
+			return errors.Wrapf(err, "failed write file %s", filePath)
+		}
+
+		keys[curKeyId] = &MasterKey{id: curKeyId, key: key}
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.curMasterKey = keys[curKeyId]
+	s.mu.Unlock()
+
+	return nil
+}
+
+// MasterKeyAws is a storage engine that uses AWS Secrets Manager to persist master keys.
+type MasterKeyAws struct {
+	// AWS Secrets Manager client used to persist the master keys.
+	secretManager *secretsmanager.SecretsManager
+	// The secret ID the master keys are stored as versions of.
+	secretID string
+	// Age after which a new master key is generated.
+	keyExpiration time.Duration
+
+	mu sync.RWMutex
+	// Map of keys by ID (version id).
+	keys map[string]*MasterKey
+	// The current active key to be used.
+	curMasterKey *MasterKey
+}
+
+// Keys returns a map of master keys by ID.
+func (s *MasterKeyAws) Keys() map[string]*MasterKey {
+	if s == nil {
+		return map[string]*MasterKey{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.keys == nil {
+		return map[string]*MasterKey{}
+	}
+	return s.keys
+}
+
+// Current returns the most recently generated master key.
+func (s *MasterKeyAws) Current() *MasterKey {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.curMasterKey
+}
+
+// NewMasterKeyAws implements the interface MasterKeyStorage to support persisting master
+// keys to AWS Secrets Manager. A new version of the secret is created when the current
+// key is older than the key expiration. Secrets Manager only keeps the current and
+// previous versions labeled, so keys should be rotated after every new version.
+// It will error if:
+// - The aws session is nil.
+// - The aws secret id is blank.
+func NewMasterKeyAws(awsSession *session.Session, awsSecretID string, now time.Time, keyExpiration time.Duration) (*MasterKeyAws, error) {
+	if awsSession == nil {
+		return nil, errors.New("aws session cannot be nil")
+	}
+
+	if awsSecretID == "" {
+		return nil, errors.New("aws secret id cannot be empty")
+	}
+
+	storage := &MasterKeyAws{
+		// Init new AWS Secret Manager using provided AWS session.
+		secretManager: secretsmanager.New(awsSession),
+		secretID:      awsSecretID,
+		keyExpiration: keyExpiration,
+	}
+
+	if err := storage.Rotate(context.Background(), now); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// Rotate reloads the master keys from AWS Secrets Manager and creates a new version of
+// the secret when the current key has expired, implements MasterKeyRotator.
+func (s *MasterKeyAws) Rotate(ctx context.Context, now time.Time) error {
+	keys := make(map[string]*MasterKey)
+	secretManager, awsSecretID := s.secretManager, s.secretID
+
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+
+	// Time threshold to create a new key. If a current key exists and the
+	// created date of the key is before this value, a new key will be created.
+	var activeCreatedDate time.Time
+	if s.keyExpiration.Seconds() > 0 {
+		activeCreatedDate = now.UTC().Add(s.keyExpiration * -1)
+	}
+
+	// A List of version ids for the stored secret.
+	var versionIds []string
+
+	// Exec call to AWS secret manager to return a list of version ids for the
+	// provided secret ID.
+	listParams := &secretsmanager.ListSecretVersionIdsInput{
+		SecretId: aws.String(awsSecretID),
+	}
+	err := secretManager.ListSecretVersionIdsPagesWithContext(ctx, listParams,
+		func(page *secretsmanager.ListSecretVersionIdsOutput, lastPage bool) bool {
+			for _, v := range page.Versions {
+				if v.VersionId != nil {
+					versionIds = append(versionIds, *v.VersionId)
+				}
+			}
+			return !lastPage
+		},
+	)
+
+	// Flag whether the secret exists and update needs to be used
+	// instead of create.
+	var awsSecretIDNotFound bool
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			switch aerr.Code() {
+			case secretsmanager.ErrCodeResourceNotFoundException:
+				awsSecretIDNotFound = true
+			}
+		}
+
+		if !awsSecretIDNotFound {
+			return errors.Wrapf(err, "aws list secret version ids for secret ID %s failed", awsSecretID)
+		}
+	}
+
+	// The current key id if there is an active one.
+	var curKeyId string
+
+	// The max created data to determine the most recent key.
+	var lastCreatedDate time.Time
+
+	for _, id := range versionIds {
+		res, err := secretManager.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+			SecretId:  aws.String(awsSecretID),
+			VersionId: aws.String(id),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "aws secret id %s, version id %s value failed", awsSecretID, id)
+		}
+
+		if len(res.SecretBinary) != masterKeySize {
+			continue
+		}
+
+		keys[*res.VersionId] = &MasterKey{id: *res.VersionId, key: res.SecretBinary}
+
+		if lastCreatedDate.IsZero() || res.CreatedDate.UTC().Unix() > lastCreatedDate.UTC().Unix() {
+			curKeyId = *res.VersionId
+			lastCreatedDate = res.CreatedDate.UTC()
+		}
+	}
+
+	if !activeCreatedDate.IsZero() && lastCreatedDate.UTC().Unix() < activeCreatedDate.UTC().Unix() {
+		curKeyId = ""
+	}
+
+	// If there are no keys stored in secret manager, create a new one or
+	// if the current key needs to be rotated, generate a new key and update the secret.
+	if curKeyId == "" {
+		key, err := generateMasterKey()
+		if err != nil {
+			return err
+		}
+
+		if awsSecretIDNotFound {
+			res, err := secretManager.CreateSecretWithContext(ctx, &secretsmanager.CreateSecretInput{
+				Name:         aws.String(awsSecretID),
+				SecretBinary: key,
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to create new secret with master key")
+			}
+			curKeyId = *res.VersionId
+		} else {
+			res, err := secretManager.UpdateSecretWithContext(ctx, &secretsmanager.UpdateSecretInput{
+				SecretId:     aws.String(awsSecretID),
+				SecretBinary: key,
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to update secret with master key")
+			}
+			curKeyId = *res.VersionId
+		}
+
+		keys[curKeyId] = &MasterKey{id: curKeyId, key: key}
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.curMasterKey = keys[curKeyId]
+	s.mu.Unlock()
+
+	return nil
+}
+
+// generateMasterKey returns a new random master key.
+func generateMasterKey() ([]byte, error) {
+	key := make([]byte, masterKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, errors.Wrap(err, "failed to generate new master key")
+	}
+	return key, nil
+}
@@ -0,0 +1,123 @@
+package keystore
+
+import (
+	"context"
+	"crypto/ed25519"
+	"time"
+
+	"exitor-dapp/internal/platform/web"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Repository defines the required dependencies for CustodialAccount.
+type Repository struct {
+	DbConn     *sqlx.DB
+	MasterKeys MasterKeyStorage
+}
+
+// NewRepository creates a new Repository that defines dependencies for CustodialAccount.
+func NewRepository(db *sqlx.DB, masterKeys MasterKeyStorage) *Repository {
+	return &Repository{
+		DbConn:     db,
+		MasterKeys: masterKeys,
+	}
+}
+
+// CustodialAccount represents an Algorand account owned by Exitor, ie: the manager or
+// reserve of assets. The private key is stored encrypted with a master key and is
+// only decrypted to sign transactions.
+type CustodialAccount struct {
+	ID          string       `json:"id" validate:"required,uuid" example:"2f3a8d1c-7a7e-4b8e-9d3a-1f3b2c9d4e5f"`
+	Address     string       `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Label       string       `json:"label" validate:"required,max=200" example:"Exitor Asset Manager"`
+	MasterKeyID string       `json:"master_key_id" truss:"api-hide"`
+	Nonce       []byte       `json:"-" truss:"api-hide"`
+	Ciphertext  []byte       `json:"-" truss:"api-hide"`
+	CreatedAt   time.Time    `json:"created_at" truss:"api-read"`
+	UpdatedAt   time.Time    `json:"updated_at" truss:"api-read"`
+	ArchivedAt  *pq.NullTime `json:"archived_at,omitempty" truss:"api-hide"`
+}
+
+// CustodialAccountResponse represents a custodial account that is returned for display.
+type CustodialAccountResponse struct {
+	ID         string            `json:"id" example:"2f3a8d1c-7a7e-4b8e-9d3a-1f3b2c9d4e5f"`
+	Address    string            `json:"address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Label      string            `json:"label" example:"Exitor Asset Manager"`
+	CreatedAt  web.TimeResponse  `json:"created_at"`            // CreatedAt contains multiple format options for display.
+	UpdatedAt  web.TimeResponse  `json:"updated_at"`            // UpdatedAt contains multiple format options for display.
+	ArchivedAt *web.TimeResponse `json:"archived_at,omitempty"` // ArchivedAt contains multiple format options for display.
+}
+
+// Response transforms CustodialAccount to the CustodialAccountResponse that is used for display.
+func (m *CustodialAccount) Response(ctx context.Context) *CustodialAccountResponse {
+	if m == nil {
+		return nil
+	}
+
+	r := &CustodialAccountResponse{
+		ID:        m.ID,
+		Address:   m.Address,
+		Label:     m.Label,
+		CreatedAt: web.NewTimeResponse(ctx, m.CreatedAt),
+		UpdatedAt: web.NewTimeResponse(ctx, m.UpdatedAt),
+	}
+
+	if m.ArchivedAt != nil && !m.ArchivedAt.Time.IsZero() {
+		at := web.NewTimeResponse(ctx, m.ArchivedAt.Time)
+		r.ArchivedAt = &at
+	}
+
+	return r
+}
+
+// CustodialAccounts a list of CustodialAccounts.
+type CustodialAccounts []*CustodialAccount
+
+// Response transforms a list of CustodialAccounts to a list of CustodialAccountResponses.
+func (m *CustodialAccounts) Response(ctx context.Context) []*CustodialAccountResponse {
+	var l []*CustodialAccountResponse
+	if m != nil && len(*m) > 0 {
+		for _, n := range *m {
+			l = append(l, n.Response(ctx))
+		}
+	}
+
+	return l
+}
+
+// CustodialAccountCreateRequest contains information needed to generate a new custodial account.
+type CustodialAccountCreateRequest struct {
+	Label string `json:"label" validate:"required,max=200" example:"Exitor Asset Manager"`
+}
+
+// CustodialAccountImportRequest contains information needed to store the key of an existing
+// account. The private key is never returned or encoded.
+type CustodialAccountImportRequest struct {
+	Label      string             `json:"label" validate:"required,max=200" example:"Exitor Asset Manager"`
+	PrivateKey ed25519.PrivateKey `json:"-" validate:"required,len=64"`
+}
+
+// CustodialAccountReadRequest defines the information needed to read a custodial account.
+type CustodialAccountReadRequest struct {
+	Address         string `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	IncludeArchived bool   `json:"include-archived" example:"false"`
+}
+
+// CustodialAccountArchiveRequest defines the information needed to archive a custodial account.
+// Archived accounts can no longer sign transactions.
+type CustodialAccountArchiveRequest struct {
+	Address string `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+}
+
+// CustodialAccountFindRequest defines the possible options to search for custodial accounts. By default
+// archived accounts will be excluded from response.
+type CustodialAccountFindRequest struct {
+	Where           string        `json:"where" example:"label = ?"`
+	Args            []interface{} `json:"args" swaggertype:"array,string" example:"Exitor Asset Manager"`
+	Order           []string      `json:"order" example:"created_at desc"`
+	Limit           *uint         `json:"limit" example:"10"`
+	Offset          *uint         `json:"offset" example:"20"`
+	IncludeArchived bool          `json:"include-archived" example:"false"`
+}
@@ -0,0 +1,54 @@
+package keystore
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+)
+
+// DefaultRotateInterval is how often the master keys are rotated when no interval is provided.
+const DefaultRotateInterval = time.Hour
+
+// KeyRotator rotates the master keys of a Repository in the background so a new master
+// key is generated once the current one expires and the private keys are re-encrypted
+// with it.
+type KeyRotator struct {
+	Repository *Repository
+	Log        *log.Logger
+	Interval   time.Duration
+}
+
+// NewKeyRotator creates a new KeyRotator for the repository.
+func NewKeyRotator(repo *Repository, log *log.Logger, interval time.Duration) *KeyRotator {
+	if interval <= 0 {
+		interval = DefaultRotateInterval
+	}
+
+	return &KeyRotator{
+		Repository: repo,
+		Log:        log,
+		Interval:   interval,
+	}
+}
+
+// Run rotates the master keys every interval until the context is cancelled.
+func (r *KeyRotator) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := r.Repository.Rotate(ctx, auth.Claims{}, time.Now())
+			if err != nil && ctx.Err() == nil {
+				r.Log.Printf("keystore : KeyRotator : %+v", err)
+			} else if n > 0 {
+				r.Log.Printf("keystore : KeyRotator : Rotated %d custodial accounts", n)
+			}
+		}
+	}
+}
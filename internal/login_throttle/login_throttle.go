@@ -0,0 +1,405 @@
+package login_throttle
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/go-redis/redis"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	// The database table for User
+	userTableName = "users"
+
+	// redisKeyPrefix is the prefix of all the keys stored in Redis.
+	redisKeyPrefix = "login_throttle"
+
+	// failureWindow is how long failed sign ins are counted for.
+	failureWindow = time.Hour
+
+	// delayFailures is the number of failed sign ins for an email before the following attempts
+	// are delayed.
+	delayFailures = 3
+
+	// baseDelay is the delay after the first failure that is delayed, it doubles with each
+	// following failure up to maxDelay.
+	baseDelay = 2 * time.Second
+
+	// maxDelay is the longest delay between attempts before the email is locked out.
+	maxDelay = 5 * time.Minute
+
+	// lockoutFailures is the number of failed sign ins for an email that locks it out.
+	lockoutFailures = 10
+
+	// lockoutTTL is how long an email is locked out for unless it's unlocked with the link emailed.
+	lockoutTTL = 30 * time.Minute
+
+	// ipMaxFailures is the number of failed sign ins from an IP address for any email before
+	// sign ins from it are blocked for the rest of the failure window.
+	ipMaxFailures = 50
+
+	// resetPasswordWindow is how long password reset requests are counted for.
+	resetPasswordWindow = time.Hour
+
+	// resetPasswordEmailLimit is the number of password reset emails sent to an email within the window.
+	resetPasswordEmailLimit = 3
+
+	// resetPasswordIPLimit is the number of password resets an IP address can request within the window.
+	resetPasswordIPLimit = 10
+)
+
+var (
+	// ErrTooManyAttempts occurs when an attempt is made before the delay after the previous failure
+	// has elapsed or the IP address has exceeded its limit.
+	ErrTooManyAttempts = errors.New("Too many attempts")
+
+	// ErrLocked occurs when the email has been locked out after too many failed sign ins.
+	ErrLocked = errors.New("Locked out after too many failed attempts")
+
+	// ErrUnlockExpired occurs when the the unlock hash exceeds the expiration.
+	ErrUnlockExpired = errors.New("Unlock expired")
+)
+
+// emailKey returns the key an email is tracked by. The email is hashed so it's not stored in Redis.
+func emailKey(email string) string {
+	h := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(h[:])
+}
+
+// lockedKey returns the Redis key that is set while the email is locked out.
+func lockedKey(email string) string {
+	return redisKeyPrefix + ":locked:" + emailKey(email)
+}
+
+// lastFailureKey returns the Redis key that stores the time of the last failed sign in for the email.
+func lastFailureKey(email string) string {
+	return redisKeyPrefix + ":last_failure:" + emailKey(email)
+}
+
+// failureDelay returns how long the next sign in must be delayed after the number of failures.
+func failureDelay(failures int) time.Duration {
+	if failures < delayFailures {
+		return 0
+	}
+
+	d := baseDelay
+	for i := delayFailures; i < failures; i++ {
+		d *= 2
+		if d >= maxDelay {
+			return maxDelay
+		}
+	}
+
+	return d
+}
+
+// Check returns the error and how long the client must wait when a sign in for the email from the
+// IP address must not be attempted. It should be called before the credentials are verified. An
+// allowed attempt is counted as a failure for the email and the IP address right away, so concurrent
+// attempts can't exceed the limits, and Failure, Success or Release must be called with its outcome.
+func (repo *Repository) Check(ctx context.Context, req LoginAttemptRequest, now time.Time) (time.Duration, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.login_throttle.Check")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	client := repo.Redis.WithContext(ctx)
+
+	// Block the IP address once it has failed for too many emails.
+	if req.IPAddress != "" {
+		res, err := repo.ipFailures.Hit(ctx, req.IPAddress)
+		if err != nil {
+			return 0, err
+		} else if !res.Allowed {
+			err = errors.WithMessagef(ErrTooManyAttempts, "ip address %s has %d failures", req.IPAddress, res.Count)
+			return res.RetryAfter, err
+		}
+	}
+
+	// Ensure the email is not locked out.
+	lockTTL, err := client.PTTL(lockedKey(req.Email)).Result()
+	if err != nil {
+		return 0, errors.Wrap(err, "redis pttl")
+	} else if lockTTL > 0 {
+		if err := repo.releaseIP(ctx, req); err != nil {
+			return 0, err
+		}
+		return lockTTL, errors.WithStack(ErrLocked)
+	}
+
+	// Count the attempt, the failures before it include the attempts still being verified.
+	res, err := repo.emailFailures.Hit(ctx, emailKey(req.Email))
+	if err != nil {
+		return 0, err
+	}
+	failures := res.Count - 1
+
+	// Reject the attempts over the limit while the attempt that reached it is being verified.
+	if !res.Allowed {
+		if err := repo.Release(ctx, req, now); err != nil {
+			return 0, err
+		}
+		err = errors.WithMessagef(ErrTooManyAttempts, "%d failures", failures)
+		return failureDelay(failures), err
+	}
+
+	// Delay the attempt when it's too soon after the last failure.
+	delay := failureDelay(failures)
+	if delay == 0 {
+		return 0, nil
+	}
+
+	lastMs, err := client.Get(lastFailureKey(req.Email)).Int64()
+	if err != nil {
+		if err == redis.Nil {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "redis get")
+	}
+
+	retryAt := time.Unix(0, lastMs*int64(time.Millisecond)).Add(delay)
+	if now.Before(retryAt) {
+		if err := repo.Release(ctx, req, now); err != nil {
+			return 0, err
+		}
+		err = errors.WithMessagef(ErrTooManyAttempts, "%d failures", failures)
+		return retryAt.Sub(now), err
+	}
+
+	return 0, nil
+}
+
+// Failure records that the sign in for the email from the IP address allowed by Check failed. When
+// the failure locks out the email, the user is emailed a link to unlock it and ErrLocked is returned.
+func (repo *Repository) Failure(ctx context.Context, req LoginAttemptRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.login_throttle.Failure")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	client := repo.Redis.WithContext(ctx)
+
+	// The attempt was already counted by Check.
+	res, err := repo.emailFailures.Peek(ctx, emailKey(req.Email))
+	if err != nil {
+		return err
+	}
+
+	lastMs := now.UnixNano() / int64(time.Millisecond)
+	err = client.Set(lastFailureKey(req.Email), strconv.FormatInt(lastMs, 10), failureWindow).Err()
+	if err != nil {
+		return errors.Wrap(err, "redis set")
+	}
+
+	if res.Count < lockoutFailures {
+		return nil
+	}
+
+	// Lock out the email, the failures are cleared so they start over once the lockout expires.
+	locked, err := client.SetNX(lockedKey(req.Email), "1", lockoutTTL).Result()
+	if err != nil {
+		return errors.Wrap(err, "redis setnx")
+	}
+
+	err = repo.clearFailures(ctx, req.Email)
+	if err != nil {
+		return err
+	}
+
+	// Only email the user once for each lockout.
+	if locked {
+		err = repo.sendUnlock(ctx, req.Email, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return errors.WithStack(ErrLocked)
+}
+
+// Success clears the failed sign ins of the email after the user has signed in with the attempt
+// allowed by Check.
+func (repo *Repository) Success(ctx context.Context, req LoginAttemptRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.login_throttle.Success")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if err := repo.releaseIP(ctx, req); err != nil {
+		return err
+	}
+
+	return repo.clearFailures(ctx, req.Email)
+}
+
+// Release removes the attempt counted by Check when the credentials were neither accepted nor
+// rejected, such as when a second factor is required. The previous failures are kept.
+func (repo *Repository) Release(ctx context.Context, req LoginAttemptRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.login_throttle.Release")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if err := repo.releaseIP(ctx, req); err != nil {
+		return err
+	}
+
+	return repo.emailFailures.Release(ctx, emailKey(req.Email))
+}
+
+// releaseIP removes the attempt counted by Check for the IP address.
+func (repo *Repository) releaseIP(ctx context.Context, req LoginAttemptRequest) error {
+	if req.IPAddress == "" {
+		return nil
+	}
+	return repo.ipFailures.Release(ctx, req.IPAddress)
+}
+
+// clearFailures removes the failed sign ins counted for the email.
+func (repo *Repository) clearFailures(ctx context.Context, email string) error {
+	if err := repo.emailFailures.Reset(ctx, emailKey(email)); err != nil {
+		return err
+	}
+
+	if err := repo.Redis.WithContext(ctx).Del(lastFailureKey(email)).Err(); err != nil {
+		return errors.Wrap(err, "redis del")
+	}
+
+	return nil
+}
+
+// sendUnlock emails the link to unlock the email when it belongs to a user.
+func (repo *Repository) sendUnlock(ctx context.Context, email string, now time.Time) error {
+
+	// Find the user by email address, no email is sent when there isn't one.
+	// select first_name from users where email = [email] and archived_at is null
+	query := sqlbuilder.NewSelectBuilder().Select("first_name").From(userTableName)
+	query.Where(query.And(
+		query.Equal("email", email),
+		query.IsNull("archived_at"),
+	))
+	queryStr, args := query.Build()
+	queryStr = repo.DbConn.Rebind(queryStr)
+
+	var firstName string
+	err := repo.DbConn.QueryRowContext(ctx, queryStr, args...).Scan(&firstName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		err = errors.Wrapf(err, "query - %s", query.String())
+		return err
+	}
+
+	encrypted, err := NewUnlockHash(ctx, repo.secretKey, email, lockoutTTL, now)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"Name":    firstName,
+		"Url":     repo.unlockUrl(encrypted),
+		"Minutes": lockoutTTL.Minutes(),
+	}
+
+	err = repo.Notify.Send(ctx, email, "Your account has been locked", "user_unlock", data)
+	if err != nil {
+		err = errors.WithMessagef(err, "Send unlock email to %s failed.", email)
+		return err
+	}
+
+	return nil
+}
+
+// Unlock removes the lockout and failed sign ins of the email using the hash from the link emailed.
+func (repo *Repository) Unlock(ctx context.Context, req UnlockRequest, now time.Time) (*UnlockHash, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.login_throttle.Unlock")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := ParseUnlockHash(ctx, repo.secretKey, req.UnlockHash, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repo.Redis.WithContext(ctx).Del(lockedKey(hash.Email)).Err(); err != nil {
+		return nil, errors.Wrap(err, "redis del")
+	}
+
+	err = repo.clearFailures(ctx, hash.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	return hash, nil
+}
+
+// ResetPasswordAttempt counts a password reset requested for the email from the IP address. It
+// returns ErrTooManyAttempts and how long the client must wait when either has exceeded its limit.
+func (repo *Repository) ResetPasswordAttempt(ctx context.Context, req ResetPasswordAttemptRequest, now time.Time) (time.Duration, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.login_throttle.ResetPasswordAttempt")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	if req.IPAddress != "" {
+		res, err := repo.resetPasswordIP.Hit(ctx, req.IPAddress)
+		if err != nil {
+			return 0, err
+		} else if !res.Allowed {
+			err = errors.WithMessagef(ErrTooManyAttempts, "ip address %s has requested %d resets", req.IPAddress, res.Count)
+			return res.RetryAfter, err
+		}
+	}
+
+	res, err := repo.resetPasswordEmail.Hit(ctx, emailKey(req.Email))
+	if err != nil {
+		return 0, err
+	} else if !res.Allowed {
+		err = errors.WithMessagef(ErrTooManyAttempts, "%d resets requested", res.Count)
+		return res.RetryAfter, err
+	}
+
+	return 0, nil
+}
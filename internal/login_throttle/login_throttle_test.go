@@ -0,0 +1,554 @@
+package login_throttle
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"exitor-dapp/internal/platform/redistest"
+	"exitor-dapp/internal/platform/tests"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// unlockPath is the path of the link emailed to unlock an email in tests.
+const unlockPath = "/user/unlock/"
+
+// sentEmail is an email sent by mockEmail.
+type sentEmail struct {
+	to           string
+	templateName string
+	data         map[string]interface{}
+}
+
+// mockEmail records the emails sent, implements notify.Email.
+type mockEmail struct {
+	sent []sentEmail
+}
+
+// Send records the email.
+func (n *mockEmail) Send(ctx context.Context, toEmail, subject, templateName string, data map[string]interface{}) error {
+	n.sent = append(n.sent, sentEmail{to: toEmail, templateName: templateName, data: data})
+	return nil
+}
+
+// Verify does nothing.
+func (n *mockEmail) Verify() error {
+	return nil
+}
+
+// usersConnector is a database/sql connector that answers the lookup of the first name of a
+// user by email, so the unlock email can be tested without a database.
+type usersConnector map[string]string
+
+func (c usersConnector) Connect(context.Context) (driver.Conn, error) { return usersConn(c), nil }
+func (c usersConnector) Driver() driver.Driver                        { return nil }
+
+type usersConn map[string]string
+
+func (c usersConn) Prepare(query string) (driver.Stmt, error) { return usersStmt(c), nil }
+func (c usersConn) Close() error                              { return nil }
+func (c usersConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type usersStmt map[string]string
+
+func (s usersStmt) Close() error  { return nil }
+func (s usersStmt) NumInput() int { return -1 }
+func (s usersStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s usersStmt) Query(args []driver.Value) (driver.Rows, error) {
+	email, _ := args[0].(string)
+	name, ok := s[email]
+	return &usersRows{name: name, ok: ok}, nil
+}
+
+type usersRows struct {
+	name string
+	ok   bool
+}
+
+func (r *usersRows) Columns() []string { return []string{"first_name"} }
+func (r *usersRows) Close() error      { return nil }
+func (r *usersRows) Next(dest []driver.Value) error {
+	if !r.ok {
+		return io.EOF
+	}
+	dest[0], r.ok = r.name, false
+	return nil
+}
+
+// newTestRepository returns a Repository backed by a fake Redis server with the users by
+// email that can be emailed an unlock link.
+func newTestRepository(users map[string]string) (*Repository, *redistest.Server, *mockEmail) {
+	srv := redistest.NewServer()
+	db := sqlx.NewDb(sql.OpenDB(usersConnector(users)), "postgres")
+	notify := &mockEmail{}
+
+	unlockUrl := func(hash string) string {
+		return "https://example.com" + unlockPath + hash
+	}
+
+	return NewRepository(db, srv.Client(), unlockUrl, notify, "6368616e676520746869732070617373"), srv, notify
+}
+
+// TestFailureDelay validates the delay between attempts grows with each failure.
+func TestFailureDelay(t *testing.T) {
+	var delays = []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{0, 0},
+		{delayFailures - 1, 0},
+		{delayFailures, baseDelay},
+		{delayFailures + 1, baseDelay * 2},
+		{delayFailures + 2, baseDelay * 4},
+		{delayFailures + 20, maxDelay},
+	}
+
+	t.Log("Given the need to delay attempts after failed sign ins.")
+	{
+		for i, tt := range delays {
+			delay := failureDelay(tt.failures)
+			if delay != tt.delay {
+				t.Logf("\t\tGot : %s", delay)
+				t.Logf("\t\tWant: %s", tt.delay)
+				t.Fatalf("\t%s\tTest %d delay for %d failures does not match.", tests.Failed, i, tt.failures)
+			}
+		}
+		t.Logf("\t%s\tfailureDelay ok.", tests.Success)
+	}
+}
+
+// TestEmailKey validates emails are tracked by the same key regardless of case and whitespace.
+func TestEmailKey(t *testing.T) {
+	t.Log("Given the need to track failed sign ins by email.")
+	{
+		if emailKey(" Gabi.May@Example.com ") != emailKey("gabi.may@example.com") {
+			t.Fatalf("\t%s\tKeys for the same email don't match.", tests.Failed)
+		}
+
+		if emailKey("gabi.may@example.com") == emailKey("lee.brown@example.com") {
+			t.Fatalf("\t%s\tKeys for different emails match.", tests.Failed)
+		}
+		t.Logf("\t%s\temailKey ok.", tests.Success)
+	}
+}
+
+// TestUnlockHash validates the hash emailed to unlock an email can be parsed until it expires.
+func TestUnlockHash(t *testing.T) {
+	now := time.Date(2020, time.April, 18, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	secretKey := "6368616e676520746869732070617373"
+	email := "gabi.may@example.com"
+
+	t.Log("Given the need to unlock an email with the link emailed.")
+	{
+		encrypted, err := NewUnlockHash(ctx, secretKey, email, lockoutTTL, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tNewUnlockHash failed.", tests.Failed)
+		}
+
+		hash, err := ParseUnlockHash(ctx, secretKey, encrypted, now.Add(time.Minute))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tParseUnlockHash failed.", tests.Failed)
+		} else if hash.Email != email {
+			t.Logf("\t\tGot : %s", hash.Email)
+			t.Logf("\t\tWant: %s", email)
+			t.Fatalf("\t%s\tParseUnlockHash email does not match.", tests.Failed)
+		}
+		t.Logf("\t%s\tParseUnlockHash ok.", tests.Success)
+
+		_, err = ParseUnlockHash(ctx, secretKey, encrypted, now.Add(lockoutTTL+time.Minute))
+		if errors.Cause(err) != ErrUnlockExpired {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrUnlockExpired)
+			t.Fatalf("\t%s\tParseUnlockHash didn't expire.", tests.Failed)
+		}
+		t.Logf("\t%s\tParseUnlockHash expired ok.", tests.Success)
+	}
+}
+
+// fail makes a sign in attempt for the request that is allowed by Check and fails, it returns
+// the error of Failure.
+func fail(t *testing.T, repo *Repository, req LoginAttemptRequest, now time.Time) error {
+	ctx := tests.Context()
+
+	wait, err := repo.Check(ctx, req, now)
+	if err != nil || wait != 0 {
+		t.Logf("\t\tGot : %s %+v", wait, err)
+		t.Fatalf("\t%s\tCheck should allow the attempt.", tests.Failed)
+	}
+
+	return repo.Failure(ctx, req, now)
+}
+
+// TestCheck validates sign ins are delayed after failures and blocked for IP addresses with
+// too many failures.
+func TestCheck(t *testing.T) {
+	repo, srv, _ := newTestRepository(nil)
+	defer srv.Close()
+
+	ctx := tests.Context()
+	now := time.Date(2020, time.April, 18, 10, 0, 0, 0, time.UTC)
+
+	req := LoginAttemptRequest{Email: "gabi.may@example.com", IPAddress: "69.56.104.36"}
+
+	t.Log("Given the need to delay sign ins after failed attempts.")
+	{
+		for i := 0; i < delayFailures; i++ {
+			if err := fail(t, repo, req, now); err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFailure %d failed.", tests.Failed, i)
+			}
+		}
+		t.Logf("\t%s\tCheck before delay ok.", tests.Success)
+
+		// Each failure after delayFailures doubles the delay.
+		for i, want := range []time.Duration{baseDelay, baseDelay * 2, baseDelay * 4} {
+			wait, err := repo.Check(ctx, req, now.Add(time.Second))
+			if errors.Cause(err) != ErrTooManyAttempts {
+				t.Logf("\t\tGot : %+v", err)
+				t.Logf("\t\tWant: %+v", ErrTooManyAttempts)
+				t.Fatalf("\t%s\tCheck %d should delay.", tests.Failed, i)
+			} else if wait != want-time.Second {
+				t.Logf("\t\tGot : %s", wait)
+				t.Logf("\t\tWant: %s", want-time.Second)
+				t.Fatalf("\t%s\tCheck %d wait does not match.", tests.Failed, i)
+			}
+
+			now = now.Add(want)
+			if err := fail(t, repo, req, now); err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFailure %d failed.", tests.Failed, i)
+			}
+		}
+		t.Logf("\t%s\tCheck delay ok.", tests.Success)
+
+		// Failures for an email don't delay sign ins for other emails.
+		other := LoginAttemptRequest{Email: "lee.brown@example.com", IPAddress: req.IPAddress}
+		wait, err := repo.Check(ctx, other, now)
+		if err != nil || wait != 0 {
+			t.Logf("\t\tGot : %s %+v", wait, err)
+			t.Fatalf("\t%s\tCheck for another email should not delay.", tests.Failed)
+		}
+
+		if err := repo.Release(ctx, other, now); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRelease failed.", tests.Failed)
+		}
+
+		res, err := repo.emailFailures.Peek(ctx, emailKey(other.Email))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tPeek failed.", tests.Failed)
+		} else if res.Count != 0 {
+			t.Logf("\t\tGot : %d", res.Count)
+			t.Logf("\t\tWant: %d", 0)
+			t.Fatalf("\t%s\tRelease should remove the attempt.", tests.Failed)
+		}
+		t.Logf("\t%s\tCheck other email ok.", tests.Success)
+	}
+
+	t.Log("Given the need to block IP addresses failing sign ins for many emails.")
+	{
+		ipReq := LoginAttemptRequest{IPAddress: "69.56.104.37"}
+		for i := 0; i < ipMaxFailures; i++ {
+			ipReq.Email = fmt.Sprintf("user%d@example.com", i)
+			if err := fail(t, repo, ipReq, now); err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFailure %d failed.", tests.Failed, i)
+			}
+		}
+
+		ipReq.Email = "lee.brown@example.com"
+		wait, err := repo.Check(ctx, ipReq, now)
+		if errors.Cause(err) != ErrTooManyAttempts {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrTooManyAttempts)
+			t.Fatalf("\t%s\tCheck should block the IP address.", tests.Failed)
+		} else if wait <= 0 || wait > failureWindow {
+			t.Logf("\t\tGot : %s", wait)
+			t.Fatalf("\t%s\tCheck wait should be within the failure window.", tests.Failed)
+		}
+
+		wait, err = repo.Check(ctx, LoginAttemptRequest{Email: ipReq.Email, IPAddress: "69.56.104.38"}, now)
+		if err != nil || wait != 0 {
+			t.Logf("\t\tGot : %s %+v", wait, err)
+			t.Fatalf("\t%s\tCheck from another IP address should be allowed.", tests.Failed)
+		}
+		t.Logf("\t%s\tCheck IP address ok.", tests.Success)
+	}
+}
+
+// TestCheckConcurrent validates concurrent sign ins for an email can't exceed the lockout.
+func TestCheckConcurrent(t *testing.T) {
+	email := "gabi.may@example.com"
+	repo, srv, notify := newTestRepository(map[string]string{email: "Gabi"})
+	defer srv.Close()
+
+	ctx := tests.Context()
+	now := time.Date(2020, time.April, 18, 10, 0, 0, 0, time.UTC)
+
+	req := LoginAttemptRequest{Email: email, IPAddress: "69.56.104.36"}
+
+	t.Log("Given the need to lock out an email attacked with concurrent sign ins.")
+	{
+		var (
+			wg      sync.WaitGroup
+			mtx     sync.Mutex
+			allowed int
+		)
+		for i := 0; i < lockoutFailures*3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				_, err := repo.Check(ctx, req, now)
+				if err == nil {
+					mtx.Lock()
+					allowed++
+					mtx.Unlock()
+				} else if errors.Cause(err) != ErrTooManyAttempts {
+					t.Errorf("\t%s\tCheck failed : %+v", tests.Failed, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if allowed != lockoutFailures {
+			t.Logf("\t\tGot : %d", allowed)
+			t.Logf("\t\tWant: %d", lockoutFailures)
+			t.Fatalf("\t%s\tCheck should only allow attempts up to the lockout.", tests.Failed)
+		}
+		t.Logf("\t%s\tCheck concurrent ok.", tests.Success)
+
+		var locked bool
+		for i := 0; i < allowed; i++ {
+			err := repo.Failure(ctx, req, now)
+			if errors.Cause(err) == ErrLocked {
+				locked = true
+			} else if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFailure failed.", tests.Failed)
+			}
+		}
+
+		if _, err := repo.Check(ctx, req, now); !locked || errors.Cause(err) != ErrLocked {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrLocked)
+			t.Fatalf("\t%s\tThe failures should lock out the email.", tests.Failed)
+		} else if len(notify.sent) != 1 {
+			t.Logf("\t\tGot : %d", len(notify.sent))
+			t.Logf("\t\tWant: %d", 1)
+			t.Fatalf("\t%s\tExpected an unlock email.", tests.Failed)
+		}
+		t.Logf("\t%s\tLockout concurrent ok.", tests.Success)
+	}
+}
+
+// TestLockout validates an email is locked out after too many failures, the user is emailed
+// a link to unlock it and a success resets the failures.
+func TestLockout(t *testing.T) {
+	email := "gabi.may@example.com"
+	repo, srv, notify := newTestRepository(map[string]string{email: "Gabi"})
+	defer srv.Close()
+
+	ctx := tests.Context()
+	now := time.Date(2020, time.April, 18, 10, 0, 0, 0, time.UTC)
+
+	req := LoginAttemptRequest{Email: email, IPAddress: "69.56.104.36"}
+
+	// lockout fails sign ins for the email until it's locked out, each after the delay of the
+	// previous failure.
+	lockout := func(t *testing.T, req LoginAttemptRequest) {
+		at := now
+		for i := 1; i < lockoutFailures; i++ {
+			if err := fail(t, repo, req, at); err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFailure %d failed.", tests.Failed, i)
+			}
+			at = at.Add(maxDelay)
+		}
+
+		err := fail(t, repo, req, at)
+		if errors.Cause(err) != ErrLocked {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrLocked)
+			t.Fatalf("\t%s\tFailure %d should lock out the email.", tests.Failed, lockoutFailures)
+		}
+	}
+
+	t.Log("Given the need to reset failed sign ins after a success.")
+	{
+		for i := 0; i < delayFailures; i++ {
+			if err := fail(t, repo, req, now); err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tFailure failed.", tests.Failed)
+			}
+		}
+
+		if _, err := repo.Check(ctx, req, now); errors.Cause(err) != ErrTooManyAttempts {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrTooManyAttempts)
+			t.Fatalf("\t%s\tCheck should delay.", tests.Failed)
+		}
+
+		wait, err := repo.Check(ctx, req, now.Add(baseDelay))
+		if err != nil || wait != 0 {
+			t.Logf("\t\tGot : %s %+v", wait, err)
+			t.Fatalf("\t%s\tCheck should be allowed once the delay has elapsed.", tests.Failed)
+		}
+
+		if err := repo.Success(ctx, req, now.Add(baseDelay)); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSuccess failed.", tests.Failed)
+		}
+
+		res, err := repo.emailFailures.Peek(ctx, emailKey(email))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tPeek failed.", tests.Failed)
+		} else if res.Count != 0 {
+			t.Logf("\t\tGot : %d", res.Count)
+			t.Logf("\t\tWant: %d", 0)
+			t.Fatalf("\t%s\tSuccess should reset the failures.", tests.Failed)
+		}
+
+		// Only the failures are counted for the IP address.
+		res, err = repo.ipFailures.Peek(ctx, req.IPAddress)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tPeek failed.", tests.Failed)
+		} else if res.Count != delayFailures {
+			t.Logf("\t\tGot : %d", res.Count)
+			t.Logf("\t\tWant: %d", delayFailures)
+			t.Fatalf("\t%s\tSuccess should not count for the IP address.", tests.Failed)
+		}
+
+		if err := fail(t, repo, req, now.Add(baseDelay)); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCheck after success should not delay.", tests.Failed)
+		}
+
+		if err := repo.Success(ctx, req, now.Add(baseDelay)); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSuccess failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tSuccess ok.", tests.Success)
+	}
+
+	t.Log("Given the need to lock out an email after too many failed sign ins.")
+	{
+		lockout(t, req)
+
+		wait, err := repo.Check(ctx, req, now)
+		if errors.Cause(err) != ErrLocked {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrLocked)
+			t.Fatalf("\t%s\tCheck should be locked out.", tests.Failed)
+		} else if wait <= 0 || wait > lockoutTTL {
+			t.Logf("\t\tGot : %s", wait)
+			t.Fatalf("\t%s\tCheck wait should be within the lockout.", tests.Failed)
+		}
+		t.Logf("\t%s\tCheck locked ok.", tests.Success)
+
+		if len(notify.sent) != 1 {
+			t.Logf("\t\tGot : %d", len(notify.sent))
+			t.Logf("\t\tWant: %d", 1)
+			t.Fatalf("\t%s\tExpected an unlock email.", tests.Failed)
+		} else if sent := notify.sent[0]; sent.to != email || sent.templateName != "user_unlock" || sent.data["Name"] != "Gabi" {
+			t.Logf("\t\tGot : %+v", sent)
+			t.Fatalf("\t%s\tUnlock email does not match.", tests.Failed)
+		}
+		t.Logf("\t%s\tUnlock email ok.", tests.Success)
+
+		// The lockout expires without using the link.
+		srv.FastForward(lockoutTTL)
+
+		wait, err = repo.Check(ctx, req, now.Add(lockoutTTL))
+		if err != nil || wait != 0 {
+			t.Logf("\t\tGot : %s %+v", wait, err)
+			t.Fatalf("\t%s\tCheck should be allowed once the lockout expired.", tests.Failed)
+		}
+		t.Logf("\t%s\tLockout expired ok.", tests.Success)
+
+		// No email is sent when the email doesn't belong to a user.
+		lockout(t, LoginAttemptRequest{Email: "lee.brown@example.com"})
+		if len(notify.sent) != 1 {
+			t.Logf("\t\tGot : %d", len(notify.sent))
+			t.Logf("\t\tWant: %d", 1)
+			t.Fatalf("\t%s\tExpected no email for an unknown email.", tests.Failed)
+		}
+		t.Logf("\t%s\tLockout unknown email ok.", tests.Success)
+	}
+}
+
+// TestUnlock validates the link emailed when an email is locked out unlocks it.
+func TestUnlock(t *testing.T) {
+	email := "gabi.may@example.com"
+	repo, srv, notify := newTestRepository(map[string]string{email: "Gabi"})
+	defer srv.Close()
+
+	ctx := tests.Context()
+	now := time.Date(2020, time.April, 18, 10, 0, 0, 0, time.UTC)
+
+	req := LoginAttemptRequest{Email: email}
+
+	t.Log("Given the need to unlock an email with the link emailed.")
+	{
+		// The failures are each after the delay of the previous, the last locks it out now.
+		var err error
+		for i := lockoutFailures - 1; i >= 0; i-- {
+			err = fail(t, repo, req, now.Add(-time.Duration(i)*maxDelay))
+		}
+		if errors.Cause(err) != ErrLocked || len(notify.sent) != 1 {
+			t.Logf("\t\tGot : %+v", err)
+			t.Fatalf("\t%s\tExpected the email to be locked out.", tests.Failed)
+		}
+
+		link, _ := notify.sent[0].data["Url"].(string)
+		idx := strings.Index(link, unlockPath)
+		if idx < 0 {
+			t.Logf("\t\tGot : %s", link)
+			t.Fatalf("\t%s\tUnlock email is missing the link.", tests.Failed)
+		}
+		unlockReq := UnlockRequest{UnlockHash: link[idx+len(unlockPath):]}
+
+		_, err = repo.Unlock(ctx, unlockReq, now.Add(lockoutTTL+time.Minute))
+		if errors.Cause(err) != ErrUnlockExpired {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrUnlockExpired)
+			t.Fatalf("\t%s\tUnlock should fail once the link expired.", tests.Failed)
+		}
+		t.Logf("\t%s\tUnlock expired ok.", tests.Success)
+
+		hash, err := repo.Unlock(ctx, unlockReq, now.Add(time.Minute))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tUnlock failed.", tests.Failed)
+		} else if hash.Email != email {
+			t.Logf("\t\tGot : %s", hash.Email)
+			t.Logf("\t\tWant: %s", email)
+			t.Fatalf("\t%s\tUnlock email does not match.", tests.Failed)
+		}
+
+		wait, err := repo.Check(ctx, req, now.Add(time.Minute))
+		if err != nil || wait != 0 {
+			t.Logf("\t\tGot : %s %+v", wait, err)
+			t.Fatalf("\t%s\tCheck should be allowed once unlocked.", tests.Failed)
+		}
+		t.Logf("\t%s\tUnlock ok.", tests.Success)
+	}
+}
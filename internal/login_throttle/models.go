@@ -0,0 +1,132 @@
+package login_throttle
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"exitor-dapp/internal/platform/notify"
+	"exitor-dapp/internal/platform/ratelimit"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/sudo-suhas/symcrypto"
+	redistrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-redis/redis"
+)
+
+// Repository defines the required dependencies for LoginThrottle.
+type Repository struct {
+	DbConn    *sqlx.DB
+	Redis     *redistrace.Client
+	Notify    notify.Email
+	unlockUrl func(string) string
+	secretKey string
+
+	emailFailures      *ratelimit.Limiter
+	ipFailures         *ratelimit.Limiter
+	resetPasswordEmail *ratelimit.Limiter
+	resetPasswordIP    *ratelimit.Limiter
+}
+
+// NewRepository creates a new Repository that defines dependencies for LoginThrottle.
+func NewRepository(db *sqlx.DB, redisClient *redistrace.Client, unlockUrl func(string) string, notify notify.Email, secretKey string) *Repository {
+	return &Repository{
+		DbConn:    db,
+		Redis:     redisClient,
+		Notify:    notify,
+		unlockUrl: unlockUrl,
+		secretKey: secretKey,
+
+		// Attempts are counted by Check, the email is locked out by the failure of the attempt
+		// that reaches the limit and the attempts over it are rejected until then.
+		emailFailures: ratelimit.New(redisClient, redisKeyPrefix+":failures:email", lockoutFailures, failureWindow),
+		ipFailures:    ratelimit.New(redisClient, redisKeyPrefix+":failures:ip", ipMaxFailures, failureWindow),
+
+		resetPasswordEmail: ratelimit.New(redisClient, redisKeyPrefix+":reset_password:email", resetPasswordEmailLimit, resetPasswordWindow),
+		resetPasswordIP:    ratelimit.New(redisClient, redisKeyPrefix+":reset_password:ip", resetPasswordIPLimit, resetPasswordWindow),
+	}
+}
+
+// LoginAttemptRequest identifies the client attempting to sign in as a user.
+type LoginAttemptRequest struct {
+	Email     string `json:"email" validate:"required,email" example:"gabi.may@geeksinthewoods.com"`
+	IPAddress string `json:"ip_address" validate:"omitempty,ip" example:"69.56.104.36"`
+}
+
+// ResetPasswordAttemptRequest identifies the client requesting a password reset email for a user.
+type ResetPasswordAttemptRequest struct {
+	Email     string `json:"email" validate:"required,email" example:"gabi.may@geeksinthewoods.com"`
+	IPAddress string `json:"ip_address" validate:"omitempty,ip" example:"69.56.104.36"`
+}
+
+// UnlockRequest defines the information needed to unlock an email that was locked out.
+type UnlockRequest struct {
+	UnlockHash string `json:"unlock_hash" validate:"required"`
+}
+
+// UnlockHash contains the details encrypted in the link emailed to unlock an email.
+type UnlockHash struct {
+	Email     string `json:"email" validate:"required,email" example:"gabi.may@geeksinthewoods.com"`
+	CreatedAt int    `json:"created_at" validate:"required"`
+	ExpiresAt int    `json:"expires_at" validate:"required"`
+}
+
+// NewUnlockHash generates a new encrypted unlock hash that is web safe for use in URLs.
+func NewUnlockHash(ctx context.Context, secretKey, email string, ttl time.Duration, now time.Time) (string, error) {
+
+	// Generate a string that embeds additional information.
+	hashPts := []string{
+		email,
+		strconv.Itoa(int(now.UTC().Unix())),
+		strconv.Itoa(int(now.UTC().Add(ttl).Unix())),
+	}
+	hashStr := strings.Join(hashPts, "|")
+
+	// This returns the nonce appended with the encrypted string.
+	crypto, err := symcrypto.New(secretKey)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	encrypted, err := crypto.Encrypt(hashStr)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return encrypted, nil
+}
+
+// ParseUnlockHash extracts the details encrypted in the hash string.
+func ParseUnlockHash(ctx context.Context, secretKey string, str string, now time.Time) (*UnlockHash, error) {
+
+	crypto, err := symcrypto.New(secretKey)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	hashStr, err := crypto.Decrypt(str)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	hashPts := strings.Split(hashStr, "|")
+
+	var hash UnlockHash
+	if len(hashPts) == 3 {
+		hash.Email = hashPts[0]
+		hash.CreatedAt, _ = strconv.Atoi(hashPts[1])
+		hash.ExpiresAt, _ = strconv.Atoi(hashPts[2])
+	}
+
+	// Validate the hash.
+	err = webcontext.Validator().StructCtx(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(hash.ExpiresAt) < now.UTC().Unix() {
+		err = errors.WithMessage(ErrUnlockExpired, "Unlock has expired.")
+		return nil, err
+	}
+
+	return &hash, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"exitor-dapp/internal/platform/auth"
 	"exitor-dapp/internal/platform/web"
@@ -51,6 +52,14 @@ func AuthenticateHeader(authenticator *auth.Authenticator) web.Middleware {
 					return weberror.NewError(ctx, err, http.StatusUnauthorized)
 				}
 
+				// Tokens of revoked sessions are rejected before they expire.
+				if err := authenticator.CheckSession(ctx, claims); err != nil {
+					if errors.Cause(err) == auth.ErrSessionRevoked {
+						return weberror.NewError(ctx, err, http.StatusUnauthorized)
+					}
+					return err
+				}
+
 				// Add claims to the context so they can be retrieved later.
 				ctx = context.WithValue(ctx, auth.Key, claims)
 
@@ -112,6 +121,14 @@ func authenticateSession(authenticator *auth.Authenticator, required bool) web.M
 					if required {
 						return weberror.NewError(ctx, err, http.StatusUnauthorized)
 					}
+				} else if err := authenticator.CheckSession(ctx, claims); err != nil {
+					// Tokens of revoked sessions are rejected before they expire.
+					if errors.Cause(err) != auth.ErrSessionRevoked {
+						return err
+					} else if required {
+						return weberror.NewError(ctx, err, http.StatusUnauthorized)
+					}
+					claims = auth.Claims{}
 				}
 
 				// Add claims to the context so they can be retrieved later.
@@ -136,6 +153,75 @@ func authenticateSession(authenticator *auth.Authenticator, required bool) web.M
 	return f
 }
 
+// SessionRefresher exchanges the refresh token stored in the session for a new access token and
+// refresh token. The claims are the ones of the expired access token. Empty tokens are returned
+// when the refresh token has been rejected.
+type SessionRefresher func(ctx context.Context, claims auth.Claims, refreshToken string) (accessToken string, newRefreshToken string, err error)
+
+// RefreshSession replaces the access token loaded from the session with a new one when it has
+// expired and the session has a refresh token. When the refresh token is rejected the session
+// is left unchanged and the request is treated as unauthenticated by AuthenticateSession.
+func RefreshSession(authenticator *auth.Authenticator, refresher SessionRefresher) web.Middleware {
+
+	// This is the actual middleware function to be executed.
+	f := func(after web.Handler) web.Handler {
+
+		// Wrap this handler around the next one provided.
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+			span, ctx := tracer.StartSpanFromContext(ctx, "internal.mid.RefreshSession")
+			defer span.Finish()
+
+			m := func() error {
+				tknStr, ok := webcontext.ContextAccessToken(ctx)
+				if !ok || tknStr == "" {
+					return nil
+				}
+
+				refreshTkn, ok := webcontext.ContextRefreshToken(ctx)
+				if !ok || refreshTkn == "" {
+					return nil
+				}
+
+				if _, err := authenticator.ParseClaims(tknStr); err == nil {
+					return nil
+				}
+
+				// Only tokens signed by us that have expired are refreshed.
+				claims, err := authenticator.ParseExpiredClaims(tknStr)
+				if err != nil || claims.VerifyExpiresAt(time.Now().Unix(), true) {
+					return nil
+				}
+
+				accessTkn, newRefreshTkn, err := refresher(ctx, claims, refreshTkn)
+				if err != nil {
+					return err
+				} else if accessTkn == "" {
+					return nil
+				}
+
+				sess := webcontext.ContextSession(ctx)
+				sess = webcontext.SessionUpdateAccessToken(sess, accessTkn)
+				sess = webcontext.SessionUpdateRefreshToken(sess, newRefreshTkn)
+
+				return sess.Save(r, w)
+			}
+
+			if err := m(); err != nil {
+				if web.RequestIsJson(r) {
+					return web.RespondJsonError(ctx, w, err)
+				}
+				return err
+			}
+
+			return after(ctx, w, r, params)
+		}
+
+		return h
+	}
+
+	return f
+}
+
 // HasAuth validates the current user is an authenticated user,
 func HasAuth() web.Middleware {
 
@@ -215,6 +301,46 @@ func HasRole(roles ...string) web.Middleware {
 	return f
 }
 
+// HasPermission validates that an authenticated user has been granted at least one
+// permission from a specified list by their roles.
+func HasPermission(permissions ...string) web.Middleware {
+
+	// This is the actual middleware function to be executed.
+	f := func(after web.Handler) web.Handler {
+
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+			span, ctx := tracer.StartSpanFromContext(ctx, "internal.mid.HasPermission")
+			defer span.Finish()
+
+			m := func() error {
+				claims, err := auth.ClaimsFromContext(ctx)
+				if err != nil {
+					return err
+				}
+
+				if !claims.HasPermission(permissions...) {
+					return ErrorForbidden(ctx)
+				}
+
+				return nil
+			}
+
+			if err := m(); err != nil {
+				if web.RequestIsJson(r) {
+					return web.RespondJsonError(ctx, w, err)
+				}
+				return err
+			}
+
+			return after(ctx, w, r, params)
+		}
+
+		return h
+	}
+
+	return f
+}
+
 // parseAuthHeader parses an authorization header. Expected header is of
 // the format `Bearer <token>`.
 func parseAuthHeader(bearerStr string) (string, error) {
@@ -0,0 +1,126 @@
+package mid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/tests"
+	"exitor-dapp/internal/platform/web/webcontext"
+	"exitor-dapp/internal/platform/web/weberror"
+
+	"github.com/gorilla/sessions"
+	"github.com/pkg/errors"
+)
+
+// TestAuthenticateRevokedSession validates the access token of a revoked session is rejected
+// before it expires.
+func TestAuthenticateRevokedSession(t *testing.T) {
+	now := time.Now().UTC()
+
+	authenticator, err := auth.NewAuthenticatorMemory(now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNewAuthenticatorMemory failed.", tests.Failed)
+	}
+
+	revoked := map[string]bool{"revoked-session": true}
+	authenticator.SessionChecker = func(ctx context.Context, claims auth.Claims) error {
+		if claims.SessionID == "unavailable-session" {
+			return errors.New("database unavailable")
+		} else if revoked[claims.SessionID] {
+			return errors.WithMessagef(auth.ErrSessionRevoked, "user session %s", claims.SessionID)
+		}
+		return nil
+	}
+
+	// token returns an access token issued for the session.
+	token := func(t *testing.T, sessionID string) string {
+		claims := auth.NewClaims("user-id", "account-id", []string{"account-id"}, []string{auth.RoleUser}, auth.ClaimPreferences{}, now, time.Hour)
+		claims.SessionID = sessionID
+
+		tkn, err := authenticator.GenerateToken(claims)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tGenerateToken failed.", tests.Failed)
+		}
+		return tkn
+	}
+
+	var authTests = []struct {
+		name      string
+		sessionID string
+		status    int
+	}{
+		{"the session is active", "active-session", 0},
+		{"the token has no session", "", 0},
+		{"the session is revoked", "revoked-session", http.StatusUnauthorized},
+		{"the session can't be checked", "unavailable-session", http.StatusInternalServerError},
+	}
+
+	t.Log("Given the need to reject the access tokens of revoked sessions.")
+	{
+		for i, tt := range authTests {
+			t.Logf("\tTest: %d\tWhen %s.", i, tt.name)
+			{
+				var got auth.Claims
+				after := func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+					got, _ = auth.ClaimsFromContext(ctx)
+					return nil
+				}
+
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Authorization", "Bearer "+token(t, tt.sessionID))
+
+				err := AuthenticateHeader(authenticator)(after)(tests.Context(), httptest.NewRecorder(), r, nil)
+				webErr, isWebErr := errors.Cause(err).(*weberror.Error)
+				switch tt.status {
+				case 0:
+					if err != nil || got.SessionID != tt.sessionID {
+						t.Logf("\t\tGot : %+v", err)
+						t.Fatalf("\t%s\tAuthenticateHeader should accept the token.", tests.Failed)
+					}
+				case http.StatusUnauthorized:
+					if !isWebErr || webErr.Status != tt.status {
+						t.Logf("\t\tGot : %+v", err)
+						t.Logf("\t\tWant: %d", tt.status)
+						t.Fatalf("\t%s\tAuthenticateHeader should reject the token.", tests.Failed)
+					}
+				default:
+					if err == nil || isWebErr {
+						t.Logf("\t\tGot : %+v", err)
+						t.Fatalf("\t%s\tAuthenticateHeader should return the error of the check.", tests.Failed)
+					}
+				}
+				t.Logf("\t%s\tAuthenticateHeader ok.", tests.Success)
+
+				sess := sessions.NewSession(nil, "test")
+				webcontext.SessionUpdateAccessToken(sess, token(t, tt.sessionID))
+				ctx := webcontext.ContextWithSession(tests.Context(), sess)
+
+				got = auth.Claims{}
+				err = AuthenticateSessionOptional(authenticator)(after)(ctx, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), nil)
+				switch tt.status {
+				case 0:
+					if err != nil || !got.HasAuth() {
+						t.Logf("\t\tGot : %+v", err)
+						t.Fatalf("\t%s\tAuthenticateSessionOptional should accept the token.", tests.Failed)
+					}
+				case http.StatusUnauthorized:
+					if err != nil || got.HasAuth() {
+						t.Logf("\t\tGot : %+v", err)
+						t.Fatalf("\t%s\tAuthenticateSessionOptional should treat the request as unauthenticated.", tests.Failed)
+					}
+				default:
+					if err == nil {
+						t.Fatalf("\t%s\tAuthenticateSessionOptional should return the error of the check.", tests.Failed)
+					}
+				}
+				t.Logf("\t%s\tAuthenticateSessionOptional ok.", tests.Success)
+			}
+		}
+	}
+}
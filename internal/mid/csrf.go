@@ -0,0 +1,167 @@
+package mid
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"exitor-dapp/internal/platform/web"
+	"exitor-dapp/internal/platform/web/webcontext"
+	"exitor-dapp/internal/platform/web/weberror"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+type (
+	// CsrfConfig defines the config for Csrf middleware.
+	CsrfConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper
+
+		// FormField is the name of the form field the token is submitted with.
+		// Optional. Default value "csrf_token".
+		FormField string
+
+		// HeaderName is the name of the header the token is submitted with by scripts.
+		// Optional. Default value "X-CSRF-Token".
+		HeaderName string
+	}
+)
+
+// DefaultCsrfConfig is the default Csrf middleware config.
+var DefaultCsrfConfig = CsrfConfig{
+	Skipper:    DefaultSkipper,
+	FormField:  "csrf_token",
+	HeaderName: "X-CSRF-Token",
+}
+
+// ErrorCsrfToken is returned when a request that changes state is missing the CSRF token of
+// the session or the token does not match.
+func ErrorCsrfToken(ctx context.Context) error {
+	return weberror.NewError(ctx,
+		errors.New("invalid CSRF token, reload the page and try again"),
+		http.StatusForbidden,
+	)
+}
+
+// Csrf returns a middleware that protects against cross-site request forgery. A random token is
+// issued for each session and made available to templates as _CsrfToken. Requests with an unsafe
+// method must include the token as a form field or header and are rejected when there is no
+// session to verify it with. Requests authenticated with a bearer token in the Authorization
+// header are not sent by browsers automatically and are exempt.
+func Csrf(config CsrfConfig) web.Middleware {
+
+	if config.Skipper == nil {
+		config.Skipper = DefaultCsrfConfig.Skipper
+	}
+	if config.FormField == "" {
+		config.FormField = DefaultCsrfConfig.FormField
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = DefaultCsrfConfig.HeaderName
+	}
+
+	// This is the actual middleware function to be executed.
+	f := func(after web.Handler) web.Handler {
+
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+			span, ctx := tracer.StartSpanFromContext(ctx, "internal.mid.Csrf")
+			defer span.Finish()
+
+			if config.Skipper(ctx, w, r, params) {
+				return after(ctx, w, r, params)
+			}
+
+			// Requests with a bearer token don't rely on the session cookie.
+			if hasBearerToken(r) {
+				return after(ctx, w, r, params)
+			}
+
+			m := func() error {
+				// The token is stored in the session, the Session middleware must be applied
+				// first. Without a session the token can't be verified.
+				sess := webcontext.ContextSession(ctx)
+				if sess == nil {
+					if isSafeMethod(r.Method) {
+						return nil
+					}
+					return ErrorCsrfToken(ctx)
+				}
+
+				sessTkn, ok := webcontext.ContextCsrfToken(ctx)
+				if !ok {
+					tkn, err := newCsrfToken()
+					if err != nil {
+						return err
+					}
+
+					// The session is saved when the response is rendered.
+					webcontext.SessionUpdateCsrfToken(sess, tkn)
+					sessTkn = tkn
+				}
+
+				if isSafeMethod(r.Method) {
+					return nil
+				}
+
+				reqTkn := r.Header.Get(config.HeaderName)
+				if reqTkn == "" {
+					reqTkn = r.PostFormValue(config.FormField)
+				}
+
+				if !ok || subtle.ConstantTimeCompare([]byte(reqTkn), []byte(sessTkn)) != 1 {
+					return ErrorCsrfToken(ctx)
+				}
+
+				// Remove the token so handlers that decode the form strictly don't have to expect it.
+				r.PostForm.Del(config.FormField)
+				r.Form.Del(config.FormField)
+				if r.MultipartForm != nil {
+					delete(r.MultipartForm.Value, config.FormField)
+				}
+
+				return nil
+			}
+
+			if err := m(); err != nil {
+				if web.RequestIsJson(r) {
+					return web.RespondJsonError(ctx, w, err)
+				}
+				return err
+			}
+
+			return after(ctx, w, r, params)
+		}
+
+		return h
+	}
+
+	return f
+}
+
+// isSafeMethod returns whether the request method doesn't change state and is not checked.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// hasBearerToken returns whether the request includes a bearer token in the Authorization header.
+func hasBearerToken(r *http.Request) bool {
+	authHdr := strings.TrimSpace(r.Header.Get("Authorization"))
+	return len(authHdr) > 7 && strings.EqualFold(authHdr[:7], "bearer ")
+}
+
+// newCsrfToken generates a random token that is web safe.
+func newCsrfToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,126 @@
+package mid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"exitor-dapp/internal/platform/tests"
+	"exitor-dapp/internal/platform/web/webcontext"
+	"exitor-dapp/internal/platform/web/weberror"
+
+	"github.com/gorilla/sessions"
+	"github.com/pkg/errors"
+)
+
+// TestCsrf validates requests with an unsafe method are rejected unless they include the
+// token of the session.
+func TestCsrf(t *testing.T) {
+	const sessTkn = "session-csrf-token"
+
+	var csrfTests = []struct {
+		name       string
+		method     string
+		session    bool
+		headerTkn  string
+		formTkn    string
+		authHeader string
+		allowed    bool
+	}{
+		{"get without token", http.MethodGet, true, "", "", "", true},
+		{"head without token", http.MethodHead, true, "", "", "", true},
+		{"get without session", http.MethodGet, false, "", "", "", true},
+		{"post header token", http.MethodPost, true, sessTkn, "", "", true},
+		{"post form token", http.MethodPost, true, "", sessTkn, "", true},
+		{"post missing token", http.MethodPost, true, "", "", "", false},
+		{"post mismatched token", http.MethodPost, true, "other-token", "", "", false},
+		{"delete mismatched form token", http.MethodDelete, true, "", "other-token", "", false},
+		{"post without session", http.MethodPost, false, sessTkn, "", "", false},
+		{"post bearer token", http.MethodPost, true, "", "", "Bearer abc.def.ghi", true},
+		{"post bearer token without session", http.MethodPost, false, "", "", "Bearer abc.def.ghi", true},
+		{"post basic auth", http.MethodPost, true, "", "", "Basic YWJjOmRlZg==", false},
+	}
+
+	t.Log("Given the need to protect requests that change state against cross-site request forgery.")
+	{
+		for i, tt := range csrfTests {
+			t.Logf("\tTest: %d\tWhen %s.", i, tt.name)
+			{
+				form := url.Values{}
+				if tt.formTkn != "" {
+					form.Set(DefaultCsrfConfig.FormField, tt.formTkn)
+				}
+				form.Set("name", "Gabi")
+
+				r := httptest.NewRequest(tt.method, "/", strings.NewReader(form.Encode()))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				if tt.headerTkn != "" {
+					r.Header.Set(DefaultCsrfConfig.HeaderName, tt.headerTkn)
+				}
+				if tt.authHeader != "" {
+					r.Header.Set("Authorization", tt.authHeader)
+				}
+
+				ctx := tests.Context()
+				if tt.session {
+					sess := sessions.NewSession(nil, "test")
+					webcontext.SessionUpdateCsrfToken(sess, sessTkn)
+					ctx = webcontext.ContextWithSession(ctx, sess)
+				}
+
+				var called bool
+				after := func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+					called = true
+					if r.PostFormValue(DefaultCsrfConfig.FormField) != "" {
+						t.Fatalf("\t%s\tThe token should be removed from the form.", tests.Failed)
+					}
+					return nil
+				}
+
+				err := Csrf(DefaultCsrfConfig)(after)(ctx, httptest.NewRecorder(), r, nil)
+				if tt.allowed {
+					if err != nil || !called {
+						t.Logf("\t\tGot : %+v", err)
+						t.Fatalf("\t%s\tRequest should be allowed.", tests.Failed)
+					}
+				} else {
+					if called {
+						t.Fatalf("\t%s\tRequest should not reach the handler.", tests.Failed)
+					}
+
+					webErr, ok := errors.Cause(err).(*weberror.Error)
+					if !ok || webErr.Status != http.StatusForbidden {
+						t.Logf("\t\tGot : %+v", err)
+						t.Logf("\t\tWant: %d", http.StatusForbidden)
+						t.Fatalf("\t%s\tRequest should be forbidden.", tests.Failed)
+					}
+				}
+				t.Logf("\t%s\tCsrf ok.", tests.Success)
+			}
+		}
+	}
+
+	t.Log("Given the need to issue a token for sessions without one.")
+	{
+		sess := sessions.NewSession(nil, "test")
+		ctx := webcontext.ContextWithSession(tests.Context(), sess)
+
+		after := func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+			return nil
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if err := Csrf(DefaultCsrfConfig)(after)(ctx, httptest.NewRecorder(), r, nil); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCsrf failed.", tests.Failed)
+		}
+
+		if tkn, ok := webcontext.ContextCsrfToken(ctx); !ok || tkn == "" {
+			t.Fatalf("\t%s\tExpected a token to be stored in the session.", tests.Failed)
+		}
+		t.Logf("\t%s\tIssue token ok.", tests.Success)
+	}
+}
@@ -0,0 +1,93 @@
+package mid
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	"exitor-dapp/internal/platform/ratelimit"
+	"exitor-dapp/internal/platform/web"
+	"exitor-dapp/internal/platform/web/weberror"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+type (
+	// RateLimitConfig defines the config for RateLimit middleware.
+	RateLimitConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper
+
+		// Limiter counts the requests for each key.
+		Limiter *ratelimit.Limiter
+
+		// KeyFunc returns the key requests are counted by.
+		// Optional. Default value is the IP address of the client.
+		KeyFunc func(ctx context.Context, r *http.Request) string
+	}
+)
+
+// ErrorTooManyRequests is returned when the client has made too many requests and must wait
+// before trying again.
+func ErrorTooManyRequests(ctx context.Context) error {
+	return weberror.NewError(ctx,
+		errors.New("too many requests, try again later"),
+		http.StatusTooManyRequests,
+	)
+}
+
+// RateLimit returns a middleware that limits the number of requests a client can make to the
+// handler. Once the limit is reached, requests are rejected with 429 Too Many Requests and a
+// Retry-After header until the window of the limiter has elapsed.
+func RateLimit(config RateLimitConfig) web.Middleware {
+
+	if config.Skipper == nil {
+		config.Skipper = DefaultSkipper
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = func(ctx context.Context, r *http.Request) string {
+			return web.RequestRealIP(r)
+		}
+	}
+
+	// This is the actual middleware function to be executed.
+	f := func(after web.Handler) web.Handler {
+
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+			span, ctx := tracer.StartSpanFromContext(ctx, "internal.mid.RateLimit")
+			defer span.Finish()
+
+			if config.Skipper(ctx, w, r, params) {
+				return after(ctx, w, r, params)
+			}
+
+			m := func() error {
+				res, err := config.Limiter.Hit(ctx, config.KeyFunc(ctx, r))
+				if err != nil {
+					return err
+				}
+
+				if !res.Allowed {
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+					return ErrorTooManyRequests(ctx)
+				}
+
+				return nil
+			}
+
+			if err := m(); err != nil {
+				if web.RequestIsJson(r) {
+					return web.RespondJsonError(ctx, w, err)
+				}
+				return err
+			}
+
+			return after(ctx, w, r, params)
+		}
+
+		return h
+	}
+
+	return f
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"crypto/ed25519"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidAlgorandAddress occurs when an address is not 58 characters of base32 with a
+	// valid checksum.
+	ErrInvalidAlgorandAddress = errors.New("Invalid Algorand address")
+
+	// ErrInvalidAlgorandSignature occurs when a signature was not made by the private key of
+	// the address.
+	ErrInvalidAlgorandSignature = errors.New("Invalid Algorand signature")
+)
+
+// AlgorandPublicKey decodes the address of an Algorand account to the ed25519 public key
+// it encodes. The last 4 bytes of the address are the checksum of the public key.
+func AlgorandPublicKey(address string) (ed25519.PublicKey, error) {
+	addr, err := types.DecodeAddress(address)
+	if err != nil {
+		return nil, errors.WithMessagef(ErrInvalidAlgorandAddress, "%s: %s", address, err)
+	}
+
+	return ed25519.PublicKey(addr[:]), nil
+}
+
+// VerifyAlgorandSignature ensures the signature of the message was made with the private key
+// of the address. Messages are signed as arbitrary bytes, ie: with crypto.SignBytes of the
+// SDK, which prefixes the message with "MX" so the signature can never be replayed as a
+// transaction.
+func VerifyAlgorandSignature(address string, message, signature []byte) error {
+	pk, err := AlgorandPublicKey(address)
+	if err != nil {
+		return err
+	}
+
+	if len(signature) != ed25519.SignatureSize || !crypto.VerifyBytes(pk, message, signature) {
+		return errors.WithMessagef(ErrInvalidAlgorandSignature, "address %s", address)
+	}
+
+	return nil
+}
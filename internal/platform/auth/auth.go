@@ -1,14 +1,27 @@
 package auth
 
 import (
+	"context"
 	"crypto/rsa"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/pkg/errors"
 )
 
+// minReloadInterval is the minimum duration between reloads of the keys triggered by a
+// token signed with an unknown key id.
+const minReloadInterval = 10 * time.Second
+
+// ErrSessionRevoked occurs when a token was issued for a session that has been revoked.
+var ErrSessionRevoked = errors.New("Session has been revoked")
+
+// SessionChecker returns ErrSessionRevoked when the session the claims were issued for has
+// been revoked.
+type SessionChecker func(ctx context.Context, claims Claims) error
+
 // KeyFunc is used to map a JWT key id (kid) to the corresponding public key.
 // It is a requirement for creating an Authenticator.
 //
@@ -38,9 +51,18 @@ type Authenticator struct {
 	privateKey *PrivateKey
 	keyID      string
 	algorithm  string
+	keys       map[string]*PrivateKey
 	kf         KeyFunc
 	parser     *jwt.Parser
 	Storage    Storage
+
+	// SessionChecker is used to reject the tokens of revoked sessions before they expire.
+	SessionChecker SessionChecker
+
+	// mu guards the keys that are replaced when the storage is rotated.
+	mu sync.RWMutex
+	// lastRotated is the last time the keys were reloaded from the storage.
+	lastRotated time.Time
 }
 
 // PrivateKey is used to associate a private key with a keyID and algorithm.
@@ -57,10 +79,6 @@ type PrivateKey struct {
 // - No current private key exists.
 func NewAuthenticator(storage Storage, now time.Time) (*Authenticator, error) {
 
-	// Lookup function to be used by the middleware to validate the kid and
-	// Return the associated public key.
-	publicKeyLookup := NewKeyFunc(storage.Keys())
-
 	// Validate the globally defined encryption algorithm is valid.
 	if jwt.GetSigningMethod(algorithm) == nil {
 		return nil, errors.Errorf("unknown algorithm %v", algorithm)
@@ -79,25 +97,96 @@ func NewAuthenticator(storage Storage, now time.Time) (*Authenticator, error) {
 		return nil, errors.New("Missing private key")
 	}
 
-	a := Authenticator{
-		privateKey: curKey,
-		keyID:      curKey.keyID,
-		algorithm:  algorithm,
-		kf:         publicKeyLookup,
-		parser:     &parser,
+	a := &Authenticator{
+		algorithm: algorithm,
+		parser:    &parser,
+		Storage:   storage,
 	}
+	a.setKeys(curKey, storage.Keys(), now)
 
-	return &a, nil
+	return a, nil
+}
+
+// setKeys replaces the key used to sign new tokens and the keys used to verify them.
+func (a *Authenticator) setKeys(curKey *PrivateKey, keys map[string]*PrivateKey, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.privateKey = curKey
+	a.keyID = curKey.keyID
+	a.keys = keys
+
+	// Lookup function to be used by the middleware to validate the kid and
+	// Return the associated public key.
+	a.kf = NewKeyFunc(keys)
+
+	a.lastRotated = now
+}
+
+// Rotate generates a new private key when the current one has expired and reloads the keys
+// generated by other instances sharing the storage. Tokens signed with previous keys remain
+// valid for as long as the storage keeps them. Storage engines that don't implement
+// StorageRotator only load their keys on start.
+func (a *Authenticator) Rotate(ctx context.Context, now time.Time) error {
+	rs, ok := a.Storage.(StorageRotator)
+	if !ok {
+		return nil
+	}
+
+	if err := rs.Rotate(ctx, now); err != nil {
+		return err
+	}
+
+	curKey := rs.Current()
+	if curKey == nil {
+		return errors.New("Missing private key")
+	}
+	a.setKeys(curKey, rs.Keys(), now)
+
+	return nil
+}
+
+// publicKey returns the public key for the key id. When the key id is unknown the keys are
+// reloaded, another instance may have generated the key since the last rotation.
+func (a *Authenticator) publicKey(kid string) (*rsa.PublicKey, error) {
+	a.mu.RLock()
+	kf, lastRotated := a.kf, a.lastRotated
+	a.mu.RUnlock()
+
+	pk, err := kf(kid)
+	if err == nil {
+		return pk, nil
+	}
+
+	// Limit how often an unknown key id can trigger a reload from the storage.
+	now := time.Now().UTC()
+	if _, ok := a.Storage.(StorageRotator); !ok || now.Sub(lastRotated) < minReloadInterval {
+		return nil, err
+	}
+
+	if rerr := a.Rotate(context.Background(), now); rerr != nil {
+		return nil, errors.WithMessage(rerr, err.Error())
+	}
+
+	a.mu.RLock()
+	kf = a.kf
+	a.mu.RUnlock()
+
+	return kf(kid)
 }
 
 // GenerateToken generates a signed JWT token string representing the user Claims.
 func (a *Authenticator) GenerateToken(claims Claims) (string, error) {
 	method := jwt.GetSigningMethod(a.algorithm)
 
+	a.mu.RLock()
+	keyID, privateKey := a.keyID, a.privateKey
+	a.mu.RUnlock()
+
 	tkn := jwt.NewWithClaims(method, claims)
-	tkn.Header["kid"] = a.keyID
+	tkn.Header["kid"] = keyID
 
-	str, err := tkn.SignedString(a.privateKey.PrivateKey)
+	str, err := tkn.SignedString(privateKey.PrivateKey)
 	if err != nil {
 		return "", errors.Wrap(err, "signing token")
 	}
@@ -109,24 +198,40 @@ func (a *Authenticator) GenerateToken(claims Claims) (string, error) {
 // verifies that the token was signed using our key.
 func (a *Authenticator) ParseClaims(tknStr string) (Claims, error) {
 
-	// f is a function that returns the public key for validating a token. We use
-	// the parsed (but unverified) token to find the key id. That ID is passed to
-	// our KeyFunc to find the public key to use for verification.
-	f := func(t *jwt.Token) (interface{}, error) {
-		kid, ok := t.Header["kid"]
-		if !ok {
-			return nil, errors.New("Missing key id (kid) in token header")
-		}
-		kidStr, ok := kid.(string)
-		if !ok {
-			return nil, errors.New("Token key id (kid) must be string")
-		}
+	var claims Claims
+	tkn, err := a.parser.ParseWithClaims(tknStr, &claims, a.keyFunc)
+	if err != nil {
+		return Claims{}, errors.Wrap(err, "parsing token")
+	}
+
+	if !tkn.Valid {
+		return Claims{}, errors.New("Invalid token")
+	}
+
+	return claims, nil
+}
 
-		return a.kf(kidStr)
+// CheckSession returns ErrSessionRevoked when the session the claims were issued for has
+// been revoked. Claims without a session, like the ones of API clients, are not checked.
+func (a *Authenticator) CheckSession(ctx context.Context, claims Claims) error {
+	if a.SessionChecker == nil || claims.SessionID == "" {
+		return nil
+	}
+
+	return a.SessionChecker(ctx, claims)
+}
+
+// ParseExpiredClaims recreates the Claims that were used to generate a token that may have
+// expired. It verifies that the token was signed using our key but skips the validation of the
+// claims, they must only be used to issue a new token for the same session.
+func (a *Authenticator) ParseExpiredClaims(tknStr string) (Claims, error) {
+	parser := jwt.Parser{
+		ValidMethods:         []string{a.algorithm},
+		SkipClaimsValidation: true,
 	}
 
 	var claims Claims
-	tkn, err := a.parser.ParseWithClaims(tknStr, &claims, f)
+	tkn, err := parser.ParseWithClaims(tknStr, &claims, a.keyFunc)
 	if err != nil {
 		return Claims{}, errors.Wrap(err, "parsing token")
 	}
@@ -138,6 +243,21 @@ func (a *Authenticator) ParseClaims(tknStr string) (Claims, error) {
 	return claims, nil
 }
 
+// keyFunc returns the public key for validating a token. We use the parsed (but unverified)
+// token to find the key id. That ID is used to find the public key to use for verification.
+func (a *Authenticator) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, ok := t.Header["kid"]
+	if !ok {
+		return nil, errors.New("Missing key id (kid) in token header")
+	}
+	kidStr, ok := kid.(string)
+	if !ok {
+		return nil, errors.New("Token key id (kid) must be string")
+	}
+
+	return a.publicKey(kidStr)
+}
+
 // mockTokenGenerator is used for testing that Authenticate calls its provided
 // token generator in a specific way.
 type MockTokenGenerator struct {
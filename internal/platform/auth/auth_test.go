@@ -4,12 +4,18 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"exitor-dapp/internal/keystore"
 	"exitor-dapp/internal/platform/auth"
 	"exitor-dapp/internal/platform/tests"
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
 )
 
 var test *tests.Test
@@ -20,8 +26,6 @@ func TestMain(m *testing.M) {
 }
 
 func testMain(m *testing.M) int {
-	tests.DisableDb = true
-
 	test = tests.New()
 	defer test.TearDown()
 
@@ -163,3 +167,282 @@ func TestAuthenticatorAws(t *testing.T) {
 		}
 	}
 }
+
+// TestAuthenticatorDB validates instances sharing keys stored in the database, rotating them
+// and publishing them as a JSON Web Key Set.
+func TestAuthenticatorDB(t *testing.T) {
+
+	// Start in the past so the keys of the first instance can expire.
+	now := time.Now().UTC().Add(time.Hour * -4)
+	keyExpiration := time.Hour
+	tokenTTL := time.Hour * 2
+
+	signedClaims := auth.Claims{
+		Roles: []string{auth.RoleAdmin},
+	}
+
+	// kid returns the key id included in the header of the token.
+	kid := func(t *testing.T, tknStr string) string {
+		tkn, _, err := new(jwt.Parser).ParseUnverified(tknStr, &auth.Claims{})
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tParseUnverified failed.", tests.Failed)
+		}
+		return tkn.Header["kid"].(string)
+	}
+
+	masterKeys, err := keystore.NewMasterKeySecret("auth-test-secret")
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tNewMasterKeySecret failed.", tests.Failed)
+	}
+	encrypter := keystore.NewAuthKeyEncrypter(masterKeys)
+
+	t.Log("Given the need to share keys between instances using database storage.")
+	{
+		a1, err := auth.NewAuthenticatorDB(test.MasterDB, encrypter, now, keyExpiration, tokenTTL)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tNewAuthenticatorDB failed.", tests.Failed)
+		}
+
+		a2, err := auth.NewAuthenticatorDB(test.MasterDB, encrypter, now, keyExpiration, tokenTTL)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tNewAuthenticatorDB failed.", tests.Failed)
+		}
+
+		tkn1, err := a1.GenerateToken(signedClaims)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tGenerateToken failed.", tests.Failed)
+		}
+
+		tkn2, err := a2.GenerateToken(signedClaims)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tGenerateToken failed.", tests.Failed)
+		} else if kid(t, tkn1) != kid(t, tkn2) {
+			t.Fatalf("\t%s\tShould sign tokens with the same key.", tests.Failed)
+		}
+
+		if _, err := a2.ParseClaims(tkn1); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tParseClaims of a token from another instance failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tShared keys ok.", tests.Success)
+
+		// Rotate after the current key expired.
+		err = a1.Rotate(tests.Context(), now.Add(keyExpiration+time.Minute))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRotate failed.", tests.Failed)
+		}
+
+		tkn3, err := a1.GenerateToken(signedClaims)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tGenerateToken failed.", tests.Failed)
+		} else if kid(t, tkn3) == kid(t, tkn1) {
+			t.Fatalf("\t%s\tShould sign tokens with a new key after rotate.", tests.Failed)
+		}
+
+		if _, err := a1.ParseClaims(tkn1); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tParseClaims of a token signed before rotate failed.", tests.Failed)
+		}
+
+		// The other instance loads the new key when it doesn't recognize the key id.
+		if _, err := a2.ParseClaims(tkn3); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tParseClaims of a token signed with a key from another instance failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tRotate ok.", tests.Success)
+
+		jwks := a1.JWKS()
+
+		var found bool
+		for _, k := range jwks.Keys {
+			if k.Kid == kid(t, tkn1) {
+				found = true
+			}
+			if k.Kty != "RSA" || k.Alg != "RS256" || k.N == "" || k.E == "" {
+				t.Logf("\t\tGot : %+v", k)
+				t.Fatalf("\t%s\tShould include the public key.", tests.Failed)
+			}
+		}
+		if len(jwks.Keys) != 2 || !found {
+			t.Logf("\t\tGot : %+v", jwks)
+			t.Fatalf("\t%s\tShould include the current and previous keys.", tests.Failed)
+		}
+		t.Logf("\t%s\tJWKS ok.", tests.Success)
+
+		var stored string
+		err = test.MasterDB.QueryRowContext(tests.Context(), test.MasterDB.Rebind(
+			"select private_key from "+auth.AuthPrivateKeyTableName+" where id = ?"), kid(t, tkn3)).Scan(&stored)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSelect private key failed.", tests.Failed)
+		} else if stored == "" || strings.Contains(stored, "PRIVATE KEY") {
+			t.Logf("\t\tGot : %s", stored)
+			t.Fatalf("\t%s\tShould not store the private key as a PEM.", tests.Failed)
+		}
+
+		// An instance with other master keys can't load the keys.
+		otherKeys, err := keystore.NewMasterKeySecret("other-secret")
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tNewMasterKeySecret failed.", tests.Failed)
+		}
+		if _, err := auth.NewAuthenticatorDB(test.MasterDB, keystore.NewAuthKeyEncrypter(otherKeys), now, keyExpiration, tokenTTL); err == nil {
+			t.Fatalf("\t%s\tNewAuthenticatorDB should fail without the master key.", tests.Failed)
+		}
+		t.Logf("\t%s\tEncrypted keys ok.", tests.Success)
+	}
+}
+
+// TestVerifyAlgorandSignature validates the checksum of addresses and the signatures of
+// messages signed with the key of an address.
+func TestVerifyAlgorandSignature(t *testing.T) {
+	wallet := crypto.GenerateAccount()
+	addr := wallet.Address.String()
+
+	msg := []byte("Exitor wallet verification: nonce")
+	sig, err := crypto.SignBytes(wallet.PrivateKey, msg)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tSignBytes failed.", tests.Failed)
+	}
+
+	// Swapping the first character breaks the checksum of the address.
+	badChecksum := "A" + addr[1:]
+	if addr[0] == 'A' {
+		badChecksum = "B" + addr[1:]
+	}
+
+	var sigTests = []struct {
+		name    string
+		address string
+		message []byte
+		sig     []byte
+		err     error
+	}{
+		{"Valid", addr, msg, sig, nil},
+		{"ShortAddress", addr[:57], msg, sig, auth.ErrInvalidAlgorandAddress},
+		{"BadChecksum", badChecksum, msg, sig, auth.ErrInvalidAlgorandAddress},
+		{"OtherMessage", addr, []byte("Exitor wallet verification: other"), sig, auth.ErrInvalidAlgorandSignature},
+		{"OtherAddress", crypto.GenerateAccount().Address.String(), msg, sig, auth.ErrInvalidAlgorandSignature},
+		{"ShortSignature", addr, msg, sig[:32], auth.ErrInvalidAlgorandSignature},
+	}
+
+	t.Log("Given the need to verify the owner of an address signed a message.")
+	{
+		for i, tt := range sigTests {
+			t.Logf("\tTest: %d\tWhen verifying a signature for %s", i, tt.name)
+			{
+				err := auth.VerifyAlgorandSignature(tt.address, tt.message, tt.sig)
+				if errors.Cause(err) != tt.err {
+					t.Logf("\t\tGot : %+v", err)
+					t.Logf("\t\tWant: %+v", tt.err)
+					t.Fatalf("\t%s\tVerifyAlgorandSignature failed.", tests.Failed)
+				}
+				t.Logf("\t%s\tVerifyAlgorandSignature ok.", tests.Success)
+			}
+		}
+	}
+}
+
+// TestClaimsHasPermission validates the permissions granted by each role.
+func TestClaimsHasPermission(t *testing.T) {
+
+	var permTests = []struct {
+		roles      []string
+		permission string
+		want       bool
+	}{
+		{[]string{auth.RoleAdmin}, auth.PermissionAssetManage, true},
+		{[]string{auth.RoleAdmin}, auth.PermissionUserManage, true},
+		{[]string{auth.RoleUser}, auth.PermissionAssetRead, false},
+		{[]string{auth.RoleDrafter}, auth.PermissionAssetDraft, true},
+		{[]string{auth.RoleDrafter}, auth.PermissionAssetMint, false},
+		{[]string{auth.RoleApprover}, auth.PermissionAssetMint, true},
+		{[]string{auth.RoleApprover}, auth.PermissionAssetDraft, false},
+		{[]string{auth.RoleCompliance}, auth.PermissionAssetFreeze, true},
+		{[]string{auth.RoleCompliance}, auth.PermissionAssetTransfer, false},
+		{[]string{auth.RoleCompliance}, auth.PermissionUserKyc, true},
+		{[]string{auth.RoleApprover}, auth.PermissionUserKyc, false},
+		{[]string{auth.RoleAuditor}, auth.PermissionUserRead, true},
+		{[]string{auth.RoleAuditor}, auth.PermissionUserManage, false},
+		{[]string{auth.RoleDrafter, auth.RoleApprover}, auth.PermissionAssetMint, true},
+		{[]string{"unknown"}, auth.PermissionAssetRead, false},
+	}
+
+	t.Log("Given the need to check the permissions granted by the roles of claims.")
+	{
+		for i, tt := range permTests {
+			claims := auth.Claims{Roles: tt.roles}
+			if got := claims.HasPermission(tt.permission); got != tt.want {
+				t.Logf("\t\tGot : %v", got)
+				t.Logf("\t\tWant: %v", tt.want)
+				t.Fatalf("\t%s\tTest %d roles %v with permission %s failed.", tests.Failed, i, tt.roles, tt.permission)
+			}
+		}
+		t.Logf("\t%s\tHasPermission ok.", tests.Success)
+	}
+}
+
+// TestRolePermissionSets validates the permissions assigned to a role for an account replace
+// its defaults.
+func TestRolePermissionSets(t *testing.T) {
+	sets := auth.RolePermissionSets{
+		auth.RoleAuditor: {auth.PermissionAssetRead, auth.PermissionAssetMint},
+		auth.RoleUser:    {auth.PermissionUserRead},
+		auth.RoleAdmin:   {},
+	}
+
+	var permTests = []struct {
+		roles      []string
+		permission string
+		want       bool
+	}{
+		{[]string{auth.RoleAuditor}, auth.PermissionAssetMint, true},
+		{[]string{auth.RoleAuditor}, auth.PermissionAccountRead, false},
+		{[]string{auth.RoleUser}, auth.PermissionUserRead, true},
+		{[]string{auth.RoleDrafter}, auth.PermissionAssetDraft, true},
+		{[]string{auth.RoleAdmin}, auth.PermissionAccountManage, true},
+		{[]string{auth.RoleDrafter, auth.RoleAuditor}, auth.PermissionAssetMint, true},
+	}
+
+	t.Log("Given the need to grant the permissions assigned to the roles of an account.")
+	{
+		for i, tt := range permTests {
+			if got := sets.HasPermission(tt.roles, tt.permission); got != tt.want {
+				t.Logf("\t\tGot : %v", got)
+				t.Logf("\t\tWant: %v", tt.want)
+				t.Fatalf("\t%s\tTest %d roles %v with permission %s failed.", tests.Failed, i, tt.roles, tt.permission)
+			}
+		}
+		t.Logf("\t%s\tHasPermission ok.", tests.Success)
+
+		want := []string{auth.PermissionAssetRead, auth.PermissionAssetDraft, auth.PermissionAssetMint}
+		if got := sets.Permissions(auth.RoleAuditor, auth.RoleDrafter); !reflect.DeepEqual(got, want) {
+			t.Logf("\t\tGot : %v", got)
+			t.Logf("\t\tWant: %v", want)
+			t.Fatalf("\t%s\tPermissions failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tPermissions ok.", tests.Success)
+
+		// The claims are granted the permissions resolved when the token was issued.
+		claims := auth.Claims{Roles: []string{auth.RoleAuditor}, Permissions: sets.Permissions(auth.RoleAuditor)}
+		if !claims.HasPermission(auth.PermissionAssetMint) || claims.HasPermission(auth.PermissionAccountRead) {
+			t.Logf("\t\tGot : %v", claims.Permissions)
+			t.Fatalf("\t%s\tClaims should have the assigned permissions.", tests.Failed)
+		}
+
+		claims.Permissions = []string{}
+		if claims.HasPermission(auth.PermissionAssetRead) {
+			t.Fatalf("\t%s\tClaims with an empty set should have no permissions.", tests.Failed)
+		}
+		t.Logf("\t%s\tClaims HasPermission ok.", tests.Success)
+	}
+}
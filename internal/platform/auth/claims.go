@@ -27,7 +27,11 @@ type Claims struct {
 	RootAccountID string           `json:"root_account_id"`
 	AccountIDs    []string         `json:"accounts"`
 	Roles         []string         `json:"roles"`
+	Permissions   []string         `json:"perms"`
 	Preferences   ClaimPreferences `json:"prefs"`
+	ClientID      string           `json:"client_id,omitempty"`
+	SessionID     string           `json:"sid,omitempty"`
+	TwoFactor     bool             `json:"mfa,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -79,9 +83,7 @@ func NewClaimPreferences(timezone *time.Location, datetimeFormat, dateFormat, ti
 // Valid is called during the parsing of a token.
 func (c Claims) Valid() error {
 	for _, r := range c.Roles {
-		switch r {
-		case RoleAdmin, RoleUser: // Role is valid.
-		default:
+		if !IsValidRole(r) {
 			return fmt.Errorf("invalid role %q", r)
 		}
 	}
@@ -99,6 +101,11 @@ func (c Claims) HasAuth() bool {
 	return false
 }
 
+// IsClient returns true when the claims were issued to an api client instead of a user.
+func (c Claims) IsClient() bool {
+	return c.ClientID != ""
+}
+
 // HasRole returns true if the claims has at least one of the provided roles.
 func (c Claims) HasRole(roles ...string) bool {
 	for _, has := range c.Roles {
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sort"
+)
+
+// JSONWebKey is the public part of a key used to sign tokens as defined by RFC 7517.
+type JSONWebKey struct {
+	Kty string `json:"kty" example:"RSA"`
+	Use string `json:"use" example:"sig"`
+	Kid string `json:"kid" example:"c1e5a7b1-9d23-4f0e-8b6a-3f1d2c4e5a6b"`
+	Alg string `json:"alg" example:"RS256"`
+	N   string `json:"n"`
+	E   string `json:"e" example:"AQAB"`
+}
+
+// JSONWebKeySet is the set of public keys that can be used to verify the tokens issued by
+// the Authenticator.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys of all the private keys loaded from the storage, this includes
+// keys that are no longer used to sign tokens but still valid for verifying them.
+func (a *Authenticator) JWKS() JSONWebKeySet {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	set := JSONWebKeySet{
+		Keys: []JSONWebKey{},
+	}
+
+	for kid, key := range a.keys {
+		pub := key.Public().(*rsa.PublicKey)
+
+		set.Keys = append(set.Keys, JSONWebKey{
+			Kty: "RSA",
+			Use: "sig",
+			Kid: kid,
+			Alg: a.algorithm,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		})
+	}
+
+	// Sort the keys so the response is consistent between requests.
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+
+	return set
+}
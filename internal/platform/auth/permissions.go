@@ -0,0 +1,148 @@
+package auth
+
+// These are the expected values for the roles of Claims in addition to RoleAdmin and RoleUser.
+// Each role grants the permissions defined by RolePermissions.
+const (
+	RoleDrafter    = "drafter"
+	RoleApprover   = "approver"
+	RoleCompliance = "compliance"
+	RoleAuditor    = "auditor"
+)
+
+// These are the permissions granted by roles that are checked with Claims.HasPermission.
+const (
+	// PermissionAssetRead allows all the assets of the account and their transactions to be viewed.
+	PermissionAssetRead = "asset:read"
+
+	// PermissionAssetDraft allows assets to be created and updated before they are minted.
+	PermissionAssetDraft = "asset:draft"
+
+	// PermissionAssetMint allows the minting of draft assets to be approved and submitted.
+	PermissionAssetMint = "asset:mint"
+
+	// PermissionAssetTransfer allows minted assets to be distributed to the users of the account.
+	PermissionAssetTransfer = "asset:transfer"
+
+	// PermissionAssetFreeze allows minted assets to be frozen, unfrozen and clawed back for holders.
+	PermissionAssetFreeze = "asset:freeze"
+
+	// PermissionAssetManage allows minted assets to be reconfigured, destroyed and archived.
+	PermissionAssetManage = "asset:manage"
+
+	// PermissionUserRead allows all the users of the account to be viewed.
+	PermissionUserRead = "user:read"
+
+	// PermissionUserManage allows users to be created, invited, updated and archived for the account.
+	PermissionUserManage = "user:manage"
+
+	// PermissionUserKyc allows the KYC status of the users of the account to be approved, rejected and revoked.
+	PermissionUserKyc = "user:kyc"
+
+	// PermissionAccountRead allows the settings of the account to be viewed.
+	PermissionAccountRead = "account:read"
+
+	// PermissionAccountManage allows the settings and api clients of the account to be updated.
+	PermissionAccountManage = "account:manage"
+)
+
+// Permissions is the list of all the permissions that can be granted by roles.
+var Permissions = []string{
+	PermissionAssetRead,
+	PermissionAssetDraft,
+	PermissionAssetMint,
+	PermissionAssetTransfer,
+	PermissionAssetFreeze,
+	PermissionAssetManage,
+	PermissionUserRead,
+	PermissionUserManage,
+	PermissionUserKyc,
+	PermissionAccountRead,
+	PermissionAccountManage,
+}
+
+// RolePermissions defines the default set of permissions granted by each role. The role of admin
+// is granted every permission, the role of user only has access to the assets they hold. Admins
+// can replace the set of the other roles for their account, see RolePermissionSets.
+var RolePermissions = map[string][]string{
+	RoleAdmin: Permissions,
+	RoleUser:  {},
+	RoleDrafter: {
+		PermissionAssetRead,
+		PermissionAssetDraft,
+	},
+	RoleApprover: {
+		PermissionAssetRead,
+		PermissionAssetMint,
+		PermissionAssetTransfer,
+	},
+	RoleCompliance: {
+		PermissionAssetRead,
+		PermissionAssetFreeze,
+		PermissionUserRead,
+		PermissionUserKyc,
+	},
+	RoleAuditor: {
+		PermissionAssetRead,
+		PermissionUserRead,
+		PermissionAccountRead,
+	},
+}
+
+// IsValidRole returns true when the role is one of the expected values.
+func IsValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// RolePermissionSets defines the set of permissions assigned to roles for an account. The roles
+// without a set are granted their default permissions defined by RolePermissions.
+type RolePermissionSets map[string][]string
+
+// Permissions returns the permissions granted by any of the roles, in the order of Permissions.
+func (s RolePermissionSets) Permissions(roles ...string) []string {
+	granted := make(map[string]bool)
+	for _, r := range roles {
+		set, ok := s[r]
+		if !ok || r == RoleAdmin {
+			set = RolePermissions[r]
+		}
+		for _, p := range set {
+			granted[p] = true
+		}
+	}
+
+	perms := []string{}
+	for _, p := range Permissions {
+		if granted[p] {
+			perms = append(perms, p)
+		}
+	}
+	return perms
+}
+
+// HasPermission returns true if any of the roles grants at least one of the provided permissions.
+func (s RolePermissionSets) HasPermission(roles []string, permissions ...string) bool {
+	return hasAnyPermission(s.Permissions(roles...), permissions)
+}
+
+// HasPermission returns true if the claims are granted at least one of the provided permissions.
+// The permissions resolved for the account when the token was issued are used, claims without
+// them are granted the default permissions of their roles.
+func (c Claims) HasPermission(permissions ...string) bool {
+	if c.Permissions != nil {
+		return hasAnyPermission(c.Permissions, permissions)
+	}
+	return RolePermissionSets(nil).HasPermission(c.Roles, permissions...)
+}
+
+// hasAnyPermission returns true if at least one of the wanted permissions has been granted.
+func hasAnyPermission(granted, wanted []string) bool {
+	for _, has := range granted {
+		for _, want := range wanted {
+			if has == want {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DefaultRotateInterval is how often the keys are rotated when no interval is provided.
+const DefaultRotateInterval = time.Minute
+
+// KeyRotator rotates the keys of an Authenticator in the background so new keys are
+// generated before the current one expires and keys generated by other instances sharing
+// the storage are loaded.
+type KeyRotator struct {
+	Authenticator *Authenticator
+	Log           *log.Logger
+	Interval      time.Duration
+}
+
+// NewKeyRotator creates a new KeyRotator for the authenticator.
+func NewKeyRotator(a *Authenticator, log *log.Logger, interval time.Duration) *KeyRotator {
+	if interval <= 0 {
+		interval = DefaultRotateInterval
+	}
+
+	return &KeyRotator{
+		Authenticator: a,
+		Log:           log,
+		Interval:      interval,
+	}
+}
+
+// Run rotates the keys every interval until the context is cancelled.
+func (r *KeyRotator) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := r.Authenticator.Rotate(ctx, time.Now())
+			if err != nil && ctx.Err() == nil {
+				r.Log.Printf("auth : KeyRotator : %+v", err)
+			}
+		}
+	}
+}
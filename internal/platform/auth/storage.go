@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"context"
+
 	"github.com/dgrijalva/jwt-go"
 	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
@@ -15,6 +17,15 @@ type Storage interface {
 	Current() *PrivateKey
 }
 
+// StorageRotator is implemented by storage engines that can rotate keys while the service is
+// running. Instances sharing the storage load the keys generated by each other.
+type StorageRotator interface {
+	Storage
+	// Rotate generates a new current key when the current one has expired and reloads
+	// all the keys that can still be used to verify tokens.
+	Rotate(ctx context.Context, now time.Time) error
+}
+
 // StorageMemory is a storage engine that stores a single private key in memory.
 type StorageMemory struct {
 	privateKey *PrivateKey
@@ -0,0 +1,299 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	// The database table for the private keys of the authenticator.
+	AuthPrivateKeyTableName = "auth_private_keys"
+
+	// rotateLockID is the postgres advisory lock held while rotating keys so only one
+	// instance generates a new key.
+	rotateLockID = 7158430622
+)
+
+// KeyEncrypter encrypts the private keys persisted to the database so anyone that can
+// read the database can't use them to sign tokens.
+type KeyEncrypter interface {
+	// EncryptKey encrypts the PEM of the private key with the ID of the key.
+	EncryptKey(id string, pem []byte) ([]byte, error)
+	// DecryptKey decrypts the PEM of a private key encrypted by EncryptKey.
+	DecryptKey(id string, ciphertext []byte) ([]byte, error)
+}
+
+// StorageDB is a storage engine that persists private keys to the database so multiple
+// instances of the service share the same keys. The keys are encrypted before they are
+// stored.
+type StorageDB struct {
+	dbConn    *sqlx.DB
+	encrypter KeyEncrypter
+	// Duration for keys to be used to sign new tokens.
+	keyExpiration time.Duration
+	// Duration tokens are issued for, keys are kept until all the tokens signed
+	// with them have expired.
+	tokenTTL time.Duration
+
+	mu sync.RWMutex
+	// Map of keys by kid.
+	keys map[string]*PrivateKey
+	// The current active key to be used.
+	curPrivateKey *PrivateKey
+}
+
+// Keys returns a map of private keys by kID.
+func (s *StorageDB) Keys() map[string]*PrivateKey {
+	if s == nil {
+		return map[string]*PrivateKey{}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make(map[string]*PrivateKey, len(s.keys))
+	for kid, k := range s.keys {
+		keys[kid] = k
+	}
+	return keys
+}
+
+// Current returns the most recently generated private key.
+func (s *StorageDB) Current() *PrivateKey {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.curPrivateKey
+}
+
+// NewAuthenticatorDB is a help function that inits a new Authenticator
+// using the database storage.
+func NewAuthenticatorDB(dbConn *sqlx.DB, encrypter KeyEncrypter, now time.Time, keyExpiration, tokenTTL time.Duration) (*Authenticator, error) {
+	storage, err := NewStorageDB(dbConn, encrypter, now, keyExpiration, tokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAuthenticator(storage, now)
+}
+
+// NewStorageDB implements the interface Storage to support persisting private keys
+// to the database. The keys are loaded and rotated if needed on init.
+// It will error if:
+// - The database connection is nil.
+// - The key encrypter is nil.
+func NewStorageDB(dbConn *sqlx.DB, encrypter KeyEncrypter, now time.Time, keyExpiration, tokenTTL time.Duration) (*StorageDB, error) {
+	if dbConn == nil {
+		return nil, errors.New("database connection cannot be nil")
+	} else if encrypter == nil {
+		return nil, errors.New("key encrypter cannot be nil")
+	}
+
+	storage := &StorageDB{
+		dbConn:        dbConn,
+		encrypter:     encrypter,
+		keyExpiration: keyExpiration,
+		tokenTTL:      tokenTTL,
+		keys:          make(map[string]*PrivateKey),
+	}
+
+	if err := storage.Rotate(context.Background(), now); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// Rotate generates a new current key when the current one has expired and reloads all the
+// keys that can still be used to verify tokens. Keys are removed once all the tokens they
+// signed have expired.
+func (s *StorageDB) Rotate(ctx context.Context, now time.Time) error {
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+
+	// Time threshold to stop loading keys, any key with a created date
+	// before this value will not be loaded.
+	var disabledCreatedDate time.Time
+
+	// Time threshold to create a new key. If a current key exists and the
+	// created date of the key is before this value, a new key will be created.
+	var activeCreatedDate time.Time
+
+	// If an expiration duration is included, the key is used to sign tokens until it
+	// expires and then kept to verify them until the last token signed has expired.
+	if s.keyExpiration.Seconds() > 0 {
+		activeCreatedDate = now.Add(s.keyExpiration * -1)
+		disabledCreatedDate = activeCreatedDate.Add(s.tokenTTL * -1)
+	}
+
+	type storedKey struct {
+		ID         string
+		PrivateKey []byte
+		CreatedAt  time.Time
+	}
+
+	dbTx, err := s.dbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	// Block other instances from generating a key at the same time, the lock is released
+	// when the transaction ends.
+	if _, err := dbTx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", rotateLockID); err != nil {
+		return errors.Wrap(err, "acquire rotate lock failed")
+	}
+
+	if !disabledCreatedDate.IsZero() {
+		query := sqlbuilder.NewDeleteBuilder()
+		query.DeleteFrom(AuthPrivateKeyTableName)
+		query.Where(query.LessThan("created_at", disabledCreatedDate))
+
+		sql, args := query.Build()
+		sql = dbTx.Rebind(sql)
+		if _, err := dbTx.ExecContext(ctx, sql, args...); err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return errors.WithMessage(err, "delete expired private keys failed")
+		}
+	}
+
+	// Load the keys with the most recent first.
+	var keys []storedKey
+	{
+		query := sqlbuilder.NewSelectBuilder()
+		query.Select("id,private_key,created_at")
+		query.From(AuthPrivateKeyTableName)
+		query.OrderBy("created_at desc")
+
+		queryStr, args := query.Build()
+		queryStr = dbTx.Rebind(queryStr)
+		rows, err := dbTx.QueryContext(ctx, queryStr, args...)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return errors.WithMessage(err, "find private keys failed")
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var k storedKey
+			if err := rows.Scan(&k.ID, &k.PrivateKey, &k.CreatedAt); err != nil {
+				err = errors.Wrapf(err, "query - %s", query.String())
+				return err
+			}
+			keys = append(keys, k)
+		}
+
+		if err := rows.Err(); err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return errors.WithMessage(err, "find private keys failed")
+		}
+	}
+
+	// If there are no keys or the current key has expired, generate a new key.
+	if len(keys) == 0 || (!activeCreatedDate.IsZero() && keys[0].CreatedAt.Before(activeCreatedDate)) {
+		privateKey, err := KeyGen()
+		if err != nil {
+			return errors.Wrap(err, "failed to generate new private key")
+		}
+
+		k := storedKey{
+			ID:         uuid.NewRandom().String(),
+			PrivateKey: privateKey,
+			CreatedAt:  now.Truncate(time.Millisecond),
+		}
+
+		ciphertext, err := s.encrypter.EncryptKey(k.ID, k.PrivateKey)
+		if err != nil {
+			return errors.WithMessage(err, "encrypt private key failed")
+		}
+
+		query := sqlbuilder.NewInsertBuilder()
+		query.InsertInto(AuthPrivateKeyTableName)
+		query.Cols("id", "private_key", "created_at")
+		query.Values(k.ID, base64.StdEncoding.EncodeToString(ciphertext), k.CreatedAt)
+
+		sql, args := query.Build()
+		sql = dbTx.Rebind(sql)
+		if _, err := dbTx.ExecContext(ctx, sql, args...); err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return errors.WithMessage(err, "create private key failed")
+		}
+
+		keys = append([]storedKey{k}, keys...)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Loop through all the key bytes and load the private key.
+	loaded := make(map[string]*PrivateKey, len(keys))
+	for _, k := range keys {
+		// Keys that were already loaded don't need to be parsed again.
+		s.mu.RLock()
+		pk, ok := s.keys[k.ID]
+		s.mu.RUnlock()
+
+		if !ok {
+			pem, err := s.decrypt(k.ID, k.PrivateKey)
+			if err != nil {
+				return err
+			}
+
+			rk, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return errors.Wrap(err, "parsing auth private key")
+			}
+
+			pk = &PrivateKey{
+				PrivateKey: rk,
+				keyID:      k.ID,
+				algorithm:  algorithm,
+			}
+		}
+
+		loaded[k.ID] = pk
+	}
+
+	s.mu.Lock()
+	s.keys = loaded
+	s.curPrivateKey = loaded[keys[0].ID]
+	s.mu.Unlock()
+
+	return nil
+}
+
+// decrypt returns the PEM of a private key stored in the database. Keys stored before
+// they were encrypted are returned as is until they expire.
+func (s *StorageDB) decrypt(id string, stored []byte) ([]byte, error) {
+	if bytes.HasPrefix(stored, []byte("-----BEGIN")) {
+		return stored, nil
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(string(stored))
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding auth private key %s", id)
+	}
+
+	pem, err := s.encrypter.DecryptKey(id, ciphertext)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "decrypt auth private key %s failed", id)
+	}
+
+	return pem, nil
+}
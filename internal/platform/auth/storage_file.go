@@ -179,6 +179,7 @@ func NewStorageFile(localDir string, now time.Time, keyExpiration time.Duration)
 			return nil, errors.Wrapf(err, "failed write file %s", filePath)
 		}
 
+		curKeyId = kID
 		keyContents[curKeyId] = privateKey
 	}
 
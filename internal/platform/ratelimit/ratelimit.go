@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+	redistrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-redis/redis"
+)
+
+// Limiter counts hits for keys in Redis and allows up to Limit hits for a key within Window. The
+// window starts with the first hit for the key and the count is removed once it has elapsed.
+type Limiter struct {
+	Redis  *redistrace.Client
+	Prefix string
+	Limit  int
+	Window time.Duration
+}
+
+// Result describes the hits counted for a key within the current window.
+type Result struct {
+	Count      int
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+// New creates a Limiter that stores its counts in Redis with keys that start with the prefix.
+func New(redisClient *redistrace.Client, prefix string, limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		Redis:  redisClient,
+		Prefix: prefix,
+		Limit:  limit,
+		Window: window,
+	}
+}
+
+// redisKey returns the Redis key the hits of the key are counted with.
+func (l *Limiter) redisKey(key string) string {
+	return l.Prefix + ":" + key
+}
+
+// result returns the Result for the count of a key that expires after ttl.
+func (l *Limiter) result(count int64, ttl time.Duration) Result {
+	res := Result{
+		Count:   int(count),
+		Allowed: int(count) <= l.Limit,
+	}
+	if !res.Allowed {
+		res.RetryAfter = ttl
+	}
+	return res
+}
+
+// Hit counts a hit for the key and returns whether it is within the limit.
+func (l *Limiter) Hit(ctx context.Context, key string) (Result, error) {
+	rk := l.redisKey(key)
+	client := l.Redis.WithContext(ctx)
+
+	var (
+		incr *redis.IntCmd
+		pttl *redis.DurationCmd
+	)
+	_, err := client.TxPipelined(func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(rk)
+		pttl = pipe.PTTL(rk)
+		return nil
+	})
+	if err != nil {
+		return Result{}, errors.Wrapf(err, "redis incr %s", rk)
+	}
+
+	// Start the window with the first hit. A negative TTL is returned when the key has no expiry.
+	ttl := pttl.Val()
+	if ttl < 0 {
+		ttl = l.Window
+		if err := client.PExpire(rk, ttl).Err(); err != nil {
+			return Result{}, errors.Wrapf(err, "redis pexpire %s", rk)
+		}
+	}
+
+	return l.result(incr.Val(), ttl), nil
+}
+
+// Peek returns the hits counted for the key without counting another one.
+func (l *Limiter) Peek(ctx context.Context, key string) (Result, error) {
+	rk := l.redisKey(key)
+
+	var (
+		get  *redis.StringCmd
+		pttl *redis.DurationCmd
+	)
+	_, err := l.Redis.WithContext(ctx).TxPipelined(func(pipe redis.Pipeliner) error {
+		get = pipe.Get(rk)
+		pttl = pipe.PTTL(rk)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return Result{}, errors.Wrapf(err, "redis get %s", rk)
+	}
+
+	count, err := get.Int64()
+	if err != nil {
+		if err == redis.Nil {
+			return l.result(0, 0), nil
+		}
+		return Result{}, errors.Wrapf(err, "redis get %s", rk)
+	}
+
+	return l.result(count, pttl.Val()), nil
+}
+
+// Release removes a hit counted for the key by Hit, the count is removed once it reaches zero.
+func (l *Limiter) Release(ctx context.Context, key string) error {
+	rk := l.redisKey(key)
+	client := l.Redis.WithContext(ctx)
+
+	count, err := client.Decr(rk).Result()
+	if err != nil {
+		return errors.Wrapf(err, "redis decr %s", rk)
+	}
+
+	// The count expired after the hit, don't leave a negative count without an expiry.
+	if count <= 0 {
+		if err := client.Del(rk).Err(); err != nil {
+			return errors.Wrapf(err, "redis del %s", rk)
+		}
+	}
+
+	return nil
+}
+
+// Reset removes the hits counted for the key.
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	rk := l.redisKey(key)
+
+	if err := l.Redis.WithContext(ctx).Del(rk).Err(); err != nil {
+		return errors.Wrapf(err, "redis del %s", rk)
+	}
+
+	return nil
+}
@@ -0,0 +1,355 @@
+// Package redistest provides an in-process fake of the Redis server so code depending
+// on Redis can be tested without a running Redis instance. Only the commands used by
+// this project are supported: GET, SET, SETNX, DEL, INCR, DECR, PTTL, PEXPIRE and MULTI/EXEC.
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	redistrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-redis/redis"
+)
+
+// entry is a value stored by the fake server, a zero expiresAt never expires.
+type entry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// Server is a fake Redis server. Keys expire against the clock of the server which can
+// be moved forward with FastForward.
+type Server struct {
+	listener net.Listener
+
+	mtx    sync.Mutex
+	data   map[string]entry
+	offset time.Duration
+	conns  map[net.Conn]bool
+	wg     sync.WaitGroup
+}
+
+// NewServer starts a fake Redis server listening on a random local port. The caller
+// should call Close when finished to shut it down.
+func NewServer() *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("redistest: failed to listen on a port: %v", err))
+	}
+
+	s := &Server{
+		listener: l,
+		data:     make(map[string]entry),
+		conns:    make(map[net.Conn]bool),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Client returns a new client connected to the server.
+func (s *Server) Client() *redistrace.Client {
+	return redistrace.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	})
+}
+
+// FastForward moves the clock of the server forward so keys expire without waiting.
+func (s *Server) FastForward(d time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.offset += d
+}
+
+// Close shuts down the server and closes all the client connections.
+func (s *Server) Close() {
+	s.listener.Close()
+
+	s.mtx.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mtx.Unlock()
+
+	s.wg.Wait()
+}
+
+// serve accepts connections until the listener is closed.
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mtx.Lock()
+		s.conns[c] = true
+		s.mtx.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(c)
+	}
+}
+
+// handle executes the commands sent on the connection until it is closed.
+func (s *Server) handle(c net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mtx.Lock()
+		delete(s.conns, c)
+		s.mtx.Unlock()
+		c.Close()
+	}()
+
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+
+	// Commands queued after MULTI, nil when not in a transaction.
+	var queued [][]string
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		switch strings.ToUpper(args[0]) {
+		case "MULTI":
+			queued = [][]string{}
+			w.WriteString("+OK\r\n")
+		case "EXEC":
+			if queued == nil {
+				w.WriteString("-ERR EXEC without MULTI\r\n")
+				break
+			}
+
+			// Hold the lock so the queued commands are executed atomically.
+			s.mtx.Lock()
+			fmt.Fprintf(w, "*%d\r\n", len(queued))
+			for _, q := range queued {
+				w.WriteString(s.exec(q))
+			}
+			s.mtx.Unlock()
+			queued = nil
+		default:
+			if queued != nil {
+				queued = append(queued, args)
+				w.WriteString("+QUEUED\r\n")
+				break
+			}
+
+			s.mtx.Lock()
+			w.WriteString(s.exec(args))
+			s.mtx.Unlock()
+		}
+
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	} else if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected line %q", line)
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid array length %q", line)
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		} else if !strings.HasPrefix(line, "$") {
+			return nil, fmt.Errorf("unexpected line %q", line)
+		}
+
+		l, err := strconv.Atoi(line[1:])
+		if err != nil || l < 0 {
+			return nil, fmt.Errorf("invalid bulk length %q", line)
+		}
+
+		buf := make([]byte, l+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:l])
+	}
+
+	return args, nil
+}
+
+// readLine reads a line without the trailing CRLF.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// now returns the time of the server clock.
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// get returns the entry for the key when it exists and has not expired. Must be called
+// with the lock held.
+func (s *Server) get(key string) (entry, bool) {
+	e, ok := s.data[key]
+	if !ok {
+		return entry{}, false
+	}
+
+	if !e.expiresAt.IsZero() && !s.now().Before(e.expiresAt) {
+		delete(s.data, key)
+		return entry{}, false
+	}
+
+	return e, true
+}
+
+// exec executes the command and returns the encoded reply. Must be called with the
+// lock held.
+func (s *Server) exec(args []string) string {
+	cmd := strings.ToUpper(args[0])
+
+	switch {
+	case cmd == "PING":
+		return "+PONG\r\n"
+
+	case cmd == "GET" && len(args) == 2:
+		e, ok := s.get(args[1])
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(e.value)
+
+	case cmd == "SET" && len(args) >= 3:
+		var (
+			ttl time.Duration
+			nx  bool
+		)
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "EX", "PX":
+				if i+1 >= len(args) {
+					return "-ERR syntax error\r\n"
+				}
+				v, err := strconv.ParseInt(args[i+1], 10, 64)
+				if err != nil || v <= 0 {
+					return "-ERR invalid expire time in set\r\n"
+				}
+				if strings.ToUpper(args[i]) == "EX" {
+					ttl = time.Duration(v) * time.Second
+				} else {
+					ttl = time.Duration(v) * time.Millisecond
+				}
+				i++
+			default:
+				return "-ERR syntax error\r\n"
+			}
+		}
+
+		if _, ok := s.get(args[1]); ok && nx {
+			return "$-1\r\n"
+		}
+
+		e := entry{value: args[2]}
+		if ttl > 0 {
+			e.expiresAt = s.now().Add(ttl)
+		}
+		s.data[args[1]] = e
+		return "+OK\r\n"
+
+	case cmd == "SETNX" && len(args) == 3:
+		if _, ok := s.get(args[1]); ok {
+			return ":0\r\n"
+		}
+		s.data[args[1]] = entry{value: args[2]}
+		return ":1\r\n"
+
+	case cmd == "DEL" && len(args) >= 2:
+		var n int
+		for _, k := range args[1:] {
+			if _, ok := s.get(k); ok {
+				delete(s.data, k)
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+
+	case (cmd == "INCR" || cmd == "DECR") && len(args) == 2:
+		e, _ := s.get(args[1])
+		var v int64
+		if e.value != "" {
+			var err error
+			v, err = strconv.ParseInt(e.value, 10, 64)
+			if err != nil {
+				return "-ERR value is not an integer or out of range\r\n"
+			}
+		}
+		if cmd == "INCR" {
+			v++
+		} else {
+			v--
+		}
+		e.value = strconv.FormatInt(v, 10)
+		s.data[args[1]] = e
+		return fmt.Sprintf(":%d\r\n", v)
+
+	case cmd == "PTTL" && len(args) == 2:
+		e, ok := s.get(args[1])
+		if !ok {
+			return ":-2\r\n"
+		} else if e.expiresAt.IsZero() {
+			return ":-1\r\n"
+		}
+		return fmt.Sprintf(":%d\r\n", e.expiresAt.Sub(s.now())/time.Millisecond)
+
+	case cmd == "PEXPIRE" && len(args) == 3:
+		ms, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		e, ok := s.get(args[1])
+		if !ok {
+			return ":0\r\n"
+		}
+		e.expiresAt = s.now().Add(time.Duration(ms) * time.Millisecond)
+		s.data[args[1]] = e
+		return ":1\r\n"
+	}
+
+	return fmt.Sprintf("-ERR unsupported command '%s'\r\n", args[0])
+}
+
+// bulk encodes the value as a bulk string reply.
+func bulk(v string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+}
@@ -125,6 +125,13 @@ func NewTemplate(templateFuncs template.FuncMap) *Template {
 			}
 			return claims.HasRole(roles...)
 		},
+		"HasPermission": func(ctx context.Context, permissions ...string) bool {
+			claims, err := auth.ClaimsFromContext(ctx)
+			if err != nil {
+				return false
+			}
+			return claims.HasPermission(permissions...)
+		},
 
 		"CmpString": func(str1 string, str2Ptr *string) bool {
 			var str2 string
@@ -372,6 +379,11 @@ func (r *TemplateRenderer) Render(ctx context.Context, w http.ResponseWriter, re
 	// to define context.Context as an argument
 	renderData["_Ctx"] = ctx
 
+	// Add the CSRF token of the session so forms can include it when they are submitted.
+	if csrfToken, ok := webcontext.ContextCsrfToken(ctx); ok {
+		renderData["_CsrfToken"] = csrfToken
+	}
+
 	if qv := req.URL.Query().Get("test-validation-error"); qv != "" {
 		data["validationErrors"] = data["validationDefaults"]
 	}
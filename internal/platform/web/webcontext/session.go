@@ -16,6 +16,10 @@ const KeySession ctxKeySession = 1
 // Session keys used to store values.
 const (
 	SessionKeyAccessToken = iota
+	SessionKeyRefreshToken
+	SessionKeyTwoFactorChallenge
+	SessionKeyTwoFactorRememberMe
+	SessionKeyCsrfToken
 )
 
 // KeySessionID is the key used to store the ID of the session in its values.
@@ -50,6 +54,45 @@ func ContextAccessToken(ctx context.Context) (string, bool) {
 	return "", false
 }
 
+// ContextRefreshToken returns the refresh token from the context session.
+func ContextRefreshToken(ctx context.Context) (string, bool) {
+	sess := ContextSession(ctx)
+	if sess == nil {
+		return "", false
+	}
+	if sv, ok := sess.Values[SessionKeyRefreshToken].(string); ok {
+		return sv, true
+	}
+	return "", false
+}
+
+// ContextTwoFactorChallenge returns the ID of the two-factor authentication challenge pending for
+// the sign in and whether the user asked to be remembered from the context session.
+func ContextTwoFactorChallenge(ctx context.Context) (string, bool, bool) {
+	sess := ContextSession(ctx)
+	if sess == nil {
+		return "", false, false
+	}
+	sv, ok := sess.Values[SessionKeyTwoFactorChallenge].(string)
+	if !ok || sv == "" {
+		return "", false, false
+	}
+	rememberMe, _ := sess.Values[SessionKeyTwoFactorRememberMe].(bool)
+	return sv, rememberMe, true
+}
+
+// ContextCsrfToken returns the token forms must include to be submitted from the context session.
+func ContextCsrfToken(ctx context.Context) (string, bool) {
+	sess := ContextSession(ctx)
+	if sess == nil {
+		return "", false
+	}
+	if sv, ok := sess.Values[SessionKeyCsrfToken].(string); ok && sv != "" {
+		return sv, true
+	}
+	return "", false
+}
+
 // SessionInit creates a new session with a valid JWT access token.
 func SessionInit(session *sessions.Session, accessToken string) *sessions.Session {
 
@@ -71,10 +114,41 @@ func SessionUpdateAccessToken(session *sessions.Session, accessToken string) *se
 	return session
 }
 
+// SessionUpdateRefreshToken updates the refresh token stored in the session.
+func SessionUpdateRefreshToken(session *sessions.Session, refreshToken string) *sessions.Session {
+	session.Values[SessionKeyRefreshToken] = refreshToken
+	return session
+}
+
+// SessionUpdateTwoFactorChallenge stores the two-factor authentication challenge pending for the
+// sign in in the session.
+func SessionUpdateTwoFactorChallenge(session *sessions.Session, challengeID string, rememberMe bool) *sessions.Session {
+	session.Values[SessionKeyTwoFactorChallenge] = challengeID
+	session.Values[SessionKeyTwoFactorRememberMe] = rememberMe
+	return session
+}
+
+// SessionClearTwoFactorChallenge removes the two-factor authentication challenge from the session.
+func SessionClearTwoFactorChallenge(session *sessions.Session) *sessions.Session {
+	delete(session.Values, SessionKeyTwoFactorChallenge)
+	delete(session.Values, SessionKeyTwoFactorRememberMe)
+	return session
+}
+
+// SessionUpdateCsrfToken updates the token forms must include to be submitted stored in the session.
+func SessionUpdateCsrfToken(session *sessions.Session, csrfToken string) *sessions.Session {
+	session.Values[SessionKeyCsrfToken] = csrfToken
+	return session
+}
+
 // SessionDestroy removes the access token from the session which revokes authentication for the user.
 func SessionDestroy(session *sessions.Session) *sessions.Session {
 
 	delete(session.Values, SessionKeyAccessToken)
+	delete(session.Values, SessionKeyRefreshToken)
+	delete(session.Values, SessionKeyTwoFactorChallenge)
+	delete(session.Values, SessionKeyTwoFactorRememberMe)
+	delete(session.Values, SessionKeyCsrfToken)
 
 	return session
 }
@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/algorand/go-algorand-sdk/types"
 	"github.com/go-playground/locales/en"
 	"github.com/go-playground/locales/fr"
 	"github.com/go-playground/locales/id"
@@ -128,6 +129,14 @@ func init() {
 		return t
 	})
 
+	validate.RegisterTranslation("algorand_address", transEn, func(ut ut.Translator) error {
+		return ut.Add("algorand_address", "{0} must be a valid Algorand address", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("algorand_address", fe.Field())
+
+		return t
+	})
+
 }
 
 // ctxKeyTagUnique represents the type of unique value for the context key used by the validation function.
@@ -183,6 +192,13 @@ func newValidator() *validator.Validate {
 	}
 	v.RegisterValidationCtx("unique", fctx)
 
+	// Custom Validation function for the algorand_address tag that ensures the value is the 58 character
+	// base32 encoding of a public key followed by its checksum.
+	v.RegisterValidation("algorand_address", func(fl validator.FieldLevel) bool {
+		_, err := types.DecodeAddress(fl.Field().String())
+		return err == nil
+	})
+
 	return v
 }
 
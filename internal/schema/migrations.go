@@ -677,7 +677,873 @@ func migrationList(ctx context.Context, db *sqlx.DB, log *log.Logger, isUnittest
 				return nil
 			},
 		},
+		// Create new table created_assets.
+		{
+			ID: "20200125-01",
+			Migrate: func(tx *sql.Tx) error {
+				if err := createTypeIfNotExists(tx, "created_asset_status_t", "enum('active','disabled')"); err != nil {
+					return err
+				}
+
+				q1 := `CREATE TABLE IF NOT EXISTS created_assets (
+					  id char(36) NOT NULL,
+					  account_id char(36) NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+					  network varchar(20) NOT NULL,
+					  asset_index bigint NOT NULL DEFAULT 0,
+					  unit_name varchar(8) NOT NULL DEFAULT '',
+					  asset_name varchar(32) NOT NULL,
+					  total bigint NOT NULL,
+					  decimals smallint NOT NULL DEFAULT 0,
+					  default_frozen boolean NOT NULL DEFAULT false,
+					  url varchar(32) NOT NULL DEFAULT '',
+					  metadata_hash bytea DEFAULT NULL,
+					  creator_address char(58) NOT NULL,
+					  manager_address varchar(58) NOT NULL DEFAULT '',
+					  reserve_address varchar(58) NOT NULL DEFAULT '',
+					  freeze_address varchar(58) NOT NULL DEFAULT '',
+					  clawback_address varchar(58) NOT NULL DEFAULT '',
+					  tx_id varchar(52) NOT NULL DEFAULT '',
+					  confirmed_round bigint NOT NULL DEFAULT 0,
+					  status created_asset_status_t NOT NULL DEFAULT 'active',
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  archived_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  PRIMARY KEY (id)
+					)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				// An asset index is only unique within a network and is not known until the
+				// create transaction has been confirmed.
+				q2 := `CREATE UNIQUE INDEX IF NOT EXISTS idx_created_assets_network_asset_index
+					ON created_assets (network, asset_index) WHERE asset_index > 0`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DROP TABLE IF EXISTS created_assets`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				if err := dropTypeIfExists(tx, "created_asset_status_t"); err != nil {
+					return err
+				}
+
+				return nil
+			},
+		},
+		// Track the minting of created assets on Algorand.
+		{
+			ID: "20200201-01",
+			Migrate: func(tx *sql.Tx) error {
+				if err := createTypeIfNotExists(tx, "created_asset_mint_status_t", "enum('draft','submitted','confirmed','failed')"); err != nil {
+					return err
+				}
+
+				q1 := `ALTER TABLE created_assets
+					ADD COLUMN IF NOT EXISTS mint_status created_asset_mint_status_t NOT NULL DEFAULT 'draft',
+					ADD COLUMN IF NOT EXISTS mint_error varchar(500) NOT NULL DEFAULT '',
+					ADD COLUMN IF NOT EXISTS last_valid_round bigint NOT NULL DEFAULT 0`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				// Assets already confirmed on chain before minting was tracked.
+				q2 := `UPDATE created_assets SET mint_status = 'confirmed' WHERE asset_index > 0`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `ALTER TABLE created_assets
+					DROP COLUMN IF EXISTS mint_status,
+					DROP COLUMN IF EXISTS mint_error,
+					DROP COLUMN IF EXISTS last_valid_round`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				if err := dropTypeIfExists(tx, "created_asset_mint_status_t"); err != nil {
+					return err
+				}
+
+				return nil
+			},
+		},
+		// Store the unsigned asset create transaction issued for offline signing.
+		{
+			ID: "20200208-01",
+			Migrate: func(tx *sql.Tx) error {
+				q1 := `ALTER TABLE created_assets ADD COLUMN IF NOT EXISTS unsigned_txn bytea NULL`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `ALTER TABLE created_assets DROP COLUMN IF EXISTS unsigned_txn`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				return nil
+			},
+		},
+		// Create new table custodial_accounts for the encrypted keys of Exitor owned accounts.
+		{
+			ID: "20200215-01",
+			Migrate: func(tx *sql.Tx) error {
+				q1 := `CREATE TABLE IF NOT EXISTS custodial_accounts (
+					  id char(36) NOT NULL,
+					  address char(58) NOT NULL,
+					  label varchar(200) NOT NULL,
+					  master_key_id varchar(100) NOT NULL,
+					  nonce bytea NOT NULL,
+					  ciphertext bytea NOT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  archived_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  PRIMARY KEY (id),
+					  CONSTRAINT custodial_accounts_address UNIQUE (address)
+					)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DROP TABLE IF EXISTS custodial_accounts`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				return nil
+			},
+		},
+		// Create new table created_asset_txns for managing created assets after minting.
+		{
+			ID: "20200222-01",
+			Migrate: func(tx *sql.Tx) error {
+				if err := createTypeIfNotExists(tx, "created_asset_txn_type_t", "enum('config','freeze','clawback','destroy')"); err != nil {
+					return err
+				}
+
+				if err := createTypeIfNotExists(tx, "created_asset_txn_status_t", "enum('draft','submitted','confirmed','failed')"); err != nil {
+					return err
+				}
+
+				q1 := `CREATE TABLE IF NOT EXISTS created_asset_txns (
+					  id char(36) NOT NULL,
+					  created_asset_id char(36) NOT NULL REFERENCES created_assets(id) ON DELETE CASCADE,
+					  account_id char(36) NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+					  type created_asset_txn_type_t NOT NULL,
+					  sender_address char(58) NOT NULL,
+					  target_address varchar(58) NOT NULL DEFAULT '',
+					  receiver_address varchar(58) NOT NULL DEFAULT '',
+					  amount bigint NOT NULL DEFAULT 0,
+					  frozen boolean NOT NULL DEFAULT false,
+					  manager_address varchar(58) NOT NULL DEFAULT '',
+					  reserve_address varchar(58) NOT NULL DEFAULT '',
+					  freeze_address varchar(58) NOT NULL DEFAULT '',
+					  clawback_address varchar(58) NOT NULL DEFAULT '',
+					  status created_asset_txn_status_t NOT NULL DEFAULT 'draft',
+					  error varchar(500) NOT NULL DEFAULT '',
+					  tx_id varchar(52) NOT NULL,
+					  last_valid_round bigint NOT NULL DEFAULT 0,
+					  confirmed_round bigint NOT NULL DEFAULT 0,
+					  unsigned_txn bytea NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  PRIMARY KEY (id)
+					)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `CREATE INDEX IF NOT EXISTS idx_created_asset_txns_created_asset_id ON created_asset_txns (created_asset_id)`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				q3 := `ALTER TABLE created_assets ADD COLUMN IF NOT EXISTS destroyed_at TIMESTAMP WITH TIME ZONE DEFAULT NULL`
+				if _, err := tx.Exec(q3); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q3)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DROP TABLE IF EXISTS created_asset_txns`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `ALTER TABLE created_assets DROP COLUMN IF EXISTS destroyed_at`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				if err := dropTypeIfExists(tx, "created_asset_txn_type_t"); err != nil {
+					return err
+				}
+
+				if err := dropTypeIfExists(tx, "created_asset_txn_status_t"); err != nil {
+					return err
+				}
+
+				return nil
+			},
+		},
+		// Track opt-in and transfer transactions against the receiving user.
+		{
+			ID: "20200229-01",
+			Migrate: func(tx *sql.Tx) error {
+				// ALTER TYPE ... ADD VALUE can't run inside the transaction of the migration,
+				// the type is recreated with the new values instead.
+				if err := replaceTxnType(tx, "enum('config','freeze','clawback','destroy','opt_in','transfer')"); err != nil {
+					return err
+				}
+
+				q1 := `ALTER TABLE created_asset_txns ADD COLUMN IF NOT EXISTS user_id char(36) NULL REFERENCES users(id) ON DELETE SET NULL`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `CREATE INDEX IF NOT EXISTS idx_created_asset_txns_user_id ON created_asset_txns (created_asset_id, user_id)`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DELETE FROM created_asset_txns WHERE type IN ('opt_in', 'transfer')`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `ALTER TABLE created_asset_txns DROP COLUMN IF EXISTS user_id`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return replaceTxnType(tx, "enum('config','freeze','clawback','destroy')")
+			},
+		},
+		// Create new table created_asset_holders for the snapshot of the holders from the indexer.
+		{
+			ID: "20200307-01",
+			Migrate: func(tx *sql.Tx) error {
+				q1 := `CREATE TABLE IF NOT EXISTS created_asset_holders (
+					  id char(36) NOT NULL,
+					  created_asset_id char(36) NOT NULL REFERENCES created_assets(id) ON DELETE CASCADE,
+					  account_id char(36) NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+					  address char(58) NOT NULL,
+					  amount bigint NOT NULL DEFAULT 0,
+					  frozen boolean NOT NULL DEFAULT false,
+					  opted_in_round bigint NOT NULL DEFAULT 0,
+					  round bigint NOT NULL DEFAULT 0,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  PRIMARY KEY (id),
+					  CONSTRAINT created_asset_holders_address UNIQUE (created_asset_id, address)
+					)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DROP TABLE IF EXISTS created_asset_holders`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				return nil
+			},
+		},
+		// Create new table wallet_addresses for linking Algorand addresses to accounts and users.
+		{
+			ID: "20200314-01",
+			Migrate: func(tx *sql.Tx) error {
+				q1 := `CREATE TABLE IF NOT EXISTS wallet_addresses (
+					  id char(36) NOT NULL,
+					  account_id char(36) NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+					  user_id char(36) DEFAULT NULL REFERENCES users(id) ON DELETE CASCADE,
+					  address char(58) NOT NULL,
+					  label varchar(200) NOT NULL DEFAULT '',
+					  default_issuer boolean NOT NULL DEFAULT false,
+					  nonce varchar(64) DEFAULT NULL,
+					  nonce_expires_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  verified_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  archived_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  PRIMARY KEY (id)
+					)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				// An address can only be linked once to an account.
+				q2 := `CREATE UNIQUE INDEX IF NOT EXISTS idx_wallet_addresses_account_address ON wallet_addresses (account_id, address) WHERE archived_at IS NULL`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				// An account can only have a single default issuer.
+				q3 := `CREATE UNIQUE INDEX IF NOT EXISTS idx_wallet_addresses_default_issuer ON wallet_addresses (account_id) WHERE default_issuer AND archived_at IS NULL`
+				if _, err := tx.Exec(q3); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q3)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DROP TABLE IF EXISTS wallet_addresses`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				return nil
+			},
+		},
+		// Create new table api_clients for the machine credentials of accounts.
+		{
+			ID: "20200321-01",
+			Migrate: func(tx *sql.Tx) error {
+				q1 := `CREATE TABLE IF NOT EXISTS api_clients (
+					  id char(36) NOT NULL,
+					  account_id char(36) NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+					  name varchar(200) NOT NULL,
+					  scopes varchar(20)[] NOT NULL,
+					  secret_hash varchar(256) NOT NULL,
+					  last_used_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  rotated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  revoked_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  PRIMARY KEY (id)
+					)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `CREATE INDEX IF NOT EXISTS idx_api_clients_account_id ON api_clients (account_id)`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DROP TABLE IF EXISTS api_clients`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				return nil
+			},
+		},
+		// Create new table auth_private_keys for sharing the keys that sign auth tokens between instances.
+		{
+			ID: "20200328-01",
+			Migrate: func(tx *sql.Tx) error {
+				q1 := `CREATE TABLE IF NOT EXISTS auth_private_keys (
+					  id char(36) NOT NULL,
+					  private_key text NOT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  PRIMARY KEY (id)
+					)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DROP TABLE IF EXISTS auth_private_keys`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				return nil
+			},
+		},
+		// Create new tables user_sessions and user_session_tokens for refresh tokens that can be revoked.
+		{
+			ID: "20200404-01",
+			Migrate: func(tx *sql.Tx) error {
+				q1 := `CREATE TABLE IF NOT EXISTS user_sessions (
+					  id char(36) NOT NULL,
+					  user_id char(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					  user_agent varchar(500) NOT NULL DEFAULT '',
+					  ip_address varchar(45) NOT NULL DEFAULT '',
+					  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  last_used_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  revoked_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  PRIMARY KEY (id)
+					)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions (user_id)`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				q3 := `CREATE TABLE IF NOT EXISTS user_session_tokens (
+					  token_hash char(64) NOT NULL,
+					  session_id char(36) NOT NULL REFERENCES user_sessions(id) ON DELETE CASCADE,
+					  used_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  PRIMARY KEY (token_hash)
+					)`
+				if _, err := tx.Exec(q3); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q3)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DROP TABLE IF EXISTS user_session_tokens`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `DROP TABLE IF EXISTS user_sessions`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return nil
+			},
+		},
+		// Create new tables user_totp, user_totp_recovery_codes and user_totp_challenges for two-factor
+		// authentication and record if a session was started with a second factor.
+		{
+			ID: "20200411-01",
+			Migrate: func(tx *sql.Tx) error {
+				q1 := `CREATE TABLE IF NOT EXISTS user_totp (
+					  user_id char(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					  secret varchar(64) NOT NULL,
+					  last_used_step bigint NOT NULL DEFAULT 0,
+					  verified_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  PRIMARY KEY (user_id)
+					)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `CREATE TABLE IF NOT EXISTS user_totp_recovery_codes (
+					  code_hash char(64) NOT NULL,
+					  user_id char(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					  used_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  PRIMARY KEY (code_hash)
+					)`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				q3 := `CREATE INDEX IF NOT EXISTS idx_user_totp_recovery_codes_user_id ON user_totp_recovery_codes (user_id)`
+				if _, err := tx.Exec(q3); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q3)
+				}
+
+				q4 := `CREATE TABLE IF NOT EXISTS user_totp_challenges (
+					  id char(36) NOT NULL,
+					  user_id char(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					  account_id varchar(36) NOT NULL DEFAULT '',
+					  attempts integer NOT NULL DEFAULT 0,
+					  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  used_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  PRIMARY KEY (id)
+					)`
+				if _, err := tx.Exec(q4); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q4)
+				}
+
+				q5 := `ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS two_factor boolean NOT NULL DEFAULT false`
+				if _, err := tx.Exec(q5); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q5)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `ALTER TABLE user_sessions DROP COLUMN IF EXISTS two_factor`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `DROP TABLE IF EXISTS user_totp_challenges`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				q3 := `DROP TABLE IF EXISTS user_totp_recovery_codes`
+				if _, err := tx.Exec(q3); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q3)
+				}
+
+				q4 := `DROP TABLE IF EXISTS user_totp`
+				if _, err := tx.Exec(q4); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q4)
+				}
+
+				return nil
+			},
+		},
+		// Add the roles drafter, approver, compliance and auditor that grant fine-grained permissions.
+		{
+			ID: "20200418-01",
+			Migrate: func(tx *sql.Tx) error {
+				// ALTER TYPE ... ADD VALUE can't run inside the transaction of the migration,
+				// the type is recreated with the new values instead.
+				return replaceUserAccountRoleType(tx, "enum('admin','user','drafter','approver','compliance','auditor')")
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `UPDATE users_accounts SET roles = ARRAY(SELECT DISTINCT CASE WHEN r IN ('admin', 'user') THEN r ELSE 'user' END FROM unnest(roles) r)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `UPDATE api_clients SET scopes = ARRAY(SELECT DISTINCT CASE WHEN s IN ('admin', 'user') THEN s ELSE 'user' END FROM unnest(scopes) s)`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return replaceUserAccountRoleType(tx, "enum('admin','user')")
+			},
+		},
+		// Add the details of created assets that are published in their ARC-3 metadata document.
+		{
+			ID: "20200425-01",
+			Migrate: func(tx *sql.Tx) error {
+				q1 := `ALTER TABLE created_assets
+					ADD COLUMN IF NOT EXISTS description varchar(1000) NOT NULL DEFAULT '',
+					ADD COLUMN IF NOT EXISTS image_url varchar(500) NOT NULL DEFAULT '',
+					ADD COLUMN IF NOT EXISTS legal_documents jsonb NOT NULL DEFAULT '[]',
+					ADD COLUMN IF NOT EXISTS metadata bytea DEFAULT NULL`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				// Published metadata is served by the asset index on the network.
+				q2 := `CREATE INDEX IF NOT EXISTS idx_created_assets_metadata
+					ON created_assets (network, asset_index) WHERE metadata IS NOT NULL`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DROP INDEX IF EXISTS idx_created_assets_metadata`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `ALTER TABLE created_assets
+					DROP COLUMN IF EXISTS description,
+					DROP COLUMN IF EXISTS image_url,
+					DROP COLUMN IF EXISTS legal_documents,
+					DROP COLUMN IF EXISTS metadata`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return nil
+			},
+		},
+		// Group created asset transactions so a payment and the units issued for it are atomic.
+		{
+			ID: "20200502-01",
+			Migrate: func(tx *sql.Tx) error {
+				if err := replaceTxnType(tx, "enum('config','freeze','clawback','destroy','opt_in','transfer','payment')"); err != nil {
+					return err
+				}
+
+				q1 := `ALTER TABLE created_asset_txns
+					ADD COLUMN IF NOT EXISTS payment_asset_id bigint NOT NULL DEFAULT 0,
+					ADD COLUMN IF NOT EXISTS group_id varchar(44) NOT NULL DEFAULT '',
+					ADD COLUMN IF NOT EXISTS group_index smallint NOT NULL DEFAULT 0,
+					ADD COLUMN IF NOT EXISTS signed_txn bytea NULL`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `CREATE INDEX IF NOT EXISTS idx_created_asset_txns_group_id
+					ON created_asset_txns (group_id) WHERE group_id <> ''`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DELETE FROM created_asset_txns WHERE type = 'payment'`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `DROP INDEX IF EXISTS idx_created_asset_txns_group_id`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				q3 := `ALTER TABLE created_asset_txns
+					DROP COLUMN IF EXISTS payment_asset_id,
+					DROP COLUMN IF EXISTS group_id,
+					DROP COLUMN IF EXISTS group_index,
+					DROP COLUMN IF EXISTS signed_txn`
+				if _, err := tx.Exec(q3); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q3)
+				}
+
+				return replaceTxnType(tx, "enum('config','freeze','clawback','destroy','opt_in','transfer')")
+			},
+		},
+		// Create new table wallet_multisigs for the multisig accounts of accounts and store the partial
+		// signatures of multisig transactions.
+		{
+			ID: "20200509-01",
+			Migrate: func(tx *sql.Tx) error {
+				q1 := `CREATE TABLE IF NOT EXISTS wallet_multisigs (
+					  id char(36) NOT NULL,
+					  account_id char(36) NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+					  address char(58) NOT NULL,
+					  label varchar(200) NOT NULL DEFAULT '',
+					  version smallint NOT NULL DEFAULT 1,
+					  threshold smallint NOT NULL,
+					  members char(58)[] NOT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  archived_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  PRIMARY KEY (id)
+					)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				// A multisig can only be defined once for an account.
+				q2 := `CREATE UNIQUE INDEX IF NOT EXISTS idx_wallet_multisigs_account_address ON wallet_multisigs (account_id, address) WHERE archived_at IS NULL`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				q3 := `ALTER TABLE created_asset_txns ADD COLUMN IF NOT EXISTS multisig_txn bytea NULL`
+				if _, err := tx.Exec(q3); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q3)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `ALTER TABLE created_asset_txns DROP COLUMN IF EXISTS multisig_txn`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `DROP TABLE IF EXISTS wallet_multisigs`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return nil
+			},
+		},
+		// Create new table user_kyc for the KYC status of the users of accounts that whitelists them
+		// to hold default frozen assets.
+		{
+			ID: "20200516-01",
+			Migrate: func(tx *sql.Tx) error {
+				if err := createTypeIfNotExists(tx, "user_kyc_status_t", "enum('pending','approved','rejected','revoked')"); err != nil {
+					return err
+				}
+
+				q1 := `CREATE TABLE IF NOT EXISTS user_kyc (
+					  user_id char(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					  account_id char(36) NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+					  status user_kyc_status_t NOT NULL DEFAULT 'pending',
+					  expires_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  note varchar(500) NOT NULL DEFAULT '',
+					  reviewed_by char(36) DEFAULT NULL,
+					  reviewed_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  PRIMARY KEY (user_id, account_id)
+					)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DROP TABLE IF EXISTS user_kyc`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `DROP TYPE IF EXISTS user_kyc_status_t`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return nil
+			},
+		},
+		// Create new tables created_asset_vesting_grants for the units of created assets that vest
+		// for holders over time and created_asset_vesting_releases for the transfers of the
+		// units once they have vested.
+		{
+			ID: "20200523-01",
+			Migrate: func(tx *sql.Tx) error {
+				q1 := `CREATE TABLE IF NOT EXISTS created_asset_vesting_grants (
+					  id char(36) NOT NULL,
+					  created_asset_id char(36) NOT NULL REFERENCES created_assets(id) ON DELETE CASCADE,
+					  account_id char(36) NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+					  user_id char(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+					  address char(58) NOT NULL,
+					  total bigint NOT NULL,
+					  start_date TIMESTAMP WITH TIME ZONE NOT NULL,
+					  cliff_months smallint NOT NULL DEFAULT 0,
+					  period_months smallint NOT NULL,
+					  interval_months smallint NOT NULL DEFAULT 1,
+					  acceleration_percent smallint NOT NULL DEFAULT 0,
+					  accelerated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  cancelled_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  PRIMARY KEY (id)
+					)`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `CREATE TABLE IF NOT EXISTS created_asset_vesting_releases (
+					  id char(36) NOT NULL,
+					  vesting_grant_id char(36) NOT NULL REFERENCES created_asset_vesting_grants(id) ON DELETE CASCADE,
+					  created_asset_txn_id char(36) NOT NULL REFERENCES created_asset_txns(id) ON DELETE CASCADE,
+					  amount bigint NOT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  PRIMARY KEY (id)
+					)`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q1 := `DROP TABLE IF EXISTS created_asset_vesting_releases`
+				if _, err := tx.Exec(q1); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q1)
+				}
+
+				q2 := `DROP TABLE IF EXISTS created_asset_vesting_grants`
+				if _, err := tx.Exec(q2); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q2)
+				}
+
+				return nil
+			},
+		},
+		// Create new table account_roles for the sets of permissions admins assign to the roles
+		// of their account in place of the defaults.
+		{
+			ID: "20200530-01",
+			Migrate: func(tx *sql.Tx) error {
+				q := `CREATE TABLE IF NOT EXISTS account_roles (
+					  account_id char(36) NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+					  role varchar(20) NOT NULL,
+					  permissions varchar(50)[] NOT NULL,
+					  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+					  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+					  CONSTRAINT account_roles_pkey UNIQUE (account_id,role)
+					)`
+				if _, err := tx.Exec(q); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q)
+				}
+
+				return nil
+			},
+			Rollback: func(tx *sql.Tx) error {
+				q := `DROP TABLE IF EXISTS account_roles`
+				if _, err := tx.Exec(q); err != nil {
+					return errors.Wrapf(err, "Query failed %s", q)
+				}
+
+				return nil
+			},
+		},
+	}
+}
+
+// replaceUserAccountRoleType recreates the type of the roles of user accounts with the values
+// and converts the existing rows.
+func replaceUserAccountRoleType(tx *sql.Tx, val string) error {
+	q1 := `ALTER TABLE users_accounts ALTER COLUMN roles TYPE varchar(20)[]`
+	if _, err := tx.Exec(q1); err != nil {
+		return errors.Wrapf(err, "Query failed %s", q1)
+	}
+
+	if err := dropTypeIfExists(tx, "user_account_role_t"); err != nil {
+		return err
+	}
+
+	if err := createTypeIfNotExists(tx, "user_account_role_t", val); err != nil {
+		return err
+	}
+
+	q2 := `ALTER TABLE users_accounts ALTER COLUMN roles TYPE user_account_role_t[] USING roles::text[]::user_account_role_t[]`
+	if _, err := tx.Exec(q2); err != nil {
+		return errors.Wrapf(err, "Query failed %s", q2)
+	}
+
+	return nil
+}
+
+// replaceTxnType recreates the type of created asset transactions with the values and
+// converts the existing rows.
+func replaceTxnType(tx *sql.Tx, val string) error {
+	q1 := `ALTER TABLE created_asset_txns ALTER COLUMN type TYPE varchar(20)`
+	if _, err := tx.Exec(q1); err != nil {
+		return errors.Wrapf(err, "Query failed %s", q1)
+	}
+
+	if err := dropTypeIfExists(tx, "created_asset_txn_type_t"); err != nil {
+		return err
 	}
+
+	if err := createTypeIfNotExists(tx, "created_asset_txn_type_t", val); err != nil {
+		return err
+	}
+
+	q2 := `ALTER TABLE created_asset_txns ALTER COLUMN type TYPE created_asset_txn_type_t USING type::created_asset_txn_type_t`
+	if _, err := tx.Exec(q2); err != nil {
+		return errors.Wrapf(err, "Query failed %s", q2)
+	}
+
+	return nil
 }
 
 // dropTypeIfExists executes drop type.
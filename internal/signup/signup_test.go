@@ -7,11 +7,16 @@ import (
 
 	"exitor-dapp/internal/account"
 	"exitor-dapp/internal/account/account_preference"
+	"exitor-dapp/internal/account/account_role"
+	"exitor-dapp/internal/account/api_client"
 	"exitor-dapp/internal/platform/auth"
 	"exitor-dapp/internal/platform/tests"
 	"exitor-dapp/internal/user"
 	"exitor-dapp/internal/user_account"
 	"exitor-dapp/internal/user_auth"
+	"exitor-dapp/internal/user_session"
+	"exitor-dapp/internal/user_totp"
+	"exitor-dapp/internal/wallet"
 	"github.com/google/go-cmp/cmp"
 	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
@@ -132,7 +137,6 @@ func TestSignupFull(t *testing.T) {
 			Email:           uuid.NewRandom().String() + "@geeksinthewoods.com",
 			Password:        "akTechFr0n!ier",
 			PasswordConfirm: "akTechFr0n!ier",
-			WalletAddress: "0uehiekjedj",
 		},
 	}
 
@@ -143,7 +147,7 @@ func TestSignupFull(t *testing.T) {
 	tknGen := &auth.MockTokenGenerator{}
 
 	accPrefRepo := account_preference.NewRepository(test.MasterDB)
-	authRepo := user_auth.NewRepository(test.MasterDB, tknGen, repo.User, repo.UserAccount, accPrefRepo)
+	authRepo := user_auth.NewRepository(test.MasterDB, tknGen, repo.User, repo.UserAccount, accPrefRepo, account_role.NewRepository(test.MasterDB), wallet.NewRepository(test.MasterDB), api_client.NewRepository(test.MasterDB), user_session.NewRepository(test.MasterDB), user_totp.NewRepository(test.MasterDB))
 
 	t.Log("Given the need to ensure signup works.")
 	{
@@ -92,8 +92,8 @@ func (repo *Repository) CanModifyUser(ctx context.Context, claims auth.Claims, u
 	// If the request has claims from a specific user, ensure that the user
 	// has the correct role for creating a new user.
 	if claims.Subject != "" && claims.Subject != userID {
-		// Users with the role of admin are ony allows to create users.
-		if !claims.HasRole(auth.RoleAdmin) {
+		// Only users that can manage users are allowed to modify other users.
+		if !claims.HasPermission(auth.PermissionUserManage) {
 			err := errors.WithStack(ErrForbidden)
 			return err
 		}
@@ -307,8 +307,8 @@ func (repo *Repository) Create(ctx context.Context, claims auth.Claims, req User
 	// If the request has claims from a specific user, ensure that the user
 	// has the correct role for creating a new user.
 	if claims.Subject != "" {
-		// Users with the role of admin are ony allows to create users.
-		if !claims.HasRole(auth.RoleAdmin) {
+		// Only users that can manage users are allowed to create users.
+		if !claims.HasPermission(auth.PermissionUserManage) {
 			err = errors.WithStack(ErrForbidden)
 			return nil, err
 		}
@@ -388,8 +388,8 @@ func (repo *Repository) CreateInvite(ctx context.Context, claims auth.Claims, re
 	// If the request has claims from a specific user, ensure that the user
 	// has the correct role for creating a new user.
 	if claims.Subject != "" {
-		// Users with the role of admin are ony allows to create users.
-		if !claims.HasRole(auth.RoleAdmin) {
+		// Only users that can manage users are allowed to create users.
+		if !claims.HasPermission(auth.PermissionUserManage) {
 			err = errors.WithStack(ErrForbidden)
 			return nil, err
 		}
@@ -36,7 +36,7 @@ type UserAccount struct {
 	//ID         string            `json:"id" validate:"required,uuid" example:"72938896-a998-4258-a17b-6418dcdb80e3"`
 	UserID     string            `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
 	AccountID  string            `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	Roles      UserAccountRoles  `json:"roles" validate:"required,dive,oneof=admin user" enums:"admin,user" swaggertype:"array,string" example:"admin"`
+	Roles      UserAccountRoles  `json:"roles" validate:"required,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"admin"`
 	Status     UserAccountStatus `json:"status" validate:"omitempty,oneof=active invited disabled" enums:"active,invited,disabled" swaggertype:"string" example:"active"`
 	CreatedAt  time.Time         `json:"created_at"`
 	UpdatedAt  time.Time         `json:"updated_at"`
@@ -46,13 +46,14 @@ type UserAccount struct {
 // UserAccountResponse defines the one to many relationship of an user to an account that is returned for display.
 type UserAccountResponse struct {
 	//ID         string            `json:"id" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
-	UserID     string                `json:"user_id" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
-	AccountID  string                `json:"account_id" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	Roles      web.EnumMultiResponse `json:"roles" validate:"required,dive,oneof=admin user" enums:"admin,user" swaggertype:"array,string" example:"admin"`
-	Status     web.EnumResponse      `json:"status"`                // Status is enum with values [active, invited, disabled].
-	CreatedAt  web.TimeResponse      `json:"created_at"`            // CreatedAt contains multiple format options for display.
-	UpdatedAt  web.TimeResponse      `json:"updated_at"`            // UpdatedAt contains multiple format options for display.
-	ArchivedAt *web.TimeResponse     `json:"archived_at,omitempty"` // ArchivedAt contains multiple format options for display.
+	UserID      string                `json:"user_id" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	AccountID   string                `json:"account_id" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Roles       web.EnumMultiResponse `json:"roles" validate:"required,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"admin"`
+	Permissions []string              `json:"permissions" example:"asset:read"` // Permissions granted by the roles.
+	Status      web.EnumResponse      `json:"status"`                           // Status is enum with values [active, invited, disabled].
+	CreatedAt   web.TimeResponse      `json:"created_at"`                       // CreatedAt contains multiple format options for display.
+	UpdatedAt   web.TimeResponse      `json:"updated_at"`                       // UpdatedAt contains multiple format options for display.
+	ArchivedAt  *web.TimeResponse     `json:"archived_at,omitempty"`            // ArchivedAt contains multiple format options for display.
 }
 
 // Response transforms UserAccount and UserAccountResponse that is used for display.
@@ -76,6 +77,7 @@ func (m *UserAccount) Response(ctx context.Context) *UserAccountResponse {
 		selectedRoles = append(selectedRoles, r.String())
 	}
 	r.Roles = web.NewEnumMultiResponse(ctx, selectedRoles, UserAccountRole_ValuesInterface()...)
+	r.Permissions = m.Roles.Permissions()
 
 	if m.ArchivedAt != nil && !m.ArchivedAt.Time.IsZero() {
 		at := web.NewTimeResponse(ctx, m.ArchivedAt.Time)
@@ -98,6 +100,14 @@ func (m *UserAccount) HasRole(role UserAccountRole) bool {
 	return false
 }
 
+// HasPermission checks if the roles of the entry grant at least one of the permissions.
+func (m *UserAccount) HasPermission(permissions ...string) bool {
+	if m == nil {
+		return false
+	}
+	return m.Roles.HasPermission(permissions...)
+}
+
 // UserAccounts a list of UserAccounts.
 type UserAccounts []*UserAccount
 
@@ -120,7 +130,7 @@ func (m *UserAccounts) Response(ctx context.Context) []*UserAccountResponse {
 type UserAccountCreateRequest struct {
 	UserID    string             `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
 	AccountID string             `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	Roles     UserAccountRoles   `json:"roles" validate:"required,dive,oneof=admin user" enums:"admin,user" swaggertype:"array,string" example:"admin"`
+	Roles     UserAccountRoles   `json:"roles" validate:"required,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"admin"`
 	Status    *UserAccountStatus `json:"status,omitempty" validate:"omitempty,oneof=active invited disabled" enums:"active,invited,disabled" swaggertype:"string" example:"active"`
 }
 
@@ -136,7 +146,7 @@ type UserAccountReadRequest struct {
 type UserAccountUpdateRequest struct {
 	UserID    string             `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
 	AccountID string             `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	Roles     *UserAccountRoles  `json:"roles,omitempty" validate:"omitempty,dive,oneof=admin user" enums:"admin,user" swaggertype:"array,string" example:"user"`
+	Roles     *UserAccountRoles  `json:"roles,omitempty" validate:"omitempty,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"user"`
 	Status    *UserAccountStatus `json:"status,omitempty" validate:"omitempty,oneof=active invited disabled" enums:"active,invited,disabled" swaggertype:"string" example:"disabled"`
 	unArchive bool               `json:"-"` // Internal use only.
 }
@@ -237,12 +247,28 @@ const (
 	// privileges for accessing an account. This role provies a user with the most
 	// limited access to an account.
 	UserAccountRole_User UserAccountRole = auth.RoleUser
+	// UserAccountRole_Drafter defines the state of a user when they can draft
+	// assets for the account before they are minted.
+	UserAccountRole_Drafter UserAccountRole = auth.RoleDrafter
+	// UserAccountRole_Approver defines the state of a user when they approve the
+	// minting and distribution of assets for the account.
+	UserAccountRole_Approver UserAccountRole = auth.RoleApprover
+	// UserAccountRole_Compliance defines the state of a user when they can freeze
+	// and claw back assets of the account.
+	UserAccountRole_Compliance UserAccountRole = auth.RoleCompliance
+	// UserAccountRole_Auditor defines the state of a user when they have read-only
+	// access to the assets, users and settings of an account.
+	UserAccountRole_Auditor UserAccountRole = auth.RoleAuditor
 )
 
 // UserAccountRole_Values provides list of valid UserAccountRole values.
 var UserAccountRole_Values = []UserAccountRole{
 	UserAccountRole_Admin,
 	UserAccountRole_User,
+	UserAccountRole_Drafter,
+	UserAccountRole_Approver,
+	UserAccountRole_Compliance,
+	UserAccountRole_Auditor,
 }
 
 // UserAccountRole_ValuesInterface returns the UserAccountRole options as a slice interface.
@@ -262,6 +288,33 @@ func (s UserAccountRole) String() string {
 // UserAccountRoles represents a set of roles for a user for an account.
 type UserAccountRoles []UserAccountRole
 
+// Permissions returns the set of permissions granted by the roles by default, without the sets
+// assigned for the account.
+func (s UserAccountRoles) Permissions() []string {
+	var perms []string
+	for _, p := range auth.Permissions {
+		if s.HasPermission(p) {
+			perms = append(perms, p)
+		}
+	}
+	return perms
+}
+
+// HasPermission returns true if any of the roles grants at least one of the provided permissions
+// by default, without the sets assigned for the account.
+func (s UserAccountRoles) HasPermission(permissions ...string) bool {
+	return auth.Claims{Roles: s.Strings()}.HasPermission(permissions...)
+}
+
+// Strings returns the roles as a list of strings.
+func (s UserAccountRoles) Strings() []string {
+	var roles []string
+	for _, r := range s {
+		roles = append(roles, r.String())
+	}
+	return roles
+}
+
 // Scan supports reading the UserAccountRole value from the database.
 func (s *UserAccountRoles) Scan(value interface{}) error {
 	arr := &pq.StringArray{}
@@ -282,7 +335,7 @@ func (s UserAccountRoles) Value() (driver.Value, error) {
 
 	var arr pq.StringArray
 	for _, r := range s {
-		errs := v.Var(r, "required,oneof=admin user")
+		errs := v.Var(r, "required,oneof=admin user drafter approver compliance auditor")
 		if errs != nil {
 			return nil, errs
 		}
@@ -301,7 +354,7 @@ type User struct {
 	Email      string            `json:"email" validate:"required,email,unique" example:"gabi@geeksinthewoods.com"`
 	Timezone   *string           `json:"timezone" validate:"omitempty" example:"America/Anchorage"`
 	AccountID  string            `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	Roles      UserAccountRoles  `json:"roles" validate:"required,dive,oneof=admin user" enums:"admin,user" swaggertype:"array,string" example:"admin"`
+	Roles      UserAccountRoles  `json:"roles" validate:"required,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"admin"`
 	Status     UserAccountStatus `json:"status" validate:"omitempty,oneof=active invited disabled" enums:"active,invited,disabled" swaggertype:"string" example:"active"`
 	CreatedAt  time.Time         `json:"created_at"`
 	UpdatedAt  time.Time         `json:"updated_at"`
@@ -310,19 +363,20 @@ type User struct {
 
 // UserResponse represents someone with access to our system that is returned for display.
 type UserResponse struct {
-	ID         string                `json:"id" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
-	Name       string                `json:"name" example:"Gabi"`
-	FirstName  string                `json:"first_name" example:"Gabi"`
-	LastName   string                `json:"last_name" example:"May"`
-	Email      string                `json:"email" example:"gabi@geeksinthewoods.com"`
-	Timezone   string                `json:"timezone" example:"America/Anchorage"`
-	AccountID  string                `json:"account_id" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	Roles      web.EnumMultiResponse `json:"roles" validate:"required,dive,oneof=admin user" enums:"admin,user" swaggertype:"array,string" example:"admin"`
-	Status     web.EnumResponse      `json:"status"`                // Status is enum with values [active, invited, disabled].
-	CreatedAt  web.TimeResponse      `json:"created_at"`            // CreatedAt contains multiple format options for display.
-	UpdatedAt  web.TimeResponse      `json:"updated_at"`            // UpdatedAt contains multiple format options for display.
-	ArchivedAt *web.TimeResponse     `json:"archived_at,omitempty"` // ArchivedAt contains multiple format options for display.
-	Gravatar   web.GravatarResponse  `json:"gravatar"`
+	ID          string                `json:"id" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Name        string                `json:"name" example:"Gabi"`
+	FirstName   string                `json:"first_name" example:"Gabi"`
+	LastName    string                `json:"last_name" example:"May"`
+	Email       string                `json:"email" example:"gabi@geeksinthewoods.com"`
+	Timezone    string                `json:"timezone" example:"America/Anchorage"`
+	AccountID   string                `json:"account_id" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Roles       web.EnumMultiResponse `json:"roles" validate:"required,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"admin"`
+	Permissions []string              `json:"permissions" example:"asset:read"` // Permissions granted by the roles.
+	Status      web.EnumResponse      `json:"status"`                           // Status is enum with values [active, invited, disabled].
+	CreatedAt   web.TimeResponse      `json:"created_at"`                       // CreatedAt contains multiple format options for display.
+	UpdatedAt   web.TimeResponse      `json:"updated_at"`                       // UpdatedAt contains multiple format options for display.
+	ArchivedAt  *web.TimeResponse     `json:"archived_at,omitempty"`            // ArchivedAt contains multiple format options for display.
+	Gravatar    web.GravatarResponse  `json:"gravatar"`
 }
 
 // Response transforms User and UserResponse that is used for display.
@@ -350,6 +404,7 @@ func (m *User) Response(ctx context.Context) *UserResponse {
 		selectedRoles = append(selectedRoles, r.String())
 	}
 	r.Roles = web.NewEnumMultiResponse(ctx, selectedRoles, UserAccountRole_ValuesInterface()...)
+	r.Permissions = m.Roles.Permissions()
 
 	if m.Timezone != nil {
 		r.Timezone = *m.Timezone
@@ -7,10 +7,13 @@ import (
 	"time"
 
 	"exitor-dapp/internal/account/account_preference"
+	"exitor-dapp/internal/account/api_client"
 	"exitor-dapp/internal/platform/auth"
 	"exitor-dapp/internal/platform/web/webcontext"
 	"exitor-dapp/internal/user"
-	"exitor-dapp/internal/user_account"
+	"exitor-dapp/internal/user_session"
+	"exitor-dapp/internal/user_totp"
+	"exitor-dapp/internal/wallet"
 
 	"github.com/huandu/go-sqlbuilder"
 	"github.com/lib/pq"
@@ -26,6 +29,14 @@ var (
 
 	// ErrForbidden occurs when a user tries to do something that is forbidden to them according to our access control policies.
 	ErrForbidden = errors.New("Attempted action is not allowed")
+
+	// ErrTwoFactorRequired occurs when a user must provide a two-factor authentication code to
+	// sign in.
+	ErrTwoFactorRequired = errors.New("Two-factor authentication required")
+
+	// ErrTwoFactorEnrollmentRequired occurs when the account requires two-factor authentication
+	// and the user has not enrolled yet.
+	ErrTwoFactorEnrollmentRequired = errors.New("Two-factor authentication enrollment required")
 )
 
 const (
@@ -35,11 +46,17 @@ const (
 	accountTableName = "accounts"
 	// The database table for User Account
 	userAccountTableName = "users_accounts"
+
+	// AccessTokenTTL is the duration of the access tokens issued for a session. They are kept
+	// short so changes to the roles of the user are picked up when the token is refreshed, the
+	// tokens of a revoked session are rejected by the auth middleware right away.
+	AccessTokenTTL = time.Minute * 15
 )
 
 // Authenticate finds a user by their email and verifies their password. On success
 // it returns a Token that can be used to authenticate access to the application in
-// the future.
+// the future. Users that have enrolled in two-factor authentication must also provide
+// a code, see twoFactorToken.
 func (repo *Repository) Authenticate(ctx context.Context, req AuthenticateRequest, expires time.Duration, now time.Time, scopes ...string) (Token, error) {
 	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_auth.Authenticate")
 	defer span.Finish()
@@ -73,7 +90,329 @@ func (repo *Repository) Authenticate(ctx context.Context, req AuthenticateReques
 	}
 
 	// The user is successfully authenticated with the supplied email and password.
-	return repo.generateToken(ctx, auth.Claims{}, u.ID, req.AccountID, expires, now, scopes...)
+	return repo.twoFactorToken(ctx, u.ID, req.AccountID, req.TOTPCode, expires, now, scopes...)
+}
+
+// WalletChallenge issues the message that must be signed with the key of a verified Algorand
+// address to authenticate as the user that linked it.
+func (repo *Repository) WalletChallenge(ctx context.Context, req WalletChallengeRequest, now time.Time) (*wallet.WalletAddressChallenge, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_auth.WalletChallenge")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.Wallet.LoginChallenge(ctx, wallet.WalletAddressLoginChallengeRequest{
+		Address:   req.Address,
+		AccountID: req.AccountID,
+	}, now)
+}
+
+// AuthenticateWallet verifies the signature of the challenge issued for an Algorand address
+// and authenticates the user that linked the address. On success it returns the same Token
+// as Authenticate.
+func (repo *Repository) AuthenticateWallet(ctx context.Context, req AuthenticateWalletRequest, expires time.Duration, now time.Time, scopes ...string) (Token, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_auth.AuthenticateWallet")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return Token{}, err
+	}
+
+	m, err := repo.Wallet.VerifyLogin(ctx, wallet.WalletAddressLoginRequest{
+		Address:   req.Address,
+		AccountID: req.AccountID,
+		Signature: req.Signature,
+	}, now)
+	if err != nil {
+		switch errors.Cause(err) {
+		case wallet.ErrChallengeExpired, auth.ErrInvalidAlgorandSignature:
+			err = errors.WithMessage(ErrAuthenticationFailure, err.Error())
+			return Token{}, err
+		default:
+			return Token{}, err
+		}
+	}
+
+	// Ensure the user that linked the address has not been archived.
+	u, err := repo.User.ReadByID(ctx, auth.Claims{}, *m.UserID)
+	if err != nil {
+		if errors.Cause(err) == user.ErrNotFound {
+			err = errors.WithStack(ErrAuthenticationFailure)
+			return Token{}, err
+		} else {
+			return Token{}, err
+		}
+	}
+
+	// The user is successfully authenticated with the key of the address, the token is
+	// issued for the account the address is linked to. The code is always entered in a
+	// separate step.
+	return repo.twoFactorToken(ctx, u.ID, m.AccountID, "", expires, now, scopes...)
+}
+
+// AuthenticateTwoFactor completes a sign in with the code of the user for the challenge issued
+// when their first factor was verified. For users that have not enrolled yet the code confirms
+// their enrollment. On success it returns the same Token as Authenticate.
+func (repo *Repository) AuthenticateTwoFactor(ctx context.Context, req AuthenticateTwoFactorRequest, expires time.Duration, now time.Time, scopes ...string) (Token, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_auth.AuthenticateTwoFactor")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return Token{}, err
+	}
+
+	ch, err := repo.UserTotp.VerifyChallenge(ctx, user_totp.UserTotpChallengeVerifyRequest{
+		ChallengeID: req.ChallengeID,
+		Code:        req.Code,
+	}, now)
+	if err != nil {
+		switch errors.Cause(err) {
+		case user_totp.ErrInvalidCode, user_totp.ErrChallengeExpired, user_totp.ErrNotFound, user_totp.ErrNotEnrolled:
+			err = errors.WithMessage(ErrAuthenticationFailure, err.Error())
+			return Token{}, err
+		default:
+			return Token{}, err
+		}
+	}
+
+	return repo.generateToken(ctx, auth.Claims{TwoFactor: true}, ch.UserID, ch.AccountID, expires, now, scopes...)
+}
+
+// twoFactorToken returns the token for a user that has verified their first factor. Users that
+// have enrolled in two-factor authentication must also provide a code, without one a challenge is
+// issued so the code can be entered in a separate step. Users that have not enrolled get a
+// challenge to enroll when the account requires two-factor authentication.
+func (repo *Repository) twoFactorToken(ctx context.Context, userID, accountID, code string, expires time.Duration, now time.Time, scopes ...string) (Token, error) {
+	enrolled, err := repo.UserTotp.IsEnrolled(ctx, userID)
+	if err != nil {
+		return Token{}, err
+	}
+
+	if !enrolled {
+		tkn, err := repo.generateToken(ctx, auth.Claims{}, userID, accountID, expires, now, scopes...)
+		if err != nil {
+			if errors.Cause(err) == ErrTwoFactorRequired {
+				return repo.twoFactorChallenge(ctx, userID, accountID, ErrTwoFactorEnrollmentRequired, now)
+			}
+			return Token{}, err
+		}
+		return tkn, nil
+	}
+
+	if code == "" {
+		return repo.twoFactorChallenge(ctx, userID, accountID, ErrTwoFactorRequired, now)
+	}
+
+	err = repo.UserTotp.Verify(ctx, user_totp.UserTotpVerifyRequest{
+		UserID: userID,
+		Code:   code,
+	}, now)
+	if err != nil {
+		if errors.Cause(err) == user_totp.ErrInvalidCode {
+			err = errors.WithMessage(ErrAuthenticationFailure, err.Error())
+			return Token{}, err
+		}
+		return Token{}, err
+	}
+
+	return repo.generateToken(ctx, auth.Claims{TwoFactor: true}, userID, accountID, expires, now, scopes...)
+}
+
+// twoFactorChallenge issues a challenge for the user and returns its ID in the token with the
+// provided error.
+func (repo *Repository) twoFactorChallenge(ctx context.Context, userID, accountID string, cause error, now time.Time) (Token, error) {
+	ch, err := repo.UserTotp.CreateChallenge(ctx, user_totp.UserTotpChallengeCreateRequest{
+		UserID:    userID,
+		AccountID: accountID,
+	}, now)
+	if err != nil {
+		return Token{}, err
+	}
+
+	return Token{TwoFactorChallenge: ch.ID}, errors.WithStack(cause)
+}
+
+// AuthenticateClient verifies the secret of an api client and returns a Token for the account
+// that owns the client. The scopes requested must be a subset of the scopes of the client.
+func (repo *Repository) AuthenticateClient(ctx context.Context, req AuthenticateClientRequest, expires time.Duration, now time.Time, scopes ...string) (Token, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_auth.AuthenticateClient")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return Token{}, err
+	}
+
+	c, err := repo.ApiClient.Authenticate(ctx, api_client.ApiClientAuthenticateRequest{
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+	}, now)
+	if err != nil {
+		if errors.Cause(err) == api_client.ErrInvalidCredentials {
+			err = errors.WithStack(ErrAuthenticationFailure)
+			return Token{}, err
+		} else {
+			return Token{}, err
+		}
+	}
+
+	// Load the account that owns the client to ensure it's still active.
+	var (
+		accountStatus   string
+		accountArchived pq.NullTime
+		accountTimezone sql.NullString
+	)
+	{
+		query := sqlbuilder.NewSelectBuilder().Select("status, archived_at, timezone").From(accountTableName)
+		query.Where(query.Equal("id", c.AccountID))
+
+		queryStr, queryArgs := query.Build()
+		queryStr = repo.DbConn.Rebind(queryStr)
+		err = repo.DbConn.QueryRowContext(ctx, queryStr, queryArgs...).Scan(&accountStatus, &accountArchived, &accountTimezone)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				err = errors.WithStack(ErrAuthenticationFailure)
+				return Token{}, err
+			}
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return Token{}, err
+		}
+	}
+
+	if accountArchived.Valid && !accountArchived.Time.IsZero() {
+		err = errors.WithMessage(ErrAuthenticationFailure, "account is archived")
+		return Token{}, err
+	} else if accountStatus != "active" {
+		err = errors.WithMessagef(ErrAuthenticationFailure, "account is not active with status of %s", accountStatus)
+		return Token{}, err
+	}
+
+	roles, err := scopeRoles(c.Scopes, scopes...)
+	if err != nil {
+		return Token{}, err
+	}
+
+	claimPref, err := repo.claimPreferences(ctx, c.AccountID, sql.NullString{}, accountTimezone)
+	if err != nil {
+		return Token{}, err
+	}
+
+	// The client acts on behalf of its account so the client ID is used as the subject. The
+	// token can't be used to switch to other accounts.
+	newClaims := auth.NewClaims(c.ID, c.AccountID, []string{c.AccountID}, roles, claimPref, now, expires)
+	newClaims.ClientID = c.ID
+
+	return repo.newToken(newClaims, expires, now)
+}
+
+// StartSession records a new session for the user of the token that expires after the provided
+// duration. The returned token includes the refresh token for the session and its access token
+// is issued again to include the ID of the session.
+func (repo *Repository) StartSession(ctx context.Context, tkn Token, req SessionRequest, sessionTTL time.Duration, now time.Time) (Token, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_auth.StartSession")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return Token{}, err
+	}
+
+	if tkn.claims.IsClient() {
+		return Token{}, errors.WithMessage(ErrForbidden, "sessions can't be started for api clients")
+	}
+
+	s, err := repo.UserSession.Create(ctx, auth.Claims{}, user_session.UserSessionCreateRequest{
+		UserID:    user_session.SessionUserID(tkn.claims),
+		UserAgent: req.UserAgent,
+		IPAddress: req.IPAddress,
+		TwoFactor: tkn.claims.TwoFactor,
+		TTL:       sessionTTL,
+	}, now)
+	if err != nil {
+		return Token{}, err
+	}
+
+	claims := tkn.claims
+	claims.SessionID = s.ID
+
+	res, err := repo.newToken(claims, tkn.TTL, now)
+	if err != nil {
+		return Token{}, err
+	}
+	res.RefreshToken = s.RefreshToken
+
+	return res, nil
+}
+
+// Refresh exchanges the refresh token of a session for a new access token and refresh token. The
+// claims of the expired access token are used to keep the account and virtual login of the
+// session, when empty the token is issued for the user that started the session. The status of
+// the user and account are verified again so users removed from an account can't refresh.
+func (repo *Repository) Refresh(ctx context.Context, claims auth.Claims, req RefreshRequest, expires time.Duration, now time.Time, scopes ...string) (Token, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_auth.Refresh")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return Token{}, err
+	}
+
+	s, err := repo.UserSession.Refresh(ctx, user_session.UserSessionRefreshRequest{
+		RefreshToken: req.RefreshToken,
+	}, now)
+	if err != nil {
+		switch errors.Cause(err) {
+		case user_session.ErrInvalidRefreshToken, user_session.ErrRefreshTokenReused:
+			err = errors.WithMessage(ErrAuthenticationFailure, err.Error())
+			return Token{}, err
+		default:
+			return Token{}, err
+		}
+	}
+
+	userID := s.UserID
+	accountID := req.AccountID
+	if claims.Subject != "" {
+		// The claims must have been issued for the same session.
+		if claims.SessionID != s.ID || user_session.SessionUserID(claims) != s.UserID {
+			err = errors.WithMessagef(ErrAuthenticationFailure, "claims were not issued for session %s", s.ID)
+			return Token{}, err
+		}
+
+		userID = claims.Subject
+		if accountID == "" {
+			accountID = claims.Audience
+		}
+	}
+	claims.SessionID = s.ID
+
+	// The second factor verified when the session was started still applies.
+	claims.TwoFactor = s.TwoFactor
+
+	tkn, err := repo.generateToken(ctx, claims, userID, accountID, expires, now, scopes...)
+	if err != nil {
+		return Token{}, err
+	}
+	tkn.RefreshToken = s.RefreshToken
+
+	return tkn, nil
 }
 
 // SwitchAccount allows users to switch between multiple accounts, this changes the claim audience.
@@ -119,10 +458,16 @@ func (repo *Repository) VirtualLogin(ctx context.Context, claims auth.Claims, re
 		return Token{}, err
 	}
 
-	// The user must have the role of admin to login any other user.
+	// The permissions of the roles can be assigned for the account.
+	permSets, err := repo.AccountRole.PermissionSets(ctx, req.AccountID)
+	if err != nil {
+		return Token{}, err
+	}
+
+	// The user must have the permission to manage users to login any other user.
 	var hasAccountAdminRole bool
 	for _, usrAcc := range usrAccs {
-		if usrAcc.HasRole(user_account.UserAccountRole_Admin) {
+		if permSets.HasPermission(usrAcc.Roles.Strings(), auth.PermissionUserManage) {
 			if usrAcc.AccountID == req.AccountID {
 				hasAccountAdminRole = true
 				break
@@ -276,6 +621,17 @@ func (repo *Repository) generateToken(ctx context.Context, claims auth.Claims, u
 		return Token{}, err
 	}
 
+	// Accounts can require all their users to sign in with a two-factor authentication code.
+	if !claims.TwoFactor {
+		required, err := repo.requiresTwoFactor(ctx, accountID)
+		if err != nil {
+			return Token{}, err
+		} else if required {
+			err = errors.WithMessagef(ErrTwoFactorRequired, "account %s requires two-factor authentication", accountID)
+			return Token{}, err
+		}
+	}
+
 	// Generate a list of all the account IDs associated with the user so the use
 	// has the ability to switch between accounts.
 	var accountIds []string
@@ -285,96 +641,20 @@ func (repo *Repository) generateToken(ctx context.Context, claims auth.Claims, u
 
 	// Allow the scope to be defined for the claims. This enables testing via the API when a user has the role of admin
 	// and would like to limit their role to user.
-	var roles []string
-	{
-		if len(scopes) > 0 && scopes[0] != "" {
-			// Parse scopes, handle when one value has a list of scopes
-			// separated by a space.
-			var scopeList []string
-			for _, vs := range scopes {
-				for _, v := range strings.Split(vs, " ") {
-					v = strings.TrimSpace(v)
-					if v == "" {
-						continue
-					}
-					scopeList = append(scopeList, v)
-				}
-			}
-
-			for _, s := range scopeList {
-				var scopeValid bool
-				for _, r := range account.Roles {
-					if r == s || (s == auth.RoleUser && r == auth.RoleAdmin) {
-						scopeValid = true
-						break
-					}
-				}
-
-				if scopeValid {
-					roles = append(roles, s)
-				} else {
-					err := errors.Wrapf(ErrForbidden, "invalid scope '%s'", s)
-					return Token{}, err
-				}
-			}
-		} else {
-			roles = account.Roles
-		}
-
-		if len(roles) == 0 {
-			err := errors.Wrapf(ErrForbidden, "no roles defined for user")
-			return Token{}, err
-		}
+	roles, err := scopeRoles(account.Roles, scopes...)
+	if err != nil {
+		return Token{}, err
 	}
 
-	var claimPref auth.ClaimPreferences
-	{
-		// Set the timezone if one is specifically set on the user.
-		var tz *time.Location
-		if account.UserTimezone.Valid && account.UserTimezone.String != "" {
-			tz, _ = time.LoadLocation(account.UserTimezone.String)
-		}
-
-		// If user timezone failed to parse or none is set, check the timezone set on the account.
-		if tz == nil && account.AccountTimezone.Valid && account.AccountTimezone.String != "" {
-			tz, _ = time.LoadLocation(account.AccountTimezone.String)
-		}
-
-		prefs, err := repo.AccountPreference.FindByAccountID(ctx, auth.Claims{}, account_preference.AccountPreferenceFindByAccountIDRequest{
-			AccountID: accountID,
-		})
-		if err != nil {
-			return Token{}, err
-		}
-
-		var (
-			preferenceDatetimeFormat string
-			preferenceDateFormat     string
-			preferenceTimeFormat     string
-		)
-
-		for _, pref := range prefs {
-			switch pref.Name {
-			case account_preference.AccountPreference_Datetime_Format:
-				preferenceDatetimeFormat = pref.Value
-			case account_preference.AccountPreference_Date_Format:
-				preferenceDateFormat = pref.Value
-			case account_preference.AccountPreference_Time_Format:
-				preferenceTimeFormat = pref.Value
-			}
-		}
-
-		if preferenceDatetimeFormat == "" {
-			preferenceDatetimeFormat = account_preference.AccountPreference_Datetime_Format_Default
-		}
-		if preferenceDateFormat == "" {
-			preferenceDateFormat = account_preference.AccountPreference_Date_Format_Default
-		}
-		if preferenceTimeFormat == "" {
-			preferenceTimeFormat = account_preference.AccountPreference_Time_Format_Default
-		}
+	claimPref, err := repo.claimPreferences(ctx, accountID, account.UserTimezone, account.AccountTimezone)
+	if err != nil {
+		return Token{}, err
+	}
 
-		claimPref = auth.NewClaimPreferences(tz, preferenceDatetimeFormat, preferenceDateFormat, preferenceTimeFormat)
+	// Resolve the permissions granted by the roles with the sets assigned for the account.
+	permSets, err := repo.AccountRole.PermissionSets(ctx, accountID)
+	if err != nil {
+		return Token{}, err
 	}
 
 	// Ensure the current claims has the root values set.
@@ -388,13 +668,23 @@ func (repo *Repository) generateToken(ctx context.Context, claims auth.Claims, u
 	// 	Audience: The ID of the account the user is accessing. A list of account IDs
 	// 			  will also be included to support the user switching between them.
 	newClaims := auth.NewClaims(userID, accountID, accountIds, roles, claimPref, now, expires)
+	newClaims.Permissions = permSets.Permissions(roles...)
 
 	// Copy the original root account/user ID.
 	newClaims.RootAccountID = claims.RootAccountID
 	newClaims.RootUserID = claims.RootUserID
 
+	// Keep the token linked to the session it was issued for.
+	newClaims.SessionID = claims.SessionID
+	newClaims.TwoFactor = claims.TwoFactor
+
 	// Generate a token for the user with the defined claims.
-	tknStr, err := repo.TknGen.GenerateToken(newClaims)
+	return repo.newToken(newClaims, expires, now)
+}
+
+// newToken signs the claims and returns the token used for authentication.
+func (repo *Repository) newToken(claims auth.Claims, expires time.Duration, now time.Time) (Token, error) {
+	tknStr, err := repo.TknGen.GenerateToken(claims)
 	if err != nil {
 		return Token{}, errors.Wrap(err, "generating token")
 	}
@@ -402,9 +692,9 @@ func (repo *Repository) generateToken(ctx context.Context, claims auth.Claims, u
 	tkn := Token{
 		AccessToken: tknStr,
 		TokenType:   "Bearer",
-		claims:      newClaims,
-		UserID:      newClaims.Subject,
-		AccountID:   newClaims.Audience,
+		claims:      claims,
+		UserID:      claims.Subject,
+		AccountID:   claims.Audience,
 	}
 
 	if expires.Seconds() > 0 {
@@ -414,3 +704,118 @@ func (repo *Repository) generateToken(ctx context.Context, claims auth.Claims, u
 
 	return tkn, nil
 }
+
+// scopeRoles returns the roles for the requested scopes. Each scope must be one of the granted
+// roles, an admin may also limit their role to user. When no scopes are requested all the
+// granted roles are returned.
+func scopeRoles(granted []string, scopes ...string) ([]string, error) {
+	var roles []string
+	if len(scopes) > 0 && scopes[0] != "" {
+		// Parse scopes, handle when one value has a list of scopes
+		// separated by a space.
+		var scopeList []string
+		for _, vs := range scopes {
+			for _, v := range strings.Split(vs, " ") {
+				v = strings.TrimSpace(v)
+				if v == "" {
+					continue
+				}
+				scopeList = append(scopeList, v)
+			}
+		}
+
+		for _, s := range scopeList {
+			var scopeValid bool
+			for _, r := range granted {
+				if r == s || (s == auth.RoleUser && r == auth.RoleAdmin) {
+					scopeValid = true
+					break
+				}
+			}
+
+			if scopeValid {
+				roles = append(roles, s)
+			} else {
+				err := errors.Wrapf(ErrForbidden, "invalid scope '%s'", s)
+				return nil, err
+			}
+		}
+	} else {
+		roles = granted
+	}
+
+	if len(roles) == 0 {
+		err := errors.Wrapf(ErrForbidden, "no roles defined for user")
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// requiresTwoFactor returns true when the account requires all its users to sign in with a
+// two-factor authentication code.
+func (repo *Repository) requiresTwoFactor(ctx context.Context, accountID string) (bool, error) {
+	pref, err := repo.AccountPreference.Read(ctx, auth.Claims{}, account_preference.AccountPreferenceReadRequest{
+		AccountID: accountID,
+		Name:      account_preference.AccountPreference_Require_Two_Factor,
+	})
+	if err != nil {
+		if errors.Cause(err) == account_preference.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return pref.Value == "true", nil
+}
+
+// claimPreferences loads the preferences of the account used to format values for display. The
+// timezone of the user takes precedence over the one of the account.
+func (repo *Repository) claimPreferences(ctx context.Context, accountID string, userTimezone, accountTimezone sql.NullString) (auth.ClaimPreferences, error) {
+	// Set the timezone if one is specifically set on the user.
+	var tz *time.Location
+	if userTimezone.Valid && userTimezone.String != "" {
+		tz, _ = time.LoadLocation(userTimezone.String)
+	}
+
+	// If user timezone failed to parse or none is set, check the timezone set on the account.
+	if tz == nil && accountTimezone.Valid && accountTimezone.String != "" {
+		tz, _ = time.LoadLocation(accountTimezone.String)
+	}
+
+	prefs, err := repo.AccountPreference.FindByAccountID(ctx, auth.Claims{}, account_preference.AccountPreferenceFindByAccountIDRequest{
+		AccountID: accountID,
+	})
+	if err != nil {
+		return auth.ClaimPreferences{}, err
+	}
+
+	var (
+		preferenceDatetimeFormat string
+		preferenceDateFormat     string
+		preferenceTimeFormat     string
+	)
+
+	for _, pref := range prefs {
+		switch pref.Name {
+		case account_preference.AccountPreference_Datetime_Format:
+			preferenceDatetimeFormat = pref.Value
+		case account_preference.AccountPreference_Date_Format:
+			preferenceDateFormat = pref.Value
+		case account_preference.AccountPreference_Time_Format:
+			preferenceTimeFormat = pref.Value
+		}
+	}
+
+	if preferenceDatetimeFormat == "" {
+		preferenceDatetimeFormat = account_preference.AccountPreference_Datetime_Format_Default
+	}
+	if preferenceDateFormat == "" {
+		preferenceDateFormat = account_preference.AccountPreference_Date_Format_Default
+	}
+	if preferenceTimeFormat == "" {
+		preferenceTimeFormat = account_preference.AccountPreference_Time_Format_Default
+	}
+
+	return auth.NewClaimPreferences(tz, preferenceDatetimeFormat, preferenceDateFormat, preferenceTimeFormat), nil
+}
@@ -1,6 +1,7 @@
 package user_auth
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -9,10 +10,16 @@ import (
 
 	"exitor-dapp/internal/account"
 	"exitor-dapp/internal/account/account_preference"
+	"exitor-dapp/internal/account/account_role"
+	"exitor-dapp/internal/account/api_client"
 	"exitor-dapp/internal/platform/auth"
 	"exitor-dapp/internal/platform/tests"
 	"exitor-dapp/internal/user"
 	"exitor-dapp/internal/user_account"
+	"exitor-dapp/internal/user_session"
+	"exitor-dapp/internal/user_totp"
+	"exitor-dapp/internal/wallet"
+	"github.com/algorand/go-algorand-sdk/crypto"
 	"github.com/google/go-cmp/cmp"
 	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
@@ -37,8 +44,13 @@ func testMain(m *testing.M) int {
 	userRepo := user.MockRepository(test.MasterDB)
 	userAccRepo := user_account.NewRepository(test.MasterDB)
 	accPrefRepo := account_preference.NewRepository(test.MasterDB)
+	accRoleRepo := account_role.NewRepository(test.MasterDB)
+	walletRepo := wallet.NewRepository(test.MasterDB)
+	apiClientRepo := api_client.NewRepository(test.MasterDB)
+	userSessionRepo := user_session.NewRepository(test.MasterDB)
+	userTotpRepo := user_totp.NewRepository(test.MasterDB)
 
-	repo = NewRepository(test.MasterDB, tknGen, userRepo, userAccRepo, accPrefRepo)
+	repo = NewRepository(test.MasterDB, tknGen, userRepo, userAccRepo, accPrefRepo, accRoleRepo, walletRepo, apiClientRepo, userSessionRepo, userTotpRepo)
 
 	return m.Run()
 }
@@ -173,6 +185,522 @@ func TestAuthenticate(t *testing.T) {
 	}
 }
 
+// TestAuthenticateWallet validates a user authenticating with the signature of a challenge made
+// by the key of an Algorand address they have verified.
+func TestAuthenticateWallet(t *testing.T) {
+	defer tests.Recover(t)
+
+	t.Log("Given the need to authenticate users with their Algorand address")
+	{
+		ctx := tests.Context()
+
+		now := time.Now().Add(time.Hour * -1)
+
+		usrAcc, err := user_account.MockUserAccount(ctx, test.MasterDB, now, user_account.UserAccountRole_User)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate user account failed.", tests.Failed)
+		}
+
+		key := crypto.GenerateAccount()
+
+		// sign returns the base64 signature of the challenge with the key.
+		sign := func(t *testing.T, key crypto.Account, challenge *wallet.WalletAddressChallenge) string {
+			sig, err := crypto.SignBytes(key.PrivateKey, []byte(challenge.Message))
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tSignBytes failed.", tests.Failed)
+			}
+			return base64.StdEncoding.EncodeToString(sig)
+		}
+
+		linked, err := repo.Wallet.Create(ctx, auth.Claims{}, wallet.WalletAddressCreateRequest{
+			AccountID: usrAcc.AccountID,
+			UserID:    &usrAcc.UserID,
+			Address:   key.Address.String(),
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate wallet address failed.", tests.Failed)
+		}
+
+		// An address that has not been verified can't be used to authenticate.
+		challenge, err := repo.WalletChallenge(ctx, WalletChallengeRequest{Address: key.Address.String()}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tWalletChallenge failed.", tests.Failed)
+		}
+
+		_, err = repo.AuthenticateWallet(ctx, AuthenticateWalletRequest{
+			Address:   key.Address.String(),
+			Signature: sign(t, key, challenge),
+		}, time.Hour, now)
+		if errors.Cause(err) != ErrAuthenticationFailure {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrAuthenticationFailure)
+			t.Fatalf("\t%s\tAuthenticate with an unverified address should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticate with an unverified address rejected.", tests.Success)
+
+		verifyChallenge, err := repo.Wallet.Challenge(ctx, auth.Claims{}, linked.ID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tChallenge failed.", tests.Failed)
+		}
+
+		_, err = repo.Wallet.Verify(ctx, auth.Claims{}, wallet.WalletAddressVerifyRequest{
+			ID:        linked.ID,
+			Signature: sign(t, key, verifyChallenge),
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tVerify failed.", tests.Failed)
+		}
+
+		challenge, err = repo.WalletChallenge(ctx, WalletChallengeRequest{Address: key.Address.String()}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tWalletChallenge failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tWalletChallenge ok.", tests.Success)
+
+		_, err = repo.AuthenticateWallet(ctx, AuthenticateWalletRequest{
+			Address:   key.Address.String(),
+			Signature: sign(t, crypto.GenerateAccount(), challenge),
+		}, time.Hour, now)
+		if errors.Cause(err) != ErrAuthenticationFailure {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrAuthenticationFailure)
+			t.Fatalf("\t%s\tAuthenticate signed by another key should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticate signed by another key rejected.", tests.Success)
+
+		tkn, err := repo.AuthenticateWallet(ctx, AuthenticateWalletRequest{
+			Address:   key.Address.String(),
+			Signature: sign(t, key, challenge),
+		}, time.Hour, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tAuthenticateWallet failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticateWallet ok.", tests.Success)
+
+		claims, err := repo.TknGen.ParseClaims(tkn.AccessToken)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tParse claims from token failed.", tests.Failed)
+		} else if claims.Subject != usrAcc.UserID || claims.Audience != usrAcc.AccountID {
+			t.Logf("\t\tGot : %s / %s", claims.Subject, claims.Audience)
+			t.Logf("\t\tWant: %s / %s", usrAcc.UserID, usrAcc.AccountID)
+			t.Fatalf("\t%s\tExpected the token to be issued for the user of the address.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticateWallet parse claims from token ok.", tests.Success)
+
+		_, err = repo.AuthenticateWallet(ctx, AuthenticateWalletRequest{
+			Address:   key.Address.String(),
+			Signature: sign(t, key, challenge),
+		}, time.Hour, now)
+		if errors.Cause(err) != ErrAuthenticationFailure {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrAuthenticationFailure)
+			t.Fatalf("\t%s\tAuthenticate with a used challenge should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticate with a used challenge rejected.", tests.Success)
+	}
+}
+
+// TestAuthenticateClient validates an api client authenticating with its secret for the
+// account that owns it.
+func TestAuthenticateClient(t *testing.T) {
+	defer tests.Recover(t)
+
+	t.Log("Given the need to authenticate api clients")
+	{
+		ctx := tests.Context()
+
+		now := time.Now().Add(time.Hour * -1)
+
+		acc, err := account.MockAccount(ctx, test.MasterDB, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+		}
+
+		client, err := repo.ApiClient.Create(ctx, auth.Claims{}, api_client.ApiClientCreateRequest{
+			AccountID: acc.ID,
+			Name:      "Payouts Service",
+			Scopes:    []string{auth.RoleUser},
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate api client failed.", tests.Failed)
+		}
+
+		_, err = repo.AuthenticateClient(ctx, AuthenticateClientRequest{
+			ClientID:     client.ID,
+			ClientSecret: "NeverTellSecret",
+		}, time.Hour, now)
+		if errors.Cause(err) != ErrAuthenticationFailure {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrAuthenticationFailure)
+			t.Fatalf("\t%s\tAuthenticate with a wrong secret should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticate with a wrong secret rejected.", tests.Success)
+
+		_, err = repo.AuthenticateClient(ctx, AuthenticateClientRequest{
+			ClientID:     client.ID,
+			ClientSecret: client.ClientSecret,
+		}, time.Hour, now, auth.RoleAdmin)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tAuthenticate with a scope not granted to the client should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticate with a scope not granted to the client rejected.", tests.Success)
+
+		tkn, err := repo.AuthenticateClient(ctx, AuthenticateClientRequest{
+			ClientID:     client.ID,
+			ClientSecret: client.ClientSecret,
+		}, time.Hour, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tAuthenticateClient failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticateClient ok.", tests.Success)
+
+		claims, err := repo.TknGen.ParseClaims(tkn.AccessToken)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tParse claims from token failed.", tests.Failed)
+		} else if !claims.IsClient() || claims.Audience != acc.ID || !claims.HasRole(auth.RoleUser) || claims.HasRole(auth.RoleAdmin) {
+			t.Logf("\t\tGot : %+v", claims)
+			t.Fatalf("\t%s\tExpected the token to be issued for the account with the scopes of the client.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticateClient parse claims from token ok.", tests.Success)
+	}
+}
+
+// TestRefresh validates a session started at login being refreshed with its refresh token and
+// refresh being rejected once the user has been removed from the account.
+func TestRefresh(t *testing.T) {
+	defer tests.Recover(t)
+
+	t.Log("Given the need to refresh the access token of a session")
+	{
+		ctx := tests.Context()
+
+		now := time.Now().Add(time.Hour * -1)
+
+		usrAcc, err := user_account.MockUserAccount(ctx, test.MasterDB, now, user_account.UserAccountRole_Admin)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate user account failed.", tests.Failed)
+		}
+
+		tkn, err := repo.Authenticate(ctx, AuthenticateRequest{
+			Email:    usrAcc.User.Email,
+			Password: usrAcc.User.Password,
+		}, AccessTokenTTL, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tAuthenticate failed.", tests.Failed)
+		}
+
+		tkn, err = repo.StartSession(ctx, tkn, SessionRequest{
+			UserAgent: "Mozilla/5.0",
+			IPAddress: "102.68.78.10",
+		}, time.Hour*36, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tStartSession failed.", tests.Failed)
+		} else if tkn.RefreshToken == "" {
+			t.Fatalf("\t%s\tExpected the token to include a refresh token.", tests.Failed)
+		}
+
+		claims, err := repo.TknGen.ParseClaims(tkn.AccessToken)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tParse claims from token failed.", tests.Failed)
+		} else if claims.SessionID == "" {
+			t.Logf("\t\tGot : %+v", claims)
+			t.Fatalf("\t%s\tExpected the claims to include the session.", tests.Failed)
+		}
+		t.Logf("\t%s\tStartSession ok.", tests.Success)
+
+		// Claims issued for another session can't be refreshed with the token.
+		otherClaims := claims
+		otherClaims.SessionID = uuid.NewRandom().String()
+		_, err = repo.Refresh(ctx, otherClaims, RefreshRequest{RefreshToken: tkn.RefreshToken}, AccessTokenTTL, now.Add(time.Minute))
+		if errors.Cause(err) != ErrAuthenticationFailure {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrAuthenticationFailure)
+			t.Fatalf("\t%s\tRefresh with the claims of another session should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tRefresh with the claims of another session rejected.", tests.Success)
+
+		// The refresh token was consumed by the rejected request, start a new session.
+		tkn, err = repo.StartSession(ctx, tkn, SessionRequest{}, time.Hour*36, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tStartSession failed.", tests.Failed)
+		}
+		claims, err = repo.TknGen.ParseClaims(tkn.AccessToken)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tParse claims from token failed.", tests.Failed)
+		}
+
+		refreshed, err := repo.Refresh(ctx, claims, RefreshRequest{RefreshToken: tkn.RefreshToken}, AccessTokenTTL, now.Add(time.Minute))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRefresh failed.", tests.Failed)
+		} else if refreshed.RefreshToken == "" || refreshed.RefreshToken == tkn.RefreshToken {
+			t.Fatalf("\t%s\tExpected a new refresh token.", tests.Failed)
+		}
+
+		refreshedClaims, err := repo.TknGen.ParseClaims(refreshed.AccessToken)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tParse claims from token failed.", tests.Failed)
+		} else if refreshedClaims.SessionID != claims.SessionID || refreshedClaims.Subject != usrAcc.UserID || refreshedClaims.Audience != usrAcc.AccountID {
+			t.Logf("\t\tGot : %+v", refreshedClaims)
+			t.Fatalf("\t%s\tExpected the token to be issued for the same session, user and account.", tests.Failed)
+		}
+		t.Logf("\t%s\tRefresh ok.", tests.Success)
+
+		// Archive the user from the account, the session can no longer be refreshed.
+		err = repo.UserAccount.Archive(ctx, auth.Claims{}, user_account.UserAccountArchiveRequest{
+			UserID:    usrAcc.UserID,
+			AccountID: usrAcc.AccountID,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tArchive user account failed.", tests.Failed)
+		}
+
+		_, err = repo.Refresh(ctx, refreshedClaims, RefreshRequest{RefreshToken: refreshed.RefreshToken}, AccessTokenTTL, now.Add(time.Minute*2))
+		if errors.Cause(err) != ErrAuthenticationFailure {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrAuthenticationFailure)
+			t.Fatalf("\t%s\tRefresh after the user was removed from the account should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tRefresh after the user was removed from the account rejected.", tests.Success)
+	}
+}
+
+// TestAuthenticateRolePermissions validates tokens are granted the permissions assigned to the
+// roles of the account in place of their defaults.
+func TestAuthenticateRolePermissions(t *testing.T) {
+	defer tests.Recover(t)
+
+	t.Log("Given the need to grant the permissions assigned to a role for the account")
+	{
+		ctx := tests.Context()
+
+		now := time.Now().Add(time.Hour * -1)
+
+		usrAcc, err := user_account.MockUserAccount(ctx, test.MasterDB, now, user_account.UserAccountRole_Auditor)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate user account failed.", tests.Failed)
+		}
+
+		// claims authenticates the user and returns the claims of the token.
+		claims := func(t *testing.T) auth.Claims {
+			tkn, err := repo.Authenticate(ctx, AuthenticateRequest{
+				Email:    usrAcc.User.Email,
+				Password: usrAcc.User.Password,
+			}, AccessTokenTTL, now)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tAuthenticate failed.", tests.Failed)
+			}
+
+			claims, err := repo.TknGen.ParseClaims(tkn.AccessToken)
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tParse claims from token failed.", tests.Failed)
+			}
+			return claims
+		}
+
+		defaults := auth.RolePermissionSets(nil).Permissions(auth.RoleAuditor)
+		if got := claims(t); !cmp.Equal(got.Permissions, defaults) || got.HasPermission(auth.PermissionAssetMint) {
+			t.Logf("\t\tGot : %v", got.Permissions)
+			t.Logf("\t\tWant: %v", defaults)
+			t.Fatalf("\t%s\tExpected the default permissions of the role.", tests.Failed)
+		}
+		t.Logf("\t%s\tDefault permissions ok.", tests.Success)
+
+		custom := []string{auth.PermissionAssetRead, auth.PermissionAssetMint}
+		err = repo.AccountRole.Set(ctx, auth.Claims{}, account_role.AccountRoleSetRequest{
+			AccountID:   usrAcc.AccountID,
+			Role:        auth.RoleAuditor,
+			Permissions: custom,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSet account role failed.", tests.Failed)
+		}
+
+		if got := claims(t); !cmp.Equal(got.Permissions, custom) || !got.HasPermission(auth.PermissionAssetMint) || got.HasPermission(auth.PermissionAccountRead) {
+			t.Logf("\t\tGot : %v", got.Permissions)
+			t.Logf("\t\tWant: %v", custom)
+			t.Fatalf("\t%s\tExpected the permissions assigned to the role.", tests.Failed)
+		}
+		t.Logf("\t%s\tCustom permissions ok.", tests.Success)
+
+		err = repo.AccountRole.Delete(ctx, auth.Claims{}, account_role.AccountRoleDeleteRequest{
+			AccountID: usrAcc.AccountID,
+			Role:      auth.RoleAuditor,
+		})
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tDelete account role failed.", tests.Failed)
+		}
+
+		if got := claims(t); !cmp.Equal(got.Permissions, defaults) {
+			t.Logf("\t\tGot : %v", got.Permissions)
+			t.Logf("\t\tWant: %v", defaults)
+			t.Fatalf("\t%s\tExpected the default permissions once the set was removed.", tests.Failed)
+		}
+		t.Logf("\t%s\tRestore default permissions ok.", tests.Success)
+	}
+}
+
+// TestAuthenticateTwoFactor validates accounts requiring two-factor authentication and users that
+// have enrolled having to provide a code to sign in.
+func TestAuthenticateTwoFactor(t *testing.T) {
+	defer tests.Recover(t)
+
+	t.Log("Given the need to sign in with a two-factor authentication code")
+	{
+		ctx := tests.Context()
+
+		now := time.Now().Add(time.Hour * -1)
+
+		usrAcc, err := user_account.MockUserAccount(ctx, test.MasterDB, now, user_account.UserAccountRole_Admin)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate user account failed.", tests.Failed)
+		}
+
+		err = repo.AccountPreference.Set(ctx, auth.Claims{}, account_preference.AccountPreferenceSetRequest{
+			AccountID: usrAcc.AccountID,
+			Name:      account_preference.AccountPreference_Require_Two_Factor,
+			Value:     "true",
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSet account preference failed.", tests.Failed)
+		}
+
+		// The user has not enrolled, a challenge is issued to enroll before signing in.
+		tkn, err := repo.Authenticate(ctx, AuthenticateRequest{
+			Email:    usrAcc.User.Email,
+			Password: usrAcc.User.Password,
+		}, AccessTokenTTL, now)
+		if errors.Cause(err) != ErrTwoFactorEnrollmentRequired {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrTwoFactorEnrollmentRequired)
+			t.Fatalf("\t%s\tAuthenticate without enrollment should fail.", tests.Failed)
+		} else if tkn.TwoFactorChallenge == "" || tkn.AccessToken != "" {
+			t.Logf("\t\tGot : %+v", tkn)
+			t.Fatalf("\t%s\tExpected only a challenge to be issued.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticate without enrollment issued a challenge.", tests.Success)
+
+		totp, err := repo.UserTotp.Enroll(ctx, auth.Claims{}, user_totp.UserTotpEnrollRequest{UserID: usrAcc.UserID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tEnroll failed.", tests.Failed)
+		}
+
+		code, err := user_totp.GenerateCode(totp.Secret, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tGenerateCode failed.", tests.Failed)
+		}
+
+		tkn, err = repo.AuthenticateTwoFactor(ctx, AuthenticateTwoFactorRequest{
+			ChallengeID: tkn.TwoFactorChallenge,
+			Code:        code,
+		}, AccessTokenTTL, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tAuthenticateTwoFactor failed.", tests.Failed)
+		}
+
+		claims, err := repo.TknGen.ParseClaims(tkn.AccessToken)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tParse claims from token failed.", tests.Failed)
+		} else if !claims.TwoFactor || claims.Subject != usrAcc.UserID || claims.Audience != usrAcc.AccountID {
+			t.Logf("\t\tGot : %+v", claims)
+			t.Fatalf("\t%s\tExpected the claims to include the second factor.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticateTwoFactor confirmed enrollment.", tests.Success)
+
+		// Sessions started with a second factor can be refreshed without the claims.
+		tkn, err = repo.StartSession(ctx, tkn, SessionRequest{}, time.Hour*36, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tStartSession failed.", tests.Failed)
+		}
+
+		_, err = repo.Refresh(ctx, auth.Claims{}, RefreshRequest{RefreshToken: tkn.RefreshToken}, AccessTokenTTL, now.Add(time.Minute))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRefresh failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tRefresh ok.", tests.Success)
+
+		// The user has enrolled, a code is required to sign in.
+		_, err = repo.Authenticate(ctx, AuthenticateRequest{
+			Email:    usrAcc.User.Email,
+			Password: usrAcc.User.Password,
+		}, AccessTokenTTL, now)
+		if errors.Cause(err) != ErrTwoFactorRequired {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrTwoFactorRequired)
+			t.Fatalf("\t%s\tAuthenticate without a code should fail.", tests.Failed)
+		}
+
+		// The code used to enroll can't be used again.
+		_, err = repo.Authenticate(ctx, AuthenticateRequest{
+			Email:    usrAcc.User.Email,
+			Password: usrAcc.User.Password,
+			TOTPCode: code,
+		}, AccessTokenTTL, now)
+		if errors.Cause(err) != ErrAuthenticationFailure {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrAuthenticationFailure)
+			t.Fatalf("\t%s\tAuthenticate with a used code should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticate without a valid code rejected.", tests.Success)
+
+		next := now.Add(time.Second * 30)
+		code, err = user_totp.GenerateCode(totp.Secret, next)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tGenerateCode failed.", tests.Failed)
+		}
+
+		tkn, err = repo.Authenticate(ctx, AuthenticateRequest{
+			Email:    usrAcc.User.Email,
+			Password: usrAcc.User.Password,
+			TOTPCode: code,
+		}, AccessTokenTTL, next)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tAuthenticate failed.", tests.Failed)
+		} else if tkn.AccessToken == "" {
+			t.Fatalf("\t%s\tExpected an access token.", tests.Failed)
+		}
+		t.Logf("\t%s\tAuthenticate with a code ok.", tests.Success)
+	}
+}
+
 // TestUserUpdatePassword validates update user password works.
 func TestUserUpdatePassword(t *testing.T) {
 
@@ -4,9 +4,14 @@ import (
 	"time"
 
 	"exitor-dapp/internal/account/account_preference"
+	"exitor-dapp/internal/account/account_role"
+	"exitor-dapp/internal/account/api_client"
 	"exitor-dapp/internal/platform/auth"
 	"exitor-dapp/internal/user"
 	"exitor-dapp/internal/user_account"
+	"exitor-dapp/internal/user_session"
+	"exitor-dapp/internal/user_totp"
+	"exitor-dapp/internal/wallet"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -17,16 +22,26 @@ type Repository struct {
 	User              *user.Repository
 	UserAccount       *user_account.Repository
 	AccountPreference *account_preference.Repository
+	AccountRole       *account_role.Repository
+	Wallet            *wallet.Repository
+	ApiClient         *api_client.Repository
+	UserSession       *user_session.Repository
+	UserTotp          *user_totp.Repository
 }
 
 // NewRepository creates a new Repository that defines dependencies for User Auth.
-func NewRepository(db *sqlx.DB, tknGen TokenGenerator, user *user.Repository, usrAcc *user_account.Repository, accPref *account_preference.Repository) *Repository {
+func NewRepository(db *sqlx.DB, tknGen TokenGenerator, user *user.Repository, usrAcc *user_account.Repository, accPref *account_preference.Repository, accRole *account_role.Repository, walletRepo *wallet.Repository, apiClientRepo *api_client.Repository, sessionRepo *user_session.Repository, totpRepo *user_totp.Repository) *Repository {
 	return &Repository{
 		DbConn:            db,
 		TknGen:            tknGen,
 		User:              user,
 		UserAccount:       usrAcc,
 		AccountPreference: accPref,
+		AccountRole:       accRole,
+		Wallet:            walletRepo,
+		ApiClient:         apiClientRepo,
+		UserSession:       sessionRepo,
+		UserTotp:          totpRepo,
 	}
 }
 
@@ -35,7 +50,29 @@ type AuthenticateRequest struct {
 	Email     string `json:"email" validate:"required,email" example:"kcelestinomaria@malibia.com"`
 	Password  string `json:"password" validate:"required" example:"NeverTellSecret"`
 	AccountID string `json:"account_id" validate:"omitempty,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	WalletAddress string `json:"algorand_walletAddress" validate:"required" example:"0jjgue2u221sbsj..."// `
+	TOTPCode  string `json:"totp_code" validate:"omitempty,max=20" example:"123456"`
+}
+
+// WalletChallengeRequest defines what information is required to issue the message a user signs
+// with the key of their Algorand address to authenticate.
+type WalletChallengeRequest struct {
+	Address   string `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	AccountID string `json:"account_id" validate:"omitempty,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+}
+
+// AuthenticateWalletRequest defines what information is required to authenticate a user with
+// the signature of the challenge issued for their Algorand address.
+type AuthenticateWalletRequest struct {
+	Address   string `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Signature string `json:"signature" validate:"required,base64" example:"bWVzc2FnZQ=="`
+	AccountID string `json:"account_id" validate:"omitempty,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+}
+
+// AuthenticateTwoFactorRequest defines what information is required to complete a sign in with
+// the two-factor authentication code of a user.
+type AuthenticateTwoFactorRequest struct {
+	ChallengeID string `json:"challenge_id" validate:"required,uuid" example:"5f0f8a4e-8a6c-4b1e-9f3d-2c7b1a0e9d8c"`
+	Code        string `json:"code" validate:"required,max=20" example:"123456"`
 }
 
 // OAuth2PasswordRequest defines what information is required to authenticate a user.
@@ -43,11 +80,47 @@ type OAuth2PasswordRequest struct {
 	Username  string   `json:"username" schema:"username" validate:"required,email" example:"gabi.may@geeksinthewoods.com"`
 	Password  string   `json:"password" schema:"password" validate:"required" example:"NeverTellSecret"`
 	AccountID string   `json:"account_id" schema:"account_id" validate:"omitempty,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
-	WalletAddress string `json:"algorand_walletAddress" schema:"algorand_walletAddress validate:"required" examples:"0eyi1h31m2"`// Added schema for algorand wallet address
-	Scope     []string `json:"scope" schema:"scope" validate:"omitempty,dive,oneof=admin user" enums:"admin,user" swaggertype:"array,string" example:"admin"`
+	TOTPCode  string   `json:"totp_code" schema:"totp_code" validate:"omitempty,max=20" example:"123456"`
+	Scope     []string `json:"scope" schema:"scope" validate:"omitempty,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"admin"`
 	// GrantType string `json:"grant_type" validate:"omitempty" example:"password"`
 }
 
+// AuthenticateClientRequest defines what information is required to authenticate an api client.
+type AuthenticateClientRequest struct {
+	ClientID     string `json:"client_id" validate:"required" example:"0d4f7c5a-6b0e-4d59-9a1f-2f3c1d8e4b7a"`
+	ClientSecret string `json:"client_secret" validate:"required" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// OAuth2ClientCredentialsRequest defines what information is required to authenticate an api
+// client. The credentials can also be provided with basic auth.
+type OAuth2ClientCredentialsRequest struct {
+	ClientID     string   `json:"client_id" schema:"client_id" validate:"required" example:"0d4f7c5a-6b0e-4d59-9a1f-2f3c1d8e4b7a"`
+	ClientSecret string   `json:"client_secret" schema:"client_secret" validate:"required" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Scope        []string `json:"scope" schema:"scope" validate:"omitempty,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"user"`
+}
+
+// SessionRequest defines the information about the device of a user that is recorded when a
+// session is started.
+type SessionRequest struct {
+	UserAgent string `json:"user_agent" validate:"max=500" example:"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_3)"`
+	IPAddress string `json:"ip_address" validate:"omitempty,ip" example:"102.68.78.10"`
+}
+
+// RefreshRequest defines what information is required to exchange a refresh token for a new
+// access token.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required" example:"4c0f5cb2e2b9a8f3d1e6c7b8a9d0e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192"`
+	AccountID    string `json:"account_id" validate:"omitempty,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+}
+
+// OAuth2RefreshTokenRequest defines what information is required to exchange a refresh token
+// for a new access token.
+type OAuth2RefreshTokenRequest struct {
+	RefreshToken string   `json:"refresh_token" schema:"refresh_token" validate:"required" example:"4c0f5cb2e2b9a8f3d1e6c7b8a9d0e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192"`
+	AccountID    string   `json:"account_id" schema:"account_id" validate:"omitempty,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Scope        []string `json:"scope" schema:"scope" validate:"omitempty,dive,oneof=admin user drafter approver compliance auditor" enums:"admin,user,drafter,approver,compliance,auditor" swaggertype:"array,string" example:"user"`
+}
+
 // Token is the payload we deliver to users when they authenticate.
 type Token struct {
 	// AccessToken is the token that authorizes and authenticates
@@ -63,12 +136,18 @@ type Token struct {
 	// mechanisms for that TokenSource will not be used.
 	Expiry time.Time     `json:"expiry,omitempty"`
 	TTL    time.Duration `json:"ttl,omitempty"`
+	// RefreshToken is used to obtain a new access token when it expires. It is only
+	// included when a session was started and changes each time it's used.
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// TwoFactorChallenge is the ID of the challenge to complete with AuthenticateTwoFactor when
+	// a code is required to sign in. It's only set with ErrTwoFactorRequired.
+	TwoFactorChallenge string `json:"-"`
 	// contains filtered or unexported fields
 	claims auth.Claims `json:"-"`
 	// UserId is the ID of the user authenticated.
 	UserID string `json:"user_id" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
 	// AccountID is the ID of the account for the user authenticated.
-	AccountID string `json:"account_id"example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	AccountID string `json:"account_id" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
 }
 
 // SwitchAccountRequest defines the information for the current user to switch between their accounts
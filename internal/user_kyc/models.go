@@ -0,0 +1,180 @@
+package user_kyc
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+
+	"exitor-dapp/internal/platform/web"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// Repository defines the required dependencies for UserKyc.
+type Repository struct {
+	DbConn *sqlx.DB
+}
+
+// NewRepository creates a new Repository that defines dependencies for UserKyc.
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{
+		DbConn: db,
+	}
+}
+
+// UserKyc represents the KYC review of a user by an account. Only users that are approved and
+// whose approval has not expired are whitelisted to hold the default frozen assets of the account.
+type UserKyc struct {
+	UserID     string        `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	AccountID  string        `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Status     UserKycStatus `json:"status" validate:"omitempty,oneof=pending approved rejected revoked" enums:"pending,approved,rejected,revoked" swaggertype:"string" example:"approved"`
+	ExpiresAt  *pq.NullTime  `json:"expires_at,omitempty"`
+	Note       string        `json:"note" validate:"omitempty,max=500" example:"Passport verified"`
+	ReviewedBy *string       `json:"reviewed_by,omitempty" truss:"api-read"`
+	ReviewedAt *pq.NullTime  `json:"reviewed_at,omitempty" truss:"api-read"`
+	CreatedAt  time.Time     `json:"created_at" truss:"api-read"`
+	UpdatedAt  time.Time     `json:"updated_at" truss:"api-read"`
+}
+
+// IsApproved returns true when the user was approved and the approval has not expired.
+func (m *UserKyc) IsApproved(now time.Time) bool {
+	if m == nil || m.Status != UserKycStatus_Approved {
+		return false
+	}
+	return !m.IsExpired(now)
+}
+
+// IsExpired returns true when the approval of the user had an expiry that has passed.
+func (m *UserKyc) IsExpired(now time.Time) bool {
+	return m.ExpiresAt != nil && m.ExpiresAt.Valid && !m.ExpiresAt.Time.After(now)
+}
+
+// UserKycResponse represents the KYC review of a user that is returned for display.
+type UserKycResponse struct {
+	UserID     string            `json:"user_id" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	AccountID  string            `json:"account_id" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Status     web.EnumResponse  `json:"status"` // Status is enum with values [pending, approved, rejected, revoked].
+	Approved   bool              `json:"approved" example:"true"`
+	Expired    bool              `json:"expired" example:"false"`
+	ExpiresAt  *web.TimeResponse `json:"expires_at,omitempty"` // ExpiresAt contains multiple format options for display.
+	Note       string            `json:"note" example:"Passport verified"`
+	ReviewedAt *web.TimeResponse `json:"reviewed_at,omitempty"` // ReviewedAt contains multiple format options for display.
+	UpdatedAt  web.TimeResponse  `json:"updated_at"`            // UpdatedAt contains multiple format options for display.
+}
+
+// Response transforms UserKyc to the UserKycResponse that is used for display.
+// Additional filtering by context values or translations could be applied.
+func (m *UserKyc) Response(ctx context.Context, now time.Time) *UserKycResponse {
+	if m == nil {
+		return nil
+	}
+
+	r := &UserKycResponse{
+		UserID:    m.UserID,
+		AccountID: m.AccountID,
+		Status:    web.NewEnumResponse(ctx, m.Status, UserKycStatus_ValuesInterface()...),
+		Approved:  m.IsApproved(now),
+		Expired:   m.Status == UserKycStatus_Approved && m.IsExpired(now),
+		Note:      m.Note,
+		UpdatedAt: web.NewTimeResponse(ctx, m.UpdatedAt),
+	}
+
+	if m.ExpiresAt != nil && m.ExpiresAt.Valid && !m.ExpiresAt.Time.IsZero() {
+		at := web.NewTimeResponse(ctx, m.ExpiresAt.Time)
+		r.ExpiresAt = &at
+	}
+
+	if m.ReviewedAt != nil && m.ReviewedAt.Valid && !m.ReviewedAt.Time.IsZero() {
+		at := web.NewTimeResponse(ctx, m.ReviewedAt.Time)
+		r.ReviewedAt = &at
+	}
+
+	return r
+}
+
+// UserKycs a list of UserKyc.
+type UserKycs []*UserKyc
+
+// Response transforms a list of UserKyc to a list of UserKycResponse.
+func (m *UserKycs) Response(ctx context.Context, now time.Time) []*UserKycResponse {
+	var l []*UserKycResponse
+	if m != nil && len(*m) > 0 {
+		for _, n := range *m {
+			l = append(l, n.Response(ctx, now))
+		}
+	}
+
+	return l
+}
+
+// UserKycReviewRequest contains information needed to record the KYC review of a user for an
+// account. Approvals can expire, ie: when the documents provided by the user expire.
+type UserKycReviewRequest struct {
+	UserID    string        `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	AccountID string        `json:"account_id" validate:"omitempty,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Status    UserKycStatus `json:"status" validate:"required,oneof=pending approved rejected revoked" enums:"pending,approved,rejected,revoked" swaggertype:"string" example:"approved"`
+	ExpiresAt *time.Time    `json:"expires_at,omitempty" example:"2021-05-16T00:00:00Z"`
+	Note      string        `json:"note" validate:"omitempty,max=500" example:"Passport verified"`
+}
+
+// UserKycStatus represents the status of the KYC review of a user.
+type UserKycStatus string
+
+// UserKycStatus values define the status field of the KYC review of a user.
+const (
+	// UserKycStatus_Pending defines the state when the user is waiting to be reviewed.
+	UserKycStatus_Pending UserKycStatus = "pending"
+	// UserKycStatus_Approved defines the state when the user passed the review and is whitelisted.
+	UserKycStatus_Approved UserKycStatus = "approved"
+	// UserKycStatus_Rejected defines the state when the user failed the review.
+	UserKycStatus_Rejected UserKycStatus = "rejected"
+	// UserKycStatus_Revoked defines the state when the approval of the user was withdrawn.
+	UserKycStatus_Revoked UserKycStatus = "revoked"
+)
+
+// UserKycStatus_Values provides list of valid UserKycStatus values.
+var UserKycStatus_Values = []UserKycStatus{
+	UserKycStatus_Pending,
+	UserKycStatus_Approved,
+	UserKycStatus_Rejected,
+	UserKycStatus_Revoked,
+}
+
+// UserKycStatus_ValuesInterface returns the UserKycStatus options as a slice interface.
+func UserKycStatus_ValuesInterface() []interface{} {
+	var l []interface{}
+	for _, v := range UserKycStatus_Values {
+		l = append(l, v.String())
+	}
+	return l
+}
+
+// Scan supports reading the UserKycStatus value from the database.
+func (s *UserKycStatus) Scan(value interface{}) error {
+	asBytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("Scan source is not []byte")
+	}
+	*s = UserKycStatus(string(asBytes))
+	return nil
+}
+
+// Value converts the UserKycStatus value to be stored in the database.
+func (s UserKycStatus) Value() (driver.Value, error) {
+	v := validator.New()
+
+	errs := v.Var(s, "required,oneof=pending approved rejected revoked")
+	if errs != nil {
+		return nil, errs
+	}
+
+	return string(s), nil
+}
+
+// String converts the UserKycStatus value to a string.
+func (s UserKycStatus) String() string {
+	return string(s)
+}
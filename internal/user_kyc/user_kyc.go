@@ -0,0 +1,298 @@
+package user_kyc
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* Regulated securities can only be held by investors that passed the KYC
+review of the issuing account. The review of each user is recorded per
+account and the approval can expire. Assets that are default frozen use the
+approved users as their whitelist, the holdings of approved users are
+unfrozen and the rest are kept frozen by the freeze manager. */
+
+const (
+	// The database table for the KYC reviews of users
+	UserKycTableName = "user_kyc"
+	// The database table for User Account
+	userAccountTableName = "users_accounts"
+)
+
+var (
+	// ErrNotFound abstracts the postgres not found error.
+	ErrNotFound = errors.New("Entity not found")
+
+	// ErrForbidden occurs when a user tries to do something that is forbidden to them according to our access control policies.
+	ErrForbidden = errors.New("Attempted action is not allowed")
+)
+
+// CanReadUserKyc determines if claims has the authority to view the KYC review of the user for
+// the account. Users can view their own and the users with access to the users of the account
+// can view all of them.
+func (repo *Repository) CanReadUserKyc(ctx context.Context, claims auth.Claims, userID, accountID string) error {
+	// If claims are empty, the request is internal.
+	if claims.Audience == "" && claims.Subject == "" {
+		return nil
+	}
+
+	if claims.Audience != accountID {
+		return errors.WithStack(ErrForbidden)
+	} else if claims.Subject == userID && !claims.IsClient() {
+		return nil
+	} else if !claims.HasPermission(auth.PermissionUserRead, auth.PermissionUserManage, auth.PermissionUserKyc) {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	return nil
+}
+
+// CanReviewUserKyc determines if claims has the authority to review the user for the account.
+// The user must have been added to the account.
+func (repo *Repository) CanReviewUserKyc(ctx context.Context, claims auth.Claims, userID, accountID string) error {
+	// If claims are empty, the request is internal.
+	if claims.Audience == "" && claims.Subject == "" {
+		return nil
+	}
+
+	if claims.Audience != accountID || !claims.HasPermission(auth.PermissionUserKyc) {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	// The user must have a record for the account of the reviewer.
+	// select id from users_accounts where account_id = [claims.Audience] and user_id = [userID]
+	query := sqlbuilder.NewSelectBuilder().Select("id").From(userAccountTableName)
+	query.Where(query.And(
+		query.Equal("account_id", claims.Audience),
+		query.Equal("user_id", userID),
+	))
+	queryStr, args := query.Build()
+	queryStr = repo.DbConn.Rebind(queryStr)
+
+	var userAccountId string
+	err := repo.DbConn.QueryRowContext(ctx, queryStr, args...).Scan(&userAccountId)
+	if err != nil && err != sql.ErrNoRows {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		return err
+	}
+
+	if userAccountId == "" {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	return nil
+}
+
+// userKycMapColumns is the list of columns needed for find.
+var userKycMapColumns = "user_id,account_id,status,expires_at,note,reviewed_by,reviewed_at,created_at,updated_at"
+
+// find internal method for getting the KYC reviews from the database using a select query.
+func find(ctx context.Context, dbConn *sqlx.DB, query *sqlbuilder.SelectBuilder) (UserKycs, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_kyc.Find")
+	defer span.Finish()
+
+	query.Select(userKycMapColumns)
+	query.From(UserKycTableName)
+
+	queryStr, args := query.Build()
+	queryStr = dbConn.Rebind(queryStr)
+
+	// Fetch all entries from the db.
+	rows, err := dbConn.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find user kyc failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Iterate over each row.
+	resp := []*UserKyc{}
+	for rows.Next() {
+		var m UserKyc
+		err = rows.Scan(&m.UserID, &m.AccountID, &m.Status, &m.ExpiresAt, &m.Note, &m.ReviewedBy, &m.ReviewedAt,
+			&m.CreatedAt, &m.UpdatedAt)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+
+		resp = append(resp, &m)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find user kyc failed")
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Read gets the KYC review of the user for the account from the database.
+func (repo *Repository) Read(ctx context.Context, claims auth.Claims, userID, accountID string) (*UserKyc, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_kyc.Read")
+	defer span.Finish()
+
+	// Ensure the claims can view the KYC review of the user.
+	err := repo.CanReadUserKyc(ctx, claims, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("user_id", userID),
+		query.Equal("account_id", accountID),
+	))
+
+	res, err := find(ctx, repo.DbConn, query)
+	if err != nil {
+		return nil, err
+	} else if len(res) == 0 {
+		err = errors.WithMessagef(ErrNotFound, "kyc for user %s not found", userID)
+		return nil, err
+	}
+
+	return res[0], nil
+}
+
+// FindByAccountID gets the KYC reviews of all the users of the account.
+func (repo *Repository) FindByAccountID(ctx context.Context, claims auth.Claims, accountID string) (UserKycs, error) {
+	if claims.Audience != "" {
+		if claims.Audience != accountID || !claims.HasPermission(auth.PermissionUserRead, auth.PermissionUserManage, auth.PermissionUserKyc) {
+			return nil, errors.WithStack(ErrForbidden)
+		}
+	}
+
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("account_id", accountID))
+	query.OrderBy("updated_at desc")
+
+	return find(ctx, repo.DbConn, query)
+}
+
+// ApprovedUserIDs returns the set of users of the account that are approved and whose approval
+// has not expired. It's the whitelist for the default frozen assets of the account.
+func ApprovedUserIDs(ctx context.Context, dbConn *sqlx.DB, accountID string, now time.Time) (map[string]bool, error) {
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("account_id", accountID),
+		query.Equal("status", UserKycStatus_Approved.String()),
+		query.Or(
+			query.IsNull("expires_at"),
+			query.GreaterThan("expires_at", now),
+		),
+	))
+
+	res, err := find(ctx, dbConn, query)
+	if err != nil {
+		return nil, err
+	}
+
+	approved := make(map[string]bool)
+	for _, m := range res {
+		approved[m.UserID] = true
+	}
+
+	return approved, nil
+}
+
+// Review records the KYC status of the user for the account. Approving the user whitelists them
+// for the default frozen assets of the account until the approval expires, rejecting or revoking
+// it causes their holdings to be frozen again.
+func (repo *Repository) Review(ctx context.Context, claims auth.Claims, req UserKycReviewRequest, now time.Time) (*UserKyc, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_kyc.Review")
+	defer span.Finish()
+
+	if claims.Audience != "" {
+		if req.AccountID != "" {
+			// Request accountId must match claims.
+			if req.AccountID != claims.Audience {
+				return nil, errors.WithStack(ErrForbidden)
+			}
+		} else {
+			// Set the accountId from claims.
+			req.AccountID = claims.Audience
+		}
+	}
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can review the user.
+	err = repo.CanReviewUserKyc(ctx, claims, req.UserID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	m := UserKyc{
+		UserID:     req.UserID,
+		AccountID:  req.AccountID,
+		Status:     req.Status,
+		Note:       req.Note,
+		ReviewedAt: &pq.NullTime{Time: now, Valid: true},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	// Only an approval can expire.
+	if req.ExpiresAt != nil && req.Status == UserKycStatus_Approved {
+		m.ExpiresAt = &pq.NullTime{Time: req.ExpiresAt.UTC().Truncate(time.Millisecond), Valid: true}
+	}
+
+	if claims.Subject != "" {
+		m.ReviewedBy = &claims.Subject
+	}
+
+	// Build the insert SQL statement, the review replaces the previous one of the user.
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(UserKycTableName)
+	query.Cols("user_id", "account_id", "status", "expires_at", "note", "reviewed_by", "reviewed_at", "created_at", "updated_at")
+	query.Values(m.UserID, m.AccountID, m.Status, m.ExpiresAt, m.Note, m.ReviewedBy, m.ReviewedAt, m.CreatedAt, m.UpdatedAt)
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	sql = sql + " ON CONFLICT (user_id, account_id) DO UPDATE set status = EXCLUDED.status, expires_at = EXCLUDED.expires_at, " +
+		"note = EXCLUDED.note, reviewed_by = EXCLUDED.reviewed_by, reviewed_at = EXCLUDED.reviewed_at, updated_at = EXCLUDED.updated_at"
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "review kyc for user %s failed", m.UserID)
+		return nil, err
+	}
+
+	return repo.Read(ctx, auth.Claims{}, m.UserID, m.AccountID)
+}
@@ -0,0 +1,173 @@
+package user_kyc
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/tests"
+	"exitor-dapp/internal/user_account"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+var test *tests.Test
+
+// TestMain is the entry point for testing.
+func TestMain(m *testing.M) {
+	os.Exit(testMain(m))
+}
+
+func testMain(m *testing.M) int {
+	test = tests.New()
+	defer test.TearDown()
+	return m.Run()
+}
+
+// TestReview validates the KYC review of a user by the compliance officers of the account and
+// the approval expiring.
+func TestReview(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.May, 16, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	repo := NewRepository(test.MasterDB)
+
+	usrAcc, err := user_account.MockUserAccount(ctx, test.MasterDB, now, user_account.UserAccountRole_User)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockUserAccount failed.", tests.Failed)
+	}
+
+	userClaims := auth.Claims{
+		Roles: []string{auth.RoleUser},
+		StandardClaims: jwt.StandardClaims{
+			Audience: usrAcc.AccountID,
+			Subject:  usrAcc.UserID,
+		},
+	}
+	approverClaims := auth.Claims{
+		Roles: []string{auth.RoleApprover},
+		StandardClaims: jwt.StandardClaims{
+			Audience: usrAcc.AccountID,
+			Subject:  uuid.NewRandom().String(),
+		},
+	}
+	complianceClaims := auth.Claims{
+		Roles: []string{auth.RoleCompliance},
+		StandardClaims: jwt.StandardClaims{
+			Audience: usrAcc.AccountID,
+			Subject:  uuid.NewRandom().String(),
+		},
+	}
+	otherClaims := auth.Claims{
+		Roles: []string{auth.RoleCompliance},
+		StandardClaims: jwt.StandardClaims{
+			Audience: uuid.NewRandom().String(),
+			Subject:  uuid.NewRandom().String(),
+		},
+	}
+
+	t.Log("Given the need to review the KYC status of a user.")
+	{
+		_, err = repo.Read(ctx, userClaims, usrAcc.UserID, usrAcc.AccountID)
+		if errors.Cause(err) != ErrNotFound {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrNotFound)
+			t.Fatalf("\t%s\tRead before a review should fail.", tests.Failed)
+		}
+
+		expires := now.Add(time.Hour * 24 * 365)
+		req := UserKycReviewRequest{
+			UserID:    usrAcc.UserID,
+			Status:    UserKycStatus_Approved,
+			ExpiresAt: &expires,
+			Note:      "Passport verified",
+		}
+
+		for _, claims := range []auth.Claims{userClaims, approverClaims, otherClaims} {
+			_, err = repo.Review(ctx, claims, req, now)
+			if errors.Cause(err) != ErrForbidden {
+				t.Logf("\t\tGot : %+v", err)
+				t.Logf("\t\tWant: %+v", ErrForbidden)
+				t.Fatalf("\t%s\tReview by %v should fail.", tests.Failed, claims.Roles)
+			}
+		}
+
+		_, err = repo.Review(ctx, complianceClaims, UserKycReviewRequest{
+			UserID: uuid.NewRandom().String(),
+			Status: UserKycStatus_Approved,
+		}, now)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tReview of a user not in the account should fail.", tests.Failed)
+		}
+
+		m, err := repo.Review(ctx, complianceClaims, req, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReview failed.", tests.Failed)
+		} else if !m.IsApproved(now) || m.AccountID != usrAcc.AccountID {
+			t.Logf("\t\tGot : %+v", m)
+			t.Fatalf("\t%s\tExpected the user to be approved.", tests.Failed)
+		} else if m.ReviewedBy == nil || *m.ReviewedBy != complianceClaims.Subject {
+			t.Logf("\t\tGot : %v", m.ReviewedBy)
+			t.Fatalf("\t%s\tExpected the reviewer to be recorded.", tests.Failed)
+		}
+		t.Logf("\t%s\tReview ok.", tests.Success)
+
+		// Users can view their own status.
+		_, err = repo.Read(ctx, userClaims, usrAcc.UserID, usrAcc.AccountID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRead by the user failed.", tests.Failed)
+		}
+
+		approved, err := ApprovedUserIDs(ctx, test.MasterDB, usrAcc.AccountID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tApprovedUserIDs failed.", tests.Failed)
+		} else if !approved[usrAcc.UserID] {
+			t.Logf("\t\tGot : %v", approved)
+			t.Fatalf("\t%s\tExpected the user to be whitelisted.", tests.Failed)
+		}
+
+		// The approval ends once it has expired.
+		approved, err = ApprovedUserIDs(ctx, test.MasterDB, usrAcc.AccountID, expires)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tApprovedUserIDs failed.", tests.Failed)
+		} else if approved[usrAcc.UserID] || m.IsApproved(expires) {
+			t.Fatalf("\t%s\tExpected the expired user to not be whitelisted.", tests.Failed)
+		}
+		t.Logf("\t%s\tApprovedUserIDs ok.", tests.Success)
+
+		m, err = repo.Review(ctx, complianceClaims, UserKycReviewRequest{
+			UserID: usrAcc.UserID,
+			Status: UserKycStatus_Revoked,
+			Note:   "Sanctions list match",
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReview failed.", tests.Failed)
+		} else if m.Status != UserKycStatus_Revoked || m.ExpiresAt != nil && m.ExpiresAt.Valid {
+			t.Logf("\t\tGot : %+v", m)
+			t.Fatalf("\t%s\tExpected the approval to be revoked.", tests.Failed)
+		}
+
+		approved, err = ApprovedUserIDs(ctx, test.MasterDB, usrAcc.AccountID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tApprovedUserIDs failed.", tests.Failed)
+		} else if approved[usrAcc.UserID] {
+			t.Fatalf("\t%s\tExpected the revoked user to not be whitelisted.", tests.Failed)
+		}
+		t.Logf("\t%s\tRevoke ok.", tests.Success)
+	}
+}
@@ -0,0 +1,135 @@
+package user_session
+
+import (
+	"context"
+	"time"
+
+	"exitor-dapp/internal/platform/web"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Repository defines the required dependencies for UserSession.
+type Repository struct {
+	DbConn *sqlx.DB
+}
+
+// NewRepository creates a new Repository that defines dependencies for UserSession.
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{
+		DbConn: db,
+	}
+}
+
+// UserSession represents a device a user has signed in from. Access tokens are short lived and
+// renewed with the refresh token of the session, revoking the session stops it from being renewed.
+type UserSession struct {
+	ID           string       `json:"id" validate:"required,uuid" example:"8e2f1d0c-3b4a-4c5d-9e6f-7a8b9c0d1e2f"`
+	UserID       string       `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	UserAgent    string       `json:"user_agent" validate:"max=500" example:"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_3)"`
+	IPAddress    string       `json:"ip_address" validate:"omitempty,ip" example:"102.68.78.10"`
+	TwoFactor    bool         `json:"two_factor" example:"true"`
+	ExpiresAt    time.Time    `json:"expires_at" truss:"api-read"`
+	LastUsedAt   *pq.NullTime `json:"last_used_at,omitempty" truss:"api-read"`
+	RevokedAt    *pq.NullTime `json:"revoked_at,omitempty" truss:"api-hide"`
+	CreatedAt    time.Time    `json:"created_at" truss:"api-read"`
+	UpdatedAt    time.Time    `json:"updated_at" truss:"api-read"`
+	RefreshToken string       `json:"-" truss:"api-hide"`
+}
+
+// IsRevoked returns true when the session has been revoked.
+func (m *UserSession) IsRevoked() bool {
+	return m.RevokedAt != nil && m.RevokedAt.Valid && !m.RevokedAt.Time.IsZero()
+}
+
+// IsActive returns true when the refresh token of the session can still be used.
+func (m *UserSession) IsActive(now time.Time) bool {
+	return !m.IsRevoked() && now.Before(m.ExpiresAt)
+}
+
+// UserSessionResponse represents a user session that is returned for display.
+type UserSessionResponse struct {
+	ID         string            `json:"id" example:"8e2f1d0c-3b4a-4c5d-9e6f-7a8b9c0d1e2f"`
+	UserID     string            `json:"user_id" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	UserAgent  string            `json:"user_agent" example:"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_3)"`
+	IPAddress  string            `json:"ip_address" example:"102.68.78.10"`
+	Current    bool              `json:"current" example:"true"`
+	ExpiresAt  web.TimeResponse  `json:"expires_at"`             // ExpiresAt contains multiple format options for display.
+	LastUsedAt *web.TimeResponse `json:"last_used_at,omitempty"` // LastUsedAt contains multiple format options for display.
+	CreatedAt  web.TimeResponse  `json:"created_at"`             // CreatedAt contains multiple format options for display.
+}
+
+// Response transforms UserSession to the UserSessionResponse that is used for display.
+func (m *UserSession) Response(ctx context.Context) *UserSessionResponse {
+	if m == nil {
+		return nil
+	}
+
+	r := &UserSessionResponse{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		UserAgent: m.UserAgent,
+		IPAddress: m.IPAddress,
+		ExpiresAt: web.NewTimeResponse(ctx, m.ExpiresAt),
+		CreatedAt: web.NewTimeResponse(ctx, m.CreatedAt),
+	}
+
+	if m.LastUsedAt != nil && !m.LastUsedAt.Time.IsZero() {
+		at := web.NewTimeResponse(ctx, m.LastUsedAt.Time)
+		r.LastUsedAt = &at
+	}
+
+	return r
+}
+
+// UserSessions a list of UserSessions.
+type UserSessions []*UserSession
+
+// Response transforms a list of UserSessions to a list of UserSessionResponses.
+func (m *UserSessions) Response(ctx context.Context) []*UserSessionResponse {
+	var l []*UserSessionResponse
+	if m != nil && len(*m) > 0 {
+		for _, n := range *m {
+			l = append(l, n.Response(ctx))
+		}
+	}
+
+	return l
+}
+
+// UserSessionCreateRequest contains information needed to start a new session for a user.
+type UserSessionCreateRequest struct {
+	UserID    string        `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	UserAgent string        `json:"user_agent" validate:"max=500" example:"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_3)"`
+	IPAddress string        `json:"ip_address" validate:"omitempty,ip" example:"102.68.78.10"`
+	TwoFactor bool          `json:"two_factor" example:"true"`
+	TTL       time.Duration `json:"ttl" validate:"required" swaggertype:"integer" example:"129600000000000"`
+}
+
+// UserSessionRefreshRequest defines the information needed to exchange a refresh token for a
+// new one.
+type UserSessionRefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required" example:"4c0f5cb2e2b9a8f3d1e6c7b8a9d0e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192"`
+}
+
+// UserSessionRevokeRequest defines the information needed to revoke a session.
+type UserSessionRevokeRequest struct {
+	ID string `json:"id" validate:"required,uuid" example:"8e2f1d0c-3b4a-4c5d-9e6f-7a8b9c0d1e2f"`
+}
+
+// UserSessionRevokeAllRequest defines the information needed to revoke all the sessions of a user.
+type UserSessionRevokeAllRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+}
+
+// UserSessionFindRequest defines the possible options to search for user sessions. By default
+// revoked sessions will be excluded from response.
+type UserSessionFindRequest struct {
+	Where          string        `json:"where" example:"user_agent LIKE ?"`
+	Args           []interface{} `json:"args" swaggertype:"array,string" example:"%Mozilla%"`
+	Order          []string      `json:"order" example:"created_at desc"`
+	Limit          *uint         `json:"limit" example:"10"`
+	Offset         *uint         `json:"offset" example:"20"`
+	IncludeRevoked bool          `json:"include-revoked" example:"false"`
+}
@@ -0,0 +1,687 @@
+package user_session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* A session is started each time a user signs in and is identified by the
+refresh token issued with the access token. The refresh token is rotated each
+time it's exchanged for a new access token, only the hashes of the tokens are
+stored. A refresh token that has already been exchanged being presented again
+means it was copied, the whole session is revoked so neither copy can be used. */
+
+const (
+	// The database table for user sessions
+	UserSessionTableName = "user_sessions"
+	// The database table for the refresh tokens issued for user sessions
+	UserSessionTokenTableName = "user_session_tokens"
+	// The database table for User Account
+	userAccountTableName = "users_accounts"
+
+	// reuseGracePeriod is the duration a refresh token that has just been exchanged is rejected
+	// without revoking the session. Browsers with multiple tabs open can send the same token
+	// concurrently when the access token expires.
+	reuseGracePeriod = 10 * time.Second
+)
+
+var (
+	// ErrNotFound abstracts the postgres not found error.
+	ErrNotFound = errors.New("Entity not found")
+
+	// ErrForbidden occurs when a user tries to do something that is forbidden to them according to our access control policies.
+	ErrForbidden = errors.New("Attempted action is not allowed")
+
+	// ErrInvalidRefreshToken occurs when a refresh token doesn't match an active session.
+	ErrInvalidRefreshToken = errors.New("Invalid refresh token")
+
+	// ErrRefreshTokenReused occurs when a refresh token that has already been exchanged is used
+	// again, the session it belongs to is revoked.
+	ErrRefreshTokenReused = errors.New("Refresh token has already been used")
+)
+
+// SessionUserID returns the ID of the user that signed in for the claims. During a virtual
+// login the session belongs to the root user.
+func SessionUserID(claims auth.Claims) string {
+	if claims.RootUserID != "" {
+		return claims.RootUserID
+	}
+	return claims.Subject
+}
+
+// CanModifyUserSession determines if claims has the authority to manage the sessions of the
+// specified user. Users can manage their own sessions and admins the sessions of the users of
+// their account.
+func (repo *Repository) CanModifyUserSession(ctx context.Context, claims auth.Claims, userID string) error {
+	// If claims are empty, the request is internal.
+	if claims.Audience == "" && claims.Subject == "" {
+		return nil
+	}
+
+	if claims.IsClient() {
+		return errors.WithStack(ErrForbidden)
+	} else if SessionUserID(claims) == userID {
+		return nil
+	} else if !claims.HasPermission(auth.PermissionUserManage) {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	// The user must have a record for the account of the admin.
+	// select id from users_accounts where account_id = [claims.Audience] and user_id = [userID]
+	query := sqlbuilder.NewSelectBuilder().Select("id").From(userAccountTableName)
+	query.Where(query.And(
+		query.Equal("account_id", claims.Audience),
+		query.Equal("user_id", userID),
+	))
+	queryStr, args := query.Build()
+	queryStr = repo.DbConn.Rebind(queryStr)
+
+	var userAccountId string
+	err := repo.DbConn.QueryRowContext(ctx, queryStr, args...).Scan(&userAccountId)
+	if err != nil && err != sql.ErrNoRows {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		return err
+	}
+
+	if userAccountId == "" {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	return nil
+}
+
+// applyClaimsSelect applies a sub-query to the provided query to enforce ACL based on the
+// claims provided.
+//  1. No claims, request is internal, no ACL applied
+//  2. Users can access their own sessions
+//  3. Users that can manage users can access the sessions of all the users of their account
+//  4. Api clients can't access any
+func applyClaimsSelect(ctx context.Context, claims auth.Claims, query *sqlbuilder.SelectBuilder) error {
+	// if claims are empty, don't apply any ACL
+	if claims.Audience == "" && claims.Subject == "" {
+		return nil
+	}
+
+	if claims.IsClient() {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	if claims.HasPermission(auth.PermissionUserManage) {
+		subQuery := sqlbuilder.NewSelectBuilder().Select("user_id").From(userAccountTableName)
+		subQuery.Where(subQuery.Equal("account_id", claims.Audience))
+
+		query.Where(query.Or(
+			query.Equal("user_id", SessionUserID(claims)),
+			query.In("user_id", subQuery),
+		))
+	} else {
+		query.Where(query.Equal("user_id", SessionUserID(claims)))
+	}
+
+	return nil
+}
+
+// userSessionMapColumns is the list of columns needed for find.
+var userSessionMapColumns = "id,user_id,user_agent,ip_address,two_factor,expires_at,last_used_at,revoked_at,created_at,updated_at"
+
+// selectQuery constructs a base select query for UserSession.
+func selectQuery() *sqlbuilder.SelectBuilder {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Select(userSessionMapColumns)
+	query.From(UserSessionTableName)
+	return query
+}
+
+// findRequestQuery generates the select query for the given find request.
+func findRequestQuery(req UserSessionFindRequest) (*sqlbuilder.SelectBuilder, []interface{}) {
+	query := selectQuery()
+
+	if req.Where != "" {
+		query.Where(query.And(req.Where))
+	}
+
+	if len(req.Order) > 0 {
+		query.OrderBy(req.Order...)
+	}
+
+	if req.Limit != nil {
+		query.Limit(int(*req.Limit))
+	}
+
+	if req.Offset != nil {
+		query.Offset(int(*req.Offset))
+	}
+
+	return query, req.Args
+}
+
+// Find gets all the user sessions from the database based on the request params.
+func (repo *Repository) Find(ctx context.Context, claims auth.Claims, req UserSessionFindRequest) (UserSessions, error) {
+	query, args := findRequestQuery(req)
+	return find(ctx, claims, repo.DbConn, query, args, req.IncludeRevoked)
+}
+
+// find internal method for getting all the user sessions from the database using a select query.
+func find(ctx context.Context, claims auth.Claims, dbConn *sqlx.DB, query *sqlbuilder.SelectBuilder, args []interface{}, includeRevoked bool) (UserSessions, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_session.Find")
+	defer span.Finish()
+
+	query.Select(userSessionMapColumns)
+	query.From(UserSessionTableName)
+	if !includeRevoked {
+		query.Where(query.IsNull("revoked_at"))
+	}
+
+	// Check to see if a sub query needs to be applied for the claims
+	err := applyClaimsSelect(ctx, claims, query)
+	if err != nil {
+		return nil, err
+	}
+
+	queryStr, queryArgs := query.Build()
+	queryStr = dbConn.Rebind(queryStr)
+	args = append(args, queryArgs...)
+
+	// Fetch all entries from the db.
+	rows, err := dbConn.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find user sessions failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Iterate over each row.
+	resp := []*UserSession{}
+	for rows.Next() {
+		var m UserSession
+		err = rows.Scan(&m.ID, &m.UserID, &m.UserAgent, &m.IPAddress, &m.TwoFactor, &m.ExpiresAt, &m.LastUsedAt,
+			&m.RevokedAt, &m.CreatedAt, &m.UpdatedAt)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+
+		resp = append(resp, &m)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find user sessions failed")
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// FindActiveByUserID gets the sessions of a user that have not been revoked or expired, the most
+// recently used first.
+func (repo *Repository) FindActiveByUserID(ctx context.Context, claims auth.Claims, userID string, now time.Time) (UserSessions, error) {
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("user_id", userID),
+		query.GreaterThan("expires_at", now.UTC()),
+	))
+	query.OrderBy("coalesce(last_used_at, created_at) desc")
+
+	return find(ctx, claims, repo.DbConn, query, []interface{}{}, false)
+}
+
+// ReadByID gets the specified user session by ID from the database.
+func (repo *Repository) ReadByID(ctx context.Context, claims auth.Claims, id string) (*UserSession, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_session.ReadByID")
+	defer span.Finish()
+
+	// Filter base select query by id.
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("id", id))
+
+	res, err := find(ctx, claims, repo.DbConn, query, []interface{}{}, false)
+	if err != nil {
+		return nil, err
+	} else if res == nil || len(res) == 0 {
+		err = errors.WithMessagef(ErrNotFound, "user session %s not found", id)
+		return nil, err
+	}
+
+	u := res[0]
+	return u, nil
+}
+
+// CheckSession returns auth.ErrSessionRevoked when the session the claims were issued for has
+// been revoked or no longer exists so its access tokens are rejected before they expire,
+// implements auth.SessionChecker.
+func (repo *Repository) CheckSession(ctx context.Context, claims auth.Claims) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_session.CheckSession")
+	defer span.Finish()
+
+	query := sqlbuilder.NewSelectBuilder()
+	query.Select("revoked_at")
+	query.From(UserSessionTableName)
+	query.Where(query.Equal("id", claims.SessionID))
+
+	queryStr, args := query.Build()
+	queryStr = repo.DbConn.Rebind(queryStr)
+
+	var revokedAt pq.NullTime
+	err := repo.DbConn.QueryRowContext(ctx, queryStr, args...).Scan(&revokedAt)
+	if err == sql.ErrNoRows {
+		return errors.WithMessagef(auth.ErrSessionRevoked, "user session %s not found", claims.SessionID)
+	} else if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		return errors.WithMessagef(err, "check user session %s failed", claims.SessionID)
+	} else if revokedAt.Valid {
+		return errors.WithMessagef(auth.ErrSessionRevoked, "user session %s", claims.SessionID)
+	}
+
+	return nil
+}
+
+// generateRefreshToken returns a new random refresh token and its hash. Refresh tokens have
+// enough entropy that a fast hash is sufficient and allows them to be looked up by hash.
+func generateRefreshToken() (string, string, error) {
+	tb := make([]byte, 32)
+	if _, err := rand.Read(tb); err != nil {
+		return "", "", errors.WithStack(err)
+	}
+	token := hex.EncodeToString(tb)
+
+	return token, hashRefreshToken(token), nil
+}
+
+// hashRefreshToken returns the hash of a refresh token that is stored.
+func hashRefreshToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// insertToken stores the hash of a refresh token issued for a session.
+func insertToken(ctx context.Context, dbTx *sqlx.Tx, sessionID, tokenHash string, now time.Time) error {
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(UserSessionTokenTableName)
+	query.Cols("token_hash", "session_id", "created_at")
+	query.Values(tokenHash, sessionID, now)
+
+	sql, args := query.Build()
+	sql = dbTx.Rebind(sql)
+	_, err := dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "create user session token failed")
+		return err
+	}
+
+	return nil
+}
+
+// Create starts a new session for a user. The returned session includes the refresh token, it is
+// not possible to retrieve it afterwards.
+func (repo *Repository) Create(ctx context.Context, claims auth.Claims, req UserSessionCreateRequest, now time.Time) (*UserSession, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_session.Create")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can manage the sessions of the user.
+	err = repo.CanModifyUserSession(ctx, claims, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	token, tokenHash, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	m := UserSession{
+		ID:           uuid.NewRandom().String(),
+		UserID:       req.UserID,
+		UserAgent:    req.UserAgent,
+		IPAddress:    req.IPAddress,
+		TwoFactor:    req.TwoFactor,
+		ExpiresAt:    now.Add(req.TTL),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		RefreshToken: token,
+	}
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	// Build the insert SQL statement.
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(UserSessionTableName)
+	query.Cols("id", "user_id", "user_agent", "ip_address", "two_factor", "expires_at", "created_at", "updated_at")
+	query.Values(m.ID, m.UserID, m.UserAgent, m.IPAddress, m.TwoFactor, m.ExpiresAt, m.CreatedAt, m.UpdatedAt)
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = dbTx.Rebind(sql)
+	_, err = dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "create user session failed")
+		return nil, err
+	}
+
+	if err := insertToken(ctx, dbTx, m.ID, tokenHash, now); err != nil {
+		return nil, err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &m, nil
+}
+
+// Refresh exchanges a refresh token for a new one. The token provided can't be used again, when
+// it is the session is revoked as one of the copies of the token was stolen.
+func (repo *Repository) Refresh(ctx context.Context, req UserSessionRefreshRequest, now time.Time) (*UserSession, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_session.Refresh")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	// Lock the token so concurrent requests with the same token are handled one at a time.
+	var (
+		sessionID string
+		usedAt    pq.NullTime
+	)
+	{
+		query := sqlbuilder.NewSelectBuilder().Select("session_id, used_at").From(UserSessionTokenTableName)
+		query.Where(query.Equal("token_hash", hashRefreshToken(req.RefreshToken)))
+
+		queryStr, queryArgs := query.Build()
+		queryStr = dbTx.Rebind(queryStr) + " FOR UPDATE"
+		err = dbTx.QueryRowContext(ctx, queryStr, queryArgs...).Scan(&sessionID, &usedAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.WithStack(ErrInvalidRefreshToken)
+			}
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+	}
+
+	query := selectQuery()
+	query.Where(query.Equal("id", sessionID))
+
+	var m UserSession
+	{
+		queryStr, queryArgs := query.Build()
+		queryStr = dbTx.Rebind(queryStr)
+		err = dbTx.QueryRowContext(ctx, queryStr, queryArgs...).Scan(&m.ID, &m.UserID, &m.UserAgent, &m.IPAddress,
+			&m.TwoFactor, &m.ExpiresAt, &m.LastUsedAt, &m.RevokedAt, &m.CreatedAt, &m.UpdatedAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.WithStack(ErrInvalidRefreshToken)
+			}
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+	}
+
+	if !m.IsActive(now) {
+		return nil, errors.WithMessagef(ErrInvalidRefreshToken, "user session %s is no longer active", m.ID)
+	}
+
+	if usedAt.Valid {
+		if now.Sub(usedAt.Time) < reuseGracePeriod {
+			return nil, errors.WithMessagef(ErrInvalidRefreshToken, "refresh token for user session %s was just used", m.ID)
+		}
+
+		// The token has already been exchanged, revoke the session. The transaction is committed
+		// so the session stays revoked even though an error is returned.
+		if err := revoke(ctx, dbTx, "id", m.ID, now); err != nil {
+			return nil, err
+		}
+		if err := dbTx.Commit(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return nil, errors.WithMessagef(ErrRefreshTokenReused, "user session %s revoked", m.ID)
+	}
+
+	{
+		query := sqlbuilder.NewUpdateBuilder()
+		query.Update(UserSessionTokenTableName)
+		query.Set(query.Assign("used_at", now))
+		query.Where(query.Equal("token_hash", hashRefreshToken(req.RefreshToken)))
+
+		sql, args := query.Build()
+		sql = dbTx.Rebind(sql)
+		_, err = dbTx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			err = errors.WithMessagef(err, "update user session token for %s failed", m.ID)
+			return nil, err
+		}
+	}
+
+	token, tokenHash, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertToken(ctx, dbTx, m.ID, tokenHash, now); err != nil {
+		return nil, err
+	}
+
+	{
+		query := sqlbuilder.NewUpdateBuilder()
+		query.Update(UserSessionTableName)
+		query.Set(
+			query.Assign("last_used_at", now),
+			query.Assign("updated_at", now),
+		)
+		query.Where(query.Equal("id", m.ID))
+
+		sql, args := query.Build()
+		sql = dbTx.Rebind(sql)
+		_, err = dbTx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			err = errors.WithMessagef(err, "update user session %s failed", m.ID)
+			return nil, err
+		}
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	m.LastUsedAt = &pq.NullTime{Time: now, Valid: true}
+	m.UpdatedAt = now
+	m.RefreshToken = token
+
+	return &m, nil
+}
+
+// revoke sets the revoked date of the sessions matching the column value that are not already
+// revoked.
+func revoke(ctx context.Context, dbTx *sqlx.Tx, col, val string, now time.Time) error {
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(UserSessionTableName)
+	query.Set(
+		query.Assign("revoked_at", now),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.And(
+		query.Equal(col, val),
+		query.IsNull("revoked_at"),
+	))
+
+	sql, args := query.Build()
+	sql = dbTx.Rebind(sql)
+	_, err := dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "revoke user sessions with %s %s failed", col, val)
+		return err
+	}
+
+	return nil
+}
+
+// Revoke ends a session, its refresh token can no longer be used to issue access tokens. Access
+// tokens already issued are rejected by CheckSession.
+func (repo *Repository) Revoke(ctx context.Context, claims auth.Claims, req UserSessionRevokeRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_session.Revoke")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	m, err := repo.ReadByID(ctx, claims, req.ID)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the claims can manage the sessions of the user.
+	err = repo.CanModifyUserSession(ctx, claims, m.UserID)
+	if err != nil {
+		return err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	if err := revoke(ctx, dbTx, "id", m.ID, now); err != nil {
+		return err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// RevokeAll ends all the sessions of a user, signing them out everywhere.
+func (repo *Repository) RevokeAll(ctx context.Context, claims auth.Claims, req UserSessionRevokeAllRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_session.RevokeAll")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the claims can manage the sessions of the user.
+	err = repo.CanModifyUserSession(ctx, claims, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	if err := revoke(ctx, dbTx, "user_id", req.UserID, now); err != nil {
+		return err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
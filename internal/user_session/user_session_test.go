@@ -0,0 +1,265 @@
+package user_session
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/tests"
+	"exitor-dapp/internal/user_account"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+var test *tests.Test
+
+// TestMain is the entry point for testing.
+func TestMain(m *testing.M) {
+	os.Exit(testMain(m))
+}
+
+func testMain(m *testing.M) int {
+	test = tests.New()
+	defer test.TearDown()
+	return m.Run()
+}
+
+// TestRefresh validates the refresh token of a session being rotated each time it's used and
+// the session being revoked when a used token is presented again.
+func TestRefresh(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.April, 4, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	repo := NewRepository(test.MasterDB)
+
+	usrAcc, err := user_account.MockUserAccount(ctx, test.MasterDB, now, user_account.UserAccountRole_User)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockUserAccount failed.", tests.Failed)
+	}
+
+	t.Log("Given the need to rotate the refresh tokens of a session.")
+	{
+		s, err := repo.Create(ctx, auth.Claims{}, UserSessionCreateRequest{
+			UserID:    usrAcc.UserID,
+			UserAgent: "Mozilla/5.0",
+			IPAddress: "102.68.78.10",
+			TTL:       time.Hour,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+		} else if s.RefreshToken == "" {
+			t.Fatalf("\t%s\tExpected the session to include a refresh token.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreate ok.", tests.Success)
+
+		refreshed, err := repo.Refresh(ctx, UserSessionRefreshRequest{RefreshToken: s.RefreshToken}, now.Add(time.Minute))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRefresh failed.", tests.Failed)
+		} else if refreshed.ID != s.ID || refreshed.RefreshToken == "" || refreshed.RefreshToken == s.RefreshToken {
+			t.Logf("\t\tGot : %+v", refreshed)
+			t.Fatalf("\t%s\tExpected a new refresh token for the same session.", tests.Failed)
+		}
+		t.Logf("\t%s\tRefresh ok.", tests.Success)
+
+		_, err = repo.Refresh(ctx, UserSessionRefreshRequest{RefreshToken: s.RefreshToken}, now.Add(time.Minute+time.Second))
+		if errors.Cause(err) != ErrInvalidRefreshToken {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrInvalidRefreshToken)
+			t.Fatalf("\t%s\tRefresh with a token that was just used should fail.", tests.Failed)
+		}
+
+		// Outside the grace period the session is revoked, including the token issued last.
+		_, err = repo.Refresh(ctx, UserSessionRefreshRequest{RefreshToken: s.RefreshToken}, now.Add(time.Minute*2))
+		if errors.Cause(err) != ErrRefreshTokenReused {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrRefreshTokenReused)
+			t.Fatalf("\t%s\tRefresh with a used token should fail.", tests.Failed)
+		}
+
+		_, err = repo.Refresh(ctx, UserSessionRefreshRequest{RefreshToken: refreshed.RefreshToken}, now.Add(time.Minute*2))
+		if errors.Cause(err) != ErrInvalidRefreshToken {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrInvalidRefreshToken)
+			t.Fatalf("\t%s\tRefresh after the session was revoked should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tRefresh with a used token revoked the session.", tests.Success)
+
+		expired, err := repo.Create(ctx, auth.Claims{}, UserSessionCreateRequest{
+			UserID: usrAcc.UserID,
+			TTL:    time.Hour,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+		}
+
+		_, err = repo.Refresh(ctx, UserSessionRefreshRequest{RefreshToken: expired.RefreshToken}, now.Add(time.Hour))
+		if errors.Cause(err) != ErrInvalidRefreshToken {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrInvalidRefreshToken)
+			t.Fatalf("\t%s\tRefresh after the session expired should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tRefresh after the session expired rejected.", tests.Success)
+	}
+}
+
+// TestRevoke validates users revoking their own sessions and admins revoking the sessions of the
+// users of their account.
+func TestRevoke(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.April, 4, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	repo := NewRepository(test.MasterDB)
+
+	usrAcc, err := user_account.MockUserAccount(ctx, test.MasterDB, now, user_account.UserAccountRole_User)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockUserAccount failed.", tests.Failed)
+	}
+
+	userClaims := auth.Claims{
+		Roles: []string{auth.RoleUser},
+		StandardClaims: jwt.StandardClaims{
+			Audience: usrAcc.AccountID,
+			Subject:  usrAcc.UserID,
+		},
+	}
+	otherClaims := auth.Claims{
+		Roles: []string{auth.RoleUser},
+		StandardClaims: jwt.StandardClaims{
+			Audience: usrAcc.AccountID,
+			Subject:  uuid.NewRandom().String(),
+		},
+	}
+	adminClaims := auth.Claims{
+		Roles: []string{auth.RoleAdmin},
+		StandardClaims: jwt.StandardClaims{
+			Audience: usrAcc.AccountID,
+			Subject:  uuid.NewRandom().String(),
+		},
+	}
+	otherAdminClaims := auth.Claims{
+		Roles: []string{auth.RoleAdmin},
+		StandardClaims: jwt.StandardClaims{
+			Audience: uuid.NewRandom().String(),
+			Subject:  uuid.NewRandom().String(),
+		},
+	}
+
+	var sessionIDs []string
+	for i := 0; i < 3; i++ {
+		s, err := repo.Create(ctx, auth.Claims{}, UserSessionCreateRequest{
+			UserID: usrAcc.UserID,
+			TTL:    time.Hour,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+		}
+		sessionIDs = append(sessionIDs, s.ID)
+	}
+
+	t.Log("Given the need to revoke the sessions of a user.")
+	{
+		res, err := repo.FindActiveByUserID(ctx, userClaims, usrAcc.UserID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tFindActiveByUserID failed.", tests.Failed)
+		} else if len(res) != len(sessionIDs) {
+			t.Logf("\t\tGot : %d", len(res))
+			t.Logf("\t\tWant: %d", len(sessionIDs))
+			t.Fatalf("\t%s\tExpected all the sessions of the user.", tests.Failed)
+		}
+		t.Logf("\t%s\tFindActiveByUserID ok.", tests.Success)
+
+		res, err = repo.FindActiveByUserID(ctx, otherAdminClaims, usrAcc.UserID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tFindActiveByUserID failed.", tests.Failed)
+		} else if len(res) != 0 {
+			t.Fatalf("\t%s\tExpected the admin of another account to find no sessions.", tests.Failed)
+		}
+		t.Logf("\t%s\tFindActiveByUserID by the admin of another account found none.", tests.Success)
+
+		err = repo.Revoke(ctx, otherClaims, UserSessionRevokeRequest{ID: sessionIDs[0]}, now)
+		if errors.Cause(err) != ErrNotFound {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrNotFound)
+			t.Fatalf("\t%s\tRevoke by another user should fail.", tests.Failed)
+		}
+
+		err = repo.RevokeAll(ctx, otherClaims, UserSessionRevokeAllRequest{UserID: usrAcc.UserID}, now)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tRevokeAll by another user should fail.", tests.Failed)
+		}
+
+		err = repo.RevokeAll(ctx, otherAdminClaims, UserSessionRevokeAllRequest{UserID: usrAcc.UserID}, now)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tRevokeAll by the admin of another account should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tRevoke by others rejected.", tests.Success)
+
+		sessClaims := userClaims
+		sessClaims.SessionID = sessionIDs[0]
+		if err := repo.CheckSession(ctx, sessClaims); err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCheckSession of an active session failed.", tests.Failed)
+		}
+
+		err = repo.Revoke(ctx, userClaims, UserSessionRevokeRequest{ID: sessionIDs[0]}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRevoke failed.", tests.Failed)
+		}
+
+		// Access tokens issued for the session are rejected before they expire.
+		if err := repo.CheckSession(ctx, sessClaims); errors.Cause(err) != auth.ErrSessionRevoked {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", auth.ErrSessionRevoked)
+			t.Fatalf("\t%s\tCheckSession of a revoked session should fail.", tests.Failed)
+		}
+
+		res, err = repo.FindActiveByUserID(ctx, userClaims, usrAcc.UserID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tFindActiveByUserID failed.", tests.Failed)
+		} else if len(res) != len(sessionIDs)-1 {
+			t.Logf("\t\tGot : %d", len(res))
+			t.Logf("\t\tWant: %d", len(sessionIDs)-1)
+			t.Fatalf("\t%s\tExpected the revoked session to be excluded.", tests.Failed)
+		}
+		t.Logf("\t%s\tRevoke ok.", tests.Success)
+
+		err = repo.RevokeAll(ctx, adminClaims, UserSessionRevokeAllRequest{UserID: usrAcc.UserID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRevokeAll failed.", tests.Failed)
+		}
+
+		res, err = repo.FindActiveByUserID(ctx, userClaims, usrAcc.UserID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tFindActiveByUserID failed.", tests.Failed)
+		} else if len(res) != 0 {
+			t.Logf("\t\tGot : %d", len(res))
+			t.Fatalf("\t%s\tExpected all the sessions to be revoked.", tests.Failed)
+		}
+		t.Logf("\t%s\tRevokeAll by an admin of the account ok.", tests.Success)
+	}
+}
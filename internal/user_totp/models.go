@@ -0,0 +1,142 @@
+package user_totp
+
+import (
+	"context"
+	"time"
+
+	"exitor-dapp/internal/platform/web"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Repository defines the required dependencies for UserTotp.
+type Repository struct {
+	DbConn *sqlx.DB
+	Issuer string
+}
+
+// NewRepository creates a new Repository that defines dependencies for UserTotp.
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{
+		DbConn: db,
+		Issuer: DefaultIssuer,
+	}
+}
+
+// UserTotp represents the secret shared with the authenticator app of a user to generate time
+// based one-time codes. The secret is only used to verify codes once enrollment was confirmed.
+type UserTotp struct {
+	UserID                 string       `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Secret                 string       `json:"-" truss:"api-hide"`
+	LastUsedStep           int64        `json:"-" truss:"api-hide"`
+	RecoveryCodesRemaining int          `json:"recovery_codes_remaining" truss:"api-read" example:"10"`
+	VerifiedAt             *pq.NullTime `json:"verified_at,omitempty" truss:"api-read"`
+	CreatedAt              time.Time    `json:"created_at" truss:"api-read"`
+	UpdatedAt              time.Time    `json:"updated_at" truss:"api-read"`
+}
+
+// IsVerified returns true when the user has confirmed enrollment with a code.
+func (m *UserTotp) IsVerified() bool {
+	return m.VerifiedAt != nil && m.VerifiedAt.Valid && !m.VerifiedAt.Time.IsZero()
+}
+
+// ProvisioningURI returns the otpauth URI of the secret for the authenticator app of the user.
+func (m *UserTotp) ProvisioningURI(issuer, accountName string) string {
+	return ProvisioningURI(issuer, accountName, m.Secret)
+}
+
+// UserTotpResponse represents the two-factor authentication of a user that is returned for display.
+type UserTotpResponse struct {
+	UserID                 string            `json:"user_id" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Enabled                bool              `json:"enabled" example:"true"`
+	RecoveryCodesRemaining int               `json:"recovery_codes_remaining" example:"10"`
+	VerifiedAt             *web.TimeResponse `json:"verified_at,omitempty"` // VerifiedAt contains multiple format options for display.
+	CreatedAt              web.TimeResponse  `json:"created_at"`            // CreatedAt contains multiple format options for display.
+}
+
+// Response transforms UserTotp to the UserTotpResponse that is used for display.
+// Additional filtering by context values or translations could be applied.
+func (m *UserTotp) Response(ctx context.Context) *UserTotpResponse {
+	if m == nil {
+		return nil
+	}
+
+	r := &UserTotpResponse{
+		UserID:                 m.UserID,
+		Enabled:                m.IsVerified(),
+		RecoveryCodesRemaining: m.RecoveryCodesRemaining,
+		CreatedAt:              web.NewTimeResponse(ctx, m.CreatedAt),
+	}
+
+	if m.IsVerified() {
+		at := web.NewTimeResponse(ctx, m.VerifiedAt.Time)
+		r.VerifiedAt = &at
+	}
+
+	return r
+}
+
+// UserTotpChallenge represents a sign in that requires a code to be completed. It's issued once
+// the first factor was verified so the code can be entered in a separate step.
+type UserTotpChallenge struct {
+	ID        string       `json:"id" validate:"required,uuid" example:"5f0f8a4e-8a6c-4b1e-9f3d-2c7b1a0e9d8c"`
+	UserID    string       `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	AccountID string       `json:"account_id" validate:"omitempty,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Attempts  int          `json:"attempts" example:"0"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    *pq.NullTime `json:"used_at,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	// Enrolled is set when verifying the challenge confirmed the enrollment of the user.
+	Enrolled bool `json:"-"`
+}
+
+// IsActive returns true when a code can still be verified for the challenge.
+func (m *UserTotpChallenge) IsActive(now time.Time) bool {
+	if m.UsedAt != nil && m.UsedAt.Valid && !m.UsedAt.Time.IsZero() {
+		return false
+	}
+	return m.Attempts < maxChallengeAttempts && now.Before(m.ExpiresAt)
+}
+
+// UserTotpEnrollRequest contains information needed to generate the secret for a user.
+type UserTotpEnrollRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+}
+
+// UserTotpConfirmRequest contains information needed to confirm the enrollment of a user with a
+// code from their authenticator app.
+type UserTotpConfirmRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Code   string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+}
+
+// UserTotpVerifyRequest contains information needed to verify a code of a user. The code is
+// either generated by their authenticator app or one of their recovery codes.
+type UserTotpVerifyRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Code   string `json:"code" validate:"required,max=20" example:"123456"`
+}
+
+// UserTotpRecoveryCodesRequest contains information needed to generate new recovery codes for a user.
+type UserTotpRecoveryCodesRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+}
+
+// UserTotpResetRequest contains information needed to remove the two-factor authentication of a
+// user so they can enroll again.
+type UserTotpResetRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+}
+
+// UserTotpChallengeCreateRequest contains information needed to issue a challenge for a user.
+type UserTotpChallengeCreateRequest struct {
+	UserID    string `json:"user_id" validate:"required,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	AccountID string `json:"account_id" validate:"omitempty,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+}
+
+// UserTotpChallengeVerifyRequest contains information needed to complete a challenge with a code.
+type UserTotpChallengeVerifyRequest struct {
+	ChallengeID string `json:"challenge_id" validate:"required,uuid" example:"5f0f8a4e-8a6c-4b1e-9f3d-2c7b1a0e9d8c"`
+	Code        string `json:"code" validate:"required,max=20" example:"123456"`
+}
@@ -0,0 +1,149 @@
+package user_totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// totpPeriod is the number of seconds a code is valid for.
+	totpPeriod = 30
+
+	// totpDigits is the number of digits of a code.
+	totpDigits = 6
+
+	// totpSkew is the number of periods before and after the current one that codes are accepted
+	// for to allow for the clock of the device to drift.
+	totpSkew = 1
+
+	// secretSize is the number of random bytes of a secret, the size recommended by RFC 4226.
+	secretSize = 20
+
+	// recoveryCodeCount is the number of recovery codes generated for a user.
+	recoveryCodeCount = 10
+)
+
+// secretEncoding is the encoding of secrets expected by authenticator apps.
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateSecret returns a new random secret encoded as base32.
+func generateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return secretEncoding.EncodeToString(b), nil
+}
+
+// totpStep returns the number of periods elapsed since the unix epoch for the time.
+func totpStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// totpCode returns the code for the secret at the step as defined by RFC 6238.
+func totpCode(secret string, step int64) (string, error) {
+	key, err := secretEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", errors.Wrap(err, "decoding secret")
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation as defined by RFC 4226.
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, bin%1000000), nil
+}
+
+// GenerateCode returns the code for the secret at the time, the same as the authenticator app of
+// the user would display.
+func GenerateCode(secret string, t time.Time) (string, error) {
+	return totpCode(secret, totpStep(t))
+}
+
+// matchCode returns the step the code was generated for when it is valid for the time. Codes
+// for steps up to the last step used are rejected so each code can only be used once.
+func matchCode(secret, code string, now time.Time, lastUsedStep int64) (int64, bool, error) {
+	cur := totpStep(now)
+	for i := -totpSkew; i <= totpSkew; i++ {
+		step := cur + int64(i)
+		if step <= lastUsedStep {
+			continue
+		}
+
+		c, err := totpCode(secret, step)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(c), []byte(code)) == 1 {
+			return step, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// isTotpCode returns true when the code has the format of a code generated by an authenticator
+// app and not a recovery code.
+func isTotpCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	_, err := strconv.ParseUint(code, 10, 64)
+	return err == nil
+}
+
+// ProvisioningURI returns the otpauth URI of the secret that is encoded as a QR code for
+// authenticator apps to scan.
+func ProvisioningURI(issuer, accountName, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", strconv.Itoa(totpDigits))
+	v.Set("period", strconv.Itoa(totpPeriod))
+
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+accountName) + "?" + v.Encode()
+}
+
+// generateRecoveryCode returns a new random recovery code formatted for display.
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WithStack(err)
+	}
+	code := strings.ToLower(secretEncoding.EncodeToString(b))
+
+	return code[:4] + "-" + code[4:], nil
+}
+
+// hashRecoveryCode returns the hash of a recovery code that is stored. The code is normalized
+// first so it can be entered without the dash or in upper case.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.Replace(code, "-", "", -1)
+	code = strings.Replace(code, " ", "", -1)
+
+	h := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,825 @@
+package user_totp
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* Users enroll in two-factor authentication by adding the secret generated for
+them to an authenticator app and confirming a code. Afterwards each sign in must
+include a code from the app or one of the recovery codes issued, only the hashes
+of the recovery codes are stored and each can be used once. When the code is
+entered in a separate step a challenge is issued after the password is verified,
+the challenge expires and only allows a few attempts so codes can't be guessed. */
+
+const (
+	// The database table for the secrets of users
+	UserTotpTableName = "user_totp"
+	// The database table for the recovery codes of users
+	UserTotpRecoveryCodeTableName = "user_totp_recovery_codes"
+	// The database table for the challenges issued for sign in
+	UserTotpChallengeTableName = "user_totp_challenges"
+	// The database table for User Account
+	userAccountTableName = "users_accounts"
+
+	// DefaultIssuer is the name displayed by authenticator apps for the codes.
+	DefaultIssuer = "Exitor"
+
+	// challengeTTL is the duration a challenge can be completed after it was issued.
+	challengeTTL = 5 * time.Minute
+
+	// maxChallengeAttempts is the number of invalid codes that can be entered for a challenge.
+	maxChallengeAttempts = 5
+)
+
+var (
+	// ErrNotFound abstracts the postgres not found error.
+	ErrNotFound = errors.New("Entity not found")
+
+	// ErrForbidden occurs when a user tries to do something that is forbidden to them according to our access control policies.
+	ErrForbidden = errors.New("Attempted action is not allowed")
+
+	// ErrAlreadyEnrolled occurs when a user that has confirmed enrollment tries to enroll again.
+	ErrAlreadyEnrolled = errors.New("Two-factor authentication is already enabled")
+
+	// ErrNotEnrolled occurs when a code is verified for a user that has not confirmed enrollment.
+	ErrNotEnrolled = errors.New("Two-factor authentication is not enabled")
+
+	// ErrInvalidCode occurs when a code doesn't match the secret or a recovery code of a user.
+	ErrInvalidCode = errors.New("Invalid two-factor authentication code")
+
+	// ErrChallengeExpired occurs when a challenge has expired, was used or had too many attempts.
+	ErrChallengeExpired = errors.New("Two-factor authentication challenge has expired")
+)
+
+// isUser returns true when the claims were issued for the user and not during a virtual login.
+func isUser(claims auth.Claims, userID string) bool {
+	return claims.Subject == userID && (claims.RootUserID == "" || claims.RootUserID == claims.Subject)
+}
+
+// canEnroll determines if claims has the authority to enroll the user. Only the user can manage
+// their secret and recovery codes.
+func canEnroll(claims auth.Claims, userID string) error {
+	// If claims are empty, the request is internal.
+	if claims.Audience == "" && claims.Subject == "" {
+		return nil
+	}
+
+	if claims.IsClient() || !isUser(claims, userID) {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	return nil
+}
+
+// CanModifyUserTotp determines if claims has the authority to view and reset the two-factor
+// authentication of the specified user. Users can manage their own and admins can reset the
+// ones of the users of their account when they lose their device.
+func (repo *Repository) CanModifyUserTotp(ctx context.Context, claims auth.Claims, userID string) error {
+	// If claims are empty, the request is internal.
+	if claims.Audience == "" && claims.Subject == "" {
+		return nil
+	}
+
+	if claims.IsClient() {
+		return errors.WithStack(ErrForbidden)
+	} else if isUser(claims, userID) {
+		return nil
+	} else if !claims.HasPermission(auth.PermissionUserManage) {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	// The user must have a record for the account of the admin.
+	// select id from users_accounts where account_id = [claims.Audience] and user_id = [userID]
+	query := sqlbuilder.NewSelectBuilder().Select("id").From(userAccountTableName)
+	query.Where(query.And(
+		query.Equal("account_id", claims.Audience),
+		query.Equal("user_id", userID),
+	))
+	queryStr, args := query.Build()
+	queryStr = repo.DbConn.Rebind(queryStr)
+
+	var userAccountId string
+	err := repo.DbConn.QueryRowContext(ctx, queryStr, args...).Scan(&userAccountId)
+	if err != nil && err != sql.ErrNoRows {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		return err
+	}
+
+	if userAccountId == "" {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	return nil
+}
+
+// userTotpMapColumns is the list of columns needed for read.
+var userTotpMapColumns = "user_id,secret,last_used_step,verified_at,created_at,updated_at"
+
+// recoveryCodesRemainingColumn counts the recovery codes of the user that have not been used.
+var recoveryCodesRemainingColumn = "(SELECT count(*) FROM " + UserTotpRecoveryCodeTableName + " rc WHERE rc.user_id = " +
+	UserTotpTableName + ".user_id AND rc.used_at IS NULL)"
+
+// read internal method for getting the secret of a user, when the transaction is provided the
+// row is locked until it ends.
+func read(ctx context.Context, dbConn *sqlx.DB, dbTx *sqlx.Tx, userID string) (*UserTotp, error) {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Select(userTotpMapColumns + "," + recoveryCodesRemainingColumn)
+	query.From(UserTotpTableName)
+	query.Where(query.Equal("user_id", userID))
+
+	queryStr, queryArgs := query.Build()
+
+	var row *sql.Row
+	if dbTx != nil {
+		queryStr = dbTx.Rebind(queryStr) + " FOR UPDATE"
+		row = dbTx.QueryRowContext(ctx, queryStr, queryArgs...)
+	} else {
+		queryStr = dbConn.Rebind(queryStr)
+		row = dbConn.QueryRowContext(ctx, queryStr, queryArgs...)
+	}
+
+	var m UserTotp
+	err := row.Scan(&m.UserID, &m.Secret, &m.LastUsedStep, &m.VerifiedAt, &m.CreatedAt, &m.UpdatedAt,
+		&m.RecoveryCodesRemaining)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.WithMessagef(ErrNotFound, "two-factor authentication for user %s not found", userID)
+		}
+		err = errors.Wrapf(err, "query - %s", query.String())
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// ReadByUserID gets the two-factor authentication of the specified user from the database.
+func (repo *Repository) ReadByUserID(ctx context.Context, claims auth.Claims, userID string) (*UserTotp, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_totp.ReadByUserID")
+	defer span.Finish()
+
+	// Ensure the claims can view the two-factor authentication of the user.
+	err := repo.CanModifyUserTotp(ctx, claims, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return read(ctx, repo.DbConn, nil, userID)
+}
+
+// IsEnrolled returns true when the user has confirmed enrollment and a code is required to sign in.
+func (repo *Repository) IsEnrolled(ctx context.Context, userID string) (bool, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_totp.IsEnrolled")
+	defer span.Finish()
+
+	m, err := read(ctx, repo.DbConn, nil, userID)
+	if err != nil {
+		if errors.Cause(err) == ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return m.IsVerified(), nil
+}
+
+// Enroll generates the secret for a user to add to their authenticator app. The secret is not
+// used to verify codes until enrollment is confirmed. Enrolling again before confirming returns
+// the same secret so the app doesn't need to be updated.
+func (repo *Repository) Enroll(ctx context.Context, claims auth.Claims, req UserTotpEnrollRequest, now time.Time) (*UserTotp, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_totp.Enroll")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can enroll the user.
+	err = canEnroll(claims, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := read(ctx, repo.DbConn, nil, req.UserID)
+	if err != nil && errors.Cause(err) != ErrNotFound {
+		return nil, err
+	} else if cur != nil {
+		if cur.IsVerified() {
+			return nil, errors.WithStack(ErrAlreadyEnrolled)
+		}
+		return cur, nil
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	m := UserTotp{
+		UserID:    req.UserID,
+		Secret:    secret,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// Build the insert SQL statement.
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(UserTotpTableName)
+	query.Cols("user_id", "secret", "created_at", "updated_at")
+	query.Values(m.UserID, m.Secret, m.CreatedAt, m.UpdatedAt)
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "enroll two-factor authentication for user %s failed", m.UserID)
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// confirm marks the enrollment of the user as verified with the step of the code used.
+func confirm(ctx context.Context, dbTx *sqlx.Tx, m *UserTotp, step int64, now time.Time) error {
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(UserTotpTableName)
+	query.Set(
+		query.Assign("verified_at", now),
+		query.Assign("last_used_step", step),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.Equal("user_id", m.UserID))
+
+	sql, args := query.Build()
+	sql = dbTx.Rebind(sql)
+	_, err := dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "confirm two-factor authentication for user %s failed", m.UserID)
+		return err
+	}
+
+	m.VerifiedAt = &pq.NullTime{Time: now, Valid: true}
+	m.LastUsedStep = step
+	m.UpdatedAt = now
+
+	return nil
+}
+
+// Confirm verifies a code generated with the secret of the user and enables two-factor
+// authentication for them. Recovery codes are generated separately.
+func (repo *Repository) Confirm(ctx context.Context, claims auth.Claims, req UserTotpConfirmRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_totp.Confirm")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the claims can enroll the user.
+	err = canEnroll(claims, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	m, err := read(ctx, repo.DbConn, dbTx, req.UserID)
+	if err != nil {
+		return err
+	} else if m.IsVerified() {
+		return errors.WithStack(ErrAlreadyEnrolled)
+	}
+
+	step, ok, err := matchCode(m.Secret, req.Code, now, m.LastUsedStep)
+	if err != nil {
+		return err
+	} else if !ok {
+		return errors.WithStack(ErrInvalidCode)
+	}
+
+	if err := confirm(ctx, dbTx, m, step, now); err != nil {
+		return err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user. The codes returned can't be
+// retrieved afterwards.
+func (repo *Repository) RegenerateRecoveryCodes(ctx context.Context, claims auth.Claims, req UserTotpRecoveryCodesRequest, now time.Time) ([]string, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_totp.RegenerateRecoveryCodes")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can enroll the user.
+	err = canEnroll(claims, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	m, err := read(ctx, repo.DbConn, dbTx, req.UserID)
+	if err != nil {
+		return nil, err
+	} else if !m.IsVerified() {
+		return nil, errors.WithStack(ErrNotEnrolled)
+	}
+
+	{
+		query := sqlbuilder.NewDeleteBuilder()
+		query.DeleteFrom(UserTotpRecoveryCodeTableName)
+		query.Where(query.Equal("user_id", m.UserID))
+
+		sql, args := query.Build()
+		sql = dbTx.Rebind(sql)
+		_, err = dbTx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			err = errors.WithMessagef(err, "delete recovery codes for user %s failed", m.UserID)
+			return nil, err
+		}
+	}
+
+	var codes []string
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(UserTotpRecoveryCodeTableName)
+	query.Cols("code_hash", "user_id", "created_at")
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+
+		query.Values(hashRecoveryCode(code), m.UserID, now)
+	}
+
+	sql, args := query.Build()
+	sql = dbTx.Rebind(sql)
+	_, err = dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "create recovery codes for user %s failed", m.UserID)
+		return nil, err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return codes, nil
+}
+
+// verifyCode checks the code against the secret of the user or their recovery codes. The code is
+// recorded as used so it can't be verified again.
+func verifyCode(ctx context.Context, dbTx *sqlx.Tx, m *UserTotp, code string, now time.Time) error {
+	if isTotpCode(code) {
+		step, ok, err := matchCode(m.Secret, code, now, m.LastUsedStep)
+		if err != nil {
+			return err
+		} else if !ok {
+			return errors.WithStack(ErrInvalidCode)
+		}
+
+		query := sqlbuilder.NewUpdateBuilder()
+		query.Update(UserTotpTableName)
+		query.Set(
+			query.Assign("last_used_step", step),
+			query.Assign("updated_at", now),
+		)
+		query.Where(query.Equal("user_id", m.UserID))
+
+		sql, args := query.Build()
+		sql = dbTx.Rebind(sql)
+		_, err = dbTx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			err = errors.WithMessagef(err, "update two-factor authentication for user %s failed", m.UserID)
+			return err
+		}
+
+		m.LastUsedStep = step
+		m.UpdatedAt = now
+
+		return nil
+	}
+
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(UserTotpRecoveryCodeTableName)
+	query.Set(query.Assign("used_at", now))
+	query.Where(query.And(
+		query.Equal("code_hash", hashRecoveryCode(code)),
+		query.Equal("user_id", m.UserID),
+		query.IsNull("used_at"),
+	))
+
+	sql, args := query.Build()
+	sql = dbTx.Rebind(sql)
+	res, err := dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "use recovery code for user %s failed", m.UserID)
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.WithStack(err)
+	} else if n == 0 {
+		return errors.WithStack(ErrInvalidCode)
+	}
+	m.RecoveryCodesRemaining--
+
+	return nil
+}
+
+// Verify checks a code generated by the authenticator app of the user or one of their recovery
+// codes. Each code can only be verified once.
+func (repo *Repository) Verify(ctx context.Context, req UserTotpVerifyRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_totp.Verify")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	m, err := read(ctx, repo.DbConn, dbTx, req.UserID)
+	if err != nil {
+		if errors.Cause(err) == ErrNotFound {
+			return errors.WithStack(ErrNotEnrolled)
+		}
+		return err
+	} else if !m.IsVerified() {
+		return errors.WithStack(ErrNotEnrolled)
+	}
+
+	if err := verifyCode(ctx, dbTx, m, req.Code, now); err != nil {
+		return err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// Reset removes the secret and recovery codes of a user so they can sign in without a code and
+// enroll again. Used by admins when a user has lost their device and recovery codes.
+func (repo *Repository) Reset(ctx context.Context, claims auth.Claims, req UserTotpResetRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_totp.Reset")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the claims can reset the two-factor authentication of the user.
+	err = repo.CanModifyUserTotp(ctx, claims, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	for _, tableName := range []string{UserTotpRecoveryCodeTableName, UserTotpChallengeTableName, UserTotpTableName} {
+		query := sqlbuilder.NewDeleteBuilder()
+		query.DeleteFrom(tableName)
+		query.Where(query.Equal("user_id", req.UserID))
+
+		sql, args := query.Build()
+		sql = dbTx.Rebind(sql)
+		_, err = dbTx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			err = errors.WithMessagef(err, "reset two-factor authentication for user %s failed", req.UserID)
+			return err
+		}
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// userTotpChallengeMapColumns is the list of columns needed for read.
+var userTotpChallengeMapColumns = "id,user_id,account_id,attempts,expires_at,used_at,created_at"
+
+// readChallenge internal method for getting a challenge, when the transaction is provided the
+// row is locked until it ends.
+func readChallenge(ctx context.Context, dbConn *sqlx.DB, dbTx *sqlx.Tx, id string) (*UserTotpChallenge, error) {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Select(userTotpChallengeMapColumns)
+	query.From(UserTotpChallengeTableName)
+	query.Where(query.Equal("id", id))
+
+	queryStr, queryArgs := query.Build()
+
+	var row *sql.Row
+	if dbTx != nil {
+		queryStr = dbTx.Rebind(queryStr) + " FOR UPDATE"
+		row = dbTx.QueryRowContext(ctx, queryStr, queryArgs...)
+	} else {
+		queryStr = dbConn.Rebind(queryStr)
+		row = dbConn.QueryRowContext(ctx, queryStr, queryArgs...)
+	}
+
+	var m UserTotpChallenge
+	err := row.Scan(&m.ID, &m.UserID, &m.AccountID, &m.Attempts, &m.ExpiresAt, &m.UsedAt, &m.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.WithMessagef(ErrNotFound, "two-factor authentication challenge %s not found", id)
+		}
+		err = errors.Wrapf(err, "query - %s", query.String())
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// CreateChallenge issues a challenge for a user that has verified their first factor. The
+// account is the one the user requested to sign in to.
+func (repo *Repository) CreateChallenge(ctx context.Context, req UserTotpChallengeCreateRequest, now time.Time) (*UserTotpChallenge, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_totp.CreateChallenge")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	m := UserTotpChallenge{
+		ID:        uuid.NewRandom().String(),
+		UserID:    req.UserID,
+		AccountID: req.AccountID,
+		ExpiresAt: now.Add(challengeTTL),
+		CreatedAt: now,
+	}
+
+	// Build the insert SQL statement.
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(UserTotpChallengeTableName)
+	query.Cols("id", "user_id", "account_id", "expires_at", "created_at")
+	query.Values(m.ID, m.UserID, m.AccountID, m.ExpiresAt, m.CreatedAt)
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "create two-factor authentication challenge for user %s failed", m.UserID)
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// ReadChallenge gets a challenge that can still be completed.
+func (repo *Repository) ReadChallenge(ctx context.Context, id string, now time.Time) (*UserTotpChallenge, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_totp.ReadChallenge")
+	defer span.Finish()
+
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	m, err := readChallenge(ctx, repo.DbConn, nil, id)
+	if err != nil {
+		return nil, err
+	} else if !m.IsActive(now) {
+		return nil, errors.WithMessagef(ErrChallengeExpired, "challenge %s", m.ID)
+	}
+
+	return m, nil
+}
+
+// VerifyChallenge completes a challenge with a code of the user. When the user has not confirmed
+// enrollment yet the code confirms it, this allows enrollment to be required before sign in is
+// completed. Invalid codes count towards the attempts of the challenge.
+func (repo *Repository) VerifyChallenge(ctx context.Context, req UserTotpChallengeVerifyRequest, now time.Time) (*UserTotpChallenge, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.user_totp.VerifyChallenge")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	ch, err := readChallenge(ctx, repo.DbConn, dbTx, req.ChallengeID)
+	if err != nil {
+		return nil, err
+	} else if !ch.IsActive(now) {
+		return nil, errors.WithMessagef(ErrChallengeExpired, "challenge %s", ch.ID)
+	}
+
+	m, err := read(ctx, repo.DbConn, dbTx, ch.UserID)
+	if err != nil {
+		if errors.Cause(err) == ErrNotFound {
+			return nil, errors.WithStack(ErrNotEnrolled)
+		}
+		return nil, err
+	}
+
+	if m.IsVerified() {
+		err = verifyCode(ctx, dbTx, m, req.Code, now)
+	} else {
+		// Only codes from the authenticator app can confirm enrollment.
+		var (
+			step int64
+			ok   bool
+		)
+		step, ok, err = matchCode(m.Secret, req.Code, now, m.LastUsedStep)
+		if err == nil && !ok {
+			err = errors.WithStack(ErrInvalidCode)
+		} else if err == nil {
+			err = confirm(ctx, dbTx, m, step, now)
+			ch.Enrolled = true
+		}
+	}
+	if err != nil {
+		if errors.Cause(err) != ErrInvalidCode {
+			return nil, err
+		}
+
+		// Record the attempt, the transaction is committed so the attempt counts even though an
+		// error is returned.
+		query := sqlbuilder.NewUpdateBuilder()
+		query.Update(UserTotpChallengeTableName)
+		query.Set(query.Incr("attempts"))
+		query.Where(query.Equal("id", ch.ID))
+
+		sql, args := query.Build()
+		sql = dbTx.Rebind(sql)
+		if _, err := dbTx.ExecContext(ctx, sql, args...); err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			err = errors.WithMessagef(err, "update two-factor authentication challenge %s failed", ch.ID)
+			return nil, err
+		}
+		if err := dbTx.Commit(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return nil, err
+	}
+
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(UserTotpChallengeTableName)
+	query.Set(query.Assign("used_at", now))
+	query.Where(query.Equal("id", ch.ID))
+
+	sql, args := query.Build()
+	sql = dbTx.Rebind(sql)
+	_, err = dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "update two-factor authentication challenge %s failed", ch.ID)
+		return nil, err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ch.UsedAt = &pq.NullTime{Time: now, Valid: true}
+
+	return ch, nil
+}
@@ -0,0 +1,310 @@
+package user_totp
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/tests"
+	"exitor-dapp/internal/user_account"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+var test *tests.Test
+
+// TestMain is the entry point for testing.
+func TestMain(m *testing.M) {
+	os.Exit(testMain(m))
+}
+
+func testMain(m *testing.M) int {
+	test = tests.New()
+	defer test.TearDown()
+	return m.Run()
+}
+
+// TestTotpCode validates codes are generated with the test vectors of RFC 6238.
+func TestTotpCode(t *testing.T) {
+	// The secret of the test vectors is the ASCII string 12345678901234567890.
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	var vectors = []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	t.Log("Given the need to generate codes for a secret.")
+	{
+		for i, tt := range vectors {
+			code, err := totpCode(secret, totpStep(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Log("\t\tGot :", err)
+				t.Fatalf("\t%s\tTest %d totpCode failed.", tests.Failed, i)
+			} else if code != tt.code {
+				t.Logf("\t\tGot : %s", code)
+				t.Logf("\t\tWant: %s", tt.code)
+				t.Fatalf("\t%s\tTest %d code does not match.", tests.Failed, i)
+			}
+		}
+		t.Logf("\t%s\ttotpCode ok.", tests.Success)
+	}
+}
+
+// TestEnroll validates a user enrolling, codes only being verified once and admins resetting
+// the enrollment of the users of their account.
+func TestEnroll(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.April, 11, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	repo := NewRepository(test.MasterDB)
+
+	usrAcc, err := user_account.MockUserAccount(ctx, test.MasterDB, now, user_account.UserAccountRole_User)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockUserAccount failed.", tests.Failed)
+	}
+
+	userClaims := auth.Claims{
+		Roles: []string{auth.RoleUser},
+		StandardClaims: jwt.StandardClaims{
+			Audience: usrAcc.AccountID,
+			Subject:  usrAcc.UserID,
+		},
+	}
+	adminClaims := auth.Claims{
+		Roles: []string{auth.RoleAdmin},
+		StandardClaims: jwt.StandardClaims{
+			Audience: usrAcc.AccountID,
+			Subject:  uuid.NewRandom().String(),
+		},
+	}
+
+	t.Log("Given the need to enroll a user in two-factor authentication.")
+	{
+		_, err = repo.Enroll(ctx, adminClaims, UserTotpEnrollRequest{UserID: usrAcc.UserID}, now)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tEnroll by an admin should fail.", tests.Failed)
+		}
+
+		m, err := repo.Enroll(ctx, userClaims, UserTotpEnrollRequest{UserID: usrAcc.UserID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tEnroll failed.", tests.Failed)
+		} else if m.Secret == "" || m.IsVerified() {
+			t.Logf("\t\tGot : %+v", m)
+			t.Fatalf("\t%s\tExpected an unverified secret.", tests.Failed)
+		}
+
+		again, err := repo.Enroll(ctx, userClaims, UserTotpEnrollRequest{UserID: usrAcc.UserID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tEnroll failed.", tests.Failed)
+		} else if again.Secret != m.Secret {
+			t.Fatalf("\t%s\tExpected enrolling again to return the same secret.", tests.Failed)
+		}
+		t.Logf("\t%s\tEnroll ok.", tests.Success)
+
+		enrolled, err := repo.IsEnrolled(ctx, usrAcc.UserID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tIsEnrolled failed.", tests.Failed)
+		} else if enrolled {
+			t.Fatalf("\t%s\tExpected the user to not be enrolled before confirming.", tests.Failed)
+		}
+
+		code, err := totpCode(m.Secret, totpStep(now))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\ttotpCode failed.", tests.Failed)
+		}
+
+		err = repo.Confirm(ctx, userClaims, UserTotpConfirmRequest{UserID: usrAcc.UserID, Code: code}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tConfirm failed.", tests.Failed)
+		}
+
+		enrolled, err = repo.IsEnrolled(ctx, usrAcc.UserID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tIsEnrolled failed.", tests.Failed)
+		} else if !enrolled {
+			t.Fatalf("\t%s\tExpected the user to be enrolled after confirming.", tests.Failed)
+		}
+		t.Logf("\t%s\tConfirm ok.", tests.Success)
+
+		// The code used to confirm can't be used again.
+		err = repo.Verify(ctx, UserTotpVerifyRequest{UserID: usrAcc.UserID, Code: code}, now)
+		if errors.Cause(err) != ErrInvalidCode {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrInvalidCode)
+			t.Fatalf("\t%s\tVerify with a used code should fail.", tests.Failed)
+		}
+
+		next := now.Add(time.Second * totpPeriod)
+		code, err = totpCode(m.Secret, totpStep(next))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\ttotpCode failed.", tests.Failed)
+		}
+
+		err = repo.Verify(ctx, UserTotpVerifyRequest{UserID: usrAcc.UserID, Code: code}, next)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tVerify failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tVerify ok.", tests.Success)
+
+		codes, err := repo.RegenerateRecoveryCodes(ctx, userClaims, UserTotpRecoveryCodesRequest{UserID: usrAcc.UserID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tRegenerateRecoveryCodes failed.", tests.Failed)
+		} else if len(codes) != recoveryCodeCount {
+			t.Logf("\t\tGot : %d", len(codes))
+			t.Logf("\t\tWant: %d", recoveryCodeCount)
+			t.Fatalf("\t%s\tExpected recovery codes.", tests.Failed)
+		}
+
+		err = repo.Verify(ctx, UserTotpVerifyRequest{UserID: usrAcc.UserID, Code: codes[0]}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tVerify with a recovery code failed.", tests.Failed)
+		}
+
+		err = repo.Verify(ctx, UserTotpVerifyRequest{UserID: usrAcc.UserID, Code: codes[0]}, now)
+		if errors.Cause(err) != ErrInvalidCode {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrInvalidCode)
+			t.Fatalf("\t%s\tVerify with a used recovery code should fail.", tests.Failed)
+		}
+
+		m, err = repo.ReadByUserID(ctx, userClaims, usrAcc.UserID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReadByUserID failed.", tests.Failed)
+		} else if m.RecoveryCodesRemaining != recoveryCodeCount-1 {
+			t.Logf("\t\tGot : %d", m.RecoveryCodesRemaining)
+			t.Logf("\t\tWant: %d", recoveryCodeCount-1)
+			t.Fatalf("\t%s\tExpected the used recovery code to be excluded.", tests.Failed)
+		}
+		t.Logf("\t%s\tVerify with a recovery code ok.", tests.Success)
+
+		err = repo.Reset(ctx, adminClaims, UserTotpResetRequest{UserID: usrAcc.UserID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tReset failed.", tests.Failed)
+		}
+
+		enrolled, err = repo.IsEnrolled(ctx, usrAcc.UserID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tIsEnrolled failed.", tests.Failed)
+		} else if enrolled {
+			t.Fatalf("\t%s\tExpected the user to not be enrolled after reset.", tests.Failed)
+		}
+		t.Logf("\t%s\tReset by an admin of the account ok.", tests.Success)
+	}
+}
+
+// TestVerifyChallenge validates challenges confirming enrollment and being rejected after too
+// many invalid codes.
+func TestVerifyChallenge(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.April, 11, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	repo := NewRepository(test.MasterDB)
+
+	usrAcc, err := user_account.MockUserAccount(ctx, test.MasterDB, now, user_account.UserAccountRole_User)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockUserAccount failed.", tests.Failed)
+	}
+
+	t.Log("Given the need to complete a sign in with a code.")
+	{
+		m, err := repo.Enroll(ctx, auth.Claims{}, UserTotpEnrollRequest{UserID: usrAcc.UserID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tEnroll failed.", tests.Failed)
+		}
+
+		ch, err := repo.CreateChallenge(ctx, UserTotpChallengeCreateRequest{
+			UserID:    usrAcc.UserID,
+			AccountID: usrAcc.AccountID,
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreateChallenge failed.", tests.Failed)
+		}
+
+		code, err := totpCode(m.Secret, totpStep(now))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\ttotpCode failed.", tests.Failed)
+		}
+
+		res, err := repo.VerifyChallenge(ctx, UserTotpChallengeVerifyRequest{ChallengeID: ch.ID, Code: code}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tVerifyChallenge failed.", tests.Failed)
+		} else if !res.Enrolled || res.AccountID != usrAcc.AccountID {
+			t.Logf("\t\tGot : %+v", res)
+			t.Fatalf("\t%s\tExpected the challenge to confirm enrollment.", tests.Failed)
+		}
+
+		_, err = repo.VerifyChallenge(ctx, UserTotpChallengeVerifyRequest{ChallengeID: ch.ID, Code: code}, now)
+		if errors.Cause(err) != ErrChallengeExpired {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrChallengeExpired)
+			t.Fatalf("\t%s\tVerifyChallenge again should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tVerifyChallenge ok.", tests.Success)
+
+		ch, err = repo.CreateChallenge(ctx, UserTotpChallengeCreateRequest{UserID: usrAcc.UserID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreateChallenge failed.", tests.Failed)
+		}
+
+		for i := 0; i < maxChallengeAttempts; i++ {
+			_, err = repo.VerifyChallenge(ctx, UserTotpChallengeVerifyRequest{ChallengeID: ch.ID, Code: "000000"}, now)
+			if errors.Cause(err) != ErrInvalidCode {
+				t.Logf("\t\tGot : %+v", err)
+				t.Logf("\t\tWant: %+v", ErrInvalidCode)
+				t.Fatalf("\t%s\tVerifyChallenge with an invalid code should fail.", tests.Failed)
+			}
+		}
+
+		next := now.Add(time.Second * totpPeriod)
+		code, err = totpCode(m.Secret, totpStep(next))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\ttotpCode failed.", tests.Failed)
+		}
+
+		_, err = repo.VerifyChallenge(ctx, UserTotpChallengeVerifyRequest{ChallengeID: ch.ID, Code: code}, next)
+		if errors.Cause(err) != ErrChallengeExpired {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrChallengeExpired)
+			t.Fatalf("\t%s\tVerifyChallenge after too many attempts should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tVerifyChallenge after too many attempts rejected.", tests.Success)
+	}
+}
@@ -0,0 +1,265 @@
+package wallet
+
+import (
+	"context"
+	"time"
+
+	"exitor-dapp/internal/platform/web"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Repository defines the required dependencies for WalletAddress.
+type Repository struct {
+	DbConn *sqlx.DB
+}
+
+// NewRepository creates a new Repository that defines dependencies for WalletAddress.
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{
+		DbConn: db,
+	}
+}
+
+// WalletAddress represents an Algorand address linked to an account. Addresses with a user
+// belong to that user, ie: the wallet of an investor, else they belong to the account. Exitor
+// never holds the key of a linked address, the owner proves they hold it by signing a nonce.
+type WalletAddress struct {
+	ID             string       `json:"id" validate:"required,uuid" example:"5c8a4b4e-1f3d-4a2b-9c6e-7d8f9a0b1c2d"`
+	AccountID      string       `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	UserID         *string      `json:"user_id,omitempty" validate:"omitempty,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Address        string       `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Label          string       `json:"label" validate:"omitempty,max=200" example:"Treasury"`
+	DefaultIssuer  bool         `json:"default_issuer" example:"false"`
+	Nonce          *string      `json:"-" truss:"api-hide"`
+	NonceExpiresAt *pq.NullTime `json:"-" truss:"api-hide"`
+	VerifiedAt     *pq.NullTime `json:"verified_at,omitempty" truss:"api-read"`
+	CreatedAt      time.Time    `json:"created_at" truss:"api-read"`
+	UpdatedAt      time.Time    `json:"updated_at" truss:"api-read"`
+	ArchivedAt     *pq.NullTime `json:"archived_at,omitempty" truss:"api-hide"`
+}
+
+// IsVerified returns true when the owner of the address has proven they hold its key.
+func (m *WalletAddress) IsVerified() bool {
+	return m.VerifiedAt != nil && m.VerifiedAt.Valid && !m.VerifiedAt.Time.IsZero()
+}
+
+// WalletAddressResponse represents a wallet address that is returned for display.
+type WalletAddressResponse struct {
+	ID            string            `json:"id" example:"5c8a4b4e-1f3d-4a2b-9c6e-7d8f9a0b1c2d"`
+	AccountID     string            `json:"account_id" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	UserID        string            `json:"user_id,omitempty" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Address       string            `json:"address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Label         string            `json:"label" example:"Treasury"`
+	DefaultIssuer bool              `json:"default_issuer" example:"false"`
+	Verified      bool              `json:"verified" example:"true"`
+	VerifiedAt    *web.TimeResponse `json:"verified_at,omitempty"` // VerifiedAt contains multiple format options for display.
+	CreatedAt     web.TimeResponse  `json:"created_at"`            // CreatedAt contains multiple format options for display.
+	UpdatedAt     web.TimeResponse  `json:"updated_at"`            // UpdatedAt contains multiple format options for display.
+	ArchivedAt    *web.TimeResponse `json:"archived_at,omitempty"` // ArchivedAt contains multiple format options for display.
+}
+
+// Response transforms WalletAddress to the WalletAddressResponse that is used for display.
+func (m *WalletAddress) Response(ctx context.Context) *WalletAddressResponse {
+	if m == nil {
+		return nil
+	}
+
+	r := &WalletAddressResponse{
+		ID:            m.ID,
+		AccountID:     m.AccountID,
+		Address:       m.Address,
+		Label:         m.Label,
+		DefaultIssuer: m.DefaultIssuer,
+		Verified:      m.IsVerified(),
+		CreatedAt:     web.NewTimeResponse(ctx, m.CreatedAt),
+		UpdatedAt:     web.NewTimeResponse(ctx, m.UpdatedAt),
+	}
+
+	if m.UserID != nil {
+		r.UserID = *m.UserID
+	}
+
+	if m.IsVerified() {
+		at := web.NewTimeResponse(ctx, m.VerifiedAt.Time)
+		r.VerifiedAt = &at
+	}
+
+	if m.ArchivedAt != nil && !m.ArchivedAt.Time.IsZero() {
+		at := web.NewTimeResponse(ctx, m.ArchivedAt.Time)
+		r.ArchivedAt = &at
+	}
+
+	return r
+}
+
+// WalletAddresses a list of WalletAddresses.
+type WalletAddresses []*WalletAddress
+
+// Response transforms a list of WalletAddresses to a list of WalletAddressResponses.
+func (m *WalletAddresses) Response(ctx context.Context) []*WalletAddressResponse {
+	var l []*WalletAddressResponse
+	if m != nil && len(*m) > 0 {
+		for _, n := range *m {
+			l = append(l, n.Response(ctx))
+		}
+	}
+
+	return l
+}
+
+// WalletAddressCreateRequest contains information needed to link an address to an account. When
+// no user is set the address is linked to the current user unless they are an admin.
+type WalletAddressCreateRequest struct {
+	AccountID string  `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	UserID    *string `json:"user_id,omitempty" validate:"omitempty,uuid" example:"d69bdef7-173f-4d29-b52c-3edc60baf6a2"`
+	Address   string  `json:"address" validate:"required,algorand_address,unique" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Label     string  `json:"label" validate:"omitempty,max=200" example:"Treasury"`
+}
+
+// WalletAddressReadRequest defines the information needed to read a wallet address.
+type WalletAddressReadRequest struct {
+	ID              string `json:"id" validate:"required,uuid" example:"5c8a4b4e-1f3d-4a2b-9c6e-7d8f9a0b1c2d"`
+	IncludeArchived bool   `json:"include-archived" example:"false"`
+}
+
+// WalletAddressChallenge is the message the owner of an address must sign to prove they hold
+// its key. The message includes a nonce issued by the server that is only valid until it expires.
+type WalletAddressChallenge struct {
+	ID        string    `json:"id" example:"5c8a4b4e-1f3d-4a2b-9c6e-7d8f9a0b1c2d"`
+	Address   string    `json:"address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Message   string    `json:"message" example:"Exitor wallet verification: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// WalletAddressVerifyRequest defines the information needed to verify the ownership of an
+// address with the signature of its challenge.
+type WalletAddressVerifyRequest struct {
+	ID        string `json:"id" validate:"required,uuid" example:"5c8a4b4e-1f3d-4a2b-9c6e-7d8f9a0b1c2d"`
+	Signature string `json:"signature" validate:"required,base64" example:"bWVzc2FnZQ=="`
+}
+
+// WalletAddressLoginChallengeRequest defines the information needed to issue the message signed
+// to sign in with an address. The account is only required when the address was verified by
+// users of multiple accounts.
+type WalletAddressLoginChallengeRequest struct {
+	Address   string `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	AccountID string `json:"account_id" validate:"omitempty,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+}
+
+// WalletAddressLoginRequest defines the information needed to sign in with the signature of the
+// login challenge of an address.
+type WalletAddressLoginRequest struct {
+	Address   string `json:"address" validate:"required,algorand_address" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	AccountID string `json:"account_id" validate:"omitempty,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Signature string `json:"signature" validate:"required,base64" example:"bWVzc2FnZQ=="`
+}
+
+// WalletAddressDefaultIssuerRequest defines the information needed to set the address used by
+// default to issue the assets of its account.
+type WalletAddressDefaultIssuerRequest struct {
+	ID string `json:"id" validate:"required,uuid" example:"5c8a4b4e-1f3d-4a2b-9c6e-7d8f9a0b1c2d"`
+}
+
+// WalletAddressArchiveRequest defines the information needed to unlink an address.
+type WalletAddressArchiveRequest struct {
+	ID string `json:"id" validate:"required,uuid" example:"5c8a4b4e-1f3d-4a2b-9c6e-7d8f9a0b1c2d"`
+}
+
+// WalletAddressFindRequest defines the possible options to search for wallet addresses. By default
+// archived addresses will be excluded from response.
+type WalletAddressFindRequest struct {
+	Where           string        `json:"where" example:"address = ?"`
+	Args            []interface{} `json:"args" swaggertype:"array,string" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	Order           []string      `json:"order" example:"created_at desc"`
+	Limit           *uint         `json:"limit" example:"10"`
+	Offset          *uint         `json:"offset" example:"20"`
+	IncludeArchived bool          `json:"include-archived" example:"false"`
+}
+
+// WalletMultisig represents a multisig account of an account, ie: the directors of a company that
+// must approve the management of its assets. The address is derived from the version, threshold
+// and the ordered member addresses, transactions sent from it must be signed by at least the
+// threshold of members.
+type WalletMultisig struct {
+	ID         string         `json:"id" validate:"required,uuid" example:"0b1b6a3e-3a4f-4f5e-9a35-5b3c9f5a2d11"`
+	AccountID  string         `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Address    string         `json:"address" validate:"required,algorand_address" example:"UCE2U2JC4O4ZR6W763GUQCG57HQCDZEUJY4J5I6VYY4HQZUJDF7AKZO5GM"`
+	Label      string         `json:"label" validate:"omitempty,max=200" example:"Board of Directors"`
+	Version    int            `json:"version" example:"1"`
+	Threshold  int            `json:"threshold" validate:"required,min=1" example:"2"`
+	Members    pq.StringArray `json:"members" validate:"required,min=1,dive,algorand_address" swaggertype:"array,string" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	CreatedAt  time.Time      `json:"created_at" truss:"api-read"`
+	UpdatedAt  time.Time      `json:"updated_at" truss:"api-read"`
+	ArchivedAt *pq.NullTime   `json:"archived_at,omitempty" truss:"api-hide"`
+}
+
+// WalletMultisigResponse represents a multisig account that is returned for display.
+type WalletMultisigResponse struct {
+	ID         string            `json:"id" example:"0b1b6a3e-3a4f-4f5e-9a35-5b3c9f5a2d11"`
+	AccountID  string            `json:"account_id" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Address    string            `json:"address" example:"UCE2U2JC4O4ZR6W763GUQCG57HQCDZEUJY4J5I6VYY4HQZUJDF7AKZO5GM"`
+	Label      string            `json:"label" example:"Board of Directors"`
+	Version    int               `json:"version" example:"1"`
+	Threshold  int               `json:"threshold" example:"2"`
+	Members    []string          `json:"members" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+	CreatedAt  web.TimeResponse  `json:"created_at"`            // CreatedAt contains multiple format options for display.
+	UpdatedAt  web.TimeResponse  `json:"updated_at"`            // UpdatedAt contains multiple format options for display.
+	ArchivedAt *web.TimeResponse `json:"archived_at,omitempty"` // ArchivedAt contains multiple format options for display.
+}
+
+// Response transforms WalletMultisig to the WalletMultisigResponse that is used for display.
+func (m *WalletMultisig) Response(ctx context.Context) *WalletMultisigResponse {
+	if m == nil {
+		return nil
+	}
+
+	r := &WalletMultisigResponse{
+		ID:        m.ID,
+		AccountID: m.AccountID,
+		Address:   m.Address,
+		Label:     m.Label,
+		Version:   m.Version,
+		Threshold: m.Threshold,
+		Members:   m.Members,
+		CreatedAt: web.NewTimeResponse(ctx, m.CreatedAt),
+		UpdatedAt: web.NewTimeResponse(ctx, m.UpdatedAt),
+	}
+
+	if m.ArchivedAt != nil && !m.ArchivedAt.Time.IsZero() {
+		at := web.NewTimeResponse(ctx, m.ArchivedAt.Time)
+		r.ArchivedAt = &at
+	}
+
+	return r
+}
+
+// WalletMultisigs a list of WalletMultisigs.
+type WalletMultisigs []*WalletMultisig
+
+// Response transforms a list of WalletMultisigs to a list of WalletMultisigResponses.
+func (m *WalletMultisigs) Response(ctx context.Context) []*WalletMultisigResponse {
+	var l []*WalletMultisigResponse
+	if m != nil && len(*m) > 0 {
+		for _, n := range *m {
+			l = append(l, n.Response(ctx))
+		}
+	}
+
+	return l
+}
+
+// WalletMultisigCreateRequest contains information needed to define a multisig account for an
+// account. The order of the members is part of the derived address.
+type WalletMultisigCreateRequest struct {
+	AccountID string   `json:"account_id" validate:"required,uuid" example:"c4653bf9-5978-48b7-89c5-95704aebb7e2"`
+	Label     string   `json:"label" validate:"omitempty,max=200" example:"Board of Directors"`
+	Threshold int      `json:"threshold" validate:"required,min=1,max=255" example:"2"`
+	Members   []string `json:"members" validate:"required,min=1,max=255,dive,algorand_address" swaggertype:"array,string" example:"EW64GC6F24M7NDSC5R3ES4YUVE3ZXXNMARJHDCCCLIHZU6TBEOC7XRSBG4"`
+}
+
+// WalletMultisigArchiveRequest defines the information needed to remove a multisig account.
+type WalletMultisigArchiveRequest struct {
+	ID string `json:"id" validate:"required,uuid" example:"0b1b6a3e-3a4f-4f5e-9a35-5b3c9f5a2d11"`
+}
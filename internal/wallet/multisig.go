@@ -0,0 +1,332 @@
+package wallet
+
+import (
+	"context"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* Multisig accounts let the roles of an asset, ie: the manager, freeze and
+clawback addresses, be held by a group of keys instead of a single key. The
+definition of the multisig is stored for the account so the address can be
+derived and the members that still have to sign a transaction can be shown.
+Exitor never holds the keys of the members, each member signs offline. */
+
+const (
+	// The database table for multisig accounts
+	WalletMultisigTableName = "wallet_multisigs"
+
+	// MultisigVersion is the version of the multisig accounts defined.
+	MultisigVersion = 1
+)
+
+var (
+	// ErrInvalidMultisig occurs when the threshold of a multisig is more than the number of its
+	// members or a member is listed more than once.
+	ErrInvalidMultisig = errors.New("Invalid multisig threshold or members")
+
+	// ErrMultisigExists occurs when a multisig with the same address is already defined for
+	// the account.
+	ErrMultisigExists = errors.New("Multisig already exists")
+)
+
+// MultisigAddress derives the address of the multisig account for the threshold and ordered
+// member addresses.
+func MultisigAddress(threshold int, members []string) (string, error) {
+	ma, err := multisigAccount(MultisigVersion, threshold, members)
+	if err != nil {
+		return "", err
+	}
+
+	addr, err := ma.Address()
+	if err != nil {
+		return "", errors.WithMessage(ErrInvalidMultisig, err.Error())
+	}
+
+	return addr.String(), nil
+}
+
+// MultisigAccount returns the multisig account used to sign transactions sent from the address.
+func (m *WalletMultisig) MultisigAccount() (crypto.MultisigAccount, error) {
+	return multisigAccount(m.Version, m.Threshold, m.Members)
+}
+
+// multisigAccount builds the multisig account for the version, threshold and members.
+func multisigAccount(version, threshold int, members []string) (crypto.MultisigAccount, error) {
+	if threshold < 1 || threshold > len(members) || threshold > 255 {
+		return crypto.MultisigAccount{}, errors.WithMessagef(ErrInvalidMultisig, "threshold %d of %d members", threshold, len(members))
+	}
+
+	seen := make(map[string]bool)
+	var addrs []types.Address
+	for _, a := range members {
+		if seen[a] {
+			return crypto.MultisigAccount{}, errors.WithMessagef(ErrInvalidMultisig, "member %s is listed more than once", a)
+		}
+		seen[a] = true
+
+		addr, err := types.DecodeAddress(a)
+		if err != nil {
+			return crypto.MultisigAccount{}, errors.WithMessagef(ErrInvalidMultisig, "member %s: %s", a, err)
+		}
+		addrs = append(addrs, addr)
+	}
+
+	ma, err := crypto.MultisigAccountWithParams(uint8(version), uint8(threshold), addrs)
+	if err != nil {
+		return crypto.MultisigAccount{}, errors.WithMessage(ErrInvalidMultisig, err.Error())
+	}
+
+	return ma, nil
+}
+
+// walletMultisigMapColumns is the list of columns needed for find.
+var walletMultisigMapColumns = "id,account_id,address,label,version,threshold,members,created_at,updated_at,archived_at"
+
+// findMultisigs internal method for getting the multisig accounts from the database using a select query.
+// Every user of an account can view its multisig accounts as they might be one of the members.
+func findMultisigs(ctx context.Context, claims auth.Claims, dbConn *sqlx.DB, query *sqlbuilder.SelectBuilder, includedArchived bool) (WalletMultisigs, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.wallet.FindMultisigs")
+	defer span.Finish()
+
+	query.Select(walletMultisigMapColumns)
+	query.From(WalletMultisigTableName)
+	if !includedArchived {
+		query.Where(query.IsNull("archived_at"))
+	}
+
+	if claims.Audience != "" {
+		query.Where(query.Equal("account_id", claims.Audience))
+	}
+
+	queryStr, args := query.Build()
+	queryStr = dbConn.Rebind(queryStr)
+
+	// Fetch all entries from the db.
+	rows, err := dbConn.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find wallet multisigs failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Iterate over each row.
+	resp := []*WalletMultisig{}
+	for rows.Next() {
+		var m WalletMultisig
+		err = rows.Scan(&m.ID, &m.AccountID, &m.Address, &m.Label, &m.Version, &m.Threshold, &m.Members,
+			&m.CreatedAt, &m.UpdatedAt, &m.ArchivedAt)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+
+		resp = append(resp, &m)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find wallet multisigs failed")
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// FindMultisigs gets all the multisig accounts of the account.
+func (repo *Repository) FindMultisigs(ctx context.Context, claims auth.Claims, accountID string) (WalletMultisigs, error) {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("account_id", accountID))
+	query.OrderBy("created_at")
+
+	return findMultisigs(ctx, claims, repo.DbConn, query, false)
+}
+
+// ReadMultisigByID gets the specified multisig account by ID from the database.
+func (repo *Repository) ReadMultisigByID(ctx context.Context, claims auth.Claims, id string) (*WalletMultisig, error) {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("id", id))
+
+	res, err := findMultisigs(ctx, claims, repo.DbConn, query, false)
+	if err != nil {
+		return nil, err
+	} else if len(res) == 0 {
+		err = errors.WithMessagef(ErrNotFound, "wallet multisig %s not found", id)
+		return nil, err
+	}
+
+	return res[0], nil
+}
+
+// FindMultisigByAddress gets the multisig account of the account with the address. It's used to
+// determine if a transaction sent from the address must be signed by the members of a multisig.
+func FindMultisigByAddress(ctx context.Context, dbConn *sqlx.DB, accountID, address string) (*WalletMultisig, error) {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("account_id", accountID),
+		query.Equal("address", address),
+	))
+
+	res, err := findMultisigs(ctx, auth.Claims{}, dbConn, query, false)
+	if err != nil {
+		return nil, err
+	} else if len(res) == 0 {
+		err = errors.WithMessagef(ErrNotFound, "wallet multisig %s not found", address)
+		return nil, err
+	}
+
+	return res[0], nil
+}
+
+// CreateMultisig defines a new multisig account for an account and derives its address.
+func (repo *Repository) CreateMultisig(ctx context.Context, claims auth.Claims, req WalletMultisigCreateRequest, now time.Time) (*WalletMultisig, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.wallet.CreateMultisig")
+	defer span.Finish()
+
+	if claims.Audience != "" {
+		if req.AccountID != "" {
+			// Request accountId must match claims.
+			if req.AccountID != claims.Audience {
+				return nil, errors.WithStack(ErrForbidden)
+			}
+		} else {
+			// Set the accountId from claims.
+			req.AccountID = claims.Audience
+		}
+
+		// Multisig accounts hold the roles of the assets of the account.
+		if !claims.HasPermission(auth.PermissionAccountManage) {
+			return nil, errors.WithStack(ErrForbidden)
+		}
+	}
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.StructCtx(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := MultisigAddress(req.Threshold, req.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := FindMultisigByAddress(ctx, repo.DbConn, req.AccountID, address)
+	if err != nil && errors.Cause(err) != ErrNotFound {
+		return nil, err
+	} else if existing != nil {
+		return nil, errors.WithMessagef(ErrMultisigExists, "address %s", address)
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	m := WalletMultisig{
+		ID:        uuid.NewRandom().String(),
+		AccountID: req.AccountID,
+		Address:   address,
+		Label:     req.Label,
+		Version:   MultisigVersion,
+		Threshold: req.Threshold,
+		Members:   req.Members,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// Build the insert SQL statement.
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(WalletMultisigTableName)
+	query.Cols("id", "account_id", "address", "label", "version", "threshold", "members", "created_at", "updated_at")
+	query.Values(m.ID, m.AccountID, m.Address, m.Label, m.Version, m.Threshold, m.Members, m.CreatedAt, m.UpdatedAt)
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "create wallet multisig failed")
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// ArchiveMultisig soft deleted the multisig account from the database. Assets with roles held
+// by the address are not changed, their transactions can still be signed offline.
+func (repo *Repository) ArchiveMultisig(ctx context.Context, claims auth.Claims, req WalletMultisigArchiveRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.wallet.ArchiveMultisig")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	if claims.Audience != "" && !claims.HasPermission(auth.PermissionAccountManage) {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	// Ensure the multisig belongs to the account of the claims.
+	m, err := repo.ReadMultisigByID(ctx, claims, req.ID)
+	if err != nil {
+		return err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(WalletMultisigTableName)
+	query.Set(
+		query.Assign("updated_at", now),
+		query.Assign("archived_at", now),
+	)
+	query.Where(query.Equal("id", m.ID))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "archive wallet multisig %s failed", m.ID)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,858 @@
+package wallet
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/web/webcontext"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+/* Wallet addresses link the Algorand accounts of users and issuers to Exitor.
+An address is only trusted once its owner has signed a nonce issued by the
+server with the key of the address. Verified addresses can receive assets and
+one of them can be set as the default issuer of the assets of the account. */
+
+const (
+	// The database table for wallet addresses
+	WalletAddressTableName = "wallet_addresses"
+
+	// ChallengePrefix is prepended to the nonce of the message signed to verify an address.
+	ChallengePrefix = "Exitor wallet verification: "
+
+	// LoginPrefix is prepended to the nonce of the message signed to sign in with an address.
+	LoginPrefix = "Exitor sign in: "
+
+	// challengeTTL is the duration a nonce can be signed before a new one must be issued.
+	challengeTTL = 15 * time.Minute
+)
+
+var (
+	// ErrNotFound abstracts the postgres not found error.
+	ErrNotFound = errors.New("Entity not found")
+
+	// ErrForbidden occurs when a user tries to do something that is forbidden to them according to our access control policies.
+	ErrForbidden = errors.New("Attempted action is not allowed")
+
+	// ErrChallengeExpired occurs when the nonce of an address was not issued or has expired.
+	ErrChallengeExpired = errors.New("Wallet address challenge has expired")
+
+	// ErrNotVerified occurs when an address is used before its owner has signed a challenge.
+	ErrNotVerified = errors.New("Wallet address has not been verified")
+)
+
+// CanReadWalletAddress determines if claims has the authority to access the specified wallet address by id.
+func (repo *Repository) CanReadWalletAddress(ctx context.Context, claims auth.Claims, id string) error {
+	// If the request has claims from a specific account, ensure that the claims
+	// has the correct access to the wallet address.
+	if claims.Audience != "" {
+		// select id from wallet_addresses where account_id = [accountID] and id = [id]
+		query := sqlbuilder.NewSelectBuilder().Select("id").From(WalletAddressTableName)
+		query.Where(query.And(
+			query.Equal("account_id", claims.Audience),
+			query.Equal("id", id),
+		))
+
+		// Users can only access their own addresses.
+		if !claims.HasRole(auth.RoleAdmin) {
+			query.Where(query.Equal("user_id", claims.Subject))
+		}
+
+		queryStr, args := query.Build()
+		queryStr = repo.DbConn.Rebind(queryStr)
+
+		var walletAddressID string
+		err := repo.DbConn.QueryRowContext(ctx, queryStr, args...).Scan(&walletAddressID)
+		if err != nil && err != sql.ErrNoRows {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return err
+		}
+
+		// When there is no id returned, then the current claim user does not have access
+		// to the specified wallet address.
+		if walletAddressID == "" {
+			return errors.WithStack(ErrForbidden)
+		}
+	}
+
+	return nil
+}
+
+// CanModifyWalletAddress determines if claims has the authority to modify the specified wallet address by id.
+// Users can modify their own addresses and admins all the addresses of their account.
+func (repo *Repository) CanModifyWalletAddress(ctx context.Context, claims auth.Claims, id string) error {
+	return repo.CanReadWalletAddress(ctx, claims, id)
+}
+
+// applyClaimsSelect applies a sub-query to the provided query to enforce ACL based on the
+// claims provided.
+//  1. No claims, request is internal, no ACL applied
+//  2. Admins can access all the addresses of their account
+//  3. Users can only access their own addresses
+func applyClaimsSelect(ctx context.Context, claims auth.Claims, query *sqlbuilder.SelectBuilder) error {
+	// if claims are empty, don't apply any ACL
+	if claims.Audience == "" {
+		return nil
+	}
+
+	query.Where(query.Equal("account_id", claims.Audience))
+
+	if !claims.HasRole(auth.RoleAdmin) {
+		query.Where(query.Equal("user_id", claims.Subject))
+	}
+
+	return nil
+}
+
+// walletAddressMapColumns is the list of columns needed for find.
+var walletAddressMapColumns = "id,account_id,user_id,address,label,default_issuer,nonce,nonce_expires_at,verified_at," +
+	"created_at,updated_at,archived_at"
+
+// selectQuery constructs a base select query for WalletAddress.
+func selectQuery() *sqlbuilder.SelectBuilder {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Select(walletAddressMapColumns)
+	query.From(WalletAddressTableName)
+	return query
+}
+
+// findRequestQuery generates the select query for the given find request.
+func findRequestQuery(req WalletAddressFindRequest) (*sqlbuilder.SelectBuilder, []interface{}) {
+	query := selectQuery()
+
+	if req.Where != "" {
+		query.Where(query.And(req.Where))
+	}
+
+	if len(req.Order) > 0 {
+		query.OrderBy(req.Order...)
+	}
+
+	if req.Limit != nil {
+		query.Limit(int(*req.Limit))
+	}
+
+	if req.Offset != nil {
+		query.Offset(int(*req.Offset))
+	}
+
+	return query, req.Args
+}
+
+// Find gets all the wallet addresses from the database based on the request params.
+func (repo *Repository) Find(ctx context.Context, claims auth.Claims, req WalletAddressFindRequest) (WalletAddresses, error) {
+	query, args := findRequestQuery(req)
+	return find(ctx, claims, repo.DbConn, query, args, req.IncludeArchived)
+}
+
+// find internal method for getting all the wallet addresses from the database using a select query.
+func find(ctx context.Context, claims auth.Claims, dbConn *sqlx.DB, query *sqlbuilder.SelectBuilder, args []interface{}, includedArchived bool) (WalletAddresses, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.wallet.Find")
+	defer span.Finish()
+
+	query.Select(walletAddressMapColumns)
+	query.From(WalletAddressTableName)
+	if !includedArchived {
+		query.Where(query.IsNull("archived_at"))
+	}
+
+	// Check to see if a sub query needs to be applied for the claims
+	err := applyClaimsSelect(ctx, claims, query)
+	if err != nil {
+		return nil, err
+	}
+
+	queryStr, queryArgs := query.Build()
+	queryStr = dbConn.Rebind(queryStr)
+	args = append(args, queryArgs...)
+
+	// Fetch all entries from the db.
+	rows, err := dbConn.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find wallet addresses failed")
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Iterate over each row.
+	resp := []*WalletAddress{}
+	for rows.Next() {
+		var m WalletAddress
+		err = rows.Scan(&m.ID, &m.AccountID, &m.UserID, &m.Address, &m.Label, &m.DefaultIssuer, &m.Nonce,
+			&m.NonceExpiresAt, &m.VerifiedAt, &m.CreatedAt, &m.UpdatedAt, &m.ArchivedAt)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			return nil, err
+		}
+
+		resp = append(resp, &m)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "find wallet addresses failed")
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ReadByID gets the specified wallet address by ID from the database.
+func (repo *Repository) ReadByID(ctx context.Context, claims auth.Claims, id string) (*WalletAddress, error) {
+	return repo.Read(ctx, claims, WalletAddressReadRequest{
+		ID:              id,
+		IncludeArchived: false,
+	})
+}
+
+// Read gets the specified wallet address from the database.
+func (repo *Repository) Read(ctx context.Context, claims auth.Claims, req WalletAddressReadRequest) (*WalletAddress, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.wallet.Read")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter base select query by id.
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.Equal("id", req.ID))
+
+	res, err := find(ctx, claims, repo.DbConn, query, []interface{}{}, req.IncludeArchived)
+	if err != nil {
+		return nil, err
+	} else if res == nil || len(res) == 0 {
+		err = errors.WithMessagef(ErrNotFound, "wallet address %s not found", req.ID)
+		return nil, err
+	}
+
+	u := res[0]
+	return u, nil
+}
+
+// DefaultIssuer gets the verified address set as the default issuer of the account.
+func (repo *Repository) DefaultIssuer(ctx context.Context, claims auth.Claims, accountID string) (*WalletAddress, error) {
+	query := sqlbuilder.NewSelectBuilder()
+	query.Where(query.And(
+		query.Equal("account_id", accountID),
+		query.Equal("default_issuer", true),
+		query.IsNotNull("verified_at"),
+	))
+
+	res, err := find(ctx, claims, repo.DbConn, query, []interface{}{}, false)
+	if err != nil {
+		return nil, err
+	} else if res == nil || len(res) == 0 {
+		err = errors.WithMessagef(ErrNotFound, "default issuer of account %s not found", accountID)
+		return nil, err
+	}
+
+	return res[0], nil
+}
+
+// UniqueAddress validates the address is not already linked to the account excluding the
+// current wallet address ID.
+func UniqueAddress(ctx context.Context, dbConn *sqlx.DB, accountID, address, walletAddressID string) (bool, error) {
+	query := sqlbuilder.NewSelectBuilder().Select("id").From(WalletAddressTableName)
+	query.Where(query.And(
+		query.Equal("account_id", accountID),
+		query.Equal("address", address),
+		query.NotEqual("id", walletAddressID),
+		query.IsNull("archived_at"),
+	))
+	queryStr, args := query.Build()
+	queryStr = dbConn.Rebind(queryStr)
+
+	var existingID string
+	err := dbConn.QueryRowContext(ctx, queryStr, args...).Scan(&existingID)
+	if err != nil && err != sql.ErrNoRows {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		return false, err
+	}
+
+	// When an ID was found in the db, the address is not unique.
+	if existingID != "" {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Create links a new wallet address to an account. The address can not be used until its
+// owner has signed the challenge of the address.
+func (repo *Repository) Create(ctx context.Context, claims auth.Claims, req WalletAddressCreateRequest, now time.Time) (*WalletAddress, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.wallet.Create")
+	defer span.Finish()
+
+	if claims.Audience != "" {
+		if req.AccountID != "" {
+			// Request accountId must match claims.
+			if req.AccountID != claims.Audience {
+				return nil, errors.WithStack(ErrForbidden)
+			}
+		} else {
+			// Set the accountId from claims.
+			req.AccountID = claims.Audience
+		}
+
+		// Users can only link addresses to themselves, admins can also link addresses to
+		// the account or to any of its users.
+		if !claims.HasRole(auth.RoleAdmin) {
+			if req.UserID == nil {
+				req.UserID = &claims.Subject
+			} else if *req.UserID != claims.Subject {
+				return nil, errors.WithStack(ErrForbidden)
+			}
+		}
+	}
+
+	v := webcontext.Validator()
+
+	// Validation the address is unique for the account in the database.
+	uniq, err := UniqueAddress(ctx, repo.DbConn, req.AccountID, req.Address, "")
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, webcontext.KeyTagUnique, uniq)
+
+	// Validate the request.
+	err = v.StructCtx(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	m := WalletAddress{
+		ID:        uuid.NewRandom().String(),
+		AccountID: req.AccountID,
+		UserID:    req.UserID,
+		Address:   req.Address,
+		Label:     req.Label,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// Build the insert SQL statement.
+	query := sqlbuilder.NewInsertBuilder()
+	query.InsertInto(WalletAddressTableName)
+	query.Cols("id", "account_id", "user_id", "address", "label", "default_issuer", "created_at", "updated_at")
+	query.Values(m.ID, m.AccountID, m.UserID, m.Address, m.Label, m.DefaultIssuer, m.CreatedAt, m.UpdatedAt)
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessage(err, "create wallet address failed")
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Challenge issues a new nonce for the wallet address that its owner must sign to prove they
+// hold the key of the address. Any previous nonce of the address is replaced.
+func (repo *Repository) Challenge(ctx context.Context, claims auth.Claims, id string, now time.Time) (*WalletAddressChallenge, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.wallet.Challenge")
+	defer span.Finish()
+
+	// Ensure the claims can modify the wallet address specified in the request.
+	err := repo.CanModifyWalletAddress(ctx, claims, id)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.ReadByID(ctx, claims, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	nb := make([]byte, 32)
+	if _, err := rand.Read(nb); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	nonce := hex.EncodeToString(nb)
+	expiresAt := now.Add(challengeTTL)
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(WalletAddressTableName)
+	query.Set(
+		query.Assign("nonce", nonce),
+		query.Assign("nonce_expires_at", expiresAt),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.Equal("id", m.ID))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "issue challenge for wallet address %s failed", m.ID)
+		return nil, err
+	}
+
+	return &WalletAddressChallenge{
+		ID:        m.ID,
+		Address:   m.Address,
+		Message:   ChallengePrefix + nonce,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Verify checks the signature of the challenge of the wallet address was made with the key of
+// the address and marks the address as verified. The nonce can only be used once.
+func (repo *Repository) Verify(ctx context.Context, claims auth.Claims, req WalletAddressVerifyRequest, now time.Time) (*WalletAddress, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.wallet.Verify")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the claims can modify the wallet address specified in the request.
+	err = repo.CanModifyWalletAddress(ctx, claims, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := repo.ReadByID(ctx, claims, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	if m.Nonce == nil || *m.Nonce == "" || m.NonceExpiresAt == nil || !now.Before(m.NonceExpiresAt.Time) {
+		return nil, errors.WithMessagef(ErrChallengeExpired, "wallet address %s", m.ID)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(req.Signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode signature failed")
+	}
+
+	err = auth.VerifyAlgorandSignature(m.Address, []byte(ChallengePrefix+*m.Nonce), sig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(WalletAddressTableName)
+	query.Set(
+		query.Assign("nonce", nil),
+		query.Assign("nonce_expires_at", nil),
+		query.Assign("verified_at", now),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.And(
+		query.Equal("id", m.ID),
+		query.Equal("nonce", *m.Nonce),
+	))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	res, err := repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "verify wallet address %s failed", m.ID)
+		return nil, err
+	}
+
+	// The nonce was used by a concurrent request.
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, errors.WithStack(err)
+	} else if n == 0 {
+		return nil, errors.WithMessagef(ErrChallengeExpired, "wallet address %s", m.ID)
+	}
+
+	m.Nonce = nil
+	m.NonceExpiresAt = nil
+	m.VerifiedAt = &pq.NullTime{Time: now, Valid: true}
+	m.UpdatedAt = now
+
+	return m, nil
+}
+
+// loginQuery constructs the select query for the verified addresses of users that can be used
+// to sign in. The most recently verified address is returned first.
+func loginQuery(address, accountID string) *sqlbuilder.SelectBuilder {
+	query := selectQuery()
+	query.Where(query.And(
+		query.Equal("address", address),
+		query.IsNotNull("user_id"),
+		query.IsNotNull("verified_at"),
+	))
+	if accountID != "" {
+		query.Where(query.Equal("account_id", accountID))
+	}
+	query.OrderBy("verified_at desc")
+	return query
+}
+
+// LoginChallenge issues the message the owner of an address must sign to sign in as the user
+// that verified it. A message is returned for any valid address so the response can't be used
+// to find out which addresses are linked to Exitor.
+func (repo *Repository) LoginChallenge(ctx context.Context, req WalletAddressLoginChallengeRequest, now time.Time) (*WalletAddressChallenge, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.wallet.LoginChallenge")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	nb := make([]byte, 32)
+	if _, err := rand.Read(nb); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	nonce := hex.EncodeToString(nb)
+	expiresAt := now.Add(challengeTTL)
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(WalletAddressTableName)
+	query.Set(
+		query.Assign("nonce", nonce),
+		query.Assign("nonce_expires_at", expiresAt),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.And(
+		query.Equal("address", req.Address),
+		query.IsNotNull("user_id"),
+		query.IsNotNull("verified_at"),
+		query.IsNull("archived_at"),
+	))
+	if req.AccountID != "" {
+		query.Where(query.Equal("account_id", req.AccountID))
+	}
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "issue login challenge for wallet address %s failed", req.Address)
+		return nil, err
+	}
+
+	return &WalletAddressChallenge{
+		Address:   req.Address,
+		Message:   LoginPrefix + nonce,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// VerifyLogin ensures the signature was made over the login challenge of the address by its
+// key and returns the verified address of the user to sign in. Each challenge can only be
+// used once.
+func (repo *Repository) VerifyLogin(ctx context.Context, req WalletAddressLoginRequest, now time.Time) (*WalletAddress, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.wallet.VerifyLogin")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	res, err := find(ctx, auth.Claims{}, repo.DbConn, loginQuery(req.Address, req.AccountID), []interface{}{}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// All the addresses share the nonce of the last challenge, select the most recently
+	// verified one that still has a valid challenge.
+	var m *WalletAddress
+	for _, a := range res {
+		if a.Nonce != nil && *a.Nonce != "" && a.NonceExpiresAt != nil && now.Before(a.NonceExpiresAt.Time) {
+			m = a
+			break
+		}
+	}
+	if m == nil {
+		return nil, errors.WithMessagef(ErrChallengeExpired, "wallet address %s", req.Address)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(req.Signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode signature failed")
+	}
+
+	err = auth.VerifyAlgorandSignature(m.Address, []byte(LoginPrefix+*m.Nonce), sig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(WalletAddressTableName)
+	query.Set(
+		query.Assign("nonce", nil),
+		query.Assign("nonce_expires_at", nil),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.And(
+		query.Equal("address", m.Address),
+		query.Equal("nonce", *m.Nonce),
+	))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	ures, err := repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "verify login for wallet address %s failed", m.Address)
+		return nil, err
+	}
+
+	// The nonce was used by a concurrent request.
+	if n, err := ures.RowsAffected(); err != nil {
+		return nil, errors.WithStack(err)
+	} else if n == 0 {
+		return nil, errors.WithMessagef(ErrChallengeExpired, "wallet address %s", m.Address)
+	}
+
+	m.Nonce = nil
+	m.NonceExpiresAt = nil
+	m.UpdatedAt = now
+
+	return m, nil
+}
+
+// SetDefaultIssuer sets the verified wallet address as the address used by default to issue the
+// assets of its account, replacing the previous default issuer.
+func (repo *Repository) SetDefaultIssuer(ctx context.Context, claims auth.Claims, req WalletAddressDefaultIssuerRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.wallet.SetDefaultIssuer")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	// Only admins can change the issuer of the account.
+	if claims.Audience != "" && !claims.HasRole(auth.RoleAdmin) {
+		return errors.WithStack(ErrForbidden)
+	}
+
+	// Ensure the claims can modify the wallet address specified in the request.
+	err = repo.CanModifyWalletAddress(ctx, claims, req.ID)
+	if err != nil {
+		return err
+	}
+
+	m, err := repo.ReadByID(ctx, claims, req.ID)
+	if err != nil {
+		return err
+	} else if !m.IsVerified() {
+		return errors.WithMessagef(ErrNotVerified, "wallet address %s", m.ID)
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	dbTx, err := repo.DbConn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer dbTx.Rollback()
+
+	// Unset the current default issuer of the account.
+	{
+		query := sqlbuilder.NewUpdateBuilder()
+		query.Update(WalletAddressTableName)
+		query.Set(
+			query.Assign("default_issuer", false),
+			query.Assign("updated_at", now),
+		)
+		query.Where(query.And(
+			query.Equal("account_id", m.AccountID),
+			query.Equal("default_issuer", true),
+		))
+
+		sql, args := query.Build()
+		sql = dbTx.Rebind(sql)
+		_, err = dbTx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			err = errors.Wrapf(err, "query - %s", query.String())
+			err = errors.WithMessagef(err, "unset default issuer of account %s failed", m.AccountID)
+			return err
+		}
+	}
+
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(WalletAddressTableName)
+	query.Set(
+		query.Assign("default_issuer", true),
+		query.Assign("updated_at", now),
+	)
+	query.Where(query.Equal("id", m.ID))
+
+	sql, args := query.Build()
+	sql = dbTx.Rebind(sql)
+	_, err = dbTx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "set default issuer %s failed", m.ID)
+		return err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// Archive soft deleted the wallet address from the database. An archived address is no
+// longer the default issuer of its account.
+func (repo *Repository) Archive(ctx context.Context, claims auth.Claims, req WalletAddressArchiveRequest, now time.Time) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "internal.wallet.Archive")
+	defer span.Finish()
+
+	// Validate the request.
+	v := webcontext.Validator()
+	err := v.Struct(req)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the claims can modify the wallet address specified in the request.
+	err = repo.CanModifyWalletAddress(ctx, claims, req.ID)
+	if err != nil {
+		return err
+	}
+
+	// If now empty set it to the current time.
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Always store the time as UTC.
+	now = now.UTC()
+
+	// Postgres truncates times to milliseconds when storing. We and do the same
+	// here so the value we return is consistent with what we store.
+	now = now.Truncate(time.Millisecond)
+
+	// Build the update SQL statement.
+	query := sqlbuilder.NewUpdateBuilder()
+	query.Update(WalletAddressTableName)
+	query.Set(
+		query.Assign("default_issuer", false),
+		query.Assign("nonce", nil),
+		query.Assign("nonce_expires_at", nil),
+		query.Assign("archived_at", now),
+	)
+	query.Where(query.Equal("id", req.ID))
+
+	// Execute the query with the provided context.
+	sql, args := query.Build()
+	sql = repo.DbConn.Rebind(sql)
+	_, err = repo.DbConn.ExecContext(ctx, sql, args...)
+	if err != nil {
+		err = errors.Wrapf(err, "query - %s", query.String())
+		err = errors.WithMessagef(err, "archive wallet address %s failed", req.ID)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,428 @@
+package wallet
+
+import (
+	"encoding/base64"
+	"os"
+	"testing"
+	"time"
+
+	"exitor-dapp/internal/account"
+	"exitor-dapp/internal/platform/auth"
+	"exitor-dapp/internal/platform/tests"
+	"exitor-dapp/internal/platform/web/weberror"
+	"exitor-dapp/internal/user"
+
+	"github.com/algorand/go-algorand-sdk/crypto"
+	"github.com/algorand/go-algorand-sdk/types"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+var test *tests.Test
+
+// TestMain is the entry point for testing.
+func TestMain(m *testing.M) {
+	os.Exit(testMain(m))
+}
+
+func testMain(m *testing.M) int {
+	test = tests.New()
+	defer test.TearDown()
+	return m.Run()
+}
+
+// TestWalletAddress validates a user linking an address, proving they hold its key and an
+// admin setting it as the default issuer of the account.
+func TestWalletAddress(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.March, 14, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	repo := NewRepository(test.MasterDB)
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	usr, err := user.MockUser(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockUser failed.", tests.Failed)
+	}
+
+	userClaims := auth.Claims{
+		Roles: []string{auth.RoleUser},
+		StandardClaims: jwt.StandardClaims{
+			Audience: acc.ID,
+			Subject:  usr.ID,
+		},
+	}
+	adminClaims := auth.Claims{
+		Roles: []string{auth.RoleAdmin},
+		StandardClaims: jwt.StandardClaims{
+			Audience: acc.ID,
+			Subject:  usr.ID + "-admin",
+		},
+	}
+
+	wallet := crypto.GenerateAccount()
+
+	// sign returns the base64 signature of the challenge with the key.
+	sign := func(t *testing.T, wallet crypto.Account, challenge *WalletAddressChallenge) string {
+		sig, err := crypto.SignBytes(wallet.PrivateKey, []byte(challenge.Message))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSignBytes failed.", tests.Failed)
+		}
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+
+	t.Log("Given the need to link a wallet address to a user.")
+	{
+		// Swapping the first character breaks the checksum of the address.
+		badChecksum := "A" + wallet.Address.String()[1:]
+		if badChecksum == wallet.Address.String() {
+			badChecksum = "B" + wallet.Address.String()[1:]
+		}
+
+		_, err := repo.Create(ctx, userClaims, WalletAddressCreateRequest{
+			Address: badChecksum,
+		}, now)
+		if err == nil {
+			t.Fatalf("\t%s\tCreate with a bad checksum should fail.", tests.Failed)
+		} else if _, ok := weberror.NewValidationError(ctx, err); !ok {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate with a bad checksum should be a validation error.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreate with a bad checksum rejected.", tests.Success)
+
+		created, err := repo.Create(ctx, userClaims, WalletAddressCreateRequest{
+			Address: wallet.Address.String(),
+			Label:   "My Wallet",
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+		} else if created.AccountID != acc.ID || created.UserID == nil || *created.UserID != usr.ID || created.IsVerified() {
+			t.Logf("\t\tGot : %+v", created)
+			t.Fatalf("\t%s\tExpected an unverified address of the user.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreate ok.", tests.Success)
+
+		_, err = repo.Create(ctx, userClaims, WalletAddressCreateRequest{
+			Address: wallet.Address.String(),
+		}, now)
+		if _, ok := weberror.NewValidationError(ctx, err); !ok {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate of a duplicate address should be a validation error.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreate of a duplicate address rejected.", tests.Success)
+
+		otherClaims := userClaims
+		otherClaims.Subject = usr.ID + "-other"
+		if _, err := repo.ReadByID(ctx, otherClaims, created.ID); errors.Cause(err) != ErrNotFound {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrNotFound)
+			t.Fatalf("\t%s\tRead by another user should not find the address.", tests.Failed)
+		}
+		t.Logf("\t%s\tRead by another user ok.", tests.Success)
+
+		_, err = repo.Verify(ctx, userClaims, WalletAddressVerifyRequest{
+			ID:        created.ID,
+			Signature: base64.StdEncoding.EncodeToString(make([]byte, 64)),
+		}, now)
+		if errors.Cause(err) != ErrChallengeExpired {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrChallengeExpired)
+			t.Fatalf("\t%s\tVerify without a challenge should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tVerify without a challenge rejected.", tests.Success)
+
+		challenge, err := repo.Challenge(ctx, userClaims, created.ID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tChallenge failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tChallenge ok.", tests.Success)
+
+		_, err = repo.Verify(ctx, userClaims, WalletAddressVerifyRequest{
+			ID:        created.ID,
+			Signature: sign(t, crypto.GenerateAccount(), challenge),
+		}, now)
+		if errors.Cause(err) != auth.ErrInvalidAlgorandSignature {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", auth.ErrInvalidAlgorandSignature)
+			t.Fatalf("\t%s\tVerify signed by another key should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tVerify signed by another key rejected.", tests.Success)
+
+		_, err = repo.Verify(ctx, userClaims, WalletAddressVerifyRequest{
+			ID:        created.ID,
+			Signature: sign(t, wallet, challenge),
+		}, challenge.ExpiresAt)
+		if errors.Cause(err) != ErrChallengeExpired {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrChallengeExpired)
+			t.Fatalf("\t%s\tVerify after the challenge expired should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tVerify after the challenge expired rejected.", tests.Success)
+
+		verified, err := repo.Verify(ctx, userClaims, WalletAddressVerifyRequest{
+			ID:        created.ID,
+			Signature: sign(t, wallet, challenge),
+		}, now.Add(time.Minute))
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tVerify failed.", tests.Failed)
+		} else if !verified.IsVerified() {
+			t.Fatalf("\t%s\tExpected the address to be verified.", tests.Failed)
+		}
+		t.Logf("\t%s\tVerify ok.", tests.Success)
+
+		_, err = repo.Verify(ctx, userClaims, WalletAddressVerifyRequest{
+			ID:        created.ID,
+			Signature: sign(t, wallet, challenge),
+		}, now.Add(time.Minute))
+		if errors.Cause(err) != ErrChallengeExpired {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrChallengeExpired)
+			t.Fatalf("\t%s\tVerify with a used challenge should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tVerify with a used challenge rejected.", tests.Success)
+
+		err = repo.SetDefaultIssuer(ctx, userClaims, WalletAddressDefaultIssuerRequest{ID: created.ID}, now)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tSetDefaultIssuer by a user should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tSetDefaultIssuer by a user rejected.", tests.Success)
+
+		err = repo.SetDefaultIssuer(ctx, adminClaims, WalletAddressDefaultIssuerRequest{ID: created.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSetDefaultIssuer failed.", tests.Failed)
+		}
+		t.Logf("\t%s\tSetDefaultIssuer ok.", tests.Success)
+	}
+
+	t.Log("Given the need to replace the default issuer of an account.")
+	{
+		treasury := crypto.GenerateAccount()
+
+		created, err := repo.Create(ctx, adminClaims, WalletAddressCreateRequest{
+			Address: treasury.Address.String(),
+			Label:   "Treasury",
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreate failed.", tests.Failed)
+		} else if created.UserID != nil {
+			t.Logf("\t\tGot : %s", *created.UserID)
+			t.Fatalf("\t%s\tExpected the address to belong to the account.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreate for the account ok.", tests.Success)
+
+		err = repo.SetDefaultIssuer(ctx, adminClaims, WalletAddressDefaultIssuerRequest{ID: created.ID}, now)
+		if errors.Cause(err) != ErrNotVerified {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrNotVerified)
+			t.Fatalf("\t%s\tSetDefaultIssuer of an unverified address should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tSetDefaultIssuer of an unverified address rejected.", tests.Success)
+
+		challenge, err := repo.Challenge(ctx, adminClaims, created.ID, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tChallenge failed.", tests.Failed)
+		}
+
+		_, err = repo.Verify(ctx, adminClaims, WalletAddressVerifyRequest{
+			ID:        created.ID,
+			Signature: sign(t, treasury, challenge),
+		}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tVerify failed.", tests.Failed)
+		}
+
+		err = repo.SetDefaultIssuer(ctx, adminClaims, WalletAddressDefaultIssuerRequest{ID: created.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tSetDefaultIssuer failed.", tests.Failed)
+		}
+
+		issuer, err := repo.DefaultIssuer(ctx, adminClaims, acc.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tDefaultIssuer failed.", tests.Failed)
+		} else if issuer.Address != treasury.Address.String() {
+			t.Logf("\t\tGot : %s", issuer.Address)
+			t.Logf("\t\tWant: %s", treasury.Address.String())
+			t.Fatalf("\t%s\tExpected the treasury to replace the default issuer.", tests.Failed)
+		}
+		t.Logf("\t%s\tDefaultIssuer ok.", tests.Success)
+
+		err = repo.Archive(ctx, adminClaims, WalletAddressArchiveRequest{ID: created.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tArchive failed.", tests.Failed)
+		}
+
+		if _, err := repo.DefaultIssuer(ctx, adminClaims, acc.ID); errors.Cause(err) != ErrNotFound {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrNotFound)
+			t.Fatalf("\t%s\tExpected no default issuer after archive.", tests.Failed)
+		}
+		t.Logf("\t%s\tArchive ok.", tests.Success)
+	}
+}
+
+// TestMultisig validates defining a multisig account for an account and deriving its address.
+func TestMultisig(t *testing.T) {
+	defer tests.Recover(t)
+
+	now := time.Date(2020, time.May, 9, 10, 0, 0, 0, time.UTC)
+
+	ctx := tests.Context()
+
+	repo := NewRepository(test.MasterDB)
+
+	acc, err := account.MockAccount(ctx, test.MasterDB, now)
+	if err != nil {
+		t.Log("\t\tGot :", err)
+		t.Fatalf("\t%s\tMockAccount failed.", tests.Failed)
+	}
+
+	userClaims := auth.Claims{
+		Roles: []string{auth.RoleUser},
+		StandardClaims: jwt.StandardClaims{
+			Audience: acc.ID,
+			Subject:  uuid.NewRandom().String(),
+		},
+	}
+	adminClaims := auth.Claims{
+		Roles: []string{auth.RoleAdmin},
+		StandardClaims: jwt.StandardClaims{
+			Audience: acc.ID,
+			Subject:  uuid.NewRandom().String(),
+		},
+	}
+
+	var (
+		members []string
+		addrs   []types.Address
+	)
+	for i := 0; i < 3; i++ {
+		director := crypto.GenerateAccount()
+		members = append(members, director.Address.String())
+		addrs = append(addrs, director.Address)
+	}
+
+	t.Log("Given the need for the directors of a company to hold the roles of its assets.")
+	{
+		req := WalletMultisigCreateRequest{
+			Label:     "Board of Directors",
+			Threshold: 2,
+			Members:   members,
+		}
+
+		_, err = repo.CreateMultisig(ctx, userClaims, req, now)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tCreateMultisig by a user should be forbidden.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreateMultisig by a user forbidden ok.", tests.Success)
+
+		invalid := []WalletMultisigCreateRequest{
+			{Threshold: 4, Members: members},
+			{Threshold: 2, Members: []string{members[0], members[1], members[0]}},
+		}
+		for _, ir := range invalid {
+			_, err = repo.CreateMultisig(ctx, adminClaims, ir, now)
+			if errors.Cause(err) != ErrInvalidMultisig {
+				t.Logf("\t\tGot : %+v", err)
+				t.Logf("\t\tWant: %+v", ErrInvalidMultisig)
+				t.Fatalf("\t%s\tCreateMultisig with threshold %d of %v should fail.", tests.Failed, ir.Threshold, ir.Members)
+			}
+		}
+		t.Logf("\t%s\tCreateMultisig invalid rejected ok.", tests.Success)
+
+		ms, err := repo.CreateMultisig(ctx, adminClaims, req, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tCreateMultisig failed.", tests.Failed)
+		}
+
+		ma, err := crypto.MultisigAccountWithParams(MultisigVersion, 2, addrs)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tMultisigAccountWithParams failed.", tests.Failed)
+		}
+		expected, err := ma.Address()
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tAddress failed.", tests.Failed)
+		} else if ms.Address != expected.String() || ms.AccountID != acc.ID {
+			t.Logf("\t\tGot : %s", ms.Address)
+			t.Logf("\t\tWant: %s", expected.String())
+			t.Fatalf("\t%s\tExpected the address to be derived from the members.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreateMultisig ok.", tests.Success)
+
+		_, err = repo.CreateMultisig(ctx, adminClaims, req, now)
+		if errors.Cause(err) != ErrMultisigExists {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrMultisigExists)
+			t.Fatalf("\t%s\tCreateMultisig twice should fail.", tests.Failed)
+		}
+		t.Logf("\t%s\tCreateMultisig twice rejected ok.", tests.Success)
+
+		// Every user of the account can see the multisig accounts they might sign for.
+		found, err := repo.FindMultisigs(ctx, userClaims, acc.ID)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tFindMultisigs failed.", tests.Failed)
+		} else if len(found) != 1 || found[0].ID != ms.ID || len(found[0].Members) != 3 || found[0].Members[2] != members[2] {
+			t.Logf("\t\tGot : %d", len(found))
+			t.Fatalf("\t%s\tExpected the multisig with its members in order.", tests.Failed)
+		}
+
+		byAddress, err := FindMultisigByAddress(ctx, test.MasterDB, acc.ID, ms.Address)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tFindMultisigByAddress failed.", tests.Failed)
+		} else if byAddress.ID != ms.ID {
+			t.Logf("\t\tGot : %s", byAddress.ID)
+			t.Logf("\t\tWant: %s", ms.ID)
+			t.Fatalf("\t%s\tExpected the multisig for the address.", tests.Failed)
+		}
+		t.Logf("\t%s\tFindMultisigs ok.", tests.Success)
+
+		err = repo.ArchiveMultisig(ctx, userClaims, WalletMultisigArchiveRequest{ID: ms.ID}, now)
+		if errors.Cause(err) != ErrForbidden {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrForbidden)
+			t.Fatalf("\t%s\tArchiveMultisig by a user should be forbidden.", tests.Failed)
+		}
+
+		err = repo.ArchiveMultisig(ctx, adminClaims, WalletMultisigArchiveRequest{ID: ms.ID}, now)
+		if err != nil {
+			t.Log("\t\tGot :", err)
+			t.Fatalf("\t%s\tArchiveMultisig failed.", tests.Failed)
+		}
+
+		if _, err := FindMultisigByAddress(ctx, test.MasterDB, acc.ID, ms.Address); errors.Cause(err) != ErrNotFound {
+			t.Logf("\t\tGot : %+v", err)
+			t.Logf("\t\tWant: %+v", ErrNotFound)
+			t.Fatalf("\t%s\tExpected no multisig after archive.", tests.Failed)
+		}
+		t.Logf("\t%s\tArchiveMultisig ok.", tests.Success)
+	}
+}
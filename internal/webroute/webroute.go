@@ -51,6 +51,12 @@ func (r WebRoute) UserResetPassword(resetHash string) string {
 	return u.String()
 }
 
+func (r WebRoute) UserUnlock(unlockHash string) string {
+	u := r.webAppUrl
+	u.Path = "/user/unlock/" + unlockHash
+	return u.String()
+}
+
 func (r WebRoute) UserInviteAccept(inviteHash string) string {
 	u := r.webAppUrl
 	u.Path = "/users/invite/" + inviteHash